@@ -11,12 +11,26 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/cache"
 	"io.winapps.journeyapp/internal/db"
+	"io.winapps.journeyapp/internal/encryption"
 	firebaseutil "io.winapps.journeyapp/internal/firebase"
 	"io.winapps.journeyapp/internal/handlers"
+	"io.winapps.journeyapp/internal/metrics"
 	"io.winapps.journeyapp/internal/middleware"
+	"io.winapps.journeyapp/internal/moderation"
+	"io.winapps.journeyapp/internal/storage"
+	"io.winapps.journeyapp/internal/subscriptions"
+	"io.winapps.journeyapp/internal/webhooks"
 )
 
+// memoryCacheSize caps the number of evictable keys held by the in-memory cache fallback
+const memoryCacheSize = 10000
+
+// version is set at build time with -ldflags "-X main.version=<version>"
+var version string
+
 func main() {
     // Initialize zap logger (production)
     baseLogger, err := zap.NewProduction()
@@ -43,65 +57,165 @@ func main() {
 	}
 
 	// Initialize PostgreSQL
-	postgresDB, err := db.InitPostgres()
+	postgresDB, err := db.InitPostgres(logger)
 	if err != nil {
 		logger.Fatalf("Failed to initialize PostgreSQL: %v", err)
 	}
 	defer postgresDB.Close()
 
-	// Initialize Redis
+	// Apply pending schema migrations
+	if err := db.Migrate(context.Background(), postgresDB); err != nil {
+		logger.Fatalf("Failed to run database migrations: %v", err)
+	}
+
+	metrics.RegisterDBPoolCollector(postgresDB)
+
+	// Initialize Redis, falling back to an in-memory cache so a single instance still
+	// works (e.g. in development) when Redis is unavailable
+	var cacheStore cache.Store
 	redisClient, err := db.InitRedis()
 	if err != nil {
-		logger.Fatalf("Failed to initialize Redis: %v", err)
+		logger.Warnw("Redis unavailable; using in-memory cache (sessions and export status are not shared between instances)", "error", err)
+		cacheStore = cache.NewMemory(memoryCacheSize, middleware.DurableKeyPrefixes...)
+	} else {
+		cacheStore = cache.NewRedis(redisClient)
 	}
-	defer redisClient.Close()
+	defer cacheStore.Close()
+
+	metrics.RegisterCacheCollector(cacheStore)
 
 	// Initialize Gin router
 	router := gin.New()
+	// Multipart uploads beyond this spill to temp files instead of being held in memory
+	router.MaxMultipartMemory = 8 << 20
 	router.Use(middleware.RequestIDMiddleware())
 	router.Use(middleware.RecoveryMiddleware(logger))
 	router.Use(middleware.RequestLoggingMiddleware(logger))
 
-	// Add CORS middleware for mobile app
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+	// Allow browser clients from configured origins only
+	router.Use(middleware.CORSMiddleware(middleware.CORSConfigFromEnv()))
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-
-		c.Next()
-	})
+	// Record Prometheus HTTP metrics
+	router.Use(middleware.MetricsMiddleware())
 
 	// Initialize handlers with logger
-	authHandler := handlers.NewAuthHandler(firebaseApp, postgresDB, redisClient, logger)
-	entryHandler := handlers.NewEntryHandler(firebaseApp, postgresDB, redisClient, logger)
-	usersHandler := handlers.NewUsersHandler(firebaseApp, postgresDB, redisClient, logger)
+	authHandler := handlers.NewAuthHandler(firebaseApp, postgresDB, cacheStore, logger)
+	entryHandler := handlers.NewEntryHandler(firebaseApp, postgresDB, cacheStore, logger)
+	usersHandler := handlers.NewUsersHandler(firebaseApp, postgresDB, cacheStore, logger)
+	notificationsHandler := handlers.NewNotificationsHandler(firebaseApp, postgresDB, cacheStore, logger)
+	entryHandler.SetNotificationsHandler(notificationsHandler)
+	usersHandler.SetNotificationsHandler(notificationsHandler)
+	webhookDispatcher := webhooks.NewDispatcher(postgresDB, cacheStore, logger)
+	entryHandler.SetWebhookDispatcher(webhookDispatcher)
+	usersHandler.SetWebhookDispatcher(webhookDispatcher)
+	webhooksHandler := handlers.NewWebhooksHandler(postgresDB, cacheStore, logger)
+
+	// Media storage; the default local backend only works with a single instance
+	mediaStore, err := storage.NewFromEnv()
+	if err != nil {
+		logger.Fatalf("Failed to configure media storage: %v", err)
+	}
+	logger.Infow("media storage configured", "backend", mediaStore.Backend())
+	authHandler.SetMediaStore(mediaStore)
+	entryHandler.SetMediaStore(mediaStore)
+
+	// Entry encryption at rest; users can only turn it on when ENTRY_ENCRYPTION_SECRET is set
+	entryKeys, err := encryption.NewFromEnv()
+	if err != nil {
+		logger.Fatalf("Failed to configure entry encryption: %v", err)
+	}
+	if entryKeys == nil {
+		logger.Infow("entry encryption disabled: ENTRY_ENCRYPTION_SECRET is not set")
+	}
+	authHandler.SetEntryKeyring(entryKeys)
+	entryHandler.SetEntryKeyring(entryKeys)
+	usersHandler.SetEntryKeyring(entryKeys)
+
+	// Optional content filter for public and semi-private entries
+	moderator, err := moderation.NewFromEnv()
+	if err != nil {
+		logger.Fatalf("Failed to configure content filter: %v", err)
+	}
+	if moderator != nil {
+		logger.Infow("content filter enabled", "mode", moderator.Mode)
+	}
+	entryHandler.SetContentModerator(moderator)
+
+	// Store receipt verification; without it VerifySubscription returns 503 and the
+	// expiry job clears lapsed subscriptions without re-validating them
+	if verifier, err := subscriptions.NewFromEnv(context.Background()); err != nil {
+		logger.Warnw("subscription verification disabled", "error", err)
+	} else {
+		authHandler.SetSubscriptionVerifier(verifier)
+	}
+	if err := authHandler.StartSubscriptionExpiry(); err != nil {
+		logger.Fatalf("Failed to schedule subscription expiry: %v", err)
+	}
+
+	// Cap request bodies on routes that accept base64 media
+	mediaBodyLimit := middleware.MaxBodySize(middleware.MediaBodyLimitFromEnv())
+
+	// Lets clients retry creates safely with an Idempotency-Key header
+	idempotent := middleware.Idempotency(cacheStore)
+
+	// Actions that reach other users need a verified email; entry visibility is checked
+	// by the entry handlers
+	requireVerifiedEmail := middleware.RequireVerifiedEmail(postgresDB)
 
 	// Define routes
 	v1 := router.Group("/api/v1")
+	// Compress JSON responses of 1KB or more; media routes are outside this group
+	v1.Use(middleware.GzipMiddleware(1024))
+	// Only the JSON API gets a deadline. Media serving routes live outside this group,
+	// and uploads, imports, exports and bulk media copies are exempt so ffmpeg/cwebp
+	// and large transfers are not cut off mid-request
+	v1.Use(middleware.TimeoutMiddleware(30*time.Second,
+		"/api/v1/auth/update-account",
+		"/api/v1/auth/add-profile-pic",
+		"/api/v1/auth/delete-account",
+		"/api/v1/auth/export-data",
+		"/api/v1/auth/download-exported-data",
+		"/api/v1/auth/import-data",
+		"/api/v1/entries/duplicate-entry",
+		"/api/v1/entries/add-image",
+		"/api/v1/entries/add-audio",
+		"/api/v1/entries/add-video",
+		"/api/v1/entries/add-attachment",
+		"/api/v1/entries/confirm-upload",
+		"/api/v1/admin/sweep-media",
+	))
 	{
 		auth := v1.Group("/auth")
 		{
+			auth.POST("/login", authHandler.Login)
 			auth.POST("/create-account", authHandler.CreateAccount)
-			auth.PUT("/update-account", middleware.AuthMiddleware(firebaseApp, postgresDB, redisClient), authHandler.UpdateAccount)
-			auth.POST("/validate-display-name", authHandler.ValidateDisplayName)
-			auth.POST("/delete-account", middleware.AuthMiddleware(firebaseApp, postgresDB, redisClient), authHandler.DeleteAccount)
-			auth.POST("/update-settings", middleware.AuthMiddleware(firebaseApp, postgresDB, redisClient), authHandler.UpdateSettings)
-			auth.GET("/get-account-details", middleware.AuthMiddleware(firebaseApp, postgresDB, redisClient), authHandler.GetAccountDetails)
-			auth.POST("/add-profile-pic", middleware.AuthMiddleware(firebaseApp, postgresDB, redisClient), authHandler.AddProfilePic)
-			auth.POST("/export-data", middleware.AuthMiddleware(firebaseApp, postgresDB, redisClient), authHandler.ExportData)
-			auth.GET("/export-progress", middleware.AuthMiddleware(firebaseApp, postgresDB, redisClient), authHandler.ExportProgress)
-			auth.GET("/download-exported-data", middleware.AuthMiddleware(firebaseApp, postgresDB, redisClient), authHandler.DownloadExportedData)
+			auth.POST("/billing-webhook", authHandler.BillingWebhook)
+			auth.POST("/verify-subscription", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.VerifySubscription)
+			auth.GET("/entitlements", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.GetEntitlements)
+			auth.POST("/refresh-token", authHandler.RefreshToken)
+			auth.POST("/logout", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.Logout)
+			auth.POST("/send-email-verification", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.SendEmailVerification)
+			auth.POST("/request-email-change", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.RequestEmailChange)
+			auth.POST("/confirm-email-change", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.ConfirmEmailChange)
+			auth.GET("/sessions", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.ListSessions)
+			auth.POST("/revoke-all-sessions", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.RevokeAllSessions)
+			auth.PUT("/update-account", mediaBodyLimit, middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.UpdateAccount)
+			auth.POST("/validate-display-name", middleware.RateLimit(cacheStore, "validate_display_name", 30, time.Minute), authHandler.ValidateDisplayName)
+			auth.POST("/delete-account", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.DeleteAccount)
+			auth.POST("/update-settings", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.UpdateSettings)
+			auth.POST("/entry-encryption", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.SetEntryEncryption)
+			auth.GET("/get-account-details", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.GetAccountDetails)
+			auth.POST("/add-profile-pic", mediaBodyLimit, middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.AddProfilePic)
+			auth.POST("/export-data", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.ExportData)
+			auth.GET("/export-progress", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.ExportProgress)
+			auth.GET("/download-exported-data", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.DownloadExportedData)
+			auth.POST("/import-data", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.ImportData)
+			auth.GET("/import-progress", middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), authHandler.ImportProgress)
 		}
 
 		// Notifications routes
-		notificationsHandler := handlers.NewNotificationsHandler(firebaseApp, postgresDB, redisClient, logger)
 		notifications := v1.Group("/notifications")
-		notifications.Use(middleware.AuthMiddleware(firebaseApp, postgresDB, redisClient))
+		notifications.Use(middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore))
 		{
 			notifications.POST("/register-for-notifications", notificationsHandler.RegisterPushToken)
 			notifications.POST("/stream-chat-webhook", notificationsHandler.HandleStreamChatWebhook)
@@ -110,39 +224,99 @@ func main() {
 
 		// Protected entries routes
 		entries := v1.Group("/entries")
-		entries.Use(middleware.AuthMiddleware(firebaseApp, postgresDB, redisClient))
+		entries.Use(middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore))
 		{
-			entries.POST("/create-entry", entryHandler.CreateEntry)
+			entries.POST("/create-entry", idempotent, entryHandler.CreateEntry)
+			entries.POST("/batch-create", idempotent, entryHandler.BatchCreateEntries)
+			entries.POST("/save-draft", idempotent, entryHandler.SaveDraft)
+			entries.POST("/publish-entry", entryHandler.PublishEntry)
 			entries.POST("/get-entry", entryHandler.GetEntry)
+			entries.POST("/get-shared-entry", entryHandler.GetFriendEntry)
+			entries.POST("/get-entries", entryHandler.GetEntries)
+			entries.POST("/duplicate-entry", idempotent, entryHandler.DuplicateEntry)
 			entries.POST("/search-entries", entryHandler.SearchEntries)
-			entries.POST("/add-tag", entryHandler.AddTag)
+			entries.GET("/list", entryHandler.ListEntries)
+			entries.GET("/discover", entryHandler.DiscoverEntries)
+			entries.GET("/activity", entryHandler.GetActivityHeatmap)
+			entries.GET("/tag-distribution", entryHandler.GetTagDistribution)
+			entries.GET("/streak", entryHandler.GetStreak)
+			entries.POST("/create-public-link", requireVerifiedEmail, idempotent, entryHandler.CreatePublicLink)
+			entries.POST("/revoke-public-link", entryHandler.RevokePublicLink)
+			entries.POST("/add-tag", idempotent, entryHandler.AddTag)
 			entries.POST("/update-tag", entryHandler.UpdateTag)
 			entries.POST("/remove-tag", entryHandler.RemoveTag)
-			entries.POST("/add-location", entryHandler.AddLocation)
+			entries.POST("/bulk-add-tag", entryHandler.BulkAddTag)
+			entries.POST("/bulk-remove-tag", entryHandler.BulkRemoveTag)
+			entries.POST("/add-location", idempotent, entryHandler.AddLocation)
 			entries.POST("/update-location", entryHandler.UpdateLocation)
 			entries.POST("/remove-location", entryHandler.RemoveLocation)
-			entries.POST("/add-image", entryHandler.AddImage)
+			entries.POST("/merge-locations", entryHandler.MergeLocations)
+			entries.POST("/add-image", mediaBodyLimit, idempotent, entryHandler.AddImage)
 			entries.POST("/remove-image", entryHandler.RemoveImage)
-			entries.POST("/add-audio", entryHandler.AddAudio)
+			entries.POST("/add-audio", mediaBodyLimit, idempotent, entryHandler.AddAudio)
 			entries.POST("/remove-audio", entryHandler.RemoveAudio)
+			entries.POST("/undo-removal", entryHandler.UndoRemoval)
+			entries.POST("/add-video", mediaBodyLimit, idempotent, entryHandler.AddVideo)
+			entries.POST("/remove-video", entryHandler.RemoveVideo)
+			entries.POST("/add-attachment", mediaBodyLimit, idempotent, entryHandler.AddAttachment)
+			entries.POST("/remove-attachment", entryHandler.RemoveAttachment)
+			entries.POST("/request-upload-url", entryHandler.RequestUploadURL)
+			entries.POST("/confirm-upload", entryHandler.ConfirmUpload)
 			entries.POST("/get-unique-tags", entryHandler.GetUniqueTags)
+			entries.POST("/normalize-tags", entryHandler.NormalizeTags)
+			entries.POST("/get-tag-values", entryHandler.GetTagValues)
 			entries.POST("/get-unique-locations", entryHandler.GetUniqueLocations)
+			entries.POST("/get-location-clusters", entryHandler.GetLocationClusters)
 			entries.POST("/update-entry", entryHandler.UpdateEntry)
 			entries.DELETE("/delete-entry", entryHandler.DeleteEntry)
+			entries.POST("/add-comment", requireVerifiedEmail, idempotent, entryHandler.AddComment)
+			entries.POST("/get-comments", entryHandler.GetComments)
+			entries.DELETE("/delete-comment", entryHandler.DeleteComment)
+			entries.POST("/report-entry", entryHandler.ReportEntry)
 		}
 
 		// Protected users routes
 		users := v1.Group("/users")
-		users.Use(middleware.AuthMiddleware(firebaseApp, postgresDB, redisClient))
+		users.Use(middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore))
 		{
 			users.GET("/get-user-details", usersHandler.GetUserDetails)
 			users.GET("/search-users", usersHandler.SearchUsers)
 			users.GET("/list-friends", usersHandler.ListFriends)
-			users.POST("/add-friend", usersHandler.AddFriendship)
-			users.POST("/approve-friend-request", usersHandler.ApproveFriendRequest)
+			users.GET("/mutual-friends", usersHandler.MutualFriends)
+			users.GET("/friend-requests", usersHandler.GetFriendRequests)
+			users.POST("/add-friend", requireVerifiedEmail, usersHandler.AddFriendship)
+			users.POST("/approve-friend-request", requireVerifiedEmail, usersHandler.ApproveFriendRequest)
 			users.POST("/reject-friend-request", usersHandler.RejectFriendRequest)
 			users.DELETE("/remove-friend", usersHandler.RemoveFriendship)
 			users.GET("/list-feeds", usersHandler.ListFeeds)
+			users.POST("/block-user", usersHandler.BlockUser)
+			users.POST("/unblock-user", usersHandler.UnblockUser)
+		}
+
+		// Protected webhook routes
+		hooks := v1.Group("/webhooks")
+		hooks.Use(middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore))
+		{
+			hooks.GET("/list", webhooksHandler.ListWebhooks)
+			hooks.POST("/create-webhook", webhooksHandler.CreateWebhook)
+			hooks.POST("/update-webhook", webhooksHandler.UpdateWebhook)
+			hooks.DELETE("/delete-webhook", webhooksHandler.DeleteWebhook)
+		}
+
+		// Moderation routes, for signed-in users flagged is_admin
+		moderation := v1.Group("/moderation")
+		moderation.Use(middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore), middleware.RequireAdmin(postgresDB))
+		{
+			moderation.GET("/reports", entryHandler.ListReports)
+			moderation.POST("/resolve-report", entryHandler.ResolveReport)
+			moderation.POST("/broadcast-notification", notificationsHandler.BroadcastNotification)
+		}
+
+		// Maintenance routes, authenticated with ADMIN_API_TOKEN rather than a user session
+		admin := v1.Group("/admin")
+		admin.Use(middleware.AdminToken())
+		{
+			admin.POST("/sweep-media", entryHandler.SweepOrphanedMedia)
 		}
 	}
 
@@ -150,26 +324,69 @@ func main() {
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
+	healthHandler := handlers.NewHealthHandler(firebaseApp, postgresDB, cacheStore, version)
+	router.GET("/health/live", healthHandler.Live)
+	router.GET("/health/ready", healthHandler.Ready)
+	router.GET("/ready", healthHandler.Ready)
 
-	// Serve static image files
-	router.Static("/images", "./internal/images")
+	// Serve media. Entry images, audio and video require a session that can view the entry;
+	// profile pictures stay public because their absolute URLs are shared with other clients.
+	router.GET("/images/:uid/profile/:file", entryHandler.ServeProfileImage)
+	// Public share links need no session; the token is the credential
+	router.GET("/shared/:token", entryHandler.GetSharedEntry)
+	router.GET("/shared/:token/images/:file", entryHandler.ServeSharedImage)
+	router.GET("/shared/:token/audio/:file", entryHandler.ServeSharedAudio)
+	router.GET("/shared/:token/videos/:file", entryHandler.ServeSharedVideo)
+	media := router.Group("/")
+	media.Use(middleware.AuthMiddleware(firebaseApp, postgresDB, cacheStore))
+	{
+		media.GET("/images/:uid/:entryId/:file", entryHandler.ServeImage)
+		media.GET("/audio/:uid/:entryId/:file", entryHandler.ServeAudio)
+		media.GET("/videos/:uid/:entryId/:file", entryHandler.ServeVideo)
+		media.GET("/attachments/:uid/:entryId/:file", entryHandler.ServeAttachment)
+	}
 
-	// Serve static audio files
-	router.Static("/audio", "./internal/audio")
+	// Unknown paths get the error envelope too, rather than gin's plain-text 404
+	router.NoRoute(func(c *gin.Context) {
+		apierror.Respond(c, http.StatusNotFound, apierror.CodeNotFound, "Route not found")
+	})
 
 	// Create HTTP server
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "9091"
+	}
 	srv := &http.Server{
-		Addr:    ":9091",
+		Addr:    ":" + port,
 		Handler: router,
 	}
 
-	// Start server in a goroutine
+	// Prometheus metrics get their own listener, bound to loopback unless METRICS_ADDR
+	// says otherwise, so route and pool details aren't served on the public port
+	metricsAddr := os.Getenv("METRICS_ADDR")
+	if metricsAddr == "" {
+		metricsAddr = "127.0.0.1:9464"
+	}
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", metrics.Handler())
+	metricsSrv := &http.Server{
+		Addr:    metricsAddr,
+		Handler: metricsMux,
+	}
+
+	// Start servers in goroutines
 	go func() {
-		logger.Infow("server starting", "addr", ":9091")
+		logger.Infow("server starting", "addr", srv.Addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logger.Fatalf("Failed to start server: %v", err)
 		}
 	}()
+	go func() {
+		logger.Infow("metrics server starting", "addr", metricsSrv.Addr)
+		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatalf("Failed to start metrics server: %v", err)
+		}
+	}()
 
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
@@ -182,7 +399,24 @@ func main() {
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatalf("Server forced to shutdown: %v", err)
+		logger.Errorw("server forced to shutdown", "error", err)
+	}
+	if err := metricsSrv.Shutdown(ctx); err != nil {
+		logger.Errorw("metrics server forced to shutdown", "error", err)
+	}
+
+	// Drain background work before the deferred DB/Redis closes run
+	bgCtx, bgCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer bgCancel()
+
+	if err := notificationsHandler.Shutdown(bgCtx); err != nil {
+		logger.Errorw("failed to stop cron jobs", "error", err)
+	}
+	if err := authHandler.Shutdown(bgCtx); err != nil {
+		logger.Errorw("failed to stop background auth jobs", "error", err)
+	}
+	if err := webhookDispatcher.Shutdown(bgCtx); err != nil {
+		logger.Errorw("failed to drain webhook deliveries", "error", err)
 	}
 
 	logger.Info("Server exited")
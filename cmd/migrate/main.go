@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/joho/godotenv"
+	"io.winapps.journeyapp/internal/db"
+)
+
+// migrate applies, rolls back or lists the embedded schema migrations.
+//
+//	go run ./cmd/migrate up
+//	go run ./cmd/migrate down [steps]
+//	go run ./cmd/migrate status
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: migrate up | down [steps] | status")
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		_ = godotenv.Load(".env", "../.env", "../../.env", "cmd/api/.env")
+	}
+
+	pool, err := db.InitPostgres(nil)
+	if err != nil {
+		log.Fatalf("Failed to initialize PostgreSQL: %v", err)
+	}
+	defer pool.Close()
+
+	ctx := context.Background()
+	switch flag.Arg(0) {
+	case "up":
+		if err := db.Migrate(ctx, pool); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		fmt.Println("Migrations applied")
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			steps, err = strconv.Atoi(flag.Arg(1))
+			if err != nil || steps <= 0 {
+				log.Fatalf("steps must be a positive integer")
+			}
+		}
+		if err := db.Rollback(ctx, pool, steps); err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+		fmt.Printf("Rolled back %d migration(s)\n", steps)
+	case "status":
+		statuses, err := db.Status(ctx, pool)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, state)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,78 @@
+package apierror
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Stable, machine-readable error codes. Clients switch on these rather than on messages,
+// so existing values must not be renamed.
+const (
+	CodeValidation      = "VALIDATION"
+	CodeUnauthorized    = "UNAUTHORIZED"
+	CodeForbidden       = "FORBIDDEN"
+	CodeNotFound        = "NOT_FOUND"
+	CodeConflict        = "CONFLICT"
+	CodeRateLimited     = "RATE_LIMITED"
+	CodeInternal        = "INTERNAL"
+	CodeUnavailable     = "UNAVAILABLE"
+	CodeTimeout         = "TIMEOUT"
+	CodePayloadTooLarge = "PAYLOAD_TOO_LARGE"
+
+	// CodeUnsupportedMedia is returned with 415 when uploaded bytes aren't an allowed format
+	CodeUnsupportedMedia = "UNSUPPORTED_MEDIA_TYPE"
+
+	// Authentication specifics so clients know whether to refresh or sign in again
+	CodeTokenExpired = "TOKEN_EXPIRED"
+	CodeTokenInvalid = "TOKEN_INVALID"
+	CodeTokenRevoked = "TOKEN_REVOKED"
+
+	CodeEmailNotVerified = "EMAIL_NOT_VERIFIED"
+
+	// CodePlanLimit is returned with 402/403 when the user's tier doesn't allow the action;
+	// details carries the premium.Denial
+	CodePlanLimit = "PLAN_LIMIT"
+
+	// CodeContentRejected is returned with 400 when the content filter refuses text in a
+	// shared entry; details names the field and the flagged terms
+	CodeContentRejected = "CONTENT_REJECTED"
+
+	// CodeIdempotencyMismatch is returned with 422 when an Idempotency-Key is reused with
+	// a different request body
+	CodeIdempotencyMismatch = "IDEMPOTENCY_KEY_REUSED"
+)
+
+// Body is the error object returned to clients
+type Body struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty"`
+	// Details is optional structured context for codes that need it, such as PLAN_LIMIT
+	Details interface{} `json:"details,omitempty"`
+}
+
+// Response is the error envelope: {"error": {"code": ..., "message": ..., "requestId": ...}}
+type Response struct {
+	Error Body `json:"error"`
+}
+
+// New builds an error envelope carrying the request_id set by RequestIDMiddleware
+func New(c *gin.Context, code, message string) Response {
+	return Response{Error: Body{Code: code, Message: message, RequestID: c.GetString("request_id")}}
+}
+
+// Respond writes an error envelope with the given HTTP status
+func Respond(c *gin.Context, status int, code, message string) {
+	c.JSON(status, New(c, code, message))
+}
+
+// RespondWithDetails writes an error envelope that includes structured details
+func RespondWithDetails(c *gin.Context, status int, code, message string, details interface{}) {
+	resp := New(c, code, message)
+	resp.Error.Details = details
+	c.JSON(status, resp)
+}
+
+// Abort writes an error envelope and stops the handler chain
+func Abort(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, New(c, code, message))
+}
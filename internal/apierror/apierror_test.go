@@ -0,0 +1,59 @@
+package apierror
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRespond(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		respond func(c *gin.Context)
+		status  int
+		want    string
+	}{
+		{
+			name:    "envelope with request id",
+			respond: func(c *gin.Context) { Respond(c, http.StatusNotFound, CodeNotFound, "Entry not found") },
+			status:  http.StatusNotFound,
+			want:    `{"error":{"code":"NOT_FOUND","message":"Entry not found","requestId":"req-1"}}`,
+		},
+		{
+			name: "details",
+			respond: func(c *gin.Context) {
+				RespondWithDetails(c, http.StatusBadRequest, CodeValidation, "title is too long", gin.H{"field": "title"})
+			},
+			status: http.StatusBadRequest,
+			want:   `{"error":{"code":"VALIDATION","message":"title is too long","requestId":"req-1","details":{"field":"title"}}}`,
+		},
+		{
+			name:    "abort",
+			respond: func(c *gin.Context) { Abort(c, http.StatusUnauthorized, CodeTokenExpired, "Token expired") },
+			status:  http.StatusUnauthorized,
+			want:    `{"error":{"code":"TOKEN_EXPIRED","message":"Token expired","requestId":"req-1"}}`,
+		},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("request_id", "req-1")
+		tt.respond(c)
+		if w.Code != tt.status || w.Body.String() != tt.want {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, w.Code, w.Body.String(), tt.status, tt.want)
+		}
+	}
+}
+
+func TestRespondOmitsMissingRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	Respond(c, http.StatusInternalServerError, CodeInternal, "Failed")
+	if want := `{"error":{"code":"INTERNAL","message":"Failed"}}`; w.Body.String() != want {
+		t.Errorf("got %s, want %s", w.Body.String(), want)
+	}
+}
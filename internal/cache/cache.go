@@ -0,0 +1,39 @@
+// Package cache abstracts the key/value store used for caching, sessions and
+// short-lived job state. Redis is used when available; an in-memory LRU keeps a
+// single instance working (e.g. in development) when it isn't.
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss is returned by Get when the key does not exist or has expired
+var ErrMiss = errors.New("cache: key not found")
+
+// Store is the subset of Redis behaviour the server relies on. Values passed to
+// Set/SetNX may be strings, byte slices or numbers and are stored as strings.
+type Store interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	// SetNX sets key only if it does not already exist and reports whether it did
+	SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
+	Del(ctx context.Context, keys ...string) error
+	// Incr increments an integer counter and returns the new value. A counter created by
+	// the call expires after ttl; incrementing an existing counter leaves its expiry alone.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+	// Keys returns keys matching a glob pattern (Redis MATCH syntax)
+	Keys(ctx context.Context, pattern string) ([]string, error)
+
+	SAdd(ctx context.Context, key string, members ...string) error
+	SRem(ctx context.Context, key string, members ...string) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+
+	Ping(ctx context.Context) error
+	Close() error
+	// Backend names the implementation ("redis" or "memory")
+	Backend() string
+}
@@ -0,0 +1,314 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryStore is a process-local LRU with per-key expiry. It is a fallback for
+// running without Redis: state is lost on restart and not shared between instances.
+// Keys under a durable prefix are kept outside the LRU and only go away on expiry or
+// Del, so a burst of cache traffic can't evict security state such as revoked tokens.
+type memoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List // front = most recently used
+	items      map[string]*list.Element
+
+	durablePrefixes []string
+	durable         map[string]*memoryItem
+	nextSweep       int // sweep expired durable keys once this many are held
+}
+
+// minDurableSweep is the durable key count at which expired ones are first swept out
+const minDurableSweep = 1024
+
+type memoryItem struct {
+	key       string
+	value     string
+	set       map[string]struct{} // non-nil for set keys
+	expiresAt time.Time           // zero = no expiry
+}
+
+// NewMemory returns an in-memory store holding at most maxEntries evictable keys. Keys
+// starting with one of durablePrefixes are never evicted and don't count towards
+// maxEntries; they should be set with a TTL.
+func NewMemory(maxEntries int, durablePrefixes ...string) Store {
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	return &memoryStore{
+		maxEntries:      maxEntries,
+		order:           list.New(),
+		items:           make(map[string]*list.Element),
+		durablePrefixes: durablePrefixes,
+		durable:         make(map[string]*memoryItem),
+		nextSweep:       minDurableSweep,
+	}
+}
+
+func (s *memoryStore) isDurable(key string) bool {
+	for _, prefix := range s.durablePrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (item *memoryItem) expired(now time.Time) bool {
+	return !item.expiresAt.IsZero() && now.After(item.expiresAt)
+}
+
+// lookup returns the live item for key, dropping it if expired. Caller holds mu.
+func (s *memoryStore) lookup(key string) *memoryItem {
+	if s.isDurable(key) {
+		item, ok := s.durable[key]
+		if !ok {
+			return nil
+		}
+		if item.expired(time.Now()) {
+			delete(s.durable, key)
+			return nil
+		}
+		return item
+	}
+	el, ok := s.items[key]
+	if !ok {
+		return nil
+	}
+	item := el.Value.(*memoryItem)
+	if item.expired(time.Now()) {
+		s.remove(el)
+		return nil
+	}
+	s.order.MoveToFront(el)
+	return item
+}
+
+// put stores item, evicting the least recently used key when full. Caller holds mu.
+func (s *memoryStore) put(item *memoryItem) {
+	if s.isDurable(item.key) {
+		s.durable[item.key] = item
+		s.sweepDurable()
+		return
+	}
+	if el, ok := s.items[item.key]; ok {
+		el.Value = item
+		s.order.MoveToFront(el)
+		return
+	}
+	s.items[item.key] = s.order.PushFront(item)
+	for s.order.Len() > s.maxEntries {
+		s.remove(s.order.Back())
+	}
+}
+
+// sweepDurable drops expired durable keys once enough have built up, so keys that are
+// never read again don't accumulate. Caller holds mu.
+func (s *memoryStore) sweepDurable() {
+	if len(s.durable) < s.nextSweep {
+		return
+	}
+	now := time.Now()
+	for key, item := range s.durable {
+		if item.expired(now) {
+			delete(s.durable, key)
+		}
+	}
+	s.nextSweep = max(2*len(s.durable), minDurableSweep)
+}
+
+func (s *memoryStore) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.items, el.Value.(*memoryItem).key)
+}
+
+// delete removes key from whichever table holds it. Caller holds mu.
+func (s *memoryStore) delete(key string) {
+	if el, ok := s.items[key]; ok {
+		s.remove(el)
+	}
+	delete(s.durable, key)
+}
+
+func expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+// stringify mirrors how go-redis encodes values
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := s.lookup(key)
+	if item == nil {
+		return "", ErrMiss
+	}
+	if item.set != nil {
+		return "", fmt.Errorf("cache: %s holds a set", key)
+	}
+	return item.value, nil
+}
+
+func (s *memoryStore) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(&memoryItem{key: key, value: stringify(value), expiresAt: expiry(ttl)})
+	return nil
+}
+
+func (s *memoryStore) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lookup(key) != nil {
+		return false, nil
+	}
+	s.put(&memoryItem{key: key, value: stringify(value), expiresAt: expiry(ttl)})
+	return true, nil
+}
+
+func (s *memoryStore) Del(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		s.delete(key)
+	}
+	return nil
+}
+
+func (s *memoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := s.lookup(key)
+	if item == nil {
+		s.put(&memoryItem{key: key, value: "1", expiresAt: expiry(ttl)})
+		return 1, nil
+	}
+	if item.set != nil {
+		return 0, fmt.Errorf("cache: %s holds a set", key)
+	}
+	n, err := strconv.ParseInt(item.value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cache: %s is not an integer", key)
+	}
+	n++
+	item.value = strconv.FormatInt(n, 10)
+	return n, nil
+}
+
+func (s *memoryStore) Exists(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lookup(key) != nil, nil
+}
+
+func (s *memoryStore) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if item := s.lookup(key); item != nil {
+		item.expiresAt = expiry(ttl)
+	}
+	return nil
+}
+
+func (s *memoryStore) Keys(ctx context.Context, pattern string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key := range s.items {
+		if ok, _ := path.Match(pattern, key); ok && s.lookup(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+	for key := range s.durable {
+		if ok, _ := path.Match(pattern, key); ok && s.lookup(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (s *memoryStore) SAdd(ctx context.Context, key string, members ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := s.lookup(key)
+	if item == nil || item.set == nil {
+		item = &memoryItem{key: key, set: make(map[string]struct{})}
+		s.put(item)
+	}
+	for _, m := range members {
+		item.set[m] = struct{}{}
+	}
+	return nil
+}
+
+func (s *memoryStore) SRem(ctx context.Context, key string, members ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := s.lookup(key)
+	if item == nil || item.set == nil {
+		return nil
+	}
+	for _, m := range members {
+		delete(item.set, m)
+	}
+	if len(item.set) == 0 {
+		s.delete(key)
+	}
+	return nil
+}
+
+func (s *memoryStore) SMembers(ctx context.Context, key string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := s.lookup(key)
+	if item == nil || item.set == nil {
+		return []string{}, nil
+	}
+	members := make([]string, 0, len(item.set))
+	for m := range item.set {
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+func (s *memoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
+
+func (s *memoryStore) Backend() string {
+	return "memory"
+}
@@ -0,0 +1,216 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemoryGetSetDel(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory(10)
+
+	if _, err := s.Get(ctx, "missing"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get of a missing key: err = %v, want ErrMiss", err)
+	}
+	if err := s.Set(ctx, "n", 42, 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, err := s.Get(ctx, "n"); err != nil || got != "42" {
+		t.Fatalf("Get = %q, %v; want 42 stored the way Redis would", got, err)
+	}
+	if err := s.Del(ctx, "n", "missing"); err != nil {
+		t.Fatalf("Del: %v", err)
+	}
+	if ok, _ := s.Exists(ctx, "n"); ok {
+		t.Fatal("key still exists after Del")
+	}
+}
+
+func TestMemoryTTL(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory(10)
+
+	_ = s.Set(ctx, "short", "v", 20*time.Millisecond)
+	_ = s.Set(ctx, "forever", "v", 0)
+	_ = s.Set(ctx, "extended", "v", 20*time.Millisecond)
+	_ = s.Expire(ctx, "extended", time.Hour)
+	time.Sleep(40 * time.Millisecond)
+
+	if _, err := s.Get(ctx, "short"); !errors.Is(err, ErrMiss) {
+		t.Errorf("expired key: err = %v, want ErrMiss", err)
+	}
+	for _, key := range []string{"forever", "extended"} {
+		if ok, _ := s.Exists(ctx, key); !ok {
+			t.Errorf("%s expired", key)
+		}
+	}
+}
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory(2)
+
+	_ = s.Set(ctx, "a", "1", 0)
+	_ = s.Set(ctx, "b", "2", 0)
+	// Reading a makes b the least recently used key
+	if _, err := s.Get(ctx, "a"); err != nil {
+		t.Fatalf("Get a: %v", err)
+	}
+	_ = s.Set(ctx, "c", "3", 0)
+
+	if ok, _ := s.Exists(ctx, "b"); ok {
+		t.Error("b was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if ok, _ := s.Exists(ctx, key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+}
+
+func TestMemorySetNX(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory(10)
+
+	if ok, err := s.SetNX(ctx, "lock", "first", time.Minute); err != nil || !ok {
+		t.Fatalf("first SetNX = %v, %v; want true", ok, err)
+	}
+	if ok, _ := s.SetNX(ctx, "lock", "second", time.Minute); ok {
+		t.Fatal("second SetNX succeeded on a held key")
+	}
+	if got, _ := s.Get(ctx, "lock"); got != "first" {
+		t.Errorf("value = %q, want first", got)
+	}
+
+	// An expired key can be claimed again
+	_ = s.Set(ctx, "stale", "old", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if ok, _ := s.SetNX(ctx, "stale", "new", time.Minute); !ok {
+		t.Error("SetNX failed on an expired key")
+	}
+}
+
+func TestMemorySets(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory(10)
+
+	_ = s.SAdd(ctx, "friends", "bob", "carol", "bob")
+	members, _ := s.SMembers(ctx, "friends")
+	sort.Strings(members)
+	if want := []string{"bob", "carol"}; !reflect.DeepEqual(members, want) {
+		t.Fatalf("SMembers = %v, want %v", members, want)
+	}
+	if _, err := s.Get(ctx, "friends"); err == nil {
+		t.Error("Get of a set key succeeded")
+	}
+
+	// Removing the last member removes the key, as in Redis
+	_ = s.SRem(ctx, "friends", "bob", "carol")
+	if ok, _ := s.Exists(ctx, "friends"); ok {
+		t.Error("empty set still exists")
+	}
+	if members, _ := s.SMembers(ctx, "friends"); members == nil || len(members) != 0 {
+		t.Errorf("SMembers of a missing set = %#v, want an empty slice", members)
+	}
+}
+
+func TestMemoryKeys(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory(10)
+
+	_ = s.Set(ctx, "user:alice", "1", 0)
+	_ = s.Set(ctx, "user:bob", "1", 0)
+	_ = s.Set(ctx, "user:expired", "1", 10*time.Millisecond)
+	_ = s.Set(ctx, "entry:1", "1", 0)
+	time.Sleep(20 * time.Millisecond)
+
+	keys, err := s.Keys(ctx, "user:*")
+	if err != nil {
+		t.Fatalf("Keys: %v", err)
+	}
+	sort.Strings(keys)
+	if want := []string{"user:alice", "user:bob"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("Keys = %v, want %v", keys, want)
+	}
+}
+
+func TestMemoryIncr(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory(10)
+
+	for want := int64(1); want <= 3; want++ {
+		if n, err := s.Incr(ctx, "hits", time.Minute); err != nil || n != want {
+			t.Fatalf("Incr = %d, %v; want %d", n, err, want)
+		}
+	}
+	_ = s.Set(ctx, "name", "alice", 0)
+	if _, err := s.Incr(ctx, "name", time.Minute); err == nil {
+		t.Error("Incr of a non-integer succeeded")
+	}
+}
+
+func TestMemoryNeverEvictsDurableKeys(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory(2, "revoked_token:", "idempotency:")
+
+	_ = s.Set(ctx, "revoked_token:abc", "1", time.Hour)
+	_, _ = s.SetNX(ctx, "idempotency:alice:/create:k1", "pending", time.Hour)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		_ = s.Set(ctx, key, "1", 0)
+	}
+
+	for _, key := range []string{"revoked_token:abc", "idempotency:alice:/create:k1"} {
+		if ok, _ := s.Exists(ctx, key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+	// Ordinary keys are still capped, and durable ones don't count towards the cap
+	for key, want := range map[string]bool{"a": false, "b": false, "c": true, "d": true} {
+		if ok, _ := s.Exists(ctx, key); ok != want {
+			t.Errorf("Exists(%s) = %v, want %v", key, ok, want)
+		}
+	}
+	keys, _ := s.Keys(ctx, "revoked_token:*")
+	if !reflect.DeepEqual(keys, []string{"revoked_token:abc"}) {
+		t.Errorf("Keys = %v", keys)
+	}
+
+	if err := s.Del(ctx, "revoked_token:abc"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.Exists(ctx, "revoked_token:abc"); ok {
+		t.Error("durable key still exists after Del")
+	}
+}
+
+func TestMemoryDurableKeysExpire(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory(10, "revoked_token:").(*memoryStore)
+
+	_ = s.Set(ctx, "revoked_token:short", "1", 10*time.Millisecond)
+	_ = s.Set(ctx, "revoked_token:long", "1", time.Hour)
+	time.Sleep(20 * time.Millisecond)
+	if ok, _ := s.Exists(ctx, "revoked_token:short"); ok {
+		t.Error("expired durable key still exists")
+	}
+
+	// Expired keys that are never read again are swept out as more are added
+	for i := 0; i < minDurableSweep; i++ {
+		_ = s.Set(ctx, "revoked_token:"+strconv.Itoa(i), "1", time.Millisecond)
+	}
+	time.Sleep(5 * time.Millisecond)
+	for i := 0; i < minDurableSweep; i++ {
+		_ = s.Set(ctx, "revoked_token:new"+strconv.Itoa(i), "1", time.Hour)
+	}
+	if n := len(s.durable); n > minDurableSweep+1 {
+		t.Errorf("%d durable keys held, want expired ones swept", n)
+	}
+	if ok, _ := s.Exists(ctx, "revoked_token:long"); !ok {
+		t.Error("live durable key was swept")
+	}
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type redisStore struct {
+	client *redis.Client
+}
+
+// NewRedis wraps a connected Redis client
+func NewRedis(client *redis.Client) Store {
+	return &redisStore{client: client}
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) (string, error) {
+	val, err := s.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrMiss
+	}
+	return val, err
+}
+
+func (s *redisStore) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (s *redisStore) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+func (s *redisStore) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.client.Del(ctx, keys...).Err()
+}
+
+func (s *redisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	n, err := s.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if n == 1 && ttl > 0 {
+		if err := s.client.Expire(ctx, key, ttl).Err(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (s *redisStore) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := s.client.Exists(ctx, key).Result()
+	return n > 0, err
+}
+
+func (s *redisStore) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	return s.client.Expire(ctx, key, ttl).Err()
+}
+
+// Keys uses SCAN rather than KEYS so large keyspaces don't block Redis
+func (s *redisStore) Keys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := s.client.Scan(ctx, 0, pattern, 0).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+func (s *redisStore) SAdd(ctx context.Context, key string, members ...string) error {
+	return s.client.SAdd(ctx, key, toInterfaces(members)...).Err()
+}
+
+func (s *redisStore) SRem(ctx context.Context, key string, members ...string) error {
+	return s.client.SRem(ctx, key, toInterfaces(members)...).Err()
+}
+
+func (s *redisStore) SMembers(ctx context.Context, key string) ([]string, error) {
+	return s.client.SMembers(ctx, key).Result()
+}
+
+func (s *redisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+func (s *redisStore) Close() error {
+	return s.client.Close()
+}
+
+func (s *redisStore) Backend() string {
+	return "redis"
+}
+
+func toInterfaces(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Fatalf("migration %d has version %d; versions must be contiguous from 1", i, m.Version)
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %04d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
+
+// The pair index belongs to 0007 alone, so rolling 0007 back removes it. Its up migration
+// also runs against databases first created by createTables, which already have it.
+func TestFriendshipPairIndexMigration(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	for _, m := range migrations {
+		creates := strings.Contains(m.Up, "idx_friendships_unique_pair")
+		if creates != (m.Version == 7) {
+			t.Errorf("migration %04d_%s: creates pair index = %v", m.Version, m.Name, creates)
+		}
+	}
+	m := migrations[6]
+	if !strings.Contains(m.Up, "CREATE UNIQUE INDEX IF NOT EXISTS idx_friendships_unique_pair") {
+		t.Errorf("0007 creates the pair index without IF NOT EXISTS")
+	}
+	if !strings.Contains(m.Down, "DROP INDEX IF EXISTS idx_friendships_unique_pair") {
+		t.Errorf("0007 down doesn't drop the pair index: %q", m.Down)
+	}
+}
+
+// Once 0007 has run, a friendship row and its reverse can't both exist, whichever is
+// inserted first
+func TestFriendshipPairConflicts(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	a, b := "pair-a-"+suffix, "pair-b-"+suffix
+	for _, uid := range []string{a, b} {
+		if _, err := pool.Exec(ctx, `
+			INSERT INTO users (uid, display_name, email, photo_url, phone_number)
+			VALUES ($1, $1, $1 || '@example.com', '', '')
+		`, uid); err != nil {
+			t.Fatalf("insert user: %v", err)
+		}
+	}
+	defer pool.Exec(context.Background(), `DELETE FROM users WHERE uid = ANY($1)`, []string{a, b})
+
+	insert := func(uid, fid string) error {
+		_, err := pool.Exec(ctx, `INSERT INTO friendships (uid, fid, status) VALUES ($1, $2, 'pending')`, uid, fid)
+		return err
+	}
+	clear := func() {
+		if _, err := pool.Exec(ctx, `DELETE FROM friendships WHERE uid = ANY($1)`, []string{a, b}); err != nil {
+			t.Fatalf("clear friendships: %v", err)
+		}
+	}
+	for _, order := range [][2]string{{a, b}, {b, a}} {
+		clear()
+		if err := insert(order[0], order[1]); err != nil {
+			t.Fatalf("insert %s -> %s: %v", order[0], order[1], err)
+		}
+		var pgErr *pgconn.PgError
+		if err := insert(order[1], order[0]); !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+			t.Errorf("insert %s -> %s after the reverse: %v, want a unique violation", order[1], order[0], err)
+		}
+	}
+	clear()
+}
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS daily_prompts;
+DROP TABLE IF EXISTS push_tokens;
+DROP TABLE IF EXISTS audio;
+DROP TABLE IF EXISTS images;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS locations;
+DROP TABLE IF EXISTS entries;
+DROP TABLE IF EXISTS user_settings;
+DROP TABLE IF EXISTS users;
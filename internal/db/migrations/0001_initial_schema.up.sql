@@ -0,0 +1,131 @@
+-- Users table - stores Firebase user information
+CREATE TABLE IF NOT EXISTS users (
+	uid VARCHAR(255) PRIMARY KEY,
+	display_name VARCHAR(255) UNIQUE NOT NULL,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	token TEXT,
+	photo_url TEXT,
+	phone_number VARCHAR(20),
+	provider_id VARCHAR(100),
+	refresh_token TEXT,
+	tenant_id VARCHAR(100),
+	provider VARCHAR(100),
+	email_verified BOOLEAN DEFAULT FALSE,
+	phone_number_verified BOOLEAN DEFAULT FALSE,
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- User Settings table - stores user preferences and settings
+CREATE TABLE IF NOT EXISTS user_settings (
+	uid VARCHAR(255) PRIMARY KEY REFERENCES users(uid) ON DELETE CASCADE,
+	theme_mode VARCHAR(10) DEFAULT 'light' CHECK (theme_mode IN ('light', 'dark')),
+	theme VARCHAR(20) DEFAULT 'default' CHECK (theme IN ('default', 'royal', 'sunset', 'coral', 'beach', 'rose', 'ocean')),
+	app_font VARCHAR(20) DEFAULT 'Montserrat' CHECK (app_font IN ('Montserrat', 'Bauhaus', 'PlayfairDisplay', 'Ubuntu')),
+	lang VARCHAR(5) DEFAULT 'en' CHECK (lang IN ('en', 'ar', 'de', 'es', 'fr', 'he', 'ja', 'ko', 'pt', 'ru', 'uk', 'vi', 'zh')),
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Entries table - stores journal entries
+CREATE TABLE IF NOT EXISTS entries (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_uid VARCHAR(255) NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+	title VARCHAR(500) NOT NULL,
+	description TEXT,
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Locations table - stores location information for entries
+CREATE TABLE IF NOT EXISTS locations (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+	latitude DECIMAL(10, 8),
+	longitude DECIMAL(11, 8),
+	address TEXT,
+	city VARCHAR(255),
+	state VARCHAR(255),
+	zip VARCHAR(20),
+	country VARCHAR(255),
+	country_code VARCHAR(10),
+	display_name VARCHAR(500),
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Tags table - stores tags for entries
+CREATE TABLE IF NOT EXISTS tags (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+	key VARCHAR(255) NOT NULL,
+	value TEXT,
+	created_at TIMESTAMP DEFAULT NOW(),
+	UNIQUE(entry_id, key)
+);
+
+-- Images table - stores image information for entries
+CREATE TABLE IF NOT EXISTS images (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+	url TEXT NOT NULL,
+	filename VARCHAR(500),
+	file_size BIGINT,
+	mime_type VARCHAR(100),
+	width INTEGER,
+	height INTEGER,
+	upload_order INTEGER DEFAULT 0,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Audio table - stores audio information for entries
+CREATE TABLE IF NOT EXISTS audio (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+	url TEXT NOT NULL,
+	filename VARCHAR(500),
+	file_size BIGINT,
+	mime_type VARCHAR(100),
+	duration INTEGER,
+	upload_order INTEGER DEFAULT 0,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Push tokens - stores device push registration
+CREATE TABLE IF NOT EXISTS push_tokens (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_id VARCHAR(255) NOT NULL,
+	expo_push_token TEXT NOT NULL,
+	fcm_token TEXT,
+	platform VARCHAR(20) NOT NULL,
+	timezone VARCHAR(50) NOT NULL DEFAULT 'UTC',
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+	active BOOLEAN DEFAULT TRUE,
+	UNIQUE(user_id)
+);
+
+-- Daily prompts - stores generated/selected prompts by date
+CREATE TABLE IF NOT EXISTS daily_prompts (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	prompt TEXT NOT NULL,
+	date DATE NOT NULL UNIQUE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+CREATE INDEX IF NOT EXISTS idx_users_display_name ON users(display_name);
+CREATE INDEX IF NOT EXISTS idx_user_settings_uid ON user_settings(uid);
+CREATE INDEX IF NOT EXISTS idx_entries_user_uid ON entries(user_uid);
+CREATE INDEX IF NOT EXISTS idx_entries_created_at ON entries(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_locations_entry_id ON locations(entry_id);
+CREATE INDEX IF NOT EXISTS idx_locations_coords ON locations(latitude, longitude);
+CREATE INDEX IF NOT EXISTS idx_tags_entry_id ON tags(entry_id);
+CREATE INDEX IF NOT EXISTS idx_tags_key ON tags(key);
+CREATE INDEX IF NOT EXISTS idx_images_entry_id ON images(entry_id);
+CREATE INDEX IF NOT EXISTS idx_images_upload_order ON images(entry_id, upload_order);
+CREATE INDEX IF NOT EXISTS idx_audio_entry_id ON audio(entry_id);
+CREATE INDEX IF NOT EXISTS idx_audio_upload_order ON audio(entry_id, upload_order);
+CREATE INDEX IF NOT EXISTS idx_push_tokens_user_id ON push_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_push_tokens_active ON push_tokens(active);
+CREATE INDEX IF NOT EXISTS idx_push_tokens_timezone ON push_tokens(timezone);
+CREATE INDEX IF NOT EXISTS idx_daily_prompts_date ON daily_prompts(date);
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_premium_consistency;
+ALTER TABLE users DROP COLUMN IF EXISTS premium_expires_at;
+ALTER TABLE users DROP COLUMN IF EXISTS is_premium;
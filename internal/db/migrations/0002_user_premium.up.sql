@@ -0,0 +1,11 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_premium BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS premium_expires_at TIMESTAMP NULL;
+
+DO $$ BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'users_premium_consistency') THEN
+		ALTER TABLE users ADD CONSTRAINT users_premium_consistency CHECK (
+			(is_premium = TRUE AND premium_expires_at IS NOT NULL) OR
+			(is_premium = FALSE AND premium_expires_at IS NULL)
+		);
+	END IF;
+END $$;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS entry_shares;
+DROP INDEX IF EXISTS idx_entries_visibility;
+ALTER TABLE entries DROP CONSTRAINT IF EXISTS entries_visibility_check;
+ALTER TABLE entries DROP COLUMN IF EXISTS visibility;
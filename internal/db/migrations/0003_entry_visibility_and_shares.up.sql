@@ -0,0 +1,19 @@
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS visibility VARCHAR(20) NOT NULL DEFAULT 'private';
+
+DO $$ BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'entries_visibility_check') THEN
+		ALTER TABLE entries ADD CONSTRAINT entries_visibility_check CHECK (visibility IN ('private','semi-private','public'));
+	END IF;
+END $$;
+
+-- Entry shares - stores which users can access semi-private entries
+CREATE TABLE IF NOT EXISTS entry_shares (
+	entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+	shared_user_uid VARCHAR(255) NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+	created_at TIMESTAMP DEFAULT NOW(),
+	PRIMARY KEY (entry_id, shared_user_uid)
+);
+
+CREATE INDEX IF NOT EXISTS idx_entries_visibility ON entries(visibility);
+CREATE INDEX IF NOT EXISTS idx_entry_shares_user_uid ON entry_shares(shared_user_uid);
+CREATE INDEX IF NOT EXISTS idx_entry_shares_entry_id ON entry_shares(entry_id);
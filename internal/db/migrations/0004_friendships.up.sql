@@ -0,0 +1,19 @@
+-- Friendships - stores friendships between users
+CREATE TABLE IF NOT EXISTS friendships (
+	uid VARCHAR(255) NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+	fid VARCHAR(255) NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+	created_at TIMESTAMP DEFAULT NOW(),
+	PRIMARY KEY (uid, fid),
+	CHECK (uid <> fid)
+);
+
+ALTER TABLE friendships ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'pending';
+
+DO $$ BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'friendships_status_check') THEN
+		ALTER TABLE friendships ADD CONSTRAINT friendships_status_check CHECK (status IN ('pending','approved','rejected','blocked'));
+	END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_friendships_uid ON friendships(uid);
+CREATE INDEX IF NOT EXISTS idx_friendships_fid ON friendships(fid);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_token;
+ALTER TABLE users DROP COLUMN IF EXISTS token_expires_at;
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_expires_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_token ON users(token);
@@ -0,0 +1,10 @@
+-- Entry comments - stores comments left on entries by users who can view them
+CREATE TABLE IF NOT EXISTS entry_comments (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+	author_uid VARCHAR(255) NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+	body TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_entry_comments_entry_id ON entry_comments(entry_id, created_at);
@@ -0,0 +1,2 @@
+-- Rows removed as duplicates are not restored
+DROP INDEX IF EXISTS idx_friendships_unique_pair;
@@ -0,0 +1,19 @@
+-- Friendships are symmetric: A->B and B->A describe the same relationship, so only one
+-- row may exist per unordered pair. Collapse reversed duplicates first, keeping the most
+-- significant status (blocked > approved > pending > rejected), then the oldest row.
+-- Databases first created by createTables already have the index and no duplicates, so
+-- both statements leave them unchanged.
+DELETE FROM friendships f
+USING friendships g
+WHERE f.uid = g.fid AND f.fid = g.uid
+	AND (
+		CASE f.status WHEN 'blocked' THEN 0 WHEN 'approved' THEN 1 WHEN 'pending' THEN 2 ELSE 3 END,
+		COALESCE(f.created_at, '-infinity'),
+		f.uid
+	) > (
+		CASE g.status WHEN 'blocked' THEN 0 WHEN 'approved' THEN 1 WHEN 'pending' THEN 2 ELSE 3 END,
+		COALESCE(g.created_at, '-infinity'),
+		g.uid
+	);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_friendships_unique_pair ON friendships (LEAST(uid, fid), GREATEST(uid, fid));
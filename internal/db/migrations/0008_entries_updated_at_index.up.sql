@@ -0,0 +1,2 @@
+-- Supports searching and sorting a user's entries by when they were last edited
+CREATE INDEX IF NOT EXISTS idx_entries_user_updated_at ON entries(user_uid, updated_at DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_users_email_trgm;
+DROP INDEX IF EXISTS idx_users_display_name_trgm;
+-- pg_trgm is left installed; other database objects may depend on it
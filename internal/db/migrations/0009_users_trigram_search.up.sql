@@ -0,0 +1,6 @@
+-- SearchUsers matches display_name and email with ILIKE '%query%'. A leading wildcard
+-- can't use a btree index, so add trigram GIN indexes that can serve both columns.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_users_display_name_trgm ON users USING gin (display_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_email_trgm ON users USING gin (email gin_trgm_ops);
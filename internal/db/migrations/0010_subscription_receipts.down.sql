@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_subscription_receipts_subscription;
+DROP INDEX IF EXISTS idx_users_premium_expires_at;
+DROP TABLE IF EXISTS subscription_receipts;
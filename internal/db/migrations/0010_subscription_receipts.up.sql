@@ -0,0 +1,18 @@
+-- Subscription receipts - the latest verified App Store / Play receipt per user, kept so
+-- the expiry job can re-validate renewals before revoking premium
+CREATE TABLE IF NOT EXISTS subscription_receipts (
+	uid VARCHAR(255) PRIMARY KEY REFERENCES users(uid) ON DELETE CASCADE,
+	platform VARCHAR(16) NOT NULL CHECK (platform IN ('ios', 'android')),
+	product_id VARCHAR(255) NOT NULL,
+	receipt TEXT NOT NULL,
+	subscription_id TEXT NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	verified_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- One account per store subscription, so a receipt can't unlock premium for several users
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscription_receipts_subscription ON subscription_receipts(platform, subscription_id);
+
+CREATE INDEX IF NOT EXISTS idx_users_premium_expires_at ON users(premium_expires_at) WHERE is_premium = TRUE;
@@ -0,0 +1,3 @@
+-- ValidateDisplayName and other lookups compare LOWER(display_name); index the expression
+-- so the check doesn't scan users. Not unique: existing rows may differ only by case.
+CREATE INDEX IF NOT EXISTS idx_users_display_name_lower ON users (LOWER(display_name));
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_entries_user_created_at;
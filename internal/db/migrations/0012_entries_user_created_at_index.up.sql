@@ -0,0 +1,2 @@
+-- Serves the newest-first listing of a user's entries (ListEntries) without a sort step
+CREATE INDEX IF NOT EXISTS idx_entries_user_created_at ON entries(user_uid, created_at DESC);
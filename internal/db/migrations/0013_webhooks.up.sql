@@ -0,0 +1,13 @@
+-- Outbound webhooks - user-registered HTTPS endpoints that receive signed event payloads
+CREATE TABLE IF NOT EXISTS webhooks (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	user_uid VARCHAR(255) NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+	url TEXT NOT NULL,
+	secret VARCHAR(255) NOT NULL,
+	events TEXT[] NOT NULL DEFAULT '{}',
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP DEFAULT NOW(),
+	updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_uid ON webhooks(user_uid) WHERE active = TRUE;
@@ -0,0 +1,10 @@
+-- Public share links - unguessable tokens that expose one entry read-only without signing in
+CREATE TABLE IF NOT EXISTS entry_public_links (
+	token VARCHAR(64) PRIMARY KEY,
+	entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+	user_uid VARCHAR(255) NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+	expires_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_entry_public_links_entry_id ON entry_public_links(entry_id);
@@ -0,0 +1,2 @@
+ALTER TABLE images DROP COLUMN IF EXISTS webp_url;
+ALTER TABLE images DROP COLUMN IF EXISTS mime_type;
@@ -0,0 +1,4 @@
+-- Image format metadata - the uploaded MIME type and, when transcoding is enabled, the
+-- URL of the WebP variant served to clients that accept it
+ALTER TABLE images ADD COLUMN IF NOT EXISTS mime_type VARCHAR(50);
+ALTER TABLE images ADD COLUMN IF NOT EXISTS webp_url TEXT;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_entries_scheduled_publish_at;
+ALTER TABLE entries DROP CONSTRAINT IF EXISTS entries_status_check;
+ALTER TABLE entries DROP COLUMN IF EXISTS publish_at;
+ALTER TABLE entries DROP COLUMN IF EXISTS status;
@@ -0,0 +1,9 @@
+-- Drafts and scheduled entries. Existing entries are published; scheduled entries are
+-- flipped to published by a cron job once publish_at passes.
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'published';
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP;
+
+ALTER TABLE entries DROP CONSTRAINT IF EXISTS entries_status_check;
+ALTER TABLE entries ADD CONSTRAINT entries_status_check CHECK (status IN ('draft', 'published', 'scheduled'));
+
+CREATE INDEX IF NOT EXISTS idx_entries_scheduled_publish_at ON entries(publish_at) WHERE status = 'scheduled';
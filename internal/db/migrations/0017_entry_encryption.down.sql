@@ -0,0 +1,7 @@
+-- Turn encryption off for every user before rolling back; sealed rows can't be read
+-- without the flag, and long sealed titles won't fit back into VARCHAR(500).
+ALTER TABLE entries ALTER COLUMN title TYPE VARCHAR(500);
+ALTER TABLE entries DROP COLUMN IF EXISTS encrypted;
+
+ALTER TABLE users DROP COLUMN IF EXISTS encryption_salt;
+ALTER TABLE users DROP COLUMN IF EXISTS encryption_enabled;
@@ -0,0 +1,8 @@
+-- Optional encryption at rest for entry titles and descriptions. users.encryption_salt
+-- feeds the per-user key derivation; entries.encrypted marks rows whose text is sealed.
+-- Sealed titles are longer than their plaintext, so title becomes TEXT.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS encryption_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS encryption_salt BYTEA;
+
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS encrypted BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE entries ALTER COLUMN title TYPE TEXT;
@@ -0,0 +1,17 @@
+-- Videos table - stores video clips for entries, mirroring audio. thumbnail_url points at
+-- the poster frame generated on upload, when ffmpeg is available.
+CREATE TABLE IF NOT EXISTS videos (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+	url TEXT NOT NULL,
+	filename VARCHAR(500),
+	file_size BIGINT,
+	mime_type VARCHAR(100),
+	duration INTEGER,
+	thumbnail_url TEXT,
+	upload_order INTEGER DEFAULT 0,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_videos_entry_id ON videos(entry_id);
+CREATE INDEX IF NOT EXISTS idx_videos_upload_order ON videos(entry_id, upload_order);
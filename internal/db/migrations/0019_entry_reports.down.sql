@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS entry_reports;
+ALTER TABLE entries DROP COLUMN IF EXISTS moderation_hidden;
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
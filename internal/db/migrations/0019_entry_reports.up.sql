@@ -0,0 +1,18 @@
+-- Moderation. Users report entries they can see; once an entry collects enough open
+-- reports it is hidden from feeds until a moderator (users.is_admin) reviews it.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS moderation_hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS entry_reports (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+	reporter_uid VARCHAR(255) NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+	reason TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'upheld')),
+	resolved_by VARCHAR(255),
+	resolved_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT NOW(),
+	UNIQUE (entry_id, reporter_uid)
+);
+
+CREATE INDEX IF NOT EXISTS idx_entry_reports_status ON entry_reports(status, created_at);
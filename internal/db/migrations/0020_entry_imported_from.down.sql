@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_entries_imported_from;
+ALTER TABLE entries DROP COLUMN IF EXISTS imported_from;
@@ -0,0 +1,5 @@
+-- The id an imported entry had in the export it came from. JSON imports skip entries
+-- whose original id is already in the account, so re-running one is harmless.
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS imported_from UUID;
+
+CREATE INDEX IF NOT EXISTS idx_entries_imported_from ON entries(user_uid, imported_from) WHERE imported_from IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_daily_prompts_date_lang;
+DELETE FROM daily_prompts WHERE lang <> 'en';
+ALTER TABLE daily_prompts ADD CONSTRAINT daily_prompts_date_key UNIQUE (date);
+ALTER TABLE daily_prompts DROP COLUMN IF EXISTS lang;
@@ -0,0 +1,6 @@
+-- Daily prompts are chosen per language, so there is one row per date and language
+-- instead of one per date. Existing rows were all English.
+ALTER TABLE daily_prompts ADD COLUMN IF NOT EXISTS lang VARCHAR(5) NOT NULL DEFAULT 'en';
+
+ALTER TABLE daily_prompts DROP CONSTRAINT IF EXISTS daily_prompts_date_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_daily_prompts_date_lang ON daily_prompts(date, lang);
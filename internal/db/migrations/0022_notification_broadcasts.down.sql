@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS notification_broadcasts;
+ALTER TABLE user_settings DROP COLUMN IF EXISTS notify_announcements;
@@ -0,0 +1,22 @@
+-- Admin broadcasts. Each one is logged with its audience and delivery counts. Users can
+-- opt out of announcements; service notices (maintenance and the like) always go out.
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS notify_announcements BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE TABLE IF NOT EXISTS notification_broadcasts (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	sender_uid VARCHAR(255) REFERENCES users(uid) ON DELETE SET NULL,
+	category VARCHAR(20) NOT NULL CHECK (category IN ('announcements', 'service')),
+	title TEXT NOT NULL,
+	body TEXT NOT NULL,
+	data JSONB,
+	segment JSONB,
+	status VARCHAR(20) NOT NULL DEFAULT 'sending' CHECK (status IN ('sending', 'sent')),
+	targeted INTEGER NOT NULL DEFAULT 0,
+	opted_out INTEGER NOT NULL DEFAULT 0,
+	succeeded INTEGER NOT NULL DEFAULT 0,
+	failed INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMP DEFAULT NOW(),
+	completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_broadcasts_created_at ON notification_broadcasts(created_at);
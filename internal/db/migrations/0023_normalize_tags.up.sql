@@ -0,0 +1,3 @@
+-- Users who turn on normalize_tags get tag keys trimmed and lowercased as they are saved,
+-- so "Mood", "mood " and "MOOD" are one tag
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS normalize_tags BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,15 @@
+-- Documents attached to entries (itineraries, tickets and the like), stored under
+-- attachments/<uid>/<entry_id>/ like the other media. filename is the name the file was
+-- uploaded with; the stored object gets a generated name.
+CREATE TABLE IF NOT EXISTS attachments (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+	url TEXT NOT NULL,
+	filename VARCHAR(255) NOT NULL,
+	mime_type VARCHAR(255) NOT NULL,
+	file_size BIGINT NOT NULL,
+	upload_order INTEGER DEFAULT 0,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_entry_id ON attachments(entry_id);
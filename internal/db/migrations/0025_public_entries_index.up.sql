@@ -0,0 +1,2 @@
+-- Walks published public entries newest first for the discovery feed and its cursors
+CREATE INDEX IF NOT EXISTS idx_entries_public_created_at ON entries(created_at DESC, id DESC) WHERE visibility = 'public' AND status = 'published';
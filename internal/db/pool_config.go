@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"io.winapps.journeyapp/internal/metrics"
+)
+
+// Pool defaults, used when the matching DB_* variable is unset
+const (
+	defaultMaxConns          = 25
+	defaultMinConns          = 5
+	defaultMaxConnLifetime   = time.Hour
+	defaultMaxConnIdleTime   = 30 * time.Minute
+	defaultHealthCheckPeriod = 5 * time.Minute
+	defaultSlowAcquire       = 500 * time.Millisecond
+)
+
+// applyPoolSettings sets the pool size and connection lifetimes from the environment:
+// DB_MAX_CONNS, DB_MIN_CONNS, DB_MAX_CONN_LIFETIME, DB_MAX_CONN_IDLE_TIME and
+// DB_HEALTH_CHECK_PERIOD. Durations use Go syntax such as "30m". Acquires that wait
+// longer than DB_SLOW_ACQUIRE_THRESHOLD are logged; "0" turns the warning off.
+func applyPoolSettings(config *pgxpool.Config, logger *zap.SugaredLogger) error {
+	maxConns, err := envInt("DB_MAX_CONNS", defaultMaxConns)
+	if err != nil {
+		return err
+	}
+	minConns, err := envInt("DB_MIN_CONNS", defaultMinConns)
+	if err != nil {
+		return err
+	}
+	if maxConns < 1 || minConns < 0 || minConns > maxConns {
+		return fmt.Errorf("invalid pool size: DB_MIN_CONNS=%d must be between 0 and DB_MAX_CONNS=%d, which must be at least 1", minConns, maxConns)
+	}
+	config.MaxConns = int32(maxConns)
+	config.MinConns = int32(minConns)
+
+	if config.MaxConnLifetime, err = envDuration("DB_MAX_CONN_LIFETIME", defaultMaxConnLifetime); err != nil {
+		return err
+	}
+	if config.MaxConnIdleTime, err = envDuration("DB_MAX_CONN_IDLE_TIME", defaultMaxConnIdleTime); err != nil {
+		return err
+	}
+	if config.HealthCheckPeriod, err = envDuration("DB_HEALTH_CHECK_PERIOD", defaultHealthCheckPeriod); err != nil {
+		return err
+	}
+
+	slow, err := envDuration("DB_SLOW_ACQUIRE_THRESHOLD", defaultSlowAcquire)
+	if err != nil {
+		return err
+	}
+	if slow > 0 && logger != nil {
+		config.ConnConfig.Tracer = &acquireTracer{threshold: slow, logger: logger}
+	}
+	return nil
+}
+
+func envInt(key string, defaultValue int) (int, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value: %w", key, err)
+	}
+	return n, nil
+}
+
+func envDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s value %q: use a duration such as 30s or 5m", key, raw)
+	}
+	return d, nil
+}
+
+type acquireStartKey struct{}
+
+// acquireTracer warns when getting a connection from the pool takes longer than
+// threshold, which usually means the pool is saturated. pgx only accepts it as a query
+// tracer, so the query hooks are no-ops.
+type acquireTracer struct {
+	threshold time.Duration
+	logger    *zap.SugaredLogger
+}
+
+func (t *acquireTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	return ctx
+}
+
+func (t *acquireTracer) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
+
+func (t *acquireTracer) TraceAcquireStart(ctx context.Context, _ *pgxpool.Pool, _ pgxpool.TraceAcquireStartData) context.Context {
+	return context.WithValue(ctx, acquireStartKey{}, time.Now())
+}
+
+func (t *acquireTracer) TraceAcquireEnd(ctx context.Context, pool *pgxpool.Pool, data pgxpool.TraceAcquireEndData) {
+	start, ok := ctx.Value(acquireStartKey{}).(time.Time)
+	if !ok {
+		return
+	}
+	waited := time.Since(start)
+	if waited < t.threshold {
+		return
+	}
+	metrics.DBPoolSlowAcquiresTotal.Inc()
+	stat := pool.Stat()
+	t.logger.Warnw("Slow database connection acquire",
+		"waited", waited.String(),
+		"acquiredConns", stat.AcquiredConns(),
+		"idleConns", stat.IdleConns(),
+		"totalConns", stat.TotalConns(),
+		"maxConns", stat.MaxConns(),
+		"error", data.Err,
+	)
+}
@@ -0,0 +1,132 @@
+// Package encryption encrypts entry titles and descriptions at rest for users who opt in.
+// Each user's key is derived with HKDF-SHA256 from a server secret and a random per-user
+// salt, so the database alone is not enough to read their journal, and text is sealed
+// with AES-256-GCM under a fresh nonce.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// SaltSize is the length of the per-user salt stored in users.encryption_salt
+	SaltSize = 16
+
+	// minSecretLength keeps the server secret at least as long as the derived key
+	minSecretLength = 32
+
+	// prefix marks sealed values and versions the format: prefix + base64(nonce || ciphertext)
+	prefix  = "v1:"
+	keyInfo = "journeyapp entry text v1"
+)
+
+var (
+	// ErrNotConfigured is returned when ENTRY_ENCRYPTION_SECRET is not set
+	ErrNotConfigured = errors.New("encryption: entry encryption is not configured")
+	// ErrMalformed is returned by Open for values that were not produced by Seal
+	ErrMalformed = errors.New("encryption: malformed ciphertext")
+)
+
+// Keyring holds the server secret user keys are derived from. A nil *Keyring is valid
+// and fails every operation with ErrNotConfigured.
+type Keyring struct {
+	secret []byte
+}
+
+// NewFromEnv reads the server secret from ENTRY_ENCRYPTION_SECRET. It returns nil without
+// error when the variable is unset, leaving encryption unavailable. The secret can't be
+// changed once users have encrypted entries, since their keys are derived from it.
+func NewFromEnv() (*Keyring, error) {
+	secret := strings.TrimSpace(os.Getenv("ENTRY_ENCRYPTION_SECRET"))
+	if secret == "" {
+		return nil, nil
+	}
+	return New([]byte(secret))
+}
+
+// New returns a keyring for secret, which must be at least 32 bytes
+func New(secret []byte) (*Keyring, error) {
+	if len(secret) < minSecretLength {
+		return nil, fmt.Errorf("encryption: secret must be at least %d bytes", minSecretLength)
+	}
+	return &Keyring{secret: append([]byte(nil), secret...)}, nil
+}
+
+// NewSalt returns a random per-user salt
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// aead derives the user's key from salt
+func (k *Keyring) aead(salt []byte) (cipher.AEAD, error) {
+	if k == nil {
+		return nil, ErrNotConfigured
+	}
+	if len(salt) != SaltSize {
+		return nil, fmt.Errorf("encryption: salt must be %d bytes", SaltSize)
+	}
+	key, err := hkdf.Key(sha256.New, k.secret, salt, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts plaintext for the user with salt. Empty strings stay empty so checks
+// such as "draft has no title" keep working on encrypted rows.
+func (k *Keyring) Seal(salt []byte, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	gcm, err := k.aead(salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value produced by Seal with the same salt
+func (k *Keyring) Open(salt []byte, ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+	gcm, err := k.aead(salt)
+	if err != nil {
+		return "", err
+	}
+	encoded, ok := strings.CutPrefix(ciphertext, prefix)
+	if !ok {
+		return "", ErrMalformed
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < gcm.NonceSize()+gcm.Overhead() {
+		return "", ErrMalformed
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("encryption: %w", err)
+	}
+	return string(plaintext), nil
+}
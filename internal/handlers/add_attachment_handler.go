@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	accountmodels "io.winapps.journeyapp/internal/models/account"
+	addattachmentmodels "io.winapps.journeyapp/internal/models/add_attachment"
+	"io.winapps.journeyapp/internal/premium"
+)
+
+// AddAttachment handles attaching a document (PDF, office file, plain text...) to an
+// existing journal entry. The filename's extension decides the type, and the file's
+// leading bytes have to agree with it.
+func (h *EntryHandler) AddAttachment(c *gin.Context) {
+	// multipart/form-data carries the file in a "file" part plus an entryId field and an
+	// optional filename overriding the part's; anything else is JSON with base64 data
+	var req addattachmentmodels.AddAttachmentRequest
+	var upload *multipart.FileHeader
+	if isMultipartRequest(c) {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			if isBodyTooLarge(err) {
+				respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Request body is too large")
+				return
+			}
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "File is required in the \"file\" field")
+			return
+		}
+		upload = fileHeader
+		req.EntryID = c.PostForm("entryId")
+		req.Filename = c.DefaultPostForm("filename", fileHeader.Filename)
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		if isBodyTooLarge(err) {
+			respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Request body is too large")
+			return
+		}
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	// Get UID from context (set by auth middleware)
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+
+	userUID, ok := uid.(string)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
+		return
+	}
+
+	// Validate required fields
+	if req.EntryID == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
+		return
+	}
+
+	if req.Data == "" && upload == nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "File data is required")
+		return
+	}
+
+	filename := cleanAttachmentFilename(req.Filename)
+	if filename == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Filename is required")
+		return
+	}
+	if _, ok := attachmentTypes[strings.ToLower(path.Ext(filename))]; !ok {
+		respondError(c, http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia, "Unsupported file type")
+		return
+	}
+
+	ctx := context.Background()
+
+	// Verify entry exists and belongs to user
+	var entryExists bool
+	entryCheckQuery := `
+		SELECT EXISTS(SELECT 1 FROM entries WHERE id = $1 AND user_uid = $2)
+	`
+	err := h.postgres.QueryRow(ctx, entryCheckQuery, req.EntryID, userUID).Scan(&entryExists)
+	if err != nil {
+		h.logError(c, err, "verify entry failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
+		return
+	}
+
+	if !entryExists {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+		return
+	}
+
+	policy, err := premium.ForUser(ctx, h.postgres, userUID)
+	if err != nil {
+		h.logError(c, err, "plan lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify account")
+		return
+	}
+	var attachmentCount int
+	if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM attachments WHERE entry_id = $1`, req.EntryID).Scan(&attachmentCount); err != nil {
+		h.logError(c, err, "count attachments failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
+		return
+	}
+	if denial := policy.CheckAttachments(attachmentCount); denial != nil {
+		respondPlanLimit(c, denial)
+		return
+	}
+	attachmentLimit := policy.Limits.MaxAttachmentBytes
+
+	// Save the file; it is only stored once its row is committed
+	detectExt := attachmentExtension(filename)
+	var file *stagedMedia
+	if upload != nil {
+		file, err = saveUploadedMedia(h.media, upload, "attachments", detectExt, userUID, req.EntryID, attachmentLimit)
+	} else {
+		file, err = h.saveAttachmentToFileSystem(req.Data, detectExt, userUID, req.EntryID, attachmentLimit)
+	}
+	if errors.Is(err, errMediaTooLarge) {
+		respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "File is too large: "+err.Error())
+		return
+	}
+	if errors.Is(err, errUnsupportedMediaType) {
+		respondError(c, http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia, "File contents don't match its type")
+		return
+	}
+	if err != nil {
+		h.logError(c, err, "save attachment to filesystem failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save attachment")
+		return
+	}
+	defer file.Discard()
+
+	fileSize, err := file.size()
+	if err != nil {
+		h.logError(c, err, "stat attachment failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save attachment")
+		return
+	}
+	attachment := accountmodels.Attachment{
+		URL:      file.URL,
+		Filename: filename,
+		MimeType: attachmentMimeType(path.Ext(filename)),
+		FileSize: fileSize,
+	}
+
+	// Start database transaction
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		h.logError(c, err, "begin transaction failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Insert the attachment after the entry's existing ones
+	now := time.Now()
+	_, err = tx.Exec(ctx, `
+		INSERT INTO attachments (entry_id, url, filename, mime_type, file_size, upload_order, created_at)
+		SELECT $1, $2, $3, $4, $5, COALESCE(MAX(upload_order), -1) + 1, $6
+		FROM attachments WHERE entry_id = $1
+	`, req.EntryID, attachment.URL, attachment.Filename, attachment.MimeType, attachment.FileSize, now)
+	if err != nil {
+		h.logError(c, err, "insert attachment failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to add attachment")
+		return
+	}
+
+	// Update entry's updated_at timestamp
+	updateEntryQuery := `
+		UPDATE entries SET updated_at = $1 WHERE id = $2
+	`
+	_, err = tx.Exec(ctx, updateEntryQuery, now, req.EntryID)
+	if err != nil {
+		h.logError(c, err, "update entry timestamp failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
+		return
+	}
+
+	// Commit transaction
+	if err = tx.Commit(ctx); err != nil {
+		h.logError(c, err, "commit attachment tx failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save attachment")
+		return
+	}
+
+	// Store the file. If that fails the row would point at nothing, so drop it.
+	if err := file.Commit(ctx); err != nil {
+		h.logError(c, err, "store attachment failed")
+		_, _ = h.postgres.Exec(ctx, `DELETE FROM attachments WHERE entry_id = $1 AND url = $2`, req.EntryID, attachment.URL)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save attachment")
+		return
+	}
+
+	// Invalidate Redis cache for this entry
+	redisKey := "entry:" + req.EntryID
+	h.cache.Del(ctx, redisKey)
+	invalidateAccountDetails(ctx, h.cache, userUID)
+
+	c.JSON(http.StatusOK, addattachmentmodels.AddAttachmentResponse{
+		EntryID:    req.EntryID,
+		Attachment: attachment,
+		Message:    "Attachment added successfully",
+	})
+}
+
+// saveAttachmentToFileSystem decodes the base64 encoded file straight to a staged file,
+// rejecting files larger than maxBytes once decoded
+func (h *EntryHandler) saveAttachmentToFileSystem(base64Data string, detectExt func([]byte) (string, bool), userUID, entryID string, maxBytes int64) (*stagedMedia, error) {
+	// Strip data URL prefix if present (e.g., "data:application/pdf;base64,")
+	if _, data, ok := strings.Cut(base64Data, ","); ok {
+		base64Data = data
+	}
+
+	if err := checkBase64Size(base64Data, maxBytes); err != nil {
+		return nil, err
+	}
+
+	decoder := base64.NewDecoder(base64.StdEncoding, strings.NewReader(base64Data))
+	return stageMediaFile(h.media, decoder, "attachments", detectExt, userUID, entryID, maxBytes)
+}
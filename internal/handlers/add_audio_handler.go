@@ -3,48 +3,67 @@ package handlers
 import (
 	"context"
 	"encoding/base64"
-	"fmt"
+	"errors"
+	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 
+	"io.winapps.journeyapp/internal/apierror"
 	addaudiomodels "io.winapps.journeyapp/internal/models/add_audio"
+	"io.winapps.journeyapp/internal/premium"
 )
 
 // AddAudio handles adding an audio file to an existing journal entry
 func (h *EntryHandler) AddAudio(c *gin.Context) {
+	// multipart/form-data carries the file in an "audio" part plus an entryId field;
+	// anything else is the original JSON body with base64 data
 	var req addaudiomodels.AddAudioRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+	var upload *multipart.FileHeader
+	if isMultipartRequest(c) {
+		fileHeader, err := c.FormFile("audio")
+		if err != nil {
+			if isBodyTooLarge(err) {
+				respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Request body is too large")
+				return
+			}
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Audio file is required in the \"audio\" field")
+			return
+		}
+		upload = fileHeader
+		req.EntryID = c.PostForm("entryId")
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		if isBodyTooLarge(err) {
+			respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Request body is too large")
+			return
+		}
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Validate required fields
 	if req.EntryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Entry ID is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
 		return
 	}
 
-	if req.Audio == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Audio data is required"})
+	if req.Audio == "" && upload == nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Audio data is required")
 		return
 	}
 
@@ -58,22 +77,55 @@ func (h *EntryHandler) AddAudio(c *gin.Context) {
 	err := h.postgres.QueryRow(ctx, entryCheckQuery, req.EntryID, userUID).Scan(&entryExists)
 	if err != nil {
 		h.logError(c, err, "verify entry failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify entry"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
 		return
 	}
 
 	if !entryExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found or access denied"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+		return
+	}
+
+	policy, err := premium.ForUser(ctx, h.postgres, userUID)
+	if err != nil {
+		h.logError(c, err, "plan lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify account")
+		return
+	}
+	var audioCount int
+	if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM audio WHERE entry_id = $1`, req.EntryID).Scan(&audioCount); err != nil {
+		h.logError(c, err, "count audio failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
 		return
 	}
+	if denial := policy.CheckAudio(audioCount); denial != nil {
+		respondPlanLimit(c, denial)
+		return
+	}
+	audioLimit := policy.Limits.MaxAudioBytes
 
-	// Process and save the audio
-	audioURL, err := h.saveAudioToFileSystem(req.Audio, userUID, req.EntryID)
+	// Process and save the audio; it is only stored once its row is committed
+	var audio *stagedMedia
+	if upload != nil {
+		audio, err = saveUploadedMedia(h.media, upload, "audio", audioExtension, userUID, req.EntryID, audioLimit)
+	} else {
+		audio, err = h.saveAudioToFileSystem(req.Audio, userUID, req.EntryID, audioLimit)
+	}
+	if errors.Is(err, errMediaTooLarge) {
+		respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Audio is too large: "+err.Error())
+		return
+	}
+	if errors.Is(err, errUnsupportedMediaType) {
+		respondError(c, http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia, "Unsupported media type")
+		return
+	}
 	if err != nil {
 		h.logError(c, err, "save audio to filesystem failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save audio: " + err.Error()})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save audio: " + err.Error())
 		return
 	}
+	defer audio.Discard()
+	audioURL := audio.URL
 
 	// Get the current highest upload_order for this entry to set the new order
 	var maxOrder int
@@ -82,20 +134,16 @@ func (h *EntryHandler) AddAudio(c *gin.Context) {
 	`
 	err = h.postgres.QueryRow(ctx, orderQuery, req.EntryID).Scan(&maxOrder)
 	if err != nil {
-		// Clean up the saved file on error
-		os.Remove(audioURL)
 		h.logError(c, err, "determine audio order failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to determine audio order"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to determine audio order")
 		return
 	}
 
 	// Start database transaction
 	tx, err := h.postgres.Begin(ctx)
 	if err != nil {
-		// Clean up the saved file on error
-		os.Remove(audioURL)
 		h.logError(c, err, "begin transaction failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start database transaction"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
 		return
 	}
 	defer tx.Rollback(ctx)
@@ -109,10 +157,8 @@ func (h *EntryHandler) AddAudio(c *gin.Context) {
 	`
 	_, err = tx.Exec(ctx, audioQuery, req.EntryID, audioURL, newOrder, now)
 	if err != nil {
-		// Clean up the saved file on error
-		os.Remove(audioURL)
 		h.logError(c, err, "insert audio failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add audio"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to add audio")
 		return
 	}
 
@@ -122,25 +168,30 @@ func (h *EntryHandler) AddAudio(c *gin.Context) {
 	`
 	_, err = tx.Exec(ctx, updateEntryQuery, now, req.EntryID)
 	if err != nil {
-		// Clean up the saved file on error
-		os.Remove(audioURL)
 		h.logError(c, err, "update entry timestamp failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry timestamp"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
 		return
 	}
 
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
-		// Clean up the saved file on error
-		os.Remove(audioURL)
 		h.logError(c, err, "commit audio tx failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save audio"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save audio")
+		return
+	}
+
+	// Store the file. If that fails the row would point at nothing, so drop it.
+	if err := audio.Commit(ctx); err != nil {
+		h.logError(c, err, "store audio failed")
+		_, _ = h.postgres.Exec(ctx, `DELETE FROM audio WHERE entry_id = $1 AND url = $2`, req.EntryID, audioURL)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save audio")
 		return
 	}
 
 	// Invalidate Redis cache for this entry
 	redisKey := "entry:" + req.EntryID
-	h.redis.Del(ctx, redisKey)
+	h.cache.Del(ctx, redisKey)
+	invalidateAccountDetails(ctx, h.cache, userUID)
 
 	// Create response
 	response := addaudiomodels.AddAudioResponse{
@@ -152,8 +203,9 @@ func (h *EntryHandler) AddAudio(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// saveAudioToFileSystem saves the base64 encoded audio to the file system
-func (h *EntryHandler) saveAudioToFileSystem(base64Audio, userUID, entryID string) (string, error) {
+// saveAudioToFileSystem decodes the base64 encoded audio straight to a staged file,
+// rejecting audio larger than maxBytes once decoded
+func (h *EntryHandler) saveAudioToFileSystem(base64Audio, userUID, entryID string, maxBytes int64) (*stagedMedia, error) {
 	// Strip data URL prefix if present (e.g., "data:audio/mp3;base64,")
 	if strings.Contains(base64Audio, ",") {
 		parts := strings.Split(base64Audio, ",")
@@ -162,63 +214,10 @@ func (h *EntryHandler) saveAudioToFileSystem(base64Audio, userUID, entryID strin
 		}
 	}
 
-	// Decode base64 audio
-	audioData, err := base64.StdEncoding.DecodeString(base64Audio)
-	if err != nil {
-		return "", fmt.Errorf("failed to decode base64 audio: %w", err)
-	}
-
-	// Detect file extension from audio data
-	var ext string
-	if len(audioData) >= 4 {
-		// Check for common audio format signatures
-		switch {
-		case len(audioData) >= 3 && audioData[0] == 0x49 && audioData[1] == 0x44 && audioData[2] == 0x33:
-			ext = ".mp3" // ID3 tag (MP3 with metadata)
-		case len(audioData) >= 11 && string(audioData[0:11]) == "FLV\x01\x05\x00\x00\x00\x09\x00\x00":
-			ext = ".flv"
-		case len(audioData) >= 4 && string(audioData[0:4]) == "OggS":
-			ext = ".ogg"
-		case len(audioData) >= 12 && string(audioData[8:12]) == "WAVE":
-			ext = ".wav"
-		case len(audioData) >= 8 && string(audioData[4:8]) == "ftyp":
-			ext = ".m4a" // MP4 audio
-		case len(audioData) >= 2 && audioData[0] == 0xFF && (audioData[1]&0xE0) == 0xE0:
-			ext = ".mp3" // MP3 frame sync
-		default:
-			ext = ".mp3" // Default to mp3 if format is unknown
-		}
-	} else {
-		ext = ".mp3"
-	}
-
-	// Create directory structure: internal/audio/{userUID}/{entryID}/
-	userDir := filepath.Join("internal", "audio", userUID)
-	entryDir := filepath.Join(userDir, entryID)
-
-	// Create user directory if it doesn't exist
-	if err := os.MkdirAll(userDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create user directory: %w", err)
-	}
-
-	// Create entry directory if it doesn't exist
-	if err := os.MkdirAll(entryDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create entry directory: %w", err)
-	}
-
-	// Generate unique filename
-	audioID := uuid.New().String()
-	filename := audioID + ext
-	filePath := filepath.Join(entryDir, filename)
-
-	// Write audio data to file
-	if err := os.WriteFile(filePath, audioData, 0644); err != nil {
-		return "", fmt.Errorf("failed to write audio file: %w", err)
+	if err := checkBase64Size(base64Audio, maxBytes); err != nil {
+		return nil, err
 	}
 
-	// Return the URL path for accessing the audio
-	// This will be served by the static file server we'll add to main.go
-	audioURL := fmt.Sprintf("/audio/%s/%s/%s", userUID, entryID, filename)
-
-	return audioURL, nil
-}
\ No newline at end of file
+	decoder := base64.NewDecoder(base64.StdEncoding, strings.NewReader(base64Audio))
+	return stageMediaFile(h.media, decoder, "audio", audioExtension, userUID, entryID, maxBytes)
+}
@@ -6,6 +6,9 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/webhooks"
 )
 
 type friendshipRequest struct {
@@ -17,57 +20,69 @@ func (h *UsersHandler) AddFriendship(c *gin.Context) {
 	// Require auth
 	uidVal, ok := c.Get("uid")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 	authUID, _ := uidVal.(string)
 
 	var req friendshipRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request body")
 		return
 	}
 	req.UID = strings.TrimSpace(req.UID)
 	req.FID = strings.TrimSpace(req.FID)
 	if req.UID == "" || req.FID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "uid and fid are required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "uid and fid are required")
 		return
 	}
 	if req.UID != authUID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "uid must match authenticated user"})
+		respondError(c, http.StatusForbidden, apierror.CodeForbidden, "uid must match authenticated user")
 		return
 	}
 	if req.UID == req.FID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot friend yourself"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Cannot friend yourself")
 		return
 	}
 
 	ctx := context.Background()
 
 	// Check existing friendship in either order
-	var exists bool
+	var existingStatus string
 	if err := h.postgres.QueryRow(ctx, `
-		SELECT TRUE FROM friendships WHERE (uid = $1 AND fid = $2) OR (uid = $2 AND fid = $1)
-	`, req.UID, req.FID).Scan(&exists); err == nil && exists {
-		c.JSON(http.StatusConflict, gin.H{"error": "Friendship already exists"})
+		SELECT status FROM friendships WHERE (uid = $1 AND fid = $2) OR (uid = $2 AND fid = $1)
+	`, req.UID, req.FID).Scan(&existingStatus); err == nil {
+		if existingStatus == "blocked" {
+			respondError(c, http.StatusForbidden, apierror.CodeForbidden, "Cannot send a friend request to this user")
+			return
+		}
+		respondError(c, http.StatusConflict, apierror.CodeConflict, "Friendship already exists")
 		return
 	}
 
-	// Insert pending friendship in canonical ordering (uid, fid)
-	// We'll store as provided order; unique symmetric index prevents duplicates in other order
-	_, err := h.postgres.Exec(ctx, `
+	// Insert pending friendship as requester -> recipient. The unique index on
+	// (LEAST(uid, fid), GREATEST(uid, fid)) rejects a concurrent request in the other order.
+	tag, err := h.postgres.Exec(ctx, `
 		INSERT INTO friendships (uid, fid, status, created_at)
 		VALUES ($1, $2, 'pending', NOW())
-		ON CONFLICT (uid, fid) DO NOTHING
+		ON CONFLICT ((LEAST(uid, fid)), (GREATEST(uid, fid))) DO NOTHING
 	`, req.UID, req.FID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create friendship"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create friendship")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		respondError(c, http.StatusConflict, apierror.CodeConflict, "Friendship already exists")
 		return
 	}
 
 	// Invalidate caches
-	_ = h.redis.Del(ctx, "friends:"+req.UID).Err()
-	_ = h.redis.Del(ctx, "friends:"+req.FID).Err()
+	h.invalidateRelationshipCaches(ctx, req.UID, req.FID)
+
+	h.notifyAsync("friend_request", req.FID, func(ns *NotificationsHandler) error {
+		return ns.SendFriendRequestNotification(req.FID, req.UID)
+	})
+	h.webhooks.Emit(req.FID, webhooks.EventFriendRequest, gin.H{"fromUid": req.UID})
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "status": "pending"})
 }
\ No newline at end of file
@@ -3,48 +3,68 @@ package handlers
 import (
 	"context"
 	"encoding/base64"
-	"fmt"
+	"errors"
+	"mime/multipart"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 
+	"io.winapps.journeyapp/internal/apierror"
 	addimagemodels "io.winapps.journeyapp/internal/models/add_image"
+	"io.winapps.journeyapp/internal/premium"
 )
 
 // AddImage handles adding an image to an existing journal entry
 func (h *EntryHandler) AddImage(c *gin.Context) {
+	// multipart/form-data carries the file in an "image" part plus an entryId field;
+	// anything else is the original JSON body with base64 data
 	var req addimagemodels.AddImageRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+	var upload *multipart.FileHeader
+	if isMultipartRequest(c) {
+		fileHeader, err := c.FormFile("image")
+		if err != nil {
+			if isBodyTooLarge(err) {
+				respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Request body is too large")
+				return
+			}
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Image file is required in the \"image\" field")
+			return
+		}
+		upload = fileHeader
+		req.EntryID = c.PostForm("entryId")
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		if isBodyTooLarge(err) {
+			respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Request body is too large")
+			return
+		}
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Validate required fields
 	if req.EntryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Entry ID is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
 		return
 	}
 
-	if req.Image == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Image data is required"})
+	if req.Image == "" && upload == nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Image data is required")
 		return
 	}
 
@@ -58,22 +78,71 @@ func (h *EntryHandler) AddImage(c *gin.Context) {
 	err := h.postgres.QueryRow(ctx, entryCheckQuery, req.EntryID, userUID).Scan(&entryExists)
 	if err != nil {
 		h.logError(c, err, "verify entry failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify entry"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
 		return
 	}
 
 	if !entryExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found or access denied"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
 		return
 	}
 
-	// Process and save the image
-	imageURL, err := h.saveImageToFileSystem(req.Image, userUID, req.EntryID)
+	policy, err := premium.ForUser(ctx, h.postgres, userUID)
+	if err != nil {
+		h.logError(c, err, "plan lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify account")
+		return
+	}
+	var imageCount int
+	if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM images WHERE entry_id = $1`, req.EntryID).Scan(&imageCount); err != nil {
+		h.logError(c, err, "count image failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
+		return
+	}
+	if denial := policy.CheckImages(imageCount); denial != nil {
+		respondPlanLimit(c, denial)
+		return
+	}
+	imageLimit := policy.Limits.MaxImageBytes
+
+	// Process and save the image; it is only stored once its row is committed
+	var image *stagedMedia
+	if upload != nil {
+		image, err = saveUploadedMedia(h.media, upload, "images", imageExtension, userUID, req.EntryID, imageLimit)
+	} else {
+		image, err = h.saveImageToFileSystem(req.Image, userUID, req.EntryID, imageLimit)
+	}
+	if errors.Is(err, errMediaTooLarge) {
+		respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Image is too large: "+err.Error())
+		return
+	}
+	if errors.Is(err, errUnsupportedMediaType) {
+		respondError(c, http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia, "Unsupported media type")
+		return
+	}
 	if err != nil {
 		h.logError(c, err, "save image to filesystem failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image: " + err.Error()})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save image: " + err.Error())
 		return
 	}
+	defer image.Discard()
+	imageURL := image.URL
+
+	mimeType := imageMimeType(filepath.Ext(imageURL))
+
+	// A failed conversion only costs the smaller copy; the original is still served
+	webp, err := h.webp.transcodeToWebP(ctx, image)
+	if err != nil {
+		h.logError(c, err, "webp transcode failed")
+		webp = nil
+	}
+	defer webp.Discard()
+	var webpURL string
+	var webpURLValue *string
+	if webp != nil {
+		webpURL = webp.URL
+		webpURLValue = &webpURL
+	}
 
 	// Get the current highest upload_order for this entry to set the new order
 	var maxOrder int
@@ -82,20 +151,16 @@ func (h *EntryHandler) AddImage(c *gin.Context) {
 	`
 	err = h.postgres.QueryRow(ctx, orderQuery, req.EntryID).Scan(&maxOrder)
 	if err != nil {
-		// Clean up the saved file on error
-		os.Remove(imageURL)
 		h.logError(c, err, "determine image order failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to determine image order"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to determine image order")
 		return
 	}
 
 	// Start database transaction
 	tx, err := h.postgres.Begin(ctx)
 	if err != nil {
-		// Clean up the saved file on error
-		os.Remove(imageURL)
 		h.logError(c, err, "begin transaction failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start database transaction"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
 		return
 	}
 	defer tx.Rollback(ctx)
@@ -104,15 +169,13 @@ func (h *EntryHandler) AddImage(c *gin.Context) {
 	now := time.Now()
 	newOrder := maxOrder + 1
 	imageQuery := `
-		INSERT INTO images (entry_id, url, upload_order, created_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO images (entry_id, url, upload_order, created_at, mime_type, webp_url)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`
-	_, err = tx.Exec(ctx, imageQuery, req.EntryID, imageURL, newOrder, now)
+	_, err = tx.Exec(ctx, imageQuery, req.EntryID, imageURL, newOrder, now, mimeType, webpURLValue)
 	if err != nil {
-		// Clean up the saved file on error
-		os.Remove(imageURL)
 		h.logError(c, err, "insert image failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add image"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to add image")
 		return
 	}
 
@@ -122,38 +185,51 @@ func (h *EntryHandler) AddImage(c *gin.Context) {
 	`
 	_, err = tx.Exec(ctx, updateEntryQuery, now, req.EntryID)
 	if err != nil {
-		// Clean up the saved file on error
-		os.Remove(imageURL)
 		h.logError(c, err, "update entry timestamp failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry timestamp"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
 		return
 	}
 
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
-		// Clean up the saved file on error
-		os.Remove(imageURL)
 		h.logError(c, err, "commit image tx failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save image")
+		return
+	}
+
+	// Store the files. If that fails the row would point at nothing, so drop it.
+	if err := image.Commit(ctx); err != nil {
+		h.logError(c, err, "store image failed")
+		_, _ = h.postgres.Exec(ctx, `DELETE FROM images WHERE entry_id = $1 AND url = $2`, req.EntryID, imageURL)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save image")
 		return
 	}
+	// Serving checks the variant exists, so a missing one falls back to the original
+	if err := webp.Commit(ctx); err != nil {
+		h.logError(c, err, "store webp variant failed")
+		webpURL = ""
+	}
 
 	// Invalidate Redis cache for this entry
 	redisKey := "entry:" + req.EntryID
-	h.redis.Del(ctx, redisKey)
+	h.cache.Del(ctx, redisKey)
+	invalidateAccountDetails(ctx, h.cache, userUID)
 
 	// Create response
 	response := addimagemodels.AddImageResponse{
 		EntryID:  req.EntryID,
 		ImageURL: imageURL,
+		WebPURL:  webpURL,
+		MimeType: mimeType,
 		Message:  "Image added successfully",
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-// saveImageToFileSystem saves the base64 encoded image to the file system
-func (h *EntryHandler) saveImageToFileSystem(base64Image, userUID, entryID string) (string, error) {
+// saveImageToFileSystem decodes the base64 encoded image straight to a staged file,
+// rejecting images larger than maxBytes once decoded
+func (h *EntryHandler) saveImageToFileSystem(base64Image, userUID, entryID string, maxBytes int64) (*stagedMedia, error) {
 	// Strip data URL prefix if present (e.g., "data:image/png;base64,")
 	if strings.Contains(base64Image, ",") {
 		parts := strings.Split(base64Image, ",")
@@ -162,59 +238,10 @@ func (h *EntryHandler) saveImageToFileSystem(base64Image, userUID, entryID strin
 		}
 	}
 
-	// Decode base64 image
-	imageData, err := base64.StdEncoding.DecodeString(base64Image)
-	if err != nil {
-		return "", fmt.Errorf("failed to decode base64 image: %w", err)
-	}
-
-	// Detect file extension from image data
-	var ext string
-	if len(imageData) >= 4 {
-		// Check for common image format signatures
-		switch {
-		case imageData[0] == 0xFF && imageData[1] == 0xD8 && imageData[2] == 0xFF:
-			ext = ".jpg"
-		case imageData[0] == 0x89 && imageData[1] == 0x50 && imageData[2] == 0x4E && imageData[3] == 0x47:
-			ext = ".png"
-		case imageData[0] == 0x47 && imageData[1] == 0x49 && imageData[2] == 0x46:
-			ext = ".gif"
-		case imageData[0] == 0x52 && imageData[1] == 0x49 && imageData[2] == 0x46 && imageData[3] == 0x46:
-			ext = ".webp"
-		default:
-			ext = ".jpg" // Default to jpg if format is unknown
-		}
-	} else {
-		ext = ".jpg"
+	if err := checkBase64Size(base64Image, maxBytes); err != nil {
+		return nil, err
 	}
 
-	// Create directory structure: internal/images/{userUID}/{entryID}/
-	userDir := filepath.Join("internal", "images", userUID)
-	entryDir := filepath.Join(userDir, entryID)
-
-	// Create user directory if it doesn't exist
-	if err := os.MkdirAll(userDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create user directory: %w", err)
-	}
-
-	// Create entry directory if it doesn't exist
-	if err := os.MkdirAll(entryDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create entry directory: %w", err)
-	}
-
-	// Generate unique filename
-	imageID := uuid.New().String()
-	filename := imageID + ext
-	filePath := filepath.Join(entryDir, filename)
-
-	// Write image data to file
-	if err := os.WriteFile(filePath, imageData, 0644); err != nil {
-		return "", fmt.Errorf("failed to write image file: %w", err)
-	}
-
-	// Return the URL path for accessing the image
-	// This will be served by the static file server we'll add to main.go
-	imageURL := fmt.Sprintf("/images/%s/%s/%s", userUID, entryID, filename)
-
-	return imageURL, nil
-}
\ No newline at end of file
+	decoder := base64.NewDecoder(base64.StdEncoding, strings.NewReader(base64Image))
+	return stageMediaFile(h.media, decoder, "images", imageExtension, userUID, entryID, maxBytes)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"io.winapps.journeyapp/internal/apierror"
 	addlocationmodels "io.winapps.journeyapp/internal/models/add_location"
 )
 
@@ -14,26 +15,26 @@ import (
 func (h *EntryHandler) AddLocation(c *gin.Context) {
 	var req addlocationmodels.AddLocationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Validate required fields
 	if req.EntryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Entry ID is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
 		return
 	}
 
@@ -46,12 +47,12 @@ func (h *EntryHandler) AddLocation(c *gin.Context) {
 	`
 	err := h.postgres.QueryRow(ctx, entryCheckQuery, req.EntryID, userUID).Scan(&entryExists)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify entry"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
 		return
 	}
 
 	if !entryExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found or access denied"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
 		return
 	}
 
@@ -63,20 +64,41 @@ func (h *EntryHandler) AddLocation(c *gin.Context) {
 		`
 		err = h.postgres.QueryRow(ctx, locationCheckQuery, req.EntryID, req.Location.Latitude, req.Location.Longitude).Scan(&locationExists)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing location"})
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check existing location")
 			return
 		}
 
 		if locationExists {
-			c.JSON(http.StatusConflict, gin.H{"error": "Location with these coordinates already exists for this entry"})
+			respondError(c, http.StatusConflict, apierror.CodeConflict, "Location with these coordinates already exists for this entry")
 			return
 		}
 	}
 
+	// Reuse the address of a nearby place the user already saved so the same spot
+	// doesn't show up under several slightly different names. Coordinates are kept.
+	snapped := false
+	if req.Location.Latitude != 0 && req.Location.Longitude != 0 {
+		nearby, err := h.findNearbyLocation(ctx, userUID, req.Location.Latitude, req.Location.Longitude)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check nearby locations")
+			return
+		}
+		if nearby != nil {
+			req.Location.Address = nearby.Address
+			req.Location.City = nearby.City
+			req.Location.State = nearby.State
+			req.Location.Zip = nearby.Zip
+			req.Location.Country = nearby.Country
+			req.Location.CountryCode = nearby.CountryCode
+			req.Location.DisplayName = nearby.DisplayName
+			snapped = true
+		}
+	}
+
 	// Start database transaction
 	tx, err := h.postgres.Begin(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start database transaction"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
 		return
 	}
 	defer tx.Rollback(ctx)
@@ -101,7 +123,7 @@ func (h *EntryHandler) AddLocation(c *gin.Context) {
 		now,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add location"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to add location")
 		return
 	}
 
@@ -111,24 +133,27 @@ func (h *EntryHandler) AddLocation(c *gin.Context) {
 	`
 	_, err = tx.Exec(ctx, updateEntryQuery, now, req.EntryID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry timestamp"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
 		return
 	}
 
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save location"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save location")
 		return
 	}
 
 	// Invalidate Redis cache for this entry
 	redisKey := "entry:" + req.EntryID
-	h.redis.Del(ctx, redisKey)
+	h.cache.Del(ctx, redisKey)
+	h.invalidateUniqueLocationsCache(ctx, userUID)
+	invalidateAccountDetails(ctx, h.cache, userUID)
 
 	// Create response
 	response := addlocationmodels.AddLocationResponse{
 		EntryID:  req.EntryID,
 		Location: req.Location,
+		Snapped:  snapped,
 		Message:  "Location added successfully",
 	}
 
@@ -3,16 +3,14 @@ package handlers
 import (
 	"context"
 	"encoding/base64"
-	"fmt"
+	"errors"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 
 	firebaseauth "firebase.google.com/go/v4/auth"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 
+	"io.winapps.journeyapp/internal/apierror"
 	firebaseutil "io.winapps.journeyapp/internal/firebase"
 	addprofilemodels "io.winapps.journeyapp/internal/models/add_profile_pic"
 )
@@ -21,55 +19,69 @@ import (
 func (h *AuthHandler) AddProfilePic(c *gin.Context) {
 	var req addprofilemodels.AddProfilePicRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		if isBodyTooLarge(err) {
+			respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Request body is too large")
+			return
+		}
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok || userUID == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	ctx := context.Background()
 
 	var finalPhotoURL string
+	// An attached photo is staged and only stored once the user row is updated
+	var photo *stagedMedia
+	defer func() { photo.Discard() }()
 
 	if req.IsPhotoAttached {
 		// Expect a data URL/base64 payload in PhotoURL when the image is attached
 		if strings.TrimSpace(req.PhotoURL) == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Missing image data"})
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Missing image data")
 			return
 		}
 
-		relativeURL, absoluteURL, err := h.saveProfileImageToFileSystem(req.PhotoURL, userUID)
+		staged, err := h.saveProfileImageToFileSystem(req.PhotoURL, userUID)
+		if errors.Is(err, errMediaTooLarge) {
+			respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Image is too large: "+err.Error())
+			return
+		}
+		if errors.Is(err, errUnsupportedMediaType) {
+			respondError(c, http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia, "Unsupported media type")
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image: " + err.Error()})
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save image: " + err.Error())
 			return
 		}
+		photo = staged
+		absoluteURL := absoluteMediaURL(c, staged.URL)
 
 		// Update Firebase Auth photo URL
 		authClient, err := firebaseutil.GetAuthClient(h.firebaseApp)
 		if err != nil {
 			// Best effort: still proceed to update Postgres and respond
 			// but surface the error to the client
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize auth client"})
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to initialize auth client")
 			return
 		}
 
 		params := (&firebaseauth.UserToUpdate{}).PhotoURL(absoluteURL)
 		if _, err := authClient.UpdateUser(ctx, userUID, params); err != nil {
-			// If Firebase update fails, remove saved file to avoid orphaned storage
-			// Note: relativeURL is like /images/<uid>/profile/<file>
-			_ = os.Remove(strings.TrimPrefix(relativeURL, "/"))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update Firebase photo URL"})
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update Firebase photo URL")
 			return
 		}
 
@@ -77,7 +89,7 @@ func (h *AuthHandler) AddProfilePic(c *gin.Context) {
 	} else {
 		// Use provided external URL directly
 		if strings.TrimSpace(req.PhotoURL) == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "photoURL is required when isPhotoAttached is false"})
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "photoURL is required when isPhotoAttached is false")
 			return
 		}
 		finalPhotoURL = req.PhotoURL
@@ -90,13 +102,17 @@ func (h *AuthHandler) AddProfilePic(c *gin.Context) {
 		WHERE uid = $2
 	`
 	if _, err := h.postgres.Exec(ctx, updateQuery, finalPhotoURL, userUID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user photo URL"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update user photo URL")
+		return
+	}
+	if err := photo.Commit(ctx); err != nil {
+		h.logError(c, err, "store profile photo failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save image")
 		return
 	}
 
 	// Invalidate cached account details
-	cacheKey := fmt.Sprintf("account_details:%s", userUID)
-	_ = h.redis.Del(ctx, cacheKey).Err()
+	invalidateAccountDetails(ctx, h.cache, userUID)
 
 	resp := addprofilemodels.AddProfilePicResponse{
 		Success:  true,
@@ -106,8 +122,9 @@ func (h *AuthHandler) AddProfilePic(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-// saveProfileImageToFileSystem saves a base64 image to internal/images/<uid>/profile/ and returns both relative and absolute URLs
-func (h *AuthHandler) saveProfileImageToFileSystem(base64Image, userUID string) (string, string, error) {
+// saveProfileImageToFileSystem stages a base64 image for images/<uid>/profile/.
+// Its URL is relative; callers build the stored absolute URL with absoluteMediaURL.
+func (h *AuthHandler) saveProfileImageToFileSystem(base64Image, userUID string) (*stagedMedia, error) {
 	// Strip data URL prefix if present (e.g., "data:image/png;base64,")
 	if strings.Contains(base64Image, ",") {
 		parts := strings.Split(base64Image, ",")
@@ -116,54 +133,11 @@ func (h *AuthHandler) saveProfileImageToFileSystem(base64Image, userUID string)
 		}
 	}
 
-	// Decode base64 image
-	imageData, err := base64.StdEncoding.DecodeString(base64Image)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to decode base64 image: %w", err)
+	if err := checkBase64Size(base64Image, maxProfilePicBytes); err != nil {
+		return nil, err
 	}
 
-	// Detect file extension from image data
-	var ext string
-	if len(imageData) >= 4 {
-		switch {
-		case imageData[0] == 0xFF && imageData[1] == 0xD8 && imageData[2] == 0xFF:
-			ext = ".jpg"
-		case imageData[0] == 0x89 && imageData[1] == 0x50 && imageData[2] == 0x4E && imageData[3] == 0x47:
-			ext = ".png"
-		case imageData[0] == 0x47 && imageData[1] == 0x49 && imageData[2] == 0x46:
-			ext = ".gif"
-		case imageData[0] == 0x52 && imageData[1] == 0x49 && imageData[2] == 0x46 && imageData[3] == 0x46:
-			ext = ".webp"
-		default:
-			ext = ".jpg"
-		}
-	} else {
-		ext = ".jpg"
-	}
-
-	// Create directory structure: internal/images/{userUID}/profile/
-	userDir := filepath.Join("internal", "images", userUID)
-	profileDir := filepath.Join(userDir, "profile")
-
-	if err := os.MkdirAll(profileDir, 0755); err != nil {
-		return "", "", fmt.Errorf("failed to create profile directory: %w", err)
-	}
-
-	// Generate unique filename
-	imageID := uuid.New().String()
-	filename := imageID + ext
-	filePath := filepath.Join(profileDir, filename)
-
-	// Write image data to file
-	if err := os.WriteFile(filePath, imageData, 0644); err != nil {
-		return "", "", fmt.Errorf("failed to write image file: %w", err)
-	}
-
-	// Relative URL served by static file server
-	relativeURL := fmt.Sprintf("/images/%s/profile/%s", userUID, filename)
-
-	// Absolute URL for public access (as specified)
-	absoluteURL := fmt.Sprintf("https://journey-app-api.winapps.dev%s", relativeURL)
-
-	return relativeURL, absoluteURL, nil
-}
\ No newline at end of file
+	// Served by ServeProfileImage from /images/<uid>/profile/<file>
+	decoder := base64.NewDecoder(base64.StdEncoding, strings.NewReader(base64Image))
+	return stageMediaFile(h.media, decoder, "images", imageExtension, userUID, "profile", maxProfilePicBytes)
+}
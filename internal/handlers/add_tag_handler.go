@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"io.winapps.journeyapp/internal/apierror"
 	addtagmodels "io.winapps.journeyapp/internal/models/add_tag"
 )
 
@@ -14,31 +15,35 @@ import (
 func (h *EntryHandler) AddTag(c *gin.Context) {
 	var req addtagmodels.AddTagRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Validate required fields
 	if req.EntryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Entry ID is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
 		return
 	}
 
 	if req.Tag.Key == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Tag key is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Tag key is required")
+		return
+	}
+	if err := validateTag("tag", req.Tag); err != nil {
+		respondFieldTooLong(c, err)
 		return
 	}
 
@@ -51,12 +56,18 @@ func (h *EntryHandler) AddTag(c *gin.Context) {
 	`
 	err := h.postgres.QueryRow(ctx, entryCheckQuery, req.EntryID, userUID).Scan(&entryExists)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify entry"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
 		return
 	}
 
 	if !entryExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found or access denied"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+		return
+	}
+
+	normalize, err := tagNormalizationEnabled(ctx, h.postgres, userUID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load settings")
 		return
 	}
 
@@ -65,21 +76,30 @@ func (h *EntryHandler) AddTag(c *gin.Context) {
 	tagCheckQuery := `
 		SELECT EXISTS(SELECT 1 FROM tags WHERE entry_id = $1 AND key = $2 AND value = $3)
 	`
-	err = h.postgres.QueryRow(ctx, tagCheckQuery, req.EntryID, req.Tag.Key, req.Tag.Value).Scan(&tagExists)
+	tagCheckArgs := []interface{}{req.EntryID, req.Tag.Key, req.Tag.Value}
+	if normalize {
+		// Any case variant of the key counts, whatever its value
+		req.Tag.Key = normalizeTagKey(req.Tag.Key)
+		tagCheckQuery = `
+			SELECT EXISTS(SELECT 1 FROM tags WHERE entry_id = $1 AND LOWER(TRIM(key)) = $2)
+		`
+		tagCheckArgs = []interface{}{req.EntryID, req.Tag.Key}
+	}
+	err = h.postgres.QueryRow(ctx, tagCheckQuery, tagCheckArgs...).Scan(&tagExists)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing tag"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check existing tag")
 		return
 	}
 
 	if tagExists {
-		c.JSON(http.StatusConflict, gin.H{"error": "Tag already exists for this entry"})
+		respondError(c, http.StatusConflict, apierror.CodeConflict, "Tag already exists for this entry")
 		return
 	}
 
 	// Start database transaction
 	tx, err := h.postgres.Begin(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start database transaction"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
 		return
 	}
 	defer tx.Rollback(ctx)
@@ -92,7 +112,7 @@ func (h *EntryHandler) AddTag(c *gin.Context) {
 	`
 	_, err = tx.Exec(ctx, tagQuery, req.EntryID, req.Tag.Key, req.Tag.Value, now)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add tag"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to add tag")
 		return
 	}
 
@@ -102,19 +122,20 @@ func (h *EntryHandler) AddTag(c *gin.Context) {
 	`
 	_, err = tx.Exec(ctx, updateEntryQuery, now, req.EntryID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry timestamp"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
 		return
 	}
 
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save tag"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save tag")
 		return
 	}
 
 	// Invalidate Redis cache for this entry
 	redisKey := "entry:" + req.EntryID
-	h.redis.Del(ctx, redisKey)
+	h.cache.Del(ctx, redisKey)
+	invalidateAccountDetails(ctx, h.cache, userUID)
 
 	// Create response
 	response := addtagmodels.AddTagResponse{
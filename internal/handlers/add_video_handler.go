@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	addvideomodels "io.winapps.journeyapp/internal/models/add_video"
+	"io.winapps.journeyapp/internal/premium"
+)
+
+// AddVideo handles adding a video clip to an existing journal entry. A poster frame is
+// stored next to the clip when ffmpeg is available.
+func (h *EntryHandler) AddVideo(c *gin.Context) {
+	// multipart/form-data carries the file in a "video" part plus an entryId field;
+	// anything else is a JSON body with base64 data
+	var req addvideomodels.AddVideoRequest
+	var upload *multipart.FileHeader
+	if isMultipartRequest(c) {
+		fileHeader, err := c.FormFile("video")
+		if err != nil {
+			if isBodyTooLarge(err) {
+				respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Request body is too large")
+				return
+			}
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Video file is required in the \"video\" field")
+			return
+		}
+		upload = fileHeader
+		req.EntryID = c.PostForm("entryId")
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		if isBodyTooLarge(err) {
+			respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Request body is too large")
+			return
+		}
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	// Get UID from context (set by auth middleware)
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+
+	userUID, ok := uid.(string)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
+		return
+	}
+
+	// Validate required fields
+	if req.EntryID == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
+		return
+	}
+
+	if req.Video == "" && upload == nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Video data is required")
+		return
+	}
+
+	ctx := context.Background()
+
+	// Verify entry exists and belongs to user
+	var entryExists bool
+	entryCheckQuery := `
+		SELECT EXISTS(SELECT 1 FROM entries WHERE id = $1 AND user_uid = $2)
+	`
+	err := h.postgres.QueryRow(ctx, entryCheckQuery, req.EntryID, userUID).Scan(&entryExists)
+	if err != nil {
+		h.logError(c, err, "verify entry failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
+		return
+	}
+
+	if !entryExists {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+		return
+	}
+
+	policy, err := premium.ForUser(ctx, h.postgres, userUID)
+	if err != nil {
+		h.logError(c, err, "plan lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify account")
+		return
+	}
+	var videoCount int
+	if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM videos WHERE entry_id = $1`, req.EntryID).Scan(&videoCount); err != nil {
+		h.logError(c, err, "count videos failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
+		return
+	}
+	if denial := policy.CheckVideos(videoCount); denial != nil {
+		respondPlanLimit(c, denial)
+		return
+	}
+	videoLimit := policy.Limits.MaxVideoBytes
+
+	// Process and save the video; it is only stored once its row is committed
+	var video *stagedMedia
+	if upload != nil {
+		video, err = saveUploadedMedia(h.media, upload, "videos", videoExtension, userUID, req.EntryID, videoLimit)
+	} else {
+		video, err = h.saveVideoToFileSystem(req.Video, userUID, req.EntryID, videoLimit)
+	}
+	if errors.Is(err, errMediaTooLarge) {
+		respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Video is too large: "+err.Error())
+		return
+	}
+	if errors.Is(err, errUnsupportedMediaType) {
+		respondError(c, http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia, "Unsupported media type")
+		return
+	}
+	if err != nil {
+		h.logError(c, err, "save video to filesystem failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save video")
+		return
+	}
+	defer video.Discard()
+	videoURL := video.URL
+
+	mimeType := videoMimeType(filepath.Ext(videoURL))
+	fileSize, err := video.size()
+	if err != nil {
+		h.logError(c, err, "stat staged video failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save video")
+		return
+	}
+
+	// Duration and poster are extras; a clip ffmpeg can't read is still stored as uploaded
+	duration, err := h.video.probeDuration(ctx, video)
+	if err != nil {
+		h.logError(c, err, "probe video duration failed")
+		duration = 0
+	}
+	poster, err := h.video.generatePoster(ctx, video, duration)
+	if err != nil {
+		h.logError(c, err, "generate video poster failed")
+		poster = nil
+	}
+	defer poster.Discard()
+	var thumbnailURL string
+	var thumbnailURLValue *string
+	if poster != nil {
+		thumbnailURL = poster.URL
+		thumbnailURLValue = &thumbnailURL
+	}
+	var durationValue *int
+	if duration > 0 {
+		durationValue = &duration
+	}
+
+	// Get the current highest upload_order for this entry to set the new order
+	var maxOrder int
+	orderQuery := `
+		SELECT COALESCE(MAX(upload_order), -1) FROM videos WHERE entry_id = $1
+	`
+	err = h.postgres.QueryRow(ctx, orderQuery, req.EntryID).Scan(&maxOrder)
+	if err != nil {
+		h.logError(c, err, "determine video order failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to determine video order")
+		return
+	}
+
+	// Start database transaction
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		h.logError(c, err, "begin transaction failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Insert new video with URL
+	now := time.Now()
+	newOrder := maxOrder + 1
+	videoQuery := `
+		INSERT INTO videos (entry_id, url, file_size, mime_type, duration, thumbnail_url, upload_order, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err = tx.Exec(ctx, videoQuery, req.EntryID, videoURL, fileSize, mimeType, durationValue, thumbnailURLValue, newOrder, now)
+	if err != nil {
+		h.logError(c, err, "insert video failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to add video")
+		return
+	}
+
+	// Update entry's updated_at timestamp
+	updateEntryQuery := `
+		UPDATE entries SET updated_at = $1 WHERE id = $2
+	`
+	_, err = tx.Exec(ctx, updateEntryQuery, now, req.EntryID)
+	if err != nil {
+		h.logError(c, err, "update entry timestamp failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
+		return
+	}
+
+	// Commit transaction
+	if err = tx.Commit(ctx); err != nil {
+		h.logError(c, err, "commit video tx failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save video")
+		return
+	}
+
+	// Store the files. If that fails the row would point at nothing, so drop it.
+	if err := video.Commit(ctx); err != nil {
+		h.logError(c, err, "store video failed")
+		_, _ = h.postgres.Exec(ctx, `DELETE FROM videos WHERE entry_id = $1 AND url = $2`, req.EntryID, videoURL)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save video")
+		return
+	}
+	// Without its poster the clip still plays; clients fall back to their own placeholder
+	if err := poster.Commit(ctx); err != nil {
+		h.logError(c, err, "store video poster failed")
+		_, _ = h.postgres.Exec(ctx, `UPDATE videos SET thumbnail_url = NULL WHERE entry_id = $1 AND url = $2`, req.EntryID, videoURL)
+		thumbnailURL = ""
+	}
+
+	// Invalidate Redis cache for this entry
+	redisKey := "entry:" + req.EntryID
+	h.cache.Del(ctx, redisKey)
+	invalidateAccountDetails(ctx, h.cache, userUID)
+
+	// Create response
+	response := addvideomodels.AddVideoResponse{
+		EntryID:      req.EntryID,
+		VideoURL:     videoURL,
+		ThumbnailURL: thumbnailURL,
+		Duration:     duration,
+		Message:      "Video added successfully",
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// saveVideoToFileSystem decodes the base64 encoded video straight to a staged file,
+// rejecting videos larger than maxBytes once decoded
+func (h *EntryHandler) saveVideoToFileSystem(base64Video, userUID, entryID string, maxBytes int64) (*stagedMedia, error) {
+	// Strip data URL prefix if present (e.g., "data:video/mp4;base64,")
+	if _, data, found := strings.Cut(base64Video, ","); found {
+		base64Video = data
+	}
+
+	if err := checkBase64Size(base64Video, maxBytes); err != nil {
+		return nil, err
+	}
+
+	decoder := base64.NewDecoder(base64.StdEncoding, strings.NewReader(base64Video))
+	return stageMediaFile(h.media, decoder, "videos", videoExtension, userUID, entryID, maxBytes)
+}
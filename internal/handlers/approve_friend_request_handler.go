@@ -6,32 +6,34 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
 )
 
 func (h *UsersHandler) ApproveFriendRequest(c *gin.Context) {
 	// Require auth
 	uidVal, ok := c.Get("uid")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 	authUID, _ := uidVal.(string)
 
 	var req friendshipRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request body")
 		return
 	}
 	req.UID = strings.TrimSpace(req.UID)
 	req.FID = strings.TrimSpace(req.FID)
 	if req.UID == "" || req.FID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "uid and fid are required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "uid and fid are required")
 		return
 	}
 
 	// Only involved users can approve
 	if authUID != req.UID && authUID != req.FID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to approve this request"})
+		respondError(c, http.StatusForbidden, apierror.CodeForbidden, "Not authorized to approve this request")
 		return
 	}
 
@@ -39,20 +41,28 @@ func (h *UsersHandler) ApproveFriendRequest(c *gin.Context) {
 	res, err := h.postgres.Exec(ctx, `
 		UPDATE friendships
 		SET status = 'approved'
-		WHERE (uid = $1 AND fid = $2) OR (uid = $2 AND fid = $1)
+		WHERE ((uid = $1 AND fid = $2) OR (uid = $2 AND fid = $1)) AND status <> 'blocked'
 	`, req.UID, req.FID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update friendship"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update friendship")
 		return
 	}
 	if res.RowsAffected() == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Friendship not found"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Friendship not found")
 		return
 	}
 
 	// Invalidate caches
-	_ = h.redis.Del(ctx, "friends:"+req.UID).Err()
-	_ = h.redis.Del(ctx, "friends:"+req.FID).Err()
+	h.invalidateRelationshipCaches(ctx, req.UID, req.FID)
+
+	// Tell the other side of the friendship that the request was accepted
+	requesterUID := req.UID
+	if requesterUID == authUID {
+		requesterUID = req.FID
+	}
+	h.notifyAsync("friend_request_accepted", requesterUID, func(ns *NotificationsHandler) error {
+		return ns.SendFriendRequestAcceptedNotification(requesterUID, authUID)
+	})
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "status": "approved"})
 }
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// attachmentTypes maps the extensions accepted for entry attachments to the type they are
+// served with
+var attachmentTypes = map[string]string{
+	".pdf":    "application/pdf",
+	".doc":    "application/msword",
+	".docx":   "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xls":    "application/vnd.ms-excel",
+	".xlsx":   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".ppt":    "application/vnd.ms-powerpoint",
+	".pptx":   "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".odt":    "application/vnd.oasis.opendocument.text",
+	".ods":    "application/vnd.oasis.opendocument.spreadsheet",
+	".odp":    "application/vnd.oasis.opendocument.presentation",
+	".epub":   "application/epub+zip",
+	".pkpass": "application/vnd.apple.pkpass",
+	".zip":    "application/zip",
+	".rtf":    "application/rtf",
+	".txt":    "text/plain; charset=utf-8",
+	".csv":    "text/csv; charset=utf-8",
+	".md":     "text/markdown; charset=utf-8",
+	".ics":    "text/calendar; charset=utf-8",
+}
+
+// maxAttachmentFilenameLength matches attachments.filename VARCHAR(255)
+const maxAttachmentFilenameLength = 255
+
+// attachmentMimeType is the type an attachment with extension ext is served with
+func attachmentMimeType(ext string) string {
+	if t, ok := attachmentTypes[strings.ToLower(ext)]; ok {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// attachmentExtension returns a detector for stageMediaFile that accepts data when the
+// uploaded filename has an allowed extension and the leading bytes agree with it. Formats
+// with a signature must carry it; text formats must not look binary.
+func attachmentExtension(filename string) func([]byte) (string, bool) {
+	ext := strings.ToLower(path.Ext(filename))
+	return func(data []byte) (string, bool) {
+		if _, ok := attachmentTypes[ext]; !ok {
+			return "", false
+		}
+		var ok bool
+		switch ext {
+		case ".pdf":
+			ok = bytes.HasPrefix(data, []byte("%PDF-"))
+		case ".docx", ".xlsx", ".pptx", ".odt", ".ods", ".odp", ".epub", ".pkpass", ".zip":
+			ok = bytes.HasPrefix(data, []byte("PK\x03\x04"))
+		case ".doc", ".xls", ".ppt":
+			ok = bytes.HasPrefix(data, []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")) // OLE compound file
+		case ".rtf":
+			ok = bytes.HasPrefix(data, []byte(`{\rtf`))
+		default:
+			ok = looksLikeText(data)
+		}
+		return ext, ok
+	}
+}
+
+// looksLikeText reports whether data could be the start of a text file: no NUL bytes and
+// none of the signatures attachmentExtension knows
+func looksLikeText(data []byte) bool {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return false
+	}
+	if _, ok := imageExtension(data); ok {
+		return false
+	}
+	for _, sig := range []string{"%PDF-", "PK\x03\x04", "\xD0\xCF\x11\xE0"} {
+		if bytes.HasPrefix(data, []byte(sig)) {
+			return false
+		}
+	}
+	return true
+}
+
+// cleanAttachmentFilename reduces an uploaded filename to a display name: the last path
+// element, without control characters, at most maxAttachmentFilenameLength characters and
+// keeping its extension when shortened
+func cleanAttachmentFilename(name string) string {
+	name = strings.ReplaceAll(name, `\`, "/")
+	name = path.Base(strings.TrimSpace(name))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "." || name == "/" {
+		return ""
+	}
+	if utf8.RuneCountInString(name) > maxAttachmentFilenameLength {
+		ext := path.Ext(name)
+		base := []rune(strings.TrimSuffix(name, ext))
+		name = string(base[:maxAttachmentFilenameLength-utf8.RuneCountInString(ext)]) + ext
+	}
+	return name
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	firebase "firebase.google.com/go/v4"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	"io.winapps.journeyapp/internal/cache"
+	"io.winapps.journeyapp/internal/encryption"
+	"io.winapps.journeyapp/internal/storage"
+	"io.winapps.journeyapp/internal/subscriptions"
+)
+
+// AuthHandler serves the /auth routes (login, sessions, account, export and import endpoints)
+type AuthHandler struct {
+	firebaseApp *firebase.App
+	postgres    *pgxpool.Pool
+	cache       cache.Store
+	logger      *zap.SugaredLogger
+	media       storage.Store
+	entryKeys   *encryption.Keyring
+
+	// exportCtx is cancelled on shutdown so running export and import jobs abort; exportJobs tracks them
+	exportCtx     context.Context
+	cancelExports context.CancelFunc
+	exportJobs    sync.WaitGroup
+
+	// authClient verifies and revokes Firebase sessions; nil means the app's auth client
+	authClient firebaseAuth
+
+	// subscriptions verifies store receipts; nil until SetSubscriptionVerifier is called
+	subscriptions    subscriptions.Verifier
+	subscriptionCron *cron.Cron
+}
+
+// NewAuthHandler creates a new authentication handler
+func NewAuthHandler(firebaseApp *firebase.App, postgres *pgxpool.Pool, store cache.Store, logger *zap.SugaredLogger) *AuthHandler {
+	exportCtx, cancelExports := context.WithCancel(context.Background())
+	return &AuthHandler{
+		firebaseApp:   firebaseApp,
+		postgres:      postgres,
+		cache:         store,
+		logger:        logger,
+		media:         storage.NewLocal(storage.DefaultLocalRoot),
+		exportCtx:     exportCtx,
+		cancelExports: cancelExports,
+	}
+}
+
+// SetMediaStore replaces the default local media storage, e.g. with an S3 bucket
+func (h *AuthHandler) SetMediaStore(store storage.Store) {
+	h.media = store
+}
+
+// SetEntryKeyring enables entry encryption, which users turn on through SetEntryEncryption
+func (h *AuthHandler) SetEntryKeyring(keys *encryption.Keyring) {
+	h.entryKeys = keys
+}
+
+// Shutdown cancels running export, import and subscription expiry jobs and waits for them to finish
+func (h *AuthHandler) Shutdown(ctx context.Context) error {
+	h.cancelExports()
+
+	done := make(chan struct{})
+	go func() {
+		if h.subscriptionCron != nil {
+			<-h.subscriptionCron.Stop().Done()
+		}
+		h.exportJobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("export jobs did not stop: %w", ctx.Err())
+	}
+}
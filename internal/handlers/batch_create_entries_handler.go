@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/middleware"
+	models "io.winapps.journeyapp/internal/models/account"
+	batchmodels "io.winapps.journeyapp/internal/models/batch_create_entries"
+	"io.winapps.journeyapp/internal/premium"
+)
+
+const maxBatchCreateEntries = 100
+
+// BatchCreateEntries creates several entries in one request for clients syncing offline
+// work. Each entry is validated like CreateEntry and written under its own savepoint in a
+// single transaction, so one bad entry is reported without discarding the others.
+func (h *EntryHandler) BatchCreateEntries(c *gin.Context) {
+	var req batchmodels.BatchCreateEntriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	if len(req.Entries) == 0 {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "At least one entry is required")
+		return
+	}
+	if len(req.Entries) > maxBatchCreateEntries {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, fmt.Sprintf("A batch may contain at most %d entries", maxBatchCreateEntries))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	normalize, err := tagNormalizationEnabled(ctx, h.postgres, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "settings lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load settings")
+		return
+	}
+	if normalize {
+		for i := range req.Entries {
+			req.Entries[i].Tags = normalizeTagList(req.Entries[i].Tags)
+		}
+	}
+
+	// Only look up verification when the batch publishes or shares something
+	emailVerified := false
+	for _, e := range req.Entries {
+		if entryReachesOthers(normalizeEntryVisibility(e.Visibility), len(e.SharedWith)) {
+			verified, err := middleware.IsEmailVerified(ctx, h.postgres, userUID)
+			if err != nil {
+				if abortOnContextError(c, err) {
+					return
+				}
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check email verification")
+				return
+			}
+			emailVerified = verified
+			break
+		}
+	}
+
+	policy, err := premium.ForUser(ctx, h.postgres, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "plan lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify account")
+		return
+	}
+	var entryCount int
+	if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM entries WHERE user_uid = $1`, userUID).Scan(&entryCount); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "count entries failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify account")
+		return
+	}
+
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	cipher := newEntryCipher(h.postgres, h.entryKeys)
+	if err := cipher.lockUser(ctx, tx, userUID); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "encryption setting lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save entries")
+		return
+	}
+	encrypted, _ := cipher.enabled(ctx, userUID)
+
+	now := time.Now()
+	results := make([]batchmodels.BatchCreateResult, len(req.Entries))
+	created := make([]*models.Entry, 0, len(req.Entries))
+	createdShares := make([][]string, 0, len(req.Entries))
+
+	for i, item := range req.Entries {
+		results[i] = batchmodels.BatchCreateResult{Index: i}
+		fail := func(code, msg string) {
+			results[i].Code = code
+			results[i].Error = msg
+		}
+
+		if item.Title == "" {
+			fail(apierror.CodeValidation, "Title is required")
+			continue
+		}
+		if err := validateEntryInput(item.Title, item.Description, item.Tags); err != nil {
+			fail(apierror.CodeValidation, err.Error())
+			results[i].Field = err.Field
+			continue
+		}
+		if err := validateEntryImages(userUID, item.Images); err != nil {
+			fail(apierror.CodeValidation, err.Error())
+			results[i].Field = err.Field
+			continue
+		}
+		visibility := normalizeEntryVisibility(item.Visibility)
+		if entryReachesOthers(visibility, len(item.SharedWith)) && !emailVerified {
+			fail(apierror.CodeEmailNotVerified, "Email address must be verified")
+			continue
+		}
+		if visibility == "semi-private" {
+			invalid, err := h.checkShareTargets(ctx, userUID, item.SharedWith)
+			if err != nil {
+				if abortOnContextError(c, err) {
+					return
+				}
+				h.logError(c, err, "share target lookup failed", "index", i)
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save entries")
+				return
+			}
+			if invalid != nil {
+				fail(apierror.CodeValidation, invalid.Error())
+				results[i].Field = invalid.Field
+				continue
+			}
+		}
+		if denial := policy.CheckEntries(entryCount + len(created)); denial != nil {
+			fail(apierror.CodePlanLimit, denial.Error())
+			continue
+		}
+		if len(item.Images) > 0 {
+			if denial := policy.CheckImages(len(item.Images) - 1); denial != nil {
+				fail(apierror.CodePlanLimit, denial.Error())
+				continue
+			}
+		}
+
+		entryID := uuid.New().String()
+		if rejection := h.filterEntryText(c, userUID, entryID, visibility, &item.Title, &item.Description); rejection != nil {
+			fail(apierror.CodeContentRejected, rejection.Error())
+			results[i].Field = rejection.Field
+			continue
+		}
+
+		text, err := cipher.seal(ctx, userUID, item.Title, item.Description)
+		if err != nil {
+			h.logError(c, err, "entry encryption failed", "index", i)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save entries")
+			return
+		}
+
+		// A savepoint per entry, so a failed insert only rolls back that entry
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "batch savepoint failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save entries")
+			return
+		}
+		if err := insertEntryRecords(ctx, sp, entryID, userUID, item, text, visibility, entryStatusPublished, now); err != nil {
+			_ = sp.Rollback(ctx)
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "batch entry insert failed", "index", i)
+			fail(apierror.CodeInternal, entryWriteMessage(err))
+			continue
+		}
+		if err := sp.Commit(ctx); err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "batch savepoint release failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save entries")
+			return
+		}
+
+		results[i].Success = true
+		results[i].ID = entryID
+		created = append(created, &models.Entry{
+			ID:          entryID,
+			Title:       item.Title,
+			Description: item.Description,
+			Images:      item.Images,
+			Tags:        item.Tags,
+			Locations:   item.Locations,
+			Visibility:  visibility,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+		createdShares = append(createdShares, item.SharedWith)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "batch commit failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save entries")
+		return
+	}
+
+	if len(created) > 0 {
+		h.invalidateEntryStatsCache(ctx, userUID)
+		h.invalidateUniqueLocationsCache(ctx, userUID)
+		invalidateAccountDetails(ctx, h.cache, userUID)
+		bumpFeedVersion(ctx, h.cache, userUID)
+		for i, entry := range created {
+			h.cacheCreatedEntry(ctx, entry, encrypted, userUID, createdShares[i])
+			h.emitEntryCreated(userUID, entry, createdShares[i])
+		}
+	}
+
+	c.JSON(http.StatusOK, batchmodels.BatchCreateEntriesResponse{
+		Results: results,
+		Created: len(created),
+		Failed:  len(req.Entries) - len(created),
+	})
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	billingmodels "io.winapps.journeyapp/internal/models/billing_webhook"
+)
+
+const billingEventRedisKeyPrefix = "billing_event:"
+const billingEventTTL = 30 * 24 * time.Hour
+
+// BillingWebhook receives subscription lifecycle events from RevenueCat (or a Stripe
+// relay using the same envelope) and updates the mapped user's premium status.
+// The request must carry the shared BILLING_WEBHOOK_SECRET either as a bearer token
+// or as a hex HMAC-SHA256 of the raw body in the X-Signature header.
+func (h *AuthHandler) BillingWebhook(c *gin.Context) {
+	secret := os.Getenv("BILLING_WEBHOOK_SECRET")
+	if secret == "" {
+		h.logError(c, fmt.Errorf("BILLING_WEBHOOK_SECRET not set"), "billing webhook rejected")
+		respondError(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, "Billing webhook is not configured")
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Failed to read request body")
+		return
+	}
+
+	if !verifyBillingSignature(c, body, secret) {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid webhook signature")
+		return
+	}
+
+	var req billingmodels.BillingWebhookRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+	event := req.Event
+	event.ID = strings.TrimSpace(event.ID)
+	event.AppUserID = strings.TrimSpace(event.AppUserID)
+	if event.ID == "" || event.Type == "" || event.AppUserID == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "event.id, event.type and event.app_user_id are required")
+		return
+	}
+
+	ctx := context.Background()
+
+	// Claim the event ID so retries from the provider are not applied twice
+	eventKey := billingEventRedisKeyPrefix + event.ID
+	claimed, err := h.cache.SetNX(ctx, eventKey, event.Type, billingEventTTL)
+	if err != nil {
+		h.logError(c, err, "claim billing event failed", "event_id", event.ID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to record webhook event")
+		return
+	}
+	if !claimed {
+		c.JSON(http.StatusOK, billingmodels.BillingWebhookResponse{
+			Success: true,
+			Message: "Event already processed",
+			EventID: event.ID,
+			UID:     event.AppUserID,
+		})
+		return
+	}
+
+	isPremium, expiresAt, handled := premiumStateForEvent(event)
+	if !handled {
+		c.JSON(http.StatusOK, billingmodels.BillingWebhookResponse{
+			Success: true,
+			Message: "Event ignored",
+			EventID: event.ID,
+			UID:     event.AppUserID,
+		})
+		return
+	}
+	if isPremium && expiresAt == nil {
+		// users_premium_consistency requires an expiry for premium users
+		_ = h.cache.Del(ctx, eventKey)
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "expiration_at_ms is required for active subscriptions")
+		return
+	}
+
+	res, err := h.postgres.Exec(ctx, `
+		UPDATE users
+		SET is_premium = $1, premium_expires_at = $2, updated_at = NOW()
+		WHERE uid = $3
+	`, isPremium, expiresAt, event.AppUserID)
+	if err != nil {
+		_ = h.cache.Del(ctx, eventKey)
+		h.logError(c, err, "update premium status failed", "event_id", event.ID, "target_uid", event.AppUserID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update premium status")
+		return
+	}
+	if res.RowsAffected() == 0 {
+		_ = h.cache.Del(ctx, eventKey)
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "User not found")
+		return
+	}
+
+	// Invalidate caches that expose premium status
+	invalidateAccountDetails(ctx, h.cache, event.AppUserID)
+
+	c.JSON(http.StatusOK, billingmodels.BillingWebhookResponse{
+		Success:          true,
+		Message:          "Premium status updated",
+		EventID:          event.ID,
+		UID:              event.AppUserID,
+		IsPremium:        isPremium,
+		PremiumExpiresAt: expiresAt,
+	})
+}
+
+// verifyBillingSignature accepts either "Authorization: Bearer <secret>" (RevenueCat)
+// or "X-Signature: <hex hmac-sha256(body)>" (signed relays such as Stripe)
+func verifyBillingSignature(c *gin.Context, body []byte, secret string) bool {
+	if sig := strings.TrimSpace(c.GetHeader("X-Signature")); sig != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		expected := hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(strings.ToLower(sig)), []byte(expected))
+	}
+
+	auth := strings.TrimSpace(c.GetHeader("Authorization"))
+	auth = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	if auth == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(auth), []byte(secret)) == 1
+}
+
+// premiumStateForEvent maps a subscription event to the resulting premium state.
+// The returned expiry is nil whenever isPremium is false so writes always satisfy
+// the users_premium_consistency constraint.
+func premiumStateForEvent(event billingmodels.BillingEvent) (bool, *time.Time, bool) {
+	var expiresAt *time.Time
+	if event.ExpirationAtMs != nil && *event.ExpirationAtMs > 0 {
+		t := time.UnixMilli(*event.ExpirationAtMs).UTC()
+		expiresAt = &t
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(event.Type)) {
+	case "INITIAL_PURCHASE", "RENEWAL", "UNCANCELLATION", "PRODUCT_CHANGE", "NON_RENEWING_PURCHASE":
+		return true, expiresAt, true
+	case "CANCELLATION":
+		// Auto-renew was turned off; access continues until the paid period ends
+		if expiresAt != nil && expiresAt.After(time.Now()) {
+			return true, expiresAt, true
+		}
+		return false, nil, true
+	case "EXPIRATION":
+		return false, nil, true
+	default:
+		return false, nil, false
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"io.winapps.journeyapp/internal/apierror"
+)
+
+// BlockUser blocks fid on behalf of uid. A blocked relationship is stored as a single
+// friendships row (uid = blocker, fid = blocked) that replaces any existing friendship.
+// Entries either user shared with the other are unshared.
+func (h *UsersHandler) BlockUser(c *gin.Context) {
+	// Require auth
+	uidVal, ok := c.Get("uid")
+	if !ok {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	authUID, _ := uidVal.(string)
+
+	var req friendshipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request body")
+		return
+	}
+	req.UID = strings.TrimSpace(req.UID)
+	req.FID = strings.TrimSpace(req.FID)
+	if req.UID == "" || req.FID == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "uid and fid are required")
+		return
+	}
+	if req.UID != authUID {
+		respondError(c, http.StatusForbidden, apierror.CodeForbidden, "uid must match authenticated user")
+		return
+	}
+	if req.UID == req.FID {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Cannot block yourself")
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := h.postgres.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// If the other user already blocked us, keep their block in place
+	var blockedBy string
+	err = tx.QueryRow(ctx, `
+		SELECT uid FROM friendships
+		WHERE ((uid = $1 AND fid = $2) OR (uid = $2 AND fid = $1)) AND status = 'blocked'
+	`, req.UID, req.FID).Scan(&blockedBy)
+	if err == nil {
+		if blockedBy == req.UID {
+			c.JSON(http.StatusOK, gin.H{"success": true, "status": "blocked"})
+		} else {
+			respondError(c, http.StatusConflict, apierror.CodeConflict, "Relationship is already blocked")
+		}
+		return
+	}
+
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM friendships WHERE (uid = $1 AND fid = $2) OR (uid = $2 AND fid = $1)
+	`, req.UID, req.FID); err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to block user")
+		return
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO friendships (uid, fid, status, created_at)
+		VALUES ($1, $2, 'blocked', NOW())
+	`, req.UID, req.FID); err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to block user")
+		return
+	}
+	revoked, err := revokeSharesBetween(ctx, tx, req.UID, req.FID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to block user")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to block user")
+		return
+	}
+
+	h.invalidateRelationshipCaches(ctx, req.UID, req.FID)
+	h.invalidateRevokedShares(ctx, revoked)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "status": "blocked"})
+}
+
+// UnblockUser removes a block previously created by uid. Only the blocker can unblock.
+func (h *UsersHandler) UnblockUser(c *gin.Context) {
+	// Require auth
+	uidVal, ok := c.Get("uid")
+	if !ok {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	authUID, _ := uidVal.(string)
+
+	var req friendshipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request body")
+		return
+	}
+	req.UID = strings.TrimSpace(req.UID)
+	req.FID = strings.TrimSpace(req.FID)
+	if req.UID == "" || req.FID == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "uid and fid are required")
+		return
+	}
+	if req.UID != authUID {
+		respondError(c, http.StatusForbidden, apierror.CodeForbidden, "uid must match authenticated user")
+		return
+	}
+
+	ctx := c.Request.Context()
+	res, err := h.postgres.Exec(ctx, `
+		DELETE FROM friendships
+		WHERE uid = $1 AND fid = $2 AND status = 'blocked'
+	`, req.UID, req.FID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to unblock user")
+		return
+	}
+	if res.RowsAffected() == 0 {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Block not found")
+		return
+	}
+
+	h.invalidateRelationshipCaches(ctx, req.UID, req.FID)
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// invalidateRelationshipCaches clears cached friend lists, feeds and user searches for both users
+func (h *UsersHandler) invalidateRelationshipCaches(ctx context.Context, uid, fid string) {
+	h.invalidateFriendsCache(ctx, uid)
+	h.invalidateFriendsCache(ctx, fid)
+	_ = h.cache.Del(ctx, "feeds:"+uid, "feeds:"+fid)
+	h.invalidateSearchUsersCache(ctx, uid)
+	h.invalidateSearchUsersCache(ctx, fid)
+}
+
+// blockedUIDs returns the set of users that uid has blocked or been blocked by
+func blockedUIDs(ctx context.Context, db *pgxpool.Pool, uid string) (map[string]bool, error) {
+	rows, err := db.Query(ctx, `
+		SELECT CASE WHEN uid = $1 THEN fid ELSE uid END
+		FROM friendships
+		WHERE (uid = $1 OR fid = $1) AND status = 'blocked'
+	`, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocked := make(map[string]bool)
+	for rows.Next() {
+		var other string
+		if err := rows.Scan(&other); err != nil {
+			return nil, err
+		}
+		blocked[other] = true
+	}
+	return blocked, rows.Err()
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/cache"
+	searchusersmodels "io.winapps.journeyapp/internal/models/search_users"
+)
+
+// Requests that fail validation are answered before the database is touched
+func TestBlockUserValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &UsersHandler{}
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("uid", "alice") })
+	router.POST("/block-user", h.BlockUser)
+	router.POST("/unblock-user", h.UnblockUser)
+
+	tests := []struct {
+		path string
+		body string
+		want int
+	}{
+		{"/block-user", `not json`, http.StatusBadRequest},
+		{"/block-user", `{"uid":"alice","fid":" "}`, http.StatusBadRequest},
+		{"/block-user", `{"uid":"bob","fid":"carol"}`, http.StatusForbidden},
+		{"/block-user", `{"uid":"alice","fid":"alice"}`, http.StatusBadRequest},
+		{"/unblock-user", `{"uid":"","fid":"bob"}`, http.StatusBadRequest},
+		{"/unblock-user", `{"uid":"bob","fid":"alice"}`, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+		if w.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d", tt.path, tt.body, w.Code, tt.want)
+		}
+	}
+}
+
+func TestInvalidateRelationshipCaches(t *testing.T) {
+	ctx := context.Background()
+	store := cache.NewMemory(100)
+	h := &UsersHandler{cache: store}
+
+	cached := []string{"friends:alice:approved", "friends:bob:pending", "feeds:alice", "feeds:bob", "search_users:bob:q=al"}
+	for _, key := range append(cached, "feeds:carol") {
+		if err := store.Set(ctx, key, "x", time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h.invalidateRelationshipCaches(ctx, "alice", "bob")
+
+	for _, key := range cached {
+		if ok, _ := store.Exists(ctx, key); ok {
+			t.Errorf("%s still cached", key)
+		}
+	}
+	if ok, _ := store.Exists(ctx, "feeds:carol"); !ok {
+		t.Error("an unrelated user's feed was dropped")
+	}
+}
+
+// searchUsers runs SearchUsers for uid and returns the results
+func searchUsers(t *testing.T, h *UsersHandler, uid, query string) []searchusersmodels.SearchUserResult {
+	t.Helper()
+	w := callAs(uid, h.SearchUsers, http.MethodGet, "/search-users?search-query="+url.QueryEscape(query), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("SearchUsers: %d %s", w.Code, w.Body.String())
+	}
+	var resp searchusersmodels.SearchUsersResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Results
+}
+
+// A block in either direction hides the users from each other's searches and stops
+// message notifications between them
+func TestBlockedUsersAreHiddenFromSearchAndMessages(t *testing.T) {
+	pool := testPool(t)
+	store := cache.NewMemory(100)
+	h := NewUsersHandler(nil, pool, store, nil)
+	ns := &NotificationsHandler{db: pool, cache: store}
+	alice := testUser(t, pool, "alice")
+	blocker := testUser(t, pool, "blocker")
+	blocked := testUser(t, pool, "blocked")
+	other := testUser(t, pool, "other")
+	for _, uid := range []string{blocker, blocked, other} {
+		mustExec(t, pool, `UPDATE users SET display_name = $1 || ' ' || uid WHERE uid = $2`, alice, uid)
+	}
+
+	if w := callAs(blocker, h.BlockUser, http.MethodPost, "/block-user", `{"uid":"`+blocker+`","fid":"`+alice+`"}`); w.Code != http.StatusOK {
+		t.Fatalf("block alice: %d %s", w.Code, w.Body.String())
+	}
+	if w := callAs(alice, h.BlockUser, http.MethodPost, "/block-user", `{"uid":"`+alice+`","fid":"`+blocked+`"}`); w.Code != http.StatusOK {
+		t.Fatalf("alice blocks: %d %s", w.Code, w.Body.String())
+	}
+
+	results := searchUsers(t, h, alice, alice)
+	if len(results) != 1 || results[0].UID != other {
+		t.Errorf("alice's search = %+v, want only %s", results, other)
+	}
+	for _, uid := range []string{blocker, blocked} {
+		for _, r := range searchUsers(t, h, uid, alice) {
+			if r.UID == alice {
+				t.Errorf("%s found alice despite the block", uid)
+			}
+		}
+	}
+
+	ctx := context.Background()
+	members := []string{alice, blocker, blocked, other}
+	recipients, err := ns.messageRecipients(ctx, alice, members)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recipients) != 1 || recipients[0] != other {
+		t.Errorf("alice's message notifies %v, want only %s", recipients, other)
+	}
+	for _, sender := range []string{blocker, blocked} {
+		recipients, err := ns.messageRecipients(ctx, sender, members)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range recipients {
+			if r == alice || r == sender {
+				t.Errorf("%s's message notifies %s", sender, r)
+			}
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/metrics"
+	notificationsmodels "io.winapps.journeyapp/internal/models/notifications"
+)
+
+const (
+	broadcastCategoryAnnouncements = "announcements"
+	broadcastCategoryService       = "service"
+
+	// fcmMulticastBatchSize is the most tokens FCM accepts in one multicast
+	fcmMulticastBatchSize = 500
+	// expoSendBatchSize is the most messages Expo accepts in one send request
+	expoSendBatchSize = 100
+
+	maxBroadcastTitleLength = 100
+	maxBroadcastBodyLength  = 1000
+)
+
+// broadcastRecipient is an active push token matching a broadcast's segment
+type broadcastRecipient struct {
+	token  string
+	isExpo bool
+}
+
+// BroadcastNotification pushes an admin's message to every active push token in the
+// requested segment. Announcements skip users who turned them off in settings; service
+// notices go to everyone. The broadcast is logged in notification_broadcasts and the
+// response carries how many devices were targeted and how many sends succeeded or failed.
+func (ns *NotificationsHandler) BroadcastNotification(c *gin.Context) {
+	var req notificationsmodels.BroadcastNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "title and body are required")
+		return
+	}
+
+	req.Title = strings.TrimSpace(req.Title)
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Title == "" || req.Body == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "title and body are required")
+		return
+	}
+	if len([]rune(req.Title)) > maxBroadcastTitleLength || len([]rune(req.Body)) > maxBroadcastBodyLength {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation,
+			fmt.Sprintf("title is limited to %d characters and body to %d", maxBroadcastTitleLength, maxBroadcastBodyLength))
+		return
+	}
+	if req.Category == "" {
+		req.Category = broadcastCategoryAnnouncements
+	}
+	if req.Category != broadcastCategoryAnnouncements && req.Category != broadcastCategoryService {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "category must be announcements or service")
+		return
+	}
+	for i, p := range req.Segment.Platforms {
+		req.Segment.Platforms[i] = strings.ToLower(strings.TrimSpace(p))
+	}
+	for _, tz := range req.Segment.Timezones {
+		if _, err := time.LoadLocation(tz); err != nil {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid timezone "+tz)
+			return
+		}
+	}
+
+	// The app tells broadcasts apart by type; it can't be overridden
+	data := map[string]string{}
+	for k, v := range req.Data {
+		data[k] = v
+	}
+	data["type"] = "broadcast"
+	data["category"] = req.Category
+
+	// Sending can outlast the request timeout; finish it even if the admin disconnects
+	ctx := context.WithoutCancel(c.Request.Context())
+	senderUID := c.GetString("uid")
+
+	dataJSON, _ := json.Marshal(data)
+	segmentJSON, _ := json.Marshal(req.Segment)
+	var broadcastID string
+	err := ns.db.QueryRow(ctx, `
+		INSERT INTO notification_broadcasts (sender_uid, category, title, body, data, segment)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, senderUID, req.Category, req.Title, req.Body, dataJSON, segmentJSON).Scan(&broadcastID)
+	if err != nil {
+		ns.logError(c, err, "Failed to log broadcast")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start broadcast")
+		return
+	}
+	data["broadcast_id"] = broadcastID
+
+	recipients, optedOut, err := ns.broadcastRecipients(ctx, req.Segment, req.Category == broadcastCategoryAnnouncements)
+	if err != nil {
+		ns.logError(c, err, "Failed to load broadcast recipients", "broadcastId", broadcastID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load recipients")
+		return
+	}
+
+	var fcmTokens []string
+	var expoTokens []string
+	for _, r := range recipients {
+		if r.isExpo {
+			expoTokens = append(expoTokens, r.token)
+		} else {
+			fcmTokens = append(fcmTokens, r.token)
+		}
+	}
+	succeeded, failed := ns.multicastFCM(ctx, fcmTokens, req.Title, req.Body, data, req.Category)
+	expoSucceeded, expoFailed := ns.multicastExpo(ctx, expoTokens, req.Title, req.Body, data)
+	succeeded += expoSucceeded
+	failed += expoFailed
+	metrics.NotificationsSentTotal.WithLabelValues(req.Category, "success").Add(float64(succeeded))
+	metrics.NotificationsSentTotal.WithLabelValues(req.Category, "failure").Add(float64(failed))
+
+	_, err = ns.db.Exec(ctx, `
+		UPDATE notification_broadcasts
+		SET status = 'sent', targeted = $2, opted_out = $3, succeeded = $4, failed = $5, completed_at = NOW()
+		WHERE id = $1
+	`, broadcastID, len(recipients), optedOut, succeeded, failed)
+	if err != nil {
+		ns.logError(c, err, "Failed to record broadcast results", "broadcastId", broadcastID)
+	}
+
+	ns.logger.Infow("Sent broadcast notification",
+		"broadcastId", broadcastID,
+		"sender", senderUID,
+		"category", req.Category,
+		"targeted", len(recipients),
+		"optedOut", optedOut,
+		"succeeded", succeeded,
+		"failed", failed,
+	)
+
+	c.JSON(http.StatusOK, notificationsmodels.BroadcastNotificationResponse{
+		BroadcastID: broadcastID,
+		Targeted:    len(recipients),
+		OptedOut:    optedOut,
+		Succeeded:   succeeded,
+		Failed:      failed,
+	})
+}
+
+// broadcastRecipients returns the active push tokens matching segment, preferring FCM over
+// Expo like sendToUser. With respectOptOut, users who turned announcements off are left
+// out and counted instead.
+func (ns *NotificationsHandler) broadcastRecipients(ctx context.Context, segment notificationsmodels.BroadcastSegment, respectOptOut bool) ([]broadcastRecipient, int, error) {
+	platforms := segment.Platforms
+	if platforms == nil {
+		platforms = []string{}
+	}
+	timezones := segment.Timezones
+	if timezones == nil {
+		timezones = []string{}
+	}
+	languages := segment.Languages
+	if languages == nil {
+		languages = []string{}
+	}
+
+	rows, err := ns.db.Query(ctx, `
+		SELECT COALESCE(p.fcm_token, ''), p.expo_push_token, COALESCE(us.notify_announcements, TRUE)
+		FROM push_tokens p
+		JOIN users u ON u.uid = p.user_id
+		LEFT JOIN user_settings us ON us.uid = p.user_id
+		WHERE p.active = true
+		  AND (cardinality($1::text[]) = 0 OR LOWER(p.platform) = ANY($1))
+		  AND (cardinality($2::text[]) = 0 OR p.timezone = ANY($2))
+		  AND (cardinality($3::text[]) = 0 OR COALESCE(us.lang, 'en') = ANY($3))
+		  AND ($4::boolean IS NULL OR COALESCE(u.is_premium AND u.premium_expires_at > NOW(), FALSE) = $4)
+	`, platforms, timezones, languages, segment.Premium)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var recipients []broadcastRecipient
+	optedOut := 0
+	for rows.Next() {
+		var fcmToken, expoToken string
+		var wantsAnnouncements bool
+		if err := rows.Scan(&fcmToken, &expoToken, &wantsAnnouncements); err != nil {
+			return nil, 0, err
+		}
+		if respectOptOut && !wantsAnnouncements {
+			optedOut++
+			continue
+		}
+		switch {
+		case fcmToken != "":
+			recipients = append(recipients, broadcastRecipient{token: fcmToken})
+		case expoToken != "":
+			recipients = append(recipients, broadcastRecipient{token: expoToken, isExpo: true})
+		}
+	}
+	return recipients, optedOut, rows.Err()
+}
+
+// multicastFCM sends one message to many FCM tokens, fcmMulticastBatchSize at a time. A
+// batch that fails outright counts all of its tokens as failed.
+func (ns *NotificationsHandler) multicastFCM(ctx context.Context, tokens []string, title, body string, data map[string]string, channelID string) (succeeded, failed int) {
+	if len(tokens) == 0 {
+		return 0, 0
+	}
+	if ns.fcmClient == nil {
+		ns.logger.Errorw("FCM client not initialized; skipping FCM recipients", "tokens", len(tokens))
+		return 0, len(tokens)
+	}
+
+	for start := 0; start < len(tokens); start += fcmMulticastBatchSize {
+		end := min(start+fcmMulticastBatchSize, len(tokens))
+		message := &messaging.MulticastMessage{
+			Tokens: tokens[start:end],
+			Notification: &messaging.Notification{
+				Title: title,
+				Body:  body,
+			},
+			Data: data,
+			Android: &messaging.AndroidConfig{
+				Notification: &messaging.AndroidNotification{
+					ChannelID: channelID,
+				},
+			},
+			APNS: &messaging.APNSConfig{
+				Payload: &messaging.APNSPayload{
+					Aps: &messaging.Aps{
+						Alert: &messaging.ApsAlert{
+							Title: title,
+							Body:  body,
+						},
+						Sound: "default",
+					},
+				},
+			},
+		}
+		resp, err := ns.fcmClient.SendEachForMulticast(ctx, message)
+		if err != nil {
+			ns.logger.Warnw("FCM multicast failed", "tokens", end-start, "error", err)
+			failed += end - start
+			continue
+		}
+		succeeded += resp.SuccessCount
+		failed += resp.FailureCount
+	}
+	return succeeded, failed
+}
+
+// multicastExpo sends one message to many Expo tokens, expoSendBatchSize per request.
+// Tickets are handled like sendExpoPush: accepted ones are queued for receipt checks and
+// DeviceNotRegistered deactivates the token.
+func (ns *NotificationsHandler) multicastExpo(ctx context.Context, tokens []string, title, body string, data map[string]string) (succeeded, failed int) {
+	for start := 0; start < len(tokens); start += expoSendBatchSize {
+		end := min(start+expoSendBatchSize, len(tokens))
+		batch := tokens[start:end]
+
+		payload := make([]map[string]interface{}, 0, len(batch))
+		for _, token := range batch {
+			payload = append(payload, map[string]interface{}{
+				"to":    token,
+				"title": title,
+				"body":  body,
+				"sound": "default",
+				"data":  data,
+			})
+		}
+		var tickets []expoTicket
+		if err := postExpo(ctx, expoSendURL, payload, &tickets); err != nil {
+			ns.logger.Warnw("Expo batch send failed", "tokens", len(batch), "error", err)
+			failed += len(batch)
+			continue
+		}
+
+		// Tickets come back in message order
+		for i, token := range batch {
+			if i >= len(tickets) {
+				failed++
+				continue
+			}
+			ticket := tickets[i]
+			if ticket.Status != "ok" {
+				failed++
+				if ticket.Details.Error == expoDeviceNotRegistered {
+					ns.deactivateExpoToken(ctx, token)
+				}
+				continue
+			}
+			succeeded++
+			ns.trackExpoTicket(ctx, ticket.ID, token)
+		}
+	}
+	return succeeded, failed
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"io.winapps.journeyapp/internal/apierror"
+	bulktagmodels "io.winapps.journeyapp/internal/models/bulk_tag"
+)
+
+// maxBulkTagEntries caps how many entries one bulk tag request may touch
+const maxBulkTagEntries = 100
+
+// BulkAddTag adds a tag to many entries at once. Because tags are unique per
+// (entry_id, key), an entry that already has the key gets its value replaced.
+func (h *EntryHandler) BulkAddTag(c *gin.Context) {
+	req, ok := h.bindBulkTagRequest(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	rows, err := tx.Query(ctx, `
+		INSERT INTO tags (entry_id, key, value, created_at)
+		SELECT id, $2, $3, $4 FROM unnest($1::uuid[]) AS id
+		ON CONFLICT (entry_id, key) DO UPDATE SET value = EXCLUDED.value
+			WHERE tags.value IS DISTINCT FROM EXCLUDED.value
+		RETURNING entry_id::text
+	`, req.EntryIDs, req.Tag.Key, req.Tag.Value, now)
+	changed, err := collectEntryIDs(rows, err)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "Failed to bulk add tag")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to add tag")
+		return
+	}
+
+	if !h.finishBulkTagChange(c, tx, changed, now) {
+		return
+	}
+
+	c.JSON(http.StatusOK, bulktagmodels.BulkAddTagResponse{
+		Tag:     req.Tag,
+		Added:   len(changed),
+		Skipped: len(req.EntryIDs) - len(changed),
+		Message: "Tag added successfully",
+	})
+}
+
+// BulkRemoveTag removes a tag from many entries at once. An empty tag value removes
+// the key whatever its value; otherwise only exact key/value matches are removed.
+func (h *EntryHandler) BulkRemoveTag(c *gin.Context) {
+	req, ok := h.bindBulkTagRequest(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	rows, err := tx.Query(ctx, `
+		DELETE FROM tags
+		WHERE entry_id = ANY($1::uuid[]) AND key = $2 AND ($3 = '' OR value = $3)
+		RETURNING entry_id::text
+	`, req.EntryIDs, req.Tag.Key, req.Tag.Value)
+	changed, err := collectEntryIDs(rows, err)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "Failed to bulk remove tag")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove tag")
+		return
+	}
+
+	if !h.finishBulkTagChange(c, tx, changed, now) {
+		return
+	}
+
+	c.JSON(http.StatusOK, bulktagmodels.BulkRemoveTagResponse{
+		Tag:     req.Tag,
+		Removed: len(changed),
+		Skipped: len(req.EntryIDs) - len(changed),
+		Message: "Tag removed successfully",
+	})
+}
+
+// bindBulkTagRequest validates the request and checks, in one query, that the caller
+// owns every entry. It writes the error response and returns ok=false on failure.
+func (h *EntryHandler) bindBulkTagRequest(c *gin.Context) (bulktagmodels.BulkTagRequest, bool) {
+	var req bulktagmodels.BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return req, false
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return req, false
+	}
+	userUID, ok := uid.(string)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
+		return req, false
+	}
+
+	req.Tag.Key = strings.TrimSpace(req.Tag.Key)
+	if req.Tag.Key == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Tag key is required")
+		return req, false
+	}
+	if err := validateTag("tag", req.Tag); err != nil {
+		respondFieldTooLong(c, err)
+		return req, false
+	}
+
+	// Drop blanks and duplicates so the counts reflect distinct entries
+	seen := make(map[string]bool, len(req.EntryIDs))
+	ids := make([]string, 0, len(req.EntryIDs))
+	for _, id := range req.EntryIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "At least one entry ID is required")
+		return req, false
+	}
+	if len(ids) > maxBulkTagEntries {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, fmt.Sprintf("At most %d entries can be tagged at once", maxBulkTagEntries))
+		return req, false
+	}
+	req.EntryIDs = ids
+
+	// Compare as text so malformed ids count as "not owned" instead of failing the cast
+	var owned int
+	if err := h.postgres.QueryRow(c.Request.Context(), `
+		SELECT COUNT(*) FROM entries WHERE id::text = ANY($1) AND user_uid = $2
+	`, ids, userUID).Scan(&owned); err != nil {
+		if abortOnContextError(c, err) {
+			return req, false
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entries")
+		return req, false
+	}
+	if owned != len(ids) {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "One or more entries not found or access denied")
+		return req, false
+	}
+
+	return req, true
+}
+
+// finishBulkTagChange bumps updated_at on the changed entries, commits, and clears their
+// cached copies. It writes the error response and returns false on failure.
+func (h *EntryHandler) finishBulkTagChange(c *gin.Context, tx pgx.Tx, changed []string, now time.Time) bool {
+	ctx := c.Request.Context()
+	if len(changed) > 0 {
+		if _, err := tx.Exec(ctx, `UPDATE entries SET updated_at = $1 WHERE id = ANY($2::uuid[])`, now, changed); err != nil {
+			if abortOnContextError(c, err) {
+				return false
+			}
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamps")
+			return false
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		if abortOnContextError(c, err) {
+			return false
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save tags")
+		return false
+	}
+
+	// Invalidate cached entries
+	keys := make([]string, len(changed))
+	for i, id := range changed {
+		keys[i] = "entry:" + id
+	}
+	_ = h.cache.Del(context.Background(), keys...)
+	invalidateAccountDetails(context.Background(), h.cache, c.GetString("uid"))
+	return true
+}
+
+func collectEntryIDs(rows pgx.Rows, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/moderation"
+)
+
+// contentRejection is sent as the details of a CONTENT_REJECTED error
+type contentRejection struct {
+	Field string   `json:"field"`
+	Terms []string `json:"terms"`
+}
+
+func (r *contentRejection) Error() string {
+	return fmt.Sprintf("%s contains terms that aren't allowed in shared entries: %s", r.Field, strings.Join(r.Terms, ", "))
+}
+
+// SetContentModerator enables the content filter for public and semi-private entries
+func (h *EntryHandler) SetContentModerator(m *moderation.Moderator) {
+	h.moderator = m
+}
+
+// filterEntryText runs the title and description of an entry with the given visibility
+// through the content filter. Private entries, nil fields and a disabled filter are left
+// alone. In mask mode flagged terms are masked in place; in reject mode the first flagged
+// field is returned. Flagged text is logged for moderators either way. A filter that fails
+// is logged and the text let through, so an outage of a moderation API doesn't block writes.
+func (h *EntryHandler) filterEntryText(c *gin.Context, uid, entryID, visibility string, title, description *string) *contentRejection {
+	if h.moderator == nil || visibility == "private" {
+		return nil
+	}
+	for _, f := range []struct {
+		name string
+		text *string
+	}{{"title", title}, {"description", description}} {
+		if f.text == nil || *f.text == "" {
+			continue
+		}
+		res, err := h.moderator.Filter.Check(c.Request.Context(), *f.text)
+		if err != nil {
+			h.logError(c, err, "content filter failed", "entryId", entryID)
+			continue
+		}
+		if !res.Flagged() {
+			continue
+		}
+		logWithContext(h.logger, c, "warn", "Content filter flagged entry text",
+			"uid", uid,
+			"entryId", entryID,
+			"visibility", visibility,
+			"field", f.name,
+			"terms", res.Terms,
+			"mode", string(h.moderator.Mode),
+			"text", *f.text,
+		)
+		if h.moderator.Mode == moderation.ModeReject {
+			return &contentRejection{Field: f.name, Terms: res.Terms}
+		}
+		*f.text = res.Masked
+	}
+	return nil
+}
+
+// filterEntryUpdate applies filterEntryText to an update of entryID. The visibility is the
+// requested one or, without one, the entry's current visibility. When an update makes a
+// private entry visible, the stored text it keeps is checked too; in mask mode title or
+// description are then pointed at the masked text so the update rewrites it.
+func (h *EntryHandler) filterEntryUpdate(c *gin.Context, entryID, uid, visibility string, title, description **string) (*contentRejection, error) {
+	if h.moderator == nil {
+		return nil, nil
+	}
+	ctx := c.Request.Context()
+
+	var currentVisibility, currentTitle, currentDescription string
+	var encrypted bool
+	err := h.postgres.QueryRow(ctx, `
+		SELECT visibility, title, COALESCE(description, ''), encrypted FROM entries WHERE id = $1 AND user_uid = $2
+	`, entryID, uid).Scan(&currentVisibility, &currentTitle, &currentDescription, &encrypted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The update itself reports the missing entry
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	effective := currentVisibility
+	if visibility != "" {
+		effective = strings.ToLower(strings.TrimSpace(visibility))
+	}
+	if effective == "private" {
+		return nil, nil
+	}
+
+	keptTitle := currentVisibility == "private" && *title == nil
+	keptDescription := currentVisibility == "private" && *description == nil
+	if keptTitle || keptDescription {
+		if err := newEntryCipher(h.postgres, h.entryKeys).open(ctx, uid, encrypted, &currentTitle, &currentDescription); err != nil {
+			return nil, err
+		}
+		if keptTitle {
+			t := currentTitle
+			*title = &t
+		}
+		if keptDescription {
+			d := currentDescription
+			*description = &d
+		}
+	}
+
+	rejection := h.filterEntryText(c, uid, entryID, effective, *title, *description)
+
+	// Stored text that masking left alone needn't be rewritten
+	if keptTitle && **title == currentTitle {
+		*title = nil
+	}
+	if keptDescription && **description == currentDescription {
+		*description = nil
+	}
+	return rejection, nil
+}
+
+// respondContentRejected writes a 400 CONTENT_REJECTED error naming the field and terms
+func respondContentRejected(c *gin.Context, r *contentRejection) {
+	apierror.RespondWithDetails(c, http.StatusBadRequest, apierror.CodeContentRejected, r.Error(), r)
+}
@@ -3,18 +3,15 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"log"
 	"net/http"
 	"os"
 	"time"
 
-	firebase "firebase.google.com/go/v4"
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 	stream "github.com/GetStream/stream-chat-go/v5"
 	"go.uber.org/zap"
 
+	"io.winapps.journeyapp/internal/apierror"
 	firebaseutil "io.winapps.journeyapp/internal/firebase"
 	createmodels "io.winapps.journeyapp/internal/models/create_account"
 	usermodels "io.winapps.journeyapp/internal/models/account"
@@ -32,76 +29,59 @@ var publicChannelIDs = []string{
 	"tech-tips",
 }
 
-func addUserToPublicChannels(ctx context.Context, client *stream.Client, uid string) {
+func addUserToPublicChannels(ctx context.Context, logger *zap.SugaredLogger, client *stream.Client, uid string) {
 	if client == nil {
-		log.Printf("Stream client is nil when adding user %s to public channels", uid)
+		logger.Errorw("Stream client is nil when adding user to public channels", "uid", uid)
 		return
 	}
 	for _, channelID := range publicChannelIDs {
 		ch := client.Channel("livestream", channelID)
 		if ch == nil {
-			log.Printf("Stream channel is nil for id %s", channelID)
+			logger.Warnw("Stream channel is nil", "channel", channelID)
 			continue
 		}
 		if _, err := ch.AddMembers(ctx, []string{uid}); err != nil {
-			log.Printf("Failed adding user %s to channel %s: %v", uid, channelID, err)
+			logger.Warnw("Failed adding user to public channel", "uid", uid, "channel", channelID, "error", err)
 		}
 	}
 }
 
-type AuthHandler struct {
-	firebaseApp *firebase.App
-	postgres    *pgxpool.Pool
-	redis       *redis.Client
-    logger      *zap.SugaredLogger
-}
-
-// NewAuthHandler creates a new authentication handler
-func NewAuthHandler(firebaseApp *firebase.App, postgres *pgxpool.Pool, redis *redis.Client, logger *zap.SugaredLogger) *AuthHandler {
-	return &AuthHandler{
-		firebaseApp: firebaseApp,
-		postgres:    postgres,
-		redis:       redis,
-        logger:      logger,
-	}
-}
-
 // CreateAccount handles user account creation from client-side Firebase authentication
 func (h *AuthHandler) CreateAccount(c *gin.Context) {
 	var req createmodels.CreateAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	ctx := context.Background()
 	authClient, err := firebaseutil.GetAuthClient(h.firebaseApp)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize auth client"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to initialize auth client")
 		return
 	}
 
 	// Verify the ID token from client
 	idToken, err := authClient.VerifyIDToken(ctx, req.IDToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired ID token"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid or expired ID token")
 		return
 	}
 
 	// Ensure the UID matches the token
 	if idToken.UID != req.UID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "UID mismatch with token"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "UID mismatch with token")
 		return
 	}
 
 	client, err := stream.NewClient(os.Getenv("STREAM_API_KEY"), os.Getenv("STREAM_API_SECRET"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize Stream client"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to initialize Stream client")
 		return
 	}
 	streamToken, err := client.CreateToken(req.UID, time.Time{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stream token"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create stream token")
 		return
 	}
 
@@ -119,45 +99,47 @@ func (h *AuthHandler) CreateAccount(c *gin.Context) {
 	}
 
 	// Create user object for storage
+	tokenExpiresAt := time.Now().Add(sessionTokenTTL)
 	user := &usermodels.User{
 		UID:                 req.UID,
 		DisplayName:         req.DisplayName,
 		Email:               req.Email,
 		Token:               req.IDToken, // Store the ID token for session management
+		TokenExpiresAt:      &tokenExpiresAt,
 		PhotoURL:            req.PhotoURL,
 		PhoneNumber:         req.PhoneNumber,
-		EmailVerified:       req.EmailVerified,
+		EmailVerified:       emailVerifiedClaim(idToken.Claims),
 		PhoneNumberVerified: req.PhoneNumberVerified,
 	}
 
 	// Store user in Redis for session management
 	userJSON, err := json.Marshal(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process user data"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to process user data")
 		return
 	}
 
-	// Set Redis key with 24-hour expiration
+	// Set Redis key with the same expiration as the session token
 	redisKey := "user:" + user.UID
-	if err := h.redis.Set(ctx, redisKey, userJSON, 24*time.Hour).Err(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+	if err := h.cache.Set(ctx, redisKey, userJSON, sessionTokenTTL); err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create session")
 		return
 	}
 
 	// Store user in PostgreSQL
 	if err := h.storeUserInPostgres(ctx, user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store user in database"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to store user in database")
 		return
 	}
 
 	// Create default user settings
 	if err := h.createDefaultUserSettings(ctx, user.UID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user settings"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create user settings")
 		return
 	}
 
 	// Add user to public channels (server-side membership)
-	addUserToPublicChannels(ctx, client, user.UID)
+	addUserToPublicChannels(ctx, h.logger, client, user.UID)
 
 	// Create success response
 	response := createmodels.CreateUserResponse{
@@ -170,16 +152,23 @@ func (h *AuthHandler) CreateAccount(c *gin.Context) {
 	c.JSON(http.StatusCreated, response)
 }
 
+// emailVerifiedClaim reads the email_verified claim from Firebase ID token claims
+func emailVerifiedClaim(claims map[string]interface{}) bool {
+	verified, _ := claims["email_verified"].(bool)
+	return verified
+}
+
 // storeUserInPostgres stores or updates user information in PostgreSQL
 func (h *AuthHandler) storeUserInPostgres(ctx context.Context, user *usermodels.User) error {
 	query := `
-		INSERT INTO users (uid, display_name, email, token, photo_url, phone_number, email_verified, phone_number_verified, is_premium, premium_expires_at, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, FALSE, NULL, NOW(), NOW())
+		INSERT INTO users (uid, display_name, email, token, token_expires_at, photo_url, phone_number, email_verified, phone_number_verified, is_premium, premium_expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, FALSE, NULL, NOW(), NOW())
 		ON CONFLICT (uid)
 		DO UPDATE SET
 			display_name = EXCLUDED.display_name,
 			email = EXCLUDED.email,
 			token = EXCLUDED.token,
+			token_expires_at = EXCLUDED.token_expires_at,
 			photo_url = EXCLUDED.photo_url,
 			phone_number = EXCLUDED.phone_number,
 			email_verified = EXCLUDED.email_verified,
@@ -192,6 +181,7 @@ func (h *AuthHandler) storeUserInPostgres(ctx context.Context, user *usermodels.
 		user.DisplayName,
 		user.Email,
 		user.Token,
+		user.TokenExpiresAt,
 		user.PhotoURL,
 		user.PhoneNumber,
 		user.EmailVerified,
@@ -204,7 +194,7 @@ func (h *AuthHandler) storeUserInPostgres(ctx context.Context, user *usermodels.
 // getUserFromDatabase retrieves user information from PostgreSQL by UID
 func (h *AuthHandler) getUserFromDatabase(ctx context.Context, uid string) (*usermodels.User, error) {
 	query := `
-		SELECT uid, display_name, email, token, photo_url, phone_number, email_verified, phone_number_verified
+		SELECT uid, display_name, email, COALESCE(token, ''), photo_url, phone_number, email_verified, phone_number_verified
 		FROM users
 		WHERE uid = $1
 	`
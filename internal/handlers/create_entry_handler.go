@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -11,73 +12,173 @@ import (
 	firebase "firebase.google.com/go/v4"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 
+	"io.winapps.journeyapp/internal/cache"
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/encryption"
+	"io.winapps.journeyapp/internal/moderation"
 	models "io.winapps.journeyapp/internal/models/account"
 	createmodels "io.winapps.journeyapp/internal/models/create_entry"
+	"io.winapps.journeyapp/internal/premium"
+	"io.winapps.journeyapp/internal/storage"
+	"io.winapps.journeyapp/internal/webhooks"
 )
 
 type EntryHandler struct {
 	firebaseApp *firebase.App
 	postgres    *pgxpool.Pool
-	redis       *redis.Client
+	cache       cache.Store
 	logger      *zap.SugaredLogger
+	webp        webpConfig
+	video       videoToolsConfig
+	media       storage.Store
+	entryKeys   *encryption.Keyring
+	moderator   *moderation.Moderator // nil unless CONTENT_FILTER_MODE is set
+
+	notifications *NotificationsHandler
+	webhooks      *webhooks.Dispatcher
 }
 
 // NewEntryHandler creates a new entry handler
-func NewEntryHandler(firebaseApp *firebase.App, postgres *pgxpool.Pool, redis *redis.Client, logger *zap.SugaredLogger) *EntryHandler {
+func NewEntryHandler(firebaseApp *firebase.App, postgres *pgxpool.Pool, store cache.Store, logger *zap.SugaredLogger) *EntryHandler {
 	return &EntryHandler{
 		firebaseApp: firebaseApp,
 		postgres:    postgres,
-		redis:       redis,
+		cache:       store,
 		logger:      logger,
+		webp:        webpConfigFromEnv(),
+		video:       videoToolsConfigFromEnv(),
+		media:       storage.NewLocal(storage.DefaultLocalRoot),
 	}
 }
 
+// SetMediaStore replaces the default local media storage, e.g. with an S3 bucket
+func (h *EntryHandler) SetMediaStore(store storage.Store) {
+	h.media = store
+}
+
+// SetEntryKeyring enables entry encryption for users who turn it on
+func (h *EntryHandler) SetEntryKeyring(keys *encryption.Keyring) {
+	h.entryKeys = keys
+}
+
+// SetNotificationsHandler enables push notifications for entry activity such as comments
+// and schedules the media trash sweep on its scheduler
+func (h *EntryHandler) SetNotificationsHandler(notifications *NotificationsHandler) {
+	h.notifications = notifications
+	h.setupMediaTrashSweep(notifications)
+}
+
+// SetWebhookDispatcher enables outbound webhooks for entry events
+func (h *EntryHandler) SetWebhookDispatcher(dispatcher *webhooks.Dispatcher) {
+	h.webhooks = dispatcher
+}
+
 // CreateEntry handles creation of new journal entries
 func (h *EntryHandler) CreateEntry(c *gin.Context) {
 	var req createmodels.CreateEntryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Validate required fields
 	if req.Title == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Title is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Title is required")
 		return
 	}
-
-	// Determine visibility (default private)
-	visibility := strings.ToLower(strings.TrimSpace(req.Visibility))
-	switch visibility {
-	case "public", "semi-private", "private":
-		// ok
-	default:
-		visibility = "private"
+	if err := validateEntryInput(req.Title, req.Description, req.Tags); err != nil {
+		respondFieldTooLong(c, err)
+		return
 	}
+	if err := validateEntryImages(userUID, req.Images); err != nil {
+		respondInvalidMediaRef(c, err)
+		return
+	}
+
+	visibility := normalizeEntryVisibility(req.Visibility)
 
 	ctx := context.Background()
 
+	normalize, err := tagNormalizationEnabled(ctx, h.postgres, userUID)
+	if err != nil {
+		h.logError(c, err, "settings lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load settings")
+		return
+	}
+	if normalize {
+		req.Tags = normalizeTagList(req.Tags)
+	}
+
+	// Publishing publicly or sharing with friends requires a verified email
+	if entryReachesOthers(visibility, len(req.SharedWith)) && !h.requireVerifiedEmail(c, ctx, userUID) {
+		return
+	}
+
+	// Semi-private entries can only be shared with friends
+	if visibility == "semi-private" {
+		invalid, err := h.checkShareTargets(ctx, userUID, req.SharedWith)
+		if err != nil {
+			h.logError(c, err, "share target lookup failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify shared users")
+			return
+		}
+		if invalid != nil {
+			respondInvalidShareTargets(c, invalid)
+			return
+		}
+	}
+
+	// Enforce the plan's entry and per-entry image limits
+	policy, err := premium.ForUser(ctx, h.postgres, userUID)
+	if err != nil {
+		h.logError(c, err, "plan lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify account")
+		return
+	}
+	var entryCount int
+	if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM entries WHERE user_uid = $1`, userUID).Scan(&entryCount); err != nil {
+		h.logError(c, err, "count entries failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify account")
+		return
+	}
+	if denial := policy.CheckEntries(entryCount); denial != nil {
+		respondPlanLimit(c, denial)
+		return
+	}
+	if len(req.Images) > 0 {
+		// Same as adding the last image to an entry that already holds the others
+		if denial := policy.CheckImages(len(req.Images) - 1); denial != nil {
+			respondPlanLimit(c, denial)
+			return
+		}
+	}
+
 	// Generate new entry ID
 	entryID := uuid.New().String()
 	now := time.Now()
 
+	if rejection := h.filterEntryText(c, userUID, entryID, visibility, &req.Title, &req.Description); rejection != nil {
+		respondContentRejected(c, rejection)
+		return
+	}
+
 	// Create entry object
 	entry := &models.Entry{
 		ID:          entryID,
@@ -94,20 +195,97 @@ func (h *EntryHandler) CreateEntry(c *gin.Context) {
 	// Start database transaction
 	tx, err := h.postgres.Begin(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start database transaction"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
 		return
 	}
 	defer tx.Rollback(ctx)
 
+	cipher := newEntryCipher(h.postgres, h.entryKeys)
+	if err := cipher.lockUser(ctx, tx, userUID); err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create entry")
+		return
+	}
+	text, err := cipher.seal(ctx, userUID, req.Title, req.Description)
+	if err != nil {
+		h.logError(c, err, "entry encryption failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create entry")
+		return
+	}
+
+	if err := insertEntryRecords(ctx, tx, entryID, userUID, req, text, visibility, entryStatusPublished, now); err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, entryWriteMessage(err))
+		return
+	}
+
+	// Commit transaction
+	if err = tx.Commit(ctx); err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save entry")
+		return
+	}
+	h.invalidateEntryStatsCache(ctx, userUID)
+	h.invalidateUniqueLocationsCache(ctx, userUID)
+	invalidateAccountDetails(ctx, h.cache, userUID)
+	bumpFeedVersion(ctx, h.cache, userUID)
+
+	h.cacheCreatedEntry(ctx, entry, text.encrypted, userUID, req.SharedWith)
+	h.emitEntryCreated(userUID, entry, req.SharedWith)
+
+	// Create response
+	response := createmodels.CreateEntryResponse{
+		ID:          entryID,
+		Title:       req.Title,
+		Description: req.Description,
+		Images:      req.Images,
+		Tags:        req.Tags,
+		Locations:   req.Locations,
+		Visibility:  visibility,
+		Status:      entryStatusPublished,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+// normalizeEntryVisibility lowercases a requested visibility, defaulting to private
+func normalizeEntryVisibility(requested string) string {
+	visibility := strings.ToLower(strings.TrimSpace(requested))
+	switch visibility {
+	case "public", "semi-private", "private":
+		return visibility
+	default:
+		return "private"
+	}
+}
+
+// entryWriteError tags a failed insert with the message returned to the client
+type entryWriteError struct {
+	msg string
+	err error
+}
+
+func (e *entryWriteError) Error() string { return e.msg + ": " + e.err.Error() }
+func (e *entryWriteError) Unwrap() error { return e.err }
+
+// entryWriteMessage returns the client-facing message for an insertEntryRecords error
+func entryWriteMessage(err error) string {
+	var we *entryWriteError
+	if errors.As(err, &we) {
+		return we.msg
+	}
+	return "Failed to create entry"
+}
+
+// insertEntryRecords writes a new entry with the given status ("published" or "draft") and
+// its shares, locations, tags and images in tx. The title and description come from text,
+// which is req's sealed for the user by entryCipher.seal.
+func insertEntryRecords(ctx context.Context, tx pgx.Tx, entryID, userUID string, req createmodels.CreateEntryRequest, text storedEntryText, visibility, status string, now time.Time) error {
 	// Insert entry into PostgreSQL
 	entryQuery := `
-		INSERT INTO entries (id, user_uid, title, description, visibility, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO entries (id, user_uid, title, description, encrypted, visibility, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
-	_, err = tx.Exec(ctx, entryQuery, entryID, userUID, req.Title, req.Description, visibility, now, now)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create entry"})
-		return
+	if _, err := tx.Exec(ctx, entryQuery, entryID, userUID, text.title, text.description, text.encrypted, visibility, status, now, now); err != nil {
+		return &entryWriteError{"Failed to create entry", err}
 	}
 
 	// Insert entry shares if semi-private
@@ -127,74 +305,66 @@ func (h *EntryHandler) CreateEntry(c *gin.Context) {
 				VALUES ($1, $2, $3)
 			`
 			if _, err := tx.Exec(ctx, shareQuery, entryID, sharedUID, now); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save shared users"})
-				return
+				return &entryWriteError{"Failed to save shared users", err}
 			}
 		}
 	}
 
 	// Insert locations if provided
-	if len(req.Locations) > 0 {
-		for _, location := range req.Locations {
-			locationQuery := `
-				INSERT INTO locations (entry_id, latitude, longitude, address, city, state, zip, country, country_code, display_name, created_at)
-				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-			`
-			_, err = tx.Exec(ctx, locationQuery,
-				entryID,
-				location.Latitude,
-				location.Longitude,
-				location.Address,
-				location.City,
-				location.State,
-				location.Zip,
-				location.Country,
-				location.CountryCode,
-				location.DisplayName,
-				now,
-			)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save location data"})
-				return
-			}
+	for _, location := range req.Locations {
+		locationQuery := `
+			INSERT INTO locations (entry_id, latitude, longitude, address, city, state, zip, country, country_code, display_name, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`
+		_, err := tx.Exec(ctx, locationQuery,
+			entryID,
+			location.Latitude,
+			location.Longitude,
+			location.Address,
+			location.City,
+			location.State,
+			location.Zip,
+			location.Country,
+			location.CountryCode,
+			location.DisplayName,
+			now,
+		)
+		if err != nil {
+			return &entryWriteError{"Failed to save location data", err}
 		}
 	}
 
 	// Insert tags if provided
-	if len(req.Tags) > 0 {
-		for _, tag := range req.Tags {
-			tagQuery := `
-				INSERT INTO tags (entry_id, key, value, created_at)
-				VALUES ($1, $2, $3, $4)
-			`
-			_, err = tx.Exec(ctx, tagQuery, entryID, tag.Key, tag.Value, now)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save tag data"})
-				return
-			}
+	for _, tag := range req.Tags {
+		tagQuery := `
+			INSERT INTO tags (entry_id, key, value, created_at)
+			VALUES ($1, $2, $3, $4)
+		`
+		if _, err := tx.Exec(ctx, tagQuery, entryID, tag.Key, tag.Value, now); err != nil {
+			return &entryWriteError{"Failed to save tag data", err}
 		}
 	}
 
 	// Insert images if provided
-	if len(req.Images) > 0 {
-		for i, imageURL := range req.Images {
-			imageQuery := `
-				INSERT INTO images (entry_id, url, upload_order, created_at)
-				VALUES ($1, $2, $3, $4)
-			`
-			_, err = tx.Exec(ctx, imageQuery, entryID, imageURL, i, now)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image data"})
-				return
-			}
+	for i, imageURL := range req.Images {
+		imageQuery := `
+			INSERT INTO images (entry_id, url, upload_order, created_at)
+			VALUES ($1, $2, $3, $4)
+		`
+		if _, err := tx.Exec(ctx, imageQuery, entryID, imageURL, i, now); err != nil {
+			return &entryWriteError{"Failed to save image data", err}
 		}
 	}
 
-	// Commit transaction
-	if err = tx.Commit(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save entry"})
-		return
-	}
+	return nil
+}
+
+// cacheCreatedEntry caches a committed entry and adds it to the user, public and shared
+// entry sets. Encrypted entries aren't cached, which would keep their text in Redis in
+// the clear. Failures are logged only since the entry is already saved.
+func (h *EntryHandler) cacheCreatedEntry(ctx context.Context, entry *models.Entry, encrypted bool, userUID string, sharedWith []string) {
+	entryID := entry.ID
+	visibility := entry.Visibility
 
 	// Cache entry in Redis
 	entryJSON, err := json.Marshal(entry)
@@ -203,61 +373,65 @@ func (h *EntryHandler) CreateEntry(c *gin.Context) {
 		fmt.Printf("Failed to marshal entry for Redis: %v\n", err)
 	} else {
 		redisKey := fmt.Sprintf("entry:%s", entryID)
-		if err := h.redis.Set(ctx, redisKey, entryJSON, 24*time.Hour).Err(); err != nil {
-			// Log error but don't fail the request since entry was saved
-			fmt.Printf("Failed to cache entry in Redis: %v\n", err)
+		if !encrypted {
+			if err := h.cache.Set(ctx, redisKey, entryJSON, 24*time.Hour); err != nil {
+				// Log error but don't fail the request since entry was saved
+				fmt.Printf("Failed to cache entry in Redis: %v\n", err)
+			}
 		}
 
 		// Cache user's entry list
 		userEntriesKey := fmt.Sprintf("user_entries:%s", userUID)
-		if err := h.redis.SAdd(ctx, userEntriesKey, entryID).Err(); err != nil {
+		if err := h.cache.SAdd(ctx, userEntriesKey, entryID); err != nil {
 			fmt.Printf("Failed to update user entries cache: %v\n", err)
 		}
 		// Set expiration for user entries list
-		h.redis.Expire(ctx, userEntriesKey, 24*time.Hour)
+		h.cache.Expire(ctx, userEntriesKey, 24*time.Hour)
 
 		// Maintain public entries sets
 		if visibility == "public" {
-			if err := h.redis.SAdd(ctx, "public_entries", entryID).Err(); err != nil {
+			if err := h.cache.SAdd(ctx, "public_entries", entryID); err != nil {
 				fmt.Printf("Failed to update public entries set: %v\n", err)
 			}
-			h.redis.Expire(ctx, "public_entries", 24*time.Hour)
+			h.cache.Expire(ctx, "public_entries", 24*time.Hour)
 			byUserKey := fmt.Sprintf("public_entries_by_user:%s", userUID)
-			if err := h.redis.SAdd(ctx, byUserKey, entryID).Err(); err != nil {
+			if err := h.cache.SAdd(ctx, byUserKey, entryID); err != nil {
 				fmt.Printf("Failed to update public entries by user set: %v\n", err)
 			}
-			h.redis.Expire(ctx, byUserKey, 24*time.Hour)
+			h.cache.Expire(ctx, byUserKey, 24*time.Hour)
 		}
 
 		// Maintain shared entries sets
-		if visibility == "semi-private" && len(req.SharedWith) > 0 {
+		if visibility == "semi-private" && len(sharedWith) > 0 {
 			entrySharesKey := fmt.Sprintf("entry_shares:%s", entryID)
-			for _, sharedUID := range req.SharedWith {
+			for _, sharedUID := range sharedWith {
 				sharedUID = strings.TrimSpace(sharedUID)
 				if sharedUID == "" {
 					continue
 				}
-				_ = h.redis.SAdd(ctx, entrySharesKey, sharedUID).Err()
+				_ = h.cache.SAdd(ctx, entrySharesKey, sharedUID)
 				userSharedKey := fmt.Sprintf("shared_entries:%s", sharedUID)
-				_ = h.redis.SAdd(ctx, userSharedKey, entryID).Err()
-				_ = h.redis.Expire(ctx, userSharedKey, 24*time.Hour).Err()
+				_ = h.cache.SAdd(ctx, userSharedKey, entryID)
+				_ = h.cache.Expire(ctx, userSharedKey, 24*time.Hour)
 			}
-			_ = h.redis.Expire(ctx, entrySharesKey, 24*time.Hour).Err()
+			_ = h.cache.Expire(ctx, entrySharesKey, 24*time.Hour)
 		}
 	}
+}
 
-	// Create response
-	response := createmodels.CreateEntryResponse{
-		ID:          entryID,
-		Title:       req.Title,
-		Description: req.Description,
-		Images:      req.Images,
-		Tags:        req.Tags,
-		Locations:   req.Locations,
-		Visibility:  visibility,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+// emitEntryCreated fires the entry.created webhook, and entry.shared for a semi-private
+// entry created with recipients
+func (h *EntryHandler) emitEntryCreated(userUID string, entry *models.Entry, sharedWith []string) {
+	h.webhooks.Emit(userUID, webhooks.EventEntryCreated, gin.H{
+		"entryId":    entry.ID,
+		"title":      entry.Title,
+		"visibility": entry.Visibility,
+		"createdAt":  entry.CreatedAt,
+	})
+	if entry.Visibility == "semi-private" && len(sharedWith) > 0 {
+		h.webhooks.Emit(userUID, webhooks.EventEntryShared, gin.H{
+			"entryId":    entry.ID,
+			"sharedWith": sharedWith,
+		})
 	}
-
-	c.JSON(http.StatusCreated, response)
-}
\ No newline at end of file
+}
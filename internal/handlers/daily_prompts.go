@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"embed"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultPromptLang is used for users without a language setting and for languages that
+// have no prompts
+const defaultPromptLang = "en"
+
+//go:embed prompts/*.json
+var embeddedPrompts embed.FS
+
+// dailyPromptSets holds the daily prompts for each language, keyed by user_settings.lang.
+// Each set is a JSON array in prompts/<lang>.json, in the same order as en.json, so on any
+// given day users in every language get the same prompt. Files in DAILY_PROMPTS_DIR add
+// languages or replace the built-in sets without a rebuild.
+var dailyPromptSets = loadDailyPromptSets(os.Getenv("DAILY_PROMPTS_DIR"))
+
+func loadDailyPromptSets(overrideDir string) map[string][]string {
+	sets := make(map[string][]string)
+	if files, err := embeddedPrompts.ReadDir("prompts"); err == nil {
+		for _, f := range files {
+			if data, err := embeddedPrompts.ReadFile("prompts/" + f.Name()); err == nil {
+				addPromptSet(sets, f.Name(), data)
+			}
+		}
+	}
+	if overrideDir != "" {
+		if files, err := os.ReadDir(overrideDir); err == nil {
+			for _, f := range files {
+				if data, err := os.ReadFile(filepath.Join(overrideDir, f.Name())); err == nil {
+					addPromptSet(sets, f.Name(), data)
+				}
+			}
+		}
+	}
+	return sets
+}
+
+// addPromptSet adds <lang>.json to sets. Unreadable or empty files are skipped, leaving
+// that language on whatever set it already had, or on English.
+func addPromptSet(sets map[string][]string, filename string, data []byte) {
+	lang, ok := strings.CutSuffix(filename, ".json")
+	if !ok || lang == "" {
+		return
+	}
+	var prompts []string
+	if err := json.Unmarshal(data, &prompts); err != nil || len(prompts) == 0 {
+		return
+	}
+	sets[strings.ToLower(lang)] = prompts
+}
+
+// promptLang returns lang if it has prompts, otherwise defaultPromptLang
+func promptLang(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if _, ok := dailyPromptSets[lang]; ok {
+		return lang
+	}
+	return defaultPromptLang
+}
+
+// promptForDay picks the prompt for the given day of the year in lang, which must have
+// prompts
+func promptForDay(lang string, dayOfYear int) string {
+	prompts := dailyPromptSets[lang]
+	return prompts[dayOfYear%len(prompts)]
+}
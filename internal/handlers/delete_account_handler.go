@@ -4,12 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
+	"path"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
 
+	"io.winapps.journeyapp/internal/apierror"
 	deleteaccountmodels "io.winapps.journeyapp/internal/models/delete_account"
 )
 
@@ -17,26 +17,26 @@ import (
 func (h *AuthHandler) DeleteAccount(c *gin.Context) {
 	var req deleteaccountmodels.DeleteAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Ensure the user can only delete their own account
 	if req.UID != "" && req.UID != userUID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot delete another user's account"})
+		respondError(c, http.StatusForbidden, apierror.CodeForbidden, "Cannot delete another user's account")
 		return
 	}
 
@@ -45,7 +45,7 @@ func (h *AuthHandler) DeleteAccount(c *gin.Context) {
 	// Perform the complete account deletion
 	err := h.deleteAccountCompletely(ctx, userUID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account: " + err.Error()})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete account: " + err.Error())
 		return
 	}
 
@@ -95,24 +95,40 @@ func (h *AuthHandler) deleteAccountCompletely(ctx context.Context, userUID strin
 	}
 
 	// Step 6: Delete all physical image files for this user
-	if err := h.deleteUserImageFiles(userUID); err != nil {
+	if err := h.deleteUserImageFiles(ctx, userUID); err != nil {
 		// Log but don't fail - file deletion is not critical for data privacy
 		fmt.Printf("Warning: failed to delete image files for user %s: %v\n", userUID, err)
 	}
 
 	// Step 7: Delete all physical audio files for this user
-	if err := h.deleteUserAudioFiles(userUID); err != nil {
+	if err := h.deleteUserAudioFiles(ctx, userUID); err != nil {
 		// Log but don't fail - file deletion is not critical for data privacy
 		fmt.Printf("Warning: failed to delete audio files for user %s: %v\n", userUID, err)
 	}
 
-	// Step 8: Clear Redis cache for this user
+	// Step 8: Delete all physical video files and posters for this user
+	if err := h.deleteUserVideoFiles(ctx, userUID); err != nil {
+		// Log but don't fail - file deletion is not critical for data privacy
+		if h.logger != nil {
+			h.logger.Warnw("Failed to delete video files", "uid", userUID, "error", err)
+		}
+	}
+
+	// Step 9: Delete all attachment files for this user
+	if err := h.deleteUserAttachmentFiles(ctx, userUID); err != nil {
+		// Log but don't fail - file deletion is not critical for data privacy
+		if h.logger != nil {
+			h.logger.Warnw("Failed to delete attachment files", "uid", userUID, "error", err)
+		}
+	}
+
+	// Step 10: Clear Redis cache for this user
 	if err := h.clearUserRedisCache(ctx, userUID, entryIDs); err != nil {
 		// Log but don't fail - Redis cache clearing is not critical
 		fmt.Printf("Warning: failed to clear Redis cache for user %s: %v\n", userUID, err)
 	}
 
-	// Step 9: Delete Firebase user
+	// Step 11: Delete Firebase user
 	if err := h.deleteFirebaseUser(ctx, userUID); err != nil {
 		return fmt.Errorf("failed to delete Firebase user: %w", err)
 	}
@@ -158,6 +174,16 @@ func (h *AuthHandler) deleteEntryRelatedData(ctx context.Context, tx pgx.Tx, ent
 		return fmt.Errorf("failed to delete audio: %w", err)
 	}
 
+	// Delete videos
+	if _, err := tx.Exec(ctx, `DELETE FROM videos WHERE entry_id = $1`, entryID); err != nil {
+		return fmt.Errorf("failed to delete videos: %w", err)
+	}
+
+	// Delete attachments
+	if _, err := tx.Exec(ctx, `DELETE FROM attachments WHERE entry_id = $1`, entryID); err != nil {
+		return fmt.Errorf("failed to delete attachments: %w", err)
+	}
+
 	// Delete tags
 	if _, err := tx.Exec(ctx, `DELETE FROM tags WHERE entry_id = $1`, entryID); err != nil {
 		return fmt.Errorf("failed to delete tags: %w", err)
@@ -196,7 +222,7 @@ func (h *AuthHandler) clearUserRedisCache(ctx context.Context, userUID string, e
 	// Clear entry caches
 	for _, entryID := range entryIDs {
 		entryKey := fmt.Sprintf("entry:%s", entryID)
-		if err := h.redis.Del(ctx, entryKey).Err(); err != nil {
+		if err := h.cache.Del(ctx, entryKey); err != nil {
 			// Log but continue - cache clearing is not critical
 			fmt.Printf("Warning: failed to clear cache for entry %s: %v\n", entryID, err)
 		}
@@ -204,7 +230,7 @@ func (h *AuthHandler) clearUserRedisCache(ctx context.Context, userUID string, e
 
 	// Clear any user-specific caches (if they exist)
 	userKey := fmt.Sprintf("user:%s", userUID)
-	if err := h.redis.Del(ctx, userKey).Err(); err != nil {
+	if err := h.cache.Del(ctx, userKey); err != nil {
 		// Log but continue
 		fmt.Printf("Warning: failed to clear cache for user %s: %v\n", userUID, err)
 	}
@@ -227,39 +253,35 @@ func (h *AuthHandler) deleteFirebaseUser(ctx context.Context, userUID string) er
 	return nil
 }
 
-// deleteUserImageFiles deletes all physical image files for a user
-func (h *AuthHandler) deleteUserImageFiles(userUID string) error {
-	userImageDir := filepath.Join("internal", "images", userUID)
-
-	// Check if user image directory exists
-	if _, err := os.Stat(userImageDir); os.IsNotExist(err) {
-		// Directory doesn't exist, nothing to delete
-		return nil
+// deleteUserImageFiles deletes all of a user's image files from media storage
+func (h *AuthHandler) deleteUserImageFiles(ctx context.Context, userUID string) error {
+	if err := h.media.DeleteAll(ctx, path.Join("images", userUID)); err != nil {
+		return fmt.Errorf("failed to delete user images for %s: %w", userUID, err)
 	}
+	return nil
+}
 
-	// Remove the entire user directory and all its contents
-	if err := os.RemoveAll(userImageDir); err != nil {
-		return fmt.Errorf("failed to delete user image directory %s: %w", userImageDir, err)
+// deleteUserAudioFiles deletes all of a user's audio files from media storage
+func (h *AuthHandler) deleteUserAudioFiles(ctx context.Context, userUID string) error {
+	if err := h.media.DeleteAll(ctx, path.Join("audio", userUID)); err != nil {
+		return fmt.Errorf("failed to delete user audio for %s: %w", userUID, err)
 	}
-
 	return nil
 }
 
-// deleteUserAudioFiles deletes all physical audio files for a user
-func (h *AuthHandler) deleteUserAudioFiles(userUID string) error {
-	userAudioDir := filepath.Join("internal", "audio", userUID)
-
-	// Check if user audio directory exists
-	if _, err := os.Stat(userAudioDir); os.IsNotExist(err) {
-		// Directory doesn't exist, nothing to delete
-		return nil
+// deleteUserVideoFiles deletes all of a user's video files and posters from media storage
+func (h *AuthHandler) deleteUserVideoFiles(ctx context.Context, userUID string) error {
+	if err := h.media.DeleteAll(ctx, path.Join("videos", userUID)); err != nil {
+		return fmt.Errorf("failed to delete user videos for %s: %w", userUID, err)
 	}
+	return nil
+}
 
-	// Remove the entire user directory and all its contents
-	if err := os.RemoveAll(userAudioDir); err != nil {
-		return fmt.Errorf("failed to delete user audio directory %s: %w", userAudioDir, err)
+// deleteUserAttachmentFiles deletes all of a user's attachment files from media storage
+func (h *AuthHandler) deleteUserAttachmentFiles(ctx context.Context, userUID string) error {
+	if err := h.media.DeleteAll(ctx, path.Join("attachments", userUID)); err != nil {
+		return fmt.Errorf("failed to delete user attachments for %s: %w", userUID, err)
 	}
-
 	return nil
 }
 
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
 
+	"io.winapps.journeyapp/internal/apierror"
 	deleteentrymodels "io.winapps.journeyapp/internal/models/delete_entry"
 )
 
@@ -15,26 +16,26 @@ import (
 func (h *EntryHandler) DeleteEntry(c *gin.Context) {
 	var req deleteentrymodels.DeleteEntryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Validate required fields
 	if req.EntryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Entry ID is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
 		return
 	}
 
@@ -43,7 +44,7 @@ func (h *EntryHandler) DeleteEntry(c *gin.Context) {
 	// Delete entry from database
 	tx, err := h.postgres.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start transaction")
 		return
 	}
 
@@ -55,23 +56,27 @@ func (h *EntryHandler) DeleteEntry(c *gin.Context) {
 	_, err = tx.Exec(ctx, query, req.EntryID, userUID)
 	if err != nil {
 		_ = tx.Rollback(ctx)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete entry"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete entry")
 		return
 	}
 
 	// Delete entry from Redis cache
 	redisKey := fmt.Sprintf("entry:%s", req.EntryID)
-	if err := h.redis.Del(ctx, redisKey).Err(); err != nil {
+	if err := h.cache.Del(ctx, redisKey); err != nil {
 		_ = tx.Rollback(ctx)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete entry from Redis cache"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete entry from Redis cache")
 		return
 	}
 
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete entry"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete entry")
 		return
 	}
+	h.invalidateEntryStatsCache(ctx, userUID)
+	h.invalidateUniqueLocationsCache(ctx, userUID)
+	invalidateAccountDetails(ctx, h.cache, userUID)
+	bumpFeedVersion(ctx, h.cache, userUID)
 
 	// Return success response
 	c.JSON(http.StatusOK, gin.H{"isDeleted": true, "message": "Entry deleted successfully"})
@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/cache"
+	uploadmodels "io.winapps.journeyapp/internal/models/direct_upload"
+	"io.winapps.journeyapp/internal/premium"
+	"io.winapps.journeyapp/internal/storage"
+)
+
+const (
+	uploadURLExpiry = 15 * time.Minute
+	// pendingUploadTTL outlives the URL so an upload that finishes just before it expires
+	// can still be confirmed
+	pendingUploadTTL = time.Hour
+)
+
+// directUploadFormats maps the content types accepted for direct uploads, per media type,
+// to the extension the uploaded bytes must sniff as
+var directUploadFormats = map[string]map[string]string{
+	"image": {
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/gif":  ".gif",
+		"image/webp": ".webp",
+		"image/heic": ".heic",
+	},
+	"audio": {
+		"audio/mpeg":  ".mp3",
+		"audio/mp4":   ".m4a",
+		"audio/x-m4a": ".m4a",
+		"audio/ogg":   ".ogg",
+		"audio/wav":   ".wav",
+		"audio/x-wav": ".wav",
+		"audio/flac":  ".flac",
+	},
+}
+
+// pendingUpload is what request-upload-url remembers under pending_upload:<mediaURL>
+// until the client confirms the upload
+type pendingUpload struct {
+	UserUID     string `json:"userUid"`
+	EntryID     string `json:"entryId"`
+	MediaType   string `json:"mediaType"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
+
+func pendingUploadKey(mediaURL string) string {
+	return "pending_upload:" + mediaURL
+}
+
+// directUploadKind maps a mediaType to its URL prefix, which is also its table name
+func directUploadKind(mediaType string) string {
+	if mediaType == "image" {
+		return "images"
+	}
+	return "audio"
+}
+
+// RequestUploadURL hands out a presigned URL the client uploads a file to directly, so
+// large media doesn't pass through this server. Nothing is attached to the entry until
+// ConfirmUpload; unconfirmed files are cleaned up by the media sweep.
+func (h *EntryHandler) RequestUploadURL(c *gin.Context) {
+	var req uploadmodels.RequestUploadURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	presigner, ok := h.media.(storage.Presigner)
+	if !ok {
+		respondError(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, "Direct uploads require object storage")
+		return
+	}
+
+	formats, ok := directUploadFormats[req.MediaType]
+	if !ok {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "mediaType must be image or audio")
+		return
+	}
+	contentType := strings.ToLower(strings.TrimSpace(req.ContentType))
+	ext, ok := formats[contentType]
+	if !ok {
+		respondError(c, http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia, "Unsupported media type")
+		return
+	}
+	if req.Size <= 0 {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "size must be positive")
+		return
+	}
+
+	ctx := c.Request.Context()
+	if !h.checkDirectUpload(c, ctx, userUID, req.EntryID, req.MediaType, req.Size) {
+		return
+	}
+
+	kind := directUploadKind(req.MediaType)
+	filename := uuid.New().String() + ext
+	mediaURL := fmt.Sprintf("/%s/%s/%s/%s", kind, userUID, req.EntryID, filename)
+	uploadURL, err := presigner.PresignPut(path.Join(kind, userUID, req.EntryID, filename), contentType, req.Size, uploadURLExpiry)
+	if err != nil {
+		h.logError(c, err, "presign upload failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create upload URL")
+		return
+	}
+
+	pending, _ := json.Marshal(pendingUpload{
+		UserUID:     userUID,
+		EntryID:     req.EntryID,
+		MediaType:   req.MediaType,
+		ContentType: contentType,
+		Size:        req.Size,
+	})
+	if err := h.cache.Set(ctx, pendingUploadKey(mediaURL), pending, pendingUploadTTL); err != nil {
+		h.logError(c, err, "save pending upload failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create upload URL")
+		return
+	}
+
+	c.JSON(http.StatusOK, uploadmodels.RequestUploadURLResponse{
+		UploadURL: uploadURL,
+		Method:    http.MethodPut,
+		Headers: map[string]string{
+			"Content-Type":   contentType,
+			"Content-Length": strconv.FormatInt(req.Size, 10),
+		},
+		MediaURL:  mediaURL,
+		ExpiresAt: time.Now().Add(uploadURLExpiry).UTC(),
+	})
+}
+
+// ConfirmUpload attaches a file uploaded through RequestUploadURL to its entry. The
+// stored object must have the declared size and start with the signature of the declared
+// format; otherwise it is deleted. Limits are checked again, since other uploads may
+// have landed in the meantime. Direct uploads skip WebP transcoding.
+func (h *EntryHandler) ConfirmUpload(c *gin.Context) {
+	var req uploadmodels.ConfirmUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ctx := c.Request.Context()
+	pendingKey := pendingUploadKey(req.MediaURL)
+
+	value, err := h.cache.Get(ctx, pendingKey)
+	if err != nil && !errors.Is(err, cache.ErrMiss) {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "load pending upload failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to confirm upload")
+		return
+	}
+	var pending pendingUpload
+	if err != nil || json.Unmarshal([]byte(value), &pending) != nil ||
+		pending.UserUID != userUID || pending.EntryID != req.EntryID {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Upload not found or expired")
+		return
+	}
+
+	// Only one confirmation of an upload may insert its row
+	claimKey := "confirm_upload:" + req.MediaURL
+	claimed, err := h.cache.SetNX(ctx, claimKey, userUID, time.Minute)
+	if err != nil {
+		h.logError(c, err, "claim upload confirmation failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to confirm upload")
+		return
+	}
+	if !claimed {
+		respondError(c, http.StatusConflict, apierror.CodeConflict, "Upload is already being confirmed")
+		return
+	}
+	defer h.cache.Del(context.WithoutCancel(ctx), claimKey)
+
+	kind := directUploadKind(pending.MediaType)
+	key, err := mediaKeyFromURL(req.MediaURL, kind, pending.UserUID, pending.EntryID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Upload not found or expired")
+		return
+	}
+	// Rejected uploads are deleted so they don't wait for the sweep
+	discard := func() {
+		cleanupCtx := context.WithoutCancel(ctx)
+		_ = h.media.Delete(cleanupCtx, key)
+		_ = h.cache.Del(cleanupCtx, pendingKey)
+	}
+
+	obj, err := h.media.Open(ctx, key)
+	if errors.Is(err, storage.ErrNotExist) {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "File has not been uploaded yet")
+		return
+	}
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "open uploaded media failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to confirm upload")
+		return
+	}
+	size := obj.Info().Size
+	header := make([]byte, 12)
+	n, err := io.ReadFull(obj, header)
+	obj.Close()
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "read uploaded media failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to confirm upload")
+		return
+	}
+
+	if size != pending.Size {
+		discard()
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Uploaded file doesn't match the requested size")
+		return
+	}
+	detectExt := audioExtension
+	if kind == "images" {
+		detectExt = imageExtension
+	}
+	if ext, ok := detectExt(header[:n]); !ok || ext != path.Ext(key) {
+		discard()
+		respondError(c, http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia, "Unsupported media type")
+		return
+	}
+
+	if !h.checkDirectUpload(c, ctx, userUID, req.EntryID, pending.MediaType, size) {
+		discard()
+		return
+	}
+
+	mimeType := pending.ContentType
+	if kind == "images" {
+		mimeType = imageMimeType(path.Ext(key))
+	}
+
+	// kind is "images" or "audio", both of which are table names
+	var maxOrder int
+	if err := h.postgres.QueryRow(ctx, fmt.Sprintf(`SELECT COALESCE(MAX(upload_order), -1) FROM %s WHERE entry_id = $1`, kind), req.EntryID).Scan(&maxOrder); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "determine media order failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to confirm upload")
+		return
+	}
+
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO %s (entry_id, url, upload_order, created_at, mime_type, file_size)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, kind), req.EntryID, req.MediaURL, maxOrder+1, now, mimeType, size); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "insert uploaded media failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to confirm upload")
+		return
+	}
+	if _, err := tx.Exec(ctx, `UPDATE entries SET updated_at = $1 WHERE id = $2`, now, req.EntryID); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "update entry timestamp failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "commit uploaded media failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to confirm upload")
+		return
+	}
+
+	_ = h.cache.Del(ctx, pendingKey, "entry:"+req.EntryID)
+	invalidateAccountDetails(ctx, h.cache, userUID)
+
+	c.JSON(http.StatusOK, uploadmodels.ConfirmUploadResponse{
+		EntryID:   req.EntryID,
+		MediaType: pending.MediaType,
+		MediaURL:  req.MediaURL,
+		MimeType:  mimeType,
+		FileSize:  size,
+		Message:   "Upload confirmed",
+	})
+}
+
+// checkDirectUpload verifies that the user owns the entry and that their plan allows one
+// more file of this type and size, responding with the error when it doesn't
+func (h *EntryHandler) checkDirectUpload(c *gin.Context, ctx context.Context, userUID, entryID, mediaType string, size int64) bool {
+	var owned bool
+	if err := h.postgres.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM entries WHERE id::text = $1 AND user_uid = $2)
+	`, entryID, userUID).Scan(&owned); err != nil {
+		if abortOnContextError(c, err) {
+			return false
+		}
+		h.logError(c, err, "verify entry failed", "entryId", entryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
+		return false
+	}
+	if !owned {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+		return false
+	}
+
+	policy, err := premium.ForUser(ctx, h.postgres, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return false
+		}
+		h.logError(c, err, "plan lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify account")
+		return false
+	}
+
+	kind := directUploadKind(mediaType)
+	var count int
+	// kind is "images" or "audio", both of which are table names
+	if err := h.postgres.QueryRow(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE entry_id = $1`, kind), entryID).Scan(&count); err != nil {
+		if abortOnContextError(c, err) {
+			return false
+		}
+		h.logError(c, err, "count media failed", "entryId", entryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
+		return false
+	}
+
+	denial, maxBytes := policy.CheckAudio(count), policy.Limits.MaxAudioBytes
+	if kind == "images" {
+		denial, maxBytes = policy.CheckImages(count), policy.Limits.MaxImageBytes
+	}
+	if denial != nil {
+		respondPlanLimit(c, denial)
+		return false
+	}
+	if size > maxBytes {
+		respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, fmt.Sprintf("File is too large: %s (%d MB)", errMediaTooLarge, maxBytes>>20))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"io.winapps.journeyapp/internal/apierror"
+	models "io.winapps.journeyapp/internal/models/account"
+	discovermodels "io.winapps.journeyapp/internal/models/discover_entries"
+	searchmodels "io.winapps.journeyapp/internal/models/search_entries"
+)
+
+// discoverCacheTTL is how long a page of the discovery feed is served from the cache. The
+// public_entries set can't be used for this: it is unordered and only holds entries made
+// public since it last expired, so pages come from Postgres and are cached briefly instead.
+const discoverCacheTTL = time.Minute
+
+// discoverEntriesFilter selects the published public entries $1 may discover: not their
+// own, not hidden by moderation, not by a user either side has blocked, and, when $2 is
+// set, tagged with that key and, when $3 is set too, that value. When $4 is set, only
+// entries whose author uses that app language are kept; users without settings use 'en'.
+const discoverEntriesFilter = `
+	e.visibility = 'public'
+	AND e.status = 'published'
+	AND NOT e.moderation_hidden
+	AND e.user_uid <> $1
+	AND NOT EXISTS (
+		SELECT 1 FROM friendships b
+		WHERE b.status = 'blocked'
+			AND ((b.uid = $1 AND b.fid = e.user_uid) OR (b.uid = e.user_uid AND b.fid = $1))
+	)
+	AND ($2 = '' OR EXISTS (
+		SELECT 1 FROM tags t WHERE t.entry_id = e.id AND t.key = $2 AND ($3 = '' OR t.value = $3)
+	))
+	AND ($4 = '' OR COALESCE((SELECT s.lang FROM user_settings s WHERE s.uid = e.user_uid), 'en') = $4)
+`
+
+// discoverCursor marks the last entry of a discovery page; the next page starts after it
+type discoverCursor struct {
+	createdAt time.Time
+	id        string
+}
+
+// encodeDiscoverCursor returns the opaque nextCursor for a page ending with entry
+func encodeDiscoverCursor(createdAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + id))
+}
+
+func parseDiscoverCursor(s string) (*discoverCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, errors.New("malformed cursor")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, err
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, err
+	}
+	return &discoverCursor{createdAt: createdAt, id: id}, nil
+}
+
+// DiscoverEntries returns recent public entries from everyone but the caller, newest
+// first, with each entry's author. It takes page and limit like ListEntries, tag and
+// tagValue to only show entries carrying that tag, and lang to only show entries by
+// authors using that app language. For infinite scroll, every response
+// carries a nextCursor while more entries follow; passing it as cursor returns the entries
+// after that point, so entries published in the meantime don't shift the pages. Cursor
+// responses skip the count and so have no pagination.
+func (h *EntryHandler) DiscoverEntries(c *gin.Context) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	page := 1
+	if v := c.Query("page"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 1 {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "page must be a positive integer")
+			return
+		}
+		page = p
+	}
+	limit := defaultListEntriesLimit
+	if v := c.Query("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l < 1 || l > maxListEntriesLimit {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "limit must be between 1 and 100")
+			return
+		}
+		limit = l
+	}
+	var after *discoverCursor
+	if v := c.Query("cursor"); v != "" {
+		cur, err := parseDiscoverCursor(v)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "cursor is invalid")
+			return
+		}
+		after = cur
+	}
+	tagKey := strings.TrimSpace(c.Query("tag"))
+	tagValue := strings.TrimSpace(c.Query("tagValue"))
+	if tagKey == "" && tagValue != "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "tagValue requires tag")
+		return
+	}
+	lang := strings.TrimSpace(c.Query("lang"))
+	if lang != "" && !contains(supportedLangs, lang) {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, fmt.Sprintf("lang must be one of %v", supportedLangs))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// Blocks are per caller, so so is the cache
+	cacheKey := fmt.Sprintf("discover:%s:%d:%d:%s:%s:%s:%s", userUID, page, limit, tagKey, tagValue, lang, c.Query("cursor"))
+	if cached, err := h.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+		var resp discovermodels.DiscoverEntriesResponse
+		if err := json.Unmarshal([]byte(cached), &resp); err == nil {
+			c.JSON(http.StatusOK, resp)
+			return
+		}
+	}
+
+	var total int
+	if after == nil {
+		if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM entries e WHERE `+discoverEntriesFilter, userUID, tagKey, tagValue, lang).Scan(&total); err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "count discover entries failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load entries")
+			return
+		}
+	}
+
+	// One row past the page tells a cursor request whether more follow
+	offset := (page - 1) * limit
+	var afterCreatedAt *time.Time
+	var afterID *string
+	if after != nil {
+		offset = 0
+		afterCreatedAt, afterID = &after.createdAt, &after.id
+	}
+	rows, err := h.postgres.Query(ctx, `
+		SELECT e.id, e.title, COALESCE(e.description, ''), e.encrypted, e.visibility, e.status, e.created_at, e.updated_at,
+		       e.user_uid, COALESCE(u.display_name, ''), COALESCE(u.photo_url, '')
+		FROM entries e
+		INNER JOIN users u ON u.uid = e.user_uid
+		WHERE `+discoverEntriesFilter+`
+			AND ($7::timestamp IS NULL OR (e.created_at, e.id) < ($7, $8::uuid))
+		ORDER BY e.created_at DESC, e.id DESC
+		LIMIT $5 OFFSET $6
+	`, userUID, tagKey, tagValue, lang, limit+1, offset, afterCreatedAt, afterID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "list discover entries failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load entries")
+		return
+	}
+	defer rows.Close()
+
+	// Encrypted entries are decrypted with their owner's key, and the page then isn't cached
+	cipher := newEntryCipher(h.postgres, h.entryKeys)
+	hasEncrypted := false
+	entries := []discovermodels.DiscoverEntry{}
+	for rows.Next() {
+		var entry discovermodels.DiscoverEntry
+		if err := rows.Scan(
+			&entry.ID, &entry.Title, &entry.Description, &entry.Encrypted, &entry.Visibility, &entry.Status, &entry.CreatedAt, &entry.UpdatedAt,
+			&entry.Owner.UID, &entry.Owner.DisplayName, &entry.Owner.PhotoURL,
+		); err != nil {
+			h.logError(c, err, "scan discover entry failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load entries")
+			return
+		}
+		if err := cipher.open(ctx, entry.Owner.UID, entry.Encrypted, &entry.Title, &entry.Description); err != nil {
+			h.logError(c, err, "decrypt entry failed", "entryId", entry.ID)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load entries")
+			return
+		}
+		hasEncrypted = hasEncrypted || entry.Encrypted
+		entry.Images = []string{}
+		entry.Audio = []string{}
+		entry.Videos = []models.Video{}
+		entry.Tags = []models.Tag{}
+		entry.Locations = []models.Location{}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "list discover entries failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load entries")
+		return
+	}
+	rows.Close()
+
+	hasMore := len(entries) > limit
+	if hasMore {
+		entries = entries[:limit]
+	}
+
+	if len(entries) > 0 {
+		entryIDs := make([]string, len(entries))
+		entryMap := make(map[string]*searchmodels.EntryResult, len(entries))
+		for i := range entries {
+			entryIDs[i] = entries[i].ID
+			entryMap[entries[i].ID] = &entries[i].EntryResult
+		}
+		if err := h.fetchRelatedDataForEntries(ctx, entryIDs, entryMap); err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "fetch entry details failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load entries")
+			return
+		}
+	}
+
+	resp := discovermodels.DiscoverEntriesResponse{Entries: entries}
+	if hasMore {
+		last := entries[len(entries)-1]
+		resp.NextCursor = encodeDiscoverCursor(last.CreatedAt, last.ID)
+	}
+	if after == nil {
+		totalPages := int(math.Ceil(float64(total) / float64(limit)))
+		resp.Pagination = &searchmodels.Pagination{
+			Page:        page,
+			Limit:       limit,
+			Total:       total,
+			TotalPages:  totalPages,
+			HasNext:     page < totalPages,
+			HasPrevious: page > 1,
+		}
+	}
+
+	if !hasEncrypted {
+		if data, err := json.Marshal(resp); err == nil {
+			_ = h.cache.Set(ctx, cacheKey, data, discoverCacheTTL)
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDiscoverCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2026, 3, 8, 9, 30, 0, 123456000, time.UTC)
+	id := "4b1c6a7e-2f5d-4c4b-9a8e-3d2f1e0c9b8a"
+	cur, err := parseDiscoverCursor(encodeDiscoverCursor(createdAt, id))
+	if err != nil {
+		t.Fatalf("parseDiscoverCursor: %v", err)
+	}
+	if !cur.createdAt.Equal(createdAt) || cur.id != id {
+		t.Errorf("got %+v", cur)
+	}
+	for _, bad := range []string{"", "not base64!", "bm8tc2VwYXJhdG9y", encodeDiscoverCursor(createdAt, "not-a-uuid")} {
+		if _, err := parseDiscoverCursor(bad); err == nil {
+			t.Errorf("parseDiscoverCursor(%q) succeeded", bad)
+		}
+	}
+}
+
+// Invalid queries are rejected before the cache or database is used
+func TestDiscoverEntriesValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &EntryHandler{}
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("uid", "alice") })
+	router.GET("/discover", h.DiscoverEntries)
+
+	for _, query := range []string{"page=0", "limit=101", "cursor=garbage", "tagValue=calm", "lang=xx", "lang=EN"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/discover?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, w.Code)
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 
 	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
 )
 
 // DownloadExportedData sends the completed export zip for the given exportJobId
@@ -14,39 +16,39 @@ import (
 func (h *AuthHandler) DownloadExportedData(c *gin.Context) {
 	uuidCtx, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 	authUID, ok := uuidCtx.(string)
 	if !ok || authUID == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	jobID := c.Query("exportJobId")
 	if jobID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required query parameter: exportJobId"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Missing required query parameter: exportJobId")
 		return
 	}
 
 	ctx := context.Background()
 	st, err := h.loadExportStatus(ctx, jobID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Export job not found"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Export job not found")
 		return
 	}
 	if st.UID != authUID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot download another user's export"})
+		respondError(c, http.StatusForbidden, apierror.CodeForbidden, "Cannot download another user's export")
 		return
 	}
 	if st.Status != "completed" || st.ZipPath == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Export is not ready for download"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Export is not ready for download")
 		return
 	}
 
 	// Ensure file exists
 	if _, err := os.Stat(st.ZipPath); os.IsNotExist(err) {
-		c.JSON(http.StatusGone, gin.H{"error": "Export file no longer exists"})
+		respondError(c, http.StatusGone, apierror.CodeNotFound, "Export file no longer exists")
 		return
 	}
 
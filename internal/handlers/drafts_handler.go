@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"io.winapps.journeyapp/internal/apierror"
+	createmodels "io.winapps.journeyapp/internal/models/create_entry"
+	draftmodels "io.winapps.journeyapp/internal/models/drafts"
+	"io.winapps.journeyapp/internal/premium"
+)
+
+// Values of entries.status. Only published entries appear in feeds, search results by
+// default, public links and to anyone but their owner.
+const (
+	entryStatusDraft     = "draft"
+	entryStatusPublished = "published"
+	entryStatusScheduled = "scheduled"
+)
+
+// SaveDraft creates an entry that only its owner can see until it is published. It takes
+// the same body as CreateEntry, but the title may be left empty until publishing.
+func (h *EntryHandler) SaveDraft(c *gin.Context) {
+	var req createmodels.CreateEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	if err := validateEntryInput(req.Title, req.Description, req.Tags); err != nil {
+		respondFieldTooLong(c, err)
+		return
+	}
+	if err := validateEntryImages(userUID, req.Images); err != nil {
+		respondInvalidMediaRef(c, err)
+		return
+	}
+
+	visibility := normalizeEntryVisibility(req.Visibility)
+
+	ctx := c.Request.Context()
+
+	normalize, err := tagNormalizationEnabled(ctx, h.postgres, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "settings lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load settings")
+		return
+	}
+	if normalize {
+		req.Tags = normalizeTagList(req.Tags)
+	}
+
+	// Semi-private entries can only be shared with friends
+	if visibility == "semi-private" {
+		invalid, err := h.checkShareTargets(ctx, userUID, req.SharedWith)
+		if err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "share target lookup failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify shared users")
+			return
+		}
+		if invalid != nil {
+			respondInvalidShareTargets(c, invalid)
+			return
+		}
+	}
+
+	// Drafts count toward the plan's limits like any other entry
+	policy, err := premium.ForUser(ctx, h.postgres, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "plan lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify account")
+		return
+	}
+	var entryCount int
+	if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM entries WHERE user_uid = $1`, userUID).Scan(&entryCount); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "count entries failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify account")
+		return
+	}
+	if denial := policy.CheckEntries(entryCount); denial != nil {
+		respondPlanLimit(c, denial)
+		return
+	}
+	if len(req.Images) > 0 {
+		if denial := policy.CheckImages(len(req.Images) - 1); denial != nil {
+			respondPlanLimit(c, denial)
+			return
+		}
+	}
+
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	cipher := newEntryCipher(h.postgres, h.entryKeys)
+	if err := cipher.lockUser(ctx, tx, userUID); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "encryption setting lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save draft")
+		return
+	}
+	text, err := cipher.seal(ctx, userUID, req.Title, req.Description)
+	if err != nil {
+		h.logError(c, err, "draft encryption failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save draft")
+		return
+	}
+
+	entryID := uuid.New().String()
+	now := time.Now()
+	if err := insertEntryRecords(ctx, tx, entryID, userUID, req, text, visibility, entryStatusDraft, now); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "insert draft failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, entryWriteMessage(err))
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "commit draft failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save draft")
+		return
+	}
+	h.invalidateEntryStatsCache(ctx, userUID)
+	h.invalidateUniqueLocationsCache(ctx, userUID)
+	invalidateAccountDetails(ctx, h.cache, userUID)
+
+	c.JSON(http.StatusCreated, createmodels.CreateEntryResponse{
+		ID:          entryID,
+		Title:       req.Title,
+		Description: req.Description,
+		Images:      req.Images,
+		Tags:        req.Tags,
+		Locations:   req.Locations,
+		Visibility:  visibility,
+		Status:      entryStatusDraft,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+}
+
+// PublishEntry publishes one of the user's drafts or scheduled entries. A publishAt in the
+// future schedules it instead, and the scheduled publishing job flips it once that time
+// passes; publishing a scheduled entry without publishAt publishes it right away.
+func (h *EntryHandler) PublishEntry(c *gin.Context) {
+	var req draftmodels.PublishEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ctx := c.Request.Context()
+
+	var title, visibility, status string
+	var shareCount int
+	err := h.postgres.QueryRow(ctx, `
+		SELECT e.title, e.visibility, e.status,
+			(SELECT COUNT(*) FROM entry_shares s WHERE s.entry_id = e.id)
+		FROM entries e WHERE e.id = $1 AND e.user_uid = $2
+	`, req.EntryID, userUID).Scan(&title, &visibility, &status, &shareCount)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+		return
+	}
+	if status == entryStatusPublished {
+		respondError(c, http.StatusConflict, apierror.CodeConflict, "Entry is already published")
+		return
+	}
+	if title == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Title is required before publishing")
+		return
+	}
+
+	// Checked now rather than when a scheduled entry goes out, so scheduling can't fail later
+	if entryReachesOthers(visibility, shareCount) && !h.requireVerifiedEmail(c, ctx, userUID) {
+		return
+	}
+
+	now := time.Now().UTC()
+	newStatus := entryStatusPublished
+	var publishAt *time.Time
+	if req.PublishAt != nil && req.PublishAt.After(now) {
+		t := req.PublishAt.UTC()
+		newStatus = entryStatusScheduled
+		publishAt = &t
+	}
+
+	// The status guard keeps a concurrent publish, or the scheduled job, from being overwritten
+	err = h.postgres.QueryRow(ctx, `
+		UPDATE entries SET status = $3, publish_at = $4, updated_at = $5
+		WHERE id = $1 AND user_uid = $2 AND status <> 'published'
+		RETURNING updated_at
+	`, req.EntryID, userUID, newStatus, publishAt, now).Scan(&now)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(c, http.StatusConflict, apierror.CodeConflict, "Entry is already published")
+		return
+	}
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "publish entry failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to publish entry")
+		return
+	}
+	_ = h.cache.Del(ctx, fmt.Sprintf("entry:%s", req.EntryID))
+	if newStatus == entryStatusPublished {
+		bumpFeedVersion(ctx, h.cache, userUID)
+	}
+
+	message := "Entry published"
+	if newStatus == entryStatusScheduled {
+		message = "Entry scheduled"
+	}
+	c.JSON(http.StatusOK, draftmodels.PublishEntryResponse{
+		ID:        req.EntryID,
+		Status:    newStatus,
+		PublishAt: publishAt,
+		UpdatedAt: now,
+		Message:   message,
+	})
+}
@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"io.winapps.journeyapp/internal/apierror"
+	duplicatemodels "io.winapps.journeyapp/internal/models/duplicate_entry"
+	"io.winapps.journeyapp/internal/premium"
+	"io.winapps.journeyapp/internal/storage"
+)
+
+// entryMediaRow is an images or audio row being copied to a duplicated entry
+type entryMediaRow struct {
+	url         string
+	filename    *string
+	fileSize    *int64
+	mimeType    *string
+	width       *int // images only
+	height      *int // images only
+	duration    *int // audio and videos
+	thumbnail   *string // videos only
+	uploadOrder int
+}
+
+// DuplicateEntry copies an entry the caller owns into a new private entry with fresh ids
+// and timestamps. Title, description, tags and locations are always copied; with
+// copyMedia the image, audio and video files are also copied under the new entry's directory.
+func (h *EntryHandler) DuplicateEntry(c *gin.Context) {
+	var req duplicatemodels.DuplicateEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ctx := c.Request.Context()
+
+	// The text is copied as stored; an encrypted entry stays sealed under the same key
+	var text storedEntryText
+	err := h.postgres.QueryRow(ctx, `
+		SELECT title, COALESCE(description, ''), encrypted FROM entries WHERE id = $1 AND user_uid = $2
+	`, req.EntryID, userUID).Scan(&text.title, &text.description, &text.encrypted)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		// Missing, malformed and other users' entry IDs all look the same to the caller
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+		return
+	}
+
+	policy, err := premium.ForUser(ctx, h.postgres, userUID)
+	if err != nil {
+		h.logError(c, err, "plan lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify account")
+		return
+	}
+	var entryCount int
+	if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM entries WHERE user_uid = $1`, userUID).Scan(&entryCount); err != nil {
+		h.logError(c, err, "count entries failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify account")
+		return
+	}
+	if denial := policy.CheckEntries(entryCount); denial != nil {
+		respondPlanLimit(c, denial)
+		return
+	}
+
+	newEntryID := uuid.New().String()
+	now := time.Now()
+
+	var images, audio, videos []entryMediaRow
+	if req.CopyMedia {
+		images, audio, err = h.loadEntryMedia(ctx, req.EntryID)
+		if err == nil {
+			videos, err = h.loadEntryVideos(ctx, req.EntryID)
+		}
+		if err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "load entry media failed", "entryId", req.EntryID)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load entry media")
+			return
+		}
+		if len(images) > 0 {
+			if denial := policy.CheckImages(len(images) - 1); denial != nil {
+				respondPlanLimit(c, denial)
+				return
+			}
+		}
+		if len(audio) > 0 {
+			if denial := policy.CheckAudio(len(audio) - 1); denial != nil {
+				respondPlanLimit(c, denial)
+				return
+			}
+		}
+		if len(videos) > 0 {
+			if denial := policy.CheckVideos(len(videos) - 1); denial != nil {
+				respondPlanLimit(c, denial)
+				return
+			}
+		}
+
+		// Copy files before the transaction; the copies are removed if anything fails
+		images, err = h.copyEntryMediaFiles(ctx, images, "images", userUID, req.EntryID, newEntryID, policy.Limits.MaxImageBytes)
+		if err == nil {
+			audio, err = h.copyEntryMediaFiles(ctx, audio, "audio", userUID, req.EntryID, newEntryID, policy.Limits.MaxAudioBytes)
+		}
+		if err == nil {
+			videos, err = h.copyEntryMediaFiles(ctx, videos, "videos", userUID, req.EntryID, newEntryID, policy.Limits.MaxVideoBytes)
+		}
+		if err != nil {
+			h.removeEntryMediaDirs(ctx, userUID, newEntryID)
+			h.logError(c, err, "copy entry media failed", "entryId", req.EntryID)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to copy entry media")
+			return
+		}
+	}
+
+	if err := h.insertDuplicateEntry(ctx, req.EntryID, newEntryID, userUID, text, images, audio, videos, now); err != nil {
+		h.removeEntryMediaDirs(ctx, userUID, newEntryID)
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "duplicate entry failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to duplicate entry")
+		return
+	}
+
+	userEntriesKey := fmt.Sprintf("user_entries:%s", userUID)
+	_ = h.cache.SAdd(ctx, userEntriesKey, newEntryID)
+	_ = h.cache.Expire(ctx, userEntriesKey, 24*time.Hour)
+	h.invalidateEntryStatsCache(ctx, userUID)
+	h.invalidateUniqueLocationsCache(ctx, userUID)
+	invalidateAccountDetails(ctx, h.cache, userUID)
+
+	entry, err := h.fetchEntryWithDetails(ctx, newEntryID, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "fetch duplicated entry failed", "entryId", newEntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Entry was duplicated but could not be loaded")
+		return
+	}
+
+	c.JSON(http.StatusCreated, duplicatemodels.DuplicateEntryResponse{
+		SourceEntryID: req.EntryID,
+		Entry:         entry,
+		Message:       "Entry duplicated successfully",
+	})
+}
+
+// insertDuplicateEntry writes the new entry, its copied tags and locations, and the
+// already-copied media rows in one transaction
+func (h *EntryHandler) insertDuplicateEntry(ctx context.Context, sourceID, entryID, userUID string, text storedEntryText, images, audio, videos []entryMediaRow, now time.Time) error {
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO entries (id, user_uid, title, description, encrypted, visibility, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, 'private', $6, $6)
+	`, entryID, userUID, text.title, text.description, text.encrypted, now); err != nil {
+		return fmt.Errorf("insert entry: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO tags (entry_id, key, value, created_at)
+		SELECT $1, key, value, $3 FROM tags WHERE entry_id = $2
+	`, entryID, sourceID, now); err != nil {
+		return fmt.Errorf("copy tags: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO locations (entry_id, latitude, longitude, address, city, state, zip, country, country_code, display_name, created_at)
+		SELECT $1, latitude, longitude, address, city, state, zip, country, country_code, display_name, $3
+		FROM locations WHERE entry_id = $2
+	`, entryID, sourceID, now); err != nil {
+		return fmt.Errorf("copy locations: %w", err)
+	}
+
+	for _, img := range images {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO images (entry_id, url, filename, file_size, mime_type, width, height, upload_order, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, entryID, img.url, img.filename, img.fileSize, img.mimeType, img.width, img.height, img.uploadOrder, now); err != nil {
+			return fmt.Errorf("insert image: %w", err)
+		}
+	}
+
+	for _, a := range audio {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO audio (entry_id, url, filename, file_size, mime_type, duration, upload_order, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, entryID, a.url, a.filename, a.fileSize, a.mimeType, a.duration, a.uploadOrder, now); err != nil {
+			return fmt.Errorf("insert audio: %w", err)
+		}
+	}
+
+	for _, v := range videos {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO videos (entry_id, url, filename, file_size, mime_type, duration, thumbnail_url, upload_order, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, entryID, v.url, v.filename, v.fileSize, v.mimeType, v.duration, v.thumbnail, v.uploadOrder, now); err != nil {
+			return fmt.Errorf("insert video: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// loadEntryMedia returns the entry's image and audio rows in upload order
+func (h *EntryHandler) loadEntryMedia(ctx context.Context, entryID string) ([]entryMediaRow, []entryMediaRow, error) {
+	rows, err := h.postgres.Query(ctx, `
+		SELECT url, filename, file_size, mime_type, width, height, COALESCE(upload_order, 0)
+		FROM images WHERE entry_id = $1 ORDER BY upload_order, created_at
+	`, entryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var images []entryMediaRow
+	for rows.Next() {
+		var m entryMediaRow
+		if err := rows.Scan(&m.url, &m.filename, &m.fileSize, &m.mimeType, &m.width, &m.height, &m.uploadOrder); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		images = append(images, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err = h.postgres.Query(ctx, `
+		SELECT url, filename, file_size, mime_type, duration, COALESCE(upload_order, 0)
+		FROM audio WHERE entry_id = $1 ORDER BY upload_order, created_at
+	`, entryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var audio []entryMediaRow
+	for rows.Next() {
+		var m entryMediaRow
+		if err := rows.Scan(&m.url, &m.filename, &m.fileSize, &m.mimeType, &m.duration, &m.uploadOrder); err != nil {
+			return nil, nil, err
+		}
+		audio = append(audio, m)
+	}
+	return images, audio, rows.Err()
+}
+
+// loadEntryVideos returns the entry's video rows in upload order
+func (h *EntryHandler) loadEntryVideos(ctx context.Context, entryID string) ([]entryMediaRow, error) {
+	rows, err := h.postgres.Query(ctx, `
+		SELECT url, filename, file_size, mime_type, duration, thumbnail_url, COALESCE(upload_order, 0)
+		FROM videos WHERE entry_id = $1 ORDER BY upload_order, created_at
+	`, entryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var videos []entryMediaRow
+	for rows.Next() {
+		var m entryMediaRow
+		if err := rows.Scan(&m.url, &m.filename, &m.fileSize, &m.mimeType, &m.duration, &m.thumbnail, &m.uploadOrder); err != nil {
+			return nil, err
+		}
+		videos = append(videos, m)
+	}
+	return videos, rows.Err()
+}
+
+// copyEntryMediaFiles copies each row's file to <kind>/<uid>/<entryID>/ in media storage
+// and returns the rows pointing at the new URLs. Only files in the source entry's own
+// directory, <kind>/<uid>/<srcEntryID>/, are copied: rows pointing anywhere else, such as
+// at another user's file, are dropped, as are rows whose source file is gone. Files over
+// maxBytes (e.g. after a plan downgrade) fail the copy. Video posters are copied along
+// with their clip under the same rule, and dropped from the row otherwise.
+func (h *EntryHandler) copyEntryMediaFiles(ctx context.Context, rows []entryMediaRow, kind, userUID, srcEntryID, entryID string, maxBytes int64) ([]entryMediaRow, error) {
+	copied := make([]entryMediaRow, 0, len(rows))
+	for _, row := range rows {
+		srcKey, err := mediaKeyFromURL(row.url, kind, userUID, srcEntryID)
+		if err != nil {
+			continue
+		}
+		info, err := h.media.Stat(ctx, srcKey)
+		if errors.Is(err, storage.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if info.Size > maxBytes {
+			return nil, fmt.Errorf("copy %s: %w (%d MB)", row.url, errMediaTooLarge, maxBytes>>20)
+		}
+		filename := uuid.New().String() + path.Ext(srcKey)
+		if err := h.media.Copy(ctx, srcKey, path.Join(kind, userUID, entryID, filename)); err != nil {
+			return nil, fmt.Errorf("copy %s: %w", row.url, err)
+		}
+		row.url = fmt.Sprintf("/%s/%s/%s/%s", kind, userUID, entryID, filename)
+		if row.thumbnail != nil {
+			posterKey, err := mediaKeyFromURL(*row.thumbnail, kind, userUID, srcEntryID)
+			row.thumbnail = nil
+			if err == nil {
+				_, err = h.media.Stat(ctx, posterKey)
+				if err != nil && !errors.Is(err, storage.ErrNotExist) {
+					return nil, err
+				}
+			}
+			if err == nil {
+				posterURL := videoPosterURL(row.url)
+				if err := h.media.Copy(ctx, posterKey, path.Join(kind, userUID, entryID, path.Base(posterURL))); err != nil {
+					return nil, fmt.Errorf("copy %s: %w", posterURL, err)
+				}
+				row.thumbnail = &posterURL
+			}
+		}
+		copied = append(copied, row)
+	}
+	return copied, nil
+}
+
+// removeEntryMediaDirs deletes everything stored for an entry's images, audio and videos. It
+// runs even if the request was cancelled, since that is often why it's cleaning up.
+func (h *EntryHandler) removeEntryMediaDirs(ctx context.Context, userUID, entryID string) {
+	ctx = context.WithoutCancel(ctx)
+	_ = h.media.DeleteAll(ctx, path.Join("images", userUID, entryID))
+	_ = h.media.DeleteAll(ctx, path.Join("audio", userUID, entryID))
+	_ = h.media.DeleteAll(ctx, path.Join("videos", userUID, entryID))
+}
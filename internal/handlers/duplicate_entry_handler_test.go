@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"io.winapps.journeyapp/internal/storage"
+)
+
+func putMedia(t *testing.T, store storage.Store, key, body string) {
+	t.Helper()
+	if err := store.Put(context.Background(), key, strings.NewReader(body), int64(len(body)), "application/octet-stream"); err != nil {
+		t.Fatalf("put %s: %v", key, err)
+	}
+}
+
+func TestCopyEntryMediaFilesSkipsFilesOutsideTheSourceEntry(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocal(t.TempDir())
+	h := &EntryHandler{media: store}
+
+	putMedia(t, store, "images/alice/src/own.jpg", "own")
+	putMedia(t, store, "images/alice/other/elsewhere.jpg", "elsewhere")
+	putMedia(t, store, "images/bob/private/secret.jpg", "secret")
+
+	rows := []entryMediaRow{
+		{url: "/images/alice/src/own.jpg"},
+		{url: "/images/bob/private/secret.jpg"},
+		{url: "/images/alice/src/../../bob/private/secret.jpg"},
+		{url: "/images/alice/other/elsewhere.jpg"},
+	}
+	copied, err := h.copyEntryMediaFiles(ctx, rows, "images", "alice", "src", "dst", 1<<20)
+	if err != nil {
+		t.Fatalf("copyEntryMediaFiles: %v", err)
+	}
+	if len(copied) != 1 || !strings.HasPrefix(copied[0].url, "/images/alice/dst/") {
+		t.Fatalf("copied = %+v, want only the source entry's own file", copied)
+	}
+
+	var names []string
+	if err := store.Walk(ctx, "images/alice/dst", func(info storage.Info) error {
+		names = append(names, info.Key)
+		return nil
+	}); err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if len(names) != 1 {
+		t.Fatalf("files in the new entry = %v, want one", names)
+	}
+	obj, err := store.Open(ctx, names[0])
+	if err != nil {
+		t.Fatalf("open copy: %v", err)
+	}
+	defer obj.Close()
+	buf := make([]byte, 16)
+	n, _ := obj.Read(buf)
+	if got := string(buf[:n]); got != "own" {
+		t.Fatalf("copied file holds %q, want %q", got, "own")
+	}
+}
+
+func TestCopyEntryMediaFilesDropsForeignPosters(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocal(t.TempDir())
+	h := &EntryHandler{media: store}
+
+	putMedia(t, store, "videos/alice/src/clip.mp4", "clip")
+	putMedia(t, store, "videos/bob/private/poster.jpg", "poster")
+
+	poster := "/videos/bob/private/poster.jpg"
+	copied, err := h.copyEntryMediaFiles(ctx, []entryMediaRow{{url: "/videos/alice/src/clip.mp4", thumbnail: &poster}}, "videos", "alice", "src", "dst", 1<<20)
+	if err != nil {
+		t.Fatalf("copyEntryMediaFiles: %v", err)
+	}
+	if len(copied) != 1 || copied[0].thumbnail != nil {
+		t.Fatalf("copied = %+v, want the clip without the foreign poster", copied)
+	}
+	files := 0
+	if err := store.Walk(ctx, "videos/alice/dst", func(storage.Info) error {
+		files++
+		return nil
+	}); err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if files != 1 {
+		t.Fatalf("new entry holds %d files, want only the clip", files)
+	}
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	firebaseauth "firebase.google.com/go/v4/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"io.winapps.journeyapp/internal/apierror"
+	firebaseutil "io.winapps.journeyapp/internal/firebase"
+	"io.winapps.journeyapp/internal/mailer"
+	emailchangemodels "io.winapps.journeyapp/internal/models/email_change"
+)
+
+const (
+	// emailChangeTTL is how long a confirmation code stays valid
+	emailChangeTTL = 30 * time.Minute
+	// emailChangeMaxAttempts wrong codes cancel the pending change
+	emailChangeMaxAttempts = 5
+)
+
+// pendingEmailChange is stored under email_change:<uid> until the new address is confirmed
+type pendingEmailChange struct {
+	Email     string    `json:"email"`
+	CodeHash  string    `json:"codeHash"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func emailChangeKey(uid string) string {
+	return "email_change:" + uid
+}
+
+func emailChangeAttemptsKey(uid string) string {
+	return "email_change_attempts:" + uid
+}
+
+func hashEmailChangeCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequestEmailChange emails a confirmation code to the address the user wants to switch
+// to. Nothing changes until ConfirmEmailChange, which updates Firebase and users.email
+// together, so the two never disagree. A new request replaces any pending one.
+func (h *AuthHandler) RequestEmailChange(c *gin.Context) {
+	var req emailchangemodels.RequestEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "email is required")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	addr, err := mail.ParseAddress(strings.TrimSpace(req.Email))
+	if err != nil || addr.Name != "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid email address")
+		return
+	}
+	newEmail := strings.ToLower(addr.Address)
+
+	ctx := c.Request.Context()
+
+	var currentEmail string
+	err = h.postgres.QueryRow(ctx, `SELECT email FROM users WHERE uid = $1`, userUID).Scan(&currentEmail)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "load user email failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch user")
+		return
+	}
+	if strings.EqualFold(currentEmail, newEmail) {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "That is already your email address")
+		return
+	}
+
+	// Check both stores up front so the user isn't sent a code that can't be used
+	var taken bool
+	if err := h.postgres.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(email) = $1 AND uid <> $2)
+	`, newEmail, userUID).Scan(&taken); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "check email in use failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check email address")
+		return
+	}
+	authClient, err := firebaseutil.GetAuthClient(h.firebaseApp)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to initialize auth client")
+		return
+	}
+	if !taken {
+		existing, err := authClient.GetUserByEmail(ctx, newEmail)
+		if err != nil && !firebaseauth.IsUserNotFound(err) {
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "Firebase email lookup failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check email address")
+			return
+		}
+		taken = existing != nil && existing.UID != userUID
+	}
+	if taken {
+		respondError(c, http.StatusConflict, apierror.CodeConflict, "That email address is already in use")
+		return
+	}
+
+	cooldownKey := fmt.Sprintf("email_change_sent:%s", userUID)
+	if ok, err := h.cache.SetNX(ctx, cooldownKey, "1", emailVerificationCooldown); err == nil && !ok {
+		respondError(c, http.StatusTooManyRequests, apierror.CodeRateLimited, "A confirmation code was sent recently; please wait before requesting another")
+		return
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		h.cache.Del(ctx, cooldownKey)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to generate confirmation code")
+		return
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	pending := pendingEmailChange{
+		Email:     newEmail,
+		CodeHash:  hashEmailChangeCode(code),
+		ExpiresAt: time.Now().Add(emailChangeTTL).UTC(),
+	}
+	data, _ := json.Marshal(pending)
+	if err := h.cache.Set(ctx, emailChangeKey(userUID), data, emailChangeTTL); err != nil {
+		h.cache.Del(ctx, cooldownKey)
+		h.logError(c, err, "store pending email change failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start email change")
+		return
+	}
+	h.cache.Del(ctx, emailChangeAttemptsKey(userUID))
+
+	sender, err := mailer.NewFromEnv()
+	if err != nil {
+		h.cache.Del(ctx, cooldownKey, emailChangeKey(userUID))
+		h.logError(c, err, "Email sender is not configured")
+		respondError(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, "Email delivery is not configured")
+		return
+	}
+	body := fmt.Sprintf("Your Journey confirmation code is:\n\n%s\n\nEnter it in the app within %d minutes to change your account's email address to %s.\n\nIf you didn't ask to change your email, you can ignore this email; your account is unchanged.\n", code, int(emailChangeTTL.Minutes()), newEmail)
+	if err := sender.Send(ctx, newEmail, "Confirm your new email for Journey", body); err != nil {
+		h.cache.Del(ctx, cooldownKey, emailChangeKey(userUID))
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "Failed to send email change code")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to send confirmation email")
+		return
+	}
+
+	c.JSON(http.StatusOK, emailchangemodels.RequestEmailChangeResponse{
+		PendingEmail: newEmail,
+		ExpiresAt:    pending.ExpiresAt,
+		Message:      "Confirmation code sent to the new address",
+	})
+}
+
+// ConfirmEmailChange checks the code sent by RequestEmailChange and switches the account to
+// the new, now verified, address. Firebase is updated first; if the database update then
+// fails, Firebase is put back so the two stay in sync.
+func (h *AuthHandler) ConfirmEmailChange(c *gin.Context) {
+	var req emailchangemodels.ConfirmEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "code is required")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ctx := c.Request.Context()
+
+	raw, err := h.cache.Get(ctx, emailChangeKey(userUID))
+	var pending pendingEmailChange
+	if err != nil || json.Unmarshal([]byte(raw), &pending) != nil || time.Now().After(pending.ExpiresAt) {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "No pending email change; request a new code")
+		return
+	}
+
+	attempts, err := h.cache.Incr(ctx, emailChangeAttemptsKey(userUID), emailChangeTTL)
+	if err != nil {
+		h.logError(c, err, "count email change attempts failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to confirm email change")
+		return
+	}
+	if attempts > emailChangeMaxAttempts {
+		h.cache.Del(ctx, emailChangeKey(userUID), emailChangeAttemptsKey(userUID))
+		respondError(c, http.StatusTooManyRequests, apierror.CodeRateLimited, "Too many incorrect codes; request a new one")
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(hashEmailChangeCode(strings.TrimSpace(req.Code))), []byte(pending.CodeHash)) != 1 {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Incorrect confirmation code")
+		return
+	}
+
+	var oldEmail string
+	var oldVerified bool
+	err = h.postgres.QueryRow(ctx, `
+		SELECT email, COALESCE(email_verified, FALSE) FROM users WHERE uid = $1
+	`, userUID).Scan(&oldEmail, &oldVerified)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "load user email failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to confirm email change")
+		return
+	}
+
+	authClient, err := firebaseutil.GetAuthClient(h.firebaseApp)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to initialize auth client")
+		return
+	}
+	params := (&firebaseauth.UserToUpdate{}).Email(pending.Email).EmailVerified(true)
+	if _, err := authClient.UpdateUser(ctx, userUID, params); err != nil {
+		if firebaseauth.IsEmailAlreadyExists(err) {
+			respondError(c, http.StatusConflict, apierror.CodeConflict, "That email address is already in use")
+			return
+		}
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "Firebase email update failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update email")
+		return
+	}
+
+	// The Firebase change is done; don't let a client disconnect strand it half applied
+	dbCtx := context.WithoutCancel(ctx)
+	_, err = h.postgres.Exec(dbCtx, `
+		UPDATE users SET email = $2, email_verified = TRUE, updated_at = NOW() WHERE uid = $1
+	`, userUID, pending.Email)
+	if err != nil {
+		revert := (&firebaseauth.UserToUpdate{}).Email(oldEmail).EmailVerified(oldVerified)
+		if _, rerr := authClient.UpdateUser(dbCtx, userUID, revert); rerr != nil {
+			h.logError(c, rerr, "Firebase email revert failed; Firebase and database emails differ", "uid", userUID)
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			respondError(c, http.StatusConflict, apierror.CodeConflict, "That email address is already in use")
+			return
+		}
+		h.logError(c, err, "update user email failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update email")
+		return
+	}
+
+	h.cache.Del(dbCtx, emailChangeKey(userUID), emailChangeAttemptsKey(userUID))
+	invalidateAccountDetails(dbCtx, h.cache, userUID)
+
+	c.JSON(http.StatusOK, emailchangemodels.ConfirmEmailChangeResponse{
+		Email:         pending.Email,
+		EmailVerified: true,
+		Message:       "Email address updated",
+	})
+}
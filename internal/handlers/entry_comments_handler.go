@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"io.winapps.journeyapp/internal/apierror"
+	commentmodels "io.winapps.journeyapp/internal/models/entry_comments"
+)
+
+const maxCommentLength = 2000
+
+var errEntryNotAccessible = errors.New("entry not found")
+
+// AddComment adds a comment to an entry visible to the authenticated user
+func (h *EntryHandler) AddComment(c *gin.Context) {
+	var req commentmodels.AddCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+
+	userUID, ok := uid.(string)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
+		return
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Comment body is required")
+		return
+	}
+	if len([]rune(body)) > maxCommentLength {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Comment is too long")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	ownerUID, err := h.entryOwnerIfVisible(ctx, req.EntryID, userUID)
+	if err != nil {
+		if errors.Is(err, errEntryNotAccessible) {
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+			return
+		}
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to add comment")
+		return
+	}
+
+	comment := commentmodels.Comment{EntryID: req.EntryID, AuthorUID: userUID, Body: body}
+	query := `
+		WITH inserted AS (
+			INSERT INTO entry_comments (entry_id, author_uid, body)
+			VALUES ($1, $2, $3)
+			RETURNING id, created_at
+		)
+		SELECT inserted.id, inserted.created_at, COALESCE(u.display_name, ''), COALESCE(u.photo_url, '')
+		FROM inserted
+		LEFT JOIN users u ON u.uid = $2
+	`
+	if err := h.postgres.QueryRow(ctx, query, req.EntryID, userUID, body).Scan(
+		&comment.ID,
+		&comment.CreatedAt,
+		&comment.DisplayName,
+		&comment.PhotoURL,
+	); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "Failed to add comment", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to add comment")
+		return
+	}
+
+	// Let the owner know someone commented, without blocking the response
+	if h.notifications != nil && ownerUID != userUID {
+		go func(recipient, commenterName, entryID, preview string) {
+			if err := h.notifications.SendCommentNotification(recipient, commenterName, entryID, preview); err != nil && h.logger != nil {
+				h.logger.Warnw("Failed to send comment notification", "recipient", recipient, "entryId", entryID, "error", err)
+			}
+		}(ownerUID, comment.DisplayName, req.EntryID, commentPreview(body))
+	}
+
+	c.JSON(http.StatusCreated, commentmodels.AddCommentResponse{
+		Success: true,
+		Message: "Comment added successfully",
+		Comment: comment,
+	})
+}
+
+// GetComments returns a page of comments for an entry visible to the authenticated user
+func (h *EntryHandler) GetComments(c *gin.Context) {
+	var req commentmodels.GetCommentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+
+	userUID, ok := uid.(string)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
+		return
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	ctx := c.Request.Context()
+
+	if _, err := h.entryOwnerIfVisible(ctx, req.EntryID, userUID); err != nil {
+		if errors.Is(err, errEntryNotAccessible) {
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+			return
+		}
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch comments")
+		return
+	}
+
+	var total int
+	if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM entry_comments WHERE entry_id = $1`, req.EntryID).Scan(&total); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to count comments")
+		return
+	}
+
+	query := `
+		SELECT ec.id, ec.entry_id, ec.author_uid, COALESCE(u.display_name, ''), COALESCE(u.photo_url, ''), ec.body, ec.created_at
+		FROM entry_comments ec
+		LEFT JOIN users u ON u.uid = ec.author_uid
+		WHERE ec.entry_id = $1
+		ORDER BY ec.created_at ASC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := h.postgres.Query(ctx, query, req.EntryID, req.Limit, (req.Page-1)*req.Limit)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch comments")
+		return
+	}
+	defer rows.Close()
+
+	comments := []commentmodels.Comment{}
+	for rows.Next() {
+		var comment commentmodels.Comment
+		if err := rows.Scan(
+			&comment.ID,
+			&comment.EntryID,
+			&comment.AuthorUID,
+			&comment.DisplayName,
+			&comment.PhotoURL,
+			&comment.Body,
+			&comment.CreatedAt,
+		); err != nil {
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read comments")
+			return
+		}
+		comments = append(comments, comment)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(req.Limit)))
+	c.JSON(http.StatusOK, commentmodels.GetCommentsResponse{
+		Comments: comments,
+		Pagination: commentmodels.Pagination{
+			Page:        req.Page,
+			Limit:       req.Limit,
+			Total:       total,
+			TotalPages:  totalPages,
+			HasNext:     req.Page < totalPages,
+			HasPrevious: req.Page > 1,
+		},
+	})
+}
+
+// DeleteComment deletes a comment; only the comment author or the entry owner may do so
+func (h *EntryHandler) DeleteComment(c *gin.Context) {
+	var req commentmodels.DeleteCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+
+	userUID, ok := uid.(string)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	var authorUID, ownerUID string
+	lookupQuery := `
+		SELECT ec.author_uid, e.user_uid
+		FROM entry_comments ec
+		JOIN entries e ON e.id = ec.entry_id
+		WHERE ec.id = $1
+	`
+	if err := h.postgres.QueryRow(ctx, lookupQuery, req.CommentID).Scan(&authorUID, &ownerUID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Comment not found")
+			return
+		}
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete comment")
+		return
+	}
+
+	if userUID != authorUID && userUID != ownerUID {
+		respondError(c, http.StatusForbidden, apierror.CodeForbidden, "Only the comment author or entry owner can delete this comment")
+		return
+	}
+
+	if _, err := h.postgres.Exec(ctx, `DELETE FROM entry_comments WHERE id = $1`, req.CommentID); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "Failed to delete comment", "commentId", req.CommentID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete comment")
+		return
+	}
+
+	c.JSON(http.StatusOK, commentmodels.DeleteCommentResponse{IsDeleted: true, Message: "Comment deleted successfully"})
+}
+
+// entryOwnerIfVisible returns the entry owner's UID if userUID may view the entry,
+// applying the same visibility rules as GetEntry. Users either side has blocked can't see
+// each other's entries, public ones included, and entries hidden by moderation are seen
+// only by their owner. Missing and malformed entry IDs give errEntryNotAccessible;
+// database errors are returned as they are.
+func (h *EntryHandler) entryOwnerIfVisible(ctx context.Context, entryID, userUID string) (string, error) {
+	if _, err := uuid.Parse(entryID); err != nil {
+		return "", errEntryNotAccessible
+	}
+
+	var ownerUID, visibility, status string
+	var hidden, blocked bool
+	err := h.postgres.QueryRow(ctx, `
+		SELECT e.user_uid, e.visibility, e.status, e.moderation_hidden,
+			EXISTS (
+				SELECT 1 FROM friendships b
+				WHERE b.status = 'blocked'
+					AND ((b.uid = $2 AND b.fid = e.user_uid) OR (b.uid = e.user_uid AND b.fid = $2))
+			)
+		FROM entries e
+		WHERE e.id = $1
+	`, entryID, userUID).Scan(&ownerUID, &visibility, &status, &hidden, &blocked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", errEntryNotAccessible
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if userUID == ownerUID {
+		return ownerUID, nil
+	}
+	// Drafts, scheduled entries and entries hidden by moderation are visible only to their owner
+	if status != entryStatusPublished || hidden || blocked {
+		return "", errEntryNotAccessible
+	}
+
+	switch strings.ToLower(strings.TrimSpace(visibility)) {
+	case "public":
+		return ownerUID, nil
+	case "semi-private":
+		var allowed int
+		err := h.postgres.QueryRow(ctx, `SELECT 1 FROM entry_shares WHERE entry_id = $1 AND shared_user_uid = $2`, entryID, userUID).Scan(&allowed)
+		if err == nil {
+			return ownerUID, nil
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", errEntryNotAccessible
+		}
+		return "", err
+	default:
+		return "", errEntryNotAccessible
+	}
+}
+
+// commentPreview truncates a comment body for use in a push notification
+func commentPreview(body string) string {
+	runes := []rune(body)
+	if len(runes) <= 100 {
+		return body
+	}
+	return string(runes[:100]) + "..."
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/cache"
+	"io.winapps.journeyapp/internal/storage"
+)
+
+// A public entry hidden by moderation disappears for everyone but its owner: the entry
+// itself, its comments, its media and any public link to it
+func TestModerationHiddenEntryVisibleOnlyToOwner(t *testing.T) {
+	pool := testPool(t)
+	store := storage.NewLocal(t.TempDir())
+	h := NewEntryHandler(nil, pool, cache.NewMemory(100), nil)
+	h.SetMediaStore(store)
+	alice := testUser(t, pool, "alice")
+	bob := testUser(t, pool, "bob")
+	entryID := testEntry(t, pool, alice, "Lisbon", "public")
+	putMedia(t, store, "images/"+alice+"/"+entryID+"/a.jpg", "jpeg")
+	mustExec(t, pool, `INSERT INTO entry_public_links (token, entry_id, user_uid) VALUES ($1, $2, $3)`, "tok-"+entryID, entryID, alice)
+	body := `{"entryId":"` + entryID + `"}`
+
+	serveImage := func(uid string) int {
+		router := gin.New()
+		router.GET("/images/:uid/:entryId/:file", func(c *gin.Context) { c.Set("uid", uid) }, h.ServeImage)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/images/"+alice+"/"+entryID+"/a.jpg", nil))
+		return w.Code
+	}
+	sharedLink := func() int {
+		router := gin.New()
+		router.GET("/shared/:token", h.GetSharedEntry)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/shared/tok-"+entryID, nil))
+		return w.Code
+	}
+	check := func(uid string, want int) {
+		t.Helper()
+		if w := callAs(uid, h.GetEntry, http.MethodPost, "/get-entry", body); w.Code != want {
+			t.Errorf("GetEntry as %s = %d, want %d", uid, w.Code, want)
+		}
+		if w := callAs(uid, h.GetComments, http.MethodPost, "/get-comments", body); w.Code != want {
+			t.Errorf("GetComments as %s = %d, want %d", uid, w.Code, want)
+		}
+		if code := serveImage(uid); code != want {
+			t.Errorf("ServeImage as %s = %d, want %d", uid, code, want)
+		}
+	}
+
+	check(bob, http.StatusOK)
+	if code := sharedLink(); code != http.StatusOK {
+		t.Fatalf("public link before hiding = %d", code)
+	}
+
+	mustExec(t, pool, `UPDATE entries SET moderation_hidden = TRUE WHERE id = $1`, entryID)
+	check(bob, http.StatusNotFound)
+	check(alice, http.StatusOK)
+	if code := sharedLink(); code != http.StatusNotFound {
+		t.Errorf("public link to a hidden entry = %d, want 404", code)
+	}
+}
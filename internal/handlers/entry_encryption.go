@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"io.winapps.journeyapp/internal/encryption"
+)
+
+// storedEntryText is an entry's title and description as written to the entries table:
+// sealed when encrypted is set, plaintext otherwise
+type storedEntryText struct {
+	title       string
+	description string
+	encrypted   bool
+}
+
+// userEncryption is a user's entry encryption setting and key salt
+type userEncryption struct {
+	enabled bool
+	salt    []byte
+}
+
+// entryCipher seals and opens entry text on behalf of their owners. It remembers each
+// user's setting, so one value should serve a single request or job.
+type entryCipher struct {
+	db    *pgxpool.Pool
+	keys  *encryption.Keyring
+	users map[string]userEncryption
+}
+
+func newEntryCipher(db *pgxpool.Pool, keys *encryption.Keyring) *entryCipher {
+	return &entryCipher{db: db, keys: keys, users: make(map[string]userEncryption)}
+}
+
+// user loads uid's encryption setting. Unknown users have encryption off.
+func (ec *entryCipher) user(ctx context.Context, uid string) (userEncryption, error) {
+	if u, ok := ec.users[uid]; ok {
+		return u, nil
+	}
+	var u userEncryption
+	err := ec.db.QueryRow(ctx, `
+		SELECT COALESCE(encryption_enabled, false), encryption_salt FROM users WHERE uid = $1
+	`, uid).Scan(&u.enabled, &u.salt)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return userEncryption{}, err
+	}
+	ec.users[uid] = u
+	return u, nil
+}
+
+// lockUser loads uid's setting inside tx and holds the users row FOR SHARE until tx ends,
+// so SetEntryEncryption can't convert the user's entries between sealing and writing.
+// Writers call it before seal.
+func (ec *entryCipher) lockUser(ctx context.Context, tx pgx.Tx, uid string) error {
+	var u userEncryption
+	err := tx.QueryRow(ctx, `
+		SELECT COALESCE(encryption_enabled, false), encryption_salt FROM users WHERE uid = $1 FOR SHARE
+	`, uid).Scan(&u.enabled, &u.salt)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	ec.users[uid] = u
+	return nil
+}
+
+// enabled reports whether uid has entry encryption turned on
+func (ec *entryCipher) enabled(ctx context.Context, uid string) (bool, error) {
+	u, err := ec.user(ctx, uid)
+	return u.enabled, err
+}
+
+// seal returns title and description as they should be stored for uid
+func (ec *entryCipher) seal(ctx context.Context, uid, title, description string) (storedEntryText, error) {
+	u, err := ec.user(ctx, uid)
+	if err != nil || !u.enabled {
+		return storedEntryText{title: title, description: description}, err
+	}
+	sealedTitle, err := ec.keys.Seal(u.salt, title)
+	if err != nil {
+		return storedEntryText{}, err
+	}
+	sealedDescription, err := ec.keys.Seal(u.salt, description)
+	if err != nil {
+		return storedEntryText{}, err
+	}
+	return storedEntryText{title: sealedTitle, description: sealedDescription, encrypted: true}, nil
+}
+
+// open decrypts fields of an entry owned by uid in place when the entry was stored
+// encrypted; plaintext entries are left alone
+func (ec *entryCipher) open(ctx context.Context, uid string, encrypted bool, fields ...*string) error {
+	if !encrypted {
+		return nil
+	}
+	u, err := ec.user(ctx, uid)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		plaintext, err := ec.keys.Open(u.salt, *field)
+		if err != nil {
+			return err
+		}
+		*field = plaintext
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/encryption"
+	encryptionmodels "io.winapps.journeyapp/internal/models/entry_encryption"
+)
+
+// SetEntryEncryption turns encryption at rest of the user's entry titles and descriptions
+// on or off. The user's existing entries are converted in the same transaction, so all of
+// them are either encrypted or not. While it's on, search can't match entry text.
+func (h *AuthHandler) SetEntryEncryption(c *gin.Context) {
+	var req encryptionmodels.SetEntryEncryptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "enabled is required")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	if h.entryKeys == nil {
+		respondError(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, "Entry encryption is not available on this server")
+		return
+	}
+	enable := *req.Enabled
+
+	ctx := c.Request.Context()
+
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// FOR UPDATE waits for entry writes holding the row FOR SHARE and blocks new ones
+	var enabled bool
+	var salt []byte
+	err = tx.QueryRow(ctx, `
+		SELECT encryption_enabled, encryption_salt FROM users WHERE uid = $1 FOR UPDATE
+	`, userUID).Scan(&enabled, &salt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "encryption setting lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry encryption")
+		return
+	}
+	if enabled == enable {
+		c.JSON(http.StatusOK, encryptionmodels.SetEntryEncryptionResponse{
+			Enabled: enable,
+			Message: "Entry encryption is already " + onOff(enable),
+		})
+		return
+	}
+
+	// The salt is created once and kept, so turning encryption back on reuses the same key
+	if salt == nil {
+		if salt, err = encryption.NewSalt(); err != nil {
+			h.logError(c, err, "generate encryption salt failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry encryption")
+			return
+		}
+	}
+
+	type storedEntry struct {
+		id          string
+		title       string
+		description *string
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT id::text, title, description FROM entries WHERE user_uid = $1 AND encrypted <> $2
+	`, userUID, enable)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "load entries for encryption failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry encryption")
+		return
+	}
+	var entries []storedEntry
+	for rows.Next() {
+		var e storedEntry
+		if err := rows.Scan(&e.id, &e.title, &e.description); err != nil {
+			rows.Close()
+			h.logError(c, err, "scan entry for encryption failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry encryption")
+			return
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "load entries for encryption failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry encryption")
+		return
+	}
+
+	convert := h.entryKeys.Open
+	if enable {
+		convert = h.entryKeys.Seal
+	}
+	for _, e := range entries {
+		title, err := convert(salt, e.title)
+		if err != nil {
+			h.logError(c, err, "convert entry failed", "entryId", e.id)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry encryption")
+			return
+		}
+		description := e.description
+		if description != nil {
+			converted, err := convert(salt, *description)
+			if err != nil {
+				h.logError(c, err, "convert entry failed", "entryId", e.id)
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry encryption")
+				return
+			}
+			description = &converted
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE entries SET title = $2, description = $3, encrypted = $4 WHERE id::text = $1
+		`, e.id, title, description, enable); err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "update converted entry failed", "entryId", e.id)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry encryption")
+			return
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE users SET encryption_enabled = $2, encryption_salt = $3, updated_at = NOW() WHERE uid = $1
+	`, userUID, enable, salt); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "update encryption setting failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry encryption")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "commit entry encryption failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry encryption")
+		return
+	}
+
+	// Cached entries and feeds hold the old text; drop them so nothing decrypted lingers
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, fmt.Sprintf("entry:%s", e.id))
+	}
+	_ = h.cache.Del(ctx, keys...)
+	invalidateAccountDetails(ctx, h.cache, userUID)
+	bumpFeedVersion(ctx, h.cache, userUID)
+
+	c.JSON(http.StatusOK, encryptionmodels.SetEntryEncryptionResponse{
+		Enabled:          enable,
+		ConvertedEntries: len(entries),
+		Message:          "Entry encryption turned " + onOff(enable),
+	})
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
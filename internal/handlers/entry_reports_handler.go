@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"io.winapps.journeyapp/internal/apierror"
+	reportmodels "io.winapps.journeyapp/internal/models/entry_reports"
+)
+
+const (
+	maxReportReasonLength = 1000
+
+	defaultReportHideThreshold = 3
+
+	reportStatusOpen      = "open"
+	reportStatusDismissed = "dismissed"
+	reportStatusUpheld    = "upheld"
+)
+
+// reportHideThreshold is how many open reports hide an entry from feeds until a moderator
+// reviews it, read from ENTRY_REPORT_HIDE_THRESHOLD
+var reportHideThreshold = reportHideThresholdFromEnv()
+
+func reportHideThresholdFromEnv() int {
+	if v, err := strconv.Atoi(os.Getenv("ENTRY_REPORT_HIDE_THRESHOLD")); err == nil && v > 0 {
+		return v
+	}
+	return defaultReportHideThreshold
+}
+
+// ReportEntry flags an entry the authenticated user can see as inappropriate. Each user
+// may report an entry once; the entry is hidden from feeds once it has
+// reportHideThreshold open reports.
+func (h *EntryHandler) ReportEntry(c *gin.Context) {
+	var req reportmodels.ReportEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Reason is required")
+		return
+	}
+	if len([]rune(reason)) > maxReportReasonLength {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Reason is too long")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	ownerUID, err := h.entryOwnerIfVisible(ctx, req.EntryID, userUID)
+	if err != nil {
+		if errors.Is(err, errEntryNotAccessible) {
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+			return
+		}
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "report access check failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to report entry")
+		return
+	}
+	if ownerUID == userUID {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "You can't report your own entry")
+		return
+	}
+
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var reportID string
+	err = tx.QueryRow(ctx, `
+		INSERT INTO entry_reports (entry_id, reporter_uid, reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (entry_id, reporter_uid) DO NOTHING
+		RETURNING id::text
+	`, req.EntryID, userUID, reason).Scan(&reportID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(c, http.StatusConflict, apierror.CodeConflict, "You have already reported this entry")
+		return
+	}
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "insert report failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to report entry")
+		return
+	}
+
+	// Hide the entry once enough people have reported it. Only open reports count, so
+	// reports a moderator already dismissed don't hide it again on their own.
+	tag, err := tx.Exec(ctx, `
+		UPDATE entries SET moderation_hidden = TRUE
+		WHERE id = $1 AND NOT moderation_hidden
+			AND (SELECT COUNT(*) FROM entry_reports WHERE entry_id = $1 AND status = 'open') >= $2
+	`, req.EntryID, reportHideThreshold)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "hide reported entry failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to report entry")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "commit report failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to report entry")
+		return
+	}
+
+	if tag.RowsAffected() > 0 {
+		bumpFeedVersion(ctx, h.cache, ownerUID)
+		if h.logger != nil {
+			h.logger.Infow("Entry hidden pending review", "entryId", req.EntryID, "threshold", reportHideThreshold)
+		}
+	}
+
+	c.JSON(http.StatusCreated, reportmodels.ReportEntryResponse{
+		ReportID: reportID,
+		Message:  "Thanks, the entry has been reported for review",
+	})
+}
+
+// ListReports returns a page of reports for moderators, oldest first so the queue is
+// worked in order. ?status= selects open (the default), dismissed, upheld or all reports.
+func (h *EntryHandler) ListReports(c *gin.Context) {
+	status := strings.ToLower(strings.TrimSpace(c.DefaultQuery("status", reportStatusOpen)))
+	switch status {
+	case reportStatusOpen, reportStatusDismissed, reportStatusUpheld, "all":
+	default:
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "status must be open, dismissed, upheld or all")
+		return
+	}
+
+	page := 1
+	if v := c.Query("page"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 1 {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "page must be a positive integer")
+			return
+		}
+		page = p
+	}
+	limit := 20
+	if v := c.Query("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l < 1 || l > 100 {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "limit must be between 1 and 100")
+			return
+		}
+		limit = l
+	}
+
+	ctx := c.Request.Context()
+
+	var total int
+	if err := h.postgres.QueryRow(ctx, `
+		SELECT COUNT(*) FROM entry_reports WHERE $1 = 'all' OR status = $1
+	`, status).Scan(&total); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "count reports failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list reports")
+		return
+	}
+
+	rows, err := h.postgres.Query(ctx, `
+		SELECT r.id::text, r.entry_id::text, e.user_uid, e.title, COALESCE(e.description, ''), e.encrypted,
+			e.moderation_hidden,
+			(SELECT COUNT(*) FROM entry_reports o WHERE o.entry_id = r.entry_id AND o.status = 'open'),
+			r.reporter_uid, r.reason, r.status, COALESCE(r.resolved_by, ''), r.resolved_at, r.created_at
+		FROM entry_reports r
+		INNER JOIN entries e ON e.id = r.entry_id
+		WHERE $1 = 'all' OR r.status = $1
+		ORDER BY r.created_at, r.id
+		LIMIT $2 OFFSET $3
+	`, status, limit, (page-1)*limit)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "list reports failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list reports")
+		return
+	}
+	defer rows.Close()
+
+	reports := []reportmodels.Report{}
+	var encrypted []bool
+	for rows.Next() {
+		var r reportmodels.Report
+		var isEncrypted bool
+		if err := rows.Scan(
+			&r.ID,
+			&r.EntryID,
+			&r.EntryOwnerUID,
+			&r.EntryTitle,
+			&r.EntryDescription,
+			&isEncrypted,
+			&r.EntryHidden,
+			&r.OpenReports,
+			&r.ReporterUID,
+			&r.Reason,
+			&r.Status,
+			&r.ResolvedBy,
+			&r.ResolvedAt,
+			&r.CreatedAt,
+		); err != nil {
+			h.logError(c, err, "scan report failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read reports")
+			return
+		}
+		reports = append(reports, r)
+		encrypted = append(encrypted, isEncrypted)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "list reports failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list reports")
+		return
+	}
+
+	// Moderators need to read what was reported, so encrypted entries are opened here.
+	// That queries the owners' salts, which is why it waits until rows is closed.
+	cipher := newEntryCipher(h.postgres, h.entryKeys)
+	for i := range reports {
+		r := &reports[i]
+		if err := cipher.open(ctx, r.EntryOwnerUID, encrypted[i], &r.EntryTitle, &r.EntryDescription); err != nil {
+			h.logError(c, err, "decrypt reported entry failed", "entryId", r.EntryID)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read reports")
+			return
+		}
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	c.JSON(http.StatusOK, reportmodels.ListReportsResponse{
+		Reports: reports,
+		Pagination: reportmodels.Pagination{
+			Page:        page,
+			Limit:       limit,
+			Total:       total,
+			TotalPages:  totalPages,
+			HasNext:     page < totalPages,
+			HasPrevious: page > 1,
+		},
+	})
+}
+
+// ResolveReport closes a report and every other open report on the same entry, since the
+// review is of the entry. Dismissing restores the entry to feeds; upholding keeps it
+// hidden from them. The owner can still see and edit it either way.
+func (h *EntryHandler) ResolveReport(c *gin.Context) {
+	var req reportmodels.ResolveReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	var status string
+	var hidden bool
+	switch strings.ToLower(strings.TrimSpace(req.Action)) {
+	case "dismiss":
+		status, hidden = reportStatusDismissed, false
+	case "uphold":
+		status, hidden = reportStatusUpheld, true
+	default:
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "action must be dismiss or uphold")
+		return
+	}
+
+	moderatorUID := c.GetString("uid")
+	ctx := c.Request.Context()
+
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the entry so a report arriving mid-review can't re-hide it behind our back
+	var entryID, ownerUID string
+	err = tx.QueryRow(ctx, `
+		SELECT e.id::text, e.user_uid
+		FROM entry_reports r
+		INNER JOIN entries e ON e.id = r.entry_id
+		WHERE r.id::text = $1
+		FOR UPDATE OF e
+	`, req.ReportID).Scan(&entryID, &ownerUID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Report not found")
+		return
+	}
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "load report failed", "reportId", req.ReportID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to resolve report")
+		return
+	}
+
+	now := time.Now()
+	tag, err := tx.Exec(ctx, `
+		UPDATE entry_reports SET status = $2, resolved_by = $3, resolved_at = $4
+		WHERE entry_id = $1 AND (status = 'open' OR id::text = $5)
+	`, entryID, status, moderatorUID, now, req.ReportID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "resolve reports failed", "entryId", entryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to resolve report")
+		return
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE entries SET moderation_hidden = $2 WHERE id = $1`, entryID, hidden); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "update entry moderation failed", "entryId", entryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to resolve report")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "commit resolve report failed", "entryId", entryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to resolve report")
+		return
+	}
+
+	bumpFeedVersion(ctx, h.cache, ownerUID)
+
+	c.JSON(http.StatusOK, reportmodels.ResolveReportResponse{
+		EntryID:         entryID,
+		Status:          status,
+		ResolvedReports: int(tag.RowsAffected()),
+		EntryHidden:     hidden,
+		Message:         "Report " + status,
+	})
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/middleware"
+)
+
+// shareTargetError lists sharedWith uids an entry can't be shared with. It is sent as the
+// details of a VALIDATION error.
+type shareTargetError struct {
+	Field       string   `json:"field"`
+	InvalidUIDs []string `json:"invalidUids"`
+}
+
+func (e *shareTargetError) Error() string {
+	return fmt.Sprintf("entries can only be shared with approved friends; not friends: %s", strings.Join(e.InvalidUIDs, ", "))
+}
+
+// checkShareTargets reports the uids in sharedWith that userUID may not share a
+// semi-private entry with: anyone who isn't an approved friend, including unknown uids,
+// and anyone either side has blocked. Blank and repeated uids are skipped, as they are
+// when the shares are stored.
+func (h *EntryHandler) checkShareTargets(ctx context.Context, userUID string, sharedWith []string) (*shareTargetError, error) {
+	seen := make(map[string]struct{})
+	uids := []string{}
+	for _, sharedUID := range sharedWith {
+		sharedUID = strings.TrimSpace(sharedUID)
+		if sharedUID == "" {
+			continue
+		}
+		if _, ok := seen[sharedUID]; ok {
+			continue
+		}
+		seen[sharedUID] = struct{}{}
+		uids = append(uids, sharedUID)
+	}
+	if len(uids) == 0 {
+		return nil, nil
+	}
+
+	rows, err := h.postgres.Query(ctx, `
+		SELECT t.uid
+		FROM unnest($2::text[]) WITH ORDINALITY AS t(uid, n)
+		WHERE NOT EXISTS (
+				SELECT 1 FROM friendships f
+				WHERE f.status = 'approved'
+					AND ((f.uid = $1 AND f.fid = t.uid) OR (f.uid = t.uid AND f.fid = $1))
+			)
+			OR EXISTS (
+				SELECT 1 FROM friendships b
+				WHERE b.status = 'blocked'
+					AND ((b.uid = $1 AND b.fid = t.uid) OR (b.uid = t.uid AND b.fid = $1))
+			)
+		ORDER BY t.n
+	`, userUID, uids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invalid []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, err
+		}
+		invalid = append(invalid, uid)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(invalid) == 0 {
+		return nil, nil
+	}
+	return &shareTargetError{Field: "sharedWith", InvalidUIDs: invalid}, nil
+}
+
+// respondInvalidShareTargets writes a 400 VALIDATION error listing the uids
+func respondInvalidShareTargets(c *gin.Context, err *shareTargetError) {
+	apierror.RespondWithDetails(c, http.StatusBadRequest, apierror.CodeValidation, err.Error(), err)
+}
+
+// entryReachesOthers reports whether an entry with this visibility and share list can be
+// seen by anyone but its owner. Saving or publishing such an entry needs a verified email,
+// like the other actions that reach other users (friend requests, comments, public links).
+func entryReachesOthers(visibility string, shareCount int) bool {
+	return visibility == "public" || (visibility == "semi-private" && shareCount > 0)
+}
+
+// requireVerifiedEmail writes the standard 403 and returns false unless userUID has a
+// verified email
+func (h *EntryHandler) requireVerifiedEmail(c *gin.Context, ctx context.Context, userUID string) bool {
+	verified, err := middleware.IsEmailVerified(ctx, h.postgres, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return false
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check email verification")
+		return false
+	}
+	if !verified {
+		middleware.AbortEmailNotVerified(c)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"io.winapps.journeyapp/internal/cache"
+)
+
+func TestEntryReachesOthers(t *testing.T) {
+	cases := []struct {
+		visibility string
+		shares     int
+		want       bool
+	}{
+		{"public", 0, true},
+		{"semi-private", 1, true},
+		{"semi-private", 0, false},
+		{"private", 0, false},
+		{"private", 2, false},
+	}
+	for _, tc := range cases {
+		if got := entryReachesOthers(tc.visibility, tc.shares); got != tc.want {
+			t.Errorf("entryReachesOthers(%q, %d) = %v, want %v", tc.visibility, tc.shares, got, tc.want)
+		}
+	}
+}
+
+// An unverified user can keep private entries but can't share or publish them
+func TestUnverifiedUserCannotShareEntries(t *testing.T) {
+	pool := testPool(t)
+	h := NewEntryHandler(nil, pool, cache.NewMemory(100), nil)
+	alice := testUser(t, pool, "alice")
+	bob := testUser(t, pool, "bob")
+	testFriends(t, pool, alice, bob)
+	mustExec(t, pool, `UPDATE users SET email_verified = FALSE WHERE uid = $1`, alice)
+
+	if w := callAs(alice, h.CreateEntry, http.MethodPost, "/create-entry", `{"title":"Mine","visibility":"private"}`); w.Code != http.StatusCreated {
+		t.Errorf("private entry: %d %s", w.Code, w.Body.String())
+	}
+	for _, body := range []string{
+		`{"title":"Shared","visibility":"semi-private","sharedWith":["` + bob + `"]}`,
+		`{"title":"Public","visibility":"public"}`,
+	} {
+		if w := callAs(alice, h.CreateEntry, http.MethodPost, "/create-entry", body); w.Code != http.StatusForbidden {
+			t.Errorf("create %s: %d, want 403", body, w.Code)
+		}
+	}
+
+	entryID := testEntry(t, pool, alice, "Draft", "semi-private")
+	if w := callAs(alice, h.UpdateEntry, http.MethodPost, "/update-entry", `{"entryId":"`+entryID+`","sharedWith":["`+bob+`"]}`); w.Code != http.StatusForbidden {
+		t.Errorf("adding a share list: %d, want 403", w.Code)
+	}
+	mustExec(t, pool, `UPDATE entries SET status = 'draft' WHERE id = $1`, entryID)
+	mustExec(t, pool, `INSERT INTO entry_shares (entry_id, shared_user_uid) VALUES ($1, $2)`, entryID, bob)
+	if w := callAs(alice, h.PublishEntry, http.MethodPost, "/publish-entry", `{"entryId":"`+entryID+`"}`); w.Code != http.StatusForbidden {
+		t.Errorf("publishing a shared draft: %d, want 403", w.Code)
+	}
+
+	mustExec(t, pool, `UPDATE users SET email_verified = TRUE WHERE uid = $1`, alice)
+	if w := callAs(alice, h.PublishEntry, http.MethodPost, "/publish-entry", `{"entryId":"`+entryID+`"}`); w.Code != http.StatusOK {
+		t.Errorf("publishing once verified: %d %s", w.Code, w.Body.String())
+	}
+}
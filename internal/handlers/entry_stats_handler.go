@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"io.winapps.journeyapp/internal/apierror"
+	heatmapmodels "io.winapps.journeyapp/internal/models/get_activity_heatmap"
+	streakmodels "io.winapps.journeyapp/internal/models/get_streak"
+	tagdistmodels "io.winapps.journeyapp/internal/models/get_tag_distribution"
+)
+
+const (
+	statsDateLayout = "2006-01-02"
+	// defaultHeatmapDays is the range used when from is omitted, ending on to (default today)
+	defaultHeatmapDays = 365
+	// maxStatsRangeDays keeps a single request from scanning a user's whole history day by day
+	maxStatsRangeDays = 731
+	// Creating or deleting an entry clears the user's stats; tag edits and day rollover
+	// don't, so keep them short-lived
+	entryStatsCacheTTL = 5 * time.Minute
+)
+
+// GetActivityHeatmap returns how many entries the authenticated user created on each day
+// of a range, bucketed by local day in the user's timezone, along with their current
+// streak. from and to are optional YYYY-MM-DD query parameters; the default range is the
+// last 365 days ending today.
+func (h *EntryHandler) GetActivityHeatmap(c *gin.Context) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ctx := c.Request.Context()
+
+	loc, err := h.statsLocation(ctx, c.Query("tz"), userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "tz must be an IANA timezone name")
+		return
+	}
+
+	from, to, err := parseStatsRange(c.Query("from"), c.Query("to"), loc, true)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, err.Error())
+		return
+	}
+
+	fromDay, toDay := from.Format(statsDateLayout), to.Format(statsDateLayout)
+	cacheKey := fmt.Sprintf("activity_heatmap:%s:%s:%s:%s", userUID, loc.String(), fromDay, toDay)
+	if cached, err := h.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+		var response heatmapmodels.GetActivityHeatmapResponse
+		if err := json.Unmarshal([]byte(cached), &response); err == nil {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+	}
+
+	// created_at is a UTC timestamp without zone, so shift it to the user's zone before bucketing
+	rows, err := h.postgres.Query(ctx, `
+		SELECT to_char(date_trunc('day', (created_at AT TIME ZONE 'UTC') AT TIME ZONE $2), 'YYYY-MM-DD') AS day,
+			COUNT(*)
+		FROM entries
+		WHERE user_uid = $1 AND created_at >= $3 AND created_at < $4
+		GROUP BY day
+	`, userUID, loc.String(), from.UTC(), to.AddDate(0, 0, 1).UTC())
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "activity heatmap query failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch activity")
+		return
+	}
+	defer rows.Close()
+
+	response := heatmapmodels.GetActivityHeatmapResponse{
+		From:     fromDay,
+		To:       toDay,
+		Timezone: loc.String(),
+		Days:     map[string]int{},
+	}
+	for rows.Next() {
+		var day string
+		var count int
+		if err := rows.Scan(&day, &count); err != nil {
+			h.logError(c, err, "activity heatmap scan failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch activity")
+			return
+		}
+		response.Days[day] = count
+		response.Total += count
+	}
+	if err := rows.Err(); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "activity heatmap query failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch activity")
+		return
+	}
+	rows.Close()
+
+	response.Streak, err = h.activityStreak(ctx, userUID, loc)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "activity streak query failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch activity")
+		return
+	}
+
+	if data, err := json.Marshal(response); err == nil {
+		_ = h.cache.Set(ctx, cacheKey, data, entryStatsCacheTTL)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTagDistribution returns how many of the authenticated user's entries carry each tag
+// key, most used first. from and to optionally limit it to entries created in that range
+// of local days; without them every entry counts.
+func (h *EntryHandler) GetTagDistribution(c *gin.Context) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ctx := c.Request.Context()
+
+	loc, err := h.statsLocation(ctx, c.Query("tz"), userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "tz must be an IANA timezone name")
+		return
+	}
+
+	response := tagdistmodels.GetTagDistributionResponse{
+		Timezone: loc.String(),
+		Tags:     []tagdistmodels.TagCount{},
+	}
+
+	// A nil bound leaves that side of the range open
+	var lower, upper *time.Time
+	if c.Query("from") != "" || c.Query("to") != "" {
+		from, to, err := parseStatsRange(c.Query("from"), c.Query("to"), loc, false)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, err.Error())
+			return
+		}
+		if !from.IsZero() {
+			l := from.UTC()
+			lower = &l
+			response.From = from.Format(statsDateLayout)
+		}
+		u := to.AddDate(0, 0, 1).UTC()
+		upper = &u
+		response.To = to.Format(statsDateLayout)
+	}
+
+	cacheKey := fmt.Sprintf("tag_distribution:%s:%s:%s:%s", userUID, loc.String(), response.From, response.To)
+	if cached, err := h.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+		var cachedResponse tagdistmodels.GetTagDistributionResponse
+		if err := json.Unmarshal([]byte(cached), &cachedResponse); err == nil {
+			c.JSON(http.StatusOK, cachedResponse)
+			return
+		}
+	}
+
+	rows, err := h.postgres.Query(ctx, `
+		SELECT t.key, COUNT(DISTINCT t.entry_id) AS entry_count
+		FROM tags t
+		INNER JOIN entries e ON t.entry_id = e.id
+		WHERE e.user_uid = $1
+			AND ($2::timestamp IS NULL OR e.created_at >= $2)
+			AND ($3::timestamp IS NULL OR e.created_at < $3)
+		GROUP BY t.key
+		ORDER BY entry_count DESC, t.key
+	`, userUID, lower, upper)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "tag distribution query failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch tag distribution")
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tc tagdistmodels.TagCount
+		if err := rows.Scan(&tc.Key, &tc.EntryCount); err != nil {
+			h.logError(c, err, "tag distribution scan failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch tag distribution")
+			return
+		}
+		response.Tags = append(response.Tags, tc)
+	}
+	if err := rows.Err(); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "tag distribution query failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch tag distribution")
+		return
+	}
+
+	if data, err := json.Marshal(response); err == nil {
+		_ = h.cache.Set(ctx, cacheKey, data, entryStatsCacheTTL)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetStreak returns the authenticated user's current and longest writing streaks and the
+// date of their last entry, with days taken in the user's timezone (see statsLocation)
+func (h *EntryHandler) GetStreak(c *gin.Context) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ctx := c.Request.Context()
+
+	loc, err := h.statsLocation(ctx, c.Query("tz"), userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "tz must be an IANA timezone name")
+		return
+	}
+
+	today := time.Now().In(loc)
+	cacheKey := fmt.Sprintf("entry_streak:%s:%s:%s", userUID, loc.String(), today.Format(statsDateLayout))
+	if cached, err := h.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+		var response streakmodels.GetStreakResponse
+		if err := json.Unmarshal([]byte(cached), &response); err == nil {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+	}
+
+	days, err := localEntryDays(ctx, h.postgres, userUID, loc)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "streak query failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch streak")
+		return
+	}
+	stats := computeStreak(days, today)
+
+	response := streakmodels.GetStreakResponse{
+		CurrentStreak: stats.Current,
+		LongestStreak: stats.Longest,
+		LastEntryDate: stats.LastEntryDate,
+		WrittenToday:  stats.LastEntryDate == today.Format(statsDateLayout),
+		Timezone:      loc.String(),
+	}
+
+	if data, err := json.Marshal(response); err == nil {
+		_ = h.cache.Set(ctx, cacheKey, data, entryStatsCacheTTL)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// activityStreak counts consecutive local days with at least one entry, ending today.
+// It is 0 when the user hasn't written anything yet today.
+func (h *EntryHandler) activityStreak(ctx context.Context, userUID string, loc *time.Location) (int, error) {
+	days, err := localEntryDays(ctx, h.postgres, userUID, loc)
+	if err != nil {
+		return 0, err
+	}
+	return computeStreak(days, time.Now().In(loc)).Current, nil
+}
+
+// invalidateEntryStatsCache clears every cached heatmap, tag distribution and streak for uid
+func (h *EntryHandler) invalidateEntryStatsCache(ctx context.Context, uid string) {
+	for _, prefix := range []string{"activity_heatmap", "tag_distribution", "entry_streak"} {
+		keys, _ := h.cache.Keys(ctx, fmt.Sprintf("%s:%s:*", prefix, uid))
+		if len(keys) > 0 {
+			_ = h.cache.Del(ctx, keys...)
+		}
+	}
+}
+
+// statsLocation resolves the timezone days are bucketed in: the tz query parameter if
+// given, otherwise the timezone the user registered for notifications, otherwise UTC
+func (h *EntryHandler) statsLocation(ctx context.Context, tz, userUID string) (*time.Location, error) {
+	if tz != "" {
+		return time.LoadLocation(tz)
+	}
+
+	err := h.postgres.QueryRow(ctx, `SELECT timezone FROM push_tokens WHERE user_id = $1`, userUID).Scan(&tz)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.UTC, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		// A bad stored value shouldn't break the endpoint
+		return time.UTC, nil
+	}
+	return loc, nil
+}
+
+// parseStatsRange parses from and to (YYYY-MM-DD, inclusive) as local midnights in loc.
+// to defaults to today. from defaults to defaultHeatmapDays before to when defaultFrom is
+// set and is left zero otherwise; a set from may be at most maxStatsRangeDays before to.
+func parseStatsRange(fromParam, toParam string, loc *time.Location, defaultFrom bool) (time.Time, time.Time, error) {
+	now := time.Now().In(loc)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if toParam != "" {
+		t, err := time.ParseInLocation(statsDateLayout, toParam, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("to must be a date in YYYY-MM-DD format")
+		}
+		to = t
+	}
+
+	var from time.Time
+	if fromParam != "" {
+		f, err := time.ParseInLocation(statsDateLayout, fromParam, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("from must be a date in YYYY-MM-DD format")
+		}
+		from = f
+	} else if defaultFrom {
+		from = to.AddDate(0, 0, -(defaultHeatmapDays - 1))
+	}
+
+	if !from.IsZero() {
+		if from.After(to) {
+			return time.Time{}, time.Time{}, errors.New("from must not be after to")
+		}
+		if from.AddDate(0, 0, maxStatsRangeDays).Before(to) {
+			return time.Time{}, time.Time{}, fmt.Errorf("range must not exceed %d days", maxStatsRangeDays)
+		}
+	}
+	return from, to, nil
+}
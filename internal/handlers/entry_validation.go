@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	accountmodels "io.winapps.journeyapp/internal/models/account"
+)
+
+const (
+	// maxEntryTitleLength matches the VARCHAR(500) the title column had before encryption
+	// widened it to hold sealed titles
+	maxEntryTitleLength = 500
+	// defaultMaxEntryDescriptionLength applies unless ENTRY_DESCRIPTION_MAX_LENGTH is set
+	defaultMaxEntryDescriptionLength = 50000
+	// maxTagKeyLength matches tags.key VARCHAR(255)
+	maxTagKeyLength = 255
+	// maxTagValueLength bounds tags.value, which is TEXT, so values stay label sized
+	maxTagValueLength = 1000
+)
+
+// maxEntryDescriptionLength is read from ENTRY_DESCRIPTION_MAX_LENGTH
+var maxEntryDescriptionLength = maxEntryDescriptionLengthFromEnv()
+
+func maxEntryDescriptionLengthFromEnv() int {
+	if v, err := strconv.Atoi(os.Getenv("ENTRY_DESCRIPTION_MAX_LENGTH")); err == nil && v > 0 {
+		return v
+	}
+	return defaultMaxEntryDescriptionLength
+}
+
+// fieldLengthError names a field that is longer than it may be. It is sent as the details
+// of a VALIDATION error so clients can point at the field.
+type fieldLengthError struct {
+	Field     string `json:"field"`
+	MaxLength int    `json:"maxLength"`
+}
+
+func (e *fieldLengthError) Error() string {
+	return fmt.Sprintf("%s must be at most %d characters", e.Field, e.MaxLength)
+}
+
+// checkLength returns a fieldLengthError when value has more than max characters
+func checkLength(field, value string, max int) *fieldLengthError {
+	if utf8.RuneCountInString(value) > max {
+		return &fieldLengthError{Field: field, MaxLength: max}
+	}
+	return nil
+}
+
+// validateEntryText checks the title and description lengths. A nil field isn't being set
+// and is skipped.
+func validateEntryText(title, description *string) *fieldLengthError {
+	if title != nil {
+		if err := checkLength("title", *title, maxEntryTitleLength); err != nil {
+			return err
+		}
+	}
+	if description != nil {
+		if err := checkLength("description", *description, maxEntryDescriptionLength); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTag checks a tag's key and value lengths; field prefixes the names in errors,
+// e.g. "tag" or "tags[2]"
+func validateTag(field string, tag accountmodels.Tag) *fieldLengthError {
+	if err := checkLength(field+".key", tag.Key, maxTagKeyLength); err != nil {
+		return err
+	}
+	return checkLength(field+".value", tag.Value, maxTagValueLength)
+}
+
+// validateEntryInput checks everything a new entry stores with a length limit: title,
+// description and tags
+func validateEntryInput(title, description string, tags []accountmodels.Tag) *fieldLengthError {
+	if err := validateEntryText(&title, &description); err != nil {
+		return err
+	}
+	for i, tag := range tags {
+		if err := validateTag(fmt.Sprintf("tags[%d]", i), tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// respondFieldTooLong writes a 400 VALIDATION error whose details name the field
+func respondFieldTooLong(c *gin.Context, err *fieldLengthError) {
+	apierror.RespondWithDetails(c, http.StatusBadRequest, apierror.CodeValidation, err.Error(), err)
+}
+
+// mediaRefError names an image URL of a new entry that isn't one of the user's own files.
+// It is sent as the details of a VALIDATION error.
+type mediaRefError struct {
+	Field string `json:"field"`
+}
+
+func (e *mediaRefError) Error() string {
+	return fmt.Sprintf("%s must be one of your own uploaded images", e.Field)
+}
+
+// validateEntryImages checks that every image URL a new entry stores points into uid's own
+// media. The URLs are stored as given and later removed, copied and exported by URL, so a
+// reference to another user's file would hand their file to this user.
+func validateEntryImages(uid string, images []string) *mediaRefError {
+	for i, imageURL := range images {
+		if _, err := mediaKeyFromURL(imageURL, "images", uid, ""); err != nil {
+			return &mediaRefError{Field: fmt.Sprintf("images[%d]", i)}
+		}
+	}
+	return nil
+}
+
+// respondInvalidMediaRef writes a 400 VALIDATION error whose details name the field
+func respondInvalidMediaRef(c *gin.Context, err *mediaRefError) {
+	apierror.RespondWithDetails(c, http.StatusBadRequest, apierror.CodeValidation, err.Error(), err)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	accountmodels "io.winapps.journeyapp/internal/models/account"
+)
+
+func TestCheckLengthCountsCharacters(t *testing.T) {
+	// Five characters, fifteen bytes
+	if err := checkLength("title", "日本語です", 5); err != nil {
+		t.Errorf("5 characters with max 5: %v", err)
+	}
+	if err := checkLength("title", "日本語です!", 5); err == nil || err.Field != "title" || err.MaxLength != 5 {
+		t.Errorf("6 characters with max 5: got %+v", err)
+	}
+}
+
+func TestValidateEntryText(t *testing.T) {
+	longTitle := strings.Repeat("t", maxEntryTitleLength+1)
+	okTitle := strings.Repeat("t", maxEntryTitleLength)
+	longDescription := strings.Repeat("d", maxEntryDescriptionLength+1)
+
+	if err := validateEntryText(nil, nil); err != nil {
+		t.Errorf("nothing set: %v", err)
+	}
+	if err := validateEntryText(&okTitle, nil); err != nil {
+		t.Errorf("title at the limit: %v", err)
+	}
+	if err := validateEntryText(&longTitle, nil); err == nil || err.Field != "title" {
+		t.Errorf("long title: got %+v", err)
+	}
+	if err := validateEntryText(nil, &longDescription); err == nil || err.Field != "description" {
+		t.Errorf("long description: got %+v", err)
+	}
+}
+
+func TestValidateEntryInputNamesTheTag(t *testing.T) {
+	tags := []accountmodels.Tag{
+		{Key: "mood", Value: "calm"},
+		{Key: "weather", Value: strings.Repeat("v", maxTagValueLength+1)},
+	}
+	err := validateEntryInput("Title", "Body", tags)
+	if err == nil || err.Field != "tags[1].value" || err.MaxLength != maxTagValueLength {
+		t.Fatalf("got %+v, want tags[1].value", err)
+	}
+	if err := validateTag("tag", accountmodels.Tag{Key: strings.Repeat("k", maxTagKeyLength+1)}); err == nil || err.Field != "tag.key" {
+		t.Errorf("long key: got %+v", err)
+	}
+}
+
+func TestMaxEntryDescriptionLengthFromEnv(t *testing.T) {
+	for value, want := range map[string]int{"": defaultMaxEntryDescriptionLength, "2000": 2000, "0": defaultMaxEntryDescriptionLength, "x": defaultMaxEntryDescriptionLength} {
+		t.Setenv("ENTRY_DESCRIPTION_MAX_LENGTH", value)
+		if got := maxEntryDescriptionLengthFromEnv(); got != want {
+			t.Errorf("ENTRY_DESCRIPTION_MAX_LENGTH=%q: got %d, want %d", value, got, want)
+		}
+	}
+}
+
+func TestRespondFieldTooLong(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("request_id", "req-1")
+	respondFieldTooLong(c, &fieldLengthError{Field: "title", MaxLength: 500})
+
+	var body struct {
+		Error struct {
+			Code      string           `json:"code"`
+			Message   string           `json:"message"`
+			RequestID string           `json:"requestId"`
+			Details   fieldLengthError `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if w.Code != http.StatusBadRequest || body.Error.Code != "VALIDATION" || body.Error.RequestID != "req-1" ||
+		body.Error.Details != (fieldLengthError{Field: "title", MaxLength: 500}) {
+		t.Errorf("got %d %s", w.Code, w.Body.String())
+	}
+}
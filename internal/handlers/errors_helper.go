@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/premium"
+)
+
+// respondError writes the standard error envelope. code should be one of the apierror.Code* constants.
+func respondError(c *gin.Context, status int, code, message string) {
+	apierror.Respond(c, status, code, message)
+}
+
+// respondPlanLimit writes a PLAN_LIMIT error (402 if upgrading would help, otherwise 403)
+// with the denial as details so clients can show an upgrade prompt
+func respondPlanLimit(c *gin.Context, denial *premium.Denial) {
+	apierror.RespondWithDetails(c, denial.Status(), apierror.CodePlanLimit, denial.Error(), denial)
+}
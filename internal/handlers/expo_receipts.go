@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"io.winapps.journeyapp/internal/cache"
+	"io.winapps.journeyapp/internal/metrics"
+)
+
+const (
+	expoSendURL     = "https://exp.host/--/api/v2/push/send"
+	expoReceiptsURL = "https://exp.host/--/api/v2/push/getReceipts"
+
+	// expoReceiptDelay is how long after sending a receipt is checked. Expo usually has
+	// them within minutes; checking earlier only finds them missing.
+	expoReceiptDelay = 15 * time.Minute
+	// expoTicketTTL matches how long Expo keeps receipts; tickets left after that are dropped
+	expoTicketTTL = 24 * time.Hour
+	// expoReceiptBatchSize is the most receipt ids Expo accepts per getReceipts request
+	expoReceiptBatchSize = 1000
+
+	expoPendingTicketsKey = "expo_pending_tickets"
+
+	expoDeviceNotRegistered = "DeviceNotRegistered"
+)
+
+// expoTicket is what Expo returns for each message sent. An ok ticket only means Expo
+// accepted the message; its id is used to fetch the receipt saying whether it was delivered.
+type expoTicket struct {
+	Status  string `json:"status"`
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Details struct {
+		Error string `json:"error"`
+	} `json:"details"`
+}
+
+// expoReceipt has the same fields as a ticket; Expo keys receipts by ticket id instead of
+// setting ID
+type expoReceipt = expoTicket
+
+// pendingExpoTicket is stored under expo_ticket:<id> until its receipt is checked
+type pendingExpoTicket struct {
+	Token  string    `json:"token"`
+	SentAt time.Time `json:"sentAt"`
+}
+
+func expoTicketKey(id string) string {
+	return "expo_ticket:" + id
+}
+
+// postExpo POSTs a JSON payload to Expo and decodes the data field of its reply into out
+func postExpo(ctx context.Context, url string, payload, out interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("expo request failed with status %d", resp.StatusCode)
+	}
+	body := struct {
+		Data interface{} `json:"data"`
+	}{Data: out}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode expo response: %w", err)
+	}
+	return nil
+}
+
+// trackExpoTicket remembers an accepted ticket so checkExpoReceipts can fetch its receipt
+func (ns *NotificationsHandler) trackExpoTicket(ctx context.Context, ticketID, expoToken string) {
+	pending, _ := json.Marshal(pendingExpoTicket{Token: expoToken, SentAt: time.Now().UTC()})
+	if err := ns.cache.Set(ctx, expoTicketKey(ticketID), pending, expoTicketTTL); err != nil {
+		ns.logger.Warnw("Failed to store expo push ticket", "ticketId", ticketID, "error", err)
+		return
+	}
+	if err := ns.cache.SAdd(ctx, expoPendingTicketsKey, ticketID); err != nil {
+		ns.logger.Warnw("Failed to queue expo push ticket", "ticketId", ticketID, "error", err)
+	}
+}
+
+// setupExpoReceiptChecks schedules the job that collects Expo push receipts
+func (ns *NotificationsHandler) setupExpoReceiptChecks() {
+	_, err := ns.cronManager.AddFunc("*/15 * * * *", ns.trackedJob(func() {
+		ns.checkExpoReceipts(context.Background())
+	}))
+	if err != nil {
+		ns.logger.Errorw("Failed to schedule expo receipt checks", "error", err)
+	}
+}
+
+// checkExpoReceipts fetches receipts for tickets sent at least expoReceiptDelay ago. Failed
+// deliveries are logged, and tokens Expo reports as DeviceNotRegistered are deactivated.
+// Tickets whose receipt isn't ready yet stay queued until expoTicketTTL runs out.
+func (ns *NotificationsHandler) checkExpoReceipts(ctx context.Context) {
+	ids, err := ns.cache.SMembers(ctx, expoPendingTicketsKey)
+	if err != nil {
+		ns.logger.Errorw("Failed to load pending expo push tickets", "error", err)
+		return
+	}
+
+	cutoff := time.Now().UTC().Add(-expoReceiptDelay)
+	tickets := make(map[string]pendingExpoTicket)
+	var due []string
+	for _, id := range ids {
+		raw, err := ns.cache.Get(ctx, expoTicketKey(id))
+		if errors.Is(err, cache.ErrMiss) {
+			// The ticket expired before a receipt showed up; Expo has forgotten it too
+			_ = ns.cache.SRem(ctx, expoPendingTicketsKey, id)
+			continue
+		}
+		if err != nil {
+			ns.logger.Warnw("Failed to load expo push ticket", "ticketId", id, "error", err)
+			continue
+		}
+		var ticket pendingExpoTicket
+		if err := json.Unmarshal([]byte(raw), &ticket); err != nil {
+			ns.forgetExpoTicket(ctx, id)
+			continue
+		}
+		if ticket.SentAt.After(cutoff) {
+			continue
+		}
+		tickets[id] = ticket
+		due = append(due, id)
+	}
+
+	for start := 0; start < len(due); start += expoReceiptBatchSize {
+		end := min(start+expoReceiptBatchSize, len(due))
+		batch := due[start:end]
+
+		receipts := make(map[string]expoReceipt)
+		if err := postExpo(ctx, expoReceiptsURL, map[string][]string{"ids": batch}, &receipts); err != nil {
+			// Left queued for the next run
+			ns.logger.Warnw("Failed to fetch expo push receipts", "tickets", len(batch), "error", err)
+			continue
+		}
+
+		for _, id := range batch {
+			receipt, ok := receipts[id]
+			if !ok {
+				continue
+			}
+			ticket := tickets[id]
+			if receipt.Status == "ok" {
+				metrics.NotificationReceiptsTotal.WithLabelValues("delivered").Inc()
+			} else {
+				metrics.NotificationReceiptsTotal.WithLabelValues("failed").Inc()
+				ns.logger.Warnw("Expo push delivery failed",
+					"ticketId", id,
+					"error", receipt.Details.Error,
+					"message", receipt.Message,
+				)
+				if receipt.Details.Error == expoDeviceNotRegistered {
+					ns.deactivateExpoToken(ctx, ticket.Token)
+				}
+			}
+			ns.forgetExpoTicket(ctx, id)
+		}
+	}
+}
+
+func (ns *NotificationsHandler) forgetExpoTicket(ctx context.Context, id string) {
+	_ = ns.cache.Del(ctx, expoTicketKey(id))
+	_ = ns.cache.SRem(ctx, expoPendingTicketsKey, id)
+}
+
+// deactivateExpoToken stops sending to a token Expo says no longer reaches a device. The
+// row is kept inactive rather than deleted; registering again turns it back on.
+func (ns *NotificationsHandler) deactivateExpoToken(ctx context.Context, expoToken string) {
+	rows, err := ns.db.Query(ctx, `
+		UPDATE push_tokens SET active = false, updated_at = NOW()
+		WHERE expo_push_token = $1 AND active = true
+		RETURNING user_id
+	`, expoToken)
+	if err != nil {
+		ns.logger.Errorw("Failed to deactivate expo push token", "error", err)
+		return
+	}
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err == nil {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		ns.logger.Errorw("Failed to deactivate expo push token", "error", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		_ = ns.cache.Del(ctx, fmt.Sprintf("push_token:%s", userID))
+		ns.logger.Infow("Deactivated unregistered expo push token", "recipient", userID)
+	}
+}
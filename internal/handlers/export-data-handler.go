@@ -16,7 +16,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/metrics"
 	exportmodels "io.winapps.journeyapp/internal/models/export_data"
+	"io.winapps.journeyapp/internal/premium"
 )
 
 // ExportJobStatus represents the progress and state of an export job
@@ -26,16 +29,22 @@ import (
 type ExportJobStatus struct {
 	JobID             string    `json:"jobId"`
 	UID               string    `json:"uid"`
-	Status            string    `json:"status"` // pending, running, completed, failed
+	Status            string    `json:"status"` // pending, running, completed, failed, cancelled
+	Format            string    `json:"format"`
 	Progress          int       `json:"progress"`
 	StartedAt         time.Time `json:"startedAt"`
 	CompletedAt       *time.Time `json:"completedAt,omitempty"`
 	TotalEntries      int       `json:"totalEntries"`
 	TotalImages       int       `json:"totalImages"`
 	TotalAudio        int       `json:"totalAudio"`
+	TotalVideos       int       `json:"totalVideos"`
+	TotalAttachments  int       `json:"totalAttachments"`
 	ProcessedEntries  int       `json:"processedEntries"`
 	ProcessedImages   int       `json:"processedImages"`
 	ProcessedAudio    int       `json:"processedAudio"`
+	ProcessedVideos   int       `json:"processedVideos"`
+	ProcessedAttachments int    `json:"processedAttachments"`
+	RenderedEntries   int       `json:"renderedEntries,omitempty"` // entries written to the PDF (pdf format only)
 	ZipPath           string    `json:"zipPath"`
 	Error             string    `json:"error,omitempty"`
 }
@@ -43,39 +52,99 @@ type ExportJobStatus struct {
 const exportJobRedisKeyPrefix = "export_job:"
 const exportJobTTL = 24 * time.Hour
 
+// exportManifestName is written at the root of every export zip so an import can
+// check which account the data came from
+const exportManifestName = "manifest.json"
+const exportManifestVersion = 1
+
+type exportManifest struct {
+	Version    int       `json:"version"`
+	UID        string    `json:"uid"`
+	ExportedAt time.Time `json:"exportedAt"`
+}
+
 // ExportData starts an asynchronous export job for the authenticated user.
-// Expects JSON body with { uid: string }. The uid must match the authenticated user.
+// Expects JSON body with { uid: string, stream?: bool, format?: "csv" | "pdf" }. The uid must match
+// the authenticated user. With stream set and at most streamExportMaxEntries entries, a csv export
+// is returned directly instead; pdf exports always run as a job.
 func (h *AuthHandler) ExportData(c *gin.Context) {
 	var req exportmodels.ExportDataRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	uidCtx, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 	authenticatedUID, ok := uidCtx.(string)
 	if !ok || authenticatedUID == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 	if req.UID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "uid is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "uid is required")
 		return
 	}
 	if req.UID != authenticatedUID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot export another user's data"})
+		respondError(c, http.StatusForbidden, apierror.CodeForbidden, "Cannot export another user's data")
 		return
 	}
 
+	format := strings.ToLower(strings.TrimSpace(req.Format))
+	if format == "" {
+		format = exportFormatCSV
+	}
+	if format != exportFormatCSV && format != exportFormatPDF {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "format must be csv or pdf")
+		return
+	}
+
+	policy, err := premium.ForUser(c.Request.Context(), h.postgres, authenticatedUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "plan lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify account")
+		return
+	}
+	if denial := policy.CheckExportFormat(format); denial != nil {
+		respondPlanLimit(c, denial)
+		return
+	}
+
+	// Don't start new jobs once shutdown has begun
+	if h.exportCtx.Err() != nil {
+		respondError(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, "Server is shutting down; try again shortly")
+		return
+	}
+
+	// Small exports can be streamed straight into the response
+	if req.Stream && format == exportFormatCSV {
+		var entryCount int
+		if err := h.postgres.QueryRow(c.Request.Context(), `SELECT COUNT(*) FROM entries WHERE user_uid = $1`, authenticatedUID).Scan(&entryCount); err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "Failed to count entries for export")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start export")
+			return
+		}
+		if entryCount <= streamExportMaxEntries {
+			h.streamExport(c, authenticatedUID)
+			return
+		}
+	}
+
 	jobID := uuid.New().String()
 	status := ExportJobStatus{
 		JobID:       jobID,
 		UID:         authenticatedUID,
 		Status:      "pending",
+		Format:      format,
 		Progress:    0,
 		StartedAt:   time.Now(),
 		ZipPath:     "",
@@ -83,12 +152,17 @@ func (h *AuthHandler) ExportData(c *gin.Context) {
 
 	ctx := context.Background()
 	if err := h.saveExportStatus(ctx, status); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize export job"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to initialize export job")
 		return
 	}
 
 	// Launch the export in background
-	go h.runExportJob(jobID, authenticatedUID)
+	metrics.ExportJobsTotal.WithLabelValues("started").Inc()
+	h.exportJobs.Add(1)
+	go func() {
+		defer h.exportJobs.Done()
+		h.runExportJob(h.exportCtx, jobID, authenticatedUID)
+	}()
 
 	resp := exportmodels.ExportDataResponse{ExportJobID: jobID, Message: "Export started"}
 	c.JSON(http.StatusAccepted, resp)
@@ -100,12 +174,12 @@ func (h *AuthHandler) saveExportStatus(ctx context.Context, status ExportJobStat
 	if err != nil {
 		return err
 	}
-	return h.redis.Set(ctx, key, data, exportJobTTL).Err()
+	return h.cache.Set(ctx, key, data, exportJobTTL)
 }
 
 func (h *AuthHandler) loadExportStatus(ctx context.Context, jobID string) (*ExportJobStatus, error) {
 	key := exportJobRedisKeyPrefix + jobID
-	val, err := h.redis.Get(ctx, key).Result()
+	val, err := h.cache.Get(ctx, key)
 	if err != nil {
 		return nil, err
 	}
@@ -121,9 +195,9 @@ func (h *AuthHandler) updateProgress(ctx context.Context, st *ExportJobStatus) {
 	_ = h.saveExportStatus(ctx, *st)
 }
 
-// runExportJob performs the export work and updates progress in Redis
-func (h *AuthHandler) runExportJob(jobID, uid string) {
-	ctx := context.Background()
+// runExportJob performs the export work and updates progress in Redis. When ctx is
+// cancelled (server shutdown) the job stops, removes its partial files and is marked cancelled.
+func (h *AuthHandler) runExportJob(ctx context.Context, jobID, uid string) {
 	// Load current status
 	st, err := h.loadExportStatus(ctx, jobID)
 	if err != nil {
@@ -132,14 +206,29 @@ func (h *AuthHandler) runExportJob(jobID, uid string) {
 	st.Status = "running"
 	h.updateProgress(ctx, st)
 
+	userRoot := filepath.Join("internal", "exports", uid)
+	jobRoot := filepath.Join(userRoot, jobID)
+	zipPath := filepath.Join(userRoot, fmt.Sprintf("%s.zip", jobID))
+
 	defer func() {
-		// Final persistence on exit
-		h.updateProgress(ctx, st)
+		if st.Status != "completed" && ctx.Err() != nil {
+			st.Status = "cancelled"
+			st.Error = "export cancelled because the server is shutting down"
+		}
+		if st.Status != "completed" {
+			// Never leave half-written exports behind
+			_ = os.RemoveAll(jobRoot)
+			_ = os.Remove(zipPath)
+			st.ZipPath = ""
+		}
+		// Final persistence on exit; ctx may already be cancelled
+		h.updateProgress(context.Background(), st)
+		if st.Status == "completed" || st.Status == "failed" || st.Status == "cancelled" {
+			metrics.ExportJobsTotal.WithLabelValues(st.Status).Inc()
+		}
 	}()
 
 	// Prepare directories
-	userRoot := filepath.Join("internal", "exports", uid)
-	jobRoot := filepath.Join(userRoot, jobID)
 	entriesDir := filepath.Join(jobRoot, "entries")
 	if err := os.MkdirAll(entriesDir, 0755); err != nil {
 		st.Status = "failed"
@@ -148,7 +237,7 @@ func (h *AuthHandler) runExportJob(jobID, uid string) {
 	}
 
 	// Compute totals for progress
-	var totalEntries, totalImages, totalAudio int
+	var totalEntries, totalImages, totalAudio, totalVideos, totalAttachments int
 	if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM entries WHERE user_uid = $1`, uid).Scan(&totalEntries); err != nil {
 		st.Status = "failed"
 		st.Error = fmt.Sprintf("failed to count entries: %v", err)
@@ -166,12 +255,35 @@ func (h *AuthHandler) runExportJob(jobID, uid string) {
 		st.Error = fmt.Sprintf("failed to count audio: %v", err)
 		return
 	}
+	// Videos total
+	if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM videos v WHERE v.entry_id IN (SELECT id FROM entries e WHERE e.user_uid = $1)`, uid).Scan(&totalVideos); err != nil {
+		st.Status = "failed"
+		st.Error = fmt.Sprintf("failed to count videos: %v", err)
+		return
+	}
+	// Attachments total
+	if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM attachments a WHERE a.entry_id IN (SELECT id FROM entries e WHERE e.user_uid = $1)`, uid).Scan(&totalAttachments); err != nil {
+		st.Status = "failed"
+		st.Error = fmt.Sprintf("failed to count attachments: %v", err)
+		return
+	}
 
 	st.TotalEntries = totalEntries
 	st.TotalImages = totalImages
 	st.TotalAudio = totalAudio
+	st.TotalVideos = totalVideos
+	st.TotalAttachments = totalAttachments
 	h.updateProgress(ctx, st)
 
+	renderPDF := st.Format == exportFormatPDF
+	var pdfEntries []pdfExportEntry
+
+	if err := writeExportManifest(filepath.Join(jobRoot, exportManifestName), uid); err != nil {
+		st.Status = "failed"
+		st.Error = fmt.Sprintf("failed to write manifest: %v", err)
+		return
+	}
+
 	// Create CSV file
 	csvPath := filepath.Join(entriesDir, "entries.csv")
 	csvFile, err := os.Create(csvPath)
@@ -188,7 +300,7 @@ func (h *AuthHandler) runExportJob(jobID, uid string) {
 	_ = csvWriter.Write([]string{"id", "title", "description", "locations", "tags", "createdAt", "updatedAt"})
 
 	// Iterate entries
-	rows, err := h.postgres.Query(ctx, `SELECT id, title, description, created_at, updated_at FROM entries WHERE user_uid = $1 ORDER BY created_at`, uid)
+	rows, err := h.postgres.Query(ctx, `SELECT id, title, description, encrypted, created_at, updated_at FROM entries WHERE user_uid = $1 ORDER BY created_at`, uid)
 	if err != nil {
 		st.Status = "failed"
 		st.Error = fmt.Sprintf("failed to fetch entries: %v", err)
@@ -196,29 +308,46 @@ func (h *AuthHandler) runExportJob(jobID, uid string) {
 	}
 	defer rows.Close()
 
+	// Exports hold decrypted text so they can be read and re-imported anywhere
+	cipher := newEntryCipher(h.postgres, h.entryKeys)
+
 	for rows.Next() {
+		if ctx.Err() != nil {
+			return
+		}
+
 		var entryID, title, description string
+		var encrypted bool
 		var createdAt, updatedAt time.Time
-		if err := rows.Scan(&entryID, &title, &description, &createdAt, &updatedAt); err != nil {
+		if err := rows.Scan(&entryID, &title, &description, &encrypted, &createdAt, &updatedAt); err != nil {
 			st.Status = "failed"
 			st.Error = fmt.Sprintf("failed to scan entry: %v", err)
 			return
 		}
+		if err := cipher.open(ctx, uid, encrypted, &title, &description); err != nil {
+			st.Status = "failed"
+			st.Error = fmt.Sprintf("failed to decrypt entry: %v", err)
+			return
+		}
 
 		// Fetch tags
-		tagsJSON, err := h.fetchTagsJSON(ctx, entryID)
+		tags, err := h.fetchExportTags(ctx, entryID)
 		if err != nil {
 			st.Status = "failed"
 			st.Error = fmt.Sprintf("failed to fetch tags: %v", err)
 			return
 		}
+		tagsBytes, _ := json.Marshal(tags)
+		tagsJSON := string(tagsBytes)
 		// Fetch locations
-		locationsJSON, err := h.fetchLocationsJSON(ctx, entryID)
+		locations, err := h.fetchExportLocations(ctx, entryID)
 		if err != nil {
 			st.Status = "failed"
 			st.Error = fmt.Sprintf("failed to fetch locations: %v", err)
 			return
 		}
+		locationsBytes, _ := json.Marshal(locations)
+		locationsJSON := string(locationsBytes)
 
 		// Write CSV row
 		_ = csvWriter.Write([]string{
@@ -238,8 +367,20 @@ func (h *AuthHandler) runExportJob(jobID, uid string) {
 		entryDir := filepath.Join(entriesDir, entryID)
 		imagesDir := filepath.Join(entryDir, "images")
 		audioDir := filepath.Join(entryDir, "audio")
+		videosDir := filepath.Join(entryDir, "videos")
+		attachmentsDir := filepath.Join(entryDir, "attachments")
 		_ = os.MkdirAll(imagesDir, 0755)
 		_ = os.MkdirAll(audioDir, 0755)
+		_ = os.MkdirAll(videosDir, 0755)
+		_ = os.MkdirAll(attachmentsDir, 0755)
+
+		pdfEntry := pdfExportEntry{
+			Title:       title,
+			Description: description,
+			CreatedAt:   createdAt,
+			Tags:        tags,
+			Locations:   locations,
+		}
 
 		// Copy images
 		imgRows, err := h.postgres.Query(ctx, `SELECT url FROM images WHERE entry_id = $1 ORDER BY upload_order`, entryID)
@@ -256,9 +397,12 @@ func (h *AuthHandler) runExportJob(jobID, uid string) {
 				st.Error = fmt.Sprintf("failed to scan image: %v", err)
 				return
 			}
-			if err := copyMediaFromURL(imageURL, filepath.Join(imagesDir, filepath.Base(imageURL))); err != nil {
+			imagePath := filepath.Join(imagesDir, filepath.Base(imageURL))
+			if err := h.copyMediaFromURL(ctx, uid, imageURL, imagePath); err != nil {
 				// Log and continue; don't fail the entire job for a missing file
 				fmt.Printf("warning: failed to copy image %s: %v\n", imageURL, err)
+			} else {
+				pdfEntry.ImagePaths = append(pdfEntry.ImagePaths, imagePath)
 			}
 			st.ProcessedImages++
 			h.recalculateAndPersistProgress(ctx, st)
@@ -280,7 +424,7 @@ func (h *AuthHandler) runExportJob(jobID, uid string) {
 				st.Error = fmt.Sprintf("failed to scan audio: %v", err)
 				return
 			}
-			if err := copyMediaFromURL(audioURL, filepath.Join(audioDir, filepath.Base(audioURL))); err != nil {
+			if err := h.copyMediaFromURL(ctx, uid, audioURL, filepath.Join(audioDir, filepath.Base(audioURL))); err != nil {
 				// Log and continue; don't fail the entire job for a missing file
 				fmt.Printf("warning: failed to copy audio %s: %v\n", audioURL, err)
 			}
@@ -288,10 +432,88 @@ func (h *AuthHandler) runExportJob(jobID, uid string) {
 			h.recalculateAndPersistProgress(ctx, st)
 		}
 		audRows.Close()
+
+		// Copy videos and their posters
+		vidRows, err := h.postgres.Query(ctx, `SELECT url, COALESCE(thumbnail_url, '') FROM videos WHERE entry_id = $1 ORDER BY upload_order`, entryID)
+		if err != nil {
+			st.Status = "failed"
+			st.Error = fmt.Sprintf("failed to fetch videos: %v", err)
+			return
+		}
+		for vidRows.Next() {
+			var videoURL, thumbnailURL string
+			if err := vidRows.Scan(&videoURL, &thumbnailURL); err != nil {
+				vidRows.Close()
+
+		// Copy attachments
+		attRows, err := h.postgres.Query(ctx, `SELECT url FROM attachments WHERE entry_id = $1 ORDER BY upload_order`, entryID)
+		if err != nil {
+			st.Status = "failed"
+			st.Error = fmt.Sprintf("failed to fetch attachments: %v", err)
+			return
+		}
+		for attRows.Next() {
+			var attachmentURL string
+			if err := attRows.Scan(&attachmentURL); err != nil {
+				attRows.Close()
+				st.Status = "failed"
+				st.Error = fmt.Sprintf("failed to scan attachment: %v", err)
+				return
+			}
+			if err := h.copyMediaFromURL(ctx, uid, attachmentURL, filepath.Join(attachmentsDir, filepath.Base(attachmentURL))); err != nil {
+				// Log and continue; don't fail the entire job for a missing file
+				if h.logger != nil {
+					h.logger.Warnw("Failed to copy attachment for export", "uid", uid, "url", attachmentURL, "error", err)
+				}
+			}
+			st.ProcessedAttachments++
+			h.recalculateAndPersistProgress(ctx, st)
+		}
+		attRows.Close()
+				st.Status = "failed"
+				st.Error = fmt.Sprintf("failed to scan video: %v", err)
+				return
+			}
+			for _, u := range []string{videoURL, thumbnailURL} {
+				if u == "" {
+					continue
+				}
+				if err := h.copyMediaFromURL(ctx, uid, u, filepath.Join(videosDir, filepath.Base(u))); err != nil {
+					// Log and continue; don't fail the entire job for a missing file
+					if h.logger != nil {
+						h.logger.Warnw("Failed to copy video for export", "uid", uid, "url", u, "error", err)
+					}
+				}
+			}
+			st.ProcessedVideos++
+			h.recalculateAndPersistProgress(ctx, st)
+		}
+		vidRows.Close()
+
+		if renderPDF {
+			pdfEntries = append(pdfEntries, pdfEntry)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	if renderPDF {
+		var displayName string
+		_ = h.postgres.QueryRow(ctx, `SELECT COALESCE(display_name, '') FROM users WHERE uid = $1`, uid).Scan(&displayName)
+		err := renderJournalPDF(filepath.Join(jobRoot, pdfExportFilename), displayName, pdfEntries, func() {
+			st.RenderedEntries++
+			h.recalculateAndPersistProgress(ctx, st)
+		})
+		if err != nil {
+			st.Status = "failed"
+			st.Error = fmt.Sprintf("failed to render PDF: %v", err)
+			return
+		}
 	}
 
 	// Zip the job directory
-	zipPath := filepath.Join(userRoot, fmt.Sprintf("%s.zip", jobID))
 	if err := zipDirectory(jobRoot, zipPath); err != nil {
 		st.Status = "failed"
 		st.Error = fmt.Sprintf("failed to create zip: %v", err)
@@ -306,8 +528,13 @@ func (h *AuthHandler) runExportJob(jobID, uid string) {
 }
 
 func (h *AuthHandler) recalculateAndPersistProgress(ctx context.Context, st *ExportJobStatus) {
-	total := st.TotalEntries + st.TotalImages + st.TotalAudio
-	processed := st.ProcessedEntries + st.ProcessedImages + st.ProcessedAudio
+	total := st.TotalEntries + st.TotalImages + st.TotalAudio + st.TotalVideos + st.TotalAttachments
+	processed := st.ProcessedEntries + st.ProcessedImages + st.ProcessedAudio + st.ProcessedVideos + st.ProcessedAttachments
+	if st.Format == exportFormatPDF {
+		// Rendering the PDF is another pass over every entry
+		total += st.TotalEntries
+		processed += st.RenderedEntries
+	}
 	if total <= 0 {
 		st.Progress = 100
 	} else {
@@ -320,70 +547,92 @@ func (h *AuthHandler) recalculateAndPersistProgress(ctx context.Context, st *Exp
 	h.updateProgress(ctx, st)
 }
 
-func (h *AuthHandler) fetchTagsJSON(ctx context.Context, entryID string) (string, error) {
-	type tag struct {
-		Key   string `json:"key"`
-		Value string `json:"value,omitempty"`
-	}
+// exportTag and exportLocation are the shapes written to the export CSV (as JSON) and PDF
+type exportTag struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+}
+
+type exportLocation struct {
+	Latitude    float64 `json:"latitude,omitempty"`
+	Longitude   float64 `json:"longitude,omitempty"`
+	Address     string  `json:"address,omitempty"`
+	City        string  `json:"city,omitempty"`
+	State       string  `json:"state,omitempty"`
+	Zip         string  `json:"zip,omitempty"`
+	Country     string  `json:"country,omitempty"`
+	CountryCode string  `json:"countryCode,omitempty"`
+	DisplayName string  `json:"displayName,omitempty"`
+}
+
+func (h *AuthHandler) fetchExportTags(ctx context.Context, entryID string) ([]exportTag, error) {
 	rows, err := h.postgres.Query(ctx, `SELECT key, value FROM tags WHERE entry_id = $1 ORDER BY created_at`, entryID)
 	if err != nil {
-		return "[]", nil
+		return nil, nil
 	}
 	defer rows.Close()
-	var tags []tag
+	var tags []exportTag
 	for rows.Next() {
-		var t tag
+		var t exportTag
 		if err := rows.Scan(&t.Key, &t.Value); err == nil {
 			tags = append(tags, t)
 		}
 	}
-	b, _ := json.Marshal(tags)
-	return string(b), nil
+	return tags, nil
 }
 
-func (h *AuthHandler) fetchLocationsJSON(ctx context.Context, entryID string) (string, error) {
-	type loc struct {
-		Latitude    float64 `json:"latitude,omitempty"`
-		Longitude   float64 `json:"longitude,omitempty"`
-		Address     string  `json:"address,omitempty"`
-		City        string  `json:"city,omitempty"`
-		State       string  `json:"state,omitempty"`
-		Zip         string  `json:"zip,omitempty"`
-		Country     string  `json:"country,omitempty"`
-		CountryCode string  `json:"countryCode,omitempty"`
-		DisplayName string  `json:"displayName,omitempty"`
-	}
+func (h *AuthHandler) fetchExportLocations(ctx context.Context, entryID string) ([]exportLocation, error) {
 	rows, err := h.postgres.Query(ctx, `SELECT latitude, longitude, address, city, state, zip, country, country_code, display_name FROM locations WHERE entry_id = $1 ORDER BY created_at`, entryID)
 	if err != nil {
-		return "[]", nil
+		return nil, nil
 	}
 	defer rows.Close()
-	var locs []loc
+	var locs []exportLocation
 	for rows.Next() {
-		var l loc
+		var l exportLocation
 		if err := rows.Scan(&l.Latitude, &l.Longitude, &l.Address, &l.City, &l.State, &l.Zip, &l.Country, &l.CountryCode, &l.DisplayName); err == nil {
 			locs = append(locs, l)
 		}
 	}
+	return locs, nil
+}
+
+func (h *AuthHandler) fetchTagsJSON(ctx context.Context, entryID string) (string, error) {
+	tags, err := h.fetchExportTags(ctx, entryID)
+	if err != nil {
+		return "", err
+	}
+	b, _ := json.Marshal(tags)
+	return string(b), nil
+}
+
+func (h *AuthHandler) fetchLocationsJSON(ctx context.Context, entryID string) (string, error) {
+	locs, err := h.fetchExportLocations(ctx, entryID)
+	if err != nil {
+		return "", err
+	}
 	b, _ := json.Marshal(locs)
 	return string(b), nil
 }
 
-// copyMediaFromURL takes a URL like "/images/<uid>/<entryID>/<filename>" or "/audio/..." and copies
-// the file into destPath. The destination directory must already exist.
-func copyMediaFromURL(urlPath, destPath string) error {
-	var srcPath string
-	if strings.HasPrefix(urlPath, "/images/") {
-		rel := strings.TrimPrefix(urlPath, "/images/")
-		srcPath = filepath.Join("internal", "images", rel)
-	} else if strings.HasPrefix(urlPath, "/audio/") {
-		rel := strings.TrimPrefix(urlPath, "/audio/")
-		srcPath = filepath.Join("internal", "audio", rel)
-	} else {
-		return fmt.Errorf("unsupported media URL: %s", urlPath)
+func writeExportManifest(path, uid string) error {
+	data, err := json.Marshal(exportManifest{Version: exportManifestVersion, UID: uid, ExportedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// copyMediaFromURL takes a URL like "/images/<uid>/<entryID>/<filename>", "/audio/..." or "/videos/..." and copies
+// the stored file to destPath. The destination directory must already exist. URLs outside
+// uid's own media are refused.
+func (h *AuthHandler) copyMediaFromURL(ctx context.Context, uid, urlPath, destPath string) error {
+	key, err := mediaSourceKey(urlPath, uid)
+	if err != nil {
+		return err
 	}
 	// Open source
-	s, err := os.Open(srcPath)
+	s, err := h.media.Open(ctx, key)
 	if err != nil {
 		return err
 	}
@@ -398,6 +647,21 @@ func copyMediaFromURL(urlPath, destPath string) error {
 	return err
 }
 
+// mediaSourceKey maps a media URL like "/images/<uid>/<entryID>/<filename>" to its storage
+// key if it is one of uid's files
+func mediaSourceKey(urlPath, uid string) (string, error) {
+	if strings.HasPrefix(urlPath, "/audio/") {
+		return mediaKeyFromURL(urlPath, "audio", uid, "")
+	}
+	if strings.HasPrefix(urlPath, "/videos/") {
+		return mediaKeyFromURL(urlPath, "videos", uid, "")
+	}
+	if strings.HasPrefix(urlPath, "/attachments/") {
+		return mediaKeyFromURL(urlPath, "attachments", uid, "")
+	}
+	return mediaKeyFromURL(urlPath, "images", uid, "")
+}
+
 // zipDirectory zips the entire contents of srcDir into destZipPath
 func zipDirectory(srcDir, destZipPath string) error {
 	zipFile, err := os.Create(destZipPath)
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	exportFormatCSV = "csv"
+	exportFormatPDF = "pdf"
+
+	pdfExportFilename = "journal.pdf"
+
+	// Largest height, in mm, an embedded image may take on an A4 page
+	pdfMaxImageHeight = 110.0
+)
+
+// pdfExportEntry is everything rendered for one entry in the PDF journal
+type pdfExportEntry struct {
+	Title       string
+	Description string
+	CreatedAt   time.Time
+	Tags        []exportTag
+	Locations   []exportLocation
+	// ImagePaths are the copies already made in the export job directory
+	ImagePaths []string
+}
+
+// renderJournalPDF writes entries to destPath as an A4 journal, one entry per page
+// (continuing onto further pages as needed). Missing, corrupt or unsupported image files
+// are skipped. onEntry is called after each entry is rendered so callers can report progress.
+func renderJournalPDF(destPath, displayName string, entries []pdfExportEntry, onEntry func()) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(18, 18, 18)
+	pdf.SetAutoPageBreak(true, 18)
+	pdf.SetTitle("Journey journal export", true)
+	pdf.AliasNbPages("")
+
+	// Core fonts are cp1252; characters outside it are replaced rather than breaking the PDF
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 6, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, _, right, bottom := pdf.GetMargins()
+	contentWidth := pageWidth - left - right
+
+	// Cover page
+	pdf.AddPage()
+	pdf.SetY(pageHeight / 3)
+	pdf.SetFont("Helvetica", "B", 28)
+	pdf.CellFormat(0, 14, "Journey", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 14)
+	if displayName != "" {
+		pdf.CellFormat(0, 8, tr(displayName), "", 1, "C", false, 0, "")
+	}
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.CellFormat(0, 8, fmt.Sprintf("%d entries - exported %s", len(entries), time.Now().UTC().Format("January 2, 2006")), "", 1, "C", false, 0, "")
+
+	for _, e := range entries {
+		pdf.AddPage()
+
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont("Helvetica", "B", 18)
+		title := e.Title
+		if strings.TrimSpace(title) == "" {
+			title = "Untitled"
+		}
+		pdf.MultiCell(0, 9, tr(title), "", "L", false)
+
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetTextColor(100, 100, 100)
+		pdf.CellFormat(0, 6, e.CreatedAt.Format("Monday, January 2, 2006 3:04 PM"), "", 1, "L", false, 0, "")
+
+		if places := locationNames(e.Locations); len(places) > 0 {
+			pdf.MultiCell(0, 5, tr("Location: "+strings.Join(places, "; ")), "", "L", false)
+		}
+		if labels := tagLabels(e.Tags); len(labels) > 0 {
+			pdf.MultiCell(0, 5, tr("Tags: "+strings.Join(labels, ", ")), "", "L", false)
+		}
+		pdf.Ln(4)
+
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont("Helvetica", "", 12)
+		if e.Description != "" {
+			pdf.MultiCell(0, 6, tr(e.Description), "", "L", false)
+			pdf.Ln(4)
+		}
+
+		for _, imgPath := range e.ImagePaths {
+			info, ok := registerPDFImage(pdf, imgPath)
+			if !ok {
+				continue
+			}
+			w, h := fitImage(info.Width(), info.Height(), contentWidth, pdfMaxImageHeight)
+			if pdf.GetY()+h > pageHeight-bottom {
+				pdf.AddPage()
+			}
+			pdf.ImageOptions(imgPath, left+(contentWidth-w)/2, pdf.GetY(), w, h, false, fpdf.ImageOptions{}, 0, "")
+			pdf.SetY(pdf.GetY() + h + 4)
+		}
+
+		if err := pdf.Error(); err != nil {
+			return err
+		}
+		if onEntry != nil {
+			onEntry()
+		}
+	}
+
+	return pdf.OutputFileAndClose(destPath)
+}
+
+// registerPDFImage registers an image with the document, reporting false for files that
+// are missing, corrupt or in a format fpdf can't embed (e.g. HEIC, WebP)
+func registerPDFImage(pdf *fpdf.Fpdf, path string) (*fpdf.ImageInfoType, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	cfg, format, err := image.DecodeConfig(f)
+	f.Close()
+	if err != nil || cfg.Width == 0 || cfg.Height == 0 {
+		return nil, false
+	}
+
+	var imageType string
+	switch format {
+	case "jpeg":
+		imageType = "JPG"
+	case "png":
+		imageType = "PNG"
+	case "gif":
+		imageType = "GIF"
+	default:
+		return nil, false
+	}
+
+	info := pdf.RegisterImageOptions(path, fpdf.ImageOptions{ImageType: imageType})
+	if pdf.Err() || info == nil {
+		// fpdf rejects some valid files (e.g. interlaced PNGs); drop the image, keep the document
+		pdf.ClearError()
+		return nil, false
+	}
+	return info, true
+}
+
+// fitImage scales w x h to fit within maxW x maxH, preserving aspect ratio
+func fitImage(w, h, maxW, maxH float64) (float64, float64) {
+	scale := maxW / w
+	if h*scale > maxH {
+		scale = maxH / h
+	}
+	return w * scale, h * scale
+}
+
+func locationNames(locs []exportLocation) []string {
+	names := make([]string, 0, len(locs))
+	for _, l := range locs {
+		switch {
+		case l.DisplayName != "":
+			names = append(names, l.DisplayName)
+		case l.City != "" || l.Country != "":
+			names = append(names, strings.Trim(strings.Join([]string{l.City, l.State, l.Country}, ", "), ", "))
+		case l.Address != "":
+			names = append(names, l.Address)
+		}
+	}
+	return names
+}
+
+func tagLabels(tags []exportTag) []string {
+	labels := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if t.Value != "" {
+			labels = append(labels, t.Key+": "+t.Value)
+		} else {
+			labels = append(labels, t.Key)
+		}
+	}
+	return labels
+}
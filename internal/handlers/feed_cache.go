@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"io.winapps.journeyapp/internal/cache"
+	listfeedsmodels "io.winapps.journeyapp/internal/models/list-feeds"
+)
+
+const (
+	feedCacheTTL = 5 * time.Minute
+	// feedVersionTTL is refreshed on every bump and only needs to outlive feedCacheTTL, so
+	// a counter that expires and restarts can't match a feed cached under its old value
+	feedVersionTTL = 24 * time.Hour
+)
+
+// cachedFeeds is what ListFeeds stores under feeds:<uid>: the response plus the feed
+// version of each friend it was built from. A hit is only served while every friend's
+// version is unchanged, so entry writes never have to look up the writer's followers.
+type cachedFeeds struct {
+	Versions map[string]string                 `json:"versions"`
+	Response listfeedsmodels.ListFeedsResponse `json:"response"`
+}
+
+func feedVersionKey(uid string) string {
+	return "feed_version:" + uid
+}
+
+// bumpFeedVersion marks uid's entries as changed, which makes every cached feed that
+// includes them stale. Call it after any write that changes what friends see: creating,
+// publishing, updating, sharing or deleting an entry.
+func bumpFeedVersion(ctx context.Context, store cache.Store, uid string) {
+	key := feedVersionKey(uid)
+	if _, err := store.Incr(ctx, key, feedVersionTTL); err != nil {
+		return
+	}
+	_ = store.Expire(ctx, key, feedVersionTTL)
+}
+
+// feedVersions returns the current feed version of each uid, "" for users who haven't
+// written since their counter expired
+func feedVersions(ctx context.Context, store cache.Store, uids []string) map[string]string {
+	versions := make(map[string]string, len(uids))
+	for _, uid := range uids {
+		v, err := store.Get(ctx, feedVersionKey(uid))
+		if err != nil {
+			v = ""
+		}
+		versions[uid] = v
+	}
+	return versions
+}
+
+// fresh reports whether none of the friends in the cached feed have written since it was built
+func (f *cachedFeeds) fresh(ctx context.Context, store cache.Store) bool {
+	uids := make([]string, 0, len(f.Versions))
+	for uid := range f.Versions {
+		uids = append(uids, uid)
+	}
+	for uid, v := range feedVersions(ctx, store, uids) {
+		if f.Versions[uid] != v {
+			return false
+		}
+	}
+	return true
+}
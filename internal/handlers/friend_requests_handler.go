@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"io.winapps.journeyapp/internal/apierror"
+	friendrequestsmodels "io.winapps.journeyapp/internal/models/friend_requests"
+)
+
+// friendRequestsCacheTTL is short since requests are the user's inbox; the cache is also
+// cleared by invalidateFriendsCache whenever a friendship changes
+const friendRequestsCacheTTL = time.Minute
+
+// GetFriendRequests returns the authenticated user's pending friend requests, split into
+// incoming (sent to the user, who can act on them) and outgoing (sent by the user), newest
+// first
+func (h *UsersHandler) GetFriendRequests(c *gin.Context) {
+	uidVal, authed := c.Get("uid")
+	if !authed {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	authUID, _ := uidVal.(string)
+
+	ctx := c.Request.Context()
+	// Under friends:<uid>: so invalidateFriendsCache clears it with the ListFriends pages
+	cacheKey := fmt.Sprintf("friends:%s:requests", authUID)
+
+	if cached, err := h.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+		var cachedResponse friendrequestsmodels.GetFriendRequestsResponse
+		if err := json.Unmarshal([]byte(cached), &cachedResponse); err == nil {
+			c.JSON(http.StatusOK, cachedResponse)
+			return
+		}
+	}
+
+	rows, err := h.postgres.Query(ctx, `
+		SELECT u.uid, u.display_name, u.photo_url, COALESCE(u.is_premium, FALSE), f.created_at, f.fid = $1 AS incoming
+		FROM friendships f
+		JOIN users u ON u.uid = CASE WHEN f.uid = $1 THEN f.fid ELSE f.uid END
+		WHERE (f.uid = $1 OR f.fid = $1) AND f.status = 'pending'
+		ORDER BY f.created_at DESC, u.uid
+	`, authUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list friend requests")
+		return
+	}
+	defer rows.Close()
+
+	response := friendrequestsmodels.GetFriendRequestsResponse{
+		Incoming: make([]friendrequestsmodels.FriendRequest, 0),
+		Outgoing: make([]friendrequestsmodels.FriendRequest, 0),
+	}
+	for rows.Next() {
+		var request friendrequestsmodels.FriendRequest
+		var incoming bool
+		if err := rows.Scan(&request.UID, &request.DisplayName, &request.PhotoURL, &request.IsPremium, &request.CreatedAt, &incoming); err != nil {
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read results")
+			return
+		}
+		if incoming {
+			response.Incoming = append(response.Incoming, request)
+		} else {
+			response.Outgoing = append(response.Outgoing, request)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read results")
+		return
+	}
+
+	if data, err := json.Marshal(response); err == nil {
+		_ = h.cache.Set(ctx, cacheKey, data, friendRequestsCacheTTL)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -4,14 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/cache"
 	getdetailsmodels "io.winapps.journeyapp/internal/models/get_account_details"
 	stream "github.com/GetStream/stream-chat-go/v5"
 )
@@ -21,34 +23,34 @@ func (h *AuthHandler) GetAccountDetails(c *gin.Context) {
 	// Ensure user is authenticated (middleware populates context)
 	uidCtx, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 	authenticatedUID, ok := uidCtx.(string)
 	if !ok || authenticatedUID == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Read uid from query string (must match authenticated user)
 	requestedUID := c.Query("uid")
 	if requestedUID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required query parameter: uid"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Missing required query parameter: uid")
 		return
 	}
 	if requestedUID != authenticatedUID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot access another user's account details"})
+		respondError(c, http.StatusForbidden, apierror.CodeForbidden, "Cannot access another user's account details")
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	// Attempt Redis cache first
-	cacheKey := fmt.Sprintf("account_details:%s", requestedUID)
-	if cached, err := h.redis.Get(ctx, cacheKey).Result(); err == nil && cached != "" {
+	cacheKey := accountDetailsKey(requestedUID)
+	if cached, err := h.cache.Get(ctx, cacheKey); err == nil && cached != "" {
 		var resp getdetailsmodels.GetAccountDetailsResponse
 		if err := json.Unmarshal([]byte(cached), &resp); err == nil {
-			c.JSON(http.StatusOK, resp)
+			respondJSONWithETag(c, resp)
 			return
 		}
 	}
@@ -64,14 +66,15 @@ func (h *AuthHandler) GetAccountDetails(c *gin.Context) {
 		phoneNumberVerified bool
 		isPremium           bool
 		premiumExpiresAtPtr *time.Time
+		entryEncryption     bool
 		accountCreatedAt    time.Time
 		accountUpdatedAt    time.Time
 	)
 
 	userQuery := `
-		SELECT token, display_name, email, photo_url, phone_number,
+		SELECT COALESCE(token, ''), display_name, email, photo_url, phone_number,
 		       email_verified, phone_number_verified,
-		       is_premium, premium_expires_at,
+		       is_premium, premium_expires_at, encryption_enabled,
 		       created_at, updated_at
 		FROM users
 		WHERE uid = $1
@@ -86,14 +89,18 @@ func (h *AuthHandler) GetAccountDetails(c *gin.Context) {
 		&phoneNumberVerified,
 		&isPremium,
 		&premiumExpiresAtPtr,
+		&entryEncryption,
 		&accountCreatedAt,
 		&accountUpdatedAt,
 	); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "User not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch user")
 		return
 	}
 
@@ -128,35 +135,21 @@ func (h *AuthHandler) GetAccountDetails(c *gin.Context) {
 			settingsCreatedAt = accountCreatedAt
 			settingsUpdatedAt = accountUpdatedAt
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+			if abortOnContextError(c, err) {
+				return
+			}
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch settings")
 			return
 		}
 	}
 
 	// Fetch aggregate counts
-	var (
-		totalEntries   int
-		totalTags      int
-		totalLocations int
-		totalImages    int
-		totalAudios    int
-	)
-	countsQuery := `
-		SELECT
-			(SELECT COUNT(*) FROM entries e WHERE e.user_uid = $1) AS total_entries,
-			(SELECT COUNT(*) FROM tags t JOIN entries e ON t.entry_id = e.id WHERE e.user_uid = $1) AS total_tags,
-			(SELECT COUNT(*) FROM locations l JOIN entries e ON l.entry_id = e.id WHERE e.user_uid = $1) AS total_locations,
-			(SELECT COUNT(*) FROM images i JOIN entries e ON i.entry_id = e.id WHERE e.user_uid = $1) AS total_images,
-			(SELECT COUNT(*) FROM audio a JOIN entries e ON a.entry_id = e.id WHERE e.user_uid = $1) AS total_audios
-	`
-	if err := h.postgres.QueryRow(ctx, countsQuery, requestedUID).Scan(
-		&totalEntries,
-		&totalTags,
-		&totalLocations,
-		&totalImages,
-		&totalAudios,
-	); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute aggregates"})
+	totals, err := loadAccountTotals(ctx, h.postgres, requestedUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to compute aggregates")
 		return
 	}
 
@@ -164,24 +157,24 @@ func (h *AuthHandler) GetAccountDetails(c *gin.Context) {
 	apiKey := os.Getenv("STREAM_API_KEY")
 	apiSecret := os.Getenv("STREAM_API_SECRET")
 	if apiKey == "" || apiSecret == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Stream credentials missing on server"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Stream credentials missing on server")
 		return
 	}
 
 	client, err := stream.NewClient(apiKey, apiSecret)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize Stream client"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to initialize Stream client")
 		return
 	}
 
 	streamToken, err := client.CreateToken(requestedUID, time.Time{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stream token"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create stream token")
 		return
 	}
 
 	// Ensure user is a member of public channels (idempotent; log errors only)
-	addUserToPublicChannels(ctx, client, requestedUID)
+	addUserToPublicChannels(ctx, h.logger, client, requestedUID)
 
 	// Assemble response
 	resp := getdetailsmodels.GetAccountDetailsResponse{
@@ -202,20 +195,71 @@ func (h *AuthHandler) GetAccountDetails(c *gin.Context) {
 		AccountUpdatedAt:    accountUpdatedAt,
 		SettingsCreatedAt:   settingsCreatedAt,
 		SettingsUpdatedAt:   settingsUpdatedAt,
-		TotalEntries:        totalEntries,
-		TotalTags:           totalTags,
-		TotalLocations:      totalLocations,
-		TotalImages:         totalImages,
-		TotalAudios:         totalAudios,
-		TotalVideos:         0,
+		TotalEntries:        totals.entries,
+		TotalTags:           totals.tags,
+		TotalLocations:      totals.locations,
+		TotalImages:         totals.images,
+		TotalAudios:         totals.audios,
+		TotalVideos:         totals.videos,
 		IsPremium:           isPremium,
 		PremiumExpiresAt:    func() time.Time { if premiumExpiresAtPtr != nil { return *premiumExpiresAtPtr }; return time.Time{} }(),
+		EntryEncryption:     entryEncryption,
 	}
 
 	// Cache response for a short period
 	if payload, err := json.Marshal(resp); err == nil {
-		_ = h.redis.Set(ctx, cacheKey, payload, 10*time.Minute).Err()
+		_ = h.cache.Set(ctx, cacheKey, payload, 10*time.Minute)
 	}
 
-	c.JSON(http.StatusOK, resp)
+	respondJSONWithETag(c, resp)
+}
+
+// accountTotals are the per-user counts reported by GetAccountDetails and, for entries,
+// GetUserDetails
+type accountTotals struct {
+	entries   int
+	tags      int
+	locations int
+	images    int
+	audios    int
+	videos    int
+}
+
+// loadAccountTotals counts uid's entries and everything attached to them
+func loadAccountTotals(ctx context.Context, postgres *pgxpool.Pool, uid string) (accountTotals, error) {
+	var t accountTotals
+	err := postgres.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM entries e WHERE e.user_uid = $1) AS total_entries,
+			(SELECT COUNT(*) FROM tags t JOIN entries e ON t.entry_id = e.id WHERE e.user_uid = $1) AS total_tags,
+			(SELECT COUNT(*) FROM locations l JOIN entries e ON l.entry_id = e.id WHERE e.user_uid = $1) AS total_locations,
+			(SELECT COUNT(*) FROM images i JOIN entries e ON i.entry_id = e.id WHERE e.user_uid = $1) AS total_images,
+			(SELECT COUNT(*) FROM audio a JOIN entries e ON a.entry_id = e.id WHERE e.user_uid = $1) AS total_audios,
+			(SELECT COUNT(*) FROM videos v JOIN entries e ON v.entry_id = e.id WHERE e.user_uid = $1) AS total_videos
+	`, uid).Scan(&t.entries, &t.tags, &t.locations, &t.images, &t.audios, &t.videos)
+	return t, err
+}
+
+// accountDetailsKey is the cache key of uid's GetAccountDetails response
+func accountDetailsKey(uid string) string {
+	return "account_details:" + uid
+}
+
+// userDetailsKey is the cache key of uid's GetUserDetails response
+func userDetailsKey(uid string) string {
+	return "user_details:" + uid
+}
+
+// invalidateAccountDetails drops the cached GetAccountDetails and GetUserDetails
+// responses for each uid. Call it after any write that changes something either snapshot
+// reports: the entry, tag, location or media totals, the profile, settings or premium
+// status.
+func invalidateAccountDetails(ctx context.Context, store cache.Store, uids ...string) {
+	keys := make([]string, 0, 2*len(uids))
+	for _, uid := range uids {
+		keys = append(keys, accountDetailsKey(uid), userDetailsKey(uid))
+	}
+	if len(keys) > 0 {
+		_ = store.Del(ctx, keys...)
+	}
 }
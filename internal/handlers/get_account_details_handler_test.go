@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"io.winapps.journeyapp/internal/cache"
+	getdetailsmodels "io.winapps.journeyapp/internal/models/get_user_details"
+)
+
+func TestInvalidateAccountDetails(t *testing.T) {
+	ctx := context.Background()
+	store := cache.NewMemory(100)
+	for _, uid := range []string{"alice", "bob", "carol"} {
+		_ = store.Set(ctx, accountDetailsKey(uid), "{}", time.Hour)
+		_ = store.Set(ctx, userDetailsKey(uid), "{}", time.Hour)
+	}
+
+	invalidateAccountDetails(ctx, store, "alice", "bob")
+
+	for _, uid := range []string{"alice", "bob"} {
+		for _, key := range []string{accountDetailsKey(uid), userDetailsKey(uid)} {
+			if v, _ := store.Get(ctx, key); v != "" {
+				t.Errorf("%s still cached", key)
+			}
+		}
+	}
+	if v, _ := store.Get(ctx, accountDetailsKey("carol")); v == "" {
+		t.Error("carol's account details were dropped too")
+	}
+}
+
+// Creating an entry shows up in the very next account and user details, not after the
+// cached copies expire
+func TestAccountCountsUpdateAfterCreateEntry(t *testing.T) {
+	pool := testPool(t)
+	store := cache.NewMemory(100)
+	entries := NewEntryHandler(nil, pool, store, nil)
+	users := NewUsersHandler(nil, pool, store, nil)
+	alice := testUser(t, pool, "alice")
+	ctx := context.Background()
+
+	totalEntries := func() int {
+		t.Helper()
+		w := callAs(alice, users.GetUserDetails, http.MethodGet, "/get-user-details?uid="+alice, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetUserDetails: %d %s", w.Code, w.Body.String())
+		}
+		var resp getdetailsmodels.GetUserDetailsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.TotalEntries
+	}
+
+	if n := totalEntries(); n != 0 {
+		t.Fatalf("totalEntries before = %d, want 0", n)
+	}
+	_ = store.Set(ctx, accountDetailsKey(alice), `{"totalEntries":0}`, time.Hour)
+
+	body := `{"title":"Porto","tags":[{"key":"trip"}],"visibility":"private"}`
+	if w := callAs(alice, entries.CreateEntry, http.MethodPost, "/create-entry", body); w.Code != http.StatusCreated {
+		t.Fatalf("CreateEntry: %d %s", w.Code, w.Body.String())
+	}
+
+	if n := totalEntries(); n != 1 {
+		t.Errorf("totalEntries after = %d, want 1", n)
+	}
+	if v, _ := store.Get(ctx, accountDetailsKey(alice)); v != "" {
+		t.Errorf("account details still cached: %s", v)
+	}
+	totals, err := loadAccountTotals(ctx, pool, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if totals.entries != 1 || totals.tags != 1 {
+		t.Errorf("totals = %+v, want 1 entry and 1 tag", totals)
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"io.winapps.journeyapp/internal/apierror"
+	entitlementsmodels "io.winapps.journeyapp/internal/models/get_entitlements"
+	"io.winapps.journeyapp/internal/premium"
+)
+
+// GetEntitlements returns the authenticated user's plan limits and current usage so the
+// client can show remaining quota and upgrade prompts
+func (h *AuthHandler) GetEntitlements(c *gin.Context) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ctx := c.Request.Context()
+
+	policy, err := premium.ForUser(ctx, h.postgres, userUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "User not found")
+			return
+		}
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "plan lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load entitlements")
+		return
+	}
+
+	var expiresAt *time.Time
+	var entryCount int
+	err = h.postgres.QueryRow(ctx, `
+		SELECT u.premium_expires_at, (SELECT COUNT(*) FROM entries e WHERE e.user_uid = u.uid)
+		FROM users u WHERE u.uid = $1
+	`, userUID).Scan(&expiresAt, &entryCount)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "entitlement usage lookup failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load entitlements")
+		return
+	}
+
+	resp := entitlementsmodels.GetEntitlementsResponse{
+		Tier:      policy.Tier,
+		IsPremium: policy.Tier == premium.TierPremium,
+		Limits:    policy.Limits,
+		Usage:     entitlementsmodels.Usage{Entries: entryCount},
+	}
+	if resp.IsPremium {
+		resp.PremiumExpiresAt = expiresAt
+	}
+	if limit := policy.Limits.MaxEntries; limit > 0 {
+		remaining := limit - entryCount
+		if remaining < 0 {
+			remaining = 0
+		}
+		resp.Usage.EntriesRemaining = &remaining
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	models "io.winapps.journeyapp/internal/models/account"
+	getentriesmodels "io.winapps.journeyapp/internal/models/get_entries"
+	getentrymodels "io.winapps.journeyapp/internal/models/get_entry"
+	searchmodels "io.winapps.journeyapp/internal/models/search_entries"
+)
+
+const maxGetEntriesBatch = 100
+
+// GetEntries returns several of the user's own entries in one request. Ownership is
+// checked for every id in a single query; entries cached by GetEntry are served from the
+// cache and the rest are hydrated together.
+func (h *EntryHandler) GetEntries(c *gin.Context) {
+	var req getentriesmodels.GetEntriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	// Dedupe while keeping the requested order
+	ids := make([]string, 0, len(req.EntryIDs))
+	seen := make(map[string]bool, len(req.EntryIDs))
+	for _, id := range req.EntryIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "At least one entry ID is required")
+		return
+	}
+	if len(ids) > maxGetEntriesBatch {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, fmt.Sprintf("At most %d entry IDs may be requested at once", maxGetEntriesBatch))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// Comparing as text keeps malformed ids from failing the whole query
+	rows, err := h.postgres.Query(ctx, `
+		SELECT id::text, title, COALESCE(description, ''), encrypted, visibility, status, publish_at, created_at, updated_at
+		FROM entries
+		WHERE id::text = ANY($1) AND user_uid = $2
+	`, ids, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "get entries failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch entries")
+		return
+	}
+	cipher := newEntryCipher(h.postgres, h.entryKeys)
+	owned := make(map[string]*searchmodels.EntryResult, len(ids))
+	for rows.Next() {
+		var entry searchmodels.EntryResult
+		if err := rows.Scan(&entry.ID, &entry.Title, &entry.Description, &entry.Encrypted, &entry.Visibility, &entry.Status, &entry.PublishAt, &entry.CreatedAt, &entry.UpdatedAt); err != nil {
+			rows.Close()
+			h.logError(c, err, "scan entry failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch entries")
+			return
+		}
+		owned[entry.ID] = &entry
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "get entries failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch entries")
+		return
+	}
+
+	// Serve what GetEntry has cached; hydrate the misses in one pass
+	cached := make(map[string]getentrymodels.GetEntryResponse)
+	missIDs := []string{}
+	missMap := make(map[string]*searchmodels.EntryResult)
+	for _, id := range ids {
+		entry, ok := owned[id]
+		if !ok {
+			continue
+		}
+		if value, err := h.cache.Get(ctx, fmt.Sprintf("entry:%s", id)); err == nil && value != "" {
+			var hit getentrymodels.GetEntryResponse
+			if err := json.Unmarshal([]byte(value), &hit); err == nil {
+				cached[id] = hit
+				continue
+			}
+		}
+		if err := cipher.open(ctx, userUID, entry.Encrypted, &entry.Title, &entry.Description); err != nil {
+			h.logError(c, err, "decrypt entry failed", "entryId", id)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch entries")
+			return
+		}
+		entry.Images = []string{}
+		entry.Audio = []string{}
+		entry.Videos = []models.Video{}
+		entry.Tags = []models.Tag{}
+		entry.Locations = []models.Location{}
+		missIDs = append(missIDs, id)
+		missMap[id] = entry
+	}
+
+	sharedWith := make(map[string][]string)
+	if len(missIDs) > 0 {
+		if err := h.fetchRelatedDataForEntries(ctx, missIDs, missMap); err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "fetch entry details failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch entries")
+			return
+		}
+
+		shareRows, err := h.postgres.Query(ctx, `
+			SELECT entry_id::text, shared_user_uid FROM entry_shares
+			WHERE entry_id::text = ANY($1)
+			ORDER BY created_at
+		`, missIDs)
+		if err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "fetch entry shares failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch entries")
+			return
+		}
+		for shareRows.Next() {
+			var entryID, sharedUID string
+			if err := shareRows.Scan(&entryID, &sharedUID); err != nil {
+				shareRows.Close()
+				h.logError(c, err, "scan entry share failed")
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch entries")
+				return
+			}
+			sharedWith[entryID] = append(sharedWith[entryID], sharedUID)
+		}
+		shareRows.Close()
+		if err := shareRows.Err(); err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "fetch entry shares failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch entries")
+			return
+		}
+	}
+
+	resp := getentriesmodels.GetEntriesResponse{
+		Entries:  make([]getentrymodels.GetEntryResponse, 0, len(owned)),
+		NotFound: []string{},
+	}
+	for _, id := range ids {
+		if hit, ok := cached[id]; ok {
+			resp.Entries = append(resp.Entries, hit)
+			continue
+		}
+		e, ok := missMap[id]
+		if !ok {
+			resp.NotFound = append(resp.NotFound, id)
+			continue
+		}
+		shares := sharedWith[id]
+		if shares == nil {
+			shares = []string{}
+		}
+		entry := getentrymodels.GetEntryResponse{
+			ID:          e.ID,
+			OwnerUID:    userUID,
+			Title:       e.Title,
+			Description: e.Description,
+			Images:      e.Images,
+			Audio:       e.Audio,
+			Videos:      e.Videos,
+			Tags:        e.Tags,
+			Locations:   e.Locations,
+			Visibility:  e.Visibility,
+			SharedWith:  shares,
+			Status:      e.Status,
+			PublishAt:   e.PublishAt,
+			Encrypted:   e.Encrypted,
+			CreatedAt:   e.CreatedAt,
+			UpdatedAt:   e.UpdatedAt,
+		}
+		resp.Entries = append(resp.Entries, entry)
+
+		// Same rule as GetEntry: unpublished and encrypted entries aren't cached
+		if entry.Status == entryStatusPublished && !entry.Encrypted {
+			if data, err := json.Marshal(entry); err == nil {
+				_ = h.cache.Set(ctx, fmt.Sprintf("entry:%s", id), data, 24*time.Hour)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
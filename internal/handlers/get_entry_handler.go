@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"io.winapps.journeyapp/internal/apierror"
 	models "io.winapps.journeyapp/internal/models/account"
 	getentrymodels "io.winapps.journeyapp/internal/models/get_entry"
 )
@@ -18,60 +20,106 @@ import (
 func (h *EntryHandler) GetEntry(c *gin.Context) {
 	var req getentrymodels.GetEntryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Validate required fields
 	if req.EntryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Entry ID is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
-	// Check Redis cache first
-	redisKey := fmt.Sprintf("entry:%s", req.EntryID)
-	cachedEntry, err := h.redis.Get(ctx, redisKey).Result()
-	if err == nil && cachedEntry != "" {
-		var entry getentrymodels.GetEntryResponse
-		if err := json.Unmarshal([]byte(cachedEntry), &entry); err == nil {
-			c.JSON(http.StatusOK, entry)
+	// Check access before the cache, which holds the owner's view of every entry
+	ownerUID, err := h.entryOwnerIfVisible(ctx, req.EntryID, userUID)
+	if err != nil {
+		if errors.Is(err, errEntryNotAccessible) {
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+			return
+		}
+		if abortOnContextError(c, err) {
 			return
 		}
+		h.logError(c, err, "check entry access failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch entry")
+		return
 	}
 
-	// Fetch entry from database
-	entry, err := h.fetchEntryWithDetails(ctx, req.EntryID, userUID)
+	entry, err := h.entryForViewer(ctx, req.EntryID, ownerUID, userUID)
 	if err != nil {
 		if err.Error() == "entry not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found or access denied"})
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+			return
+		}
+		if abortOnContextError(c, err) {
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch entry"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch entry")
 		return
 	}
 
-	// Cache the entry in Redis
-	entryJSON, err := json.Marshal(entry)
-	if err == nil {
-		h.redis.Set(ctx, redisKey, entryJSON, 24*time.Hour)
+	respondJSONWithETag(c, entry)
+}
+
+// entryForViewer returns an entry viewerUID has been cleared to see by entryOwnerIfVisible,
+// from the Redis cache when it's there. Viewers other than the owner get the read-only view.
+func (h *EntryHandler) entryForViewer(ctx context.Context, entryID, ownerUID, viewerUID string) (*getentrymodels.GetEntryResponse, error) {
+	var entry *getentrymodels.GetEntryResponse
+
+	// Check Redis cache first
+	redisKey := fmt.Sprintf("entry:%s", entryID)
+	if cachedEntry, err := h.cache.Get(ctx, redisKey); err == nil && cachedEntry != "" {
+		var hit getentrymodels.GetEntryResponse
+		if err := json.Unmarshal([]byte(cachedEntry), &hit); err == nil {
+			hit.OwnerUID = ownerUID
+			entry = &hit
+		}
 	}
 
-	c.JSON(http.StatusOK, entry)
+	if entry == nil {
+		// Fetch entry from database
+		fetched, err := h.fetchEntryWithDetails(ctx, entryID, viewerUID)
+		if err != nil {
+			return nil, err
+		}
+		entry = fetched
+
+		// Cache the entry in Redis; unpublished entries aren't cached, nor are encrypted
+		// ones, which would sit there decrypted
+		if entry.Status == entryStatusPublished && !entry.Encrypted {
+			entryJSON, err := json.Marshal(entry)
+			if err == nil {
+				h.cache.Set(ctx, redisKey, entryJSON, 24*time.Hour)
+			}
+		}
+	}
+
+	if viewerUID != ownerUID {
+		readOnlyEntryView(entry)
+	}
+	return entry, nil
+}
+
+// readOnlyEntryView turns the owner's view of an entry into what a public or shared
+// viewer sees: marked read-only and without the list of other users it's shared with
+func readOnlyEntryView(entry *getentrymodels.GetEntryResponse) {
+	entry.ReadOnly = true
+	entry.SharedWith = []string{}
 }
 
 // fetchEntryWithDetails retrieves an entry with all its related data
@@ -80,8 +128,9 @@ func (h *EntryHandler) fetchEntryWithDetails(ctx context.Context, entryID, userU
 	var entry getentrymodels.GetEntryResponse
 	var ownerUID string
 	var visibility string
+	var hidden bool
 	entryQuery := `
-		SELECT id, title, description, visibility, user_uid, created_at, updated_at
+		SELECT id, title, description, encrypted, visibility, user_uid, status, moderation_hidden, publish_at, created_at, updated_at
 		FROM entries
 		WHERE id = $1
 	`
@@ -89,8 +138,12 @@ func (h *EntryHandler) fetchEntryWithDetails(ctx context.Context, entryID, userU
 		&entry.ID,
 		&entry.Title,
 		&entry.Description,
+		&entry.Encrypted,
 		&visibility,
 		&ownerUID,
+		&entry.Status,
+		&hidden,
+		&entry.PublishAt,
 		&entry.CreatedAt,
 		&entry.UpdatedAt,
 	)
@@ -99,6 +152,12 @@ func (h *EntryHandler) fetchEntryWithDetails(ctx context.Context, entryID, userU
 	}
 
 	entry.Visibility = visibility
+	entry.OwnerUID = ownerUID
+
+	// Drafts, scheduled entries and entries hidden by moderation are visible only to their owner
+	if (entry.Status != entryStatusPublished || hidden) && userUID != ownerUID {
+		return nil, fmt.Errorf("entry not found")
+	}
 
 	// Enforce access rules
 	v := strings.ToLower(strings.TrimSpace(visibility))
@@ -120,8 +179,15 @@ func (h *EntryHandler) fetchEntryWithDetails(ctx context.Context, entryID, userU
 		return nil, fmt.Errorf("entry not found")
 	}
 
+	if err := newEntryCipher(h.postgres, h.entryKeys).open(ctx, ownerUID, entry.Encrypted, &entry.Title, &entry.Description); err != nil {
+		return nil, fmt.Errorf("failed to decrypt entry: %w", err)
+	}
+
 	// Initialize slices
+	entry.SharedWith = []string{}
 	entry.Images = []string{}
+	entry.Videos = []models.Video{}
+	entry.Attachments = []models.Attachment{}
 	entry.Tags = []models.Tag{}
 	entry.Locations = []models.Location{}
 
@@ -208,5 +274,68 @@ func (h *EntryHandler) fetchEntryWithDetails(ctx context.Context, entryID, userU
 		entry.Audio = append(entry.Audio, audioURL)
 	}
 
+	// Fetch videos
+	videoQuery := `
+		SELECT url, COALESCE(thumbnail_url, ''), COALESCE(duration, 0) FROM videos WHERE entry_id = $1 ORDER BY upload_order
+	`
+	videoRows, err := h.postgres.Query(ctx, videoQuery, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch videos: %w", err)
+	}
+	defer videoRows.Close()
+
+	for videoRows.Next() {
+		var video models.Video
+		if err := videoRows.Scan(&video.URL, &video.ThumbnailURL, &video.Duration); err != nil {
+			return nil, fmt.Errorf("failed to scan video: %w", err)
+		}
+		entry.Videos = append(entry.Videos, video)
+	}
+
+	// Fetch attachments
+	attachmentRows, err := h.postgres.Query(ctx, `
+		SELECT url, filename, mime_type, file_size FROM attachments WHERE entry_id = $1 ORDER BY upload_order
+	`, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attachments: %w", err)
+	}
+	defer attachmentRows.Close()
+
+	for attachmentRows.Next() {
+		var attachment models.Attachment
+		if err := attachmentRows.Scan(&attachment.URL, &attachment.Filename, &attachment.MimeType, &attachment.FileSize); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		entry.Attachments = append(entry.Attachments, attachment)
+	}
+
+	// Fetch shared users
+	sharedWith, err := h.entrySharedWith(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+	entry.SharedWith = sharedWith
+
 	return &entry, nil
-}
\ No newline at end of file
+}
+
+// entrySharedWith returns the users an entry is shared with, in the order they were added
+func (h *EntryHandler) entrySharedWith(ctx context.Context, entryID string) ([]string, error) {
+	rows, err := h.postgres.Query(ctx, `
+		SELECT shared_user_uid FROM entry_shares WHERE entry_id = $1 ORDER BY created_at
+	`, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch shares: %w", err)
+	}
+	defer rows.Close()
+
+	sharedWith := []string{}
+	for rows.Next() {
+		var sharedUID string
+		if err := rows.Scan(&sharedUID); err != nil {
+			return nil, fmt.Errorf("failed to scan share: %w", err)
+		}
+		sharedWith = append(sharedWith, sharedUID)
+	}
+	return sharedWith, rows.Err()
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"io.winapps.journeyapp/internal/cache"
+	getentrymodels "io.winapps.journeyapp/internal/models/get_entry"
+)
+
+func TestReadOnlyEntryView(t *testing.T) {
+	entry := &getentrymodels.GetEntryResponse{
+		ID:         "e1",
+		OwnerUID:   "alice",
+		Title:      "Trip",
+		Visibility: "semi-private",
+		SharedWith: []string{"bob", "carol"},
+	}
+	readOnlyEntryView(entry)
+
+	if !entry.ReadOnly {
+		t.Error("ReadOnly = false, want true")
+	}
+	if entry.OwnerUID != "alice" || entry.Title != "Trip" {
+		t.Errorf("entry content changed: %+v", entry)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Viewers get an empty list rather than null, and never the other recipients
+	if !strings.Contains(string(data), `"sharedWith":[]`) || strings.Contains(string(data), "carol") {
+		t.Errorf("read-only view leaks the share list: %s", data)
+	}
+}
+
+// GetEntry runs on the in-memory backend when Redis is unavailable: the first read
+// fills the cache, later reads are served from it, and the access check still runs
+// before a cached entry is returned
+func TestGetEntryWithMemoryCache(t *testing.T) {
+	pool := testPool(t)
+	store := cache.NewMemory(100)
+	h := NewEntryHandler(nil, pool, store, nil)
+	alice := testUser(t, pool, "alice")
+	mallory := testUser(t, pool, "mallory")
+	entryID := testEntry(t, pool, alice, "Lisbon", "private")
+	body := `{"entryId":"` + entryID + `"}`
+
+	get := func(uid string) (int, getentrymodels.GetEntryResponse) {
+		w := callAs(uid, h.GetEntry, http.MethodPost, "/get-entry", body)
+		var got getentrymodels.GetEntryResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &got)
+		return w.Code, got
+	}
+
+	if code, got := get(alice); code != http.StatusOK || got.Title != "Lisbon" {
+		t.Fatalf("first read = %d %+v", code, got)
+	}
+	if ok, _ := store.Exists(context.Background(), "entry:"+entryID); !ok {
+		t.Fatal("entry was not cached in the memory store")
+	}
+
+	// A change made behind the handler's back is not seen while the cached copy lives
+	mustExec(t, pool, `UPDATE entries SET title = 'Porto' WHERE id = $1`, entryID)
+	if code, got := get(alice); code != http.StatusOK || got.Title != "Lisbon" {
+		t.Fatalf("cached read = %d %+v, want the cached title", code, got)
+	}
+
+	if code, _ := get(mallory); code != http.StatusNotFound {
+		t.Fatalf("other user read a cached private entry: %d", code)
+	}
+}
+
+func TestEntrySharedWith(t *testing.T) {
+	pool := testPool(t)
+	h := NewEntryHandler(nil, pool, cache.NewMemory(100), nil)
+	alice := testUser(t, pool, "alice")
+	bob := testUser(t, pool, "bob")
+	carol := testUser(t, pool, "carol")
+	entryID := testEntry(t, pool, alice, "Lisbon", "semi-private")
+	ctx := context.Background()
+
+	if got, err := h.entrySharedWith(ctx, entryID); err != nil || got == nil || len(got) != 0 {
+		t.Fatalf("unshared entry = %#v, %v; want an empty list", got, err)
+	}
+	mustExec(t, pool, `INSERT INTO entry_shares (entry_id, shared_user_uid, created_at) VALUES ($1, $2, NOW() - INTERVAL '1 minute'), ($1, $3, NOW())`, entryID, carol, bob)
+	got, err := h.entrySharedWith(ctx, entryID)
+	if err != nil || len(got) != 2 || got[0] != carol || got[1] != bob {
+		t.Fatalf("entrySharedWith = %v, %v; want [%s %s] in the order shared", got, err, carol, bob)
+	}
+}
+
+// The owner gets the editable entry with its share list, a user it is shared with gets the
+// read-only view, and anyone else gets 404 until the entry is public
+func TestGetEntryAccess(t *testing.T) {
+	pool := testPool(t)
+	h := NewEntryHandler(nil, pool, cache.NewMemory(100), nil)
+	alice := testUser(t, pool, "alice")
+	bob := testUser(t, pool, "bob")
+	carol := testUser(t, pool, "carol")
+	testFriends(t, pool, alice, carol)
+	entryID := testEntry(t, pool, alice, "Lisbon", "semi-private")
+	mustExec(t, pool, `INSERT INTO entry_shares (entry_id, shared_user_uid) VALUES ($1, $2)`, entryID, bob)
+	body := `{"entryId":"` + entryID + `"}`
+
+	get := func(uid string) (int, getentrymodels.GetEntryResponse) {
+		t.Helper()
+		w := callAs(uid, h.GetEntry, http.MethodPost, "/get-entry", body)
+		var got getentrymodels.GetEntryResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &got)
+		return w.Code, got
+	}
+
+	code, got := get(alice)
+	if code != http.StatusOK || got.ReadOnly || len(got.SharedWith) != 1 || got.SharedWith[0] != bob {
+		t.Errorf("owner = %d %+v, want an editable entry shared with %s", code, got, bob)
+	}
+
+	code, got = get(bob)
+	if code != http.StatusOK || !got.ReadOnly || got.Title != "Lisbon" || got.OwnerUID != alice || len(got.SharedWith) != 0 {
+		t.Errorf("shared viewer = %d %+v, want a read-only view without the share list", code, got)
+	}
+
+	// Being the owner's friend isn't enough without a share
+	if code, _ := get(carol); code != http.StatusNotFound {
+		t.Errorf("unauthorized viewer = %d, want 404", code)
+	}
+
+	mustExec(t, pool, `UPDATE entries SET visibility = 'public' WHERE id = $1`, entryID)
+	if code, got := get(carol); code != http.StatusOK || !got.ReadOnly {
+		t.Errorf("public entry for another user = %d %+v, want a read-only view", code, got)
+	}
+}
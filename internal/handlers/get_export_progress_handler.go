@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
 )
 
 // ExportProgress returns the status/progress for the provided exportJobId
@@ -13,29 +15,29 @@ import (
 func (h *AuthHandler) ExportProgress(c *gin.Context) {
 	uuidCtx, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 	authUID, ok := uuidCtx.(string)
 	if !ok || authUID == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	jobID := c.Query("exportJobId")
 	if jobID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required query parameter: exportJobId"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Missing required query parameter: exportJobId")
 		return
 	}
 
 	ctx := context.Background()
 	st, err := h.loadExportStatus(ctx, jobID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Export job not found"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Export job not found")
 		return
 	}
 	if st.UID != authUID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot view another user's export job"})
+		respondError(c, http.StatusForbidden, apierror.CodeForbidden, "Cannot view another user's export job")
 		return
 	}
 
@@ -50,9 +52,11 @@ func (h *AuthHandler) ExportProgress(c *gin.Context) {
 		"startedAt":   st.StartedAt.Format(time.RFC3339),
 		"completedAt": nil,
 		"totals": gin.H{
-			"entries": st.TotalEntries,
-			"images":  st.TotalImages,
-			"audio":   st.TotalAudio,
+			"entries":     st.TotalEntries,
+			"images":      st.TotalImages,
+			"audio":       st.TotalAudio,
+			"videos":      st.TotalVideos,
+			"attachments": st.TotalAttachments,
 		},
 	}
 	if st.CompletedAt != nil {
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+)
+
+// ImportProgress returns the status/progress for the provided importJobId
+// Query params: importJobId (required)
+func (h *AuthHandler) ImportProgress(c *gin.Context) {
+	uuidCtx, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	authUID, ok := uuidCtx.(string)
+	if !ok || authUID == "" {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
+		return
+	}
+
+	jobID := c.Query("importJobId")
+	if jobID == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Missing required query parameter: importJobId")
+		return
+	}
+
+	ctx := context.Background()
+	st, err := h.loadImportStatus(ctx, jobID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Import job not found")
+		return
+	}
+	if st.UID != authUID {
+		respondError(c, http.StatusForbidden, apierror.CodeForbidden, "Cannot view another user's import job")
+		return
+	}
+
+	resp := gin.H{
+		"importJobId": st.JobID,
+		"status":      st.Status,
+		"format":      st.Format,
+		"progress":    st.Progress,
+		"startedAt":   st.StartedAt.Format(time.RFC3339),
+		"completedAt": nil,
+		"totals": gin.H{
+			"entries":      st.TotalEntries,
+			"imported":     st.ImportedEntries,
+			"skipped":      st.SkippedEntries,
+			"failed":       st.FailedEntries,
+			"images":       st.ImportedImages,
+			"audio":        st.ImportedAudio,
+			"videos":       st.ImportedVideos,
+			"missingMedia": st.MissingMedia,
+		},
+	}
+	if st.CompletedAt != nil {
+		resp["completedAt"] = st.CompletedAt.Format(time.RFC3339)
+	}
+	if st.Error != "" {
+		resp["error"] = st.Error
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
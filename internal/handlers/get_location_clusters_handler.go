@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	clustermodels "io.winapps.journeyapp/internal/models/get_location_clusters"
+)
+
+const (
+	// clusterCellsPerTile is how many grid cells span one map tile at any zoom level
+	clusterCellsPerTile = 8
+	maxClusterZoom      = 22
+	maxClusters         = 500
+	// Clusters aren't invalidated when locations change, so keep them short-lived
+	locationClustersCacheTTL = time.Minute
+)
+
+// GetLocationClusters returns the authenticated user's entry locations inside a bounding
+// box, snapped to a grid whose cell size shrinks as the map zooms in
+func (h *EntryHandler) GetLocationClusters(c *gin.Context) {
+	var req clustermodels.GetLocationClustersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	// Get UID from context (set by auth middleware)
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+
+	userUID, ok := uid.(string)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
+		return
+	}
+
+	if req.MinLatitude < -90 || req.MaxLatitude > 90 || req.MinLatitude >= req.MaxLatitude {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Latitude bounds must satisfy -90 <= minLatitude < maxLatitude <= 90")
+		return
+	}
+	if req.MinLongitude < -180 || req.MinLongitude > 180 || req.MaxLongitude < -180 || req.MaxLongitude > 180 || req.MinLongitude == req.MaxLongitude {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Longitude bounds must be distinct values between -180 and 180")
+		return
+	}
+	if req.Zoom < 0 || req.Zoom > maxClusterZoom {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, fmt.Sprintf("Zoom must be between 0 and %d", maxClusterZoom))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	cacheKey := fmt.Sprintf("location_clusters:%s:%g,%g,%g,%g:%d",
+		userUID, req.MinLatitude, req.MinLongitude, req.MaxLatitude, req.MaxLongitude, req.Zoom)
+	if cached, err := h.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+		var response clustermodels.GetLocationClustersResponse
+		if err := json.Unmarshal([]byte(cached), &response); err == nil {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+	}
+
+	cellSize := 360 / (clusterCellsPerTile * math.Pow(2, float64(req.Zoom)))
+	clusters, err := h.fetchLocationClusters(ctx, userUID, req, cellSize)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "Failed to cluster locations")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch location clusters")
+		return
+	}
+
+	response := clustermodels.GetLocationClustersResponse{
+		Clusters: clusters,
+		Zoom:     req.Zoom,
+		CellSize: cellSize,
+	}
+
+	if data, err := json.Marshal(response); err == nil {
+		_ = h.cache.Set(ctx, cacheKey, data, locationClustersCacheTTL)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// fetchLocationClusters groups locations by rounding their coordinates down to a
+// cellSize grid. Locations without coordinates are skipped.
+func (h *EntryHandler) fetchLocationClusters(ctx context.Context, userUID string, req clustermodels.GetLocationClustersRequest, cellSize float64) ([]clustermodels.LocationCluster, error) {
+	query := fmt.Sprintf(`
+		WITH points AS (
+			SELECT l.latitude::float8 AS lat, l.longitude::float8 AS lng,
+				NULLIF(l.display_name, '') AS display_name, l.entry_id
+			FROM locations l
+			INNER JOIN entries e ON l.entry_id = e.id
+			WHERE e.user_uid = $1
+				AND l.latitude IS NOT NULL AND l.longitude IS NOT NULL
+		)
+		SELECT AVG(lat), AVG(lng), MIN(lat), MIN(lng), MAX(lat), MAX(lng),
+			COUNT(*), COUNT(DISTINCT entry_id),
+			COALESCE(mode() WITHIN GROUP (ORDER BY display_name), '')
+		FROM points
+		WHERE lat BETWEEN $2::float8 AND $3::float8
+			AND (CASE WHEN $4::float8 <= $5::float8
+				THEN lng BETWEEN $4::float8 AND $5::float8
+				ELSE lng >= $4::float8 OR lng <= $5::float8 END)
+		GROUP BY floor(lat / $6::float8), floor(lng / $6::float8)
+		ORDER BY COUNT(*) DESC
+		LIMIT %d
+	`, maxClusters)
+
+	rows, err := h.postgres.Query(ctx, query, userUID,
+		req.MinLatitude, req.MaxLatitude, req.MinLongitude, req.MaxLongitude, cellSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clusters := []clustermodels.LocationCluster{}
+	for rows.Next() {
+		var cluster clustermodels.LocationCluster
+		if err := rows.Scan(
+			&cluster.Latitude,
+			&cluster.Longitude,
+			&cluster.MinLatitude,
+			&cluster.MinLongitude,
+			&cluster.MaxLatitude,
+			&cluster.MaxLongitude,
+			&cluster.Count,
+			&cluster.EntryCount,
+			&cluster.DisplayName,
+		); err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, rows.Err()
+}
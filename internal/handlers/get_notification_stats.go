@@ -7,13 +7,15 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
 )
 
 // GetNotificationStats returns notification statistics
 func (ns *NotificationsHandler) GetNotificationStats(c *gin.Context) {
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
@@ -28,15 +30,14 @@ func (ns *NotificationsHandler) GetNotificationStats(c *gin.Context) {
 	for i := 0; i < 7; i++ {
 		date := weekAgo.AddDate(0, 0, i)
 		key := fmt.Sprintf("notification_sent:%s:%s", userID, date.Format("2006-01-02"))
-		exists := ns.redisClient.Exists(ctx, key).Val()
-		if exists > 0 {
+		if exists, _ := ns.cache.Exists(ctx, key); exists {
 			dailyPromptCount++
 		}
 	}
 
 	// Count message notifications (approximate from Redis keys)
 	pattern := fmt.Sprintf("message_notification:%s:*", userID)
-	messageKeys := ns.redisClient.Keys(ctx, pattern).Val()
+	messageKeys, _ := ns.cache.Keys(ctx, pattern)
 	messageCount := len(messageKeys)
 
 	// Determine current push token active status
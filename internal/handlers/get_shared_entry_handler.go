@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	getsharedentrymodels "io.winapps.journeyapp/internal/models/get_shared_entry"
+)
+
+// GetFriendEntry opens an entry someone else wrote, such as one picked from the feed: any
+// published public entry, or a semi-private one shared with the user. The response is the
+// read-only view GetEntry gives non-owners plus the owner's display name and photo.
+// Private entries and entries not shared with the user are a 404, like missing ones.
+func (h *EntryHandler) GetFriendEntry(c *gin.Context) {
+	var req getsharedentrymodels.GetSharedEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ctx := c.Request.Context()
+
+	ownerUID, err := h.entryOwnerIfVisible(ctx, req.EntryID, userUID)
+	if err != nil {
+		if errors.Is(err, errEntryNotAccessible) {
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+			return
+		}
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "check entry access failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch entry")
+		return
+	}
+
+	entry, err := h.entryForViewer(ctx, req.EntryID, ownerUID, userUID)
+	if err != nil {
+		if err.Error() == "entry not found" {
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+			return
+		}
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "fetch shared entry failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch entry")
+		return
+	}
+
+	owner := getsharedentrymodels.EntryOwner{UID: ownerUID}
+	err = h.postgres.QueryRow(ctx, `
+		SELECT COALESCE(display_name, ''), COALESCE(photo_url, '') FROM users WHERE uid = $1
+	`, ownerUID).Scan(&owner.DisplayName, &owner.PhotoURL)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "fetch entry owner failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch entry")
+		return
+	}
+
+	respondJSONWithETag(c, getsharedentrymodels.GetSharedEntryResponse{
+		GetEntryResponse: entry,
+		Owner:            owner,
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	tagvaluesmodels "io.winapps.journeyapp/internal/models/get_tag_values"
+)
+
+const (
+	maxTagValues = 50
+	// Tag edits don't invalidate the list, so keep it short-lived
+	tagValuesCacheTTL = time.Minute
+)
+
+// GetTagValues returns the distinct values the authenticated user has used with a tag key,
+// most frequent first, so the app can autocomplete the value once a key is chosen
+func (h *EntryHandler) GetTagValues(c *gin.Context) {
+	var req tagvaluesmodels.GetTagValuesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	key := strings.TrimSpace(req.Key)
+	if key == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Tag key is required")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	cacheKey := fmt.Sprintf("tag_values:%s:%s", userUID, key)
+	if cached, err := h.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+		var response tagvaluesmodels.GetTagValuesResponse
+		if err := json.Unmarshal([]byte(cached), &response); err == nil {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+	}
+
+	values, err := h.fetchTagValues(ctx, userUID, key)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "fetch tag values failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch tag values")
+		return
+	}
+
+	response := tagvaluesmodels.GetTagValuesResponse{
+		Key:    key,
+		Values: values,
+	}
+
+	if data, err := json.Marshal(response); err == nil {
+		_ = h.cache.Set(ctx, cacheKey, data, tagValuesCacheTTL)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// fetchTagValues counts how often each value appears with key across the user's entries
+func (h *EntryHandler) fetchTagValues(ctx context.Context, userUID, key string) ([]tagvaluesmodels.TagValue, error) {
+	query := fmt.Sprintf(`
+		SELECT t.value, COUNT(*) AS uses
+		FROM tags t
+		INNER JOIN entries e ON t.entry_id = e.id
+		WHERE e.user_uid = $1 AND t.key = $2 AND t.value <> ''
+		GROUP BY t.value
+		ORDER BY uses DESC, MAX(t.created_at) DESC
+		LIMIT %d
+	`, maxTagValues)
+
+	rows, err := h.postgres.Query(ctx, query, userUID, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []tagvaluesmodels.TagValue{}
+	for rows.Next() {
+		var v tagvaluesmodels.TagValue
+		if err := rows.Scan(&v.Value, &v.Count); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
@@ -2,72 +2,169 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
-	models "io.winapps.journeyapp/internal/models/account"
+	"io.winapps.journeyapp/internal/apierror"
 	uniquelocationsmodels "io.winapps.journeyapp/internal/models/get_unique_locations"
 )
 
-// GetUniqueLocations handles fetching all unique locations for the authenticated user
+const (
+	defaultUniqueLocationsLimit = 100
+	maxUniqueLocationsLimit     = 500
+	uniqueLocationsCacheTTL     = 10 * time.Minute
+)
+
+// uniqueLocationsFilter selects and orders one page of a user's distinct locations
+type uniqueLocationsFilter struct {
+	query  string // substring of display_name, city or country; empty matches everything
+	byName bool   // alphabetical instead of most-visited first
+	limit  int
+	offset int
+}
+
+// GetUniqueLocations handles fetching the unique locations for the authenticated user, one
+// page at a time. Query parameters: limit (default 100, max 500), offset, q to filter on
+// display name, city or country, and sort=name for alphabetical order instead of most-visited first.
 func (h *EntryHandler) GetUniqueLocations(c *gin.Context) {
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
-	ctx := context.Background()
+	filter := uniqueLocationsFilter{
+		query:  strings.TrimSpace(c.Query("q")),
+		byName: c.Query("sort") == "name",
+		limit:  defaultUniqueLocationsLimit,
+	}
+	if v := c.Query("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l < 1 || l > maxUniqueLocationsLimit {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, fmt.Sprintf("limit must be between 1 and %d", maxUniqueLocationsLimit))
+			return
+		}
+		filter.limit = l
+	}
+	if v := c.Query("offset"); v != "" {
+		o, err := strconv.Atoi(v)
+		if err != nil || o < 0 {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "offset must be a non-negative integer")
+			return
+		}
+		filter.offset = o
+	}
+
+	ctx := c.Request.Context()
+
+	cacheKey := fmt.Sprintf("unique_locations:%s:%t:%d:%d:%s", userUID, filter.byName, filter.limit, filter.offset, strings.ToLower(filter.query))
+	if cached, err := h.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+		var response uniquelocationsmodels.GetUniqueLocationsResponse
+		if err := json.Unmarshal([]byte(cached), &response); err == nil {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+	}
 
 	// Fetch unique locations from database
-	locations, err := h.fetchUniqueLocations(ctx, userUID)
+	locations, total, err := h.fetchUniqueLocations(ctx, userUID, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch unique locations"})
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch unique locations")
 		return
 	}
 
 	response := uniquelocationsmodels.GetUniqueLocationsResponse{
 		Locations: locations,
+		Total:     total,
+		Limit:     filter.limit,
+		Offset:    filter.offset,
+		HasMore:   filter.offset+len(locations) < total,
+	}
+
+	if data, err := json.Marshal(response); err == nil {
+		_ = h.cache.Set(ctx, cacheKey, data, uniqueLocationsCacheTTL)
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-// fetchUniqueLocations retrieves all unique locations for a user
-func (h *EntryHandler) fetchUniqueLocations(ctx context.Context, userUID string) ([]models.Location, error) {
-	// Query to get unique locations based on a combination of fields
+// fetchUniqueLocations retrieves one page of unique locations for a user and the total
+// number of unique locations matching the filter
+func (h *EntryHandler) fetchUniqueLocations(ctx context.Context, userUID string, filter uniqueLocationsFilter) ([]uniquelocationsmodels.UniqueLocation, int, error) {
 	// We'll use display_name as the primary uniqueness criteria, but fall back to coordinates if display_name is empty
-	query := `
-		SELECT DISTINCT ON (
-			COALESCE(NULLIF(l.display_name, ''), l.latitude::text || ',' || l.longitude::text)
+	matched := `
+		WITH matched AS (
+			SELECT l.*, COALESCE(NULLIF(l.display_name, ''), COALESCE(l.latitude::text, '') || ',' || COALESCE(l.longitude::text, '')) AS loc_key
+			FROM locations l
+			INNER JOIN entries e ON l.entry_id = e.id
+			WHERE e.user_uid = $1
+				AND ($2 = '' OR l.display_name ILIKE $2 OR l.city ILIKE $2 OR l.country ILIKE $2)
+		)`
+
+	pattern := ""
+	if filter.query != "" {
+		pattern = "%" + likeEscaper.Replace(filter.query) + "%"
+	}
+
+	var total int
+	if err := h.postgres.QueryRow(ctx, matched+` SELECT COUNT(DISTINCT loc_key) FROM matched`, userUID, pattern).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	orderBy := "counts.entry_count DESC, latest.loc_key"
+	if filter.byName {
+		orderBy = "latest.loc_key"
+	}
+	query := matched + fmt.Sprintf(`,
+		latest AS (
+			SELECT DISTINCT ON (loc_key)
+				loc_key, latitude, longitude, address, city, state, zip, country, country_code, display_name
+			FROM matched
+			ORDER BY loc_key, created_at DESC
+		),
+		counts AS (
+			SELECT loc_key, COUNT(DISTINCT entry_id) AS entry_count FROM matched GROUP BY loc_key
 		)
-		l.latitude, l.longitude, l.address, l.city, l.state, l.zip, l.country, l.country_code, l.display_name
-		FROM locations l
-		INNER JOIN entries e ON l.entry_id = e.id
-		WHERE e.user_uid = $1
-		ORDER BY COALESCE(NULLIF(l.display_name, ''), l.latitude::text || ',' || l.longitude::text), l.created_at DESC
-	`
-
-	rows, err := h.postgres.Query(ctx, query, userUID)
+		SELECT latest.latitude, latest.longitude,
+			COALESCE(latest.address, ''), COALESCE(latest.city, ''), COALESCE(latest.state, ''), COALESCE(latest.zip, ''),
+			COALESCE(latest.country, ''), COALESCE(latest.country_code, ''), COALESCE(latest.display_name, ''),
+			counts.entry_count
+		FROM latest
+		INNER JOIN counts ON counts.loc_key = latest.loc_key
+		ORDER BY %s
+		LIMIT $3 OFFSET $4
+	`, orderBy)
+
+	rows, err := h.postgres.Query(ctx, query, userUID, pattern, filter.limit, filter.offset)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var locations []models.Location
+	locations := []uniquelocationsmodels.UniqueLocation{}
 	for rows.Next() {
-		var location models.Location
+		var location uniquelocationsmodels.UniqueLocation
+		// Address-only locations have NULL coordinates; leave them zero so they're omitted
+		var latitude, longitude *float64
 		if err := rows.Scan(
-			&location.Latitude,
-			&location.Longitude,
+			&latitude,
+			&longitude,
 			&location.Address,
 			&location.City,
 			&location.State,
@@ -75,11 +172,26 @@ func (h *EntryHandler) fetchUniqueLocations(ctx context.Context, userUID string)
 			&location.Country,
 			&location.CountryCode,
 			&location.DisplayName,
+			&location.Count,
 		); err != nil {
-			return nil, err
+			return nil, 0, err
+		}
+		if latitude != nil {
+			location.Latitude = *latitude
+		}
+		if longitude != nil {
+			location.Longitude = *longitude
 		}
 		locations = append(locations, location)
 	}
 
-	return locations, nil
-}
\ No newline at end of file
+	return locations, total, rows.Err()
+}
+
+// invalidateUniqueLocationsCache clears every cached GetUniqueLocations page for uid
+func (h *EntryHandler) invalidateUniqueLocationsCache(ctx context.Context, uid string) {
+	keys, _ := h.cache.Keys(ctx, fmt.Sprintf("unique_locations:%s:*", uid))
+	if len(keys) > 0 {
+		_ = h.cache.Del(ctx, keys...)
+	}
+}
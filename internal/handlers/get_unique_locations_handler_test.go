@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"io.winapps.journeyapp/internal/cache"
+	uniquelocationsmodels "io.winapps.journeyapp/internal/models/get_unique_locations"
+)
+
+// An address-only location with NULL coordinates is listed with the coordinates left out
+func TestUniqueLocationsWithNullCoordinates(t *testing.T) {
+	pool := testPool(t)
+	h := NewEntryHandler(nil, pool, cache.NewMemory(100), nil)
+	alice := testUser(t, pool, "alice")
+	entryID := testEntry(t, pool, alice, "Entry", "private")
+	mustExec(t, pool, `INSERT INTO locations (entry_id, latitude, longitude, city, display_name) VALUES ($1, NULL, NULL, 'Lisbon', 'Rua Augusta')`, entryID)
+	mustExec(t, pool, `INSERT INTO locations (entry_id, latitude, longitude, display_name) VALUES ($1, 38.7, -9.1, 'Belém')`, entryID)
+
+	w := callAs(alice, h.GetUniqueLocations, http.MethodPost, "/get-unique-locations?sort=name", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetUniqueLocations: %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Locations []json.RawMessage `json:"locations"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Locations) != 2 {
+		t.Fatalf("got %d locations, want 2: %s", len(resp.Locations), w.Body.String())
+	}
+
+	var withCoords, addressOnly uniquelocationsmodels.UniqueLocation
+	_ = json.Unmarshal(resp.Locations[0], &withCoords)
+	_ = json.Unmarshal(resp.Locations[1], &addressOnly)
+	if withCoords.DisplayName != "Belém" || withCoords.Latitude != 38.7 || withCoords.Longitude != -9.1 {
+		t.Errorf("first location = %+v, want Belém at 38.7,-9.1", withCoords)
+	}
+	if addressOnly.DisplayName != "Rua Augusta" || addressOnly.City != "Lisbon" {
+		t.Errorf("second location = %+v, want Rua Augusta in Lisbon", addressOnly)
+	}
+	if raw := string(resp.Locations[1]); strings.Contains(raw, "latitude") || strings.Contains(raw, "longitude") {
+		t.Errorf("address-only location has coordinates: %s", raw)
+	}
+}
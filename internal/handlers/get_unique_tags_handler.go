@@ -6,31 +6,45 @@ import (
 
 	"github.com/gin-gonic/gin"
 
-	models "io.winapps.journeyapp/internal/models/account"
+	"io.winapps.journeyapp/internal/apierror"
 	uniquetagsmodels "io.winapps.journeyapp/internal/models/get_unique_tags"
 )
 
-// GetUniqueTags handles fetching all unique tag keys for the authenticated user
+// GetUniqueTags handles fetching all unique tag keys for the authenticated user, each with
+// the number of entries using it
 func (h *EntryHandler) GetUniqueTags(c *gin.Context) {
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
+
+	// Users who normalize tags see case variants saved before they turned it on as one key
+	normalize, err := tagNormalizationEnabled(ctx, h.postgres, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load settings")
+		return
+	}
 
 	// Fetch unique tags from database
-	tags, err := h.fetchUniqueTags(ctx, userUID)
+	tags, err := h.fetchUniqueTags(ctx, userUID, normalize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch unique tags"})
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch unique tags")
 		return
 	}
 
@@ -41,17 +55,31 @@ func (h *EntryHandler) GetUniqueTags(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// fetchUniqueTags retrieves all unique tag keys for a user
-func (h *EntryHandler) fetchUniqueTags(ctx context.Context, userUID string) ([]models.Tag, error) {
-	// Query to get unique tag keys with their most recent value for each key
-	// This ensures we get one Tag object per unique key
+// fetchUniqueTags retrieves every tag key the user has used with its most recent value
+// and the number of entries carrying it, most used first. With collapse, keys that differ
+// only in case or surrounding spaces are counted as one normalized key.
+func (h *EntryHandler) fetchUniqueTags(ctx context.Context, userUID string, collapse bool) ([]uniquetagsmodels.UniqueTag, error) {
+	// An entry holds each key at most once, so COUNT(*) is the number of entries per key
 	query := `
-		SELECT DISTINCT ON (t.key) t.key, t.value
+		SELECT t.key, COALESCE((array_agg(t.value ORDER BY t.created_at DESC))[1], ''), COUNT(*) AS uses
 		FROM tags t
 		INNER JOIN entries e ON t.entry_id = e.id
 		WHERE e.user_uid = $1
-		ORDER BY t.key, t.created_at DESC
+		GROUP BY t.key
+		ORDER BY uses DESC, t.key
 	`
+	if collapse {
+		// An entry can still hold several variants of a key, so count entries, not rows
+		query = `
+			SELECT LOWER(TRIM(t.key)) AS norm_key, COALESCE((array_agg(t.value ORDER BY t.created_at DESC))[1], ''),
+			       COUNT(DISTINCT t.entry_id) AS uses
+			FROM tags t
+			INNER JOIN entries e ON t.entry_id = e.id
+			WHERE e.user_uid = $1
+			GROUP BY norm_key
+			ORDER BY uses DESC, norm_key
+		`
+	}
 
 	rows, err := h.postgres.Query(ctx, query, userUID)
 	if err != nil {
@@ -59,14 +87,14 @@ func (h *EntryHandler) fetchUniqueTags(ctx context.Context, userUID string) ([]m
 	}
 	defer rows.Close()
 
-	var tags []models.Tag
+	tags := []uniquetagsmodels.UniqueTag{}
 	for rows.Next() {
-		var tag models.Tag
-		if err := rows.Scan(&tag.Key, &tag.Value); err != nil {
+		var tag uniquetagsmodels.UniqueTag
+		if err := rows.Scan(&tag.Key, &tag.Value, &tag.Count); err != nil {
 			return nil, err
 		}
 		tags = append(tags, tag)
 	}
 
-	return tags, nil
-}
\ No newline at end of file
+	return tags, rows.Err()
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"io.winapps.journeyapp/internal/cache"
+	uniquelocationsmodels "io.winapps.journeyapp/internal/models/get_unique_locations"
+	uniquetagsmodels "io.winapps.journeyapp/internal/models/get_unique_tags"
+)
+
+// Tag and location counts are the number of the user's entries using each, most used
+// first, and other users' entries don't count
+func TestUniqueTagAndLocationCounts(t *testing.T) {
+	pool := testPool(t)
+	h := NewEntryHandler(nil, pool, cache.NewMemory(100), nil)
+	alice := testUser(t, pool, "alice")
+	bob := testUser(t, pool, "bob")
+
+	seed := func(owner string, tags []string, places []string) {
+		id := testEntry(t, pool, owner, "Entry", "private")
+		for _, key := range tags {
+			mustExec(t, pool, `INSERT INTO tags (entry_id, key, value) VALUES ($1, $2, '')`, id, key)
+		}
+		for _, place := range places {
+			mustExec(t, pool, `INSERT INTO locations (entry_id, latitude, longitude, display_name) VALUES ($1, 1, 2, $2)`, id, place)
+		}
+	}
+	seed(alice, []string{"travel", "food"}, []string{"Lisbon"})
+	seed(alice, []string{"travel"}, []string{"Lisbon", "Porto"})
+	seed(alice, []string{"travel", "work"}, []string{"Lisbon"})
+	seed(bob, []string{"work", "food"}, []string{"Porto"})
+
+	w := callAs(alice, h.GetUniqueTags, http.MethodPost, "/get-unique-tags", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetUniqueTags: %d %s", w.Code, w.Body.String())
+	}
+	var tags uniquetagsmodels.GetUniqueTagsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &tags); err != nil {
+		t.Fatal(err)
+	}
+	wantTags := []struct {
+		key   string
+		count int
+	}{{"travel", 3}, {"food", 1}, {"work", 1}}
+	if len(tags.Tags) != len(wantTags) {
+		t.Fatalf("tags = %+v, want %v", tags.Tags, wantTags)
+	}
+	for i, want := range wantTags {
+		if got := tags.Tags[i]; got.Key != want.key || got.Count != want.count {
+			t.Errorf("tags[%d] = %s x%d, want %s x%d", i, got.Key, got.Count, want.key, want.count)
+		}
+	}
+
+	w = callAs(alice, h.GetUniqueLocations, http.MethodPost, "/get-unique-locations", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetUniqueLocations: %d %s", w.Code, w.Body.String())
+	}
+	var locations uniquelocationsmodels.GetUniqueLocationsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &locations); err != nil {
+		t.Fatal(err)
+	}
+	if len(locations.Locations) != 2 || locations.Total != 2 {
+		t.Fatalf("locations = %+v, want Lisbon and Porto", locations)
+	}
+	if got := locations.Locations[0]; got.DisplayName != "Lisbon" || got.Count != 3 {
+		t.Errorf("first location = %s x%d, want Lisbon x3", got.DisplayName, got.Count)
+	}
+	if got := locations.Locations[1]; got.DisplayName != "Porto" || got.Count != 1 {
+		t.Errorf("second location = %s x%d, want Porto x1", got.DisplayName, got.Count)
+	}
+}
@@ -1,16 +1,15 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
 
+	"io.winapps.journeyapp/internal/apierror"
 	getdetailsmodels "io.winapps.journeyapp/internal/models/get_user_details"
 )
 
@@ -19,26 +18,26 @@ func (h *UsersHandler) GetUserDetails(c *gin.Context) {
 	// Ensure user is authenticated (middleware populates context)
 	uidCtx, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 	authenticatedUID, ok := uidCtx.(string)
 	if !ok || authenticatedUID == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	targetUID := c.Query("uid")
 	if targetUID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required query parameter: uid"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Missing required query parameter: uid")
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 
 	// Attempt Redis cache first
-	cacheKey := fmt.Sprintf("user_details:%s", targetUID)
-	if cached, err := h.redis.Get(ctx, cacheKey).Result(); err == nil && cached != "" {
+	cacheKey := userDetailsKey(targetUID)
+	if cached, err := h.cache.Get(ctx, cacheKey); err == nil && cached != "" {
 		var resp getdetailsmodels.GetUserDetailsResponse
 		if err := json.Unmarshal([]byte(cached), &resp); err == nil {
 			c.JSON(http.StatusOK, resp)
@@ -47,14 +46,12 @@ func (h *UsersHandler) GetUserDetails(c *gin.Context) {
 	}
 
 	// Fetch aggregate counts
-	var totalEntries int
-	countsQuery := `
-		SELECT COUNT(*) FROM entries WHERE user_uid = $1
-	`
-	if err := h.postgres.QueryRow(ctx, countsQuery, targetUID).Scan(
-		&totalEntries,
-	); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute aggregate count of entries"})
+	totals, err := loadAccountTotals(ctx, h.postgres, targetUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to compute aggregate count of entries")
 		return
 	}
 
@@ -74,7 +71,7 @@ func (h *UsersHandler) GetUserDetails(c *gin.Context) {
 		WHERE u.uid = $1
 	`
 
-	if err := h.postgres.QueryRow(context.Background(), query, targetUID).Scan(
+	if err := h.postgres.QueryRow(ctx, query, targetUID).Scan(
 		&uid,
 		&displayName,
 		&email,
@@ -83,10 +80,13 @@ func (h *UsersHandler) GetUserDetails(c *gin.Context) {
 		&isPremium,
 	); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "User not found")
+			return
+		}
+		if abortOnContextError(c, err) {
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch user")
 		return
 	}
 
@@ -96,13 +96,13 @@ func (h *UsersHandler) GetUserDetails(c *gin.Context) {
 		Email: email,
 		PhotoURL: photoURL,
 		CreatedAt: createdAt,
-		TotalEntries: totalEntries,
+		TotalEntries: totals.entries,
 		IsPremium: isPremium,
 	}
 
 	// Cache response for a short period
 	if payload, err := json.Marshal(resp); err == nil {
-		_ = h.redis.Set(ctx, cacheKey, payload, 10*time.Minute).Err()
+		_ = h.cache.Set(ctx, cacheKey, payload, 10*time.Minute)
 	}
 
 	c.JSON(http.StatusOK, resp)
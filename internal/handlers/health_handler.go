@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"io.winapps.journeyapp/internal/cache"
+	firebaseutil "io.winapps.journeyapp/internal/firebase"
+)
+
+// readinessTimeout bounds how long each dependency check may take
+const readinessTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	firebaseApp *firebase.App
+	postgres    *pgxpool.Pool
+	cache       cache.Store
+	version     string
+}
+
+// NewHealthHandler creates a health handler. version is reported in responses; when empty
+// it falls back to APP_VERSION and then the VCS revision embedded by the Go toolchain.
+func NewHealthHandler(firebaseApp *firebase.App, postgres *pgxpool.Pool, store cache.Store, version string) *HealthHandler {
+	return &HealthHandler{
+		firebaseApp: firebaseApp,
+		postgres:    postgres,
+		cache:       store,
+		version:     resolveVersion(version),
+	}
+}
+
+// Live reports that the process is up and serving requests
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "version": h.version})
+}
+
+// dependencyCheck is the result of probing one dependency. Soft checks are reported
+// but never make the instance unready.
+type dependencyCheck struct {
+	Status    string `json:"status"` // ok, error or disabled
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+	Soft      bool   `json:"soft,omitempty"`
+}
+
+// Ready checks Postgres and Redis (when configured) and returns 503 naming the failed
+// dependency if either is unavailable. Firebase auth reachability is a soft check.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	checks := map[string]dependencyCheck{}
+	ready := true
+
+	probe := func(name string, soft bool, fn func(ctx context.Context) error) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
+
+		start := time.Now()
+		err := fn(ctx)
+		check := dependencyCheck{Status: "ok", LatencyMs: time.Since(start).Milliseconds(), Soft: soft}
+		if err != nil {
+			check.Status = "error"
+			check.Error = err.Error()
+			if !soft {
+				ready = false
+			}
+		}
+		checks[name] = check
+	}
+
+	probe("postgres", false, h.postgres.Ping)
+	if h.cache.Backend() == "redis" {
+		probe("redis", false, h.cache.Ping)
+	} else {
+		// Running on the in-memory fallback: ready, but flag that state isn't shared
+		checks["redis"] = dependencyCheck{Status: "disabled", Error: "using in-memory cache"}
+	}
+	probe("firebase", true, h.pingFirebase)
+
+	failed := []string{}
+	for name, check := range checks {
+		if check.Status == "error" && !check.Soft {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+
+	status := http.StatusOK
+	body := gin.H{"status": "ok", "version": h.version, "checks": checks}
+	if !ready {
+		status = http.StatusServiceUnavailable
+		body["status"] = "unavailable"
+		body["failed"] = failed
+	}
+	c.JSON(status, body)
+}
+
+// pingFirebase looks up a uid that can't exist; a not-found answer proves the auth
+// backend is reachable and our credentials are accepted.
+func (h *HealthHandler) pingFirebase(ctx context.Context) error {
+	authClient, err := firebaseutil.GetAuthClient(h.firebaseApp)
+	if err != nil {
+		return err
+	}
+	_, err = authClient.GetUser(ctx, "journeyapp-readiness-probe")
+	if err != nil && !auth.IsUserNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func resolveVersion(version string) string {
+	if version != "" {
+		return version
+	}
+	if v := os.Getenv("APP_VERSION"); v != "" {
+		return v
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				return s.Value
+			}
+		}
+	}
+	return "dev"
+}
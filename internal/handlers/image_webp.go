@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultWebPQuality = 80
+	webpEncodeTimeout  = 30 * time.Second
+)
+
+// webpConfig controls re-encoding uploaded JPEG and PNG images to WebP. The encoder is
+// libwebp's cwebp binary, since the standard library can decode but not encode WebP.
+// Set IMAGE_WEBP_ENABLED=true to turn it on; IMAGE_WEBP_QUALITY (1-100, default 80) and
+// CWEBP_PATH (default: cwebp on PATH) tune it.
+type webpConfig struct {
+	enabled bool
+	quality int
+	binary  string
+}
+
+// webpConfigFromEnv reads the WebP settings. Conversion stays off when the binary can't
+// be found, so a missing encoder never fails uploads.
+func webpConfigFromEnv() webpConfig {
+	cfg := webpConfig{quality: defaultWebPQuality}
+	if enabled, _ := strconv.ParseBool(os.Getenv("IMAGE_WEBP_ENABLED")); !enabled {
+		return cfg
+	}
+	if q, err := strconv.Atoi(os.Getenv("IMAGE_WEBP_QUALITY")); err == nil && q >= 1 && q <= 100 {
+		cfg.quality = q
+	}
+	binary := os.Getenv("CWEBP_PATH")
+	if binary == "" {
+		binary = "cwebp"
+	}
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return cfg
+	}
+	cfg.binary = path
+	cfg.enabled = true
+	return cfg
+}
+
+// imageMimeType maps an extension chosen by imageExtension to its MIME type
+func imageMimeType(ext string) string {
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	case ".heic":
+		return "image/heic"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// webpConvertible reports whether an image with this extension gets a WebP variant. GIFs
+// are left alone because they may be animated; WebP and HEIC are already compact.
+func webpConvertible(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg", ".png":
+		return true
+	}
+	return false
+}
+
+// webpVariantURL returns the URL of the WebP stored next to a JPEG or PNG image
+func webpVariantURL(imageURL string) string {
+	return strings.TrimSuffix(imageURL, filepath.Ext(imageURL)) + ".webp"
+}
+
+// transcodeToWebP stages a WebP copy of a staged JPEG or PNG image, to be committed along
+// with it. It returns nil without error when conversion is disabled, the format isn't
+// convertible, or the WebP would be no smaller than the original, which is then kept on
+// its own.
+func (cfg webpConfig) transcodeToWebP(ctx context.Context, src *stagedMedia) (*stagedMedia, error) {
+	if !cfg.enabled || !webpConvertible(filepath.Ext(src.URL)) {
+		return nil, nil
+	}
+	webpURL := webpVariantURL(src.URL)
+	key := path.Join(path.Dir(src.key), path.Base(webpURL))
+	f, err := os.CreateTemp("", "journeyapp-*.webp")
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	dst := &stagedMedia{URL: webpURL, key: key, tempPath: f.Name(), store: src.store}
+
+	ctx, cancel := context.WithTimeout(ctx, webpEncodeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, cfg.binary, "-quiet", "-metadata", "none", "-q", strconv.Itoa(cfg.quality), src.tempPath, "-o", dst.tempPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		dst.Discard()
+		return nil, fmt.Errorf("cwebp failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	srcInfo, err := os.Stat(src.tempPath)
+	if err != nil {
+		dst.Discard()
+		return nil, err
+	}
+	dstInfo, err := os.Stat(dst.tempPath)
+	if err != nil {
+		dst.Discard()
+		return nil, err
+	}
+	if dstInfo.Size() >= srcInfo.Size() {
+		dst.Discard()
+		return nil, nil
+	}
+	return dst, nil
+}
+
+// negotiateImageFile picks the file to serve for an entry image: the WebP variant when
+// the client accepts WebP and one exists, otherwise the original
+func (h *EntryHandler) negotiateImageFile(c *gin.Context, uid, entryID, file string) string {
+	if !webpConvertible(filepath.Ext(file)) {
+		return file
+	}
+	// The response differs by Accept whenever a variant could exist
+	c.Header("Vary", "Accept")
+	if !strings.Contains(c.GetHeader("Accept"), "image/webp") {
+		return file
+	}
+	variant := strings.TrimSuffix(file, filepath.Ext(file)) + ".webp"
+	if !isPlainPathSegment(uid) || !isPlainPathSegment(entryID) || !isPlainPathSegment(variant) {
+		return file
+	}
+	if _, err := h.media.Stat(c.Request.Context(), path.Join("images", uid, entryID, variant)); err != nil {
+		return file
+	}
+	return variant
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"io.winapps.journeyapp/internal/storage"
+)
+
+// fakeCWebP writes a stand-in for cwebp that outputs size bytes, so the size comparison
+// can be tested without libwebp installed
+func fakeCWebP(t *testing.T, size int) webpConfig {
+	t.Helper()
+	script := filepath.Join(t.TempDir(), "cwebp")
+	body := "#!/bin/sh\nwhile [ \"$1\" != -o ]; do shift; done\nhead -c " + strconv.Itoa(size) + " /dev/zero > \"$2\"\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return webpConfig{enabled: true, quality: defaultWebPQuality, binary: script}
+}
+
+// stageImage stages data as an entry image, the way add-image does before transcoding
+func stageImage(t *testing.T, store storage.Store, data []byte) *stagedMedia {
+	t.Helper()
+	media, err := stageMediaFile(store, bytes.NewReader(data), "images", imageExtension, "alice", "e1", 1<<20)
+	if err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+	t.Cleanup(media.Discard)
+	return media
+}
+
+func TestTranscodeToWebPKeepsOnlySmallerCopies(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	store := storage.NewLocal(t.TempDir())
+	ctx := context.Background()
+	jpegData := append([]byte("\xff\xd8\xff\xe0"), make([]byte, 996)...)
+	pngData := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 992)...)
+	gifData := append([]byte("GIF89a"), make([]byte, 994)...)
+
+	src := stageImage(t, store, jpegData)
+	variant, err := fakeCWebP(t, 400).transcodeToWebP(ctx, src)
+	if err != nil || variant == nil || variant.URL != webpVariantURL(src.URL) {
+		t.Fatalf("smaller WebP: got %+v, %v", variant, err)
+	}
+	if info, err := os.Stat(variant.tempPath); err != nil || info.Size() != 400 {
+		t.Errorf("staged WebP = %v, %v; want 400 bytes", info, err)
+	}
+	variant.Discard()
+
+	src = stageImage(t, store, pngData)
+	variant, err = fakeCWebP(t, 1000).transcodeToWebP(ctx, src)
+	if err != nil || variant != nil {
+		t.Fatalf("WebP no smaller than the original: got %+v, %v; want it dropped", variant, err)
+	}
+	if left := tempFiles(t, tmp); len(left) != 2 {
+		t.Errorf("staged files = %v; want only the two originals, not the dropped WebP", left)
+	}
+
+	// GIFs are never converted, and a disabled config does nothing
+	if variant, err := fakeCWebP(t, 1).transcodeToWebP(ctx, stageImage(t, store, gifData)); variant != nil || err != nil {
+		t.Errorf("GIF: got %+v, %v", variant, err)
+	}
+	if variant, err := (webpConfig{}).transcodeToWebP(ctx, src); variant != nil || err != nil {
+		t.Errorf("disabled: got %+v, %v", variant, err)
+	}
+}
+
+// With libwebp installed, a real photo-like PNG comes out smaller as WebP
+func TestTranscodeToWebPWithCWebP(t *testing.T) {
+	binary, err := exec.LookPath("cwebp")
+	if err != nil {
+		t.Skip("cwebp not installed")
+	}
+	t.Setenv("TMPDIR", t.TempDir())
+	store := storage.NewLocal(t.TempDir())
+
+	img := image.NewRGBA(image.Rect(0, 0, 256, 256))
+	for y := 0; y < 256; y++ {
+		for x := 0; x < 256; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), uint8(x ^ y), 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	cfg := webpConfig{enabled: true, quality: defaultWebPQuality, binary: binary}
+	variant, err := cfg.transcodeToWebP(context.Background(), stageImage(t, store, buf.Bytes()))
+	if err != nil || variant == nil {
+		t.Fatalf("transcodeToWebP = %+v, %v; want a WebP copy", variant, err)
+	}
+	defer variant.Discard()
+	info, err := os.Stat(variant.tempPath)
+	if err != nil || info.Size() >= int64(buf.Len()) {
+		t.Errorf("WebP = %v, %v; want smaller than the %d-byte PNG", info, err, buf.Len())
+	}
+}
@@ -0,0 +1,745 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/metrics"
+	accountmodels "io.winapps.journeyapp/internal/models/account"
+	importmodels "io.winapps.journeyapp/internal/models/import_data"
+)
+
+// ImportJobStatus represents the progress and state of an import job
+// Stored in Redis as JSON under key: import_job:<jobID>, with the same TTL as exports
+type ImportJobStatus struct {
+	JobID            string     `json:"jobId"`
+	UID              string     `json:"uid"`
+	Status           string     `json:"status"` // pending, running, completed, failed, cancelled
+	Format           string     `json:"format"` // zip or json
+	Progress         int        `json:"progress"`
+	Dedupe           bool       `json:"dedupe"`
+	StartedAt        time.Time  `json:"startedAt"`
+	CompletedAt      *time.Time `json:"completedAt,omitempty"`
+	TotalEntries     int        `json:"totalEntries"`
+	ProcessedEntries int        `json:"processedEntries"`
+	ImportedEntries  int        `json:"importedEntries"`
+	SkippedEntries   int        `json:"skippedEntries"` // duplicates of existing entries (dedupe, or already imported for json)
+	FailedEntries    int        `json:"failedEntries"`
+	ImportedImages   int        `json:"importedImages"`
+	ImportedAudio    int        `json:"importedAudio"`
+	ImportedVideos   int        `json:"importedVideos"`
+	MissingMedia     int        `json:"missingMedia"` // json only: referenced files no longer in storage
+	ZipPath          string     `json:"zipPath"`
+	Error            string     `json:"error,omitempty"`
+}
+
+const importJobRedisKeyPrefix = "import_job:"
+
+const (
+	importFormatZip  = "zip"
+	importFormatJSON = "json"
+)
+
+const (
+	// maxImportZipBytes caps the uploaded archive; maxImportMediaBytes caps each file in it
+	maxImportZipBytes   = 1 << 30
+	maxImportMediaBytes = 100 << 20
+	maxImportEntries    = 50000
+)
+
+// importEntry is one row of entries.csv (or one object of entries.json) in an export
+type importEntry struct {
+	ID          string           `json:"id"`
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Locations   []exportLocation `json:"locations"`
+	Tags        []exportTag      `json:"tags"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	UpdatedAt   time.Time        `json:"updatedAt"`
+	Images      []*zip.File      `json:"-"`
+	Audio       []*zip.File      `json:"-"`
+	Videos      []*zip.File      `json:"-"` // clips and their posters
+
+	// Media already in storage, referenced by a JSON import instead of carried in a zip
+	ImageRefs []string              `json:"-"`
+	AudioRefs []string              `json:"-"`
+	VideoRefs []accountmodels.Video `json:"-"`
+}
+
+// importedMedia counts the files restored with one entry
+type importedMedia struct {
+	images, audio, videos int
+}
+
+// importedVideo is a restored clip and the URL of its poster, if the export had one
+type importedVideo struct {
+	url       string
+	thumbnail *string
+}
+
+// ImportData restores an export zip into the authenticated user's account as an async job.
+// Expects multipart/form-data with a "file" part holding the zip produced by ExportData and an
+// optional "dedupe" field; with dedupe=true, entries whose title, description and creation time
+// match an existing entry are skipped. Entries get new ids; media is copied under those ids.
+// A JSON body is imported by importJSON instead.
+func (h *AuthHandler) ImportData(c *gin.Context) {
+	uidCtx, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	authenticatedUID, ok := uidCtx.(string)
+	if !ok || authenticatedUID == "" {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
+		return
+	}
+
+	if c.ContentType() == "application/json" {
+		h.importJSON(c, authenticatedUID)
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "A zip file is required in the \"file\" field")
+		return
+	}
+	if fileHeader.Size > maxImportZipBytes {
+		respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Import file is too large")
+		return
+	}
+	dedupe, _ := strconv.ParseBool(c.PostForm("dedupe"))
+
+	// Don't start new jobs once shutdown has begun
+	if h.exportCtx.Err() != nil {
+		respondError(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, "Server is shutting down; try again shortly")
+		return
+	}
+
+	jobID := uuid.New().String()
+	userRoot := filepath.Join("internal", "imports", authenticatedUID)
+	zipPath := filepath.Join(userRoot, fmt.Sprintf("%s.zip", jobID))
+	if err := os.MkdirAll(userRoot, 0755); err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to store import file")
+		return
+	}
+	if err := c.SaveUploadedFile(fileHeader, zipPath); err != nil {
+		h.logError(c, err, "Failed to save import upload")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to store import file")
+		return
+	}
+
+	// Validate the archive up front so obvious problems are reported synchronously
+	status, code, msg := validateImportZip(zipPath, authenticatedUID)
+	if status != 0 {
+		_ = os.Remove(zipPath)
+		respondError(c, status, code, msg)
+		return
+	}
+
+	st := ImportJobStatus{
+		JobID:     jobID,
+		UID:       authenticatedUID,
+		Status:    "pending",
+		Format:    importFormatZip,
+		Dedupe:    dedupe,
+		StartedAt: time.Now(),
+		ZipPath:   zipPath,
+	}
+	if err := h.saveImportStatus(context.Background(), st); err != nil {
+		_ = os.Remove(zipPath)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to initialize import job")
+		return
+	}
+
+	metrics.ImportJobsTotal.WithLabelValues("started").Inc()
+	h.exportJobs.Add(1)
+	go func() {
+		defer h.exportJobs.Done()
+		h.runImportJob(h.exportCtx, jobID, authenticatedUID)
+	}()
+
+	c.JSON(http.StatusAccepted, importmodels.ImportDataResponse{ImportJobID: jobID, Message: "Import started"})
+}
+
+// validateImportZip checks that path is a readable export archive for uid. It returns a
+// zero status when the archive is acceptable, otherwise the HTTP status, code and message.
+func validateImportZip(zipPath, uid string) (int, string, string) {
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return http.StatusBadRequest, apierror.CodeValidation, "File is not a valid zip archive"
+	}
+	defer archive.Close()
+
+	hasEntries := false
+	for _, f := range archive.File {
+		name := f.Name
+		if !validImportPath(name) {
+			return http.StatusBadRequest, apierror.CodeValidation, "Zip contains an invalid path"
+		}
+		switch name {
+		case "entries/entries.csv", "entries/entries.json":
+			hasEntries = true
+		case exportManifestName:
+			manifest, err := readImportManifest(f)
+			if err != nil {
+				return http.StatusBadRequest, apierror.CodeValidation, "Zip manifest is invalid"
+			}
+			if manifest.UID != uid {
+				return http.StatusForbidden, apierror.CodeForbidden, "Cannot import another user's export"
+			}
+		}
+	}
+	if !hasEntries {
+		return http.StatusBadRequest, apierror.CodeValidation, "Zip does not contain entries/entries.csv or entries/entries.json"
+	}
+	return 0, "", ""
+}
+
+// validImportPath rejects absolute paths and parent-directory segments
+func validImportPath(name string) bool {
+	if strings.HasPrefix(name, "/") || strings.Contains(name, "\\") {
+		return false
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func readImportManifest(f *zip.File) (*exportManifest, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var manifest exportManifest
+	if err := json.NewDecoder(io.LimitReader(r, 64<<10)).Decode(&manifest); err != nil {
+		return nil, err
+	}
+	if manifest.UID == "" {
+		return nil, fmt.Errorf("manifest has no uid")
+	}
+	return &manifest, nil
+}
+
+func (h *AuthHandler) saveImportStatus(ctx context.Context, status ImportJobStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return h.cache.Set(ctx, importJobRedisKeyPrefix+status.JobID, data, exportJobTTL)
+}
+
+func (h *AuthHandler) loadImportStatus(ctx context.Context, jobID string) (*ImportJobStatus, error) {
+	val, err := h.cache.Get(ctx, importJobRedisKeyPrefix+jobID)
+	if err != nil {
+		return nil, err
+	}
+	var st ImportJobStatus
+	if err := json.Unmarshal([]byte(val), &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// runImportJob recreates the archive's entries for uid and updates progress in Redis. Each
+// entry is written in its own transaction, so a cancelled or failed job keeps the entries
+// already imported; the uploaded zip is always removed.
+func (h *AuthHandler) runImportJob(ctx context.Context, jobID, uid string) {
+	st, err := h.loadImportStatus(ctx, jobID)
+	if err != nil {
+		return
+	}
+	st.Status = "running"
+	_ = h.saveImportStatus(ctx, *st)
+	defer h.finishImportJob(ctx, uid, st)
+
+	archive, err := zip.OpenReader(st.ZipPath)
+	if err != nil {
+		st.Status = "failed"
+		st.Error = fmt.Sprintf("failed to open zip: %v", err)
+		return
+	}
+	defer archive.Close()
+
+	entries, err := parseImportEntries(&archive.Reader)
+	if err != nil {
+		st.Status = "failed"
+		st.Error = err.Error()
+		return
+	}
+	h.restoreEntries(ctx, jobID, uid, st, entries)
+}
+
+// finishImportJob records how a job ended; runImportJob and runJSONImportJob defer it
+func (h *AuthHandler) finishImportJob(ctx context.Context, uid string, st *ImportJobStatus) {
+	if st.Status != "completed" && ctx.Err() != nil {
+		st.Status = "cancelled"
+		st.Error = "import cancelled because the server is shutting down"
+	}
+	if st.ZipPath != "" {
+		_ = os.Remove(st.ZipPath)
+		st.ZipPath = ""
+	}
+	// Imported entries change the user's entry list and totals
+	_ = h.cache.Del(context.Background(), fmt.Sprintf("user_entries:%s", uid))
+	invalidateAccountDetails(context.Background(), h.cache, uid)
+	_ = h.saveImportStatus(context.Background(), *st)
+	if st.Status == "completed" || st.Status == "failed" || st.Status == "cancelled" {
+		metrics.ImportJobsTotal.WithLabelValues(st.Status).Inc()
+	}
+}
+
+// restoreEntries writes entries for uid one at a time, saving progress after each, and
+// marks the job completed unless ctx is cancelled first. Entries that match an existing
+// one are skipped: by content with dedupe, and by original id for JSON imports.
+func (h *AuthHandler) restoreEntries(ctx context.Context, jobID, uid string, st *ImportJobStatus, entries []*importEntry) {
+	st.TotalEntries = len(entries)
+	_ = h.saveImportStatus(ctx, *st)
+
+	var existing map[string]bool
+	var err error
+	if st.Dedupe {
+		if existing, err = h.existingEntryHashes(ctx, uid); err != nil {
+			st.Status = "failed"
+			st.Error = fmt.Sprintf("failed to load existing entries: %v", err)
+			return
+		}
+	}
+	var restored map[string]bool
+	if st.Format == importFormatJSON {
+		if restored, err = h.restoredEntryIDs(ctx, uid); err != nil {
+			st.Status = "failed"
+			st.Error = fmt.Sprintf("failed to load existing entries: %v", err)
+			return
+		}
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		hash := entryContentHash(entry.Title, entry.Description, entry.CreatedAt)
+		if (st.Dedupe && existing[hash]) || restored[entry.ID] {
+			st.SkippedEntries++
+		} else if media, err := h.restoreEntry(ctx, uid, entry); err != nil {
+			st.FailedEntries++
+			st.Error = fmt.Sprintf("failed to import entry %s: %v", entry.ID, err)
+			if h.logger != nil {
+				h.logger.Warnw("Failed to import entry", "job_id", jobID, "entry_id", entry.ID, "error", err)
+			}
+		} else {
+			st.ImportedEntries++
+			st.ImportedImages += media.images
+			st.ImportedAudio += media.audio
+			st.ImportedVideos += media.videos
+			if existing != nil {
+				existing[hash] = true
+			}
+		}
+
+		st.ProcessedEntries++
+		st.Progress = st.ProcessedEntries * 100 / st.TotalEntries
+		_ = h.saveImportStatus(ctx, *st)
+	}
+
+	completed := time.Now()
+	st.CompletedAt = &completed
+	st.Status = "completed"
+	st.Progress = 100
+}
+
+// parseImportEntries reads entries.json, or entries.csv when there is no JSON file, and
+// attaches each entry's media files from entries/<id>/images|audio|videos
+func parseImportEntries(archive *zip.Reader) ([]*importEntry, error) {
+	var csvFile, jsonFile *zip.File
+	for _, f := range archive.File {
+		switch f.Name {
+		case "entries/entries.csv":
+			csvFile = f
+		case "entries/entries.json":
+			jsonFile = f
+		}
+	}
+
+	var entries []*importEntry
+	var err error
+	if jsonFile != nil {
+		entries, err = parseImportJSON(jsonFile)
+	} else if csvFile != nil {
+		entries, err = parseImportCSV(csvFile)
+	} else {
+		err = fmt.Errorf("zip does not contain entries/entries.csv or entries/entries.json")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > maxImportEntries {
+		return nil, fmt.Errorf("zip contains more than %d entries", maxImportEntries)
+	}
+
+	byID := make(map[string]*importEntry, len(entries))
+	for _, e := range entries {
+		if e.ID == "" {
+			return nil, fmt.Errorf("entry without an id")
+		}
+		if _, dup := byID[e.ID]; dup {
+			return nil, fmt.Errorf("duplicate entry id %s", e.ID)
+		}
+		byID[e.ID] = e
+	}
+
+	// Media lives at entries/<id>/images/<file>, entries/<id>/audio/<file> and
+	// entries/<id>/videos/<file>
+	for _, f := range archive.File {
+		parts := strings.Split(f.Name, "/")
+		if len(parts) != 4 || parts[0] != "entries" || f.FileInfo().IsDir() {
+			continue
+		}
+		e, ok := byID[parts[1]]
+		if !ok {
+			continue
+		}
+		switch parts[2] {
+		case "images":
+			e.Images = append(e.Images, f)
+		case "audio":
+			e.Audio = append(e.Audio, f)
+		case "videos":
+			e.Videos = append(e.Videos, f)
+		}
+	}
+	return entries, nil
+}
+
+func parseImportCSV(f *zip.File) ([]*importEntry, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open entries.csv: %w", err)
+	}
+	defer r.Close()
+
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entries.csv header: %w", err)
+	}
+	expected := []string{"id", "title", "description", "locations", "tags", "createdAt", "updatedAt"}
+	if strings.Join(header, ",") != strings.Join(expected, ",") {
+		return nil, fmt.Errorf("entries.csv has unexpected columns: %s", strings.Join(header, ","))
+	}
+
+	var entries []*importEntry
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read entries.csv line %d: %w", line, err)
+		}
+		e := &importEntry{ID: record[0], Title: record[1], Description: record[2]}
+		if record[3] != "" && record[3] != "null" {
+			if err := json.Unmarshal([]byte(record[3]), &e.Locations); err != nil {
+				return nil, fmt.Errorf("invalid locations on entries.csv line %d: %w", line, err)
+			}
+		}
+		if record[4] != "" && record[4] != "null" {
+			if err := json.Unmarshal([]byte(record[4]), &e.Tags); err != nil {
+				return nil, fmt.Errorf("invalid tags on entries.csv line %d: %w", line, err)
+			}
+		}
+		if e.CreatedAt, err = time.Parse(time.RFC3339, record[5]); err != nil {
+			return nil, fmt.Errorf("invalid createdAt on entries.csv line %d: %w", line, err)
+		}
+		if e.UpdatedAt, err = time.Parse(time.RFC3339, record[6]); err != nil {
+			return nil, fmt.Errorf("invalid updatedAt on entries.csv line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func parseImportJSON(f *zip.File) ([]*importEntry, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open entries.json: %w", err)
+	}
+	defer r.Close()
+
+	var entries []*importEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("invalid entries.json: %w", err)
+	}
+	return entries, nil
+}
+
+// restoreEntry writes one entry with its tags, locations and media under a new id and
+// returns how many images, audio files and videos were imported
+func (h *AuthHandler) restoreEntry(ctx context.Context, uid string, entry *importEntry) (importedMedia, error) {
+	entryID := uuid.New().String()
+	createdAt := entry.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	updatedAt := entry.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = createdAt
+	}
+
+	// Copy media first so the rows only reference files that exist
+	imageURLs, err := h.importMediaFiles(ctx, entry.Images, "images", uid, entryID)
+	if err == nil {
+		var refs []string
+		refs, err = h.copyMediaRefs(ctx, entry.ImageRefs, "images", uid, entryID)
+		imageURLs = append(imageURLs, refs...)
+	}
+	if err != nil {
+		h.removeImportedMedia(ctx, uid, entryID)
+		return importedMedia{}, err
+	}
+	audioURLs, err := h.importMediaFiles(ctx, entry.Audio, "audio", uid, entryID)
+	if err == nil {
+		var refs []string
+		refs, err = h.copyMediaRefs(ctx, entry.AudioRefs, "audio", uid, entryID)
+		audioURLs = append(audioURLs, refs...)
+	}
+	if err != nil {
+		h.removeImportedMedia(ctx, uid, entryID)
+		return importedMedia{}, err
+	}
+	videoURLs, err := h.importMediaFiles(ctx, entry.Videos, "videos", uid, entryID)
+	if err != nil {
+		h.removeImportedMedia(ctx, uid, entryID)
+		return importedMedia{}, err
+	}
+	videos := pairVideoPosters(videoURLs)
+	refVideos, err := h.copyVideoRefs(ctx, entry.VideoRefs, uid, entryID)
+	if err != nil {
+		h.removeImportedMedia(ctx, uid, entryID)
+		return importedMedia{}, err
+	}
+	videos = append(videos, refVideos...)
+
+	if err := h.insertImportedEntry(ctx, uid, entryID, entry, createdAt, updatedAt, imageURLs, audioURLs, videos); err != nil {
+		h.removeImportedMedia(ctx, uid, entryID)
+		return importedMedia{}, err
+	}
+	return importedMedia{images: len(imageURLs), audio: len(audioURLs), videos: len(videos)}, nil
+}
+
+// pairVideoPosters splits the files from an export's videos directory into clips, each
+// with the poster stored next to it under the same name and a .jpg extension
+func pairVideoPosters(urls []string) []importedVideo {
+	stored := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		stored[url] = true
+	}
+	var videos []importedVideo
+	for _, url := range urls {
+		if videoMimeType(path.Ext(url)) == "image/jpeg" {
+			continue
+		}
+		v := importedVideo{url: url}
+		if poster := videoPosterURL(url); stored[poster] {
+			v.thumbnail = &poster
+		}
+		videos = append(videos, v)
+	}
+	return videos
+}
+
+func (h *AuthHandler) insertImportedEntry(ctx context.Context, uid, entryID string, entry *importEntry, createdAt, updatedAt time.Time, imageURLs, audioURLs []string, videos []importedVideo) error {
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cipher := newEntryCipher(h.postgres, h.entryKeys)
+	if err := cipher.lockUser(ctx, tx, uid); err != nil {
+		return err
+	}
+	text, err := cipher.seal(ctx, uid, entry.Title, entry.Description)
+	if err != nil {
+		return fmt.Errorf("encrypt entry: %w", err)
+	}
+
+	// Ids that aren't UUIDs can come from hand-edited CSV exports; they just aren't recorded
+	var importedFrom *string
+	if original, err := uuid.Parse(entry.ID); err == nil {
+		id := original.String()
+		importedFrom = &id
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO entries (id, user_uid, title, description, encrypted, imported_from, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, entryID, uid, text.title, text.description, text.encrypted, importedFrom, createdAt, updatedAt); err != nil {
+		return fmt.Errorf("insert entry: %w", err)
+	}
+
+	for _, tag := range entry.Tags {
+		key := strings.TrimSpace(tag.Key)
+		if key == "" {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO tags (entry_id, key, value, created_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (entry_id, key) DO UPDATE SET value = EXCLUDED.value
+		`, entryID, key, tag.Value, createdAt); err != nil {
+			return fmt.Errorf("insert tag: %w", err)
+		}
+	}
+
+	for _, l := range entry.Locations {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO locations (entry_id, latitude, longitude, address, city, state, zip, country, country_code, display_name, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`, entryID, l.Latitude, l.Longitude, l.Address, l.City, l.State, l.Zip, l.Country, l.CountryCode, l.DisplayName, createdAt); err != nil {
+			return fmt.Errorf("insert location: %w", err)
+		}
+	}
+
+	for i, url := range imageURLs {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO images (entry_id, url, upload_order, created_at)
+			VALUES ($1, $2, $3, $4)
+		`, entryID, url, i, createdAt); err != nil {
+			return fmt.Errorf("insert image: %w", err)
+		}
+	}
+
+	for i, url := range audioURLs {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO audio (entry_id, url, upload_order, created_at)
+			VALUES ($1, $2, $3, $4)
+		`, entryID, url, i, createdAt); err != nil {
+			return fmt.Errorf("insert audio: %w", err)
+		}
+	}
+
+	for i, v := range videos {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO videos (entry_id, url, mime_type, thumbnail_url, upload_order, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, entryID, v.url, videoMimeType(path.Ext(v.url)), v.thumbnail, i, createdAt); err != nil {
+			return fmt.Errorf("insert video: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// importMediaFiles stores files from the archive under <kind>/<uid>/<entryID>/ and
+// returns their URLs in archive order
+func (h *AuthHandler) importMediaFiles(ctx context.Context, files []*zip.File, kind, uid, entryID string) ([]string, error) {
+	var urls []string
+	for _, f := range files {
+		filename := path.Base(f.Name)
+		if filename == "." || filename == "/" || strings.HasPrefix(filename, ".") {
+			continue
+		}
+		if f.UncompressedSize64 > maxImportMediaBytes {
+			return nil, fmt.Errorf("%s exceeds the %d byte media limit", f.Name, maxImportMediaBytes)
+		}
+		if err := h.importZipFile(ctx, f, path.Join(kind, uid, entryID, filename)); err != nil {
+			return nil, fmt.Errorf("copy %s: %w", f.Name, err)
+		}
+		urls = append(urls, fmt.Sprintf("/%s/%s/%s/%s", kind, uid, entryID, filename))
+	}
+	return urls, nil
+}
+
+// importZipFile extracts f to a temporary file first, since the header size can lie and
+// storage needs the real size up front, then stores it under key
+func (h *AuthHandler) importZipFile(ctx context.Context, f *zip.File, key string) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	tmp, err := os.CreateTemp("", "journeyapp-import-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// Stop reading past the limit either way
+	n, err := io.Copy(tmp, io.LimitReader(r, maxImportMediaBytes+1))
+	if err != nil {
+		return err
+	}
+	if n > maxImportMediaBytes {
+		return fmt.Errorf("file exceeds the %d byte media limit", maxImportMediaBytes)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return h.media.Put(ctx, key, tmp, n, mediaContentType(key))
+}
+
+// removeImportedMedia deletes an entry's imported files; like the rest of the import it
+// runs on the job's context
+func (h *AuthHandler) removeImportedMedia(ctx context.Context, uid, entryID string) {
+	ctx = context.WithoutCancel(ctx)
+	_ = h.media.DeleteAll(ctx, path.Join("images", uid, entryID))
+	_ = h.media.DeleteAll(ctx, path.Join("audio", uid, entryID))
+	_ = h.media.DeleteAll(ctx, path.Join("videos", uid, entryID))
+}
+
+// existingEntryHashes returns the content hashes of every entry the user already has
+func (h *AuthHandler) existingEntryHashes(ctx context.Context, uid string) (map[string]bool, error) {
+	rows, err := h.postgres.Query(ctx, `SELECT title, COALESCE(description, ''), encrypted, created_at FROM entries WHERE user_uid = $1`, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cipher := newEntryCipher(h.postgres, h.entryKeys)
+	hashes := map[string]bool{}
+	for rows.Next() {
+		var title, description string
+		var encrypted bool
+		var createdAt time.Time
+		if err := rows.Scan(&title, &description, &encrypted, &createdAt); err != nil {
+			return nil, err
+		}
+		if err := cipher.open(ctx, uid, encrypted, &title, &description); err != nil {
+			return nil, err
+		}
+		hashes[entryContentHash(title, description, createdAt)] = true
+	}
+	return hashes, rows.Err()
+}
+
+// entryContentHash identifies an entry by its text and creation time. Exports store
+// timestamps to the second, so the time is truncated to match.
+func entryContentHash(title, description string, createdAt time.Time) string {
+	sum := sha256.Sum256([]byte(title + "\x00" + description + "\x00" + strconv.FormatInt(createdAt.Unix(), 10)))
+	return hex.EncodeToString(sum[:])
+}
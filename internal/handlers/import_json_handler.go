@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/metrics"
+	accountmodels "io.winapps.journeyapp/internal/models/account"
+	importmodels "io.winapps.journeyapp/internal/models/import_data"
+	"io.winapps.journeyapp/internal/storage"
+)
+
+// maxImportJSONBytes caps a JSON import body. It only carries text and media references;
+// the referenced media is capped separately by maxImportZipBytes.
+const maxImportJSONBytes = 64 << 20
+
+// importJSON starts a job restoring a JSON export posted to ImportData. Media isn't in the
+// body: entries reference files in the user's own media storage, which are copied under
+// the new entries. Entries whose original id the account already has are skipped, so
+// posting the same export twice imports it once.
+func (h *AuthHandler) importJSON(c *gin.Context, uid string) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportJSONBytes)
+	var req importmodels.ImportDataJSONRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if isBodyTooLarge(err) {
+			respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Import file is too large")
+			return
+		}
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	entries, status, code, msg := importEntriesFromJSON(&req, uid)
+	if status != 0 {
+		respondError(c, status, code, msg)
+		return
+	}
+
+	// Don't start new jobs once shutdown has begun
+	if h.exportCtx.Err() != nil {
+		respondError(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, "Server is shutting down; try again shortly")
+		return
+	}
+
+	jobID := uuid.New().String()
+	st := ImportJobStatus{
+		JobID:     jobID,
+		UID:       uid,
+		Status:    "pending",
+		Format:    importFormatJSON,
+		StartedAt: time.Now(),
+	}
+	if err := h.saveImportStatus(context.Background(), st); err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to initialize import job")
+		return
+	}
+
+	metrics.ImportJobsTotal.WithLabelValues("started").Inc()
+	h.exportJobs.Add(1)
+	go func() {
+		defer h.exportJobs.Done()
+		h.runJSONImportJob(h.exportCtx, jobID, uid, entries)
+	}()
+
+	c.JSON(http.StatusAccepted, importmodels.ImportDataResponse{ImportJobID: jobID, Message: "Import started"})
+}
+
+// importEntriesFromJSON validates a JSON export for uid and converts its entries. It
+// returns a zero status when the export is acceptable, otherwise the HTTP status, code and
+// message, like validateImportZip.
+func importEntriesFromJSON(req *importmodels.ImportDataJSONRequest, uid string) ([]*importEntry, int, string, string) {
+	if req.UID != uid {
+		return nil, http.StatusForbidden, apierror.CodeForbidden, "Cannot import another user's export"
+	}
+	if len(req.Entries) == 0 {
+		return nil, http.StatusBadRequest, apierror.CodeValidation, "Export contains no entries"
+	}
+	if len(req.Entries) > maxImportEntries {
+		return nil, http.StatusBadRequest, apierror.CodeValidation, fmt.Sprintf("Export contains more than %d entries", maxImportEntries)
+	}
+
+	seen := make(map[string]bool, len(req.Entries))
+	entries := make([]*importEntry, 0, len(req.Entries))
+	for _, e := range req.Entries {
+		original, err := uuid.Parse(e.ID)
+		if err != nil {
+			return nil, http.StatusBadRequest, apierror.CodeValidation, "Every entry needs the id it was exported with"
+		}
+		id := original.String()
+		if seen[id] {
+			return nil, http.StatusBadRequest, apierror.CodeValidation, "Duplicate entry id " + id
+		}
+		seen[id] = true
+
+		entry := &importEntry{
+			ID:          id,
+			Title:       e.Title,
+			Description: e.Description,
+			CreatedAt:   e.CreatedAt,
+			UpdatedAt:   e.UpdatedAt,
+			ImageRefs:   e.Images,
+			AudioRefs:   e.Audio,
+			VideoRefs:   e.Videos,
+		}
+		for _, t := range e.Tags {
+			entry.Tags = append(entry.Tags, exportTag{Key: t.Key, Value: t.Value})
+		}
+		for _, l := range e.Locations {
+			entry.Locations = append(entry.Locations, exportLocation{
+				Latitude:    l.Latitude,
+				Longitude:   l.Longitude,
+				Address:     l.Address,
+				City:        l.City,
+				State:       l.State,
+				Zip:         l.Zip,
+				Country:     l.Country,
+				CountryCode: l.CountryCode,
+				DisplayName: l.DisplayName,
+			})
+		}
+
+		// Only the user's own media can be referenced
+		type mediaRef struct{ url, kind string }
+		var refs []mediaRef
+		for _, url := range e.Images {
+			refs = append(refs, mediaRef{url, "images"})
+		}
+		for _, url := range e.Audio {
+			refs = append(refs, mediaRef{url, "audio"})
+		}
+		for _, v := range e.Videos {
+			refs = append(refs, mediaRef{v.URL, "videos"})
+			if v.ThumbnailURL != "" {
+				refs = append(refs, mediaRef{v.ThumbnailURL, "videos"})
+			}
+		}
+		for _, ref := range refs {
+			if _, err := mediaKeyFromURL(ref.url, ref.kind, uid, ""); err != nil {
+				if errors.Is(err, errForeignMedia) {
+					return nil, http.StatusForbidden, apierror.CodeForbidden, "Cannot import another user's media"
+				}
+				return nil, http.StatusBadRequest, apierror.CodeValidation, "Export contains an invalid media reference"
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+	return entries, 0, "", ""
+}
+
+// runJSONImportJob checks the referenced media exists and fits the size cap, then
+// restores the entries like runImportJob
+func (h *AuthHandler) runJSONImportJob(ctx context.Context, jobID, uid string, entries []*importEntry) {
+	st, err := h.loadImportStatus(ctx, jobID)
+	if err != nil {
+		return
+	}
+	st.Status = "running"
+	_ = h.saveImportStatus(ctx, *st)
+	defer h.finishImportJob(ctx, uid, st)
+
+	missing, err := h.checkMediaRefs(ctx, uid, entries)
+	if err != nil {
+		st.Status = "failed"
+		st.Error = err.Error()
+		return
+	}
+	st.MissingMedia = missing
+
+	h.restoreEntries(ctx, jobID, uid, st, entries)
+}
+
+// checkMediaRefs drops references to files that are no longer in storage, returning how
+// many were dropped, and fails when the rest add up to more than maxImportZipBytes
+func (h *AuthHandler) checkMediaRefs(ctx context.Context, uid string, entries []*importEntry) (int, error) {
+	var total int64
+	missing := 0
+	exists := func(url, kind string) (bool, error) {
+		key, err := mediaKeyFromURL(url, kind, uid, "")
+		if err != nil {
+			return false, err
+		}
+		info, err := h.media.Stat(ctx, key)
+		if errors.Is(err, storage.ErrNotExist) {
+			missing++
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to check %s: %w", url, err)
+		}
+		total += info.Size
+		return true, nil
+	}
+	keep := func(urls []string, kind string) ([]string, error) {
+		kept := urls[:0]
+		for _, url := range urls {
+			ok, err := exists(url, kind)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				kept = append(kept, url)
+			}
+		}
+		return kept, nil
+	}
+
+	for _, e := range entries {
+		var err error
+		if e.ImageRefs, err = keep(e.ImageRefs, "images"); err != nil {
+			return 0, err
+		}
+		if e.AudioRefs, err = keep(e.AudioRefs, "audio"); err != nil {
+			return 0, err
+		}
+		videos := e.VideoRefs[:0]
+		for _, v := range e.VideoRefs {
+			ok, err := exists(v.URL, "videos")
+			if err != nil {
+				return 0, err
+			}
+			if !ok {
+				continue
+			}
+			if v.ThumbnailURL != "" {
+				if ok, err = exists(v.ThumbnailURL, "videos"); err != nil {
+					return 0, err
+				} else if !ok {
+					v.ThumbnailURL = ""
+				}
+			}
+			videos = append(videos, v)
+		}
+		e.VideoRefs = videos
+
+		if total > maxImportZipBytes {
+			return 0, fmt.Errorf("referenced media exceeds the %d byte import limit", maxImportZipBytes)
+		}
+	}
+	return missing, nil
+}
+
+// copyMediaRefs copies referenced files under <kind>/<uid>/<entryID>/ and returns their
+// URLs in order
+func (h *AuthHandler) copyMediaRefs(ctx context.Context, refs []string, kind, uid, entryID string) ([]string, error) {
+	var urls []string
+	for _, ref := range refs {
+		url, err := h.copyMediaRef(ctx, ref, kind, uid, entryID)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return urls, nil
+}
+
+// copyVideoRefs copies referenced clips and their posters
+func (h *AuthHandler) copyVideoRefs(ctx context.Context, refs []accountmodels.Video, uid, entryID string) ([]importedVideo, error) {
+	var videos []importedVideo
+	for _, ref := range refs {
+		url, err := h.copyMediaRef(ctx, ref.URL, "videos", uid, entryID)
+		if err != nil {
+			return nil, err
+		}
+		v := importedVideo{url: url}
+		if ref.ThumbnailURL != "" {
+			thumbnail, err := h.copyMediaRef(ctx, ref.ThumbnailURL, "videos", uid, entryID)
+			if err != nil {
+				return nil, err
+			}
+			v.thumbnail = &thumbnail
+		}
+		videos = append(videos, v)
+	}
+	return videos, nil
+}
+
+func (h *AuthHandler) copyMediaRef(ctx context.Context, ref, kind, uid, entryID string) (string, error) {
+	src, err := mediaKeyFromURL(ref, kind, uid, "")
+	if err != nil {
+		return "", err
+	}
+	filename := path.Base(src)
+	if err := h.media.Copy(ctx, src, path.Join(kind, uid, entryID, filename)); err != nil {
+		return "", fmt.Errorf("copy %s: %w", ref, err)
+	}
+	return fmt.Sprintf("/%s/%s/%s/%s", kind, uid, entryID, filename), nil
+}
+
+// restoredEntryIDs returns the ids of the user's entries and of the exported entries they
+// were imported from, which a JSON import skips
+func (h *AuthHandler) restoredEntryIDs(ctx context.Context, uid string) (map[string]bool, error) {
+	rows, err := h.postgres.Query(ctx, `
+		SELECT id::text, COALESCE(imported_from::text, '') FROM entries WHERE user_uid = $1
+	`, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := map[string]bool{}
+	for rows.Next() {
+		var id, importedFrom string
+		if err := rows.Scan(&id, &importedFrom); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+		if importedFrom != "" {
+			ids[importedFrom] = true
+		}
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	models "io.winapps.journeyapp/internal/models/account"
+	listentriesmodels "io.winapps.journeyapp/internal/models/list_entries"
+	searchmodels "io.winapps.journeyapp/internal/models/search_entries"
+)
+
+const (
+	defaultListEntriesLimit = 20
+	maxListEntriesLimit     = 100
+)
+
+// ListEntries returns the authenticated user's entries newest-first. It takes only
+// page, limit and includeDrafts query parameters, so clients don't need to build a
+// SearchEntries body. Drafts and scheduled entries are left out unless includeDrafts=true.
+func (h *EntryHandler) ListEntries(c *gin.Context) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	page := 1
+	if v := c.Query("page"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 1 {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "page must be a positive integer")
+			return
+		}
+		page = p
+	}
+	limit := defaultListEntriesLimit
+	if v := c.Query("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l < 1 || l > maxListEntriesLimit {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "limit must be between 1 and 100")
+			return
+		}
+		limit = l
+	}
+	includeDrafts, _ := strconv.ParseBool(c.Query("includeDrafts"))
+
+	ctx := c.Request.Context()
+
+	var total int
+	if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM entries WHERE user_uid = $1 AND ($2 OR status = 'published')`, userUID, includeDrafts).Scan(&total); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "count entries failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list entries")
+		return
+	}
+
+	rows, err := h.postgres.Query(ctx, `
+		SELECT id, title, COALESCE(description, ''), encrypted, visibility, status, publish_at, created_at, updated_at
+		FROM entries
+		WHERE user_uid = $1 AND ($4 OR status = 'published')
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3
+	`, userUID, limit, (page-1)*limit, includeDrafts)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "list entries failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list entries")
+		return
+	}
+	defer rows.Close()
+
+	cipher := newEntryCipher(h.postgres, h.entryKeys)
+	entryIDs := []string{}
+	entryMap := make(map[string]*searchmodels.EntryResult)
+	for rows.Next() {
+		var entry searchmodels.EntryResult
+		if err := rows.Scan(&entry.ID, &entry.Title, &entry.Description, &entry.Encrypted, &entry.Visibility, &entry.Status, &entry.PublishAt, &entry.CreatedAt, &entry.UpdatedAt); err != nil {
+			h.logError(c, err, "scan entry failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list entries")
+			return
+		}
+		if err := cipher.open(ctx, userUID, entry.Encrypted, &entry.Title, &entry.Description); err != nil {
+			h.logError(c, err, "decrypt entry failed", "entryId", entry.ID)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list entries")
+			return
+		}
+		entry.Images = []string{}
+		entry.Audio = []string{}
+		entry.Videos = []models.Video{}
+		entry.Tags = []models.Tag{}
+		entry.Locations = []models.Location{}
+
+		entryIDs = append(entryIDs, entry.ID)
+		entryMap[entry.ID] = &entry
+	}
+	if err := rows.Err(); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "list entries failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list entries")
+		return
+	}
+	rows.Close()
+
+	if len(entryIDs) > 0 {
+		if err := h.fetchRelatedDataForEntries(ctx, entryIDs, entryMap); err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "fetch entry details failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list entries")
+			return
+		}
+	}
+
+	entries := make([]searchmodels.EntryResult, 0, len(entryIDs))
+	for _, id := range entryIDs {
+		entries = append(entries, *entryMap[id])
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	c.JSON(http.StatusOK, listentriesmodels.ListEntriesResponse{
+		Entries: entries,
+		Pagination: searchmodels.Pagination{
+			Page:        page,
+			Limit:       limit,
+			Total:       total,
+			TotalPages:  totalPages,
+			HasNext:     page < totalPages,
+			HasPrevious: page > 1,
+		},
+	})
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,6 +8,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"io.winapps.journeyapp/internal/apierror"
 	accountmodels "io.winapps.journeyapp/internal/models/account"
 	listfeedsmodels "io.winapps.journeyapp/internal/models/list-feeds"
 )
@@ -18,7 +18,7 @@ func (h *UsersHandler) ListFeeds(c *gin.Context) {
 	// Ensure request is authenticated (middleware sets uid)
 	_, authed := c.Get("uid")
 	if !authed {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
@@ -32,18 +32,18 @@ func (h *UsersHandler) ListFeeds(c *gin.Context) {
 		}
 	}
 	if targetUID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "uid is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "uid is required")
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
 	cacheKey := fmt.Sprintf("feeds:%s", targetUID)
 
-	// Try Redis cache first
-	if cached, err := h.redis.Get(ctx, cacheKey).Result(); err == nil && cached != "" {
-		var cachedResponse listfeedsmodels.ListFeedsResponse
-		if err := json.Unmarshal([]byte(cached), &cachedResponse); err == nil {
-			c.JSON(http.StatusOK, cachedResponse)
+	// Try Redis cache first; it is only used while no friend has written since it was built
+	if cached, err := h.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+		var cachedResponse cachedFeeds
+		if err := json.Unmarshal([]byte(cached), &cachedResponse); err == nil && cachedResponse.fresh(ctx, h.cache) {
+			c.JSON(http.StatusOK, cachedResponse.Response)
 			return
 		}
 	}
@@ -53,11 +53,19 @@ func (h *UsersHandler) ListFeeds(c *gin.Context) {
 		SELECT DISTINCT CASE WHEN f.uid = $1 THEN f.fid ELSE f.uid END AS friend_uid
 		FROM friendships f
 		WHERE (f.uid = $1 OR f.fid = $1) AND f.status = 'approved'
+			AND NOT EXISTS (
+				SELECT 1 FROM friendships b
+				WHERE b.status = 'blocked'
+					AND ((b.uid = f.uid AND b.fid = f.fid) OR (b.uid = f.fid AND b.fid = f.uid))
+			)
 	`
 
 	friendRows, err := h.postgres.Query(ctx, friendsQuery, targetUID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list feeds"})
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list feeds")
 		return
 	}
 	defer friendRows.Close()
@@ -67,7 +75,7 @@ func (h *UsersHandler) ListFeeds(c *gin.Context) {
 	for friendRows.Next() {
 		var uid string
 		if err := friendRows.Scan(&uid); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read friends"})
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read friends")
 			return
 		}
 		if !friendUIDSeen[uid] {
@@ -80,13 +88,17 @@ func (h *UsersHandler) ListFeeds(c *gin.Context) {
 	if len(friendUIDs) == 0 {
 		response := listfeedsmodels.ListFeedsResponse{Feeds: []listfeedsmodels.ListFeedResult{}}
 		// Cache empty result briefly
-		if data, err := json.Marshal(response); err == nil {
-			_ = h.redis.Set(ctx, cacheKey, data, 5*time.Minute).Err()
+		if data, err := json.Marshal(cachedFeeds{Versions: map[string]string{}, Response: response}); err == nil {
+			_ = h.cache.Set(ctx, cacheKey, data, feedCacheTTL)
 		}
 		c.JSON(http.StatusOK, response)
 		return
 	}
 
+	// Read versions before the entries, so a write that lands mid-query leaves the cached
+	// feed stale rather than missing the write
+	versions := feedVersions(ctx, h.cache, friendUIDs)
+
 	// 2) Fetch entries for all friends that are visible to target user
 	placeholders := make([]string, len(friendUIDs))
 	args := make([]interface{}, 0, 1+len(friendUIDs))
@@ -97,9 +109,11 @@ func (h *UsersHandler) ListFeeds(c *gin.Context) {
 	}
 
 	entriesQuery := fmt.Sprintf(`
-		SELECT e.id, e.title, e.description, e.visibility, e.created_at, e.updated_at, e.user_uid
+		SELECT e.id, e.title, e.description, e.encrypted, e.visibility, e.created_at, e.updated_at, e.user_uid
 		FROM entries e
 		WHERE e.user_uid IN (%s)
+			AND e.status = 'published'
+			AND NOT e.moderation_hidden
 			AND (
 				e.visibility = 'public'
 				OR (
@@ -115,45 +129,61 @@ func (h *UsersHandler) ListFeeds(c *gin.Context) {
 
 	rows, err := h.postgres.Query(ctx, entriesQuery, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query feeds"})
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to query feeds")
 		return
 	}
 	defer rows.Close()
 
 	// Prepare maps for grouping and related data hydration
-	friendToEntries := make(map[string][]accountmodels.Entry)
-	entryMap := make(map[string]*accountmodels.Entry)
+	friendToEntries := make(map[string][]*listfeedsmodels.FeedEntry)
+	entryMap := make(map[string]*listfeedsmodels.FeedEntry)
 	entryIDs := make([]string, 0)
 
+	// Friends' encrypted entries are decrypted for the feed, which is then kept out of Redis
+	cipher := newEntryCipher(h.postgres, h.entryKeys)
+	hasEncrypted := false
+
 	for rows.Next() {
 		var (
 			id string
 			title string
 			description string
+			encrypted bool
 			visibility string
 			createdAt time.Time
 			updatedAt time.Time
 			ownerUID string
 		)
-		if err := rows.Scan(&id, &title, &description, &visibility, &createdAt, &updatedAt, &ownerUID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read entries"})
+		if err := rows.Scan(&id, &title, &description, &encrypted, &visibility, &createdAt, &updatedAt, &ownerUID); err != nil {
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read entries")
 			return
 		}
+		if err := cipher.open(ctx, ownerUID, encrypted, &title, &description); err != nil {
+			h.logError(c, err, "decrypt feed entry failed", "entryId", id)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read entries")
+			return
+		}
+		hasEncrypted = hasEncrypted || encrypted
 
-		entry := accountmodels.Entry{
+		entry := &listfeedsmodels.FeedEntry{Entry: &accountmodels.Entry{
 			ID:         id,
 			Title:      title,
 			Description: description,
 			Images:     []string{},
 			Audio:      []string{},
+			Videos:     []accountmodels.Video{},
 			Tags:       []accountmodels.Tag{},
 			Locations:  []accountmodels.Location{},
 			Visibility: visibility,
 			CreatedAt:  createdAt,
 			UpdatedAt:  updatedAt,
-		}
+		}}
 
-		entryMap[id] = &entry
+		// Both maps share the same pointer so hydration below is reflected in the feed
+		entryMap[id] = entry
 		entryIDs = append(entryIDs, id)
 		friendToEntries[ownerUID] = append(friendToEntries[ownerUID], entry)
 	}
@@ -176,7 +206,10 @@ func (h *UsersHandler) ListFeeds(c *gin.Context) {
 		`, inClause)
 		tagRows, err := h.postgres.Query(ctx, tagsQuery, idArgs...)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+			if abortOnContextError(c, err) {
+				return
+			}
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch tags")
 			return
 		}
 		for tagRows.Next() {
@@ -184,7 +217,7 @@ func (h *UsersHandler) ListFeeds(c *gin.Context) {
 			var tag accountmodels.Tag
 			if err := tagRows.Scan(&entryID, &tag.Key, &tag.Value); err != nil {
 				tagRows.Close()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read tags"})
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read tags")
 				return
 			}
 			if e := entryMap[entryID]; e != nil {
@@ -202,7 +235,10 @@ func (h *UsersHandler) ListFeeds(c *gin.Context) {
 		`, inClause)
 		locationRows, err := h.postgres.Query(ctx, locationsQuery, idArgs...)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch locations"})
+			if abortOnContextError(c, err) {
+				return
+			}
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch locations")
 			return
 		}
 		for locationRows.Next() {
@@ -221,7 +257,7 @@ func (h *UsersHandler) ListFeeds(c *gin.Context) {
 				&loc.DisplayName,
 			); err != nil {
 				locationRows.Close()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read locations"})
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read locations")
 				return
 			}
 			if e := entryMap[entryID]; e != nil {
@@ -238,14 +274,17 @@ func (h *UsersHandler) ListFeeds(c *gin.Context) {
 		`, inClause)
 		imageRows, err := h.postgres.Query(ctx, imagesQuery, idArgs...)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch images"})
+			if abortOnContextError(c, err) {
+				return
+			}
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch images")
 			return
 		}
 		for imageRows.Next() {
 			var entryID, url string
 			if err := imageRows.Scan(&entryID, &url); err != nil {
 				imageRows.Close()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read images"})
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read images")
 				return
 			}
 			if e := entryMap[entryID]; e != nil {
@@ -262,14 +301,17 @@ func (h *UsersHandler) ListFeeds(c *gin.Context) {
 		`, inClause)
 		audioRows, err := h.postgres.Query(ctx, audioQuery, idArgs...)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audio"})
+			if abortOnContextError(c, err) {
+				return
+			}
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch audio")
 			return
 		}
 		for audioRows.Next() {
 			var entryID, url string
 			if err := audioRows.Scan(&entryID, &url); err != nil {
 				audioRows.Close()
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read audio"})
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read audio")
 				return
 			}
 			if e := entryMap[entryID]; e != nil {
@@ -277,29 +319,78 @@ func (h *UsersHandler) ListFeeds(c *gin.Context) {
 			}
 		}
 		audioRows.Close()
+
+		// Videos
+		videoQuery := fmt.Sprintf(`
+			SELECT entry_id, url, COALESCE(thumbnail_url, ''), COALESCE(duration, 0) FROM videos
+			WHERE entry_id IN (%s)
+			ORDER BY entry_id, upload_order
+		`, inClause)
+		videoRows, err := h.postgres.Query(ctx, videoQuery, idArgs...)
+		if err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch videos")
+			return
+		}
+		for videoRows.Next() {
+			var entryID string
+			var video accountmodels.Video
+			if err := videoRows.Scan(&entryID, &video.URL, &video.ThumbnailURL, &video.Duration); err != nil {
+				videoRows.Close()
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read videos")
+				return
+			}
+			if e := entryMap[entryID]; e != nil {
+				e.Videos = append(e.Videos, video)
+			}
+		}
+		videoRows.Close()
+
+		// Comment counts
+		commentCountsQuery := fmt.Sprintf(`
+			SELECT entry_id, COUNT(*) FROM entry_comments
+			WHERE entry_id IN (%s)
+			GROUP BY entry_id
+		`, inClause)
+		commentRows, err := h.postgres.Query(ctx, commentCountsQuery, idArgs...)
+		if err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch comment counts")
+			return
+		}
+		for commentRows.Next() {
+			var entryID string
+			var count int
+			if err := commentRows.Scan(&entryID, &count); err != nil {
+				commentRows.Close()
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read comment counts")
+				return
+			}
+			if e := entryMap[entryID]; e != nil {
+				e.CommentCount = count
+			}
+		}
+		commentRows.Close()
 	}
 
 	// 4) Build response grouped by friend UID
 	feeds := make([]listfeedsmodels.ListFeedResult, 0, len(friendUIDs))
 	for _, fuid := range friendUIDs {
-		entries := friendToEntries[fuid]
-		// Ensure entries reflect hydrated data from pointers
-		for i := range entries {
-			if e := entryMap[entries[i].ID]; e != nil {
-				entries[i] = *e
-			}
-		}
 		feeds = append(feeds, listfeedsmodels.ListFeedResult{
 			UID:     fuid,
-			Entries: entries,
+			Entries: friendToEntries[fuid],
 		})
 	}
 
 	response := listfeedsmodels.ListFeedsResponse{Feeds: feeds}
 
 	// Cache for a short period
-	if data, err := json.Marshal(response); err == nil {
-		_ = h.redis.Set(ctx, cacheKey, data, 5*time.Minute).Err()
+	if data, err := json.Marshal(cachedFeeds{Versions: versions, Response: response}); err == nil && !hasEncrypted {
+		_ = h.cache.Set(ctx, cacheKey, data, feedCacheTTL)
 	}
 
 	c.JSON(http.StatusOK, response)
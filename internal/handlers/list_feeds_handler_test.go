@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"io.winapps.journeyapp/internal/cache"
+	listfeedsmodels "io.winapps.journeyapp/internal/models/list-feeds"
+)
+
+// Each feed entry keeps its own tags and images, across friends and across the public and
+// shared entries of one friend
+func TestListFeedsHydratesEachEntry(t *testing.T) {
+	pool := testPool(t)
+	h := NewUsersHandler(nil, pool, cache.NewMemory(100), nil)
+	viewer := testUser(t, pool, "viewer")
+	alice := testUser(t, pool, "alice")
+	bob := testUser(t, pool, "bob")
+	testFriends(t, pool, viewer, alice)
+	testFriends(t, pool, bob, viewer)
+
+	type want struct {
+		owner, tag, image string
+	}
+	seed := func(owner, title, visibility, tag, image string) string {
+		id := testEntry(t, pool, owner, title, visibility)
+		mustExec(t, pool, `INSERT INTO tags (entry_id, key, value) VALUES ($1, $2, '')`, id, tag)
+		mustExec(t, pool, `INSERT INTO images (entry_id, url, upload_order) VALUES ($1, $2, 0)`, id, image)
+		return id
+	}
+	wants := map[string]want{}
+	for _, e := range []struct{ owner, title, visibility, tag, image string }{
+		{alice, "Alice public", "public", "alice-public", "/images/alice/1.jpg"},
+		{alice, "Alice shared", "semi-private", "alice-shared", "/images/alice/2.jpg"},
+		{bob, "Bob public", "public", "bob-public", "/images/bob/1.jpg"},
+	} {
+		id := seed(e.owner, e.title, e.visibility, e.tag, e.image)
+		if e.visibility == "semi-private" {
+			mustExec(t, pool, `INSERT INTO entry_shares (entry_id, shared_user_uid) VALUES ($1, $2)`, id, viewer)
+		}
+		wants[id] = want{e.owner, e.tag, e.image}
+	}
+	seed(alice, "Alice private", "private", "alice-private", "/images/alice/3.jpg")
+
+	w := callAs(viewer, h.ListFeeds, http.MethodGet, "/list-feeds", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("ListFeeds: %d %s", w.Code, w.Body.String())
+	}
+	var resp listfeedsmodels.ListFeedsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := 0
+	for _, feed := range resp.Feeds {
+		for _, e := range feed.Entries {
+			want, ok := wants[e.ID]
+			if !ok {
+				t.Errorf("unexpected entry %q in %s's feed", e.Title, feed.UID)
+				continue
+			}
+			seen++
+			if feed.UID != want.owner {
+				t.Errorf("%q listed under %s, want %s", e.Title, feed.UID, want.owner)
+			}
+			if len(e.Tags) != 1 || e.Tags[0].Key != want.tag {
+				t.Errorf("%q tags = %+v, want only %s", e.Title, e.Tags, want.tag)
+			}
+			if len(e.Images) != 1 || e.Images[0] != want.image {
+				t.Errorf("%q images = %v, want only %s", e.Title, e.Images, want.image)
+			}
+		}
+	}
+	if seen != len(wants) || len(resp.Feeds) != 2 {
+		t.Errorf("got %d entries in %d feeds, want %d in 2", seen, len(resp.Feeds), len(wants))
+	}
+}
@@ -5,19 +5,26 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"io.winapps.journeyapp/internal/apierror"
 	listfriendsmodels "io.winapps.journeyapp/internal/models/list-friends"
 )
 
-// ListFriends returns user profiles for all friends of the given uid
+const (
+	defaultFriendsLimit = 50
+	maxFriendsLimit     = 200
+)
+
+// ListFriends returns a page of user profiles for friends of the given uid
 func (h *UsersHandler) ListFriends(c *gin.Context) {
 	// Ensure request is authenticated (middleware sets uid)
 	_, authed := c.Get("uid")
 	if !authed {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
@@ -31,7 +38,7 @@ func (h *UsersHandler) ListFriends(c *gin.Context) {
 		}
 	}
 	if targetUID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "uid is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "uid is required")
 		return
 	}
 
@@ -51,22 +58,44 @@ func (h *UsersHandler) ListFriends(c *gin.Context) {
 		statuses = []string{"pending", "approved", "rejected", "blocked"}
 	default:
 		if !allowedStatuses[statusParam] {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "invalid status")
 			return
 		}
 		statuses = []string{statusParam}
 	}
 
-	ctx := context.Background()
-	cacheKey := fmt.Sprintf("friends:%s:%s", targetUID, func() string {
+	limit := defaultFriendsLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "limit must be a positive integer")
+			return
+		}
+		if n > maxFriendsLimit {
+			n = maxFriendsLimit
+		}
+		limit = n
+	}
+	offset := 0
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "offset must be a non-negative integer")
+			return
+		}
+		offset = n
+	}
+
+	ctx := c.Request.Context()
+	cacheKey := fmt.Sprintf("friends:%s:%s:%d:%d", targetUID, func() string {
 		if statusParam == "" {
 			return "default"
 		}
 		return statusParam
-	}())
+	}(), limit, offset)
 
 	// Try Redis cache first
-	if cached, err := h.redis.Get(ctx, cacheKey).Result(); err == nil && cached != "" {
+	if cached, err := h.cache.Get(ctx, cacheKey); err == nil && cached != "" {
 		var cachedResponse listfriendsmodels.ListFriendsResponse
 		if err := json.Unmarshal([]byte(cached), &cachedResponse); err == nil {
 			c.JSON(http.StatusOK, cachedResponse)
@@ -76,34 +105,47 @@ func (h *UsersHandler) ListFriends(c *gin.Context) {
 
 	// Build IN clause for statuses
 	placeholders := make([]string, len(statuses))
-	args := make([]interface{}, 0, 1+len(statuses))
+	args := make([]interface{}, 0, 3+len(statuses))
 	args = append(args, targetUID)
 	for i, s := range statuses {
 		placeholders[i] = fmt.Sprintf("$%d", i+2)
 		args = append(args, s)
 	}
+	limitArg := len(args) + 1
+	args = append(args, limit, offset)
 
 	query := fmt.Sprintf(`
-		SELECT u.uid, u.display_name, u.email, u.photo_url, f.status, f.created_at
+		SELECT u.uid, u.display_name, u.email, u.photo_url, f.status, f.created_at,
+		       COALESCE(u.is_premium, FALSE),
+		       (SELECT COUNT(*) FROM entries e WHERE e.user_uid = u.uid) AS entry_count,
+		       COUNT(*) OVER() AS total
 		FROM friendships f
 		JOIN users u ON u.uid = CASE WHEN f.uid = $1 THEN f.fid ELSE f.uid END
 		WHERE (f.uid = $1 OR f.fid = $1) AND f.status IN (%s)
-		ORDER BY u.display_name
-	`, strings.Join(placeholders, ","))
+			AND NOT (f.status = 'blocked' AND f.fid = $1)
+		ORDER BY u.display_name, u.uid
+		LIMIT $%d OFFSET $%d
+	`, strings.Join(placeholders, ","), limitArg, limitArg+1)
 
 	rows, err := h.postgres.Query(ctx, query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list friends"})
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list friends")
 		return
 	}
 	defer rows.Close()
 
 	friends := make([]listfriendsmodels.ListFriend, 0)
+	total := 0
 	for rows.Next() {
 		var uid, displayName, email, photoURL, status string
 		var createdAt time.Time
-		if err := rows.Scan(&uid, &displayName, &email, &photoURL, &status, &createdAt); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read results"})
+		var isPremium bool
+		var entryCount int
+		if err := rows.Scan(&uid, &displayName, &email, &photoURL, &status, &createdAt, &isPremium, &entryCount, &total); err != nil {
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read results")
 			return
 		}
 		friends = append(friends, listfriendsmodels.ListFriend{
@@ -112,18 +154,49 @@ func (h *UsersHandler) ListFriends(c *gin.Context) {
 			Email:       email,
 			PhotoURL:    photoURL,
 			Status:      status,
+			IsPremium:   isPremium,
+			EntryCount:  entryCount,
 			CreatedAt:   createdAt,
 		})
 	}
 
+	// An offset past the end returns no rows, so count separately to report the real total
+	if len(friends) == 0 && offset > 0 {
+		countQuery := fmt.Sprintf(`
+			SELECT COUNT(*) FROM friendships f
+			WHERE (f.uid = $1 OR f.fid = $1) AND f.status IN (%s)
+				AND NOT (f.status = 'blocked' AND f.fid = $1)
+		`, strings.Join(placeholders, ","))
+		if err := h.postgres.QueryRow(ctx, countQuery, args[:limitArg-1]...).Scan(&total); err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to count friends")
+			return
+		}
+	}
+
 	response := listfriendsmodels.ListFriendsResponse{
 		Friends: friends,
+		Pagination: listfriendsmodels.Pagination{
+			Limit:   limit,
+			Offset:  offset,
+			Total:   total,
+			HasMore: offset+len(friends) < total,
+		},
 	}
 
 	// Cache for a short period
 	if data, err := json.Marshal(response); err == nil {
-		_ = h.redis.Set(ctx, cacheKey, data, 5*time.Minute).Err()
+		_ = h.cache.Set(ctx, cacheKey, data, 5*time.Minute)
 	}
 
 	c.JSON(http.StatusOK, response)
 }
+
+// invalidateFriendsCache clears every cached ListFriends page and the GetFriendRequests
+// inbox for uid
+func (h *UsersHandler) invalidateFriendsCache(ctx context.Context, uid string) {
+	keys, _ := h.cache.Keys(ctx, fmt.Sprintf("friends:%s:*", uid))
+	_ = h.cache.Del(ctx, keys...)
+}
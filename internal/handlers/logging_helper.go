@@ -56,4 +56,9 @@ func (h *NotificationsHandler) logError(c *gin.Context, err error, msg string, f
 	logWithContext(h.logger, c, "error", msg, append(fields, "error", err)...)
 }
 
+func (h *WebhooksHandler) logError(c *gin.Context, err error, msg string, fields ...interface{}) {
+	if h.logger == nil { return }
+	logWithContext(h.logger, c, "error", msg, append(fields, "error", err)...)
+}
+
 
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	stream "github.com/GetStream/stream-chat-go/v5"
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	firebaseutil "io.winapps.journeyapp/internal/firebase"
+	"io.winapps.journeyapp/internal/middleware"
+	loginmodels "io.winapps.journeyapp/internal/models/login"
+)
+
+// Login exchanges a Firebase ID token for a server session token. The Firebase Admin SDK
+// cannot verify passwords, so clients must sign in with Firebase Auth first and send the
+// resulting ID token.
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req loginmodels.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	if req.IDToken == "" {
+		if req.Password != "" {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Email/password login is not supported by the server; sign in with Firebase Auth on the client and send the ID token")
+			return
+		}
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "idToken is required")
+		return
+	}
+
+	ctx := c.Request.Context()
+	idToken, err := h.verifySignInToken(ctx, req.IDToken)
+	if err != nil {
+		respondIDTokenError(c, err)
+		return
+	}
+
+	if err := h.syncEmailVerified(ctx, idToken); err != nil {
+		h.logError(c, err, "Failed to sync email verification", "uid", idToken.UID)
+	}
+
+	user, err := h.getUserFromDatabase(ctx, idToken.UID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "User not found; create an account first")
+		return
+	}
+
+	sessionToken, expiresAt, err := h.issueSessionToken(ctx, user)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "Failed to issue session token", "uid", user.UID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create session")
+		return
+	}
+
+	response := loginmodels.LoginResponse{
+		Success:     true,
+		Message:     "Logged in successfully",
+		UID:         user.UID,
+		DisplayName: user.DisplayName,
+		Email:       user.Email,
+		PhotoURL:    user.PhotoURL,
+		Token:       sessionToken,
+		ExpiresAt:   expiresAt,
+	}
+
+	// A chat token is a convenience for the client; login still succeeds without one
+	if client, err := stream.NewClient(os.Getenv("STREAM_API_KEY"), os.Getenv("STREAM_API_SECRET")); err == nil {
+		if streamToken, err := client.CreateToken(user.UID, time.Time{}); err == nil {
+			response.StreamToken = streamToken
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// firebaseAuth is the part of the Firebase auth client used to sign users in and out
+type firebaseAuth interface {
+	VerifyIDTokenAndCheckRevoked(ctx context.Context, idToken string) (*auth.Token, error)
+	RevokeRefreshTokens(ctx context.Context, uid string) error
+}
+
+// firebaseAuthClient returns the client set on the handler, or the app's Firebase auth client
+func (h *AuthHandler) firebaseAuthClient() (firebaseAuth, error) {
+	if h.authClient != nil {
+		return h.authClient, nil
+	}
+	client, err := firebaseutil.GetAuthClient(h.firebaseApp)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+var (
+	errAuthUnavailable = errors.New("auth client unavailable")
+	errIDTokenRevoked  = errors.New("ID token revoked")
+)
+
+// verifySignInToken verifies a Firebase ID token exchanged for a session at login or
+// refresh. Besides the signature and expiry it applies the same revocation rules as
+// AuthMiddleware: the token must not have been revoked by Firebase, logged out, or
+// issued at or before the user's last sign-out-everywhere.
+func (h *AuthHandler) verifySignInToken(ctx context.Context, rawToken string) (*auth.Token, error) {
+	client, err := h.firebaseAuthClient()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errAuthUnavailable, err)
+	}
+
+	idToken, err := client.VerifyIDTokenAndCheckRevoked(ctx, rawToken)
+	if err != nil {
+		if auth.IsIDTokenRevoked(err) || auth.IsUserDisabled(err) {
+			return nil, errIDTokenRevoked
+		}
+		return nil, err
+	}
+	if middleware.IDTokenRevoked(ctx, h.cache, rawToken, idToken.UID, idToken.IssuedAt) {
+		return nil, errIDTokenRevoked
+	}
+	return idToken, nil
+}
+
+// respondIDTokenError writes the response for a sign-in token verifySignInToken rejected
+func respondIDTokenError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errAuthUnavailable):
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to initialize auth client")
+	case errors.Is(err, errIDTokenRevoked):
+		respondError(c, http.StatusUnauthorized, apierror.CodeTokenRevoked, "ID token has been revoked")
+	case auth.IsIDTokenExpired(err):
+		respondError(c, http.StatusUnauthorized, apierror.CodeTokenExpired, "ID token has expired")
+	default:
+		respondError(c, http.StatusUnauthorized, apierror.CodeTokenInvalid, "Invalid or expired ID token")
+	}
+}
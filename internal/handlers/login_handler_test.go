@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+
+	"io.winapps.journeyapp/internal/cache"
+	"io.winapps.journeyapp/internal/middleware"
+)
+
+// fakeFirebaseAuth accepts any ID token as uid's, issued at issuedAt
+type fakeFirebaseAuth struct {
+	uid      string
+	issuedAt int64
+	revoked  []string
+}
+
+func (f *fakeFirebaseAuth) VerifyIDTokenAndCheckRevoked(ctx context.Context, idToken string) (*auth.Token, error) {
+	return &auth.Token{UID: f.uid, IssuedAt: f.issuedAt}, nil
+}
+
+func (f *fakeFirebaseAuth) RevokeRefreshTokens(ctx context.Context, uid string) error {
+	f.revoked = append(f.revoked, uid)
+	return nil
+}
+
+// ID tokens that AuthMiddleware would reject can't be exchanged for a new session either
+func TestSignInRejectsRevokedIDTokens(t *testing.T) {
+	ctx := context.Background()
+	for _, route := range []string{"/login", "/refresh-token"} {
+		t.Run(route, func(t *testing.T) {
+			store := cache.NewMemory(100, middleware.DurableKeyPrefixes...)
+			fake := &fakeFirebaseAuth{uid: "alice", issuedAt: time.Now().Add(-time.Minute).Unix()}
+			h := &AuthHandler{cache: store, authClient: fake}
+			handler := h.Login
+			if route == "/refresh-token" {
+				handler = h.RefreshToken
+			}
+			post := func(idToken string) int {
+				w := callAs("", handler, http.MethodPost, route, `{"idToken":"`+idToken+`"}`)
+				if w.Code == http.StatusUnauthorized && !strings.Contains(w.Body.String(), `"TOKEN_REVOKED"`) {
+					t.Errorf("401 without TOKEN_REVOKED: %s", w.Body.String())
+				}
+				return w.Code
+			}
+
+			// Logged out with this token
+			_ = store.Set(ctx, middleware.RevokedTokenKey("logged-out"), "1", time.Hour)
+			if code := post("logged-out"); code != http.StatusUnauthorized {
+				t.Errorf("logged-out token: %d, want 401", code)
+			}
+
+			// Signed out everywhere after the token was issued
+			_ = store.Set(ctx, middleware.SessionsRevokedAtKey("alice"), strconv.FormatInt(time.Now().Unix(), 10), time.Hour)
+			if code := post("old"); code != http.StatusUnauthorized {
+				t.Errorf("token issued before sign-out-everywhere: %d, want 401", code)
+			}
+		})
+	}
+}
+
+// Revoking all sessions and then logging in again with the ID token from before fails
+func TestLoginAfterRevokeAllSessions(t *testing.T) {
+	pool := testPool(t)
+	alice := testUser(t, pool, "alice")
+	fake := &fakeFirebaseAuth{uid: alice, issuedAt: time.Now().Add(-time.Minute).Unix()}
+	h := &AuthHandler{postgres: pool, cache: cache.NewMemory(100, middleware.DurableKeyPrefixes...), authClient: fake}
+	login := `{"idToken":"before-revoke"}`
+
+	if w := callAs("", h.Login, http.MethodPost, "/login", login); w.Code != http.StatusOK {
+		t.Fatalf("login before revoking: %d %s", w.Code, w.Body.String())
+	}
+	if w := callAs(alice, h.RevokeAllSessions, http.MethodPost, "/revoke-all-sessions", ""); w.Code != http.StatusOK {
+		t.Fatalf("revoke all sessions: %d %s", w.Code, w.Body.String())
+	}
+	if len(fake.revoked) != 1 || fake.revoked[0] != alice {
+		t.Errorf("Firebase refresh tokens revoked for %v, want %s", fake.revoked, alice)
+	}
+	if w := callAs("", h.Login, http.MethodPost, "/login", login); w.Code != http.StatusUnauthorized {
+		t.Fatalf("login with the old ID token: %d %s, want 401", w.Code, w.Body.String())
+	}
+
+	// A token issued after the revocation works again
+	fake.issuedAt = time.Now().Add(time.Second).Unix()
+	if w := callAs("", h.Login, http.MethodPost, "/login", `{"idToken":"after-revoke"}`); w.Code != http.StatusOK {
+		t.Fatalf("login with a new ID token: %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxProfilePicBytes caps decoded profile pictures. Entry image and audio caps depend on
+// the user's tier and come from premium.Limits.
+const maxProfilePicBytes int64 = 5 << 20
+
+// errMediaTooLarge is returned by the save functions when a decoded payload exceeds its limit
+var errMediaTooLarge = errors.New("media exceeds the maximum allowed size")
+
+// errUnsupportedMediaType is returned by the save functions when the data isn't a supported
+// image or audio format
+var errUnsupportedMediaType = errors.New("unsupported media type")
+
+// checkBase64Size rejects a base64 payload whose decoded size would exceed limit,
+// before anything is decoded. Trailing '=' padding doesn't count towards the size.
+func checkBase64Size(payload string, limit int64) error {
+	padding := len(payload) - len(strings.TrimRight(payload, "="))
+	if int64(base64.StdEncoding.DecodedLen(len(payload))-padding) > limit {
+		return fmt.Errorf("%w (%d MB)", errMediaTooLarge, limit>>20)
+	}
+	return nil
+}
+
+// isBodyTooLarge reports whether err came from reading past a MaxBodySize limit
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
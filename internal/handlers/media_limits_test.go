@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/middleware"
+)
+
+func TestCheckBase64Size(t *testing.T) {
+	// 1022, 1023 and 1024 bytes end in each possible amount of padding
+	for _, size := range []int64{1022, 1023, 1024} {
+		payload := base64.StdEncoding.EncodeToString(make([]byte, size))
+		if err := checkBase64Size(payload, size); err != nil {
+			t.Errorf("%d bytes at the limit: %v", size, err)
+		}
+		if err := checkBase64Size(payload, size-1); !errors.Is(err, errMediaTooLarge) {
+			t.Errorf("%d bytes over the limit: err = %v, want errMediaTooLarge", size, err)
+		}
+	}
+}
+
+// Oversized bodies are rejected with 413 while the request is still being parsed,
+// before authentication or any database access
+func TestUploadRoutesReturn413PastTheBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &EntryHandler{}
+	router := gin.New()
+	router.Use(middleware.MaxBodySize(1024))
+	router.POST("/add-image", h.AddImage)
+	router.POST("/add-audio", h.AddAudio)
+
+	jsonBody := func() (string, *bytes.Buffer) {
+		return "application/json", bytes.NewBufferString(`{"entryId":"e1","image":"` + strings.Repeat("A", 2048) + `"}`)
+	}
+	multipartBody := func(field string) func() (string, *bytes.Buffer) {
+		return func() (string, *bytes.Buffer) {
+			var buf bytes.Buffer
+			mw := multipart.NewWriter(&buf)
+			_ = mw.WriteField("entryId", "e1")
+			part, _ := mw.CreateFormFile(field, "upload.bin")
+			_, _ = part.Write(make([]byte, 2048))
+			_ = mw.Close()
+			return mw.FormDataContentType(), &buf
+		}
+	}
+
+	tests := []struct {
+		path string
+		body func() (string, *bytes.Buffer)
+	}{
+		{"/add-image", jsonBody},
+		{"/add-audio", jsonBody},
+		{"/add-image", multipartBody("image")},
+		{"/add-audio", multipartBody("audio")},
+	}
+	for _, tt := range tests {
+		contentType, body := tt.body()
+		r := httptest.NewRequest(http.MethodPost, tt.path, body)
+		r.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != http.StatusRequestEntityTooLarge || !strings.Contains(w.Body.String(), `"PAYLOAD_TOO_LARGE"`) {
+			t.Errorf("%s (%s): got %d %s, want 413 PAYLOAD_TOO_LARGE", tt.path, contentType, w.Code, w.Body.String())
+		}
+	}
+}
+
+// Bogus and oversized profile pictures are refused before anything is written or sent
+// to Firebase
+func TestAddProfilePicRejectsBogusAndOversizedPayloads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Chdir(t.TempDir())
+	h := &AuthHandler{}
+	router := gin.New()
+	router.POST("/add-profile-pic", func(c *gin.Context) { c.Set("uid", "alice") }, h.AddProfilePic)
+
+	tests := []struct {
+		name     string
+		photo    string
+		wantCode int
+		wantErr  string
+	}{
+		{"bogus", base64.StdEncoding.EncodeToString([]byte("#!/bin/sh\necho not an image")), http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE"},
+		{"oversized", base64.StdEncoding.EncodeToString(make([]byte, maxProfilePicBytes+1)), http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE"},
+	}
+	for _, tt := range tests {
+		body := `{"isPhotoAttached":true,"photoURL":"data:image/png;base64,` + tt.photo + `"}`
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/add-profile-pic", strings.NewReader(body)))
+		if w.Code != tt.wantCode || !strings.Contains(w.Body.String(), `"`+tt.wantErr+`"`) {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, w.Code, w.Body.String(), tt.wantCode, tt.wantErr)
+		}
+	}
+	if _, err := os.Stat("internal"); !os.IsNotExist(err) {
+		t.Errorf("rejected uploads left files behind (stat err %v)", err)
+	}
+}
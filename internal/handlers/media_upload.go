@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"io.winapps.journeyapp/internal/storage"
+)
+
+// errInvalidMediaPath is returned when a media URL doesn't resolve to an object under its media root
+var errInvalidMediaPath = errors.New("invalid media path")
+
+// errForeignMedia is returned when a media URL points into another user's or entry's directory
+var errForeignMedia = errors.New("media belongs to another user or entry")
+
+// mediaKeyFromURL maps a URL like "/<kind>/<uid>/<dir>/<file>" to its storage key,
+// "<kind>/<uid>/<dir>/<file>", if it lies in the given uid's dir: an entry id, or
+// "profile". URLs come from clients (directly or via stored rows), so the key is cleaned
+// first, and anything outside <kind>/<uid>/<dir>/ is refused, so one user's request can't
+// read, copy or delete another's files. An empty dir accepts any directory of uid, for
+// references that aren't tied to one entry, such as those in an import.
+func mediaKeyFromURL(urlPath, kind, uid, dir string) (string, error) {
+	key, err := cleanMediaKey(urlPath, kind)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(key, "/")
+	if len(parts) != 4 {
+		return "", fmt.Errorf("%w: %s", errInvalidMediaPath, urlPath)
+	}
+	if uid == "" || parts[1] != uid || (dir != "" && parts[2] != dir) {
+		return "", fmt.Errorf("%w: %s", errForeignMedia, urlPath)
+	}
+	return key, nil
+}
+
+// cleanMediaKey maps a media URL to its storage key without checking whose it is. It is
+// only for comparing stored references with stored objects; anything acting on a file for
+// a user goes through mediaKeyFromURL.
+func cleanMediaKey(urlPath, kind string) (string, error) {
+	prefix := "/" + kind + "/"
+	if !strings.HasPrefix(urlPath, prefix) {
+		return "", fmt.Errorf("%w: %s", errInvalidMediaPath, urlPath)
+	}
+	key := path.Clean(kind + "/" + strings.TrimPrefix(urlPath, prefix))
+	if !strings.HasPrefix(key, kind+"/") || strings.ContainsAny(key, "\\\x00") {
+		return "", fmt.Errorf("%w: %s", errInvalidMediaPath, urlPath)
+	}
+	return key, nil
+}
+
+// mediaContentType is the Content-Type stored with a media object
+func mediaContentType(key string) string {
+	ext := path.Ext(key)
+	if strings.HasPrefix(key, "images/") {
+		return imageMimeType(ext)
+	}
+	if strings.HasPrefix(key, "videos/") {
+		return videoMimeType(ext)
+	}
+	if strings.HasPrefix(key, "attachments/") {
+		return attachmentMimeType(ext)
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// stagedMedia is an upload written to a local temporary file. Handlers commit the
+// database row that references URL and only then call Commit, which uploads the file to
+// media storage, so a failed request can't leave an object no row points to. Discard
+// removes the temporary file; it is meant to be deferred and is safe after Commit. A
+// crash between the two leaves only a file in the OS temp directory behind.
+type stagedMedia struct {
+	URL      string // /<kind>/<uid>/<dir>/<file>, served once committed
+	key      string
+	tempPath string
+	store    storage.Store
+}
+
+// Commit puts the file into media storage under its final key. It is a no-op on a nil
+// stagedMedia.
+func (m *stagedMedia) Commit(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	f, err := os.Open(m.tempPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := m.store.Put(ctx, m.key, f, info.Size(), mediaContentType(m.key)); err != nil {
+		return err
+	}
+	m.Discard()
+	return nil
+}
+
+// Discard removes the temporary file if it is still there
+func (m *stagedMedia) Discard() {
+	if m == nil {
+		return
+	}
+	_ = os.Remove(m.tempPath)
+}
+
+// size is the staged file's length in bytes
+func (m *stagedMedia) size() (int64, error) {
+	info, err := os.Stat(m.tempPath)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// isMultipartRequest reports whether the request carries multipart/form-data rather than JSON
+func isMultipartRequest(c *gin.Context) bool {
+	return strings.HasPrefix(strings.ToLower(c.ContentType()), "multipart/form-data")
+}
+
+// saveUploadedMedia streams a multipart file part to a file staged for
+// <kind>/<uid>/<entryID>/. Gin spools large parts to a temp file, so the upload is never
+// held in memory whole.
+func saveUploadedMedia(store storage.Store, fileHeader *multipart.FileHeader, kind string, detectExt func([]byte) (string, bool), userUID, entryID string, maxBytes int64) (*stagedMedia, error) {
+	if fileHeader.Size > maxBytes {
+		return nil, fmt.Errorf("%w (%d MB)", errMediaTooLarge, maxBytes>>20)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer file.Close()
+	return stageMediaFile(store, file, kind, detectExt, userUID, entryID, maxBytes)
+}
+
+// stageMediaFile copies r into a temporary file that will be stored as a new uniquely
+// named object under <kind>/<uid>/<dir>/, choosing the extension from the first bytes.
+// Nothing reaches media storage until the returned stagedMedia is committed. Data that
+// doesn't start with a supported signature is rejected with errUnsupportedMediaType. The
+// temporary file is removed if r fails or turns out to be larger than maxBytes.
+func stageMediaFile(store storage.Store, r io.Reader, kind string, detectExt func([]byte) (string, bool), userUID, dir string, maxBytes int64) (*stagedMedia, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(12)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read %s data: %w", kind, err)
+	}
+
+	ext, ok := detectExt(header)
+	if !ok {
+		return nil, errUnsupportedMediaType
+	}
+
+	// Generate unique filename
+	filename := uuid.New().String() + ext
+	staged := &stagedMedia{
+		URL:   fmt.Sprintf("/%s/%s/%s/%s", kind, userUID, dir, filename),
+		key:   path.Join(kind, userUID, dir, filename),
+		store: store,
+	}
+
+	f, err := os.CreateTemp("", "journeyapp-*"+ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s file: %w", kind, err)
+	}
+	staged.tempPath = f.Name()
+	n, err := io.Copy(f, io.LimitReader(br, maxBytes+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > maxBytes {
+		err = fmt.Errorf("%w (%d MB)", errMediaTooLarge, maxBytes>>20)
+	}
+	if err != nil {
+		staged.Discard()
+		if errors.Is(err, errMediaTooLarge) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to write %s file: %w", kind, err)
+	}
+
+	return staged, nil
+}
+
+// imageExtension picks a file extension from an image's leading bytes and reports
+// false when they don't match a supported image format
+func imageExtension(data []byte) (string, bool) {
+	switch {
+	case len(data) >= 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF:
+		return ".jpg", true
+	case len(data) >= 8 && string(data[0:8]) == "\x89PNG\r\n\x1a\n":
+		return ".png", true
+	case len(data) >= 6 && (string(data[0:6]) == "GIF87a" || string(data[0:6]) == "GIF89a"):
+		return ".gif", true
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return ".webp", true
+	case len(data) >= 12 && string(data[4:8]) == "ftyp" && isHEICBrand(string(data[8:12])):
+		return ".heic", true // iOS camera photos
+	default:
+		return "", false
+	}
+}
+
+// isHEICBrand reports whether an ISO-BMFF major brand is a HEIF/HEIC image
+func isHEICBrand(brand string) bool {
+	switch brand {
+	case "heic", "heix", "hevc", "hevx", "mif1", "msf1":
+		return true
+	}
+	return false
+}
+
+// audioExtension picks a file extension from an audio file's leading bytes and reports
+// false when they don't match a supported audio format
+func audioExtension(data []byte) (string, bool) {
+	switch {
+	case len(data) >= 3 && data[0] == 0x49 && data[1] == 0x44 && data[2] == 0x33:
+		return ".mp3", true // ID3 tag (MP3 with metadata)
+	case len(data) >= 11 && string(data[0:11]) == "FLV\x01\x05\x00\x00\x00\x09\x00\x00":
+		return ".flv", true
+	case len(data) >= 4 && string(data[0:4]) == "OggS":
+		return ".ogg", true
+	case len(data) >= 4 && string(data[0:4]) == "fLaC":
+		return ".flac", true
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return ".wav", true
+	case len(data) >= 8 && string(data[4:8]) == "ftyp":
+		return ".m4a", true // MP4 audio
+	case len(data) >= 2 && data[0] == 0xFF && (data[1]&0xE0) == 0xE0:
+		return ".mp3", true // MP3/AAC frame sync
+	default:
+		return "", false
+	}
+}
+
+// videoExtension picks a file extension from a video's leading bytes and reports false
+// when they don't match a supported video format. MP4-family files share the ftyp box
+// with M4A audio and HEIC images, so those brands are refused here.
+func videoExtension(data []byte) (string, bool) {
+	switch {
+	case len(data) >= 12 && string(data[4:8]) == "ftyp" && string(data[8:12]) == "qt  ":
+		return ".mov", true // QuickTime, the iOS camera default
+	case len(data) >= 12 && string(data[4:8]) == "ftyp" && !isHEICBrand(string(data[8:12])) && !isAudioBrand(string(data[8:12])):
+		return ".mp4", true
+	case len(data) >= 4 && data[0] == 0x1A && data[1] == 0x45 && data[2] == 0xDF && data[3] == 0xA3:
+		return ".webm", true // EBML header (WebM/Matroska)
+	default:
+		return "", false
+	}
+}
+
+// isAudioBrand reports whether an ISO-BMFF major brand is audio-only
+func isAudioBrand(brand string) bool {
+	switch brand {
+	case "M4A ", "M4B ", "M4P ":
+		return true
+	}
+	return false
+}
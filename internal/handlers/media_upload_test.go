@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/storage"
+)
+
+func TestMediaKeyFromURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		kind    string
+		uid     string
+		dir     string
+		want    string
+		wantErr error
+	}{
+		{name: "own entry file", url: "/images/alice/e1/a.jpg", kind: "images", uid: "alice", dir: "e1", want: "images/alice/e1/a.jpg"},
+		{name: "any own directory", url: "/audio/alice/e2/a.m4a", kind: "audio", uid: "alice", want: "audio/alice/e2/a.m4a"},
+		{name: "profile picture", url: "/images/alice/profile/p.png", kind: "images", uid: "alice", dir: "profile", want: "images/alice/profile/p.png"},
+		{name: "redundant segments cleaned", url: "/images/alice/./e1//a.jpg", kind: "images", uid: "alice", dir: "e1", want: "images/alice/e1/a.jpg"},
+
+		{name: "another user's file", url: "/images/bob/e1/a.jpg", kind: "images", uid: "alice", dir: "e1", wantErr: errForeignMedia},
+		{name: "another user's file, any directory", url: "/images/bob/e9/a.jpg", kind: "images", uid: "alice", wantErr: errForeignMedia},
+		{name: "another entry's file", url: "/images/alice/e2/a.jpg", kind: "images", uid: "alice", dir: "e1", wantErr: errForeignMedia},
+		{name: "dot-dot into another user", url: "/images/alice/e1/../../bob/e1/a.jpg", kind: "images", uid: "alice", dir: "e1", wantErr: errForeignMedia},
+		{name: "dot-dot into another entry", url: "/images/alice/e1/../e2/a.jpg", kind: "images", uid: "alice", dir: "e1", wantErr: errForeignMedia},
+		{name: "no uid", url: "/images/alice/e1/a.jpg", kind: "images", wantErr: errForeignMedia},
+
+		{name: "dot-dot out of the kind", url: "/images/../videos/alice/e1/a.mp4", kind: "images", uid: "alice", dir: "e1", wantErr: errInvalidMediaPath},
+		{name: "dot-dot out of the root", url: "/images/alice/e1/../../../../etc/passwd", kind: "images", uid: "alice", dir: "e1", wantErr: errInvalidMediaPath},
+		{name: "wrong kind", url: "/videos/alice/e1/a.mp4", kind: "images", uid: "alice", dir: "e1", wantErr: errInvalidMediaPath},
+		{name: "absolute URL", url: "https://example.com/images/alice/e1/a.jpg", kind: "images", uid: "alice", wantErr: errInvalidMediaPath},
+		{name: "too shallow", url: "/images/alice/a.jpg", kind: "images", uid: "alice", wantErr: errInvalidMediaPath},
+		{name: "too deep", url: "/images/alice/e1/x/a.jpg", kind: "images", uid: "alice", dir: "e1", wantErr: errInvalidMediaPath},
+		{name: "backslash", url: `/images/alice/e1/..\..\bob\a.jpg`, kind: "images", uid: "alice", dir: "e1", wantErr: errInvalidMediaPath},
+		{name: "NUL byte", url: "/images/alice/e1/a.jpg\x00", kind: "images", uid: "alice", dir: "e1", wantErr: errInvalidMediaPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mediaKeyFromURL(tt.url, tt.kind, tt.uid, tt.dir)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("mediaKeyFromURL(%q) error = %v, want %v", tt.url, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("mediaKeyFromURL(%q) = %q, %v, want %q", tt.url, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageFileKeysOnlyCoverTheEntry(t *testing.T) {
+	got := imageFileKeys("/images/alice/e1/a.jpg", "alice", "e1")
+	want := []string{"images/alice/e1/a.jpg", "images/alice/e1/a.webp"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("imageFileKeys = %v, want %v", got, want)
+	}
+
+	for _, url := range []string{
+		"/images/bob/e1/a.jpg",
+		"/images/alice/e1/../../bob/e1/a.jpg",
+		"/images/alice/e2/a.jpg",
+	} {
+		if keys := imageFileKeys(url, "alice", "e1"); keys != nil {
+			t.Errorf("imageFileKeys(%q) = %v, want none", url, keys)
+		}
+	}
+}
+
+func TestValidateEntryImages(t *testing.T) {
+	if err := validateEntryImages("alice", []string{"/images/alice/e1/a.jpg", "/images/alice/e2/b.png"}); err != nil {
+		t.Fatalf("own images rejected: %v", err)
+	}
+	if err := validateEntryImages("alice", nil); err != nil {
+		t.Fatalf("no images rejected: %v", err)
+	}
+
+	tests := []struct {
+		images []string
+		field  string
+	}{
+		{[]string{"/images/alice/e1/a.jpg", "/images/bob/e1/b.jpg"}, "images[1]"},
+		{[]string{"/images/alice/e1/../../bob/e1/b.jpg"}, "images[0]"},
+		{[]string{"/images/../attachments/bob/e1/doc.pdf"}, "images[0]"},
+		{[]string{"https://example.com/cat.jpg"}, "images[0]"},
+	}
+	for _, tt := range tests {
+		err := validateEntryImages("alice", tt.images)
+		if err == nil || err.Field != tt.field {
+			t.Errorf("validateEntryImages(%q) = %v, want field %s", tt.images, err, tt.field)
+		}
+	}
+}
+
+// storedFiles counts the image and audio objects in store
+func storedFiles(t *testing.T, store storage.Store) int {
+	t.Helper()
+	n := 0
+	for _, kind := range []string{"images", "audio"} {
+		if err := store.Walk(context.Background(), kind, func(storage.Info) error {
+			n++
+			return nil
+		}); err != nil {
+			t.Fatalf("walk %s: %v", kind, err)
+		}
+	}
+	return n
+}
+
+// tempFiles lists what is left in the directory staged uploads are written to
+func tempFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read %s: %v", dir, err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestFailedInsertLeavesNoOrphanFile(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	store := storage.NewLocal(t.TempDir())
+	entries := &EntryHandler{media: store}
+	auth := &AuthHandler{media: store}
+
+	jpeg := base64.StdEncoding.EncodeToString([]byte("\xff\xd8\xff\xe0jpeg data"))
+	mp3 := base64.StdEncoding.EncodeToString([]byte("ID3\x03mp3 data"))
+	saves := map[string]func() (*stagedMedia, error){
+		"image":   func() (*stagedMedia, error) { return entries.saveImageToFileSystem(jpeg, "alice", "e1", 1<<20) },
+		"audio":   func() (*stagedMedia, error) { return entries.saveAudioToFileSystem(mp3, "alice", "e1", 1<<20) },
+		"profile": func() (*stagedMedia, error) { return auth.saveProfileImageToFileSystem(jpeg, "alice") },
+	}
+	for name, save := range saves {
+		t.Run(name, func(t *testing.T) {
+			media, err := save()
+			if err != nil {
+				t.Fatalf("save: %v", err)
+			}
+			if len(tempFiles(t, tmp)) != 1 {
+				t.Fatalf("upload was not staged: %v", tempFiles(t, tmp))
+			}
+			// When the INSERT fails the handler responds without calling Commit, and
+			// only its deferred Discard runs
+			media.Discard()
+			if n := storedFiles(t, store); n != 0 {
+				t.Errorf("%d file(s) in media storage after a failed insert", n)
+			}
+			if left := tempFiles(t, tmp); len(left) != 0 {
+				t.Errorf("staged file left behind: %v", left)
+			}
+		})
+	}
+}
+
+var errInjected = errors.New("injected failure")
+
+// failingReader returns n bytes of data and then errInjected
+type failingReader struct {
+	data string
+	n    int
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, errInjected
+	}
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	r.n -= n
+	return n, nil
+}
+
+// interruptedStore cuts every Put off after a few bytes, as if the move into media
+// storage died halfway
+type interruptedStore struct {
+	storage.Store
+}
+
+func (s interruptedStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	return s.Store.Put(ctx, key, io.MultiReader(io.LimitReader(r, 4), &failingReader{}), size, contentType)
+}
+
+func TestStagedMediaFaultInjection(t *testing.T) {
+	ctx := context.Background()
+	jpeg := "\xff\xd8\xff\xe0" + strings.Repeat("x", 64)
+
+	t.Run("interrupted upload", func(t *testing.T) {
+		tmp := t.TempDir()
+		t.Setenv("TMPDIR", tmp)
+		store := storage.NewLocal(t.TempDir())
+
+		_, err := stageMediaFile(store, &failingReader{data: jpeg, n: 16}, "images", imageExtension, "alice", "e1", 1<<20)
+		if !errors.Is(err, errInjected) {
+			t.Fatalf("err = %v, want the injected failure", err)
+		}
+		if left := tempFiles(t, tmp); len(left) != 0 {
+			t.Errorf("staged file left behind: %v", left)
+		}
+		if n := storedFiles(t, store); n != 0 {
+			t.Errorf("%d file(s) in media storage", n)
+		}
+	})
+
+	t.Run("interrupted commit", func(t *testing.T) {
+		tmp := t.TempDir()
+		t.Setenv("TMPDIR", tmp)
+		root := t.TempDir()
+		store := interruptedStore{storage.NewLocal(root)}
+
+		media, err := stageMediaFile(store, strings.NewReader(jpeg), "images", imageExtension, "alice", "e1", 1<<20)
+		if err != nil {
+			t.Fatalf("stage: %v", err)
+		}
+		if err := media.Commit(ctx); !errors.Is(err, errInjected) {
+			t.Fatalf("Commit err = %v, want the injected failure", err)
+		}
+		media.Discard()
+		if _, err := store.Stat(ctx, media.key); !errors.Is(err, storage.ErrNotExist) {
+			t.Errorf("Stat after interrupted commit: err = %v, want ErrNotExist", err)
+		}
+		var partial []string
+		if err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				partial = append(partial, p)
+			}
+			return err
+		}); err != nil {
+			t.Fatalf("walk: %v", err)
+		}
+		if len(partial) != 0 {
+			t.Errorf("partial files in media storage: %v", partial)
+		}
+		if left := tempFiles(t, tmp); len(left) != 0 {
+			t.Errorf("staged file left behind: %v", left)
+		}
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		tmp := t.TempDir()
+		t.Setenv("TMPDIR", tmp)
+		store := storage.NewLocal(t.TempDir())
+
+		media, err := stageMediaFile(store, strings.NewReader(jpeg), "images", imageExtension, "alice", "e1", 1<<20)
+		if err != nil {
+			t.Fatalf("stage: %v", err)
+		}
+		if n := storedFiles(t, store); n != 0 {
+			t.Fatalf("%d file(s) in media storage before Commit", n)
+		}
+		if err := media.Commit(ctx); err != nil {
+			t.Fatalf("Commit: %v", err)
+		}
+		media.Discard()
+		info, err := store.Stat(ctx, media.key)
+		if err != nil || info.Size != int64(len(jpeg)) {
+			t.Errorf("Stat = %+v, %v; want the whole file", info, err)
+		}
+		if left := tempFiles(t, tmp); len(left) != 0 {
+			t.Errorf("staged file left behind: %v", left)
+		}
+	})
+}
+
+// multipartFile builds a request carrying data as the named file part and returns the
+// parsed part
+func multipartFile(t *testing.T, field string, data []byte) *multipart.FileHeader {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile(field, "upload.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/", &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatalf("ParseMultipartForm: %v", err)
+	}
+	return r.MultipartForm.File[field][0]
+}
+
+func TestIsMultipartRequest(t *testing.T) {
+	for contentType, want := range map[string]bool{
+		"multipart/form-data; boundary=x": true,
+		"Multipart/Form-Data; boundary=x": true,
+		"application/json":                false,
+		"":                                false,
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+		c.Request.Header.Set("Content-Type", contentType)
+		if got := isMultipartRequest(c); got != want {
+			t.Errorf("isMultipartRequest(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+}
+
+func TestSaveUploadedMedia(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("TMPDIR", t.TempDir())
+	store := storage.NewLocal(t.TempDir())
+	mp3 := append([]byte("ID3\x03"), make([]byte, 256)...)
+
+	media, err := saveUploadedMedia(store, multipartFile(t, "audio", mp3), "audio", audioExtension, "alice", "e1", 1<<20)
+	if err != nil {
+		t.Fatalf("saveUploadedMedia: %v", err)
+	}
+	defer media.Discard()
+	if !strings.HasPrefix(media.URL, "/audio/alice/e1/") || !strings.HasSuffix(media.URL, ".mp3") {
+		t.Errorf("URL = %q", media.URL)
+	}
+	if err := media.Commit(ctx); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if info, err := store.Stat(ctx, media.key); err != nil || info.Size != int64(len(mp3)) {
+		t.Errorf("Stat = %+v, %v", info, err)
+	}
+
+	if _, err := saveUploadedMedia(store, multipartFile(t, "audio", mp3), "audio", audioExtension, "alice", "e1", 64); !errors.Is(err, errMediaTooLarge) {
+		t.Errorf("oversized part: err = %v, want errMediaTooLarge", err)
+	}
+	if _, err := saveUploadedMedia(store, multipartFile(t, "audio", []byte("plain text, not audio")), "audio", audioExtension, "alice", "e1", 1<<20); !errors.Is(err, errUnsupportedMediaType) {
+		t.Errorf("text part: err = %v, want errUnsupportedMediaType", err)
+	}
+}
+
+// A stream that turns out larger than the limit leaves no staged file behind
+func TestStageMediaFileRemovesOversizedFile(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	store := storage.NewLocal(t.TempDir())
+	mp3 := append([]byte("ID3\x03"), make([]byte, 256)...)
+
+	if _, err := stageMediaFile(store, bytes.NewReader(mp3), "audio", audioExtension, "alice", "e1", 64); !errors.Is(err, errMediaTooLarge) {
+		t.Fatalf("err = %v, want errMediaTooLarge", err)
+	}
+	if left := tempFiles(t, tmp); len(left) != 0 {
+		t.Errorf("staged file left behind: %v", left)
+	}
+	if n := storedFiles(t, store); n != 0 {
+		t.Errorf("%d file(s) in media storage", n)
+	}
+}
+
+func TestImageExtension(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"jpeg", "\xff\xd8\xff\xe0\x00\x10JFIF", ".jpg"},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\x0d", ".png"},
+		{"gif87a", "GIF87a\x01\x00", ".gif"},
+		{"gif89a", "GIF89a\x01\x00", ".gif"},
+		{"webp", "RIFF\x24\x00\x00\x00WEBP", ".webp"},
+		{"heic", "\x00\x00\x00\x18ftypheic", ".heic"},
+		{"heif mif1", "\x00\x00\x00\x18ftypmif1", ".heic"},
+		{"wav is not an image", "RIFF\x24\x00\x00\x00WAVE", ""},
+		{"mp4 is not an image", "\x00\x00\x00\x18ftypisom", ""},
+		{"html", "<!DOCTYPE html>", ""},
+		{"truncated jpeg", "\xff\xd8", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		got, ok := imageExtension([]byte(tt.header))
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("%s: imageExtension = %q, %v; want %q", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestAudioExtension(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"mp3 with id3", "ID3\x03\x00\x00", ".mp3"},
+		{"mp3 frame sync", "\xff\xfb\x90\x00", ".mp3"},
+		{"ogg", "OggS\x00\x02", ".ogg"},
+		{"flac", "fLaC\x00\x00", ".flac"},
+		{"wav", "RIFF\x24\x00\x00\x00WAVE", ".wav"},
+		{"m4a", "\x00\x00\x00\x20ftypM4A ", ".m4a"},
+		{"flv", "FLV\x01\x05\x00\x00\x00\x09\x00\x00", ".flv"},
+		{"webp is not audio", "RIFF\x24\x00\x00\x00WEBP", ""},
+		{"jpeg is not audio", "\xff\xd8\xff\xe0", ""},
+		{"text", "hello world!", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		got, ok := audioExtension([]byte(tt.header))
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("%s: audioExtension = %q, %v; want %q", tt.name, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	models "io.winapps.journeyapp/internal/models/account"
+	mergelocationsmodels "io.winapps.journeyapp/internal/models/merge_locations"
+)
+
+const (
+	// locationSnapRadiusMeters is how close a new location must be to an existing one
+	// for AddLocation to reuse its address, and the default MergeLocations radius
+	locationSnapRadiusMeters = 50.0
+	maxMergeRadiusMeters     = 500.0
+	earthRadiusMeters        = 6371000.0
+	metersPerDegreeLatitude  = 111320.0
+	// maxMergeLngCells bounds the neighbour scan near the poles, where a radius spans
+	// many longitude cells; pairs that far apart in longitude aren't merged by distance
+	maxMergeLngCells = 64
+)
+
+// storedLocation is a location row with the metadata needed to pick a canonical one
+type storedLocation struct {
+	id        string
+	entryID   string
+	location  models.Location
+	createdAt time.Time
+}
+
+// MergeLocations normalizes the address fields of the caller's near-identical
+// locations onto one canonical value per group. Coordinates are left untouched.
+func (h *EntryHandler) MergeLocations(c *gin.Context) {
+	var req mergelocationsmodels.MergeLocationsRequest
+	// An empty body means "use the defaults"
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	// Get UID from context (set by auth middleware)
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+
+	userUID, ok := uid.(string)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
+		return
+	}
+
+	radius := req.RadiusMeters
+	if radius == 0 {
+		radius = locationSnapRadiusMeters
+	}
+	if radius < 0 || radius > maxMergeRadiusMeters {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "radiusMeters must be between 0 and 500")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the rows so a concurrent edit can't be overwritten with stale values
+	rows, err := tx.Query(ctx, `
+		SELECT l.id::text, l.entry_id::text,
+			COALESCE(l.latitude, 0)::float8, COALESCE(l.longitude, 0)::float8,
+			COALESCE(l.address, ''), COALESCE(l.city, ''), COALESCE(l.state, ''), COALESCE(l.zip, ''),
+			COALESCE(l.country, ''), COALESCE(l.country_code, ''), COALESCE(l.display_name, ''),
+			COALESCE(l.created_at, NOW())
+		FROM locations l
+		INNER JOIN entries e ON l.entry_id = e.id
+		WHERE e.user_uid = $1
+		ORDER BY l.created_at, l.id
+		FOR UPDATE OF l
+	`, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "Failed to load locations for merge")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load locations")
+		return
+	}
+	var locations []storedLocation
+	for rows.Next() {
+		var l storedLocation
+		if err := rows.Scan(&l.id, &l.entryID,
+			&l.location.Latitude, &l.location.Longitude,
+			&l.location.Address, &l.location.City, &l.location.State, &l.location.Zip,
+			&l.location.Country, &l.location.CountryCode, &l.location.DisplayName,
+			&l.createdAt,
+		); err != nil {
+			rows.Close()
+			h.logError(c, err, "Failed to scan location for merge")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load locations")
+			return
+		}
+		locations = append(locations, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load locations")
+		return
+	}
+
+	groups := groupNearbyLocations(locations, radius)
+
+	merged := 0
+	touched := map[string]bool{}
+	now := time.Now()
+	for _, group := range groups {
+		canonical := canonicalLocation(group)
+		for _, l := range group {
+			if sameAddress(l.location, canonical) {
+				continue
+			}
+			_, err := tx.Exec(ctx, `
+				UPDATE locations
+				SET address = $1, city = $2, state = $3, zip = $4, country = $5, country_code = $6, display_name = $7
+				WHERE id = $8
+			`, canonical.Address, canonical.City, canonical.State, canonical.Zip,
+				canonical.Country, canonical.CountryCode, canonical.DisplayName, l.id)
+			if err != nil {
+				if abortOnContextError(c, err) {
+					return
+				}
+				h.logError(c, err, "Failed to merge location", "location_id", l.id)
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to merge locations")
+				return
+			}
+			merged++
+			touched[l.entryID] = true
+		}
+	}
+
+	entryIDs := make([]string, 0, len(touched))
+	for id := range touched {
+		entryIDs = append(entryIDs, id)
+	}
+	if len(entryIDs) > 0 {
+		if _, err := tx.Exec(ctx, `UPDATE entries SET updated_at = $1 WHERE id = ANY($2::uuid[])`, now, entryIDs); err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamps")
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save merged locations")
+		return
+	}
+
+	// Invalidate cached entries
+	if len(entryIDs) > 0 {
+		keys := make([]string, len(entryIDs))
+		for i, id := range entryIDs {
+			keys[i] = "entry:" + id
+		}
+		_ = h.cache.Del(context.Background(), keys...)
+	}
+	h.invalidateUniqueLocationsCache(context.Background(), userUID)
+	invalidateAccountDetails(context.Background(), h.cache, userUID)
+
+	c.JSON(http.StatusOK, mergelocationsmodels.MergeLocationsResponse{
+		Merged:         merged,
+		Groups:         len(groups),
+		EntriesUpdated: len(entryIDs),
+		Message:        "Locations merged successfully",
+	})
+}
+
+// findNearbyLocation returns the closest of the user's named locations within
+// locationSnapRadiusMeters of the given point, or nil if there is none
+func (h *EntryHandler) findNearbyLocation(ctx context.Context, userUID string, lat, lng float64) (*models.Location, error) {
+	dLat, dLng := degreeSpan(lat, locationSnapRadiusMeters)
+	rows, err := h.postgres.Query(ctx, `
+		SELECT l.latitude::float8, l.longitude::float8,
+			COALESCE(l.address, ''), COALESCE(l.city, ''), COALESCE(l.state, ''), COALESCE(l.zip, ''),
+			COALESCE(l.country, ''), COALESCE(l.country_code, ''), l.display_name
+		FROM locations l
+		INNER JOIN entries e ON l.entry_id = e.id
+		WHERE e.user_uid = $1
+			AND COALESCE(l.display_name, '') <> ''
+			AND l.latitude BETWEEN $2::float8 - $4::float8 AND $2::float8 + $4::float8
+			AND l.longitude BETWEEN $3::float8 - $5::float8 AND $3::float8 + $5::float8
+		ORDER BY l.created_at DESC
+	`, userUID, lat, lng, dLat, dLng)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nearest *models.Location
+	best := locationSnapRadiusMeters
+	for rows.Next() {
+		var l models.Location
+		if err := rows.Scan(&l.Latitude, &l.Longitude,
+			&l.Address, &l.City, &l.State, &l.Zip, &l.Country, &l.CountryCode, &l.DisplayName,
+		); err != nil {
+			return nil, err
+		}
+		if d := distanceMeters(lat, lng, l.Latitude, l.Longitude); d <= best {
+			best = d
+			nearest = &l
+		}
+	}
+	return nearest, rows.Err()
+}
+
+// groupNearbyLocations unions locations that share a display name (case-insensitive)
+// or lie within radius meters of each other, and returns groups of two or more
+func groupNearbyLocations(locations []storedLocation, radius float64) [][]storedLocation {
+	parent := make([]int, len(locations))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) {
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	byName := map[string]int{}
+	// Bucket coordinates into radius-sized cells so only neighbouring cells are compared
+	type cell struct{ lat, lng int }
+	cells := map[cell][]int{}
+	cellDeg := radius / metersPerDegreeLatitude
+	for i, l := range locations {
+		if name := strings.ToLower(strings.TrimSpace(l.location.DisplayName)); name != "" {
+			if j, ok := byName[name]; ok {
+				union(j, i)
+			} else {
+				byName[name] = i
+			}
+		}
+
+		if radius == 0 || (l.location.Latitude == 0 && l.location.Longitude == 0) {
+			continue
+		}
+		// Longitude degrees shrink towards the poles, so scan enough cells to cover radius
+		_, dLng := degreeSpan(l.location.Latitude, radius)
+		lngCells := int(math.Ceil(dLng / cellDeg))
+		if lngCells > maxMergeLngCells {
+			lngCells = maxMergeLngCells
+		}
+		home := cell{int(math.Floor(l.location.Latitude / cellDeg)), int(math.Floor(l.location.Longitude / cellDeg))}
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -lngCells; dx <= lngCells; dx++ {
+				for _, j := range cells[cell{home.lat + dy, home.lng + dx}] {
+					o := locations[j].location
+					if distanceMeters(l.location.Latitude, l.location.Longitude, o.Latitude, o.Longitude) <= radius {
+						union(j, i)
+					}
+				}
+			}
+		}
+		cells[home] = append(cells[home], i)
+	}
+
+	members := map[int][]storedLocation{}
+	var roots []int
+	for i, l := range locations {
+		r := find(i)
+		if _, ok := members[r]; !ok {
+			roots = append(roots, r)
+		}
+		members[r] = append(members[r], l)
+	}
+	var groups [][]storedLocation
+	for _, r := range roots {
+		if len(members[r]) > 1 {
+			groups = append(groups, members[r])
+		}
+	}
+	return groups
+}
+
+// canonicalLocation picks the address used most often in the group, preferring named
+// addresses and, on a tie, the one seen first
+func canonicalLocation(group []storedLocation) models.Location {
+	type candidate struct {
+		location models.Location
+		count    int
+		first    int
+	}
+	var candidates []*candidate
+	for i, l := range group {
+		found := false
+		for _, cand := range candidates {
+			if sameAddress(cand.location, l.location) {
+				cand.count++
+				found = true
+				break
+			}
+		}
+		if !found {
+			candidates = append(candidates, &candidate{location: l.location, count: 1, first: i})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if (a.location.DisplayName != "") != (b.location.DisplayName != "") {
+			return a.location.DisplayName != ""
+		}
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		return a.first < b.first
+	})
+	return candidates[0].location
+}
+
+func sameAddress(a, b models.Location) bool {
+	return a.Address == b.Address && a.City == b.City && a.State == b.State && a.Zip == b.Zip &&
+		a.Country == b.Country && a.CountryCode == b.CountryCode && a.DisplayName == b.DisplayName
+}
+
+// degreeSpan converts a distance in meters to latitude and longitude spans at lat
+func degreeSpan(lat, meters float64) (float64, float64) {
+	dLat := meters / metersPerDegreeLatitude
+	cos := math.Cos(lat * math.Pi / 180)
+	if cos < 0.01 {
+		return dLat, 360
+	}
+	return dLat, meters / (metersPerDegreeLatitude * cos)
+}
+
+// distanceMeters is the haversine distance between two coordinates
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"io.winapps.journeyapp/internal/apierror"
+	mutualfriendsmodels "io.winapps.journeyapp/internal/models/mutual_friends"
+)
+
+// MutualFriends returns approved friends shared by the authenticated user and the uid query param
+func (h *UsersHandler) MutualFriends(c *gin.Context) {
+	uidVal, authed := c.Get("uid")
+	if !authed {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	authUID, _ := uidVal.(string)
+
+	otherUID := strings.TrimSpace(c.Query("uid"))
+	if otherUID == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "uid is required")
+		return
+	}
+	if otherUID == authUID {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "uid must be a different user")
+		return
+	}
+
+	// The result is symmetric, so key the cache by the unordered pair
+	first, second := authUID, otherUID
+	if second < first {
+		first, second = second, first
+	}
+	ctx := c.Request.Context()
+	cacheKey := fmt.Sprintf("mutual_friends:%s:%s", first, second)
+
+	if cached, err := h.cache.Get(ctx, cacheKey); err == nil && cached != "" {
+		var cachedResponse mutualfriendsmodels.MutualFriendsResponse
+		if err := json.Unmarshal([]byte(cached), &cachedResponse); err == nil {
+			cachedResponse.UID = otherUID
+			c.JSON(http.StatusOK, cachedResponse)
+			return
+		}
+	}
+
+	rows, err := h.postgres.Query(ctx, `
+		WITH friends_a AS (
+			SELECT CASE WHEN uid = $1 THEN fid ELSE uid END AS friend_uid
+			FROM friendships
+			WHERE (uid = $1 OR fid = $1) AND status = 'approved'
+		), friends_b AS (
+			SELECT CASE WHEN uid = $2 THEN fid ELSE uid END AS friend_uid
+			FROM friendships
+			WHERE (uid = $2 OR fid = $2) AND status = 'approved'
+		)
+		SELECT u.uid, u.display_name, u.photo_url
+		FROM (SELECT friend_uid FROM friends_a INTERSECT SELECT friend_uid FROM friends_b) m
+		JOIN users u ON u.uid = m.friend_uid
+		ORDER BY u.display_name, u.uid
+	`, authUID, otherUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list mutual friends")
+		return
+	}
+	defer rows.Close()
+
+	mutual := make([]mutualfriendsmodels.MutualFriend, 0)
+	for rows.Next() {
+		var friend mutualfriendsmodels.MutualFriend
+		if err := rows.Scan(&friend.UID, &friend.DisplayName, &friend.PhotoURL); err != nil {
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read results")
+			return
+		}
+		mutual = append(mutual, friend)
+	}
+
+	response := mutualfriendsmodels.MutualFriendsResponse{
+		UID:           otherUID,
+		MutualFriends: mutual,
+		Count:         len(mutual),
+	}
+
+	// Cache for a short period
+	if data, err := json.Marshal(response); err == nil {
+		_ = h.cache.Set(ctx, cacheKey, data, 5*time.Minute)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
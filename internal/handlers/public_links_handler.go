@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"io.winapps.journeyapp/internal/apierror"
+	models "io.winapps.journeyapp/internal/models/account"
+	publiclinkmodels "io.winapps.journeyapp/internal/models/public_links"
+	searchmodels "io.winapps.journeyapp/internal/models/search_entries"
+)
+
+const maxPublicLinkDays = 365
+
+// errPublicLinkNotFound covers unknown, revoked and expired tokens alike
+var errPublicLinkNotFound = errors.New("public link not found")
+
+// publicLink is a valid, unexpired link resolved from its token
+type publicLink struct {
+	entryID   string
+	ownerUID  string
+	expiresAt *time.Time
+}
+
+// CreatePublicLink creates a read-only link to one of the user's entries that works
+// without signing in. Links never expire unless expiresInDays is set.
+func (h *EntryHandler) CreatePublicLink(c *gin.Context) {
+	var req publiclinkmodels.CreatePublicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxPublicLinkDays {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, fmt.Sprintf("expiresInDays must be between 0 and %d", maxPublicLinkDays))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	var owner string
+	if err := h.postgres.QueryRow(ctx, `SELECT user_uid FROM entries WHERE id = $1`, req.EntryID).Scan(&owner); err != nil || owner != userUID {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+		return
+	}
+
+	token, err := generateSessionToken()
+	if err != nil {
+		h.logError(c, err, "generate public link token failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create public link")
+		return
+	}
+
+	now := time.Now().UTC()
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := now.AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	_, err = h.postgres.Exec(ctx, `
+		INSERT INTO entry_public_links (token, entry_id, user_uid, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, token, req.EntryID, userUID, expiresAt, now)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "insert public link failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create public link")
+		return
+	}
+
+	c.JSON(http.StatusCreated, publiclinkmodels.CreatePublicLinkResponse{
+		Token:     token,
+		URL:       absoluteMediaURL(c, "/shared/"+token),
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	})
+}
+
+// RevokePublicLink deletes one public link to an entry, or all of them when no token is given
+func (h *EntryHandler) RevokePublicLink(c *gin.Context) {
+	var req publiclinkmodels.RevokePublicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	tag, err := h.postgres.Exec(c.Request.Context(), `
+		DELETE FROM entry_public_links
+		WHERE entry_id = $1 AND user_uid = $2 AND ($3 = '' OR token = $3)
+	`, req.EntryID, userUID, strings.TrimSpace(req.Token))
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "revoke public link failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to revoke public link")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Public link not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, publiclinkmodels.RevokePublicLinkResponse{
+		Revoked: int(tag.RowsAffected()),
+		Message: "Public link revoked",
+	})
+}
+
+// GetSharedEntry serves GET /shared/:token without authentication
+func (h *EntryHandler) GetSharedEntry(c *gin.Context) {
+	token := c.Param("token")
+	ctx := c.Request.Context()
+
+	link, err := h.resolvePublicLink(ctx, token)
+	if err != nil {
+		if errors.Is(err, errPublicLinkNotFound) {
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Link not found or expired")
+			return
+		}
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "resolve public link failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load shared entry")
+		return
+	}
+
+	var entry searchmodels.EntryResult
+	var authorName string
+	err = h.postgres.QueryRow(ctx, `
+		SELECT e.id, e.title, COALESCE(e.description, ''), e.encrypted, e.created_at, e.updated_at, COALESCE(u.display_name, '')
+		FROM entries e
+		INNER JOIN users u ON u.uid = e.user_uid
+		WHERE e.id = $1
+	`, link.entryID).Scan(&entry.ID, &entry.Title, &entry.Description, &entry.Encrypted, &entry.CreatedAt, &entry.UpdatedAt, &authorName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Link not found or expired")
+			return
+		}
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "load shared entry failed", "entryId", link.entryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load shared entry")
+		return
+	}
+	if err := newEntryCipher(h.postgres, h.entryKeys).open(ctx, link.ownerUID, entry.Encrypted, &entry.Title, &entry.Description); err != nil {
+		h.logError(c, err, "decrypt shared entry failed", "entryId", link.entryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load shared entry")
+		return
+	}
+	entry.Images = []string{}
+	entry.Audio = []string{}
+	entry.Videos = []models.Video{}
+	entry.Tags = []models.Tag{}
+	entry.Locations = []models.Location{}
+
+	if err := h.fetchRelatedDataForEntries(ctx, []string{entry.ID}, map[string]*searchmodels.EntryResult{entry.ID: &entry}); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "fetch shared entry details failed", "entryId", entry.ID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load shared entry")
+		return
+	}
+
+	// Stored media URLs contain the owner's uid; point them at the link instead
+	images := make([]string, 0, len(entry.Images))
+	for _, u := range entry.Images {
+		images = append(images, absoluteMediaURL(c, "/shared/"+token+"/images/"+path.Base(u)))
+	}
+	audio := make([]string, 0, len(entry.Audio))
+	for _, u := range entry.Audio {
+		audio = append(audio, absoluteMediaURL(c, "/shared/"+token+"/audio/"+path.Base(u)))
+	}
+	videos := make([]models.Video, 0, len(entry.Videos))
+	for _, v := range entry.Videos {
+		v.URL = absoluteMediaURL(c, "/shared/"+token+"/videos/"+path.Base(v.URL))
+		if v.ThumbnailURL != "" {
+			v.ThumbnailURL = absoluteMediaURL(c, "/shared/"+token+"/videos/"+path.Base(v.ThumbnailURL))
+		}
+		videos = append(videos, v)
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.JSON(http.StatusOK, publiclinkmodels.SharedEntryResponse{
+		ID:          entry.ID,
+		Title:       entry.Title,
+		Description: entry.Description,
+		Images:      images,
+		Audio:       audio,
+		Videos:      videos,
+		Tags:        entry.Tags,
+		Locations:   entry.Locations,
+		AuthorName:  authorName,
+		CreatedAt:   entry.CreatedAt,
+		UpdatedAt:   entry.UpdatedAt,
+		ExpiresAt:   link.expiresAt,
+	})
+}
+
+// ServeSharedImage serves /shared/:token/images/:file without authentication
+func (h *EntryHandler) ServeSharedImage(c *gin.Context) {
+	h.serveSharedMedia(c, "images")
+}
+
+// ServeSharedAudio serves /shared/:token/audio/:file without authentication
+func (h *EntryHandler) ServeSharedAudio(c *gin.Context) {
+	h.serveSharedMedia(c, "audio")
+}
+
+// ServeSharedVideo serves /shared/:token/videos/:file, clips and their posters, without
+// authentication
+func (h *EntryHandler) ServeSharedVideo(c *gin.Context) {
+	h.serveSharedMedia(c, "videos")
+}
+
+// serveSharedMedia serves a file only if it is still attached to the linked entry, so a
+// link can't reach media that was removed from the entry but not yet from storage
+func (h *EntryHandler) serveSharedMedia(c *gin.Context, kind string) {
+	ctx := c.Request.Context()
+
+	link, err := h.resolvePublicLink(ctx, c.Param("token"))
+	if err != nil {
+		if errors.Is(err, errPublicLinkNotFound) {
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "File not found")
+			return
+		}
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "resolve public link failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load file")
+		return
+	}
+
+	file := c.Param("file")
+	mediaURL := fmt.Sprintf("/%s/%s/%s/%s", kind, link.ownerUID, link.entryID, file)
+	var attached bool
+	// kind is "images", "audio" or "videos", all of which are table names
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE entry_id = $1 AND url = $2)`, kind)
+	if kind == "videos" {
+		// A video's poster is attached through its row too
+		query = `SELECT EXISTS (SELECT 1 FROM videos WHERE entry_id = $1 AND (url = $2 OR thumbnail_url = $2))`
+	}
+	if err := h.postgres.QueryRow(ctx, query, link.entryID, mediaURL).Scan(&attached); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "shared media lookup failed", "entryId", link.entryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load file")
+		return
+	}
+	if !attached {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "File not found")
+		return
+	}
+
+	if kind == "images" {
+		file = h.negotiateImageFile(c, link.ownerUID, link.entryID, file)
+	}
+	h.serveMediaFile(c, kind, link.ownerUID, link.entryID, file)
+}
+
+// resolvePublicLink looks up an unexpired link by token
+func (h *EntryHandler) resolvePublicLink(ctx context.Context, token string) (*publicLink, error) {
+	if token == "" {
+		return nil, errPublicLinkNotFound
+	}
+
+	var link publicLink
+	err := h.postgres.QueryRow(ctx, `
+		SELECT l.entry_id, l.user_uid, l.expires_at
+		FROM entry_public_links l
+		INNER JOIN entries e ON e.id = l.entry_id
+		WHERE l.token = $1 AND (l.expires_at IS NULL OR l.expires_at > $2)
+			AND e.status = 'published' AND NOT e.moderation_hidden
+	`, token, time.Now().UTC()).Scan(&link.entryID, &link.ownerUID, &link.expiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errPublicLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
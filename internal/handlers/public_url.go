@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// absoluteMediaURL turns a served path such as /images/<uid>/profile/<file> into an
+// absolute URL. The base comes from PUBLIC_BASE_URL (e.g. https://api.example.com); when
+// that is unset the scheme and host of the current request are used, which suits local
+// development but trusts the Host and X-Forwarded-Proto headers.
+func absoluteMediaURL(c *gin.Context, relativeURL string) string {
+	if base := strings.TrimRight(strings.TrimSpace(os.Getenv("PUBLIC_BASE_URL")), "/"); base != "" {
+		return base + relativeURL
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "https" || proto == "http" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host + relativeURL
+}
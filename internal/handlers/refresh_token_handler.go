@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	usermodels "io.winapps.journeyapp/internal/models/account"
+	refreshmodels "io.winapps.journeyapp/internal/models/refresh_token"
+)
+
+// sessionTokenTTL is how long a session token stays valid before the client must refresh it
+const sessionTokenTTL = 24 * time.Hour
+
+// RefreshToken verifies a still-valid Firebase ID token and issues a new session token
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req refreshmodels.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	ctx := c.Request.Context()
+	idToken, err := h.verifySignInToken(ctx, req.IDToken)
+	if err != nil {
+		respondIDTokenError(c, err)
+		return
+	}
+
+	if err := h.syncEmailVerified(ctx, idToken); err != nil {
+		h.logError(c, err, "Failed to sync email verification", "uid", idToken.UID)
+	}
+
+	user, err := h.getUserFromDatabase(ctx, idToken.UID)
+	if err != nil {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "User not found")
+		return
+	}
+
+	sessionToken, expiresAt, err := h.issueSessionToken(ctx, user)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "Failed to issue session token", "uid", user.UID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to refresh token")
+		return
+	}
+
+	c.JSON(http.StatusOK, refreshmodels.RefreshTokenResponse{
+		Success:   true,
+		Message:   "Token refreshed successfully",
+		UID:       user.UID,
+		Token:     sessionToken,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// issueSessionToken generates a new session token for user, stores it with a fresh expiry
+// in Postgres and replaces the cached session so the previous token stops matching
+func (h *AuthHandler) issueSessionToken(ctx context.Context, user *usermodels.User) (string, time.Time, error) {
+	sessionToken, err := generateSessionToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(sessionTokenTTL)
+
+	query := `UPDATE users SET token = $1, token_expires_at = $2, updated_at = NOW() WHERE uid = $3`
+	if _, err := h.postgres.Exec(ctx, query, sessionToken, expiresAt, user.UID); err != nil {
+		return "", time.Time{}, err
+	}
+
+	user.Token = sessionToken
+	user.TokenExpiresAt = &expiresAt
+	if userJSON, err := json.Marshal(user); err == nil {
+		h.cache.Set(ctx, "user:"+user.UID, userJSON, sessionTokenTTL)
+	}
+	invalidateAccountDetails(ctx, h.cache, user.UID)
+
+	return sessionToken, expiresAt, nil
+}
+
+// generateSessionToken returns a random 256-bit hex-encoded token
+func generateSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1,12 +1,12 @@
 package handlers
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	firebase "firebase.google.com/go/v4"
@@ -14,27 +14,30 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 	"github.com/robfig/cron/v3"
     "go.uber.org/zap"
 
+	"io.winapps.journeyapp/internal/cache"
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/metrics"
 	notificationsmodels "io.winapps.journeyapp/internal/models/notifications"
 )
 
 type NotificationsHandler struct {
 	fcmClient   *messaging.Client
 	db          *pgxpool.Pool
-	redisClient *redis.Client
+	cache       cache.Store
 	cronManager *cron.Cron
+	runningJobs atomic.Int32 // cron jobs currently executing, reported on shutdown
     logger      *zap.SugaredLogger
 }
 
-func NewNotificationsHandler(firebaseApp *firebase.App, dbPool *pgxpool.Pool, redisClient *redis.Client, logger *zap.SugaredLogger) *NotificationsHandler {
+func NewNotificationsHandler(firebaseApp *firebase.App, dbPool *pgxpool.Pool, store cache.Store, logger *zap.SugaredLogger) *NotificationsHandler {
 	ctx := context.Background()
 
 	fcmClient, err := firebaseApp.Messaging(ctx)
 	if err != nil {
-		log.Printf("error getting FCM client: %v", err)
+		logger.Errorw("Failed to get FCM client", "error", err)
 	}
 
 	c := cron.New(cron.WithLocation(time.UTC))
@@ -42,29 +45,54 @@ func NewNotificationsHandler(firebaseApp *firebase.App, dbPool *pgxpool.Pool, re
 	h := &NotificationsHandler{
 		fcmClient:   fcmClient,
 		db:          dbPool,
-		redisClient: redisClient,
+		cache:       store,
 		cronManager: c,
 		logger:      logger,
 	}
 
 	// Setup cron jobs for daily prompts
 	h.setupDailyPromptScheduler()
+	h.setupStreakMilestones()
+	h.setupScheduledPublishing()
+	h.setupExpoReceiptChecks()
 
 	return h
 }
 
+// Shutdown stops scheduling new cron jobs and waits for running ones to finish
+func (ns *NotificationsHandler) Shutdown(ctx context.Context) error {
+	stopped := ns.cronManager.Stop()
+	ns.logger.Infow("Stopping notification scheduler", "inFlightJobs", ns.runningJobs.Load())
+	select {
+	case <-stopped.Done():
+		ns.logger.Info("Notification scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d cron jobs still running: %w", ns.runningJobs.Load(), ctx.Err())
+	}
+}
+
+// trackedJob wraps a cron job so Shutdown can report how many are in flight
+func (ns *NotificationsHandler) trackedJob(job func()) func() {
+	return func() {
+		ns.runningJobs.Add(1)
+		defer ns.runningJobs.Add(-1)
+		job()
+	}
+}
+
 // RegisterPushToken handles registering user push tokens
 func (ns *NotificationsHandler) RegisterPushToken(c *gin.Context) {
 	var tokenData notificationsmodels.PushToken
 	if err := c.ShouldBindJSON(&tokenData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, err.Error())
 		return
 	}
 
 	// Get user ID from Firebase JWT (set by AuthMiddleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
@@ -96,15 +124,15 @@ func (ns *NotificationsHandler) RegisterPushToken(c *gin.Context) {
 	).Scan(&id)
 
 	if err != nil {
-		log.Printf("Error saving push token: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save token"})
+		ns.logError(c, err, "Failed to save push token")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save token")
 		return
 	}
 
 	// Cache the token in Redis for quick access
 	tokenKey := fmt.Sprintf("push_token:%s", tokenData.UserID)
 	tokenJSON, _ := json.Marshal(tokenData)
-	ns.redisClient.Set(context.Background(), tokenKey, tokenJSON, 24*time.Hour)
+	ns.cache.Set(context.Background(), tokenKey, tokenJSON, 24*time.Hour)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Token registered successfully",
@@ -114,6 +142,16 @@ func (ns *NotificationsHandler) RegisterPushToken(c *gin.Context) {
 
 // SendNotification sends a notification via FCM or Expo push as fallback
 func (ns *NotificationsHandler) SendNotification(expoOrFcmToken, title, body string, data map[string]string, channelID string) error {
+	err := ns.sendNotification(expoOrFcmToken, title, body, data, channelID)
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	metrics.NotificationsSentTotal.WithLabelValues(channelID, result).Inc()
+	return err
+}
+
+func (ns *NotificationsHandler) sendNotification(expoOrFcmToken, title, body string, data map[string]string, channelID string) error {
 	// If token looks like Expo token, use Expo push service
 	if len(expoOrFcmToken) > 0 && (expoOrFcmToken[:6] == "ExpoPush" || expoOrFcmToken[:4] == "Expo") {
 		return ns.sendExpoPush(expoOrFcmToken, title, body, data)
@@ -155,10 +193,12 @@ func (ns *NotificationsHandler) SendNotification(expoOrFcmToken, title, body str
 		return fmt.Errorf("error sending message: %v", err)
 	}
 
-	log.Printf("Successfully sent message: %s", response)
+	ns.logger.Debugw("Sent FCM message", "messageId", response)
 	return nil
 }
 
+// sendExpoPush hands a message to Expo. Expo answers with a ticket: an error ticket fails
+// the send, and an ok ticket is queued so checkExpoReceipts can confirm delivery later.
 func (ns *NotificationsHandler) sendExpoPush(expoToken, title, body string, data map[string]string) error {
 	payload := []map[string]interface{}{
 		{
@@ -169,21 +209,22 @@ func (ns *NotificationsHandler) sendExpoPush(expoToken, title, body string, data
 			"data": data,
 		},
 	}
-	b, _ := json.Marshal(payload)
-	req, err := http.NewRequest("POST", "https://exp.host/--/api/v2/push/send", bytes.NewReader(b))
-	if err != nil {
+	ctx := context.Background()
+	var tickets []expoTicket
+	if err := postExpo(ctx, expoSendURL, payload, &tickets); err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
+	if len(tickets) == 0 {
+		return fmt.Errorf("expo push returned no ticket")
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return fmt.Errorf("expo push failed with status %d", resp.StatusCode)
+	ticket := tickets[0]
+	if ticket.Status != "ok" {
+		if ticket.Details.Error == expoDeviceNotRegistered {
+			ns.deactivateExpoToken(ctx, expoToken)
+		}
+		return fmt.Errorf("expo push rejected: %s: %s", ticket.Details.Error, ticket.Message)
 	}
+	ns.trackExpoTicket(ctx, ticket.ID, expoToken)
 	return nil
 }
 
@@ -195,7 +236,7 @@ func (ns *NotificationsHandler) setupDailyPromptScheduler() {
 	for _, tzName := range timezones {
 		loc, err := time.LoadLocation(tzName)
 		if err != nil {
-			log.Printf("Invalid timezone %s: %v", tzName, err)
+			ns.logger.Warnw("Invalid timezone", "timezone", tzName, "error", err)
 			continue
 		}
 
@@ -214,30 +255,30 @@ func (ns *NotificationsHandler) setupDailyPromptScheduler() {
 		// Add cron at computed UTC hour and selected minute
 		spec := fmt.Sprintf("%d %d * * *", minute, utcHour)
 		z := tzName
-		_, err = ns.cronManager.AddFunc(spec, func() {
+		_, err = ns.cronManager.AddFunc(spec, ns.trackedJob(func() {
 			ns.sendDailyPromptsForTimezone(z)
-		})
+		}))
 		if err != nil {
-			log.Printf("Error scheduling daily prompts for timezone %s: %v", tzName, err)
+			ns.logger.Errorw("Failed to schedule daily prompts", "timezone", tzName, "error", err)
 		}
 	}
 
 	ns.cronManager.Start()
 
 	// Also schedule a job to refresh timezone list daily (in case new users register)
-	ns.cronManager.AddFunc("0 0 * * *", func() {
+	ns.cronManager.AddFunc("0 0 * * *", ns.trackedJob(func() {
 		ns.refreshTimezoneScheduler()
-	})
+	}))
 }
 
 // getAllUserTimezones gets all unique timezones from registered users
 func (ns *NotificationsHandler) getAllUserTimezones() []string {
 	// First check Redis cache
 	cacheKey := "user_timezones"
-	cached := ns.redisClient.Get(context.Background(), cacheKey)
-	if cached.Err() == nil {
+	cached, err := ns.cache.Get(context.Background(), cacheKey)
+	if err == nil {
 		var timezones []string
-		if err := json.Unmarshal([]byte(cached.Val()), &timezones); err == nil {
+		if err := json.Unmarshal([]byte(cached), &timezones); err == nil {
 			return timezones
 		}
 	}
@@ -246,7 +287,7 @@ func (ns *NotificationsHandler) getAllUserTimezones() []string {
 	query := `SELECT DISTINCT timezone FROM push_tokens WHERE active = true`
 	rows, err := ns.db.Query(context.Background(), query)
 	if err != nil {
-		log.Printf("Error getting timezones: %v", err)
+		ns.logger.Errorw("Failed to load push token timezones", "error", err)
 		return []string{"UTC"} // Fallback
 	}
 	defer rows.Close()
@@ -265,7 +306,7 @@ func (ns *NotificationsHandler) getAllUserTimezones() []string {
 
 	// Cache the result for 1 hour
 	timezonesJSON, _ := json.Marshal(timezones)
-	ns.redisClient.Set(context.Background(), cacheKey, timezonesJSON, time.Hour)
+	ns.cache.Set(context.Background(), cacheKey, timezonesJSON, time.Hour)
 
 	return timezones
 }
@@ -273,35 +314,42 @@ func (ns *NotificationsHandler) getAllUserTimezones() []string {
 // refreshTimezoneScheduler refreshes the cron jobs when new timezones are added
 func (ns *NotificationsHandler) refreshTimezoneScheduler() {
 	// Clear cache to force refresh
-	ns.redisClient.Del(context.Background(), "user_timezones")
+	ns.cache.Del(context.Background(), "user_timezones")
 
 	// Get updated timezones
 	timezones := ns.getAllUserTimezones()
 	_ = timezones
 	// In production, we would reschedule jobs; for now, we log the list.
-	log.Printf("Refreshed timezone scheduler. Active timezones: %v", timezones)
+	ns.logger.Infow("Refreshed timezone scheduler", "timezones", timezones)
 }
 
 // sendDailyPromptsForTimezone sends daily prompts to users in a specific timezone
 func (ns *NotificationsHandler) sendDailyPromptsForTimezone(timezone string) {
-	log.Printf("Sending daily prompts for timezone: %s", timezone)
+	ns.logger.Infow("Sending daily prompts", "timezone", timezone)
 
-	// Generate or get today's prompt
-	prompt := ns.getTodaysPrompt()
+	// Today's prompt in each language, generated or loaded on first use
+	prompts := make(map[string]notificationsmodels.DailyPrompt)
+
+	// Users about to lose a streak get a reminder carrying the prompt instead
+	atRisk := ns.streaksAtRisk(context.Background(), timezone)
 
 	// Get all users in this timezone from PostgreSQL
-	query := `SELECT user_id, COALESCE(fcm_token, ''), expo_push_token FROM push_tokens WHERE timezone = $1 AND active = true`
+	query := `
+		SELECT p.user_id, COALESCE(p.fcm_token, ''), p.expo_push_token, COALESCE(us.lang, 'en')
+		FROM push_tokens p
+		LEFT JOIN user_settings us ON us.uid = p.user_id
+		WHERE p.timezone = $1 AND p.active = true`
 	rows, err := ns.db.Query(context.Background(), query, timezone)
 	if err != nil {
-		log.Printf("Error finding users for timezone %s: %v", timezone, err)
+		ns.logger.Errorw("Failed to find users for daily prompts", "timezone", timezone, "error", err)
 		return
 	}
 	defer rows.Close()
 
 	// Send notifications to each user
 	for rows.Next() {
-		var userID, fcmToken, expoToken string
-		if err := rows.Scan(&userID, &fcmToken, &expoToken); err != nil {
+		var userID, fcmToken, expoToken, lang string
+		if err := rows.Scan(&userID, &fcmToken, &expoToken, &lang); err != nil {
 			continue
 		}
 
@@ -315,124 +363,94 @@ func (ns *NotificationsHandler) sendDailyPromptsForTimezone(timezone string) {
 			continue
 		}
 
+		lang = promptLang(lang)
+		prompt, ok := prompts[lang]
+		if !ok {
+			prompt = ns.getTodaysPrompt(lang)
+			prompts[lang] = prompt
+		}
+
 		data := map[string]string{
 			"type":   "daily_prompt",
 			"prompt": prompt.Prompt,
 			"date":   prompt.Date.Format("2006-01-02"),
 		}
+		title, body := "Daily Writing Prompt", prompt.Prompt
+		if streak := atRisk[userID]; streak > 0 {
+			data["type"] = "streak_reminder"
+			data["streak"] = strconv.Itoa(streak)
+			title = fmt.Sprintf("Don't break your %d-day streak", streak)
+			body = fmt.Sprintf("You haven't written today yet. Tonight's prompt: %s", prompt.Prompt)
+		}
 
 		err := ns.SendNotification(
 			tokenToUse,
-			"Daily Writing Prompt",
-			prompt.Prompt,
+			title,
+			body,
 			data,
 			"prompts",
 		)
 
 		if err != nil {
-			log.Printf("Failed to send daily prompt to user %s: %v", userID, err)
+			ns.logger.Warnw("Failed to send daily prompt", "recipient", userID, "error", err)
 		}
 
 		// Track notification sent in Redis (for analytics)
 		notificationKey := fmt.Sprintf("notification_sent:%s:%s", userID, prompt.Date.Format("2006-01-02"))
-		ns.redisClient.Set(context.Background(), notificationKey, "daily_prompt", 7*24*time.Hour)
+		ns.cache.Set(context.Background(), notificationKey, "daily_prompt", 7*24*time.Hour)
 	}
 }
 
-// getTodaysPrompt gets or generates today's writing prompt
-func (ns *NotificationsHandler) getTodaysPrompt() notificationsmodels.DailyPrompt {
+// getTodaysPrompt gets or generates today's writing prompt in lang, falling back to
+// English for languages without prompts
+func (ns *NotificationsHandler) getTodaysPrompt(lang string) notificationsmodels.DailyPrompt {
 	today := time.Now().Truncate(24 * time.Hour)
+	lang = promptLang(lang)
 
 	// First check Redis cache
-	cacheKey := fmt.Sprintf("daily_prompt:%s", today.Format("2006-01-02"))
-	cached := ns.redisClient.Get(context.Background(), cacheKey)
-	if cached.Err() == nil {
+	cacheKey := fmt.Sprintf("daily_prompt:%s:%s", today.Format("2006-01-02"), lang)
+	cached, err := ns.cache.Get(context.Background(), cacheKey)
+	if err == nil {
 		var prompt notificationsmodels.DailyPrompt
-		if err := json.Unmarshal([]byte(cached.Val()), &prompt); err == nil {
+		if err := json.Unmarshal([]byte(cached), &prompt); err == nil {
 			return prompt
 		}
 	}
 
 	// Check PostgreSQL
 	var prompt notificationsmodels.DailyPrompt
-	query := `SELECT id, prompt, date, created_at FROM daily_prompts WHERE date = $1`
-	err := ns.db.QueryRow(context.Background(), query, today).Scan(
-		&prompt.ID, &prompt.Prompt, &prompt.Date, &prompt.CreatedAt,
+	query := `SELECT id, prompt, lang, date, created_at FROM daily_prompts WHERE date = $1 AND lang = $2`
+	err = ns.db.QueryRow(context.Background(), query, today, lang).Scan(
+		&prompt.ID, &prompt.Prompt, &prompt.Lang, &prompt.Date, &prompt.CreatedAt,
 	)
 
 	if err != nil {
-		// Generate a new prompt
-		prompts := []string{
-			// "What made you smile today?",
-			// "Describe a moment when you felt truly grateful.",
-			// "What's one thing you learned about yourself recently?",
-			// "Write about a challenge you overcame this week.",
-			// "What would you tell your younger self?",
-			// "Describe your perfect day in detail.",
-			// "What are three things you're looking forward to?",
-			// "Write about someone who has positively influenced your life.",
-			// "What's a skill you'd like to develop and why?",
-			// "Describe a place that makes you feel peaceful.",
-			// "What's the best advice you've ever received?",
-			// "Write about a time you stepped out of your comfort zone.",
-			"What made you smile today? Describe the moment in vivid detail and why it brought you joy.",
-			"Write about a time you surprised yourself. What did you discover about your capabilities or character?",
-			"If your current self could give advice to your past self from five years ago, what would you say?",
-			"Describe a challenge you're currently facing as if you're explaining it to a wise friend. What insights emerge?",
-			"What's one belief you held strongly in the past that you've since changed your mind about? What caused the shift?",
-			"You wake up with the ability to communicate with inanimate objects for one day. What conversations do you have?",
-			"Write a letter from your 80-year-old self to your current self. What wisdom do they share?",
-			"Imagine you could time travel but only to witness (not change) one moment in history. Where would you go and why?",
-			"You discover a door in your home that wasn't there yesterday. Where does it lead and what do you find?",
-			"Write about your life as if it were a book. What would the current chapter be titled and why?",
-			"Describe someone who has influenced your life without them knowing it. How did they impact you?",
-			"Write about a conversation you wish you could have with someone no longer in your life.",
-			"What's the most valuable lesson someone taught you without trying to teach you anything?",
-			"If you could have dinner with any three people (living or dead), who would they be and what would you want to discuss?",
-			"Write about a moment when someone showed you unexpected kindness. How did it change your day or perspective?",
-			"Describe your perfect day, from morning to night, with unlimited resources and no constraints.",
-			"What would you attempt if you knew you couldn't fail? Why haven't you started already?",
-			"Write about a skill you'd love to master. What draws you to it and how would it change your life?",
-			"If you could solve one problem in the world, what would it be and how would you approach it?",
-			"Imagine you're 90 years old, looking back on your life. What are you most proud of accomplishing?",
-			"Choose an ordinary object near you and write its secret life story. What adventures has it been on?",
-			"Describe a place that feels magical to you. What makes it special and how does it affect your mood?",
-			"Write about a small ritual or habit that brings you comfort. Why is it meaningful to you?",
-			"If you could pause time for an hour while everyone else is frozen, how would you spend it?",
-			"Describe the view from your window as if you're seeing it for the first time. What details stand out?",
-			"What's something you've been avoiding that you know would be good for you? Explore why you're resisting it.",
-			"Write about a fear you've overcome or are working to overcome. What steps have you taken?",
-			"Describe a moment when you felt truly proud of yourself. What did you accomplish and why did it matter?",
-			"If you could develop one new habit that would improve your life, what would it be and how would you implement it?",
-			"Write about something you're grateful for that you might normally take for granted. Why does it deserve appreciation?",
-		}
-
-		// Simple rotation based on day of year
-		dayOfYear := today.YearDay()
-		selectedPrompt := prompts[dayOfYear%len(prompts)]
-
+		// Simple rotation based on day of year; every language's set is in the same
+		// order, so everyone gets the same prompt on a given day
 		prompt = notificationsmodels.DailyPrompt{
 			ID:        uuid.New().String(),
-			Prompt:    selectedPrompt,
+			Prompt:    promptForDay(lang, today.YearDay()),
+			Lang:      lang,
 			Date:      today,
 			CreatedAt: time.Now(),
 		}
 
 		// Save the prompt to PostgreSQL
 		insertQuery := `
-			INSERT INTO daily_prompts (id, prompt, date, created_at)
-			VALUES ($1, $2, $3, $4)`
+			INSERT INTO daily_prompts (id, prompt, lang, date, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (date, lang) DO NOTHING`
 		_, err := ns.db.Exec(context.Background(), insertQuery,
-			prompt.ID, prompt.Prompt, prompt.Date, prompt.CreatedAt)
+			prompt.ID, prompt.Prompt, prompt.Lang, prompt.Date, prompt.CreatedAt)
 
 		if err != nil {
-			log.Printf("Error saving daily prompt: %v", err)
+			ns.logger.Errorw("Failed to save daily prompt", "lang", lang, "error", err)
 		}
 	}
 
 	// Cache the prompt in Redis for quick access
 	promptJSON, _ := json.Marshal(prompt)
-	ns.redisClient.Set(context.Background(), cacheKey, promptJSON, 24*time.Hour)
+	ns.cache.Set(context.Background(), cacheKey, promptJSON, 24*time.Hour)
 
 	return prompt
 }
@@ -441,10 +459,10 @@ func (ns *NotificationsHandler) getTodaysPrompt() notificationsmodels.DailyPromp
 func (ns *NotificationsHandler) getPushTokenFromCache(userID string) (*notificationsmodels.PushToken, error) {
 	// Check Redis first
 	tokenKey := fmt.Sprintf("push_token:%s", userID)
-	cached := ns.redisClient.Get(context.Background(), tokenKey)
-	if cached.Err() == nil {
+	cached, err := ns.cache.Get(context.Background(), tokenKey)
+	if err == nil {
 		var token notificationsmodels.PushToken
-		if err := json.Unmarshal([]byte(cached.Val()), &token); err == nil {
+		if err := json.Unmarshal([]byte(cached), &token); err == nil {
 			return &token, nil
 		}
 	}
@@ -456,7 +474,7 @@ func (ns *NotificationsHandler) getPushTokenFromCache(userID string) (*notificat
 		FROM push_tokens
 		WHERE user_id = $1 AND active = true`
 
-	err := ns.db.QueryRow(context.Background(), query, userID).Scan(
+	err = ns.db.QueryRow(context.Background(), query, userID).Scan(
 		&token.UserID,
 		&token.ExpoPushToken,
 		&token.FCMToken,
@@ -471,7 +489,7 @@ func (ns *NotificationsHandler) getPushTokenFromCache(userID string) (*notificat
 
 	// Cache it for next time
 	tokenJSON, _ := json.Marshal(token)
-	ns.redisClient.Set(context.Background(), tokenKey, tokenJSON, 24*time.Hour)
+	ns.cache.Set(context.Background(), tokenKey, tokenJSON, 24*time.Hour)
 
 	return &token, nil
 }
@@ -505,16 +523,77 @@ func (ns *NotificationsHandler) SendMessageNotification(recipientUserID, senderN
 
 	// Track message notification in Redis
 	notificationKey := fmt.Sprintf("message_notification:%s:%d", recipientUserID, time.Now().Unix())
-	ns.redisClient.Set(context.Background(), notificationKey, senderName, 24*time.Hour)
+	ns.cache.Set(context.Background(), notificationKey, senderName, 24*time.Hour)
 
 	return ns.SendNotification(tokenToUse, title, body, data, "messages")
 }
 
+// SendCommentNotification notifies an entry owner that someone commented on their entry
+func (ns *NotificationsHandler) SendCommentNotification(recipientUserID, commenterName, entryID, commentPreview string) error {
+	data := map[string]string{
+		"type":           "new_comment",
+		"commenter_name": commenterName,
+		"entry_id":       entryID,
+		"preview":        commentPreview,
+		"recipient_id":   recipientUserID,
+	}
+	title := fmt.Sprintf("%s commented on your entry", commenterName)
+	return ns.sendToUser(recipientUserID, title, commentPreview, data, "comments")
+}
+
+// SendFriendRequestNotification tells recipientUserID that senderUserID sent them a friend request
+func (ns *NotificationsHandler) SendFriendRequestNotification(recipientUserID, senderUserID string) error {
+	senderName := ns.getUserDisplayName(senderUserID)
+	data := map[string]string{
+		"type":         "friend_request",
+		"sender_id":    senderUserID,
+		"sender_name":  senderName,
+		"recipient_id": recipientUserID,
+	}
+	title := "New friend request"
+	body := fmt.Sprintf("%s sent you a friend request", senderName)
+	return ns.sendToUser(recipientUserID, title, body, data, "friends")
+}
+
+// SendFriendRequestAcceptedNotification tells recipientUserID that accepterUserID accepted their request
+func (ns *NotificationsHandler) SendFriendRequestAcceptedNotification(recipientUserID, accepterUserID string) error {
+	accepterName := ns.getUserDisplayName(accepterUserID)
+	data := map[string]string{
+		"type":          "friend_request_accepted",
+		"accepter_id":   accepterUserID,
+		"accepter_name": accepterName,
+		"recipient_id":  recipientUserID,
+	}
+	title := "Friend request accepted"
+	body := fmt.Sprintf("%s accepted your friend request", accepterName)
+	return ns.sendToUser(recipientUserID, title, body, data, "friends")
+}
+
+// sendToUser looks up the user's push token and sends a notification, preferring FCM over Expo
+func (ns *NotificationsHandler) sendToUser(recipientUserID, title, body string, data map[string]string, channelID string) error {
+	token, err := ns.getPushTokenFromCache(recipientUserID)
+	if err != nil {
+		return err
+	}
+
+	var tokenToUse string
+	if token.FCMToken != nil && *token.FCMToken != "" {
+		tokenToUse = *token.FCMToken
+	} else {
+		tokenToUse = token.ExpoPushToken
+	}
+	if tokenToUse == "" {
+		return fmt.Errorf("no push token available for user %s", recipientUserID)
+	}
+
+	return ns.SendNotification(tokenToUse, title, body, data, channelID)
+}
+
 // Webhook handler for Stream Chat integration
 func (ns *NotificationsHandler) HandleStreamChatWebhook(c *gin.Context) {
 	var webhookData map[string]interface{}
 	if err := c.ShouldBindJSON(&webhookData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, err.Error())
 		return
 	}
 
@@ -528,7 +607,7 @@ func (ns *NotificationsHandler) HandleStreamChatWebhook(c *gin.Context) {
 	// Extract message data
 	message, ok := webhookData["message"].(map[string]interface{})
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message data"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid message data")
 		return
 	}
 
@@ -538,13 +617,19 @@ func (ns *NotificationsHandler) HandleStreamChatWebhook(c *gin.Context) {
 	// Get channel members and send notifications to everyone except sender
 	channelMembers := ns.getChannelMembers(webhookData)
 
-	for _, memberID := range channelMembers {
-		if memberID != senderID {
-			senderName := ns.getUserDisplayName(senderID)
+	recipients, err := ns.messageRecipients(c.Request.Context(), senderID, channelMembers)
+	if err != nil {
+		ns.logError(c, err, "Failed to load blocked users", "sender", senderID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load blocked users")
+		return
+	}
 
+	if len(recipients) > 0 {
+		senderName := ns.getUserDisplayName(senderID)
+		for _, memberID := range recipients {
 			err := ns.SendMessageNotification(memberID, senderName, messageText)
 			if err != nil {
-				log.Printf("Failed to send message notification to %s: %v", memberID, err)
+				ns.logger.Warnw("Failed to send message notification", "recipient", memberID, "error", err)
 			}
 		}
 	}
@@ -552,6 +637,22 @@ func (ns *NotificationsHandler) HandleStreamChatWebhook(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Notifications sent"})
 }
 
+// messageRecipients returns the channel members to notify of senderID's message: everyone
+// but the sender and users who blocked, or were blocked by, the sender
+func (ns *NotificationsHandler) messageRecipients(ctx context.Context, senderID string, members []string) ([]string, error) {
+	blocked, err := blockedUIDs(ctx, ns.db, senderID)
+	if err != nil {
+		return nil, err
+	}
+	recipients := make([]string, 0, len(members))
+	for _, memberID := range members {
+		if memberID != senderID && !blocked[memberID] {
+			recipients = append(recipients, memberID)
+		}
+	}
+	return recipients, nil
+}
+
 // Helper functions
 func (ns *NotificationsHandler) getChannelMembers(webhookData map[string]interface{}) []string {
 	// Extract channel members from Stream Chat webhook
@@ -581,22 +682,22 @@ func (ns *NotificationsHandler) getChannelMembers(webhookData map[string]interfa
 func (ns *NotificationsHandler) getUserDisplayName(userID string) string {
 	// Check Redis cache first
 	cacheKey := fmt.Sprintf("user_name:%s", userID)
-	cached := ns.redisClient.Get(context.Background(), cacheKey)
-	if cached.Err() == nil {
-		return cached.Val()
+	cached, err := ns.cache.Get(context.Background(), cacheKey)
+	if err == nil {
+		return cached
 	}
 
 	// Query your users table in PostgreSQL
 	var displayName string
 	query := `SELECT display_name FROM users WHERE uid = $1`
-	err := ns.db.QueryRow(context.Background(), query, userID).Scan(&displayName)
+	err = ns.db.QueryRow(context.Background(), query, userID).Scan(&displayName)
 
 	if err != nil {
 		displayName = "User" // Fallback
 	}
 
 	// Cache the result
-	ns.redisClient.Set(context.Background(), cacheKey, displayName, time.Hour)
+	ns.cache.Set(context.Background(), cacheKey, displayName, time.Hour)
 
 	return displayName
 }
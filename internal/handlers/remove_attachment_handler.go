@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	removeattachmentmodels "io.winapps.journeyapp/internal/models/remove_attachment"
+)
+
+// RemoveAttachment handles removing a document from an existing journal entry
+func (h *EntryHandler) RemoveAttachment(c *gin.Context) {
+	var req removeattachmentmodels.RemoveAttachmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	// Get UID from context (set by auth middleware)
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+
+	userUID, ok := uid.(string)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
+		return
+	}
+
+	// Validate required fields
+	if req.EntryID == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
+		return
+	}
+
+	if req.AttachmentURL == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Attachment URL is required")
+		return
+	}
+
+	ctx := context.Background()
+
+	// Verify entry exists and belongs to user
+	var entryExists bool
+	entryCheckQuery := `
+		SELECT EXISTS(SELECT 1 FROM entries WHERE id = $1 AND user_uid = $2)
+	`
+	err := h.postgres.QueryRow(ctx, entryCheckQuery, req.EntryID, userUID).Scan(&entryExists)
+	if err != nil {
+		h.logError(c, err, "verify entry failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
+		return
+	}
+
+	if !entryExists {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+		return
+	}
+
+	// Start database transaction
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		h.logError(c, err, "begin transaction failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Remove attachment from database
+	now := time.Now()
+	tag, err := tx.Exec(ctx, `
+		DELETE FROM attachments WHERE entry_id = $1 AND url = $2
+	`, req.EntryID, req.AttachmentURL)
+	if err != nil {
+		h.logError(c, err, "delete attachment failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove attachment")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Attachment not found")
+		return
+	}
+
+	// Update entry's updated_at timestamp
+	updateEntryQuery := `
+		UPDATE entries SET updated_at = $1 WHERE id = $2
+	`
+	_, err = tx.Exec(ctx, updateEntryQuery, now, req.EntryID)
+	if err != nil {
+		h.logError(c, err, "update entry timestamp failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
+		return
+	}
+
+	// Commit transaction
+	if err = tx.Commit(ctx); err != nil {
+		h.logError(c, err, "commit remove attachment tx failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove attachment")
+		return
+	}
+
+	// Delete the physical file once the row is gone; leftovers are picked up by the media sweep
+	if err := h.deleteAttachmentFile(ctx, req.AttachmentURL, userUID, req.EntryID); err != nil {
+		h.logError(c, err, "delete attachment file failed", "attachment_url", req.AttachmentURL)
+	}
+
+	// Invalidate Redis cache for this entry
+	redisKey := "entry:" + req.EntryID
+	h.cache.Del(ctx, redisKey)
+	invalidateAccountDetails(ctx, h.cache, userUID)
+
+	c.JSON(http.StatusOK, removeattachmentmodels.RemoveAttachmentResponse{
+		EntryID:       req.EntryID,
+		AttachmentURL: req.AttachmentURL,
+		Message:       "Attachment removed successfully",
+	})
+}
+
+// deleteAttachmentFile deletes an attachment of uid's entry from media storage
+func (h *EntryHandler) deleteAttachmentFile(ctx context.Context, attachmentURL, uid, entryID string) error {
+	// attachmentURL format: "/attachments/{userUID}/{entryID}/{filename}"; keys outside this entry's directory are refused
+	key, err := mediaKeyFromURL(attachmentURL, "attachments", uid, entryID)
+	if err != nil {
+		return err
+	}
+
+	// A missing file is fine - maybe it was already deleted
+	if err := h.media.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete file %s: %w", key, err)
+	}
+
+	return nil
+}
@@ -2,15 +2,14 @@ package handlers
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 
+	"io.winapps.journeyapp/internal/apierror"
 	removeaudiomodels "io.winapps.journeyapp/internal/models/remove_audio"
 )
 
@@ -18,31 +17,31 @@ import (
 func (h *EntryHandler) RemoveAudio(c *gin.Context) {
 	var req removeaudiomodels.RemoveAudioRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Validate required fields
 	if req.EntryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Entry ID is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
 		return
 	}
 
 	if req.AudioURL == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Audio URL is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Audio URL is required")
 		return
 	}
 
@@ -56,12 +55,12 @@ func (h *EntryHandler) RemoveAudio(c *gin.Context) {
 	err := h.postgres.QueryRow(ctx, entryCheckQuery, req.EntryID, userUID).Scan(&entryExists)
 	if err != nil {
 		h.logError(c, err, "verify entry failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify entry"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
 		return
 	}
 
 	if !entryExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found or access denied"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
 		return
 	}
 
@@ -69,7 +68,7 @@ func (h *EntryHandler) RemoveAudio(c *gin.Context) {
 	tx, err := h.postgres.Begin(ctx)
 	if err != nil {
 		h.logError(c, err, "begin transaction failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start database transaction"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
 		return
 	}
 	defer tx.Rollback(ctx)
@@ -78,26 +77,23 @@ func (h *EntryHandler) RemoveAudio(c *gin.Context) {
 	now := time.Now()
 	audioQuery := `
 		DELETE FROM audio WHERE entry_id = $1 AND url = $2
+		RETURNING url, filename, file_size, mime_type, duration, COALESCE(upload_order, 0), created_at
 	`
-	result, err := tx.Exec(ctx, audioQuery, req.EntryID, req.AudioURL)
-	if err != nil {
-		h.logError(c, err, "delete audio failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove audio"})
+	var removed removedMedia
+	err = tx.QueryRow(ctx, audioQuery, req.EntryID, req.AudioURL).Scan(
+		&removed.URL, &removed.Filename, &removed.FileSize, &removed.MimeType, &removed.Duration,
+		&removed.UploadOrder, &removed.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Audio not found")
 		return
 	}
-
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Audio not found"})
+	if err != nil {
+		h.logError(c, err, "delete audio failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove audio")
 		return
 	}
 
-	// Delete the physical file
-	if err := h.deleteAudioFile(req.AudioURL); err != nil {
-		// Log the error but don't fail the request since the database record is already deleted
-		h.logError(c, err, "delete audio file failed", "audio_url", req.AudioURL)
-	}
-
 	// Update entry's updated_at timestamp
 	updateEntryQuery := `
 		UPDATE entries SET updated_at = $1 WHERE id = $2
@@ -105,55 +101,43 @@ func (h *EntryHandler) RemoveAudio(c *gin.Context) {
 	_, err = tx.Exec(ctx, updateEntryQuery, now, req.EntryID)
 	if err != nil {
 		h.logError(c, err, "update entry timestamp failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry timestamp"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
 		return
 	}
 
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
 		h.logError(c, err, "commit remove audio tx failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove audio"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove audio")
 		return
 	}
 
 	// Invalidate Redis cache for this entry
 	redisKey := "entry:" + req.EntryID
-	h.redis.Del(ctx, redisKey)
+	h.cache.Del(ctx, redisKey)
+	invalidateAccountDetails(ctx, h.cache, userUID)
+
+	// The file goes to the trash rather than being deleted, so the removal can be undone
+	var keys []string
+	// audioURL format: "/audio/{userUID}/{entryID}/{filename}"; keys outside this entry's directory are refused
+	if key, err := mediaKeyFromURL(req.AudioURL, "audio", userUID, req.EntryID); err == nil {
+		keys = append(keys, key)
+	}
+	undoToken, undoExpiresAt := h.recordRemoval(ctx, &pendingRemoval{
+		UID:     userUID,
+		EntryID: req.EntryID,
+		Kind:    removalKindAudio,
+		Media:   &removed,
+	}, keys)
 
 	// Create response
 	response := removeaudiomodels.RemoveAudioResponse{
-		EntryID:  req.EntryID,
-		AudioURL: req.AudioURL,
-		Message:  "Audio removed successfully",
+		EntryID:       req.EntryID,
+		AudioURL:      req.AudioURL,
+		Message:       "Audio removed successfully",
+		UndoToken:     undoToken,
+		UndoExpiresAt: undoExpiresAt,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
-
-// deleteAudioFile deletes the physical audio file from the file system
-func (h *EntryHandler) deleteAudioFile(audioURL string) error {
-	// Extract the file path from the URL
-	// audioURL format: "/audio/{userUID}/{entryID}/{filename}"
-	if !strings.HasPrefix(audioURL, "/audio/") {
-		return fmt.Errorf("invalid audio URL format: %s", audioURL)
-	}
-
-	// Remove the "/audio/" prefix
-	relativePath := strings.TrimPrefix(audioURL, "/audio/")
-
-	// Construct the full file path
-	filePath := filepath.Join("internal", "audio", relativePath)
-
-	// Check if file exists before trying to delete
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		// File doesn't exist, which is fine - maybe it was already deleted
-		return nil
-	}
-
-	// Delete the file
-	if err := os.Remove(filePath); err != nil {
-		return fmt.Errorf("failed to delete file %s: %w", filePath, err)
-	}
-
-	return nil
-}
\ No newline at end of file
@@ -6,52 +6,122 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"io.winapps.journeyapp/internal/apierror"
 )
 
+// revokedShare is an entry_shares row deleted because its two users are no longer friends
+type revokedShare struct {
+	entryID  string
+	ownerUID string
+	userUID  string // the user who lost access
+}
+
+// RemoveFriendship ends a friendship or withdraws a request between uid and fid. Semi-private
+// entries either user shared with the other are unshared in the same transaction, so a
+// removed friend can't keep reading them.
 func (h *UsersHandler) RemoveFriendship(c *gin.Context) {
 	// Require auth
 	uidVal, ok := c.Get("uid")
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 	authUID, _ := uidVal.(string)
 
 	var req friendshipRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request body")
 		return
 	}
 	req.UID = strings.TrimSpace(req.UID)
 	req.FID = strings.TrimSpace(req.FID)
 	if req.UID == "" || req.FID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "uid and fid are required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "uid and fid are required")
 		return
 	}
 
 	// Only involved users can remove
 	if authUID != req.UID && authUID != req.FID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to remove this friendship"})
+		respondError(c, http.StatusForbidden, apierror.CodeForbidden, "Not authorized to remove this friendship")
 		return
 	}
 
 	ctx := context.Background()
-	res, err := h.postgres.Exec(ctx, `
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove friendship")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	res, err := tx.Exec(ctx, `
 		DELETE FROM friendships
-		WHERE (uid = $1 AND fid = $2) OR (uid = $2 AND fid = $1)
+		WHERE ((uid = $1 AND fid = $2) OR (uid = $2 AND fid = $1)) AND status <> 'blocked'
 	`, req.UID, req.FID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove friendship"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove friendship")
 		return
 	}
 	if res.RowsAffected() == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Friendship not found"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Friendship not found")
+		return
+	}
+	revoked, err := revokeSharesBetween(ctx, tx, req.UID, req.FID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove friendship")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove friendship")
 		return
 	}
 
 	// Invalidate caches
-	_ = h.redis.Del(ctx, "friends:"+req.UID).Err()
-	_ = h.redis.Del(ctx, "friends:"+req.FID).Err()
+	h.invalidateRelationshipCaches(ctx, req.UID, req.FID)
+	h.invalidateRevokedShares(ctx, revoked)
 
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
+
+// revokeSharesBetween deletes the entry_shares rows sharing either user's entries with the
+// other and returns them
+func revokeSharesBetween(ctx context.Context, tx pgx.Tx, uid, fid string) ([]revokedShare, error) {
+	rows, err := tx.Query(ctx, `
+		DELETE FROM entry_shares s
+		USING entries e
+		WHERE e.id = s.entry_id
+			AND ((e.user_uid = $1 AND s.shared_user_uid = $2) OR (e.user_uid = $2 AND s.shared_user_uid = $1))
+		RETURNING s.entry_id::text, e.user_uid, s.shared_user_uid
+	`, uid, fid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revoked []revokedShare
+	for rows.Next() {
+		var r revokedShare
+		if err := rows.Scan(&r.entryID, &r.ownerUID, &r.userUID); err != nil {
+			return nil, err
+		}
+		revoked = append(revoked, r)
+	}
+	return revoked, rows.Err()
+}
+
+// invalidateRevokedShares drops the share sets and cached entries affected by revoked
+// shares and marks the owners' feeds as changed
+func (h *UsersHandler) invalidateRevokedShares(ctx context.Context, revoked []revokedShare) {
+	owners := make(map[string]bool)
+	for _, r := range revoked {
+		_ = h.cache.SRem(ctx, "shared_entries:"+r.userUID, r.entryID)
+		_ = h.cache.SRem(ctx, "entry_shares:"+r.entryID, r.userUID)
+		_ = h.cache.Del(ctx, "entry:"+r.entryID)
+		owners[r.ownerUID] = true
+	}
+	for uid := range owners {
+		bumpFeedVersion(ctx, h.cache, uid)
+	}
+}
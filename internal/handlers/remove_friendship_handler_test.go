@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"io.winapps.journeyapp/internal/cache"
+)
+
+func TestInvalidateRevokedShares(t *testing.T) {
+	ctx := context.Background()
+	store := cache.NewMemory(100)
+	h := &UsersHandler{cache: store}
+
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(store.SAdd(ctx, "shared_entries:bob", "e1", "e3"))
+	must(store.SAdd(ctx, "entry_shares:e1", "bob", "carol"))
+	must(store.SAdd(ctx, "shared_entries:alice", "e2"))
+	must(store.SAdd(ctx, "entry_shares:e2", "alice"))
+	must(store.Set(ctx, "entry:e1", "{}", time.Minute))
+	must(store.Set(ctx, "entry:e2", "{}", time.Minute))
+
+	h.invalidateRevokedShares(ctx, []revokedShare{
+		{entryID: "e1", ownerUID: "alice", userUID: "bob"},
+		{entryID: "e2", ownerUID: "bob", userUID: "alice"},
+	})
+
+	members := func(key string) []string {
+		m, err := store.SMembers(ctx, key)
+		must(err)
+		if m == nil {
+			m = []string{}
+		}
+		return m
+	}
+	if got := members("shared_entries:bob"); !reflect.DeepEqual(got, []string{"e3"}) {
+		t.Errorf("shared_entries:bob = %q, want only e3", got)
+	}
+	if got := members("entry_shares:e1"); !reflect.DeepEqual(got, []string{"carol"}) {
+		t.Errorf("entry_shares:e1 = %q, want only carol", got)
+	}
+	if got := members("shared_entries:alice"); len(got) != 0 {
+		t.Errorf("shared_entries:alice = %q, want empty", got)
+	}
+	for _, key := range []string{"entry:e1", "entry:e2"} {
+		if ok, _ := store.Exists(ctx, key); ok {
+			t.Errorf("%s still cached", key)
+		}
+	}
+	for _, uid := range []string{"alice", "bob"} {
+		if v, _ := store.Get(ctx, feedVersionKey(uid)); v == "" {
+			t.Errorf("feed version of %s was not bumped", uid)
+		}
+	}
+}
@@ -2,15 +2,15 @@ package handlers
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
+	"path"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 
+	"io.winapps.journeyapp/internal/apierror"
 	removeimagemodels "io.winapps.journeyapp/internal/models/remove_image"
 )
 
@@ -18,31 +18,31 @@ import (
 func (h *EntryHandler) RemoveImage(c *gin.Context) {
 	var req removeimagemodels.RemoveImageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Validate required fields
 	if req.EntryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Entry ID is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
 		return
 	}
 
 	if req.ImageURL == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Image URL is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Image URL is required")
 		return
 	}
 
@@ -56,12 +56,12 @@ func (h *EntryHandler) RemoveImage(c *gin.Context) {
 	err := h.postgres.QueryRow(ctx, entryCheckQuery, req.EntryID, userUID).Scan(&entryExists)
 	if err != nil {
 		h.logError(c, err, "verify entry failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify entry"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
 		return
 	}
 
 	if !entryExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found or access denied"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
 		return
 	}
 
@@ -69,7 +69,7 @@ func (h *EntryHandler) RemoveImage(c *gin.Context) {
 	tx, err := h.postgres.Begin(ctx)
 	if err != nil {
 		h.logError(c, err, "begin transaction failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start database transaction"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
 		return
 	}
 	defer tx.Rollback(ctx)
@@ -78,26 +78,23 @@ func (h *EntryHandler) RemoveImage(c *gin.Context) {
 	now := time.Now()
 	imageQuery := `
 		DELETE FROM images WHERE entry_id = $1 AND url = $2
+		RETURNING url, filename, file_size, mime_type, width, height, webp_url, COALESCE(upload_order, 0), created_at
 	`
-	result, err := tx.Exec(ctx, imageQuery, req.EntryID, req.ImageURL)
-	if err != nil {
-		h.logError(c, err, "delete image failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove image"})
+	var removed removedMedia
+	err = tx.QueryRow(ctx, imageQuery, req.EntryID, req.ImageURL).Scan(
+		&removed.URL, &removed.Filename, &removed.FileSize, &removed.MimeType, &removed.Width, &removed.Height,
+		&removed.WebPURL, &removed.UploadOrder, &removed.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Image not found")
 		return
 	}
-
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+	if err != nil {
+		h.logError(c, err, "delete image failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove image")
 		return
 	}
 
-	// Delete the physical file
-	if err := h.deleteImageFile(req.ImageURL); err != nil {
-		// Log the error but don't fail the request since the database record is already deleted
-		h.logError(c, err, "delete image file failed", "image_url", req.ImageURL)
-	}
-
 	// Update entry's updated_at timestamp
 	updateEntryQuery := `
 		UPDATE entries SET updated_at = $1 WHERE id = $2
@@ -105,55 +102,59 @@ func (h *EntryHandler) RemoveImage(c *gin.Context) {
 	_, err = tx.Exec(ctx, updateEntryQuery, now, req.EntryID)
 	if err != nil {
 		h.logError(c, err, "update entry timestamp failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry timestamp"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
 		return
 	}
 
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
 		h.logError(c, err, "commit remove image tx failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove image"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove image")
 		return
 	}
 
 	// Invalidate Redis cache for this entry
 	redisKey := "entry:" + req.EntryID
-	h.redis.Del(ctx, redisKey)
+	h.cache.Del(ctx, redisKey)
+	invalidateAccountDetails(ctx, h.cache, userUID)
+
+	// The file goes to the trash rather than being deleted, so the removal can be undone
+	undoToken, undoExpiresAt := h.recordRemoval(ctx, &pendingRemoval{
+		UID:     userUID,
+		EntryID: req.EntryID,
+		Kind:    removalKindImage,
+		Media:   &removed,
+	}, imageFileKeys(req.ImageURL, userUID, req.EntryID))
 
 	// Create response
 	response := removeimagemodels.RemoveImageResponse{
-		EntryID:  req.EntryID,
-		ImageURL: req.ImageURL,
-		Message:  "Image removed successfully",
+		EntryID:       req.EntryID,
+		ImageURL:      req.ImageURL,
+		Message:       "Image removed successfully",
+		UndoToken:     undoToken,
+		UndoExpiresAt: undoExpiresAt,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-// deleteImageFile deletes the physical image file from the file system
-func (h *EntryHandler) deleteImageFile(imageURL string) error {
-	// Extract the file path from the URL
+// imageFileKeys returns the storage keys of an image of uid's entry and of its WebP variant
+// if it can have one. A URL outside images/<uid>/<entryID>/ has no keys, so a row pointing
+// at someone else's file only loses the row.
+func imageFileKeys(imageURL, uid, entryID string) []string {
 	// imageURL format: "/images/{userUID}/{entryID}/{filename}"
-	if !strings.HasPrefix(imageURL, "/images/") {
-		return fmt.Errorf("invalid image URL format: %s", imageURL)
-	}
-
-	// Remove the "/images/" prefix
-	relativePath := strings.TrimPrefix(imageURL, "/images/")
-
-	// Construct the full file path
-	filePath := filepath.Join("internal", "images", relativePath)
-
-	// Check if file exists before trying to delete
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		// File doesn't exist, which is fine - maybe it was already deleted
+	key, err := mediaKeyFromURL(imageURL, "images", uid, entryID)
+	if err != nil {
 		return nil
 	}
+	keys := []string{key}
 
-	// Delete the file
-	if err := os.Remove(filePath); err != nil {
-		return fmt.Errorf("failed to delete file %s: %w", filePath, err)
+	// And its WebP variant, if transcoding made one
+	if webpConvertible(path.Ext(imageURL)) {
+		if variantKey, err := mediaKeyFromURL(webpVariantURL(imageURL), "images", uid, entryID); err == nil {
+			keys = append(keys, variantKey)
+		}
 	}
 
-	return nil
-}
\ No newline at end of file
+	return keys
+}
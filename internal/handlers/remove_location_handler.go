@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"io.winapps.journeyapp/internal/apierror"
 	removelocationmodels "io.winapps.journeyapp/internal/models/remove_location"
 )
 
@@ -14,26 +15,26 @@ import (
 func (h *EntryHandler) RemoveLocation(c *gin.Context) {
 	var req removelocationmodels.RemoveLocationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Validate required fields
 	if req.EntryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Entry ID is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
 		return
 	}
 
@@ -46,19 +47,19 @@ func (h *EntryHandler) RemoveLocation(c *gin.Context) {
 	`
 	err := h.postgres.QueryRow(ctx, entryCheckQuery, req.EntryID, userUID).Scan(&entryExists)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify entry"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
 		return
 	}
 
 	if !entryExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found or access denied"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
 		return
 	}
 
 	// Start database transaction
 	tx, err := h.postgres.Begin(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start database transaction"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
 		return
 	}
 	defer tx.Rollback(ctx)
@@ -67,16 +68,21 @@ func (h *EntryHandler) RemoveLocation(c *gin.Context) {
 	now := time.Now()
 	locationQuery := `
 		DELETE FROM locations WHERE entry_id = $1 AND latitude = $2 AND longitude = $3
+		RETURNING latitude, longitude, address, city, state, zip, country, country_code, display_name, created_at
 	`
-	result, err := tx.Exec(ctx, locationQuery, req.EntryID, req.Location.Latitude, req.Location.Longitude)
+	rows, err := tx.Query(ctx, locationQuery, req.EntryID, req.Location.Latitude, req.Location.Longitude)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove location"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove location")
+		return
+	}
+	removed, err := removedLocationsFromRows(rows)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove location")
 		return
 	}
 
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+	if len(removed) == 0 {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Location not found")
 		return
 	}
 
@@ -86,25 +92,36 @@ func (h *EntryHandler) RemoveLocation(c *gin.Context) {
 	`
 	_, err = tx.Exec(ctx, updateEntryQuery, now, req.EntryID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry timestamp"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
 		return
 	}
 
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove location"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove location")
 		return
 	}
 
 	// Invalidate Redis cache for this entry
 	redisKey := "entry:" + req.EntryID
-	h.redis.Del(ctx, redisKey)
+	h.cache.Del(ctx, redisKey)
+	h.invalidateUniqueLocationsCache(ctx, userUID)
+	invalidateAccountDetails(ctx, h.cache, userUID)
+
+	undoToken, undoExpiresAt := h.recordRemoval(ctx, &pendingRemoval{
+		UID:       userUID,
+		EntryID:   req.EntryID,
+		Kind:      removalKindLocation,
+		Locations: removed,
+	}, nil)
 
 	// Create response
 	response := removelocationmodels.RemoveLocationResponse{
-		EntryID:  req.EntryID,
-		Location: req.Location,
-		Message:  "Location removed successfully",
+		EntryID:       req.EntryID,
+		Location:      req.Location,
+		Message:       "Location removed successfully",
+		UndoToken:     undoToken,
+		UndoExpiresAt: undoExpiresAt,
 	}
 
 	c.JSON(http.StatusOK, response)
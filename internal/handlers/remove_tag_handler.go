@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 
+	"io.winapps.journeyapp/internal/apierror"
 	removetagmodels "io.winapps.journeyapp/internal/models/remove_tag"
 )
 
@@ -14,31 +17,31 @@ import (
 func (h *EntryHandler) RemoveTag(c *gin.Context) {
 	var req removetagmodels.RemoveTagRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Validate required fields
 	if req.EntryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Entry ID is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
 		return
 	}
 
 	if req.Tag.Key == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Tag key is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Tag key is required")
 		return
 	}
 
@@ -51,19 +54,19 @@ func (h *EntryHandler) RemoveTag(c *gin.Context) {
 	`
 	err := h.postgres.QueryRow(ctx, entryCheckQuery, req.EntryID, userUID).Scan(&entryExists)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify entry"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
 		return
 	}
 
 	if !entryExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found or access denied"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
 		return
 	}
 
 	// Start database transaction
 	tx, err := h.postgres.Begin(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start database transaction"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
 		return
 	}
 	defer tx.Rollback(ctx)
@@ -72,16 +75,16 @@ func (h *EntryHandler) RemoveTag(c *gin.Context) {
 	now := time.Now()
 	tagQuery := `
 		DELETE FROM tags WHERE entry_id = $1 AND key = $2 AND value = $3
+		RETURNING key, value, created_at
 	`
-	result, err := tx.Exec(ctx, tagQuery, req.EntryID, req.Tag.Key, req.Tag.Value)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove tag"})
+	var removed removedTag
+	err = tx.QueryRow(ctx, tagQuery, req.EntryID, req.Tag.Key, req.Tag.Value).Scan(&removed.Key, &removed.Value, &removed.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Tag not found")
 		return
 	}
-
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove tag")
 		return
 	}
 
@@ -91,25 +94,35 @@ func (h *EntryHandler) RemoveTag(c *gin.Context) {
 	`
 	_, err = tx.Exec(ctx, updateEntryQuery, now, req.EntryID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry timestamp"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
 		return
 	}
 
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove tag"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove tag")
 		return
 	}
 
 	// Invalidate Redis cache for this entry
 	redisKey := "entry:" + req.EntryID
-	h.redis.Del(ctx, redisKey)
+	h.cache.Del(ctx, redisKey)
+	invalidateAccountDetails(ctx, h.cache, userUID)
+
+	undoToken, undoExpiresAt := h.recordRemoval(ctx, &pendingRemoval{
+		UID:     userUID,
+		EntryID: req.EntryID,
+		Kind:    removalKindTag,
+		Tags:    []removedTag{removed},
+	}, nil)
 
 	// Create response
 	response := removetagmodels.RemoveTagResponse{
-		EntryID: req.EntryID,
-		Tag:     req.Tag,
-		Message: "Tag removed successfully",
+		EntryID:       req.EntryID,
+		Tag:           req.Tag,
+		Message:       "Tag removed successfully",
+		UndoToken:     undoToken,
+		UndoExpiresAt: undoExpiresAt,
 	}
 
 	c.JSON(http.StatusOK, response)
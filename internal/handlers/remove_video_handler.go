@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"io.winapps.journeyapp/internal/apierror"
+	removevideomodels "io.winapps.journeyapp/internal/models/remove_video"
+)
+
+// RemoveVideo handles removing a video clip and its poster from a journal entry
+func (h *EntryHandler) RemoveVideo(c *gin.Context) {
+	var req removevideomodels.RemoveVideoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	// Get UID from context (set by auth middleware)
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+
+	userUID, ok := uid.(string)
+	if !ok {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
+		return
+	}
+
+	if req.EntryID == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
+		return
+	}
+
+	if req.VideoURL == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Video URL is required")
+		return
+	}
+
+	ctx := context.Background()
+
+	// Verify entry exists and belongs to user
+	var entryExists bool
+	entryCheckQuery := `
+		SELECT EXISTS(SELECT 1 FROM entries WHERE id = $1 AND user_uid = $2)
+	`
+	err := h.postgres.QueryRow(ctx, entryCheckQuery, req.EntryID, userUID).Scan(&entryExists)
+	if err != nil {
+		h.logError(c, err, "verify entry failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
+		return
+	}
+
+	if !entryExists {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+		return
+	}
+
+	// Start database transaction
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		h.logError(c, err, "begin transaction failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Remove video from database
+	now := time.Now()
+	var thumbnailURL *string
+	err = tx.QueryRow(ctx, `
+		DELETE FROM videos WHERE entry_id = $1 AND url = $2 RETURNING thumbnail_url
+	`, req.EntryID, req.VideoURL).Scan(&thumbnailURL)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Video not found")
+		return
+	}
+	if err != nil {
+		h.logError(c, err, "delete video failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove video")
+		return
+	}
+
+	// Update entry's updated_at timestamp
+	updateEntryQuery := `
+		UPDATE entries SET updated_at = $1 WHERE id = $2
+	`
+	_, err = tx.Exec(ctx, updateEntryQuery, now, req.EntryID)
+	if err != nil {
+		h.logError(c, err, "update entry timestamp failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
+		return
+	}
+
+	// Commit transaction
+	if err = tx.Commit(ctx); err != nil {
+		h.logError(c, err, "commit remove video tx failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to remove video")
+		return
+	}
+
+	// Delete the physical files once the row is gone; leftovers are picked up by the media sweep
+	for _, url := range []*string{&req.VideoURL, thumbnailURL} {
+		if url == nil {
+			continue
+		}
+		if err := h.deleteVideoFile(ctx, *url, userUID, req.EntryID); err != nil {
+			h.logError(c, err, "delete video file failed", "video_url", *url)
+		}
+	}
+
+	// Invalidate Redis cache for this entry
+	redisKey := "entry:" + req.EntryID
+	h.cache.Del(ctx, redisKey)
+	invalidateAccountDetails(ctx, h.cache, userUID)
+
+	// Create response
+	response := removevideomodels.RemoveVideoResponse{
+		EntryID:  req.EntryID,
+		VideoURL: req.VideoURL,
+		Message:  "Video removed successfully",
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// deleteVideoFile deletes a video or poster file of uid's entry from media storage
+func (h *EntryHandler) deleteVideoFile(ctx context.Context, videoURL, uid, entryID string) error {
+	// videoURL format: "/videos/{userUID}/{entryID}/{filename}"; keys outside this entry's directory are refused
+	key, err := mediaKeyFromURL(videoURL, "videos", uid, entryID)
+	if err != nil {
+		return err
+	}
+
+	// A missing file is fine - maybe it was already deleted
+	if err := h.media.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete file %s: %w", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+)
+
+// abortOnContextError writes a 504/503 response when err was caused by the request
+// deadline or client cancellation. It returns true if a response was written.
+func abortOnContextError(c *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		respondError(c, http.StatusGatewayTimeout, apierror.CodeTimeout, "Request timed out")
+		return true
+	}
+	if errors.Is(err, context.Canceled) {
+		respondError(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, "Request was cancelled")
+		return true
+	}
+	return false
+}
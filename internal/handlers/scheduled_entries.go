@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// setupScheduledPublishing schedules the per-minute job that publishes scheduled entries
+func (ns *NotificationsHandler) setupScheduledPublishing() {
+	_, err := ns.cronManager.AddFunc("* * * * *", ns.trackedJob(func() {
+		ns.publishScheduledEntries(context.Background())
+	}))
+	if err != nil {
+		ns.logger.Errorw("Failed to schedule entry publishing", "error", err)
+	}
+}
+
+// publishScheduledEntries publishes every scheduled entry whose publish_at has passed and
+// lets each owner know. The UPDATE claims the rows, so overlapping runs can't notify twice.
+func (ns *NotificationsHandler) publishScheduledEntries(ctx context.Context) {
+	now := time.Now().UTC()
+	rows, err := ns.db.Query(ctx, `
+		UPDATE entries SET status = 'published', publish_at = NULL, updated_at = $1
+		WHERE status = 'scheduled' AND publish_at <= $1
+		RETURNING id, user_uid, title, encrypted
+	`, now)
+	if err != nil {
+		ns.logger.Errorw("Failed to publish scheduled entries", "error", err)
+		return
+	}
+	type published struct {
+		id, uid, title string
+		encrypted      bool
+	}
+	var entries []published
+	for rows.Next() {
+		var p published
+		if err := rows.Scan(&p.id, &p.uid, &p.title, &p.encrypted); err == nil {
+			entries = append(entries, p)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		ns.logger.Errorw("Failed to publish scheduled entries", "error", err)
+		return
+	}
+
+	for _, p := range entries {
+		_ = ns.cache.Del(ctx, fmt.Sprintf("entry:%s", p.id))
+		bumpFeedVersion(ctx, ns.cache, p.uid)
+
+		data := map[string]string{
+			"type":    "entry_published",
+			"entryId": p.id,
+		}
+		// Encrypted titles stay out of push payloads, which pass through third parties
+		body := fmt.Sprintf("\"%s\" is now published.", p.title)
+		if p.encrypted {
+			body = "Your scheduled entry is now published."
+		}
+		if err := ns.sendToUser(p.uid, "Your entry is live", body, data, "prompts"); err != nil {
+			ns.logger.Warnw("Failed to send entry published notification", "recipient", p.uid, "entryId", p.id, "error", err)
+		}
+	}
+	if len(entries) > 0 {
+		ns.logger.Infow("Published scheduled entries", "count", len(entries))
+	}
+}
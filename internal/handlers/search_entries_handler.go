@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"io.winapps.journeyapp/internal/apierror"
 	models "io.winapps.journeyapp/internal/models/account"
 	searchmodels "io.winapps.journeyapp/internal/models/search_entries"
 )
@@ -35,17 +36,18 @@ func (h *EntryHandler) SearchEntries(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		// If JSON parsing fails, it's still OK - we can search with just query params
 	}
+	req.IncludeDrafts, _ = strconv.ParseBool(c.Query("includeDrafts"))
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
@@ -59,16 +61,42 @@ func (h *EntryHandler) SearchEntries(c *gin.Context) {
 	if req.Filters.Timeframe.Type == "" {
 		req.Filters.Timeframe.Type = "All"
 	}
+	if req.Filters.UpdatedWithin.Type == "" {
+		req.Filters.UpdatedWithin.Type = "All"
+	}
 	if req.Filters.SortRule == "" {
 		req.Filters.SortRule = "Newest"
 	}
+	req.Filters.TagMatchMode = strings.ToLower(strings.TrimSpace(req.Filters.TagMatchMode))
+	if req.Filters.TagMatchMode == "" {
+		req.Filters.TagMatchMode = "all"
+	}
+	if req.Filters.TagMatchMode != "all" && req.Filters.TagMatchMode != "any" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "tagMatchMode must be all or any")
+		return
+	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
+
+	// Encrypted titles and descriptions can't be matched in SQL, so for those users the
+	// query only matches locations and the client searches the text itself
+	cipher := newEntryCipher(h.postgres, h.entryKeys)
+	encrypted, err := cipher.enabled(ctx, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to search entries")
+		return
+	}
 
 	// Build the search query
-	entries, total, err := h.searchEntriesWithFilters(ctx, userUID, req)
+	entries, total, err := h.searchEntriesWithFilters(ctx, cipher, userUID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search entries"})
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to search entries")
 		return
 	}
 
@@ -87,21 +115,38 @@ func (h *EntryHandler) SearchEntries(c *gin.Context) {
 			HasNext:     hasNext,
 			HasPrevious: hasPrevious,
 		},
+		TextSearchSkipped: encrypted && req.SearchQuery != "",
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-// searchEntriesWithFilters performs the actual search with all filters and returns entries
-func (h *EntryHandler) searchEntriesWithFilters(ctx context.Context, userUID string, req searchmodels.SearchEntriesRequest) ([]searchmodels.EntryResult, int, error) {
+// searchEntriesWithFilters performs the actual search with all filters and returns entries,
+// decrypted with cipher
+func (h *EntryHandler) searchEntriesWithFilters(ctx context.Context, cipher *entryCipher, userUID string, req searchmodels.SearchEntriesRequest) ([]searchmodels.EntryResult, int, error) {
 	// Build WHERE clause to include visibility access
 	whereConditions := []string{"e.user_uid = $1"}
 	args := []interface{}{userUID}
 	argCounter := 2
 
+	// Drafts and scheduled entries are left out unless asked for
+	if !req.IncludeDrafts {
+		whereConditions = append(whereConditions, "e.status = 'published'")
+	}
+
 	// Add timeframe filter
 	if req.Filters.Timeframe.Type != "All" {
-		timeCondition, timeArgs := h.buildTimeframeCondition(req.Filters.Timeframe, argCounter)
+		timeCondition, timeArgs := h.buildTimeframeCondition("e.created_at", req.Filters.Timeframe, argCounter)
+		if timeCondition != "" {
+			whereConditions = append(whereConditions, timeCondition)
+			args = append(args, timeArgs...)
+			argCounter += len(timeArgs)
+		}
+	}
+
+	// Add updated-at timeframe filter (e.g. entries that recently gained photos or tags)
+	if req.Filters.UpdatedWithin.Type != "All" {
+		timeCondition, timeArgs := h.buildTimeframeCondition("e.updated_at", req.Filters.UpdatedWithin, argCounter)
 		if timeCondition != "" {
 			whereConditions = append(whereConditions, timeCondition)
 			args = append(args, timeArgs...)
@@ -120,12 +165,14 @@ func (h *EntryHandler) searchEntriesWithFilters(ctx context.Context, userUID str
 		whereConditions = append(whereConditions, fmt.Sprintf("e.visibility IN (%s)", strings.Join(visPlaceholders, ",")))
 	}
 
+	// Add "has media" filters
+	whereConditions = append(whereConditions, mediaPresenceConditions(req.Filters)...)
+
 	// Add search query filter
 	searchJoins := ""
 	if req.SearchQuery != "" {
 		searchCondition := fmt.Sprintf(`(
-			e.title ILIKE $%d OR
-			e.description ILIKE $%d OR
+			(NOT e.encrypted AND (e.title ILIKE $%d OR e.description ILIKE $%d)) OR
 			EXISTS (SELECT 1 FROM locations l WHERE l.entry_id = e.id AND l.display_name ILIKE $%d)
 		)`, argCounter, argCounter, argCounter)
 		whereConditions = append(whereConditions, searchCondition)
@@ -154,13 +201,25 @@ func (h *EntryHandler) searchEntriesWithFilters(ctx context.Context, userUID str
 	if len(req.Filters.Tags) > 0 {
 		tagConditions := []string{}
 		for _, tag := range req.Filters.Tags {
+			// An empty value matches the key with any value
+			if tag.Value == "" {
+				condition := fmt.Sprintf(`EXISTS (SELECT 1 FROM tags t WHERE t.entry_id = e.id AND t.key = $%d)`, argCounter)
+				tagConditions = append(tagConditions, condition)
+				args = append(args, tag.Key)
+				argCounter++
+				continue
+			}
 			condition := fmt.Sprintf(`EXISTS (SELECT 1 FROM tags t WHERE t.entry_id = e.id AND t.key = $%d AND t.value = $%d)`, argCounter, argCounter+1)
 			tagConditions = append(tagConditions, condition)
 			args = append(args, tag.Key, tag.Value)
 			argCounter += 2
 		}
+		joiner := " AND "
+		if req.Filters.TagMatchMode == "any" {
+			joiner = " OR "
+		}
 		if len(tagConditions) > 0 {
-			whereConditions = append(whereConditions, "("+strings.Join(tagConditions, " AND ")+")")
+			whereConditions = append(whereConditions, "("+strings.Join(tagConditions, joiner)+")")
 		}
 	}
 
@@ -168,8 +227,13 @@ func (h *EntryHandler) searchEntriesWithFilters(ctx context.Context, userUID str
 
 	// Build ORDER BY clause
 	orderBy := "ORDER BY e.created_at DESC"
-	if req.Filters.SortRule == "Oldest" {
+	switch req.Filters.SortRule {
+	case "Oldest":
 		orderBy = "ORDER BY e.created_at ASC"
+	case "RecentlyUpdated":
+		orderBy = "ORDER BY e.updated_at DESC, e.created_at DESC"
+	case "LeastRecentlyUpdated":
+		orderBy = "ORDER BY e.updated_at ASC, e.created_at ASC"
 	}
 
 	// Count total entries
@@ -191,7 +255,7 @@ func (h *EntryHandler) searchEntriesWithFilters(ctx context.Context, userUID str
 
 	// Get entries
 	entriesQuery := fmt.Sprintf(`
-		SELECT DISTINCT e.id, e.title, e.description, e.visibility, e.created_at, e.updated_at
+		SELECT DISTINCT e.id, e.title, e.description, e.encrypted, e.visibility, e.status, e.publish_at, e.created_at, e.updated_at
 		FROM entries e
 		%s
 		%s
@@ -212,12 +276,16 @@ func (h *EntryHandler) searchEntriesWithFilters(ctx context.Context, userUID str
 
 	for rows.Next() {
 		var entry searchmodels.EntryResult
-		if err := rows.Scan(&entry.ID, &entry.Title, &entry.Description, &entry.Visibility, &entry.CreatedAt, &entry.UpdatedAt); err != nil {
+		if err := rows.Scan(&entry.ID, &entry.Title, &entry.Description, &entry.Encrypted, &entry.Visibility, &entry.Status, &entry.PublishAt, &entry.CreatedAt, &entry.UpdatedAt); err != nil {
 			return nil, 0, fmt.Errorf("failed to scan entry: %w", err)
 		}
+		if err := cipher.open(ctx, userUID, entry.Encrypted, &entry.Title, &entry.Description); err != nil {
+			return nil, 0, fmt.Errorf("failed to decrypt entry: %w", err)
+		}
 
 		// Initialize slices
 		entry.Images = []string{}
+		entry.Videos = []models.Video{}
 		entry.Tags = []models.Tag{}
 		entry.Locations = []models.Location{}
 
@@ -243,34 +311,56 @@ func (h *EntryHandler) searchEntriesWithFilters(ctx context.Context, userUID str
 	return entries, total, nil
 }
 
-// buildTimeframeCondition creates SQL condition for timeframe filter
-func (h *EntryHandler) buildTimeframeCondition(timeframe searchmodels.TimeframeFilter, argCounter int) (string, []interface{}) {
+// mediaPresenceConditions builds EXISTS / NOT EXISTS conditions for the tri-state Has* filters
+func mediaPresenceConditions(filters searchmodels.SearchFilters) []string {
+	var conditions []string
+	add := func(want *bool, table string) {
+		if want == nil {
+			return
+		}
+		exists := fmt.Sprintf("EXISTS (SELECT 1 FROM %s m WHERE m.entry_id = e.id)", table)
+		if *want {
+			conditions = append(conditions, exists)
+		} else {
+			conditions = append(conditions, "NOT "+exists)
+		}
+	}
+	add(filters.HasImages, "images")
+	add(filters.HasAudio, "audio")
+	add(filters.HasVideos, "videos")
+	add(filters.HasLocation, "locations")
+	add(filters.HasTags, "tags")
+	return conditions
+}
+
+// buildTimeframeCondition creates SQL condition for timeframe filter on column (e.created_at or e.updated_at)
+func (h *EntryHandler) buildTimeframeCondition(column string, timeframe searchmodels.TimeframeFilter, argCounter int) (string, []interface{}) {
 	now := time.Now()
 
 	switch timeframe.Type {
 	case "custom":
 		if timeframe.FromDate != nil && timeframe.ToDate != nil {
-			return fmt.Sprintf("e.created_at BETWEEN $%d AND $%d", argCounter, argCounter+1),
+			return fmt.Sprintf("%s BETWEEN $%d AND $%d", column, argCounter, argCounter+1),
 				   []interface{}{*timeframe.FromDate, *timeframe.ToDate}
 		}
 	case "Past year":
 		oneYearAgo := now.AddDate(-1, 0, 0)
-		return fmt.Sprintf("e.created_at >= $%d", argCounter), []interface{}{oneYearAgo}
+		return fmt.Sprintf("%s >= $%d", column, argCounter), []interface{}{oneYearAgo}
 	case "Past 6 months":
 		sixMonthsAgo := now.AddDate(0, -6, 0)
-		return fmt.Sprintf("e.created_at >= $%d", argCounter), []interface{}{sixMonthsAgo}
+		return fmt.Sprintf("%s >= $%d", column, argCounter), []interface{}{sixMonthsAgo}
 	case "Past 3 months":
 		threeMonthsAgo := now.AddDate(0, -3, 0)
-		return fmt.Sprintf("e.created_at >= $%d", argCounter), []interface{}{threeMonthsAgo}
+		return fmt.Sprintf("%s >= $%d", column, argCounter), []interface{}{threeMonthsAgo}
 	case "Past 30 days":
 		thirtyDaysAgo := now.AddDate(0, 0, -30)
-		return fmt.Sprintf("e.created_at >= $%d", argCounter), []interface{}{thirtyDaysAgo}
+		return fmt.Sprintf("%s >= $%d", column, argCounter), []interface{}{thirtyDaysAgo}
 	}
 
 	return "", []interface{}{}
 }
 
-// fetchRelatedDataForEntries efficiently fetches tags, locations, and images for multiple entries
+// fetchRelatedDataForEntries efficiently fetches tags, locations, images, audio and videos for multiple entries
 func (h *EntryHandler) fetchRelatedDataForEntries(ctx context.Context, entryIDs []string, entryMap map[string]*searchmodels.EntryResult) error {
 	if len(entryIDs) == 0 {
 		return nil
@@ -391,5 +481,29 @@ func (h *EntryHandler) fetchRelatedDataForEntries(ctx context.Context, entryIDs
 		}
 	}
 
+	// Fetch videos
+	videoQuery := fmt.Sprintf(`
+		SELECT entry_id, url, COALESCE(thumbnail_url, ''), COALESCE(duration, 0) FROM videos
+		WHERE entry_id IN (%s)
+		ORDER BY entry_id, upload_order
+	`, inClause)
+
+	videoRows, err := h.postgres.Query(ctx, videoQuery, args...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch videos: %w", err)
+	}
+	defer videoRows.Close()
+
+	for videoRows.Next() {
+		var entryID string
+		var video models.Video
+		if err := videoRows.Scan(&entryID, &video.URL, &video.ThumbnailURL, &video.Duration); err != nil {
+			return fmt.Errorf("failed to scan video: %w", err)
+		}
+		if entry, exists := entryMap[entryID]; exists {
+			entry.Videos = append(entry.Videos, video)
+		}
+	}
+
 	return nil
 }
\ No newline at end of file
@@ -5,33 +5,90 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"io.winapps.journeyapp/internal/apierror"
 	searchusersmodels "io.winapps.journeyapp/internal/models/search_users"
 )
 
-// SearchUsers finds users by display name or email using a case-insensitive partial match
+const (
+	defaultSearchUsersLimit = 20
+	maxSearchUsersLimit     = 50
+)
+
+// likeEscaper escapes LIKE wildcards so a query is matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// searchUsersFilter matches users against the escaped pattern $1 for the searcher $2,
+// leaving out the searcher and anyone with a friendship in one of the statuses in $3
+const searchUsersFilter = `
+	(u.display_name ILIKE $1 OR u.email ILIKE $1)
+	AND u.uid <> $2
+	AND NOT EXISTS (
+		SELECT 1 FROM friendships f
+		WHERE f.status = ANY($3)
+			AND ((f.uid = $2 AND f.fid = u.uid) OR (f.fid = $2 AND f.uid = u.uid))
+	)`
+
+// SearchUsers finds users by display name or email using a case-insensitive partial match.
+// It serves the "add friend" flow, so the requester and anyone they already have a pending,
+// approved or blocked friendship with are left out. With includeConnected=true pending and
+// approved friends are kept; blocks never are. Each result carries its relationship to the
+// requester (none, pending or approved) and how many published public entries the user
+// has and when the latest was written, so the app can show how active they are.
+// Query params: search-query (required), limit (default 20, max 50), offset, includeConnected
 func (h *UsersHandler) SearchUsers(c *gin.Context) {
 	// Ensure request is authenticated (middleware sets uid)
-	if _, exists := c.Get("uid"); !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+	uidVal, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
+	authUID, _ := uidVal.(string)
 
 	query := strings.TrimSpace(c.Query("search-query"))
 	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "search-query is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "search-query is required")
 		return
 	}
 
-	ctx := context.Background()
-	cacheKey := fmt.Sprintf("search_users:%s", strings.ToLower(query))
+	limit := defaultSearchUsersLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "limit must be a positive integer")
+			return
+		}
+		if n > maxSearchUsersLimit {
+			n = maxSearchUsersLimit
+		}
+		limit = n
+	}
+	offset := 0
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "offset must be a non-negative integer")
+			return
+		}
+		offset = n
+	}
+	excluded := []string{"pending", "approved", "blocked"}
+	includeConnected, _ := strconv.ParseBool(c.Query("includeConnected"))
+	if includeConnected {
+		excluded = []string{"blocked"}
+	}
+
+	ctx := c.Request.Context()
+	// Results depend on the requester's friendships, so pages are cached per requester
+	cacheKey := fmt.Sprintf("search_users:%s:%t:%s:%d:%d", authUID, includeConnected, strings.ToLower(query), limit, offset)
 
 	// Try Redis cache first
-	if cached, err := h.redis.Get(ctx, cacheKey).Result(); err == nil && cached != "" {
+	if cached, err := h.cache.Get(ctx, cacheKey); err == nil && cached != "" {
 		var cachedResponse searchusersmodels.SearchUsersResponse
 		if err := json.Unmarshal([]byte(cached), &cachedResponse); err == nil {
 			c.JSON(http.StatusOK, cachedResponse)
@@ -39,47 +96,99 @@ func (h *UsersHandler) SearchUsers(c *gin.Context) {
 		}
 	}
 
-	like := fmt.Sprintf("%%%s%%", query)
+	// The leading wildcard is served by the pg_trgm GIN indexes on display_name and email
+	like := "%" + likeEscaper.Replace(query) + "%"
 	rows, err := h.postgres.Query(ctx, `
-		SELECT uid, display_name, email, photo_url, created_at, is_premium
-		FROM users
-		WHERE display_name ILIKE $1 OR email ILIKE $1
-		ORDER BY display_name
-		LIMIT 50
-	`, like)
+		SELECT u.uid, COALESCE(u.display_name, ''), u.email, COALESCE(u.photo_url, ''), u.created_at, u.is_premium,
+		       activity.public_entries, activity.last_public_entry_at,
+		       CASE WHEN rel.status IN ('pending', 'approved') THEN rel.status ELSE 'none' END,
+		       COUNT(*) OVER() AS total
+		FROM users u
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS public_entries, MAX(e.created_at) AS last_public_entry_at
+			FROM entries e
+			WHERE e.user_uid = u.uid AND e.visibility = 'public' AND e.status = 'published'
+		) activity
+		LEFT JOIN friendships rel
+			ON (rel.uid = $2 AND rel.fid = u.uid) OR (rel.fid = $2 AND rel.uid = u.uid)
+		WHERE `+searchUsersFilter+`
+		ORDER BY u.display_name, u.uid
+		LIMIT $4 OFFSET $5
+	`, like, authUID, excluded, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to search users")
 		return
 	}
 	defer rows.Close()
 
 	results := make([]searchusersmodels.SearchUserResult, 0)
+	total := 0
 	for rows.Next() {
 		var uid, displayName, email, photoURL string
 		var createdAt time.Time
 		var isPremium bool
-		if err := rows.Scan(&uid, &displayName, &email, &photoURL, &createdAt, &isPremium); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read results " + err.Error() })
+		var publicEntries int
+		var lastPublicEntryAt *time.Time
+		var relationship string
+		if err := rows.Scan(&uid, &displayName, &email, &photoURL, &createdAt, &isPremium, &publicEntries, &lastPublicEntryAt, &relationship, &total); err != nil {
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to read results "+err.Error())
 			return
 		}
 		results = append(results, searchusersmodels.SearchUserResult{
-			UID:          uid,
-			DisplayName:  displayName,
-			Email:        email,
-			PhotoURL:     photoURL,
-			CreatedAt:    createdAt,
-			IsPremium:    isPremium,
+			UID:               uid,
+			DisplayName:       displayName,
+			Email:             email,
+			PhotoURL:          photoURL,
+			CreatedAt:         createdAt,
+			IsPremium:         isPremium,
+			PublicEntryCount:  publicEntries,
+			LastPublicEntryAt: lastPublicEntryAt,
+			Relationship:      relationship,
 		})
 	}
+	if err := rows.Err(); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to search users")
+		return
+	}
+
+	// An offset past the end returns no rows, so count separately to report the real total
+	if len(results) == 0 && offset > 0 {
+		if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM users u WHERE `+searchUsersFilter,
+			like, authUID, excluded).Scan(&total); err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to count users")
+			return
+		}
+	}
 
 	response := searchusersmodels.SearchUsersResponse{
 		Results: results,
+		Pagination: searchusersmodels.Pagination{
+			Limit:   limit,
+			Offset:  offset,
+			Total:   total,
+			HasMore: offset+len(results) < total,
+		},
 	}
 
 	// Cache for a short period
 	if data, err := json.Marshal(response); err == nil {
-		_ = h.redis.Set(ctx, cacheKey, data, 5*time.Minute).Err()
+		_ = h.cache.Set(ctx, cacheKey, data, 5*time.Minute)
 	}
 
 	c.JSON(http.StatusOK, response)
 }
+
+// invalidateSearchUsersCache clears every cached SearchUsers page requested by uid
+func (h *UsersHandler) invalidateSearchUsersCache(ctx context.Context, uid string) {
+	keys, _ := h.cache.Keys(ctx, fmt.Sprintf("search_users:%s:*", uid))
+	_ = h.cache.Del(ctx, keys...)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"io.winapps.journeyapp/internal/cache"
+)
+
+// Results count only published public entries, report the newest one's date, and leave
+// out users who blocked the searcher
+func TestSearchUsersActivityAndBlocks(t *testing.T) {
+	pool := testPool(t)
+	h := NewUsersHandler(nil, pool, cache.NewMemory(100), nil)
+	alice := testUser(t, pool, "alice")
+	active := testUser(t, pool, "active")
+	quiet := testUser(t, pool, "quiet")
+	blocker := testUser(t, pool, "blocker")
+	for _, uid := range []string{active, quiet, blocker} {
+		mustExec(t, pool, `UPDATE users SET display_name = $1 || ' ' || uid WHERE uid = $2`, alice, uid)
+	}
+
+	newest := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	for _, e := range []struct {
+		visibility string
+		status     string
+		createdAt  time.Time
+	}{
+		{"public", "published", newest.AddDate(0, -1, 0)},
+		{"public", "published", newest},
+		{"private", "published", newest.AddDate(0, 1, 0)},
+		{"public", "draft", newest.AddDate(0, 2, 0)},
+	} {
+		id := testEntry(t, pool, active, "Entry", e.visibility)
+		mustExec(t, pool, `UPDATE entries SET status = $1, created_at = $2 WHERE id = $3`, e.status, e.createdAt, id)
+	}
+	testEntry(t, pool, quiet, "Private", "private")
+	mustExec(t, pool, `INSERT INTO friendships (uid, fid, status) VALUES ($1, $2, 'blocked')`, blocker, alice)
+
+	results := searchUsers(t, h, alice, alice)
+	byUID := map[string]int{}
+	for i, r := range results {
+		byUID[r.UID] = i
+	}
+	if _, ok := byUID[blocker]; ok {
+		t.Errorf("found %s, who blocked the searcher", blocker)
+	}
+	if len(results) != 2 {
+		t.Fatalf("results = %+v, want %s and %s", results, active, quiet)
+	}
+
+	got := results[byUID[active]]
+	if got.PublicEntryCount != 2 {
+		t.Errorf("active publicEntryCount = %d, want 2", got.PublicEntryCount)
+	}
+	if got.LastPublicEntryAt == nil || !got.LastPublicEntryAt.Equal(newest) {
+		t.Errorf("active lastPublicEntryAt = %v, want %v", got.LastPublicEntryAt, newest)
+	}
+	got = results[byUID[quiet]]
+	if got.PublicEntryCount != 0 || got.LastPublicEntryAt != nil {
+		t.Errorf("quiet = %d entries, last %v; want 0 and null", got.PublicEntryCount, got.LastPublicEntryAt)
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"io.winapps.journeyapp/internal/apierror"
+	firebaseutil "io.winapps.journeyapp/internal/firebase"
+	"io.winapps.journeyapp/internal/mailer"
+)
+
+// emailVerificationCooldown limits how often a user can request a new verification email
+const emailVerificationCooldown = time.Minute
+
+// SendEmailVerification emails the authenticated user a Firebase email verification link
+func (h *AuthHandler) SendEmailVerification(c *gin.Context) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ctx := c.Request.Context()
+
+	var email string
+	var verified bool
+	err := h.postgres.QueryRow(ctx, `SELECT email, COALESCE(email_verified, FALSE) FROM users WHERE uid = $1`, userUID).Scan(&email, &verified)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "User not found")
+			return
+		}
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to fetch user")
+		return
+	}
+	if verified {
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Email is already verified"})
+		return
+	}
+	if email == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "No email address on account")
+		return
+	}
+
+	cooldownKey := fmt.Sprintf("email_verification_sent:%s", userUID)
+	if ok, err := h.cache.SetNX(ctx, cooldownKey, "1", emailVerificationCooldown); err == nil && !ok {
+		respondError(c, http.StatusTooManyRequests, apierror.CodeRateLimited, "Verification email was sent recently; please wait before requesting another")
+		return
+	}
+
+	authClient, err := firebaseutil.GetAuthClient(h.firebaseApp)
+	if err != nil {
+		h.cache.Del(ctx, cooldownKey)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to initialize auth client")
+		return
+	}
+	link, err := authClient.EmailVerificationLink(ctx, email)
+	if err != nil {
+		h.cache.Del(ctx, cooldownKey)
+		h.logError(c, err, "Failed to generate email verification link", "uid", userUID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to generate verification link")
+		return
+	}
+
+	sender, err := mailer.NewFromEnv()
+	if err != nil {
+		h.cache.Del(ctx, cooldownKey)
+		h.logError(c, err, "Email sender is not configured")
+		respondError(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, "Email delivery is not configured")
+		return
+	}
+	body := fmt.Sprintf("Welcome to Journey!\n\nPlease verify your email address by opening the link below:\n\n%s\n\nIf you didn't create a Journey account, you can ignore this email.\n", link)
+	if err := sender.Send(ctx, email, "Verify your email for Journey", body); err != nil {
+		h.cache.Del(ctx, cooldownKey)
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "Failed to send verification email", "uid", userUID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to send verification email")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Verification email sent"})
+}
+
+// syncEmailVerified copies the email_verified claim from a verified Firebase ID token
+// onto the user row so verification done on the client is picked up at login
+func (h *AuthHandler) syncEmailVerified(ctx context.Context, token *auth.Token) error {
+	verified, ok := token.Claims["email_verified"].(bool)
+	if !ok {
+		return nil
+	}
+	res, err := h.postgres.Exec(ctx, `
+		UPDATE users SET email_verified = $1, updated_at = NOW()
+		WHERE uid = $2 AND email_verified IS DISTINCT FROM $1
+	`, verified, token.UID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() > 0 {
+		invalidateAccountDetails(ctx, h.cache, token.UID)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/storage"
+)
+
+// profileMediaDir is the directory under images/<uid>/ that holds profile pictures
+const profileMediaDir = "profile"
+
+const (
+	// defaultMediaMaxAge applies unless MEDIA_CACHE_MAX_AGE is set. Media files are named
+	// with UUIDs and never rewritten, so clients can keep them for a year.
+	defaultMediaMaxAge = 365 * 24 * 60 * 60
+	// defaultProfileImageMaxAge applies unless PROFILE_IMAGE_CACHE_MAX_AGE is set
+	defaultProfileImageMaxAge = 24 * 60 * 60
+)
+
+// mediaCacheControl is sent with entry media, read from MEDIA_CACHE_MAX_AGE (seconds).
+// It is private since the files sit behind access checks that a shared cache would skip.
+var mediaCacheControl = cacheControlFromEnv("MEDIA_CACHE_MAX_AGE", defaultMediaMaxAge, "private")
+
+// profileImageCacheControl is sent with profile pictures, read from
+// PROFILE_IMAGE_CACHE_MAX_AGE (seconds); 0 sends no-store
+var profileImageCacheControl = cacheControlFromEnv("PROFILE_IMAGE_CACHE_MAX_AGE", defaultProfileImageMaxAge, "public")
+
+// cacheControlFromEnv builds a Cache-Control value with the max-age in the named variable,
+// or def when it is unset or invalid. A max-age of 0 disables caching altogether.
+func cacheControlFromEnv(name string, def int, scope string) string {
+	maxAge := def
+	if v, err := strconv.Atoi(os.Getenv(name)); err == nil && v >= 0 {
+		maxAge = v
+	}
+	if maxAge == 0 {
+		return "no-store"
+	}
+	return fmt.Sprintf("%s, max-age=%d, immutable", scope, maxAge)
+}
+
+// ServeImage serves /images/:uid/:entryId/:file to users who may view the entry
+func (h *EntryHandler) ServeImage(c *gin.Context) {
+	h.serveEntryMedia(c, "images")
+}
+
+// ServeAudio serves /audio/:uid/:entryId/:file to users who may view the entry
+func (h *EntryHandler) ServeAudio(c *gin.Context) {
+	h.serveEntryMedia(c, "audio")
+}
+
+// ServeVideo serves /videos/:uid/:entryId/:file, clips and their posters, to users who
+// may view the entry
+func (h *EntryHandler) ServeVideo(c *gin.Context) {
+	h.serveEntryMedia(c, "videos")
+}
+
+// ServeAttachment serves /attachments/:uid/:entryId/:file to users who may view the entry
+func (h *EntryHandler) ServeAttachment(c *gin.Context) {
+	h.serveEntryMedia(c, "attachments")
+}
+
+// ServeProfileImage serves /images/:uid/profile/:file without authentication. Profile
+// pictures are shown to other users and to third-party clients such as chat, so their
+// absolute URLs stay public.
+func (h *EntryHandler) ServeProfileImage(c *gin.Context) {
+	h.serveMediaFile(c, "images", c.Param("uid"), profileMediaDir, c.Param("file"))
+}
+
+// serveEntryMedia checks that the caller can view the entry and that the entry belongs to
+// the uid in the path before serving the file. Inaccessible entries get the same 404 as
+// missing files so URLs can't be probed.
+func (h *EntryHandler) serveEntryMedia(c *gin.Context, kind string) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ownerUID, entryID := c.Param("uid"), c.Param("entryId")
+
+	entryOwner, err := h.entryOwnerIfVisible(c.Request.Context(), entryID, userUID)
+	if err != nil {
+		if errors.Is(err, errEntryNotAccessible) {
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "File not found")
+			return
+		}
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "media access check failed", "entryId", entryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load file")
+		return
+	}
+	if entryOwner != ownerUID {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "File not found")
+		return
+	}
+
+	file := c.Param("file")
+	if kind == "images" {
+		file = h.negotiateImageFile(c, ownerUID, entryID, file)
+	}
+	h.serveMediaFile(c, kind, ownerUID, entryID, file)
+}
+
+// serveMediaFile streams <kind>/<uid>/<dir>/<file> from media storage with
+// http.ServeContent, which handles Range requests for audio and video seeking, and
+// If-None-Match and If-Modified-Since against an ETag built from the file's size and
+// modification time. Media URLs stay on this server whichever backend holds the files,
+// so the access checks in front of it keep applying.
+func (h *EntryHandler) serveMediaFile(c *gin.Context, kind, uid, dir, file string) {
+	// Every segment must be a plain name so the key can't leave <kind>/
+	if !isPlainPathSegment(uid) || !isPlainPathSegment(dir) || !isPlainPathSegment(file) {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "File not found")
+		return
+	}
+
+	obj, err := h.media.Open(c.Request.Context(), path.Join(kind, uid, dir, file))
+	if errors.Is(err, storage.ErrNotExist) {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "File not found")
+		return
+	}
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "open media failed", "kind", kind, "file", file)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load file")
+		return
+	}
+	defer obj.Close()
+
+	info := obj.Info()
+	if dir == profileMediaDir {
+		c.Header("Cache-Control", profileImageCacheControl)
+	} else {
+		c.Header("Cache-Control", mediaCacheControl)
+	}
+	c.Header("ETag", fmt.Sprintf(`"%x-%x"`, info.ModTime.UnixNano(), info.Size))
+	c.Header("X-Content-Type-Options", "nosniff")
+	// Sniffing can't identify QuickTime, so video types come from the extension
+	if kind == "videos" {
+		c.Header("Content-Type", videoMimeType(path.Ext(file)))
+	}
+	// Documents are downloaded rather than rendered, so HTML-ish text can't run in our origin
+	if kind == "attachments" {
+		c.Header("Content-Type", attachmentMimeType(path.Ext(file)))
+		c.Header("Content-Disposition", "attachment")
+	}
+	http.ServeContent(c.Writer, c.Request, file, info.ModTime, obj)
+}
+
+// isPlainPathSegment reports whether s is a single non-special path element
+func isPlainPathSegment(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `/\`) && !strings.ContainsRune(s, 0)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/middleware"
+	sessionmodels "io.winapps.journeyapp/internal/models/sessions"
+)
+
+// Logout revokes the token used for this request and clears the stored session
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req sessionmodels.LogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+	token := c.GetString("token")
+
+	ctx := c.Request.Context()
+
+	// Deny the presented token even if it is a Firebase ID token that is still cryptographically valid
+	if token != "" {
+		if err := h.cache.Set(ctx, middleware.RevokedTokenKey(token), "1", sessionTokenTTL); err != nil {
+			h.logError(c, err, "Failed to revoke token", "uid", userUID)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to log out")
+			return
+		}
+	}
+
+	if err := h.clearStoredSession(ctx, userUID); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "Failed to clear session", "uid", userUID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to log out")
+		return
+	}
+
+	if req.RevokeFirebase {
+		if err := h.revokeFirebaseSessions(ctx, userUID); err != nil {
+			h.logError(c, err, "Failed to revoke Firebase refresh tokens", "uid", userUID)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to revoke Firebase sessions")
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, sessionmodels.LogoutResponse{
+		Success: true,
+		Message: "Logged out successfully",
+	})
+}
+
+// ListSessions returns the active session stored for the authenticated user
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+	currentToken := c.GetString("token")
+
+	ctx := c.Request.Context()
+
+	var token string
+	var expiresAt *time.Time
+	query := `SELECT COALESCE(token, ''), token_expires_at FROM users WHERE uid = $1`
+	if err := h.postgres.QueryRow(ctx, query, userUID).Scan(&token, &expiresAt); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list sessions")
+		return
+	}
+
+	sessions := []sessionmodels.Session{}
+	if token != "" && (expiresAt == nil || time.Now().Before(*expiresAt)) {
+		sessions = append(sessions, sessionmodels.Session{
+			TokenHint: tokenHint(token),
+			ExpiresAt: expiresAt,
+			Current:   token == currentToken,
+		})
+	}
+
+	c.JSON(http.StatusOK, sessionmodels.ListSessionsResponse{Sessions: sessions})
+}
+
+// RevokeAllSessions signs the user out everywhere, including Firebase refresh tokens
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ctx := c.Request.Context()
+
+	if err := h.clearStoredSession(ctx, userUID); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "Failed to clear session", "uid", userUID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to revoke sessions")
+		return
+	}
+
+	if err := h.revokeFirebaseSessions(ctx, userUID); err != nil {
+		h.logError(c, err, "Failed to revoke Firebase refresh tokens", "uid", userUID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to revoke Firebase sessions")
+		return
+	}
+
+	c.JSON(http.StatusOK, sessionmodels.LogoutResponse{
+		Success: true,
+		Message: "All sessions revoked successfully",
+	})
+}
+
+// clearStoredSession removes the session token from Postgres and the cached session from Redis
+func (h *AuthHandler) clearStoredSession(ctx context.Context, uid string) error {
+	query := `UPDATE users SET token = NULL, token_expires_at = NULL, updated_at = NOW() WHERE uid = $1`
+	if _, err := h.postgres.Exec(ctx, query, uid); err != nil {
+		return err
+	}
+
+	h.cache.Del(ctx, "user:"+uid)
+	invalidateAccountDetails(ctx, h.cache, uid)
+	return nil
+}
+
+// revokeFirebaseSessions revokes Firebase refresh tokens and records the revocation time
+// so AuthMiddleware rejects ID tokens issued before it
+func (h *AuthHandler) revokeFirebaseSessions(ctx context.Context, uid string) error {
+	authClient, err := h.firebaseAuthClient()
+	if err != nil {
+		return err
+	}
+	if err := authClient.RevokeRefreshTokens(ctx, uid); err != nil {
+		return err
+	}
+
+	// Firebase ID tokens live for an hour, so the marker only needs to outlive them
+	revokedAt := strconv.FormatInt(time.Now().Unix(), 10)
+	return h.cache.Set(ctx, middleware.SessionsRevokedAtKey(uid), revokedAt, sessionTokenTTL)
+}
+
+// tokenHint returns the last few characters of a token for display
+func tokenHint(token string) string {
+	if len(token) <= 6 {
+		return token
+	}
+	return "..." + token[len(token)-6:]
+}
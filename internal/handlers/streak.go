@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// streakMilestones are the current-streak lengths that earn a congratulatory push
+var streakMilestones = []int{7, 30, 100}
+
+// streakStats summarises a user's writing days
+type streakStats struct {
+	Current       int    // consecutive days with an entry, ending today
+	Longest       int    // longest run of consecutive days ever
+	LastEntryDate string // most recent local day with an entry (YYYY-MM-DD), empty if none
+}
+
+// localEntryDays returns the distinct local days (YYYY-MM-DD) in loc on which the user
+// created entries, newest first. created_at is a UTC timestamp without zone, so it is
+// shifted into loc before truncating; Postgres applies the DST rules for each instant.
+func localEntryDays(ctx context.Context, postgres *pgxpool.Pool, userUID string, loc *time.Location) ([]string, error) {
+	rows, err := postgres.Query(ctx, `
+		SELECT DISTINCT to_char(date_trunc('day', (created_at AT TIME ZONE 'UTC') AT TIME ZONE $2), 'YYYY-MM-DD') AS day
+		FROM entries
+		WHERE user_uid = $1
+		ORDER BY day DESC
+	`, userUID, loc.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []string{}
+	for rows.Next() {
+		var day string
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
+// computeStreak derives streak stats from distinct days (YYYY-MM-DD, newest first) as seen
+// on the local date today. Days are compared as calendar dates, so DST transitions never
+// make two adjacent days look more or less than one day apart. Days after today (clock
+// skew between devices and the server) are ignored.
+func computeStreak(days []string, today time.Time) streakStats {
+	todayDate := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+
+	var stats streakStats
+	var prev time.Time
+	run := 0
+	counting := true
+	expected := todayDate
+	for _, day := range days {
+		d, err := time.Parse(statsDateLayout, day)
+		if err != nil || d.After(todayDate) {
+			continue
+		}
+		if stats.LastEntryDate == "" {
+			stats.LastEntryDate = day
+		}
+
+		if run > 0 && d.Equal(prev.AddDate(0, 0, -1)) {
+			run++
+		} else {
+			run = 1
+		}
+		prev = d
+		if run > stats.Longest {
+			stats.Longest = run
+		}
+
+		if counting {
+			if d.Equal(expected) {
+				stats.Current++
+				expected = expected.AddDate(0, 0, -1)
+			} else {
+				counting = false
+			}
+		}
+	}
+	return stats
+}
+
+// isStreakMilestone reports whether n is one of streakMilestones
+func isStreakMilestone(n int) bool {
+	for _, m := range streakMilestones {
+		if n == m {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// streakMilestoneLookback overlaps the hourly schedule so entries written while a run
+	// was in progress aren't missed; the milestone key keeps repeats from sending twice
+	streakMilestoneLookback = 65 * time.Minute
+	streakMilestoneKeyTTL   = 48 * time.Hour
+)
+
+// setupStreakMilestones schedules the hourly check for users who just reached a streak milestone
+func (ns *NotificationsHandler) setupStreakMilestones() {
+	_, err := ns.cronManager.AddFunc("10 * * * *", ns.trackedJob(func() {
+		ns.sendStreakMilestones(context.Background())
+	}))
+	if err != nil {
+		ns.logger.Errorw("Failed to schedule streak milestones", "error", err)
+	}
+}
+
+// sendStreakMilestones congratulates users whose entry in the last hour brought their
+// current streak to one of streakMilestones
+func (ns *NotificationsHandler) sendStreakMilestones(ctx context.Context) {
+	since := time.Now().UTC().Add(-streakMilestoneLookback)
+	rows, err := ns.db.Query(ctx, `
+		SELECT DISTINCT e.user_uid, p.timezone
+		FROM entries e
+		INNER JOIN push_tokens p ON p.user_id = e.user_uid AND p.active = true
+		WHERE e.created_at >= $1
+	`, since)
+	if err != nil {
+		ns.logger.Errorw("Failed to find recent writers for streak milestones", "error", err)
+		return
+	}
+	type writer struct{ uid, timezone string }
+	var writers []writer
+	for rows.Next() {
+		var w writer
+		if err := rows.Scan(&w.uid, &w.timezone); err == nil {
+			writers = append(writers, w)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		ns.logger.Errorw("Failed to find recent writers for streak milestones", "error", err)
+		return
+	}
+
+	for _, w := range writers {
+		loc, err := time.LoadLocation(w.timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		days, err := localEntryDays(ctx, ns.db, w.uid, loc)
+		if err != nil {
+			ns.logger.Warnw("Failed to load entry days for streak", "recipient", w.uid, "error", err)
+			continue
+		}
+		stats := computeStreak(days, time.Now().In(loc))
+		if !isStreakMilestone(stats.Current) {
+			continue
+		}
+
+		// One congratulation per milestone per streak, however many runs see it
+		key := fmt.Sprintf("streak_milestone:%s:%d:%s", w.uid, stats.Current, stats.LastEntryDate)
+		if claimed, err := ns.cache.SetNX(ctx, key, "1", streakMilestoneKeyTTL); err != nil || !claimed {
+			continue
+		}
+
+		data := map[string]string{
+			"type":   "streak_milestone",
+			"streak": strconv.Itoa(stats.Current),
+		}
+		title := fmt.Sprintf("%d-day writing streak!", stats.Current)
+		body := fmt.Sprintf("You've written every day for %d days. Keep it going!", stats.Current)
+		if err := ns.sendToUser(w.uid, title, body, data, "prompts"); err != nil {
+			ns.logger.Warnw("Failed to send streak milestone", "recipient", w.uid, "streak", stats.Current, "error", err)
+		}
+	}
+}
+
+// streaksAtRisk returns users in timezone who wrote yesterday but not yet today, mapped
+// to the streak they would lose. The daily prompt job sends them a reminder instead of
+// the plain prompt.
+func (ns *NotificationsHandler) streaksAtRisk(ctx context.Context, timezone string) map[string]int {
+	atRisk := make(map[string]int)
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return atRisk
+	}
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	yesterday := today.AddDate(0, 0, -1)
+
+	rows, err := ns.db.Query(ctx, `
+		SELECT p.user_id
+		FROM push_tokens p
+		WHERE p.timezone = $1 AND p.active = true
+			AND EXISTS (SELECT 1 FROM entries e WHERE e.user_uid = p.user_id AND e.created_at >= $2 AND e.created_at < $3)
+			AND NOT EXISTS (SELECT 1 FROM entries e WHERE e.user_uid = p.user_id AND e.created_at >= $3)
+	`, timezone, yesterday.UTC(), today.UTC())
+	if err != nil {
+		ns.logger.Errorw("Failed to find streaks at risk", "timezone", timezone, "error", err)
+		return atRisk
+	}
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err == nil {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	rows.Close()
+
+	for _, userID := range userIDs {
+		days, err := localEntryDays(ctx, ns.db, userID, loc)
+		if err != nil {
+			continue
+		}
+		// Counted as of yesterday, since nothing has been written today
+		if n := computeStreak(days, yesterday).Current; n > 0 {
+			atRisk[userID] = n
+		}
+	}
+	return atRisk
+}
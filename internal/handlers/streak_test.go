@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeStreak(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		days  []string
+		today time.Time
+		want  streakStats
+	}{
+		{
+			name:  "no entries",
+			days:  []string{},
+			today: time.Date(2024, 3, 11, 9, 0, 0, 0, newYork),
+			want:  streakStats{},
+		},
+		{
+			// Clocks went forward on 2024-03-10, so that day is only 23 hours long
+			name:  "across spring forward",
+			days:  []string{"2024-03-11", "2024-03-10", "2024-03-09", "2024-03-08"},
+			today: time.Date(2024, 3, 11, 0, 30, 0, 0, newYork),
+			want:  streakStats{Current: 4, Longest: 4, LastEntryDate: "2024-03-11"},
+		},
+		{
+			// Clocks went back on 2024-11-03, so that day is 25 hours long
+			name:  "across fall back, late in the evening",
+			days:  []string{"2024-11-04", "2024-11-03", "2024-11-02"},
+			today: time.Date(2024, 11, 4, 23, 59, 0, 0, newYork),
+			want:  streakStats{Current: 3, Longest: 3, LastEntryDate: "2024-11-04"},
+		},
+		{
+			name:  "no entry today ends the current streak",
+			days:  []string{"2024-03-10", "2024-03-09"},
+			today: time.Date(2024, 3, 11, 12, 0, 0, 0, newYork),
+			want:  streakStats{Current: 0, Longest: 2, LastEntryDate: "2024-03-10"},
+		},
+		{
+			name:  "longest run in the past",
+			days:  []string{"2024-03-11", "2024-03-05", "2024-03-04", "2024-03-03", "2024-02-29"},
+			today: time.Date(2024, 3, 11, 12, 0, 0, 0, newYork),
+			want:  streakStats{Current: 1, Longest: 3, LastEntryDate: "2024-03-11"},
+		},
+		{
+			name:  "days after today are ignored",
+			days:  []string{"2024-03-12", "2024-03-11", "2024-03-10"},
+			today: time.Date(2024, 3, 11, 12, 0, 0, 0, newYork),
+			want:  streakStats{Current: 2, Longest: 2, LastEntryDate: "2024-03-11"},
+		},
+		{
+			name:  "runs across a month and leap day",
+			days:  []string{"2024-03-01", "2024-02-29", "2024-02-28"},
+			today: time.Date(2024, 3, 1, 8, 0, 0, 0, newYork),
+			want:  streakStats{Current: 3, Longest: 3, LastEntryDate: "2024-03-01"},
+		},
+	}
+	for _, tt := range tests {
+		if got := computeStreak(tt.days, tt.today); got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsStreakMilestone(t *testing.T) {
+	for n, want := range map[int]bool{1: false, 7: true, 8: false, 30: true, 100: true, 365: false} {
+		if got := isStreakMilestone(n); got != want {
+			t.Errorf("isStreakMilestone(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/metrics"
+	"io.winapps.journeyapp/internal/storage"
+)
+
+// streamExportMaxEntries is the largest account (by entry count) exported synchronously;
+// anything bigger goes through the async job so the request doesn't hit the timeout
+const streamExportMaxEntries = 100
+
+type streamExportEntry struct {
+	ID            string
+	Title         string
+	Description   string
+	TagsJSON      string
+	LocationsJSON string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Images        []string
+	Audio         []string
+	Videos        []string // clips and their posters
+	Attachments   []string
+}
+
+// streamExport writes the user's export zip directly to the response. The zip has the
+// same layout as the async export (manifest.json, entries/entries.csv plus
+// entries/<id>/images|audio|videos|attachments).
+// All database reads happen before the first byte is written so they can still fail
+// with a JSON error; once streaming starts, a failure aborts the connection and the
+// client receives a truncated (invalid) zip.
+func (h *AuthHandler) streamExport(c *gin.Context, uid string) {
+	ctx := c.Request.Context()
+
+	entries, err := h.loadStreamExportEntries(ctx, uid)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "Failed to load entries for export")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to export data")
+		return
+	}
+
+	filename := fmt.Sprintf("journey-export-%s.zip", time.Now().UTC().Format("20060102-150405"))
+	c.Header("Content-Description", "File Transfer")
+	c.Header("Content-Transfer-Encoding", "binary")
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+
+	archive := zip.NewWriter(c.Writer)
+	if err := writeStreamExport(ctx, h.media, archive, uid, entries); err != nil {
+		// Headers are already sent; skip the central directory so the client can't
+		// mistake the partial archive for a complete one
+		h.logError(c, err, "Export stream aborted")
+		metrics.ExportJobsTotal.WithLabelValues("failed").Inc()
+		c.Abort()
+		return
+	}
+	if err := archive.Close(); err != nil {
+		h.logError(c, err, "Failed to finish export stream")
+		metrics.ExportJobsTotal.WithLabelValues("failed").Inc()
+		c.Abort()
+		return
+	}
+	metrics.ExportJobsTotal.WithLabelValues("streamed").Inc()
+}
+
+// loadStreamExportEntries reads every entry with its tags, locations and media URLs
+func (h *AuthHandler) loadStreamExportEntries(ctx context.Context, uid string) ([]streamExportEntry, error) {
+	rows, err := h.postgres.Query(ctx, `SELECT id, title, description, encrypted, created_at, updated_at FROM entries WHERE user_uid = $1 ORDER BY created_at`, uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch entries: %w", err)
+	}
+	cipher := newEntryCipher(h.postgres, h.entryKeys)
+	var entries []streamExportEntry
+	for rows.Next() {
+		var e streamExportEntry
+		var encrypted bool
+		if err := rows.Scan(&e.ID, &e.Title, &e.Description, &encrypted, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan entry: %w", err)
+		}
+		if err := cipher.open(ctx, uid, encrypted, &e.Title, &e.Description); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to decrypt entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch entries: %w", err)
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		if e.TagsJSON, err = h.fetchTagsJSON(ctx, e.ID); err != nil {
+			return nil, fmt.Errorf("failed to fetch tags: %w", err)
+		}
+		if e.LocationsJSON, err = h.fetchLocationsJSON(ctx, e.ID); err != nil {
+			return nil, fmt.Errorf("failed to fetch locations: %w", err)
+		}
+		if e.Images, err = h.fetchMediaURLs(ctx, `SELECT url FROM images WHERE entry_id = $1 ORDER BY upload_order`, e.ID); err != nil {
+			return nil, fmt.Errorf("failed to fetch images: %w", err)
+		}
+		if e.Audio, err = h.fetchMediaURLs(ctx, `SELECT url FROM audio WHERE entry_id = $1 ORDER BY upload_order`, e.ID); err != nil {
+			return nil, fmt.Errorf("failed to fetch audio: %w", err)
+		}
+		if e.Videos, err = h.fetchMediaURLs(ctx, `
+			SELECT m.url FROM videos v, LATERAL (VALUES (v.url, 0), (v.thumbnail_url, 1)) AS m(url, n)
+			WHERE v.entry_id = $1 AND m.url IS NOT NULL
+			ORDER BY v.upload_order, m.n
+		`, e.ID); err != nil {
+			return nil, fmt.Errorf("failed to fetch videos: %w", err)
+		}
+		if e.Attachments, err = h.fetchMediaURLs(ctx, `SELECT url FROM attachments WHERE entry_id = $1 ORDER BY upload_order`, e.ID); err != nil {
+			return nil, fmt.Errorf("failed to fetch attachments: %w", err)
+		}
+	}
+	return entries, nil
+}
+
+func (h *AuthHandler) fetchMediaURLs(ctx context.Context, query, entryID string) ([]string, error) {
+	rows, err := h.postgres.Query(ctx, query, entryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var urls []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return urls, rows.Err()
+}
+
+// writeStreamExport writes the manifest, CSV and media files into archive without closing it
+func writeStreamExport(ctx context.Context, media storage.Store, archive *zip.Writer, uid string, entries []streamExportEntry) error {
+	manifest, err := json.Marshal(exportManifest{Version: exportManifestVersion, UID: uid, ExportedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	mw, err := archive.Create(exportManifestName)
+	if err != nil {
+		return err
+	}
+	if _, err := mw.Write(manifest); err != nil {
+		return err
+	}
+
+	w, err := archive.Create("entries/entries.csv")
+	if err != nil {
+		return err
+	}
+	csvWriter := csv.NewWriter(w)
+	_ = csvWriter.Write([]string{"id", "title", "description", "locations", "tags", "createdAt", "updatedAt"})
+	for _, e := range entries {
+		_ = csvWriter.Write([]string{
+			e.ID,
+			e.Title,
+			e.Description,
+			e.LocationsJSON,
+			e.TagsJSON,
+			e.CreatedAt.Format(time.RFC3339),
+			e.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		for _, url := range e.Images {
+			if err := addMediaToZip(ctx, media, archive, uid, url, path.Join("entries", e.ID, "images", path.Base(url))); err != nil {
+				return err
+			}
+		}
+		for _, url := range e.Audio {
+			if err := addMediaToZip(ctx, media, archive, uid, url, path.Join("entries", e.ID, "audio", path.Base(url))); err != nil {
+				return err
+			}
+		}
+		for _, url := range e.Videos {
+			if err := addMediaToZip(ctx, media, archive, uid, url, path.Join("entries", e.ID, "videos", path.Base(url))); err != nil {
+				return err
+			}
+		}
+		for _, url := range e.Attachments {
+			if err := addMediaToZip(ctx, media, archive, uid, url, path.Join("entries", e.ID, "attachments", path.Base(url))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addMediaToZip copies a media file into the archive. Missing files are skipped, as in
+// the async export; only write failures (e.g. the client went away) are returned.
+func addMediaToZip(ctx context.Context, media storage.Store, archive *zip.Writer, uid, url, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	key, err := mediaSourceKey(url, uid)
+	if err != nil {
+		return nil
+	}
+	f, err := media.Open(ctx, key)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"io.winapps.journeyapp/internal/subscriptions"
+)
+
+// subscriptionExpirySchedule runs the expiry sweep once a day, in UTC
+const subscriptionExpirySchedule = "30 3 * * *"
+
+// subscriptionExpiryTimeout bounds one sweep, including store re-validation calls
+const subscriptionExpiryTimeout = 10 * time.Minute
+
+// StartSubscriptionExpiry schedules the daily job that revokes premium from users whose
+// subscription has lapsed. Shutdown stops it.
+func (h *AuthHandler) StartSubscriptionExpiry() error {
+	c := cron.New(cron.WithLocation(time.UTC))
+	if _, err := c.AddFunc(subscriptionExpirySchedule, func() {
+		ctx, cancel := context.WithTimeout(h.exportCtx, subscriptionExpiryTimeout)
+		defer cancel()
+		if err := h.expireLapsedSubscriptions(ctx); err != nil {
+			h.logger.Errorw("subscription expiry sweep failed", "error", err)
+		}
+	}); err != nil {
+		return fmt.Errorf("failed to schedule subscription expiry: %w", err)
+	}
+	c.Start()
+	h.subscriptionCron = c
+	return nil
+}
+
+// expireLapsedSubscriptions re-validates the stored receipt of every premium user past
+// their expiry, extending those that renewed and clearing the rest. Users whose store
+// lookup fails for a transient reason are left for the next run; their lapsed expiry
+// already denies premium features in the meantime.
+func (h *AuthHandler) expireLapsedSubscriptions(ctx context.Context) error {
+	rows, err := h.postgres.Query(ctx, `
+		SELECT u.uid, r.platform, r.product_id, r.receipt
+		FROM users u
+		LEFT JOIN subscription_receipts r ON r.uid = u.uid
+		WHERE u.is_premium = TRUE AND u.premium_expires_at <= NOW()
+	`)
+	if err != nil {
+		return err
+	}
+	type lapsedUser struct {
+		uid                          string
+		platform, productID, receipt *string // NULL when no receipt was ever verified
+	}
+	var lapsed []lapsedUser
+	for rows.Next() {
+		var u lapsedUser
+		if err := rows.Scan(&u.uid, &u.platform, &u.productID, &u.receipt); err != nil {
+			rows.Close()
+			return err
+		}
+		lapsed = append(lapsed, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var expire []string
+	renewed := 0
+	for _, u := range lapsed {
+		uid := u.uid
+		if u.receipt == nil || h.subscriptions == nil {
+			expire = append(expire, uid)
+			continue
+		}
+
+		purchase, err := h.subscriptions.Verify(ctx, *u.platform, *u.productID, *u.receipt)
+		if err != nil && !errors.Is(err, subscriptions.ErrInvalidReceipt) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			h.logger.Warnw("subscription re-validation failed; retrying next run", "uid", uid, "error", err)
+			continue
+		}
+		if err == nil && purchase.Active {
+			if err := h.applySubscription(ctx, uid, *u.platform, *u.receipt, purchase); err != nil {
+				h.logger.Errorw("failed to extend renewed subscription", "uid", uid, "error", err)
+				continue
+			}
+			renewed++
+			continue
+		}
+		expire = append(expire, uid)
+	}
+
+	expired := 0
+	if len(expire) > 0 {
+		// is_premium and premium_expires_at are cleared together to satisfy users_premium_consistency
+		res, err := h.postgres.Exec(ctx, `
+			UPDATE users SET is_premium = FALSE, premium_expires_at = NULL, updated_at = NOW()
+			WHERE uid = ANY($1) AND is_premium = TRUE AND premium_expires_at <= NOW()
+		`, expire)
+		if err != nil {
+			return err
+		}
+		expired = int(res.RowsAffected())
+
+		invalidateAccountDetails(ctx, h.cache, expire...)
+	}
+
+	h.logger.Infow("subscription expiry sweep finished", "lapsed", len(lapsed), "renewed", renewed, "expired", expired)
+	return nil
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	sweepmodels "io.winapps.journeyapp/internal/models/sweep_media"
+	"io.winapps.journeyapp/internal/storage"
+)
+
+const (
+	// mediaSweepGracePeriod skips files young enough to belong to an upload whose row
+	// isn't committed yet
+	mediaSweepGracePeriod = time.Hour
+	// maxSweepListed caps the orphan and missing lists in the response
+	maxSweepListed = 500
+)
+
+// SweepOrphanedMedia walks images/, audio/, videos/ and attachments/ in media storage and
+// finds files that no images, audio, videos, attachments or users row refers to, plus rows whose file is gone. It
+// is a dry run unless ?apply=true, which deletes the orphaned files. Missing files are
+// only reported.
+func (h *EntryHandler) SweepOrphanedMedia(c *gin.Context) {
+	apply, _ := strconv.ParseBool(c.Query("apply"))
+	ctx := c.Request.Context()
+
+	referenced, required, err := h.referencedMedia(ctx)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "load media references failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load media references")
+		return
+	}
+
+	resp := sweepmodels.SweepMediaResponse{
+		Applied: apply,
+		Orphans: []string{},
+		Missing: []sweepmodels.MissingMedia{},
+	}
+	cutoff := time.Now().Add(-mediaSweepGracePeriod)
+
+	// One listing serves both checks, rather than a storage request per row
+	stored := make(map[string]bool)
+	for _, kind := range []string{"images", "audio", "videos", "attachments"} {
+		err := h.media.Walk(ctx, kind, func(info storage.Info) error {
+			resp.Scanned++
+			stored[info.Key] = true
+
+			url := "/" + info.Key
+			if referenced[url] {
+				return nil
+			}
+			if info.ModTime.After(cutoff) {
+				resp.Skipped++
+				return nil
+			}
+
+			resp.OrphanCount++
+			resp.OrphanBytes += info.Size
+			if len(resp.Orphans) < maxSweepListed {
+				resp.Orphans = append(resp.Orphans, url)
+			} else {
+				resp.Truncated = true
+			}
+			if apply {
+				if err := h.media.Delete(ctx, info.Key); err == nil {
+					resp.Deleted++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "media sweep failed", "kind", kind)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to sweep media")
+			return
+		}
+	}
+
+	for _, row := range required {
+		key, err := cleanMediaKey(row.URL, row.kind)
+		if err != nil || stored[key] {
+			continue
+		}
+		resp.MissingCount++
+		if len(resp.Missing) < maxSweepListed {
+			resp.Missing = append(resp.Missing, row.MissingMedia)
+		} else {
+			resp.Truncated = true
+		}
+	}
+
+	h.logger.Infow("Media sweep finished",
+		"applied", apply,
+		"scanned", resp.Scanned,
+		"orphans", resp.OrphanCount,
+		"orphanBytes", resp.OrphanBytes,
+		"deleted", resp.Deleted,
+		"missing", resp.MissingCount,
+	)
+	c.JSON(http.StatusOK, resp)
+}
+
+// requiredMedia is a row whose file should exist
+type requiredMedia struct {
+	sweepmodels.MissingMedia
+	kind string
+}
+
+// referencedMedia returns the URL of every file a row refers to, plus the rows whose file
+// must exist. WebP variants and video posters count as references but aren't required,
+// since clients can do without them.
+func (h *EntryHandler) referencedMedia(ctx context.Context) (map[string]bool, []requiredMedia, error) {
+	referenced := make(map[string]bool)
+	var required []requiredMedia
+
+	rows, err := h.postgres.Query(ctx, `
+		SELECT 'images', entry_id::text, url, COALESCE(webp_url, '') FROM images
+		UNION ALL
+		SELECT 'audio', entry_id::text, url, '' FROM audio
+		UNION ALL
+		SELECT 'videos', entry_id::text, url, COALESCE(thumbnail_url, '') FROM videos
+		UNION ALL
+		SELECT 'attachments', entry_id::text, url, '' FROM attachments
+	`)
+	if err != nil {
+		return nil, nil, err
+	}
+	for rows.Next() {
+		var table, entryID, url, variantURL string
+		if err := rows.Scan(&table, &entryID, &url, &variantURL); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		referenced[url] = true
+		if variantURL != "" {
+			referenced[variantURL] = true
+		}
+		required = append(required, requiredMedia{
+			MissingMedia: sweepmodels.MissingMedia{Table: table, EntryID: entryID, URL: url},
+			kind:         table,
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// Profile photos are stored as absolute URLs; keep the /images/<uid>/profile/<file> part
+	rows, err = h.postgres.Query(ctx, `SELECT photo_url FROM users WHERE photo_url LIKE '%/images/%/profile/%'`)
+	if err != nil {
+		return nil, nil, err
+	}
+	for rows.Next() {
+		var photoURL string
+		if err := rows.Scan(&photoURL); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		i := strings.Index(photoURL, "/images/")
+		if i < 0 {
+			continue
+		}
+		url := photoURL[i:]
+		referenced[url] = true
+		required = append(required, requiredMedia{
+			MissingMedia: sweepmodels.MissingMedia{Table: "users", URL: url},
+			kind:         "images",
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return referenced, required, nil
+}
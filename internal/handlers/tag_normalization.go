@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"io.winapps.journeyapp/internal/apierror"
+	accountmodels "io.winapps.journeyapp/internal/models/account"
+	normalizetagsmodels "io.winapps.journeyapp/internal/models/normalize_tags"
+)
+
+// normalizeTagKey is the form tag keys are saved in for users with normalize_tags on
+func normalizeTagKey(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
+
+// normalizeTagList normalizes the keys of tags and drops earlier duplicates, so the last
+// value given for a key wins and the list fits UNIQUE(entry_id, key)
+func normalizeTagList(tags []accountmodels.Tag) []accountmodels.Tag {
+	index := make(map[string]int, len(tags))
+	normalized := make([]accountmodels.Tag, 0, len(tags))
+	for _, tag := range tags {
+		tag.Key = normalizeTagKey(tag.Key)
+		if i, ok := index[tag.Key]; ok {
+			normalized[i] = tag
+			continue
+		}
+		index[tag.Key] = len(normalized)
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// tagNormalizationEnabled reports whether the user turned on normalize_tags. Users without
+// a settings row have it off.
+func tagNormalizationEnabled(ctx context.Context, db *pgxpool.Pool, uid string) (bool, error) {
+	var enabled bool
+	err := db.QueryRow(ctx, `
+		SELECT COALESCE((SELECT normalize_tags FROM user_settings WHERE uid = $1), FALSE)
+	`, uid).Scan(&enabled)
+	return enabled, err
+}
+
+// NormalizeTags trims and lowercases the keys of all the user's existing tags, for users
+// turning on normalize_tags with tags saved before it. Where an entry has several case
+// variants of a key, the most recently set one is kept. Running it again changes nothing.
+func (h *EntryHandler) NormalizeTags(c *gin.Context) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ctx := c.Request.Context()
+
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Drop all but the newest variant of each key first, so the renames can't collide
+	// on UNIQUE(entry_id, key)
+	rows, err := tx.Query(ctx, `
+		DELETE FROM tags
+		WHERE id IN (
+			SELECT id FROM (
+				SELECT t.id, ROW_NUMBER() OVER (
+					PARTITION BY t.entry_id, LOWER(TRIM(t.key))
+					ORDER BY t.created_at DESC NULLS LAST, t.id
+				) AS rn
+				FROM tags t
+				JOIN entries e ON e.id = t.entry_id
+				WHERE e.user_uid = $1
+			) ranked
+			WHERE rn > 1
+		)
+		RETURNING entry_id::text
+	`, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "merge tag variants failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to normalize tags")
+		return
+	}
+	changed := map[string]bool{}
+	merged := 0
+	for rows.Next() {
+		var entryID string
+		if err := rows.Scan(&entryID); err != nil {
+			rows.Close()
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to normalize tags")
+			return
+		}
+		changed[entryID] = true
+		merged++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		h.logError(c, err, "merge tag variants failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to normalize tags")
+		return
+	}
+
+	rows, err = tx.Query(ctx, `
+		UPDATE tags t SET key = LOWER(TRIM(t.key))
+		FROM entries e
+		WHERE e.id = t.entry_id AND e.user_uid = $1 AND t.key <> LOWER(TRIM(t.key))
+		RETURNING t.entry_id::text
+	`, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "rename tags failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to normalize tags")
+		return
+	}
+	renamed := 0
+	for rows.Next() {
+		var entryID string
+		if err := rows.Scan(&entryID); err != nil {
+			rows.Close()
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to normalize tags")
+			return
+		}
+		changed[entryID] = true
+		renamed++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		h.logError(c, err, "rename tags failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to normalize tags")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to normalize tags")
+		return
+	}
+
+	if len(changed) > 0 {
+		keys := make([]string, 0, len(changed))
+		for entryID := range changed {
+			keys = append(keys, "entry:"+entryID)
+		}
+		if tagValueKeys, err := h.cache.Keys(ctx, fmt.Sprintf("tag_values:%s:*", userUID)); err == nil {
+			keys = append(keys, tagValueKeys...)
+		}
+		_ = h.cache.Del(ctx, keys...)
+		invalidateAccountDetails(ctx, h.cache, userUID)
+	}
+
+	c.JSON(http.StatusOK, normalizetagsmodels.NormalizeTagsResponse{
+		Renamed: renamed,
+		Merged:  merged,
+		Message: "Tags normalized",
+	})
+}
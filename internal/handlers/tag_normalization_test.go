@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	accountmodels "io.winapps.journeyapp/internal/models/account"
+)
+
+func TestNormalizeTagKey(t *testing.T) {
+	for key, want := range map[string]string{"Mood": "mood", "  WEATHER ": "weather", "already": "already", "": ""} {
+		if got := normalizeTagKey(key); got != want {
+			t.Errorf("normalizeTagKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestNormalizeTagListDedupes(t *testing.T) {
+	tags := []accountmodels.Tag{
+		{Key: "Mood", Value: "calm"},
+		{Key: "weather", Value: "rain"},
+		{Key: " mood ", Value: "happy"},
+		{Key: "MOOD", Value: "tired"},
+		{Key: "Place", Value: "home"},
+	}
+	want := []accountmodels.Tag{
+		{Key: "mood", Value: "tired"},
+		{Key: "weather", Value: "rain"},
+		{Key: "place", Value: "home"},
+	}
+	if got := normalizeTagList(tags); !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeTagList = %+v, want %+v", got, want)
+	}
+	if tags[0].Key != "Mood" {
+		t.Error("normalizeTagList modified its input")
+	}
+	if got := normalizeTagList(nil); len(got) != 0 {
+		t.Errorf("normalizeTagList(nil) = %+v", got)
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"io.winapps.journeyapp/internal/db"
+)
+
+// testPool connects to the database in TEST_DATABASE_URL and applies the migrations.
+// Tests that need Postgres are skipped when it isn't set.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if err := db.Migrate(ctx, pool); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return pool
+}
+
+// testUser inserts a verified user with a unique uid, removed again (along with
+// everything that cascades from it) when the test ends
+func testUser(t *testing.T, pool *pgxpool.Pool, name string) string {
+	t.Helper()
+	suffix := make([]byte, 6)
+	_, _ = rand.Read(suffix)
+	uid := name + "-" + hex.EncodeToString(suffix)
+	ctx := context.Background()
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO users (uid, display_name, email, photo_url, phone_number, email_verified)
+		VALUES ($1, $1, $1 || '@example.com', '', '', TRUE)
+	`, uid); err != nil {
+		t.Fatalf("insert user %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), `DELETE FROM users WHERE uid = $1`, uid)
+	})
+	return uid
+}
+
+// testEntry inserts a published entry and returns its id
+func testEntry(t *testing.T, pool *pgxpool.Pool, ownerUID, title, visibility string) string {
+	t.Helper()
+	var id string
+	if err := pool.QueryRow(context.Background(), `
+		INSERT INTO entries (user_uid, title, description, visibility)
+		VALUES ($1, $2, '', $3)
+		RETURNING id
+	`, ownerUID, title, visibility).Scan(&id); err != nil {
+		t.Fatalf("insert entry %q: %v", title, err)
+	}
+	return id
+}
+
+// testFriends records an approved friendship between a and b
+func testFriends(t *testing.T, pool *pgxpool.Pool, a, b string) {
+	t.Helper()
+	if _, err := pool.Exec(context.Background(), `
+		INSERT INTO friendships (uid, fid, status) VALUES ($1, $2, 'approved')
+	`, a, b); err != nil {
+		t.Fatalf("befriend %s and %s: %v", a, b, err)
+	}
+}
+
+// mustExec runs a fixture statement, failing the test on error
+func mustExec(t *testing.T, pool *pgxpool.Pool, sql string, args ...any) {
+	t.Helper()
+	if _, err := pool.Exec(context.Background(), sql, args...); err != nil {
+		t.Fatalf("%s: %v", strings.TrimSpace(sql), err)
+	}
+}
+
+// callAs runs handler for a request made by uid, the way AuthMiddleware would leave it.
+// target is the request path and query; the route is registered on its path.
+func callAs(uid string, handler gin.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	u, _ := url.Parse(target)
+	router.Handle(method, u.Path, func(c *gin.Context) {
+		c.Set("uid", uid)
+	}, handler)
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/cache"
+	accountmodels "io.winapps.journeyapp/internal/models/account"
+	undomodels "io.winapps.journeyapp/internal/models/undo_removal"
+	"io.winapps.journeyapp/internal/storage"
+)
+
+const (
+	// undoRemovalTTL is how long a removed tag, location, image or audio clip can be restored
+	undoRemovalTTL = 10 * time.Minute
+	// mediaTrashPrefix holds the files of removed media until the undo window closes
+	mediaTrashPrefix = "trash"
+	// mediaTrashRetention is how old trashed files must be before the sweep deletes them.
+	// It outlasts undoRemovalTTL so an undo started just before expiry still finds its files.
+	mediaTrashRetention = time.Hour
+
+	removalKindTag      = "tag"
+	removalKindLocation = "location"
+	removalKindImage    = "image"
+	removalKindAudio    = "audio"
+)
+
+// removedTag is a deleted tags row
+type removedTag struct {
+	Key       string     `json:"key"`
+	Value     *string    `json:"value"`
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+// removedLocation is a deleted locations row
+type removedLocation struct {
+	Latitude    float64    `json:"latitude"`
+	Longitude   float64    `json:"longitude"`
+	Address     *string    `json:"address"`
+	City        *string    `json:"city"`
+	State       *string    `json:"state"`
+	Zip         *string    `json:"zip"`
+	Country     *string    `json:"country"`
+	CountryCode *string    `json:"countryCode"`
+	DisplayName *string    `json:"displayName"`
+	CreatedAt   *time.Time `json:"createdAt"`
+}
+
+// removedMedia is a deleted images or audio row. Width, Height and WebPURL only apply to
+// images, Duration only to audio.
+type removedMedia struct {
+	URL         string     `json:"url"`
+	Filename    *string    `json:"filename"`
+	FileSize    *int64     `json:"fileSize"`
+	MimeType    *string    `json:"mimeType"`
+	Width       *int       `json:"width,omitempty"`
+	Height      *int       `json:"height,omitempty"`
+	WebPURL     *string    `json:"webpUrl,omitempty"`
+	Duration    *int       `json:"duration,omitempty"`
+	UploadOrder int        `json:"uploadOrder"`
+	CreatedAt   *time.Time `json:"createdAt"`
+	// Trashed maps each storage key the files had to where they were moved
+	Trashed map[string]string `json:"trashed"`
+}
+
+// pendingRemoval is stored under undo_removal:<token> until it is undone or expires
+type pendingRemoval struct {
+	UID       string            `json:"uid"`
+	EntryID   string            `json:"entryId"`
+	Kind      string            `json:"kind"`
+	Tags      []removedTag      `json:"tags,omitempty"`
+	Locations []removedLocation `json:"locations,omitempty"`
+	Media     *removedMedia     `json:"media,omitempty"`
+}
+
+func undoRemovalKey(token string) string {
+	return "undo_removal:" + token
+}
+
+// recordRemoval makes a committed removal undoable and returns its undo token. Media files
+// are moved to the trash instead of being deleted. If the undo record can't be saved the
+// removal stands without a token and the trashed files are deleted right away.
+func (h *EntryHandler) recordRemoval(ctx context.Context, r *pendingRemoval, mediaKeys []string) (string, *time.Time) {
+	token := uuid.New().String()
+	if r.Media != nil {
+		r.Media.Trashed = h.trashMedia(ctx, token, mediaKeys)
+	}
+
+	data, _ := json.Marshal(r)
+	if err := h.cache.Set(ctx, undoRemovalKey(token), data, undoRemovalTTL); err != nil {
+		h.logger.Warnw("Failed to save undo record; removal can't be undone", "entryId", r.EntryID, "kind", r.Kind, "error", err)
+		if r.Media != nil {
+			for _, trashKey := range r.Media.Trashed {
+				_ = h.media.Delete(ctx, trashKey)
+			}
+		}
+		return "", nil
+	}
+	expiresAt := time.Now().Add(undoRemovalTTL).UTC()
+	return token, &expiresAt
+}
+
+// trashMedia moves each key to trash/<token>/<key> and returns the keys it moved. Files
+// that are already gone are skipped.
+func (h *EntryHandler) trashMedia(ctx context.Context, token string, keys []string) map[string]string {
+	trashed := make(map[string]string, len(keys))
+	for _, key := range keys {
+		trashKey := path.Join(mediaTrashPrefix, token, key)
+		if err := h.media.Copy(ctx, key, trashKey); err != nil {
+			if !errors.Is(err, storage.ErrNotExist) {
+				h.logger.Warnw("Failed to move removed media to trash", "key", key, "error", err)
+			}
+			continue
+		}
+		if err := h.media.Delete(ctx, key); err != nil {
+			h.logger.Warnw("Failed to delete removed media after trashing it", "key", key, "error", err)
+		}
+		trashed[key] = trashKey
+	}
+	return trashed
+}
+
+// UndoRemoval restores a tag, location, image or audio clip removed within the last
+// undoRemovalTTL, using the undoToken its remove endpoint returned
+func (h *EntryHandler) UndoRemoval(c *gin.Context) {
+	var req undomodels.UndoRemovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "undoToken is required")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ctx := c.Request.Context()
+
+	// Only one undo of a removal may restore it
+	claimKey := "undo_removal_claim:" + req.UndoToken
+	claimed, err := h.cache.SetNX(ctx, claimKey, userUID, time.Minute)
+	if err != nil {
+		h.logError(c, err, "claim undo failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to undo removal")
+		return
+	}
+	if !claimed {
+		respondError(c, http.StatusConflict, apierror.CodeConflict, "Removal is already being restored")
+		return
+	}
+	defer h.cache.Del(context.WithoutCancel(ctx), claimKey)
+
+	value, err := h.cache.Get(ctx, undoRemovalKey(req.UndoToken))
+	if err != nil && !errors.Is(err, cache.ErrMiss) {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "load undo record failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to undo removal")
+		return
+	}
+	var r pendingRemoval
+	if err != nil || json.Unmarshal([]byte(value), &r) != nil || r.UID != userUID {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Nothing to undo; the undo window may have expired")
+		return
+	}
+
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var entryExists bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM entries WHERE id = $1 AND user_uid = $2)
+	`, r.EntryID, userUID).Scan(&entryExists)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "verify entry failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
+		return
+	}
+	if !entryExists {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+		return
+	}
+
+	resp := undomodels.UndoRemovalResponse{EntryID: r.EntryID, Kind: r.Kind}
+
+	// Files copied back out of the trash; deleted again if the restore doesn't commit.
+	// The trash keeps its copies until the restore succeeds, so the undo can be retried.
+	var restoredKeys []string
+	committed := false
+	defer func() {
+		if !committed {
+			for _, key := range restoredKeys {
+				_ = h.media.Delete(context.WithoutCancel(ctx), key)
+			}
+		}
+	}()
+
+	switch r.Kind {
+	case removalKindTag:
+		for _, t := range r.Tags {
+			result, err := tx.Exec(ctx, `
+				INSERT INTO tags (entry_id, key, value, created_at)
+				VALUES ($1, $2, $3, COALESCE($4::timestamp, NOW()))
+				ON CONFLICT (entry_id, key) DO NOTHING
+			`, r.EntryID, t.Key, t.Value, t.CreatedAt)
+			if err != nil {
+				h.logError(c, err, "restore tag failed")
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to restore tag")
+				return
+			}
+			if result.RowsAffected() == 0 {
+				respondError(c, http.StatusConflict, apierror.CodeConflict, "The entry has a tag with that key again; remove it before undoing")
+				return
+			}
+			tag := accountmodels.Tag{Key: t.Key}
+			if t.Value != nil {
+				tag.Value = *t.Value
+			}
+			resp.Tags = append(resp.Tags, tag)
+		}
+
+	case removalKindLocation:
+		for _, l := range r.Locations {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO locations (entry_id, latitude, longitude, address, city, state, zip, country, country_code, display_name, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11::timestamp, NOW()))
+			`, r.EntryID, l.Latitude, l.Longitude, l.Address, l.City, l.State, l.Zip, l.Country, l.CountryCode, l.DisplayName, l.CreatedAt)
+			if err != nil {
+				h.logError(c, err, "restore location failed")
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to restore location")
+				return
+			}
+			resp.Locations = append(resp.Locations, accountmodels.Location{
+				Latitude:    l.Latitude,
+				Longitude:   l.Longitude,
+				Address:     derefString(l.Address),
+				City:        derefString(l.City),
+				State:       derefString(l.State),
+				Zip:         derefString(l.Zip),
+				Country:     derefString(l.Country),
+				CountryCode: derefString(l.CountryCode),
+				DisplayName: derefString(l.DisplayName),
+			})
+		}
+
+	case removalKindImage, removalKindAudio:
+		m := r.Media
+		if m == nil {
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Nothing to undo; the undo window may have expired")
+			return
+		}
+		for key, trashKey := range m.Trashed {
+			if err := h.media.Copy(ctx, trashKey, key); err != nil {
+				if errors.Is(err, storage.ErrNotExist) {
+					respondError(c, http.StatusGone, apierror.CodeNotFound, "The removed file is no longer available")
+					return
+				}
+				if abortOnContextError(c, err) {
+					return
+				}
+				h.logError(c, err, "restore media file failed", "key", key)
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to restore file")
+				return
+			}
+			restoredKeys = append(restoredKeys, key)
+		}
+
+		if r.Kind == removalKindImage {
+			_, err = tx.Exec(ctx, `
+				INSERT INTO images (entry_id, url, filename, file_size, mime_type, width, height, webp_url, upload_order, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE($10::timestamp, NOW()))
+			`, r.EntryID, m.URL, m.Filename, m.FileSize, m.MimeType, m.Width, m.Height, m.WebPURL, m.UploadOrder, m.CreatedAt)
+			resp.ImageURL = m.URL
+		} else {
+			_, err = tx.Exec(ctx, `
+				INSERT INTO audio (entry_id, url, filename, file_size, mime_type, duration, upload_order, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8::timestamp, NOW()))
+			`, r.EntryID, m.URL, m.Filename, m.FileSize, m.MimeType, m.Duration, m.UploadOrder, m.CreatedAt)
+			resp.AudioURL = m.URL
+		}
+		if err != nil {
+			h.logError(c, err, "restore media row failed", "kind", r.Kind)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to restore "+r.Kind)
+			return
+		}
+
+	default:
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Nothing to undo; the undo window may have expired")
+		return
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE entries SET updated_at = $1 WHERE id = $2`, time.Now(), r.EntryID); err != nil {
+		h.logError(c, err, "update entry timestamp failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "commit undo failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to undo removal")
+		return
+	}
+	committed = true
+
+	cleanupCtx := context.WithoutCancel(ctx)
+	_ = h.cache.Del(cleanupCtx, undoRemovalKey(req.UndoToken), "entry:"+r.EntryID)
+	if r.Media != nil {
+		for _, trashKey := range r.Media.Trashed {
+			_ = h.media.Delete(cleanupCtx, trashKey)
+		}
+	}
+	if r.Kind == removalKindLocation {
+		h.invalidateUniqueLocationsCache(cleanupCtx, userUID)
+	}
+	invalidateAccountDetails(cleanupCtx, h.cache, userUID)
+
+	resp.Message = "Removal undone"
+	c.JSON(http.StatusOK, resp)
+}
+
+// setupMediaTrashSweep schedules emptying the media trash on the notifications scheduler,
+// which runs the server's other periodic jobs
+func (h *EntryHandler) setupMediaTrashSweep(ns *NotificationsHandler) {
+	_, err := ns.cronManager.AddFunc("*/10 * * * *", ns.trackedJob(func() {
+		h.sweepMediaTrash(context.Background())
+	}))
+	if err != nil {
+		h.logger.Errorw("Failed to schedule media trash sweep", "error", err)
+	}
+}
+
+// sweepMediaTrash deletes trashed files older than mediaTrashRetention, whose removals can
+// no longer be undone
+func (h *EntryHandler) sweepMediaTrash(ctx context.Context) {
+	cutoff := time.Now().Add(-mediaTrashRetention)
+	var stale []string
+	err := h.media.Walk(ctx, mediaTrashPrefix, func(info storage.Info) error {
+		if info.ModTime.Before(cutoff) {
+			stale = append(stale, info.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		h.logger.Errorw("Failed to list media trash", "error", err)
+		return
+	}
+
+	deleted := 0
+	for _, key := range stale {
+		if err := h.media.Delete(ctx, key); err != nil {
+			h.logger.Warnw("Failed to delete trashed media", "key", key, "error", err)
+			continue
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		h.logger.Infow("Emptied media trash", "deleted", deleted)
+	}
+}
+
+// removedLocationsFromRows collects the rows of a DELETE FROM locations ... RETURNING
+func removedLocationsFromRows(rows pgx.Rows) ([]removedLocation, error) {
+	defer rows.Close()
+	var removed []removedLocation
+	for rows.Next() {
+		var l removedLocation
+		if err := rows.Scan(&l.Latitude, &l.Longitude, &l.Address, &l.City, &l.State, &l.Zip, &l.Country, &l.CountryCode, &l.DisplayName, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		removed = append(removed, l)
+	}
+	return removed, rows.Err()
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
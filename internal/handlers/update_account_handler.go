@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	firebaseauth "firebase.google.com/go/v4/auth"
 	"github.com/gin-gonic/gin"
 
+	"io.winapps.journeyapp/internal/apierror"
 	firebaseutil "io.winapps.journeyapp/internal/firebase"
 	updatemodels "io.winapps.journeyapp/internal/models/update-account"
 )
@@ -22,12 +24,12 @@ func (h *AuthHandler) UpdateAccount(c *gin.Context) {
 	// Ensure user is authenticated (middleware populates context)
 	uidCtx, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 	authenticatedUID, ok := uidCtx.(string)
 	if !ok || authenticatedUID == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
@@ -36,7 +38,10 @@ func (h *AuthHandler) UpdateAccount(c *gin.Context) {
 	// Parse JSON body into a raw map to detect which keys are present
 	var raw map[string]json.RawMessage
 	if strings.Contains(strings.ToLower(c.ContentType()), "application/json") {
-		_ = c.ShouldBindJSON(&raw)
+		if err := c.ShouldBindJSON(&raw); isBodyTooLarge(err) {
+			respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Request body is too large")
+			return
+		}
 	}
 
 	// Determine target UID from JSON or query
@@ -48,11 +53,11 @@ func (h *AuthHandler) UpdateAccount(c *gin.Context) {
 		}
 	}
 	if targetUID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing uid in request"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Missing uid in request")
 		return
 	}
 	if targetUID != authenticatedUID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot update another user's account"})
+		respondError(c, http.StatusForbidden, apierror.CodeForbidden, "Cannot update another user's account")
 		return
 	}
 
@@ -73,18 +78,8 @@ func (h *AuthHandler) UpdateAccount(c *gin.Context) {
 		}
 	}
 
-	// email
-	if b, ok := raw["email"]; ok {
-		var v string
-		if err := json.Unmarshal(b, &v); err == nil {
-			v = strings.TrimSpace(v)
-			if v != "" {
-				setClauses = append(setClauses, fmt.Sprintf("email = $%d", argIndex))
-				args = append(args, v)
-				argIndex++
-			}
-		}
-	}
+	// email is not client-writable here; RequestEmailChange and ConfirmEmailChange change it
+	// in Firebase and Postgres together once the new address is verified
 
 	// phoneNumber
 	if b, ok := raw["phoneNumber"]; ok {
@@ -99,15 +94,7 @@ func (h *AuthHandler) UpdateAccount(c *gin.Context) {
 		}
 	}
 
-	// emailVerified
-	if b, ok := raw["emailVerified"]; ok {
-		var v bool
-		if err := json.Unmarshal(b, &v); err == nil {
-			setClauses = append(setClauses, fmt.Sprintf("email_verified = $%d", argIndex))
-			args = append(args, v)
-			argIndex++
-		}
-	}
+	// emailVerified is not client-writable; it is synced from Firebase token claims at login
 
 	// phoneNumberVerified
 	if b, ok := raw["phoneNumberVerified"]; ok {
@@ -119,52 +106,44 @@ func (h *AuthHandler) UpdateAccount(c *gin.Context) {
 		}
 	}
 
-	// isPremium
-	if b, ok := raw["isPremium"]; ok {
-		var v bool
-		if err := json.Unmarshal(b, &v); err == nil {
-			setClauses = append(setClauses, fmt.Sprintf("is_premium = $%d", argIndex))
-			args = append(args, v)
-			argIndex++
-		}
-	}
-
-	// premiumExpiresAt (supports explicit null)
-	if b, ok := raw["premiumExpiresAt"]; ok {
-		if strings.TrimSpace(string(b)) == "null" {
-			setClauses = append(setClauses, "premium_expires_at = NULL")
-		} else {
-			var v time.Time
-			if err := json.Unmarshal(b, &v); err == nil {
-				setClauses = append(setClauses, fmt.Sprintf("premium_expires_at = $%d", argIndex))
-				args = append(args, v)
-				argIndex++
-			}
-		}
-	}
+	// isPremium and premiumExpiresAt are not client-writable; they are set by VerifySubscription,
+	// the billing webhook and the daily expiry job
 
-	// Photo handling (photoURL in JSON or multipart file)
+	// Photo handling (photoURL in JSON or multipart file). An uploaded photo is staged and
+	// only stored once the user row is updated.
 	photoWasUpdated := false
+	var photo *stagedMedia
+	defer func() { photo.Discard() }()
 	if b, ok := raw["photoURL"]; ok {
 		var v string
 		if err := json.Unmarshal(b, &v); err == nil {
 			v = strings.TrimSpace(v)
 			if v != "" {
 				if strings.HasPrefix(strings.ToLower(v), "data:") || strings.Contains(v, ",") {
-					_, absoluteURL, err := h.saveProfileImageToFileSystem(v, targetUID)
+					staged, err := h.saveProfileImageToFileSystem(v, targetUID)
+					if errors.Is(err, errMediaTooLarge) {
+						respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Image is too large: "+err.Error())
+						return
+					}
+					if errors.Is(err, errUnsupportedMediaType) {
+						respondError(c, http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia, "Unsupported media type")
+						return
+					}
 					if err != nil {
-						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image: " + err.Error()})
+						respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save image: " + err.Error())
 						return
 					}
+					photo = staged
+					absoluteURL := absoluteMediaURL(c, staged.URL)
 					// Update Firebase Auth photo URL
 					authClient, err := firebaseutil.GetAuthClient(h.firebaseApp)
 					if err != nil {
-						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize auth client"})
+						respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to initialize auth client")
 						return
 					}
 					params := (&firebaseauth.UserToUpdate{}).PhotoURL(absoluteURL)
 					if _, err := authClient.UpdateUser(ctx, targetUID, params); err != nil {
-						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update Firebase photo URL"})
+						respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update Firebase photo URL")
 						return
 					}
 					setClauses = append(setClauses, fmt.Sprintf("photo_url = $%d", argIndex))
@@ -182,33 +161,48 @@ func (h *AuthHandler) UpdateAccount(c *gin.Context) {
 	}
 
 	if !photoWasUpdated {
-		if fileHeader, err := c.FormFile("photo"); err == nil && fileHeader != nil {
+		fileHeader, err := c.FormFile("photo")
+		if isBodyTooLarge(err) {
+			respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Request body is too large")
+			return
+		}
+		if err == nil && fileHeader != nil {
+			if fileHeader.Size > maxProfilePicBytes {
+				respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, fmt.Sprintf("Image is too large: %v (%d MB)", errMediaTooLarge, maxProfilePicBytes>>20))
+				return
+			}
 			file, err := fileHeader.Open()
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to open uploaded image"})
+				respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Failed to open uploaded image")
 				return
 			}
 			defer file.Close()
 			data, err := io.ReadAll(file)
 			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded image"})
+				respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Failed to read uploaded image")
 				return
 			}
 			base64Body := base64.StdEncoding.EncodeToString(data)
-			_, absoluteURL, err := h.saveProfileImageToFileSystem(base64Body, targetUID)
+			staged, err := h.saveProfileImageToFileSystem(base64Body, targetUID)
+			if errors.Is(err, errUnsupportedMediaType) {
+				respondError(c, http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia, "Unsupported media type")
+				return
+			}
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image: " + err.Error()})
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save image: " + err.Error())
 				return
 			}
+			photo = staged
+			absoluteURL := absoluteMediaURL(c, staged.URL)
 			// Update Firebase Auth photo URL
 			authClient, err := firebaseutil.GetAuthClient(h.firebaseApp)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initialize auth client"})
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to initialize auth client")
 				return
 			}
 			params := (&firebaseauth.UserToUpdate{}).PhotoURL(absoluteURL)
 			if _, err := authClient.UpdateUser(ctx, targetUID, params); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update Firebase photo URL"})
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update Firebase photo URL")
 				return
 			}
 			setClauses = append(setClauses, fmt.Sprintf("photo_url = $%d", argIndex))
@@ -257,13 +251,17 @@ func (h *AuthHandler) UpdateAccount(c *gin.Context) {
 		&createdAt,
 		&updatedAt,
 	); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update user")
+		return
+	}
+	if err := photo.Commit(ctx); err != nil {
+		h.logError(c, err, "store profile photo failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save image")
 		return
 	}
 
 	// Invalidate cached account details
-	cacheKey := fmt.Sprintf("account_details:%s", targetUID)
-	_ = h.redis.Del(ctx, cacheKey).Err()
+	invalidateAccountDetails(ctx, h.cache, targetUID)
 
 	resp := updatemodels.UpdateAccountResponse{
 		UID: uid,
@@ -280,7 +278,7 @@ func (h *AuthHandler) UpdateAccount(c *gin.Context) {
 	}
 
 	if payload, err := json.Marshal(resp); err == nil {
-		_ = h.redis.Set(ctx, "user:"+uid, payload, 24*time.Hour).Err()
+		_ = h.cache.Set(ctx, "user:"+uid, payload, 24*time.Hour)
 	}
 
 	c.JSON(http.StatusOK, resp)
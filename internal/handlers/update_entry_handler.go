@@ -11,61 +11,133 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"io.winapps.journeyapp/internal/apierror"
 	models "io.winapps.journeyapp/internal/models/account"
 	updateentrymodels "io.winapps.journeyapp/internal/models/update_entry"
+	"io.winapps.journeyapp/internal/webhooks"
 )
 
-// UpdateEntry handles updating the title and/or description of an entry
+// UpdateEntry handles updating the title and/or description of an entry. Only fields
+// present in the body change, so an explicit "description": "" clears the description
+// while leaving it out keeps it. The title can't be cleared.
 func (h *EntryHandler) UpdateEntry(c *gin.Context) {
+	body, err := c.GetRawData()
+	if isBodyTooLarge(err) {
+		respondError(c, http.StatusRequestEntityTooLarge, apierror.CodePayloadTooLarge, "Request body is too large")
+		return
+	}
+	// Parse into a raw map as well to detect which keys are present
 	var req updateentrymodels.UpdateEntryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+	var raw map[string]json.RawMessage
+	if err != nil || json.Unmarshal(body, &req) != nil || json.Unmarshal(body, &raw) != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+	var title, description *string
+	if _, ok := raw["title"]; ok {
+		if strings.TrimSpace(req.Title) == "" {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Title cannot be empty")
+			return
+		}
+		title = &req.Title
+	}
+	if _, ok := raw["description"]; ok {
+		description = &req.Description
+	}
+	if err := validateEntryText(title, description); err != nil {
+		respondFieldTooLong(c, err)
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Validate required fields
 	if req.EntryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Entry ID is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
 		return
 	}
 
 	// At least one field must be provided for update
-	if req.Title == "" && req.Description == "" && req.Visibility == "" && len(req.SharedWith) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one field must be provided"})
+	if title == nil && description == nil && req.Visibility == "" && len(req.SharedWith) == 0 {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "At least one field must be provided")
 		return
 	}
 
 	ctx := context.Background()
 
+	// Publishing publicly or sharing with friends requires a verified email. A new share
+	// list without a requested visibility counts as sharing.
+	requested := strings.ToLower(strings.TrimSpace(req.Visibility))
+	reachVisibility := requested
+	if requested == "" && len(req.SharedWith) > 0 {
+		reachVisibility = "semi-private"
+	}
+	if entryReachesOthers(reachVisibility, len(req.SharedWith)) && !h.requireVerifiedEmail(c, ctx, userUID) {
+		return
+	}
+
+	// A new share list may only name friends. It's kept only if the entry ends up
+	// semi-private, which without a requested visibility depends on the current one.
+	if req.SharedWith != nil && (requested == "" || requested == "semi-private") {
+		invalid, err := h.checkShareTargets(ctx, userUID, req.SharedWith)
+		if err != nil {
+			h.logError(c, err, "share target lookup failed", "entryId", req.EntryID)
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify shared users")
+			return
+		}
+		if invalid != nil {
+			respondInvalidShareTargets(c, invalid)
+			return
+		}
+	}
+
+	// Text others will see goes through the content filter
+	rejection, err := h.filterEntryUpdate(c, req.EntryID, userUID, req.Visibility, &title, &description)
+	if err != nil {
+		h.logError(c, err, "content filter lookup failed", "entryId", req.EntryID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry")
+		return
+	}
+	if rejection != nil {
+		respondContentRejected(c, rejection)
+		return
+	}
+
 	// Update the entry
-	updatedEntry, err := h.updateEntryFields(ctx, req.EntryID, userUID, req.Title, req.Description, req.Visibility, req.SharedWith)
+	updatedEntry, err := h.updateEntryFields(ctx, req.EntryID, userUID, title, description, req.Visibility, req.SharedWith)
 	if err != nil {
 		if err.Error() == "entry not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found or access denied"})
+			respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry")
 		return
 	}
 
+	if len(req.SharedWith) > 0 && updatedEntry.Visibility == "semi-private" {
+		h.webhooks.Emit(userUID, webhooks.EventEntryShared, gin.H{
+			"entryId":    req.EntryID,
+			"sharedWith": updatedEntry.SharedWith,
+		})
+	}
+
 	c.JSON(http.StatusOK, updatedEntry)
 }
 
-// updateEntryFields updates the entry title and/or description in the database
-func (h *EntryHandler) updateEntryFields(ctx context.Context, entryID, userUID, title, description, visibility string, sharedWith []string) (*updateentrymodels.UpdateEntryResponse, error) {
+// updateEntryFields updates the entry title and/or description in the database. A nil
+// title or description is left unchanged.
+func (h *EntryHandler) updateEntryFields(ctx context.Context, entryID, userUID string, title, description *string, visibility string, sharedWith []string) (*updateentrymodels.UpdateEntryResponse, error) {
 	// Start transaction
 	tx, err := h.postgres.Begin(ctx)
 	if err != nil {
@@ -73,20 +145,37 @@ func (h *EntryHandler) updateEntryFields(ctx context.Context, entryID, userUID,
 	}
 	defer tx.Rollback(ctx)
 
+	// Seal the new text the same way as the rest of the user's entries
+	cipher := newEntryCipher(h.postgres, h.entryKeys)
+	if err := cipher.lockUser(ctx, tx, userUID); err != nil {
+		return nil, err
+	}
+	var newTitle, newDescription string
+	if title != nil {
+		newTitle = *title
+	}
+	if description != nil {
+		newDescription = *description
+	}
+	text, err := cipher.seal(ctx, userUID, newTitle, newDescription)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build dynamic update query based on provided fields
 	updateFields := []string{}
 	args := []interface{}{}
 	argCounter := 1
 
-	if title != "" {
+	if title != nil {
 		updateFields = append(updateFields, "title = $"+strconv.Itoa(argCounter))
-		args = append(args, title)
+		args = append(args, text.title)
 		argCounter++
 	}
 
-	if description != "" {
+	if description != nil {
 		updateFields = append(updateFields, "description = $"+strconv.Itoa(argCounter))
-		args = append(args, description)
+		args = append(args, text.description)
 		argCounter++
 	}
 
@@ -177,6 +266,7 @@ func (h *EntryHandler) updateEntryFields(ctx context.Context, entryID, userUID,
 	if err := tx.Commit(ctx); err != nil {
 		return nil, err
 	}
+	bumpFeedVersion(ctx, h.cache, userUID)
 
 	// Fetch the updated entry with all its data
 	updated, err := h.fetchUpdatedEntryWithDetails(ctx, entryID, userUID)
@@ -184,11 +274,12 @@ func (h *EntryHandler) updateEntryFields(ctx context.Context, entryID, userUID,
 		return nil, err
 	}
 
-	// Update Redis cache
-	entryJSON, mErr := json.Marshal(updated)
-	if mErr == nil {
-		redisKey := fmt.Sprintf("entry:%s", entryID)
-		_ = h.redis.Set(ctx, redisKey, entryJSON, 24*time.Hour).Err()
+	// Update Redis cache; encrypted entries are kept out of it
+	redisKey := fmt.Sprintf("entry:%s", entryID)
+	if text.encrypted {
+		_ = h.cache.Del(ctx, redisKey)
+	} else if entryJSON, mErr := json.Marshal(updated); mErr == nil {
+		_ = h.cache.Set(ctx, redisKey, entryJSON, 24*time.Hour)
 	}
 
 	// Maintain public/shared sets based on updated visibility
@@ -196,17 +287,17 @@ func (h *EntryHandler) updateEntryFields(ctx context.Context, entryID, userUID,
 		v := strings.ToLower(strings.TrimSpace(visibility))
 		switch v {
 		case "public":
-			_ = h.redis.SAdd(ctx, "public_entries", entryID).Err()
+			_ = h.cache.SAdd(ctx, "public_entries", entryID)
 			ownerKey := fmt.Sprintf("public_entries_by_user:%s", userUID)
-			_ = h.redis.SAdd(ctx, ownerKey, entryID).Err()
+			_ = h.cache.SAdd(ctx, ownerKey, entryID)
 		case "semi-private":
-			_ = h.redis.SRem(ctx, "public_entries", entryID).Err()
+			_ = h.cache.SRem(ctx, "public_entries", entryID)
 			ownerKey := fmt.Sprintf("public_entries_by_user:%s", userUID)
-			_ = h.redis.SRem(ctx, ownerKey, entryID).Err()
+			_ = h.cache.SRem(ctx, ownerKey, entryID)
 		case "private":
-			_ = h.redis.SRem(ctx, "public_entries", entryID).Err()
+			_ = h.cache.SRem(ctx, "public_entries", entryID)
 			ownerKey := fmt.Sprintf("public_entries_by_user:%s", userUID)
-			_ = h.redis.SRem(ctx, ownerKey, entryID).Err()
+			_ = h.cache.SRem(ctx, ownerKey, entryID)
 		}
 	}
 
@@ -214,15 +305,15 @@ func (h *EntryHandler) updateEntryFields(ctx context.Context, entryID, userUID,
 	entrySharesKey := fmt.Sprintf("entry_shares:%s", entryID)
 	if visibility != "" && strings.ToLower(strings.TrimSpace(visibility)) != "semi-private" {
 		// clear all share cache
-		members, _ := h.redis.SMembers(ctx, entrySharesKey).Result()
+		members, _ := h.cache.SMembers(ctx, entrySharesKey)
 		for _, m := range members {
 			userSharedKey := fmt.Sprintf("shared_entries:%s", m)
-			_ = h.redis.SRem(ctx, userSharedKey, entryID).Err()
+			_ = h.cache.SRem(ctx, userSharedKey, entryID)
 		}
-		_ = h.redis.Del(ctx, entrySharesKey).Err()
+		_ = h.cache.Del(ctx, entrySharesKey)
 	} else if sharedWith != nil {
 		// replace membership
-		oldMembers, _ := h.redis.SMembers(ctx, entrySharesKey).Result()
+		oldMembers, _ := h.cache.SMembers(ctx, entrySharesKey)
 		oldSet := make(map[string]struct{})
 		for _, om := range oldMembers { oldSet[om] = struct{}{} }
 		newSet := make(map[string]struct{})
@@ -235,16 +326,16 @@ func (h *EntryHandler) updateEntryFields(ctx context.Context, entryID, userUID,
 		for om := range oldSet {
 			if _, ok := newSet[om]; !ok {
 				userSharedKey := fmt.Sprintf("shared_entries:%s", om)
-				_ = h.redis.SRem(ctx, userSharedKey, entryID).Err()
-				_ = h.redis.SRem(ctx, entrySharesKey, om).Err()
+				_ = h.cache.SRem(ctx, userSharedKey, entryID)
+				_ = h.cache.SRem(ctx, entrySharesKey, om)
 			}
 		}
 		// additions
 		for sw := range newSet {
 			if _, ok := oldSet[sw]; !ok {
 				userSharedKey := fmt.Sprintf("shared_entries:%s", sw)
-				_ = h.redis.SAdd(ctx, userSharedKey, entryID).Err()
-				_ = h.redis.SAdd(ctx, entrySharesKey, sw).Err()
+				_ = h.cache.SAdd(ctx, userSharedKey, entryID)
+				_ = h.cache.SAdd(ctx, entrySharesKey, sw)
 			}
 		}
 	}
@@ -257,14 +348,16 @@ func (h *EntryHandler) fetchUpdatedEntryWithDetails(ctx context.Context, entryID
 	// Get the basic entry information
 	var entry updateentrymodels.UpdateEntryResponse
 	entryQuery := `
-		SELECT id, title, description, visibility, created_at, updated_at
+		SELECT id, title, description, encrypted, visibility, created_at, updated_at
 		FROM entries
 		WHERE id = $1 AND user_uid = $2
 	`
+	var encrypted bool
 	err := h.postgres.QueryRow(ctx, entryQuery, entryID, userUID).Scan(
 		&entry.ID,
 		&entry.Title,
 		&entry.Description,
+		&encrypted,
 		&entry.Visibility,
 		&entry.CreatedAt,
 		&entry.UpdatedAt,
@@ -272,9 +365,14 @@ func (h *EntryHandler) fetchUpdatedEntryWithDetails(ctx context.Context, entryID
 	if err != nil {
 		return nil, fmt.Errorf("entry not found")
 	}
+	if err := newEntryCipher(h.postgres, h.entryKeys).open(ctx, userUID, encrypted, &entry.Title, &entry.Description); err != nil {
+		return nil, fmt.Errorf("failed to decrypt entry: %w", err)
+	}
 
 	// Initialize slices
+	entry.SharedWith = []string{}
 	entry.Images = []string{}
+	entry.Videos = []models.Video{}
 	entry.Tags = []models.Tag{}
 	entry.Locations = []models.Location{}
 
@@ -361,5 +459,30 @@ func (h *EntryHandler) fetchUpdatedEntryWithDetails(ctx context.Context, entryID
 		entry.Audio = append(entry.Audio, audioURL)
 	}
 
+	// Fetch videos
+	videoQuery := `
+		SELECT url, COALESCE(thumbnail_url, ''), COALESCE(duration, 0) FROM videos WHERE entry_id = $1 ORDER BY upload_order
+	`
+	videoRows, err := h.postgres.Query(ctx, videoQuery, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch videos: %w", err)
+	}
+	defer videoRows.Close()
+
+	for videoRows.Next() {
+		var video models.Video
+		if err := videoRows.Scan(&video.URL, &video.ThumbnailURL, &video.Duration); err != nil {
+			return nil, fmt.Errorf("failed to scan video: %w", err)
+		}
+		entry.Videos = append(entry.Videos, video)
+	}
+
+	// Fetch shared users
+	sharedWith, err := h.entrySharedWith(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+	entry.SharedWith = sharedWith
+
 	return &entry, nil
 }
\ No newline at end of file
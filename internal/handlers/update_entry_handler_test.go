@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"io.winapps.journeyapp/internal/cache"
+)
+
+// Leaving description out keeps it; sending it empty clears it
+func TestUpdateEntryOmittedVersusEmptyDescription(t *testing.T) {
+	pool := testPool(t)
+	h := NewEntryHandler(nil, pool, cache.NewMemory(100), nil)
+	alice := testUser(t, pool, "alice")
+	entryID := testEntry(t, pool, alice, "Original title", "private")
+	mustExec(t, pool, `UPDATE entries SET description = 'Original description' WHERE id = $1`, entryID)
+
+	update := func(body string) int {
+		t.Helper()
+		w := callAs(alice, h.UpdateEntry, http.MethodPost, "/update-entry", body)
+		return w.Code
+	}
+	stored := func() (title, description string) {
+		t.Helper()
+		if err := pool.QueryRow(context.Background(), `SELECT title, description FROM entries WHERE id = $1`, entryID).Scan(&title, &description); err != nil {
+			t.Fatal(err)
+		}
+		return title, description
+	}
+
+	if code := update(`{"entryId":"` + entryID + `","title":"New title"}`); code != http.StatusOK {
+		t.Fatalf("update title: %d", code)
+	}
+	if title, description := stored(); title != "New title" || description != "Original description" {
+		t.Errorf("after omitting description: %q / %q, want the description kept", title, description)
+	}
+
+	if code := update(`{"entryId":"` + entryID + `","description":""}`); code != http.StatusOK {
+		t.Fatalf("clear description: %d", code)
+	}
+	if title, description := stored(); title != "New title" || description != "" {
+		t.Errorf("after an empty description: %q / %q, want the description cleared and the title kept", title, description)
+	}
+
+	if code := update(`{"entryId":"` + entryID + `","title":""}`); code != http.StatusBadRequest {
+		t.Errorf("clearing the title: %d, want 400", code)
+	}
+	if title, _ := stored(); title != "New title" {
+		t.Errorf("title after a rejected clear = %q", title)
+	}
+}
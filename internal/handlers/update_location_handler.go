@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"io.winapps.journeyapp/internal/apierror"
 	updatelocationmodels "io.winapps.journeyapp/internal/models/update_location"
 )
 
@@ -14,26 +15,26 @@ import (
 func (h *EntryHandler) UpdateLocation(c *gin.Context) {
 	var req updatelocationmodels.UpdateLocationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Validate required fields
 	if req.EntryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Entry ID is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
 		return
 	}
 
@@ -46,12 +47,12 @@ func (h *EntryHandler) UpdateLocation(c *gin.Context) {
 	`
 	err := h.postgres.QueryRow(ctx, entryCheckQuery, req.EntryID, userUID).Scan(&entryExists)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify entry"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
 		return
 	}
 
 	if !entryExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found or access denied"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
 		return
 	}
 
@@ -62,12 +63,12 @@ func (h *EntryHandler) UpdateLocation(c *gin.Context) {
 	`
 	err = h.postgres.QueryRow(ctx, oldLocationCheckQuery, req.EntryID, req.OldLocation.Latitude, req.OldLocation.Longitude).Scan(&oldLocationExists)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing location"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check existing location")
 		return
 	}
 
 	if !oldLocationExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Old location not found"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Old location not found")
 		return
 	}
 
@@ -80,12 +81,12 @@ func (h *EntryHandler) UpdateLocation(c *gin.Context) {
 			`
 			err = h.postgres.QueryRow(ctx, newLocationCheckQuery, req.EntryID, req.NewLocation.Latitude, req.NewLocation.Longitude).Scan(&newLocationExists)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check new location"})
+				respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check new location")
 				return
 			}
 
 			if newLocationExists {
-				c.JSON(http.StatusConflict, gin.H{"error": "New location coordinates already exist for this entry"})
+				respondError(c, http.StatusConflict, apierror.CodeConflict, "New location coordinates already exist for this entry")
 				return
 			}
 		}
@@ -94,7 +95,7 @@ func (h *EntryHandler) UpdateLocation(c *gin.Context) {
 	// Start database transaction
 	tx, err := h.postgres.Begin(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start database transaction"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
 		return
 	}
 	defer tx.Rollback(ctx)
@@ -122,13 +123,13 @@ func (h *EntryHandler) UpdateLocation(c *gin.Context) {
 		req.OldLocation.Longitude,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update location"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update location")
 		return
 	}
 
 	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Location not found")
 		return
 	}
 
@@ -138,19 +139,21 @@ func (h *EntryHandler) UpdateLocation(c *gin.Context) {
 	`
 	_, err = tx.Exec(ctx, updateEntryQuery, now, req.EntryID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry timestamp"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
 		return
 	}
 
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save location update"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save location update")
 		return
 	}
 
 	// Invalidate Redis cache for this entry
 	redisKey := "entry:" + req.EntryID
-	h.redis.Del(ctx, redisKey)
+	h.cache.Del(ctx, redisKey)
+	h.invalidateUniqueLocationsCache(ctx, userUID)
+	invalidateAccountDetails(ctx, h.cache, userUID)
 
 	// Create response
 	response := updatelocationmodels.UpdateLocationResponse{
@@ -9,28 +9,32 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"io.winapps.journeyapp/internal/apierror"
 	accountmodels "io.winapps.journeyapp/internal/models/account"
 	updatesettingsmodels "io.winapps.journeyapp/internal/models/update_settings"
 )
 
+// supportedLangs are the app languages user_settings.lang accepts
+var supportedLangs = []string{"en", "ar", "de", "es", "fr", "he", "ja", "ko", "pt", "ru", "uk", "vi", "zh"}
+
 // UpdateSettings handles updating user settings
 func (h *AuthHandler) UpdateSettings(c *gin.Context) {
 	var req updatesettingsmodels.UpdateSettingsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
@@ -38,27 +42,32 @@ func (h *AuthHandler) UpdateSettings(c *gin.Context) {
 
 	// Validate the request fields
 	if err := h.validateSettingsRequest(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, err.Error())
 		return
 	}
 
 	// Update the settings
 	updatedSettings, err := h.updateUserSettings(ctx, userUID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update settings: " + err.Error()})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update settings: "+err.Error())
 		return
 	}
 
+	// Account details carry the theme, font and language
+	invalidateAccountDetails(ctx, h.cache, userUID)
+
 	// Create success response
 	response := updatesettingsmodels.UpdateSettingsResponse{
-		Success:   true,
-		Message:   "Settings updated successfully",
-		UID:       updatedSettings.UID,
-		ThemeMode: updatedSettings.ThemeMode,
-		Theme:     updatedSettings.Theme,
-		AppFont:   updatedSettings.AppFont,
-		Lang:      updatedSettings.Lang,
-		UpdatedAt: updatedSettings.UpdatedAt,
+		Success:             true,
+		Message:             "Settings updated successfully",
+		UID:                 updatedSettings.UID,
+		ThemeMode:           updatedSettings.ThemeMode,
+		Theme:               updatedSettings.Theme,
+		AppFont:             updatedSettings.AppFont,
+		Lang:                updatedSettings.Lang,
+		NotifyAnnouncements: updatedSettings.NotifyAnnouncements,
+		NormalizeTags:       updatedSettings.NormalizeTags,
+		UpdatedAt:           updatedSettings.UpdatedAt,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -92,9 +101,8 @@ func (h *AuthHandler) validateSettingsRequest(req *updatesettingsmodels.UpdateSe
 
 	// Validate lang
 	if req.Lang != nil {
-		validLangs := []string{"en", "ar", "de", "es", "fr", "he", "ja", "ko", "pt", "ru", "uk", "vi", "zh"}
-		if !contains(validLangs, *req.Lang) {
-			return fmt.Errorf("invalid lang: must be one of %v", validLangs)
+		if !contains(supportedLangs, *req.Lang) {
+			return fmt.Errorf("invalid lang: must be one of %v", supportedLangs)
 		}
 	}
 
@@ -132,6 +140,18 @@ func (h *AuthHandler) updateUserSettings(ctx context.Context, uid string, req *u
 		argIndex++
 	}
 
+	if req.NotifyAnnouncements != nil {
+		setParts = append(setParts, fmt.Sprintf("notify_announcements = $%d", argIndex))
+		args = append(args, *req.NotifyAnnouncements)
+		argIndex++
+	}
+
+	if req.NormalizeTags != nil {
+		setParts = append(setParts, fmt.Sprintf("normalize_tags = $%d", argIndex))
+		args = append(args, *req.NormalizeTags)
+		argIndex++
+	}
+
 	if len(setParts) == 0 {
 		// No fields to update, just return current settings
 		return h.getUserSettings(ctx, uid)
@@ -149,7 +169,7 @@ func (h *AuthHandler) updateUserSettings(ctx context.Context, uid string, req *u
 		UPDATE user_settings
 		SET %s
 		WHERE uid = $%d
-		RETURNING uid, theme_mode, theme, app_font, lang, created_at, updated_at
+		RETURNING uid, theme_mode, theme, app_font, lang, notify_announcements, normalize_tags, created_at, updated_at
 	`, strings.Join(setParts, ", "), argIndex)
 
 	var settings accountmodels.UserSettings
@@ -159,6 +179,8 @@ func (h *AuthHandler) updateUserSettings(ctx context.Context, uid string, req *u
 		&settings.Theme,
 		&settings.AppFont,
 		&settings.Lang,
+		&settings.NotifyAnnouncements,
+		&settings.NormalizeTags,
 		&settings.CreatedAt,
 		&settings.UpdatedAt,
 	)
@@ -173,7 +195,7 @@ func (h *AuthHandler) updateUserSettings(ctx context.Context, uid string, req *u
 // getUserSettings retrieves current user settings
 func (h *AuthHandler) getUserSettings(ctx context.Context, uid string) (*accountmodels.UserSettings, error) {
 	query := `
-		SELECT uid, theme_mode, theme, app_font, lang, created_at, updated_at
+		SELECT uid, theme_mode, theme, app_font, lang, notify_announcements, normalize_tags, created_at, updated_at
 		FROM user_settings
 		WHERE uid = $1
 	`
@@ -185,6 +207,8 @@ func (h *AuthHandler) getUserSettings(ctx context.Context, uid string) (*account
 		&settings.Theme,
 		&settings.AppFont,
 		&settings.Lang,
+		&settings.NotifyAnnouncements,
+		&settings.NormalizeTags,
 		&settings.CreatedAt,
 		&settings.UpdatedAt,
 	)
@@ -204,4 +228,4 @@ func contains(slice []string, item string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
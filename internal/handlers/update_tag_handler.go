@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"io.winapps.journeyapp/internal/apierror"
 	updatetagmodels "io.winapps.journeyapp/internal/models/update_tag"
 )
 
@@ -14,31 +15,35 @@ import (
 func (h *EntryHandler) UpdateTag(c *gin.Context) {
 	var req updatetagmodels.UpdateTagRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	// Get UID from context (set by auth middleware)
 	uid, exists := c.Get("uid")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
 		return
 	}
 
 	userUID, ok := uid.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user context"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Invalid user context")
 		return
 	}
 
 	// Validate required fields
 	if req.EntryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Entry ID is required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Entry ID is required")
 		return
 	}
 
 	if req.OldTag.Key == "" || req.NewTag.Key == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Both old and new tag keys are required"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Both old and new tag keys are required")
+		return
+	}
+	if err := validateTag("newTag", req.NewTag); err != nil {
+		respondFieldTooLong(c, err)
 		return
 	}
 
@@ -51,14 +56,23 @@ func (h *EntryHandler) UpdateTag(c *gin.Context) {
 	`
 	err := h.postgres.QueryRow(ctx, entryCheckQuery, req.EntryID, userUID).Scan(&entryExists)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify entry"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to verify entry")
 		return
 	}
 
 	if !entryExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found or access denied"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Entry not found or access denied")
+		return
+	}
+
+	normalize, err := tagNormalizationEnabled(ctx, h.postgres, userUID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load settings")
 		return
 	}
+	if normalize {
+		req.NewTag.Key = normalizeTagKey(req.NewTag.Key)
+	}
 
 	// Check if old tag exists
 	var oldTagExists bool
@@ -67,12 +81,12 @@ func (h *EntryHandler) UpdateTag(c *gin.Context) {
 	`
 	err = h.postgres.QueryRow(ctx, oldTagCheckQuery, req.EntryID, req.OldTag.Key, req.OldTag.Value).Scan(&oldTagExists)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing tag"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check existing tag")
 		return
 	}
 
 	if !oldTagExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Old tag not found"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Old tag not found")
 		return
 	}
 
@@ -82,14 +96,22 @@ func (h *EntryHandler) UpdateTag(c *gin.Context) {
 		newTagCheckQuery := `
 			SELECT EXISTS(SELECT 1 FROM tags WHERE entry_id = $1 AND key = $2 AND value = $3)
 		`
-		err = h.postgres.QueryRow(ctx, newTagCheckQuery, req.EntryID, req.NewTag.Key, req.NewTag.Value).Scan(&newTagExists)
+		newTagCheckArgs := []interface{}{req.EntryID, req.NewTag.Key, req.NewTag.Value}
+		if normalize {
+			// Any other case variant of the new key would collide once renamed
+			newTagCheckQuery = `
+				SELECT EXISTS(SELECT 1 FROM tags WHERE entry_id = $1 AND LOWER(TRIM(key)) = $2 AND key <> $3)
+			`
+			newTagCheckArgs = []interface{}{req.EntryID, req.NewTag.Key, req.OldTag.Key}
+		}
+		err = h.postgres.QueryRow(ctx, newTagCheckQuery, newTagCheckArgs...).Scan(&newTagExists)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check new tag"})
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check new tag")
 			return
 		}
 
 		if newTagExists {
-			c.JSON(http.StatusConflict, gin.H{"error": "New tag already exists for this entry"})
+			respondError(c, http.StatusConflict, apierror.CodeConflict, "New tag already exists for this entry")
 			return
 		}
 	}
@@ -97,7 +119,7 @@ func (h *EntryHandler) UpdateTag(c *gin.Context) {
 	// Start database transaction
 	tx, err := h.postgres.Begin(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start database transaction"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to start database transaction")
 		return
 	}
 	defer tx.Rollback(ctx)
@@ -110,13 +132,13 @@ func (h *EntryHandler) UpdateTag(c *gin.Context) {
 	`
 	result, err := tx.Exec(ctx, tagQuery, req.NewTag.Key, req.NewTag.Value, now, req.EntryID, req.OldTag.Key, req.OldTag.Value)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tag"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update tag")
 		return
 	}
 
 	rowsAffected := result.RowsAffected()
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Tag not found")
 		return
 	}
 
@@ -126,19 +148,20 @@ func (h *EntryHandler) UpdateTag(c *gin.Context) {
 	`
 	_, err = tx.Exec(ctx, updateEntryQuery, now, req.EntryID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update entry timestamp"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update entry timestamp")
 		return
 	}
 
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save tag update"})
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to save tag update")
 		return
 	}
 
 	// Invalidate Redis cache for this entry
 	redisKey := "entry:" + req.EntryID
-	h.redis.Del(ctx, redisKey)
+	h.cache.Del(ctx, redisKey)
+	invalidateAccountDetails(ctx, h.cache, userUID)
 
 	// Create response
 	response := updatetagmodels.UpdateTagResponse{
@@ -3,23 +3,57 @@ package handlers
 import (
 	firebase "firebase.google.com/go/v4"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+
+	"io.winapps.journeyapp/internal/cache"
+	"io.winapps.journeyapp/internal/encryption"
+	"io.winapps.journeyapp/internal/webhooks"
 )
 
 type UsersHandler struct {
 	firebaseApp *firebase.App
 	postgres    *pgxpool.Pool
-	redis       *redis.Client
+	cache       cache.Store
 	logger      *zap.SugaredLogger
+	entryKeys   *encryption.Keyring
+
+	notifications *NotificationsHandler
+	webhooks      *webhooks.Dispatcher
 }
 
 // NewUsersHandler creates a new users handler
-func NewUsersHandler(firebaseApp *firebase.App, postgres *pgxpool.Pool, redis *redis.Client, logger *zap.SugaredLogger) *UsersHandler {
+func NewUsersHandler(firebaseApp *firebase.App, postgres *pgxpool.Pool, store cache.Store, logger *zap.SugaredLogger) *UsersHandler {
 	return &UsersHandler{
 		firebaseApp: firebaseApp,
 		postgres:    postgres,
-		redis:       redis,
+		cache:       store,
 		logger:      logger,
 	}
-}
\ No newline at end of file
+}
+
+// SetNotificationsHandler enables push notifications for friendship activity
+func (h *UsersHandler) SetNotificationsHandler(notifications *NotificationsHandler) {
+	h.notifications = notifications
+}
+
+// SetEntryKeyring lets feeds show friends' encrypted entries
+func (h *UsersHandler) SetEntryKeyring(keys *encryption.Keyring) {
+	h.entryKeys = keys
+}
+
+// SetWebhookDispatcher enables outbound webhooks for friendship events
+func (h *UsersHandler) SetWebhookDispatcher(dispatcher *webhooks.Dispatcher) {
+	h.webhooks = dispatcher
+}
+
+// notifyAsync runs a best-effort push notification without blocking or failing the request
+func (h *UsersHandler) notifyAsync(kind, recipientUID string, send func(ns *NotificationsHandler) error) {
+	if h.notifications == nil {
+		return
+	}
+	go func() {
+		if err := send(h.notifications); err != nil && h.logger != nil {
+			h.logger.Warnw("Failed to send push notification", "kind", kind, "recipient", recipientUID, "error", err)
+		}
+	}()
+}
@@ -2,44 +2,130 @@ package handlers
 
 import (
 	"context"
+	"fmt"
+	"math/rand"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"io.winapps.journeyapp/internal/apierror"
 	validatedisplaymodels "io.winapps.journeyapp/internal/models/validate_display_name"
 )
 
-// ValidateDisplayName checks if a provided displayName (username) is available
-func (h *AuthHandler) ValidateDisplayName(c *gin.Context) {
-	// Basic origin check to mitigate abuse from untrusted origins
-	origin := c.GetHeader("Origin")
-	if origin != "https://app.lifethread.me" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
-		return
-	}
+const (
+	// maxDisplayNameSuggestions caps the alternatives returned for a taken name
+	maxDisplayNameSuggestions = 3
+	// displayNameSuffixCandidates is how many sequential suffixes (name1, name2, ...) are tried
+	displayNameSuffixCandidates = 20
+)
 
+// ValidateDisplayName checks if a provided displayName (username) is available, comparing
+// case-insensitively. Taken names come back with suggestions that append a numeric suffix.
+// The route is unauthenticated, so main applies a per-IP rate limit.
+func (h *AuthHandler) ValidateDisplayName(c *gin.Context) {
 	var req validatedisplaymodels.ValidateDisplayNameRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
 		return
 	}
 
 	displayName := strings.TrimSpace(req.DisplayName)
 	if displayName == "" {
-		c.JSON(http.StatusOK, validatedisplaymodels.ValidateDisplayNameResponse{IsValid: false})
+		c.JSON(http.StatusOK, validatedisplaymodels.ValidateDisplayNameResponse{Suggestions: []string{}})
 		return
 	}
 
-	ctx := context.Background()
+	ctx := c.Request.Context()
+
+	taken, err := h.takenDisplayNames(ctx, []string{displayName})
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to validate display name")
+		return
+	}
+	if !taken[strings.ToLower(displayName)] {
+		c.JSON(http.StatusOK, validatedisplaymodels.ValidateDisplayNameResponse{IsValid: true, Available: true, Suggestions: []string{}})
+		return
+	}
 
-	// Case-insensitive existence check
-	var exists bool
-	query := `SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(display_name) = LOWER($1))`
-	if err := h.postgres.QueryRow(ctx, query, displayName).Scan(&exists); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate display name"})
+	suggestions, err := h.suggestDisplayNames(ctx, displayName)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to validate display name")
 		return
 	}
 
-	c.JSON(http.StatusOK, validatedisplaymodels.ValidateDisplayNameResponse{IsValid: !exists})
-}
\ No newline at end of file
+	c.JSON(http.StatusOK, validatedisplaymodels.ValidateDisplayNameResponse{IsValid: false, Available: false, Suggestions: suggestions})
+}
+
+// suggestDisplayNames returns up to maxDisplayNameSuggestions free names built from base
+// plus a numeric suffix
+func (h *AuthHandler) suggestDisplayNames(ctx context.Context, base string) ([]string, error) {
+	candidates := displayNameCandidates(base)
+	taken, err := h.takenDisplayNames(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	return pickDisplayNameSuggestions(candidates, taken), nil
+}
+
+// displayNameCandidates lists base1..base20 followed by a few random four-digit suffixes
+func displayNameCandidates(base string) []string {
+	candidates := make([]string, 0, displayNameSuffixCandidates+maxDisplayNameSuggestions)
+	for i := 1; i <= displayNameSuffixCandidates; i++ {
+		candidates = append(candidates, fmt.Sprintf("%s%d", base, i))
+	}
+	for i := 0; i < maxDisplayNameSuggestions; i++ {
+		candidates = append(candidates, fmt.Sprintf("%s%d", base, 1000+rand.Intn(9000)))
+	}
+	return candidates
+}
+
+// pickDisplayNameSuggestions returns the first maxDisplayNameSuggestions candidates that
+// aren't taken (keys are lowercased), skipping case-insensitive repeats
+func pickDisplayNameSuggestions(candidates []string, taken map[string]bool) []string {
+	suggestions := make([]string, 0, maxDisplayNameSuggestions)
+	seen := make(map[string]bool)
+	for _, name := range candidates {
+		key := strings.ToLower(name)
+		if taken[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		suggestions = append(suggestions, name)
+		if len(suggestions) == maxDisplayNameSuggestions {
+			break
+		}
+	}
+	return suggestions
+}
+
+// takenDisplayNames returns which of names (lowercased) already belong to a user.
+// The lookup uses the idx_users_display_name_lower expression index.
+func (h *AuthHandler) takenDisplayNames(ctx context.Context, names []string) (map[string]bool, error) {
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(name)
+	}
+
+	rows, err := h.postgres.Query(ctx, `SELECT LOWER(display_name) FROM users WHERE LOWER(display_name) = ANY($1)`, lowered)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taken := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		taken[name] = true
+	}
+	return taken, rows.Err()
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestDisplayNameCandidates(t *testing.T) {
+	candidates := displayNameCandidates("Journey")
+	if len(candidates) != displayNameSuffixCandidates+maxDisplayNameSuggestions {
+		t.Fatalf("got %d candidates", len(candidates))
+	}
+	if candidates[0] != "Journey1" || candidates[displayNameSuffixCandidates-1] != "Journey20" {
+		t.Errorf("sequential candidates = %q ... %q", candidates[0], candidates[displayNameSuffixCandidates-1])
+	}
+	random := regexp.MustCompile(`^Journey[1-9][0-9]{3}$`)
+	for _, name := range candidates[displayNameSuffixCandidates:] {
+		if !random.MatchString(name) {
+			t.Errorf("random candidate %q lacks a four-digit suffix", name)
+		}
+	}
+}
+
+func TestPickDisplayNameSuggestions(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []string
+		taken      map[string]bool
+		want       []string
+	}{
+		{
+			name:       "first free names in order",
+			candidates: []string{"Sam1", "Sam2", "Sam3", "Sam4", "Sam5"},
+			taken:      map[string]bool{"sam1": true, "sam3": true},
+			want:       []string{"Sam2", "Sam4", "Sam5"},
+		},
+		{
+			name:       "taken check ignores case",
+			candidates: []string{"Sam1", "Sam2"},
+			taken:      map[string]bool{"sam1": true},
+			want:       []string{"Sam2"},
+		},
+		{
+			name:       "case-insensitive repeats are suggested once",
+			candidates: []string{"Sam1234", "SAM1234", "Sam2"},
+			taken:      map[string]bool{},
+			want:       []string{"Sam1234", "Sam2"},
+		},
+		{
+			name:       "everything taken",
+			candidates: []string{"Sam1", "Sam2"},
+			taken:      map[string]bool{"sam1": true, "sam2": true},
+			want:       []string{},
+		},
+	}
+	for _, tt := range tests {
+		if got := pickDisplayNameSuggestions(tt.candidates, tt.taken); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"io.winapps.journeyapp/internal/apierror"
+	verifymodels "io.winapps.journeyapp/internal/models/verify_subscription"
+	"io.winapps.journeyapp/internal/subscriptions"
+)
+
+// errSubscriptionLinked is returned when a store subscription already belongs to another account
+var errSubscriptionLinked = errors.New("subscription is linked to another account")
+
+// SetSubscriptionVerifier enables VerifySubscription and receipt re-validation in the expiry job
+func (h *AuthHandler) SetSubscriptionVerifier(verifier subscriptions.Verifier) {
+	h.subscriptions = verifier
+}
+
+// VerifySubscription validates an App Store or Google Play receipt with the store and,
+// if the subscription is active, grants premium until the store's expiry date. This is
+// the only client-facing way to set is_premium and premium_expires_at.
+func (h *AuthHandler) VerifySubscription(c *gin.Context) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	var req verifymodels.VerifySubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+	req.Receipt = strings.TrimSpace(req.Receipt)
+	req.ProductID = strings.TrimSpace(req.ProductID)
+
+	if h.subscriptions == nil {
+		respondError(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, "Subscription verification is not configured")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	purchase, err := h.subscriptions.Verify(ctx, req.Platform, req.ProductID, req.Receipt)
+	if err != nil {
+		switch {
+		case errors.Is(err, subscriptions.ErrInvalidReceipt):
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid subscription receipt")
+		case errors.Is(err, subscriptions.ErrNotConfigured):
+			respondError(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, "Subscription verification is not configured for "+req.Platform)
+		default:
+			if abortOnContextError(c, err) {
+				return
+			}
+			h.logError(c, err, "store receipt verification failed", "platform", req.Platform)
+			respondError(c, http.StatusBadGateway, apierror.CodeUnavailable, "Failed to verify receipt with the store")
+		}
+		return
+	}
+
+	if !purchase.Active {
+		c.JSON(http.StatusOK, verifymodels.VerifySubscriptionResponse{
+			Success:   true,
+			Message:   "Subscription is not active",
+			ProductID: purchase.ProductID,
+			IsPremium: false,
+		})
+		return
+	}
+
+	if err := h.applySubscription(ctx, userUID, req.Platform, req.Receipt, purchase); err != nil {
+		if errors.Is(err, errSubscriptionLinked) {
+			respondError(c, http.StatusConflict, apierror.CodeConflict, "Subscription is already linked to another account")
+			return
+		}
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "store subscription failed", "platform", req.Platform)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update premium status")
+		return
+	}
+
+	expiresAt := purchase.ExpiresAt
+	c.JSON(http.StatusOK, verifymodels.VerifySubscriptionResponse{
+		Success:          true,
+		Message:          "Subscription verified",
+		ProductID:        purchase.ProductID,
+		IsPremium:        true,
+		PremiumExpiresAt: &expiresAt,
+	})
+}
+
+// applySubscription records the receipt and marks the user premium until the purchase expires
+func (h *AuthHandler) applySubscription(ctx context.Context, userUID, platform, receipt string, purchase *subscriptions.Purchase) error {
+	tx, err := h.postgres.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var owner string
+	err = tx.QueryRow(ctx, `
+		SELECT uid FROM subscription_receipts
+		WHERE platform = $1 AND subscription_id = $2 AND uid <> $3
+	`, platform, purchase.SubscriptionID, userUID).Scan(&owner)
+	if err == nil {
+		return errSubscriptionLinked
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO subscription_receipts (uid, platform, product_id, receipt, subscription_id, expires_at, verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (uid) DO UPDATE
+		SET platform = EXCLUDED.platform, product_id = EXCLUDED.product_id, receipt = EXCLUDED.receipt,
+		    subscription_id = EXCLUDED.subscription_id, expires_at = EXCLUDED.expires_at,
+		    verified_at = NOW(), updated_at = NOW()
+	`, userUID, platform, purchase.ProductID, receipt, purchase.SubscriptionID, purchase.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE users SET is_premium = TRUE, premium_expires_at = $1, updated_at = NOW()
+		WHERE uid = $2
+	`, purchase.ExpiresAt, userUID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	// Invalidate caches that expose premium status
+	invalidateAccountDetails(ctx, h.cache, userUID)
+	return nil
+}
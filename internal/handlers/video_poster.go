@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	videoProbeTimeout  = 15 * time.Second
+	videoPosterTimeout = 30 * time.Second
+
+	// posterWidth caps the poster frame's width; it is a preview, not a still export
+	posterWidth = 640
+)
+
+// videoToolsConfig locates the ffmpeg and ffprobe binaries used to read a video's duration
+// and grab its poster frame. FFMPEG_PATH and FFPROBE_PATH override the defaults on PATH.
+// A missing binary only turns its feature off, so uploads never fail for lack of them.
+type videoToolsConfig struct {
+	ffmpeg  string
+	ffprobe string
+}
+
+// videoToolsConfigFromEnv looks the binaries up once at startup
+func videoToolsConfigFromEnv() videoToolsConfig {
+	return videoToolsConfig{
+		ffmpeg:  lookPathOrEmpty(os.Getenv("FFMPEG_PATH"), "ffmpeg"),
+		ffprobe: lookPathOrEmpty(os.Getenv("FFPROBE_PATH"), "ffprobe"),
+	}
+}
+
+func lookPathOrEmpty(binary, fallback string) string {
+	if binary == "" {
+		binary = fallback
+	}
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// videoMimeType maps an extension chosen by videoExtension to its MIME type. Posters are
+// stored next to their video as JPEGs.
+func videoMimeType(ext string) string {
+	switch strings.ToLower(ext) {
+	case ".mp4":
+		return "video/mp4"
+	case ".mov":
+		return "video/quicktime"
+	case ".webm":
+		return "video/webm"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// videoPosterURL returns the URL of the poster frame stored next to a video
+func videoPosterURL(videoURL string) string {
+	return strings.TrimSuffix(videoURL, filepath.Ext(videoURL)) + ".jpg"
+}
+
+// probeDuration returns a staged video's length in whole seconds, or 0 when ffprobe is
+// unavailable
+func (cfg videoToolsConfig) probeDuration(ctx context.Context, src *stagedMedia) (int, error) {
+	if cfg.ffprobe == "" {
+		return 0, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, videoProbeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, cfg.ffprobe, "-v", "error", "-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", src.tempPath)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %w", err)
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+	if err != nil || seconds < 0 || math.IsInf(seconds, 0) || math.IsNaN(seconds) {
+		return 0, fmt.Errorf("ffprobe returned an unexpected duration %q", strings.TrimSpace(string(out)))
+	}
+	return int(math.Round(seconds)), nil
+}
+
+// generatePoster stages a JPEG of an early frame of a staged video, to be committed along
+// with it. It returns nil without error when ffmpeg is unavailable. The frame is taken a
+// second in, past the black lead-in many clips start with, unless the clip is shorter.
+func (cfg videoToolsConfig) generatePoster(ctx context.Context, src *stagedMedia, duration int) (*stagedMedia, error) {
+	if cfg.ffmpeg == "" {
+		return nil, nil
+	}
+	posterURL := videoPosterURL(src.URL)
+	key := path.Join(path.Dir(src.key), path.Base(posterURL))
+	f, err := os.CreateTemp("", "journeyapp-*.jpg")
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	dst := &stagedMedia{URL: posterURL, key: key, tempPath: f.Name(), store: src.store}
+
+	offset := "1"
+	if duration < 2 {
+		offset = "0"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, videoPosterTimeout)
+	defer cancel()
+
+	scale := fmt.Sprintf("scale='min(%d,iw)':-2", posterWidth)
+	cmd := exec.CommandContext(ctx, cfg.ffmpeg, "-v", "error", "-y", "-ss", offset, "-i", src.tempPath, "-frames:v", "1", "-vf", scale, "-q:v", "4", dst.tempPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		dst.Discard()
+		return nil, fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	if info, err := os.Stat(dst.tempPath); err != nil || info.Size() == 0 {
+		dst.Discard()
+		return nil, errors.New("ffmpeg produced no poster frame")
+	}
+	return dst, nil
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/cache"
+	webhookmodels "io.winapps.journeyapp/internal/models/webhooks"
+	"io.winapps.journeyapp/internal/webhooks"
+)
+
+// maxWebhooksPerUser bounds how many endpoints a single event can fan out to
+const maxWebhooksPerUser = 10
+
+type WebhooksHandler struct {
+	postgres *pgxpool.Pool
+	cache    cache.Store
+	logger   *zap.SugaredLogger
+}
+
+// NewWebhooksHandler creates a handler for managing a user's outbound webhooks
+func NewWebhooksHandler(postgres *pgxpool.Pool, store cache.Store, logger *zap.SugaredLogger) *WebhooksHandler {
+	return &WebhooksHandler{
+		postgres: postgres,
+		cache:    store,
+		logger:   logger,
+	}
+}
+
+// CreateWebhook registers an https endpoint for the given events. The signing secret is
+// generated here and returned only in this response.
+func (h *WebhooksHandler) CreateWebhook(c *gin.Context) {
+	var req webhookmodels.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	url := strings.TrimSpace(req.URL)
+	if err := webhooks.ValidateURL(url); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, err.Error())
+		return
+	}
+	events, ok := normalizeWebhookEvents(c, req.Events)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	var count int
+	if err := h.postgres.QueryRow(ctx, `SELECT COUNT(*) FROM webhooks WHERE user_uid = $1`, userUID).Scan(&count); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "count webhooks failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create webhook")
+		return
+	}
+	if count >= maxWebhooksPerUser {
+		respondError(c, http.StatusConflict, apierror.CodeConflict, "Webhook limit reached; delete one before adding another")
+		return
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		h.logError(c, err, "generate webhook secret failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create webhook")
+		return
+	}
+
+	hook := webhookmodels.Webhook{URL: url, Events: events, Active: true, Secret: secret}
+	err = h.postgres.QueryRow(ctx, `
+		INSERT INTO webhooks (user_uid, url, secret, events)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`, userUID, url, secret, events).Scan(&hook.ID, &hook.CreatedAt, &hook.UpdatedAt)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "insert webhook failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhookmodels.WebhookResponse{Webhook: hook, Message: "Webhook created; store the secret, it won't be shown again"})
+}
+
+// ListWebhooks returns the user's webhooks without their secrets
+func (h *WebhooksHandler) ListWebhooks(c *gin.Context) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	ctx := c.Request.Context()
+
+	rows, err := h.postgres.Query(ctx, `
+		SELECT id, url, events, active, created_at, updated_at
+		FROM webhooks WHERE user_uid = $1
+		ORDER BY created_at
+	`, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "list webhooks failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list webhooks")
+		return
+	}
+	defer rows.Close()
+
+	hooks := []webhookmodels.Webhook{}
+	for rows.Next() {
+		var hook webhookmodels.Webhook
+		if err := rows.Scan(&hook.ID, &hook.URL, &hook.Events, &hook.Active, &hook.CreatedAt, &hook.UpdatedAt); err != nil {
+			h.logError(c, err, "scan webhook failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list webhooks")
+			return
+		}
+		hooks = append(hooks, hook)
+	}
+	if err := rows.Err(); err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "list webhooks failed")
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list webhooks")
+		return
+	}
+
+	c.JSON(http.StatusOK, webhookmodels.ListWebhooksResponse{Webhooks: hooks, Events: webhooks.Events})
+}
+
+// UpdateWebhook changes a webhook's URL, events or active flag, and can rotate its secret
+func (h *WebhooksHandler) UpdateWebhook(c *gin.Context) {
+	var req webhookmodels.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	if req.WebhookID == "" {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "webhookId is required")
+		return
+	}
+
+	var url *string
+	if req.URL != nil {
+		u := strings.TrimSpace(*req.URL)
+		if err := webhooks.ValidateURL(u); err != nil {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, err.Error())
+			return
+		}
+		url = &u
+	}
+	var events []string
+	if req.Events != nil {
+		var ok bool
+		if events, ok = normalizeWebhookEvents(c, *req.Events); !ok {
+			return
+		}
+	}
+	var secret *string
+	if req.RotateSecret {
+		s, err := newWebhookSecret()
+		if err != nil {
+			h.logError(c, err, "generate webhook secret failed")
+			respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update webhook")
+			return
+		}
+		secret = &s
+	}
+
+	ctx := c.Request.Context()
+
+	var hook webhookmodels.Webhook
+	err := h.postgres.QueryRow(ctx, `
+		UPDATE webhooks SET
+			url = COALESCE($3, url),
+			events = COALESCE($4, events),
+			active = COALESCE($5, active),
+			secret = COALESCE($6, secret),
+			updated_at = NOW()
+		WHERE id::text = $1 AND user_uid = $2
+		RETURNING id, url, events, active, created_at, updated_at
+	`, req.WebhookID, userUID, url, events, req.Active, secret).Scan(&hook.ID, &hook.URL, &hook.Events, &hook.Active, &hook.CreatedAt, &hook.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Webhook not found")
+		return
+	}
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "update webhook failed", "webhookId", req.WebhookID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to update webhook")
+		return
+	}
+	if secret != nil {
+		hook.Secret = *secret
+	}
+
+	c.JSON(http.StatusOK, webhookmodels.WebhookResponse{Webhook: hook, Message: "Webhook updated"})
+}
+
+// DeleteWebhook removes one of the user's webhooks
+func (h *WebhooksHandler) DeleteWebhook(c *gin.Context) {
+	var req webhookmodels.DeleteWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Invalid request format")
+		return
+	}
+
+	uid, exists := c.Get("uid")
+	if !exists {
+		respondError(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+		return
+	}
+	userUID := uid.(string)
+
+	tag, err := h.postgres.Exec(c.Request.Context(), `DELETE FROM webhooks WHERE id::text = $1 AND user_uid = $2`, req.WebhookID, userUID)
+	if err != nil {
+		if abortOnContextError(c, err) {
+			return
+		}
+		h.logError(c, err, "delete webhook failed", "webhookId", req.WebhookID)
+		respondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete webhook")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		respondError(c, http.StatusNotFound, apierror.CodeNotFound, "Webhook not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Webhook deleted"})
+}
+
+// normalizeWebhookEvents dedupes events and rejects unknown or empty lists. It writes the
+// error response and returns false when the list is invalid.
+func normalizeWebhookEvents(c *gin.Context, requested []string) ([]string, bool) {
+	seen := make(map[string]bool)
+	events := []string{}
+	for _, e := range requested {
+		e = strings.TrimSpace(e)
+		if !webhooks.IsValidEvent(e) {
+			respondError(c, http.StatusBadRequest, apierror.CodeValidation, "Unknown event "+e+"; supported events are "+strings.Join(webhooks.Events, ", "))
+			return nil, false
+		}
+		if !seen[e] {
+			seen[e] = true
+			events = append(events, e)
+		}
+	}
+	if len(events) == 0 {
+		respondError(c, http.StatusBadRequest, apierror.CodeValidation, "At least one event is required")
+		return nil, false
+	}
+	return events, true
+}
+
+// newWebhookSecret returns 32 random bytes, hex encoded
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,131 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// Sender delivers a plain-text email
+type Sender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// NewFromEnv builds a Sender from EMAIL_PROVIDER ("smtp" or "sendgrid") and the
+// provider-specific settings. EMAIL_FROM is required for both.
+func NewFromEnv() (Sender, error) {
+	from := os.Getenv("EMAIL_FROM")
+	if from == "" {
+		return nil, fmt.Errorf("EMAIL_FROM is not configured")
+	}
+
+	switch strings.ToLower(os.Getenv("EMAIL_PROVIDER")) {
+	case "sendgrid":
+		apiKey := os.Getenv("SENDGRID_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("SENDGRID_API_KEY is not configured")
+		}
+		return &sendGridSender{apiKey: apiKey, from: from, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	case "smtp", "":
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("SMTP_HOST is not configured")
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return &smtpSender{
+			addr:     net.JoinHostPort(host, port),
+			host:     host,
+			username: os.Getenv("SMTP_USERNAME"),
+			password: os.Getenv("SMTP_PASSWORD"),
+			from:     from,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported EMAIL_PROVIDER %q", os.Getenv("EMAIL_PROVIDER"))
+	}
+}
+
+type smtpSender struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func (s *smtpSender) Send(ctx context.Context, to, subject, body string) error {
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + s.from,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	// net/smtp has no context support, so run the send and give up when ctx is done
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- smtp.SendMail(s.addr, auth, s.from, []string{to}, []byte(msg))
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type sendGridSender struct {
+	apiKey string
+	from   string
+	client *http.Client
+}
+
+func (s *sendGridSender) Send(ctx context.Context, to, subject, body string) error {
+	payload := map[string]interface{}{
+		"personalizations": []map[string]interface{}{
+			{"to": []map[string]string{{"email": to}}},
+		},
+		"from":    map[string]string{"email": s.from},
+		"subject": subject,
+		"content": []map[string]string{{"type": "text/plain", "value": body}},
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.sendgrid.com/v3/mail/send", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sendgrid returned status %d", resp.StatusCode)
+	}
+	return nil
+}
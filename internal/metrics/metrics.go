@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"io.winapps.journeyapp/internal/cache"
+)
+
+// Metrics are registered with the default Prometheus registry, which also carries the Go
+// runtime and process collectors
+
+var (
+	// HTTPRequestsTotal counts completed HTTP requests by route template, method and status
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "journeyapp_http_requests_total",
+		Help: "Total number of HTTP requests processed.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPRequestDuration observes request latency by route template and method
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "journeyapp_http_request_duration_seconds",
+		Help:    "HTTP request latency in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	// NotificationsSentTotal counts push notifications by channel and result (success, failure)
+	NotificationsSentTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "journeyapp_notifications_sent_total",
+		Help: "Total number of push notifications attempted.",
+	}, []string{"channel", "result"})
+
+	// NotificationReceiptsTotal counts Expo push receipts by result (delivered, failed)
+	NotificationReceiptsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "journeyapp_notification_receipts_total",
+		Help: "Total number of Expo push receipts checked.",
+	}, []string{"result"})
+
+	// ExportJobsTotal counts export jobs by lifecycle event (started, completed, failed, cancelled, streamed)
+	ExportJobsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "journeyapp_export_jobs_total",
+		Help: "Total number of data export jobs by status.",
+	}, []string{"status"})
+
+	// ImportJobsTotal counts import jobs by lifecycle event (started, completed, failed, cancelled)
+	ImportJobsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "journeyapp_import_jobs_total",
+		Help: "Total number of data import jobs by status.",
+	}, []string{"status"})
+
+	// DBPoolSlowAcquiresTotal counts connection acquires that waited longer than DB_SLOW_ACQUIRE_THRESHOLD
+	DBPoolSlowAcquiresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "journeyapp_db_pool_slow_acquires_total",
+		Help: "Total number of database connection acquires slower than the warning threshold.",
+	})
+)
+
+// Handler serves all registered metrics
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterDBPoolCollector exposes pgxpool.Pool.Stat() values
+func RegisterDBPoolCollector(pool *pgxpool.Pool) {
+	gauge := func(name, help string, value func(*pgxpool.Stat) float64) {
+		promauto.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, func() float64 { return value(pool.Stat()) })
+	}
+	counter := func(name, help string, value func(*pgxpool.Stat) float64) {
+		promauto.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help}, func() float64 { return value(pool.Stat()) })
+	}
+	gauge("journeyapp_db_pool_acquired_conns", "Number of currently acquired connections in the pool.", func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) })
+	gauge("journeyapp_db_pool_idle_conns", "Number of currently idle connections in the pool.", func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) })
+	gauge("journeyapp_db_pool_total_conns", "Total number of connections currently in the pool.", func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) })
+	gauge("journeyapp_db_pool_max_conns", "Maximum size of the pool.", func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) })
+	counter("journeyapp_db_pool_acquire_count_total", "Cumulative count of successful acquires from the pool.", func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) })
+	counter("journeyapp_db_pool_acquire_wait_seconds_total", "Total time spent waiting for a connection to be acquired.", func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() })
+	counter("journeyapp_db_pool_empty_acquire_count_total", "Cumulative count of acquires that waited because the pool was empty.", func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) })
+}
+
+// cachePingTimeout bounds the Redis ping made on each scrape
+const cachePingTimeout = time.Second
+
+// RegisterCacheCollector exposes whether the cache backend is reachable. It reports 1/0
+// for Redis, and 0 for the Redis gauge plus 1 for the in-memory fallback gauge otherwise,
+// so alerts can fire when an instance is running without Redis.
+func RegisterCacheCollector(store cache.Store) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "journeyapp_redis_up",
+		Help: "Whether Redis answered a ping (1) or not (0).",
+	}, func() float64 {
+		if store.Backend() != "redis" {
+			return 0
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), cachePingTimeout)
+		defer cancel()
+		if err := store.Ping(ctx); err != nil {
+			return 0
+		}
+		return 1
+	})
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "journeyapp_cache_memory_fallback",
+		Help: "Whether the in-memory cache fallback is in use instead of Redis (1) or not (0).",
+	}, func() float64 {
+		if store.Backend() == "memory" {
+			return 1
+		}
+		return 0
+	})
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"io.winapps.journeyapp/internal/cache"
+)
+
+// Label values are escaped by client_golang; quotes and backslashes must come out intact
+func TestHandlerExposition(t *testing.T) {
+	HTTPRequestsTotal.WithLabelValues(`/a"b\c`, "GET", "200").Inc()
+	RegisterCacheCollector(cache.NewMemory(10))
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`journeyapp_http_requests_total{method="GET",route="/a\"b\\c",status="200"} 1`,
+		"journeyapp_cache_memory_fallback 1",
+		"journeyapp_redis_up 0",
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+}
+
+func TestCounters(t *testing.T) {
+	ExportJobsTotal.WithLabelValues("started").Inc()
+	ExportJobsTotal.WithLabelValues("started").Add(2)
+	if got := testutil.ToFloat64(ExportJobsTotal.WithLabelValues("started")); got != 3 {
+		t.Errorf("export jobs started = %v, want 3", got)
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"io.winapps.journeyapp/internal/apierror"
+)
+
+// AdminToken guards maintenance routes with the shared ADMIN_API_TOKEN, sent as a bearer
+// token. The routes answer 503 while no token is configured, so they are off by default.
+func AdminToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := os.Getenv("ADMIN_API_TOKEN")
+		if token == "" {
+			apierror.Abort(c, http.StatusServiceUnavailable, apierror.CodeUnavailable, "Admin API is not configured")
+			return
+		}
+		auth := strings.TrimSpace(c.GetHeader("Authorization"))
+		auth = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+		if auth == "" || subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Invalid admin token")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin blocks the request with 403 unless the authenticated user has users.is_admin
+// set. It must run after AuthMiddleware.
+func RequireAdmin(postgres *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := c.GetString("uid")
+		if uid == "" {
+			apierror.Abort(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+			return
+		}
+
+		var isAdmin bool
+		err := postgres.QueryRow(c.Request.Context(), `SELECT COALESCE(is_admin, FALSE) FROM users WHERE uid = $1`, uid).Scan(&isAdmin)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			apierror.Abort(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check permissions")
+			return
+		}
+		if !isAdmin {
+			apierror.Abort(c, http.StatusForbidden, apierror.CodeForbidden, "Moderator access required")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,26 +1,28 @@
 package middleware
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
 	"strings"
+	"time"
 
 	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/auth"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/cache"
 	firebaseutil "io.winapps.journeyapp/internal/firebase"
 	usermodels "io.winapps.journeyapp/internal/models/account"
 )
 
 // AuthMiddleware checks ID token and sets user context
-func AuthMiddleware(firebaseApp *firebase.App, postgres *pgxpool.Pool, redisClient *redis.Client) gin.HandlerFunc {
+func AuthMiddleware(firebaseApp *firebase.App, postgres *pgxpool.Pool, store cache.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Authorization header is required")
 			c.Abort()
 			return
 		}
@@ -28,7 +30,7 @@ func AuthMiddleware(firebaseApp *firebase.App, postgres *pgxpool.Pool, redisClie
 		// Check if header starts with "Bearer "
 		const bearerPrefix = "Bearer "
 		if !strings.HasPrefix(authHeader, bearerPrefix) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header must start with 'Bearer '"})
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Authorization header must start with 'Bearer '")
 			c.Abort()
 			return
 		}
@@ -36,28 +38,44 @@ func AuthMiddleware(firebaseApp *firebase.App, postgres *pgxpool.Pool, redisClie
 		// Extract token
 		token := strings.TrimPrefix(authHeader, bearerPrefix)
 		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token is required"})
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "Token is required")
 			c.Abort()
 			return
 		}
 
-		ctx := context.Background()
+		ctx := c.Request.Context()
 		var userUID string
+		tokenExpired := false
+
+		// Reject tokens that were explicitly logged out
+		if revoked, err := store.Exists(ctx, RevokedTokenKey(token)); err == nil && revoked {
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeTokenRevoked, "Token has been revoked")
+			c.Abort()
+			return
+		}
 
 		// Step 1: Try to verify as Firebase ID token (primary method)
 		authClient, err := firebaseutil.GetAuthClient(firebaseApp)
 		if err == nil {
-			if idToken, err := authClient.VerifyIDToken(ctx, token); err == nil {
+			idToken, err := authClient.VerifyIDToken(ctx, token)
+			if err == nil {
 				userUID = idToken.UID
+				// Reject ID tokens issued before a sign-out-everywhere
+				if revokedAt, err := sessionsRevokedAt(ctx, store, idToken.UID); err == nil && idToken.IssuedAt <= revokedAt {
+					apierror.Respond(c, http.StatusUnauthorized, apierror.CodeTokenRevoked, "Token has been revoked")
+					c.Abort()
+					return
+				}
+			} else if auth.IsIDTokenExpired(err) {
+				tokenExpired = true
 			}
 		}
 
 		// Step 2: If Firebase verification failed, try Redis cache as fallback
 		if userUID == "" {
-			iter := redisClient.Scan(ctx, 0, "user:*", 0).Iterator()
-			for iter.Next(ctx) {
-				key := iter.Val()
-				userJSON, err := redisClient.Get(ctx, key).Result()
+			keys, _ := store.Keys(ctx, "user:*")
+			for _, key := range keys {
+				userJSON, err := store.Get(ctx, key)
 				if err != nil {
 					continue
 				}
@@ -67,28 +85,52 @@ func AuthMiddleware(firebaseApp *firebase.App, postgres *pgxpool.Pool, redisClie
 					continue
 				}
 
-				// Check if this user has the provided token
+				// Check if this user has the provided token and it has not expired
 				if user.Token == token {
-					userUID = user.UID
+					if sessionTokenExpired(user.TokenExpiresAt, time.Now()) {
+						tokenExpired = true
+					} else {
+						userUID = user.UID
+					}
 					break
 				}
 			}
 		}
 
 		// Step 3: If not found in Redis, try PostgreSQL as final fallback
-		if userUID == "" {
-			query := `SELECT uid FROM users WHERE token = $1`
-			postgres.QueryRow(ctx, query, token).Scan(&userUID)
+		if userUID == "" && !tokenExpired {
+			var uid string
+			var expiresAt *time.Time
+			query := `SELECT uid, token_expires_at FROM users WHERE token = $1`
+			if err := postgres.QueryRow(ctx, query, token).Scan(&uid, &expiresAt); err == nil {
+				if sessionTokenExpired(expiresAt, time.Now()) {
+					tokenExpired = true
+				} else {
+					userUID = uid
+				}
+			}
 		}
 
 		if userUID == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			if tokenExpired {
+				apierror.Respond(c, http.StatusUnauthorized, apierror.CodeTokenExpired, "Token has expired")
+			} else {
+				apierror.Respond(c, http.StatusUnauthorized, apierror.CodeTokenInvalid, "Invalid token")
+			}
 			c.Abort()
 			return
 		}
 
 		// Set user UID in context for use in handlers
 		c.Set("uid", userUID)
+		c.Set("token", token)
 		c.Next()
 	}
 }
+
+// sessionTokenExpired reports whether a session token with the given expiry is no longer
+// valid at now. Tokens without a recorded expiry predate expiry enforcement and must be
+// refreshed, whether the session came from Redis or Postgres.
+func sessionTokenExpired(expiresAt *time.Time, now time.Time) bool {
+	return expiresAt == nil || now.After(*expiresAt)
+}
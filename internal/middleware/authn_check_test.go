@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionTokenExpired(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Second)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		want      bool
+	}{
+		{"no recorded expiry", nil, true},
+		{"expired", &past, true},
+		{"expires exactly now", &now, false},
+		{"valid", &future, false},
+	}
+	for _, tt := range tests {
+		if got := sessionTokenExpired(tt.expiresAt, now); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
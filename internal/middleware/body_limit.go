@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMediaBodyLimit fits the largest premium audio upload once base64 encoded
+const DefaultMediaBodyLimit int64 = 150 << 20
+
+// MediaBodyLimitFromEnv reads MEDIA_MAX_BODY_BYTES, falling back to DefaultMediaBodyLimit
+func MediaBodyLimitFromEnv() int64 {
+	if v, err := strconv.ParseInt(os.Getenv("MEDIA_MAX_BODY_BYTES"), 10, 64); err == nil && v > 0 {
+		return v
+	}
+	return DefaultMediaBodyLimit
+}
+
+// MaxBodySize caps the request body at limit bytes. Reading past the cap fails with
+// *http.MaxBytesError, which handlers report as 413.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
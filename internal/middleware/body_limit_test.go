@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(MaxBodySize(16))
+	router.POST("/", func(c *gin.Context) {
+		_, err := io.ReadAll(c.Request.Body)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for body, want := range map[string]int{
+		strings.Repeat("a", 16): http.StatusOK,
+		strings.Repeat("a", 17): http.StatusRequestEntityTooLarge,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		if w.Code != want {
+			t.Errorf("%d-byte body: status = %d, want %d", len(body), w.Code, want)
+		}
+	}
+}
+
+func TestMediaBodyLimitFromEnv(t *testing.T) {
+	for value, want := range map[string]int64{
+		"":        DefaultMediaBodyLimit,
+		"1048576": 1 << 20,
+		"0":       DefaultMediaBodyLimit,
+		"-5":      DefaultMediaBodyLimit,
+		"lots":    DefaultMediaBodyLimit,
+	} {
+		t.Setenv("MEDIA_MAX_BODY_BYTES", value)
+		if got := MediaBodyLimitFromEnv(); got != want {
+			t.Errorf("MEDIA_MAX_BODY_BYTES=%q: got %d, want %d", value, got, want)
+		}
+	}
+}
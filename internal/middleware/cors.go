@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultDevOrigins are allowed when ALLOWED_ORIGINS is unset so local web and Expo
+// clients work out of the box
+var defaultDevOrigins = []string{
+	"http://localhost:3000",
+	"http://localhost:8081",
+	"http://127.0.0.1:3000",
+	"http://127.0.0.1:8081",
+}
+
+// CORSConfig controls which browser origins may call the API
+type CORSConfig struct {
+	// AllowedOrigins lists exact origins (scheme://host[:port]). "*" allows any origin,
+	// but is never combined with credentials.
+	AllowedOrigins   []string
+	AllowCredentials bool
+}
+
+// CORSConfigFromEnv reads ALLOWED_ORIGINS (comma-separated) and CORS_ALLOW_CREDENTIALS,
+// falling back to localhost origins without credentials
+func CORSConfigFromEnv() CORSConfig {
+	var origins []string
+	for _, o := range strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",") {
+		if o = strings.TrimRight(strings.TrimSpace(o), "/"); o != "" {
+			origins = append(origins, o)
+		}
+	}
+	if len(origins) == 0 {
+		origins = defaultDevOrigins
+	}
+	credentials, _ := strconv.ParseBool(os.Getenv("CORS_ALLOW_CREDENTIALS"))
+	return CORSConfig{AllowedOrigins: origins, AllowCredentials: credentials}
+}
+
+// CORSMiddleware echoes the request Origin back only when it is in the allowlist.
+// Requests without an Origin (the mobile app, server-to-server) get no CORS headers.
+// Preflight OPTIONS requests are answered with 204 either way; browsers block the
+// actual request when the allow header is missing.
+func CORSMiddleware(cfg CORSConfig) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(cfg.AllowedOrigins))
+	allowAny := false
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			allowAny = true
+			continue
+		}
+		allowed[strings.ToLower(o)] = true
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		listed := allowed[strings.ToLower(origin)]
+		c.Writer.Header().Add("Vary", "Origin")
+
+		if origin != "" && (allowAny || listed) {
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Idempotency-Key")
+			c.Header("Access-Control-Expose-Headers", "Idempotent-Replayed")
+			// Credentials are only sent to explicitly listed origins, never via "*"
+			if cfg.AllowCredentials && listed {
+				c.Header("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	}
+}
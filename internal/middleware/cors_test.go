@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSConfigFromEnv(t *testing.T) {
+	t.Setenv("ALLOWED_ORIGINS", " https://app.example.com/ ,,https://admin.example.com")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	cfg := CORSConfigFromEnv()
+	if len(cfg.AllowedOrigins) != 2 || cfg.AllowedOrigins[0] != "https://app.example.com" || cfg.AllowedOrigins[1] != "https://admin.example.com" {
+		t.Errorf("AllowedOrigins = %q", cfg.AllowedOrigins)
+	}
+	if !cfg.AllowCredentials {
+		t.Error("AllowCredentials = false, want true")
+	}
+
+	t.Setenv("ALLOWED_ORIGINS", "")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "")
+	cfg = CORSConfigFromEnv()
+	if len(cfg.AllowedOrigins) != len(defaultDevOrigins) || cfg.AllowCredentials {
+		t.Errorf("unset env = %+v, want the localhost defaults without credentials", cfg)
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(cfg CORSConfig) *gin.Engine {
+		router := gin.New()
+		router.Use(CORSMiddleware(cfg))
+		router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return router
+	}
+	serve := func(router *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	listed := newRouter(CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true})
+	tests := []struct {
+		name       string
+		router     *gin.Engine
+		method     string
+		origin     string
+		wantCode   int
+		wantOrigin string
+		wantCreds  string
+	}{
+		{"listed origin", listed, http.MethodGet, "https://app.example.com", http.StatusOK, "https://app.example.com", "true"},
+		{"origin case-insensitive", listed, http.MethodGet, "https://APP.example.com", http.StatusOK, "https://APP.example.com", "true"},
+		{"unlisted origin", listed, http.MethodGet, "https://evil.example.com", http.StatusOK, "", ""},
+		{"no origin", listed, http.MethodGet, "", http.StatusOK, "", ""},
+		{"preflight", listed, http.MethodOptions, "https://app.example.com", http.StatusNoContent, "https://app.example.com", "true"},
+		{"unlisted preflight", listed, http.MethodOptions, "https://evil.example.com", http.StatusNoContent, "", ""},
+		{"wildcard never sends credentials", newRouter(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}), http.MethodGet, "https://any.example.com", http.StatusOK, "https://any.example.com", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.router, tt.method, tt.origin)
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCreds {
+				t.Errorf("Allow-Credentials = %q, want %q", got, tt.wantCreds)
+			}
+			if w.Header().Get("Vary") != "Origin" {
+				t.Errorf("Vary = %q, want Origin", w.Header().Get("Vary"))
+			}
+		})
+	}
+}
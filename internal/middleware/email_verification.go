@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"io.winapps.journeyapp/internal/apierror"
+)
+
+// RequireVerifiedEmail blocks the request with 403 until the authenticated user's email
+// is verified. It must run after AuthMiddleware.
+func RequireVerifiedEmail(postgres *pgxpool.Pool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := c.GetString("uid")
+		if uid == "" {
+			apierror.Respond(c, http.StatusUnauthorized, apierror.CodeUnauthorized, "User not authenticated")
+			c.Abort()
+			return
+		}
+
+		verified, err := IsEmailVerified(c.Request.Context(), postgres, uid)
+		if err != nil {
+			apierror.Respond(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to check email verification")
+			c.Abort()
+			return
+		}
+		if !verified {
+			AbortEmailNotVerified(c)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// IsEmailVerified reports whether uid has a verified email address
+func IsEmailVerified(ctx context.Context, postgres *pgxpool.Pool, uid string) (bool, error) {
+	var verified bool
+	err := postgres.QueryRow(ctx, `SELECT COALESCE(email_verified, FALSE) FROM users WHERE uid = $1`, uid).Scan(&verified)
+	return verified, err
+}
+
+// AbortEmailNotVerified writes the standard 403 for actions that need a verified email.
+// details.reason keeps the "email_not_verified" value clients matched on before the
+// error envelope existed.
+func AbortEmailNotVerified(c *gin.Context) {
+	apierror.RespondWithDetails(c, http.StatusForbidden, apierror.CodeEmailNotVerified, "Email address must be verified", gin.H{"reason": "email_not_verified"})
+	c.Abort()
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAbortEmailNotVerified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("request_id", "req-1")
+	AbortEmailNotVerified(c)
+
+	want := `{"error":{"code":"EMAIL_NOT_VERIFIED","message":"Email address must be verified","requestId":"req-1","details":{"reason":"email_not_verified"}}}`
+	if w.Code != http.StatusForbidden || w.Body.String() != want {
+		t.Errorf("got %d %s, want 403 %s", w.Code, w.Body.String(), want)
+	}
+	if !c.IsAborted() {
+		t.Error("handler chain was not aborted")
+	}
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GzipMiddleware compresses JSON and text responses for clients that send
+// Accept-Encoding: gzip. Bodies shorter than minLength, and anything that isn't
+// JSON/text (e.g. the export zip download), are passed through unchanged.
+func GzipMiddleware(minLength int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !acceptsGzip(c.Request) {
+			c.Next()
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: c.Writer, minLength: minLength}
+		c.Writer = gw
+		defer func() {
+			gw.finish()
+			c.Writer = gw.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+func acceptsGzip(r *http.Request) bool {
+	if r.Method == http.MethodHead {
+		return false
+	}
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(enc, ";")
+		if strings.TrimSpace(name) != "gzip" {
+			continue
+		}
+		// "gzip;q=0" means the client explicitly refuses gzip
+		q := strings.ReplaceAll(params, " ", "")
+		return !(q == "q=0" || (strings.HasPrefix(q, "q=0.") && strings.Trim(q[len("q=0."):], "0") == ""))
+	}
+	return false
+}
+
+func compressibleContentType(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	return strings.HasPrefix(ct, "application/json") || strings.HasPrefix(ct, "text/")
+}
+
+// gzipResponseWriter buffers the start of the body until it knows whether the
+// response is large enough to compress, then either gzips or passes through
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	minLength int
+	status    int
+	buf       bytes.Buffer
+	gz        *gzip.Writer
+	decided   bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *gzipResponseWriter) WriteHeaderNow() {
+	// Headers are sent once the compression decision is made
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *gzipResponseWriter) Status() int {
+	if !w.decided && w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *gzipResponseWriter) Written() bool {
+	return w.decided || w.status != 0 || w.buf.Len() > 0
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || !compressibleContentType(header.Get("Content-Type")) {
+		if err := w.passThrough(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf.Write(b)
+	if w.buf.Len() >= w.minLength {
+		if err := w.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		_ = w.passThrough()
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *gzipResponseWriter) writeStatus() {
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *gzipResponseWriter) passThrough() error {
+	w.decided = true
+	w.writeStatus()
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *gzipResponseWriter) startGzip() error {
+	w.decided = true
+	header := w.Header()
+	header.Set("Content-Encoding", "gzip")
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+	w.writeStatus()
+
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+	_, err := w.gz.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// finish sends whatever is still buffered once the handler chain returns
+func (w *gzipResponseWriter) finish() {
+	if !w.decided {
+		if w.status != 0 || w.buf.Len() > 0 {
+			_ = w.passThrough()
+		}
+		return
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		method string
+		header string
+		want   bool
+	}{
+		{http.MethodGet, "gzip", true},
+		{http.MethodGet, "deflate, gzip;q=0.8", true},
+		{http.MethodGet, "br, gzip ; q=1", true},
+		{http.MethodGet, "", false},
+		{http.MethodGet, "deflate, br", false},
+		{http.MethodGet, "gzip;q=0", false},
+		{http.MethodGet, "gzip; q=0.000", false},
+		{http.MethodGet, "gzip;q=0.001", true},
+		{http.MethodHead, "gzip", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/", nil)
+		r.Header.Set("Accept-Encoding", tt.header)
+		if got := acceptsGzip(r); got != tt.want {
+			t.Errorf("acceptsGzip(%s %q) = %v, want %v", tt.method, tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestGzipMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	large := `{"data":"` + strings.Repeat("a", 2048) + `"}`
+	router := gin.New()
+	router.Use(GzipMiddleware(1024))
+	router.GET("/large", func(c *gin.Context) { c.Data(http.StatusOK, "application/json", []byte(large)) })
+	router.GET("/small", func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"ok": true}) })
+	router.GET("/zip", func(c *gin.Context) { c.Data(http.StatusOK, "application/zip", []byte(large)) })
+
+	serve := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("large JSON is compressed", func(t *testing.T) {
+		w := serve("/large", "gzip")
+		if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Fatalf("headers = %v, want gzip encoding with Vary", w.Header())
+		}
+		zr, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("gzip.NewReader: %v", err)
+		}
+		body, err := io.ReadAll(zr)
+		if err != nil || string(body) != large {
+			t.Fatalf("decompressed body mismatch (err %v)", err)
+		}
+	})
+
+	t.Run("identity without Accept-Encoding", func(t *testing.T) {
+		w := serve("/large", "")
+		if w.Header().Get("Content-Encoding") != "" || w.Body.String() != large {
+			t.Fatalf("response was altered: encoding %q", w.Header().Get("Content-Encoding"))
+		}
+	})
+
+	t.Run("small responses keep their status uncompressed", func(t *testing.T) {
+		w := serve("/small", "gzip")
+		if w.Code != http.StatusCreated || w.Header().Get("Content-Encoding") != "" || w.Body.String() != `{"ok":true}` {
+			t.Fatalf("got %d %q %q", w.Code, w.Header().Get("Content-Encoding"), w.Body.String())
+		}
+	})
+
+	t.Run("non-JSON passes through", func(t *testing.T) {
+		w := serve("/zip", "gzip")
+		if w.Header().Get("Content-Encoding") != "" || w.Body.String() != large {
+			t.Fatalf("zip download was compressed")
+		}
+	})
+}
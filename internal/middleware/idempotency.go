@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/cache"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	idempotencyKeyPrefix = "idempotency:"
+	maxIdempotencyKeyLen = 255
+	// idempotencyTTL is how long a completed request can be replayed
+	idempotencyTTL = 24 * time.Hour
+	// idempotencyPendingTTL bounds how long a request that died mid-flight blocks its key.
+	// Upload routes are exempt from the request timeout and can run longer than this, so
+	// the reservation is renewed while the request runs
+	idempotencyPendingTTL = 2 * time.Minute
+	idempotencyPending    = "pending"
+	// Responses larger than this aren't stored, so their keys give no retry protection
+	maxIdempotentResponseBytes = 1 << 20
+)
+
+// idempotentResponse is what a replay writes back: the original status, content type and
+// body. BodyHash is the SHA-256 of the request that produced it
+type idempotentResponse struct {
+	BodyHash    string `json:"bodyHash"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+// Idempotency makes a mutating route safe to retry. A request carrying an
+// Idempotency-Key header reserves idempotency:<uid>:<route>:<key> in the cache; a 2xx
+// response is stored for 24 hours and replayed with its original status for any retry
+// with the same key and body; reusing the key with a different body gets 422. A retry
+// that arrives while the first request is still running gets 409, and a request that
+// fails frees its key. Requests without the header, and all requests while the cache is
+// unavailable, run normally. It must run after AuthMiddleware.
+func Idempotency(store cache.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := strings.TrimSpace(c.GetHeader(idempotencyKeyHeader))
+		uid := c.GetString("uid")
+		if header == "" || uid == "" {
+			c.Next()
+			return
+		}
+		if len(header) > maxIdempotencyKeyLen {
+			apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidation, fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLen))
+			return
+		}
+
+		ctx := c.Request.Context()
+		key := fmt.Sprintf("%s%s:%s:%s", idempotencyKeyPrefix, uid, c.FullPath(), header)
+
+		claimed, err := store.SetNX(ctx, key, idempotencyPending, idempotencyPendingTTL)
+		if err != nil {
+			c.Next()
+			return
+		}
+		if !claimed {
+			replayIdempotent(c, store, key)
+			return
+		}
+
+		// The body is hashed as the handler reads it, so uploads aren't buffered
+		body := &hashingReader{ReadCloser: c.Request.Body, hash: sha256.New()}
+		c.Request.Body = body
+		rec := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		stopRenewing := renewPending(store, key, idempotencyPendingTTL)
+		stored := false
+		defer func() {
+			stopRenewing()
+			c.Writer = rec.ResponseWriter
+			if !stored {
+				// Also reached on panic, so the client can retry
+				_ = store.Del(context.Background(), key)
+			}
+		}()
+
+		c.Next()
+		stopRenewing()
+
+		status := rec.Status()
+		if status < 200 || status >= 300 || rec.overflow {
+			return
+		}
+		bodyHash, err := body.sum()
+		if err != nil {
+			return
+		}
+		data, err := json.Marshal(idempotentResponse{
+			BodyHash:    bodyHash,
+			Status:      status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		})
+		if err != nil {
+			return
+		}
+		stored = store.Set(context.Background(), key, data, idempotencyTTL) == nil
+	}
+}
+
+// replayIdempotent answers a request whose key is already taken
+func replayIdempotent(c *gin.Context, store cache.Store, key string) {
+	value, err := store.Get(c.Request.Context(), key)
+	if err != nil || value == "" {
+		// The reservation expired between SetNX and Get; let this request proceed unprotected
+		c.Next()
+		return
+	}
+	if value == idempotencyPending {
+		apierror.Abort(c, http.StatusConflict, apierror.CodeConflict, "A request with this Idempotency-Key is still being processed")
+		return
+	}
+
+	var resp idempotentResponse
+	if err := json.Unmarshal([]byte(value), &resp); err != nil {
+		c.Next()
+		return
+	}
+	bodyHash, err := (&hashingReader{ReadCloser: c.Request.Body, hash: sha256.New()}).sum()
+	if err != nil {
+		apierror.Abort(c, http.StatusBadRequest, apierror.CodeValidation, "Could not read request body")
+		return
+	}
+	// Responses stored before bodies were hashed have no hash and are replayed as before
+	if resp.BodyHash != "" && bodyHash != resp.BodyHash {
+		apierror.Abort(c, http.StatusUnprocessableEntity, apierror.CodeIdempotencyMismatch, "This Idempotency-Key was already used with a different request body")
+		return
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(resp.Status, resp.ContentType, resp.Body)
+	c.Abort()
+}
+
+// renewPending extends a pending reservation by ttl every ttl/3 until the returned stop
+// function is called, so a slow upload doesn't lose its key and let a retry run the
+// request twice. stop waits for the renewer to exit, so it can't shorten the TTL of a
+// response stored afterwards
+func renewPending(store cache.Store, key string, ttl time.Duration) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = store.Expire(context.Background(), key, ttl)
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-exited
+		})
+	}
+}
+
+// hashingReader hashes a request body as it is read
+type hashingReader struct {
+	io.ReadCloser
+	hash hash.Hash
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	return n, err
+}
+
+// sum reads whatever the handler left unread and returns the hex digest of the whole body
+func (r *hashingReader) sum() (string, error) {
+	if r.ReadCloser != nil {
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(r.hash.Sum(nil)), nil
+}
+
+// responseRecorder copies the body written through it so it can be stored for replays
+type responseRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.record(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.record([]byte(s))
+	return r.ResponseWriter.WriteString(s)
+}
+
+func (r *responseRecorder) record(b []byte) {
+	if r.overflow {
+		return
+	}
+	if r.body.Len()+len(b) > maxIdempotentResponseBytes {
+		r.overflow = true
+		r.body.Reset()
+		return
+	}
+	r.body.Write(b)
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/cache"
+)
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := cache.NewMemory(100)
+	created := 0
+	fail := false
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("uid", c.GetHeader("X-Test-User"))
+	})
+	router.POST("/create", Idempotency(store), func(c *gin.Context) {
+		if fail {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+			return
+		}
+		created++
+		c.JSON(http.StatusCreated, gin.H{"id": created})
+	})
+
+	postBody := func(user, key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/create", strings.NewReader(body))
+		r.Header.Set("X-Test-User", user)
+		if key != "" {
+			r.Header.Set(idempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+	post := func(user, key string) *httptest.ResponseRecorder {
+		return postBody(user, key, `{"title":"Lisbon"}`)
+	}
+
+	first := post("alice", "k1")
+	if first.Code != http.StatusCreated || first.Body.String() != `{"id":1}` {
+		t.Fatalf("first post: %d %s", first.Code, first.Body.String())
+	}
+
+	t.Run("double post replays the first response", func(t *testing.T) {
+		w := post("alice", "k1")
+		if w.Code != http.StatusCreated || w.Body.String() != `{"id":1}` || w.Header().Get("Idempotent-Replayed") != "true" {
+			t.Fatalf("retry: %d %s %v", w.Code, w.Body.String(), w.Header())
+		}
+		if created != 1 {
+			t.Fatalf("handler ran %d times, want 1", created)
+		}
+	})
+
+	t.Run("reusing a key with a different body gets 422", func(t *testing.T) {
+		w := postBody("alice", "k1", `{"title":"Porto"}`)
+		if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), `"IDEMPOTENCY_KEY_REUSED"`) {
+			t.Fatalf("different body: %d %s", w.Code, w.Body.String())
+		}
+		if created != 1 {
+			t.Fatalf("handler ran %d times, want 1", created)
+		}
+	})
+
+	t.Run("keys are scoped to the user", func(t *testing.T) {
+		if w := post("bob", "k1"); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+			t.Fatalf("other user: %d %v", w.Code, w.Header())
+		}
+	})
+
+	t.Run("requests without a key always run", func(t *testing.T) {
+		before := created
+		post("alice", "")
+		post("alice", "")
+		if created != before+2 {
+			t.Fatalf("handler ran %d times, want 2", created-before)
+		}
+	})
+
+	t.Run("a failed request frees its key", func(t *testing.T) {
+		fail = true
+		if w := post("alice", "k2"); w.Code != http.StatusInternalServerError {
+			t.Fatalf("failing post: %d", w.Code)
+		}
+		fail = false
+		if w := post("alice", "k2"); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+			t.Fatalf("retry after failure: %d %v", w.Code, w.Header())
+		}
+	})
+
+	t.Run("a request still in flight gets 409", func(t *testing.T) {
+		if err := store.Set(context.Background(), "idempotency:alice:/create:k3", idempotencyPending, idempotencyPendingTTL); err != nil {
+			t.Fatal(err)
+		}
+		if w := post("alice", "k3"); w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"CONFLICT"`) {
+			t.Fatalf("in-flight retry: %d %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("overlong keys are rejected", func(t *testing.T) {
+		if w := post("alice", strings.Repeat("k", maxIdempotencyKeyLen+1)); w.Code != http.StatusBadRequest {
+			t.Fatalf("overlong key: %d", w.Code)
+		}
+	})
+}
+
+// A reservation outlives its TTL while the request is still running, and stops being
+// renewed once it finishes
+func TestRenewPending(t *testing.T) {
+	ctx := context.Background()
+	store := cache.NewMemory(10)
+	ttl := 60 * time.Millisecond
+	if _, err := store.SetNX(ctx, "k", idempotencyPending, ttl); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := renewPending(store, "k", ttl)
+	time.Sleep(3 * ttl)
+	if ok, _ := store.Exists(ctx, "k"); !ok {
+		t.Fatal("reservation expired while the request was running")
+	}
+	stop()
+	stop()
+
+	time.Sleep(2 * ttl)
+	if ok, _ := store.Exists(ctx, "k"); ok {
+		t.Fatal("reservation still renewed after stop")
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+
+	"io.winapps.journeyapp/internal/apierror"
 )
 
 // RequestIDMiddleware ensures every request has a request_id available in headers and context
@@ -99,7 +101,7 @@ func RecoveryMiddleware(logger *zap.SugaredLogger) gin.HandlerFunc {
 					"query", c.Request.URL.RawQuery,
 					"client_ip", c.ClientIP(),
 				)
-				c.AbortWithStatusJSON(500, gin.H{"error": "Internal server error", "request_id": c.GetString("request_id")})
+				apierror.Abort(c, 500, apierror.CodeInternal, "Internal server error")
 			}
 		}()
 		c.Next()
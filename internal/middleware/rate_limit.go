@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"io.winapps.journeyapp/internal/apierror"
+	"io.winapps.journeyapp/internal/cache"
+)
+
+// RateLimit allows each client IP at most limit requests per fixed window for the named
+// route. Counters live in the cache so instances sharing Redis share the budget. If the
+// cache is unavailable the request is let through rather than failing the endpoint.
+func RateLimit(store cache.Store, name string, limit int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		windowStart := now.Truncate(window)
+		key := fmt.Sprintf("rate_limit:%s:%s:%d", name, c.ClientIP(), windowStart.Unix())
+
+		count, err := store.Incr(c.Request.Context(), key, window)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		remaining := int64(limit) - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+
+		if count > int64(limit) {
+			retryAfter := int(windowStart.Add(window).Sub(now).Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			apierror.Abort(c, http.StatusTooManyRequests, apierror.CodeRateLimited, "Too many requests; try again later")
+			return
+		}
+
+		c.Next()
+	}
+}
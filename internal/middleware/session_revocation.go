@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"io.winapps.journeyapp/internal/cache"
+)
+
+const (
+	revokedTokenPrefix      = "revoked_token:"
+	sessionsRevokedAtPrefix = "sessions_revoked_at:"
+)
+
+// DurableKeyPrefixes lists the cache keys holding security state: revoked tokens,
+// sign-out-everywhere markers and idempotency reservations. The in-memory cache keeps
+// these until they expire instead of evicting them when it fills up, since losing one
+// would let a revoked token back in or a retried request run twice.
+var DurableKeyPrefixes = []string{revokedTokenPrefix, sessionsRevokedAtPrefix, idempotencyKeyPrefix}
+
+// RevokedTokenKey is the Redis key marking a single token as logged out. The token is
+// hashed so raw credentials never appear in Redis key names.
+func RevokedTokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return revokedTokenPrefix + hex.EncodeToString(sum[:])
+}
+
+// SessionsRevokedAtKey is the Redis key holding the unix time at which all of a user's
+// sessions were revoked; Firebase ID tokens issued at or before it are rejected.
+func SessionsRevokedAtKey(uid string) string {
+	return sessionsRevokedAtPrefix + uid
+}
+
+// sessionsRevokedAt reads the sign-out-everywhere time stored under SessionsRevokedAtKey
+func sessionsRevokedAt(ctx context.Context, store cache.Store, uid string) (int64, error) {
+	val, err := store.Get(ctx, SessionsRevokedAtKey(uid))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(val, 10, 64)
+}
+
+// IDTokenRevoked reports whether a verified Firebase ID token may no longer be used: it
+// was logged out, or it was issued at or before its user's last sign-out-everywhere
+func IDTokenRevoked(ctx context.Context, store cache.Store, rawToken, uid string, issuedAt int64) bool {
+	if revoked, err := store.Exists(ctx, RevokedTokenKey(rawToken)); err == nil && revoked {
+		return true
+	}
+	revokedAt, err := sessionsRevokedAt(ctx, store, uid)
+	return err == nil && issuedAt <= revokedAt
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeoutMiddleware attaches a deadline to the request context so handlers that pass
+// c.Request.Context() to Postgres/Redis are cancelled when the deadline passes or
+// the client disconnects. Routes listed in exempt (full route paths, e.g.
+// "/api/v1/entries/add-video") keep the plain request context: they upload,
+// transcode or stream files and can legitimately run longer than d
+func TimeoutMiddleware(d time.Duration, exempt ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(exempt))
+	for _, route := range exempt {
+		skip[route] = true
+	}
+	return func(c *gin.Context) {
+		if skip[c.FullPath()] {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
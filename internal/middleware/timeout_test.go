@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeoutMiddlewareSkipsExemptRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TimeoutMiddleware(time.Minute, "/upload"))
+
+	hasDeadline := func(c *gin.Context) {
+		_, ok := c.Request.Context().Deadline()
+		if ok {
+			c.Status(http.StatusOK)
+		} else {
+			c.Status(http.StatusNoContent)
+		}
+	}
+	router.GET("/json", hasDeadline)
+	router.GET("/upload", hasDeadline)
+
+	for path, want := range map[string]int{"/json": http.StatusOK, "/upload": http.StatusNoContent} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Errorf("%s: status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package models
+
+// Attachment is a document attached to an entry
+type Attachment struct {
+	URL      string `json:"url"`
+	Filename string `json:"filename"` // the name it was uploaded with
+	MimeType string `json:"mimeType"`
+	FileSize int64  `json:"fileSize"` // bytes
+}
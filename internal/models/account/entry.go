@@ -8,6 +8,7 @@ type Entry struct {
 	Description string    `json:"description"`
 	Images      []string  `json:"images"`
 	Audio       []string  `json:"audio"`
+	Videos      []Video   `json:"videos"`
 	Tags        []Tag     `json:"tags"`
 	Locations   []Location  `json:"locations"`
 	Visibility  string    `json:"visibility"`
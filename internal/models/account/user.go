@@ -1,10 +1,13 @@
 package models
 
+import "time"
+
 type User struct {
 	UID   string `json:"uid"`
 	DisplayName string `json:"displayName"`
 	Email string `json:"email"`
 	Token string `json:"token"`
+	TokenExpiresAt *time.Time `json:"tokenExpiresAt,omitempty"`
 	PhotoURL string `json:"photoURL"`
 	PhoneNumber string `json:"phoneNumber"`
 	ProviderID string `json:"providerId"`
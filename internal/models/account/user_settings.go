@@ -3,11 +3,13 @@ package models
 import "time"
 
 type UserSettings struct {
-	UID       string    `json:"uid" db:"uid"`
-	ThemeMode string    `json:"themeMode" db:"theme_mode"`
-	Theme     string    `json:"theme" db:"theme"`
-	AppFont   string    `json:"appFont" db:"app_font"`
-	Lang      string    `json:"lang" db:"lang"`
-	CreatedAt time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
-}
\ No newline at end of file
+	UID                 string    `json:"uid" db:"uid"`
+	ThemeMode           string    `json:"themeMode" db:"theme_mode"`
+	Theme               string    `json:"theme" db:"theme"`
+	AppFont             string    `json:"appFont" db:"app_font"`
+	Lang                string    `json:"lang" db:"lang"`
+	NotifyAnnouncements bool      `json:"notifyAnnouncements" db:"notify_announcements"`
+	NormalizeTags       bool      `json:"normalizeTags" db:"normalize_tags"`
+	CreatedAt           time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt           time.Time `json:"updatedAt" db:"updated_at"`
+}
@@ -0,0 +1,7 @@
+package models
+
+type Video struct {
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl,omitempty"`
+	Duration     int    `json:"duration,omitempty"` // seconds
+}
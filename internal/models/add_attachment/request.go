@@ -0,0 +1,7 @@
+package models
+
+type AddAttachmentRequest struct {
+	EntryID  string `json:"entryId" binding:"required"`
+	Filename string `json:"filename" binding:"required"` // its extension picks the type
+	Data     string `json:"data" binding:"required"`     // Base64 encoded file data
+}
@@ -0,0 +1,11 @@
+package models
+
+import (
+	accountmodels "io.winapps.journeyapp/internal/models/account"
+)
+
+type AddAttachmentResponse struct {
+	EntryID    string                   `json:"entryId"`
+	Attachment accountmodels.Attachment `json:"attachment"`
+	Message    string                   `json:"message"`
+}
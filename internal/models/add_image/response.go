@@ -3,5 +3,7 @@ package models
 type AddImageResponse struct {
 	EntryID  string `json:"entryId"`
 	ImageURL string `json:"imageUrl"`
+	WebPURL  string `json:"webpUrl,omitempty"` // smaller WebP copy, when transcoding is enabled
+	MimeType string `json:"mimeType"`
 	Message  string `json:"message"`
 }
\ No newline at end of file
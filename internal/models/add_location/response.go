@@ -7,5 +7,6 @@ import (
 type AddLocationResponse struct {
 	EntryID  string                    `json:"entryId"`
 	Location accountmodels.Location    `json:"location"`
+	Snapped  bool                      `json:"snapped,omitempty"` // address fields were copied from a nearby saved location
 	Message  string                    `json:"message"`
 }
\ No newline at end of file
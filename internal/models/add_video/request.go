@@ -0,0 +1,6 @@
+package models
+
+type AddVideoRequest struct {
+	EntryID string `json:"entryId" binding:"required"`
+	Video   string `json:"video" binding:"required"` // Base64 encoded video data
+}
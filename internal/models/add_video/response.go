@@ -0,0 +1,9 @@
+package models
+
+type AddVideoResponse struct {
+	EntryID      string `json:"entryId"`
+	VideoURL     string `json:"videoUrl"`
+	ThumbnailURL string `json:"thumbnailUrl,omitempty"`
+	Duration     int    `json:"duration,omitempty"`
+	Message      string `json:"message"`
+}
@@ -0,0 +1,9 @@
+package models
+
+import (
+	createmodels "io.winapps.journeyapp/internal/models/create_entry"
+)
+
+type BatchCreateEntriesRequest struct {
+	Entries []createmodels.CreateEntryRequest `json:"entries"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// BatchCreateResult reports the outcome for the entry at Index in the request
+type BatchCreateResult struct {
+	Index   int    `json:"index"`
+	Success bool   `json:"success"`
+	ID      string `json:"id,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// Field names the offending field when Code is VALIDATION because a value is too long
+	Field string `json:"field,omitempty"`
+}
+
+type BatchCreateEntriesResponse struct {
+	Results []BatchCreateResult `json:"results"`
+	Created int                 `json:"created"`
+	Failed  int                 `json:"failed"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// BillingWebhookRequest mirrors the RevenueCat webhook envelope. Stripe events
+// are expected to be normalized into the same shape by the billing proxy.
+type BillingWebhookRequest struct {
+	APIVersion string       `json:"api_version"`
+	Event      BillingEvent `json:"event" binding:"required"`
+}
+
+type BillingEvent struct {
+	ID               string `json:"id" binding:"required"`
+	Type             string `json:"type" binding:"required"` // INITIAL_PURCHASE, RENEWAL, CANCELLATION, EXPIRATION, ...
+	AppUserID        string `json:"app_user_id" binding:"required"`
+	ProductID        string `json:"product_id"`
+	Store            string `json:"store"`
+	EventTimestampMs int64  `json:"event_timestamp_ms"`
+	ExpirationAtMs   *int64 `json:"expiration_at_ms"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type BillingWebhookResponse struct {
+	Success          bool       `json:"success"`
+	Message          string     `json:"message"`
+	EventID          string     `json:"eventId"`
+	UID              string     `json:"uid,omitempty"`
+	IsPremium        bool       `json:"isPremium"`
+	PremiumExpiresAt *time.Time `json:"premiumExpiresAt,omitempty"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import (
+	accountmodels "io.winapps.journeyapp/internal/models/account"
+)
+
+// BulkTagRequest applies one tag change to many entries owned by the caller
+type BulkTagRequest struct {
+	EntryIDs []string          `json:"entryIds" binding:"required"`
+	Tag      accountmodels.Tag `json:"tag" binding:"required"`
+}
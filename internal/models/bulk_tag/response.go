@@ -0,0 +1,19 @@
+package models
+
+import (
+	accountmodels "io.winapps.journeyapp/internal/models/account"
+)
+
+type BulkAddTagResponse struct {
+	Tag     accountmodels.Tag `json:"tag"`
+	Added   int               `json:"added"`   // entries that gained the tag or had its value changed
+	Skipped int               `json:"skipped"` // entries that already had the exact tag
+	Message string            `json:"message"`
+}
+
+type BulkRemoveTagResponse struct {
+	Tag     accountmodels.Tag `json:"tag"`
+	Removed int               `json:"removed"`
+	Skipped int               `json:"skipped"` // entries that didn't have the tag
+	Message string            `json:"message"`
+}
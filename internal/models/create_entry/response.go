@@ -14,6 +14,7 @@ type CreateEntryResponse struct {
 	Tags        []accountmodels.Tag     `json:"tags"`
 	Locations   []accountmodels.Location  `json:"locations"`
 	Visibility  string    `json:"visibility"`
+	Status      string    `json:"status"`
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
\ No newline at end of file
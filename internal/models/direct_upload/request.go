@@ -0,0 +1,16 @@
+package models
+
+// RequestUploadURLRequest describes a file the client wants to upload straight to media
+// storage. MediaType is "image" or "audio"; Size is the exact byte count it will send.
+type RequestUploadURLRequest struct {
+	EntryID     string `json:"entryId" binding:"required"`
+	MediaType   string `json:"mediaType" binding:"required"`
+	ContentType string `json:"contentType" binding:"required"`
+	Size        int64  `json:"size" binding:"required"`
+}
+
+// ConfirmUploadRequest attaches a finished direct upload to its entry
+type ConfirmUploadRequest struct {
+	EntryID  string `json:"entryId" binding:"required"`
+	MediaURL string `json:"mediaUrl" binding:"required"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RequestUploadURLResponse tells the client where to PUT the file. Headers must be sent
+// as given; the signature covers them.
+type RequestUploadURLResponse struct {
+	UploadURL string            `json:"uploadUrl"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	MediaURL  string            `json:"mediaUrl"` // pass to confirm-upload; becomes the entry's media URL
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+type ConfirmUploadResponse struct {
+	EntryID   string `json:"entryId"`
+	MediaType string `json:"mediaType"`
+	MediaURL  string `json:"mediaUrl"`
+	MimeType  string `json:"mimeType"`
+	FileSize  int64  `json:"fileSize"`
+	Message   string `json:"message"`
+}
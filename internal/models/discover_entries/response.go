@@ -0,0 +1,18 @@
+package models
+
+import (
+	getsharedentrymodels "io.winapps.journeyapp/internal/models/get_shared_entry"
+	searchmodels "io.winapps.journeyapp/internal/models/search_entries"
+)
+
+// DiscoverEntry is a public entry with the author it's shown under
+type DiscoverEntry struct {
+	searchmodels.EntryResult
+	Owner getsharedentrymodels.EntryOwner `json:"owner"`
+}
+
+type DiscoverEntriesResponse struct {
+	Entries    []DiscoverEntry          `json:"entries"`
+	Pagination *searchmodels.Pagination `json:"pagination,omitempty"` // left out of cursor responses
+	NextCursor string                   `json:"nextCursor,omitempty"` // set while more entries follow
+}
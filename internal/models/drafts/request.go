@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+// PublishEntryRequest publishes a draft now, or schedules it when PublishAt is in the future
+type PublishEntryRequest struct {
+	EntryID   string     `json:"entryId" binding:"required"`
+	PublishAt *time.Time `json:"publishAt,omitempty"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type PublishEntryResponse struct {
+	ID        string     `json:"id"`
+	Status    string     `json:"status"` // "published" or "scheduled"
+	PublishAt *time.Time `json:"publishAt,omitempty"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	Message   string     `json:"message"`
+}
@@ -0,0 +1,7 @@
+package models
+
+type DuplicateEntryRequest struct {
+	EntryID string `json:"entryId" binding:"required"`
+	// CopyMedia also copies the entry's image, audio and video files to the new entry
+	CopyMedia bool `json:"copyMedia,omitempty"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import (
+	getentrymodels "io.winapps.journeyapp/internal/models/get_entry"
+)
+
+type DuplicateEntryResponse struct {
+	SourceEntryID string                           `json:"sourceEntryId"`
+	Entry         *getentrymodels.GetEntryResponse `json:"entry"`
+	Message       string                           `json:"message"`
+}
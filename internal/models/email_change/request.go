@@ -0,0 +1,9 @@
+package models
+
+type RequestEmailChangeRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
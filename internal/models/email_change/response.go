@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type RequestEmailChangeResponse struct {
+	PendingEmail string    `json:"pendingEmail"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	Message      string    `json:"message"`
+}
+
+type ConfirmEmailChangeResponse struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailVerified"`
+	Message       string `json:"message"`
+}
@@ -0,0 +1,16 @@
+package models
+
+type AddCommentRequest struct {
+	EntryID string `json:"entryId" binding:"required"`
+	Body    string `json:"body" binding:"required"`
+}
+
+type GetCommentsRequest struct {
+	EntryID string `json:"entryId" binding:"required"`
+	Page    int    `json:"page,omitempty"`  // Default: 1
+	Limit   int    `json:"limit,omitempty"` // Default: 20
+}
+
+type DeleteCommentRequest struct {
+	CommentID string `json:"commentId" binding:"required"`
+}
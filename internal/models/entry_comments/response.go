@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+type Comment struct {
+	ID          string    `json:"id"`
+	EntryID     string    `json:"entryId"`
+	AuthorUID   string    `json:"authorUid"`
+	DisplayName string    `json:"displayName"`
+	PhotoURL    string    `json:"photoURL"`
+	Body        string    `json:"body"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type AddCommentResponse struct {
+	Success bool    `json:"success"`
+	Message string  `json:"message"`
+	Comment Comment `json:"comment"`
+}
+
+type Pagination struct {
+	Page        int  `json:"page"`
+	Limit       int  `json:"limit"`
+	Total       int  `json:"total"`
+	TotalPages  int  `json:"totalPages"`
+	HasNext     bool `json:"hasNext"`
+	HasPrevious bool `json:"hasPrevious"`
+}
+
+type GetCommentsResponse struct {
+	Comments   []Comment  `json:"comments"`
+	Pagination Pagination `json:"pagination"`
+}
+
+type DeleteCommentResponse struct {
+	IsDeleted bool   `json:"isDeleted"`
+	Message   string `json:"message"`
+}
@@ -0,0 +1,5 @@
+package models
+
+type SetEntryEncryptionRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
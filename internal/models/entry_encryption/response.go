@@ -0,0 +1,7 @@
+package models
+
+type SetEntryEncryptionResponse struct {
+	Enabled          bool   `json:"enabled"`
+	ConvertedEntries int    `json:"convertedEntries"` // entries encrypted or decrypted by this change
+	Message          string `json:"message"`
+}
@@ -0,0 +1,13 @@
+package models
+
+type ReportEntryRequest struct {
+	EntryID string `json:"entryId" binding:"required"`
+	Reason  string `json:"reason" binding:"required"`
+}
+
+type ResolveReportRequest struct {
+	ReportID string `json:"reportId" binding:"required"`
+	// Action is "dismiss", which restores the entry to feeds, or "uphold", which keeps it
+	// hidden. It applies to every open report on the same entry.
+	Action string `json:"action" binding:"required"`
+}
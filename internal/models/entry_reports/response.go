@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type ReportEntryResponse struct {
+	ReportID string `json:"reportId"`
+	Message  string `json:"message"`
+}
+
+// Report is one user's report together with the reported entry, so moderators can review
+// it without access to the entry itself
+type Report struct {
+	ID               string     `json:"id"`
+	EntryID          string     `json:"entryId"`
+	EntryOwnerUID    string     `json:"entryOwnerUid"`
+	EntryTitle       string     `json:"entryTitle"`
+	EntryDescription string     `json:"entryDescription"`
+	EntryHidden      bool       `json:"entryHidden"` // hidden from feeds pending or after review
+	OpenReports      int        `json:"openReports"` // open reports on the same entry
+	ReporterUID      string     `json:"reporterUid"`
+	Reason           string     `json:"reason"`
+	Status           string     `json:"status"`
+	ResolvedBy       string     `json:"resolvedBy,omitempty"`
+	ResolvedAt       *time.Time `json:"resolvedAt,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+}
+
+type Pagination struct {
+	Page        int  `json:"page"`
+	Limit       int  `json:"limit"`
+	Total       int  `json:"total"`
+	TotalPages  int  `json:"totalPages"`
+	HasNext     bool `json:"hasNext"`
+	HasPrevious bool `json:"hasPrevious"`
+}
+
+type ListReportsResponse struct {
+	Reports    []Report   `json:"reports"`
+	Pagination Pagination `json:"pagination"`
+}
+
+type ResolveReportResponse struct {
+	EntryID         string `json:"entryId"`
+	Status          string `json:"status"`
+	ResolvedReports int    `json:"resolvedReports"`
+	EntryHidden     bool   `json:"entryHidden"`
+	Message         string `json:"message"`
+}
@@ -2,4 +2,9 @@ package models
 
 type ExportDataRequest struct {
 	UID string `json:"uid" binding:"required"`
+	// Stream asks for the zip in the response body instead of an async job. It is
+	// honored only for small accounts; larger exports still return an exportJobId.
+	Stream bool `json:"stream,omitempty"`
+	// Format is "csv" (default) or "pdf"; "pdf" adds a rendered journal.pdf to the zip
+	Format string `json:"format,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// FriendRequest is a pending friendship, with the profile of the user on the other side
+type FriendRequest struct {
+	UID         string    `json:"uid"`
+	DisplayName string    `json:"displayName"`
+	PhotoURL    string    `json:"photoURL"`
+	IsPremium   bool      `json:"isPremium"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type GetFriendRequestsResponse struct {
+	// Incoming requests were sent to the caller and can be approved or rejected
+	Incoming []FriendRequest `json:"incoming"`
+	// Outgoing requests were sent by the caller and are waiting on the other user
+	Outgoing []FriendRequest `json:"outgoing"`
+}
@@ -28,4 +28,5 @@ type GetAccountDetailsResponse struct {
 	TotalVideos         int       `json:"totalVideos" binding:"required"`
 	IsPremium           bool      `json:"isPremium" binding:"required"`
 	PremiumExpiresAt    time.Time `json:"premiumExpiresAt" binding:"required"`
+	EntryEncryption     bool      `json:"entryEncryption"`
 }
\ No newline at end of file
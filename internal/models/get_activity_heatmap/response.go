@@ -0,0 +1,10 @@
+package models
+
+type GetActivityHeatmapResponse struct {
+	From     string         `json:"from"` // first day of the range, YYYY-MM-DD
+	To       string         `json:"to"`   // last day of the range, inclusive
+	Timezone string         `json:"timezone"`
+	Days     map[string]int `json:"days"` // YYYY-MM-DD -> entries created that day; days without entries are omitted
+	Total    int            `json:"total"`
+	Streak   int            `json:"streak"` // consecutive days with an entry, ending today
+}
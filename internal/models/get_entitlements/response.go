@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"io.winapps.journeyapp/internal/premium"
+)
+
+// GetEntitlementsResponse describes what the user's plan allows and how much of it is used
+type GetEntitlementsResponse struct {
+	Tier             premium.Tier   `json:"tier"`
+	IsPremium        bool           `json:"isPremium"`
+	PremiumExpiresAt *time.Time     `json:"premiumExpiresAt,omitempty"`
+	Limits           premium.Limits `json:"limits"`
+	Usage            Usage          `json:"usage"`
+}
+
+type Usage struct {
+	Entries int `json:"entries"`
+	// EntriesRemaining is omitted when the plan has no entry limit
+	EntriesRemaining *int `json:"entriesRemaining,omitempty"`
+}
@@ -0,0 +1,5 @@
+package models
+
+type GetEntriesRequest struct {
+	EntryIDs []string `json:"entryIds" binding:"required"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import (
+	getentrymodels "io.winapps.journeyapp/internal/models/get_entry"
+)
+
+// GetEntriesResponse lists the requested entries in request order. IDs that don't exist
+// or belong to another user are listed in NotFound instead.
+type GetEntriesResponse struct {
+	Entries  []getentrymodels.GetEntryResponse `json:"entries"`
+	NotFound []string                          `json:"notFound"`
+}
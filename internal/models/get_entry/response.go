@@ -7,14 +7,22 @@ import (
 )
 
 type GetEntryResponse struct {
-	ID          string                      `json:"id"`
-	Title       string                      `json:"title"`
-	Description string                      `json:"description"`
-	Images      []string                    `json:"images"`
-	Audio       []string                    `json:"audio"`
-	Tags        []accountmodels.Tag         `json:"tags"`
-	Locations   []accountmodels.Location    `json:"locations"`
-	Visibility  string                      `json:"visibility"`
-	CreatedAt   time.Time                   `json:"createdAt"`
-	UpdatedAt   time.Time                   `json:"updatedAt"`
-}
\ No newline at end of file
+	ID          string                     `json:"id"`
+	OwnerUID    string                     `json:"ownerUid"`
+	Title       string                     `json:"title"`
+	Description string                     `json:"description"`
+	Images      []string                   `json:"images"`
+	Audio       []string                   `json:"audio"`
+	Videos      []accountmodels.Video      `json:"videos"`
+	Attachments []accountmodels.Attachment `json:"attachments"`
+	Tags        []accountmodels.Tag        `json:"tags"`
+	Locations   []accountmodels.Location   `json:"locations"`
+	Visibility  string                     `json:"visibility"`
+	SharedWith  []string                   `json:"sharedWith"`
+	Status      string                     `json:"status"`              // "draft", "published" or "scheduled"
+	PublishAt   *time.Time                 `json:"publishAt,omitempty"` // set while scheduled
+	Encrypted   bool                       `json:"encrypted"`           // title and description are encrypted at rest
+	CreatedAt   time.Time                  `json:"createdAt"`
+	UpdatedAt   time.Time                  `json:"updatedAt"`
+	ReadOnly    bool                       `json:"readOnly"` // the viewer isn't the owner and can't edit the entry
+}
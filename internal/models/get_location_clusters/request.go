@@ -0,0 +1,12 @@
+package models
+
+// GetLocationClustersRequest asks for the caller's locations inside a bounding box,
+// grouped into clusters sized for the given map zoom level (0-22).
+// A MinLongitude greater than MaxLongitude means the box crosses the antimeridian.
+type GetLocationClustersRequest struct {
+	MinLatitude  float64 `json:"minLatitude"`
+	MinLongitude float64 `json:"minLongitude"`
+	MaxLatitude  float64 `json:"maxLatitude"`
+	MaxLongitude float64 `json:"maxLongitude"`
+	Zoom         int     `json:"zoom"`
+}
@@ -0,0 +1,19 @@
+package models
+
+type LocationCluster struct {
+	Latitude     float64 `json:"latitude"` // centroid of the clustered points
+	Longitude    float64 `json:"longitude"`
+	Count        int     `json:"count"`                 // locations in the cluster
+	EntryCount   int     `json:"entryCount"`            // distinct entries those locations belong to
+	DisplayName  string  `json:"displayName,omitempty"` // most common display name in the cluster
+	MinLatitude  float64 `json:"minLatitude"`
+	MinLongitude float64 `json:"minLongitude"`
+	MaxLatitude  float64 `json:"maxLatitude"`
+	MaxLongitude float64 `json:"maxLongitude"`
+}
+
+type GetLocationClustersResponse struct {
+	Clusters []LocationCluster `json:"clusters"`
+	Zoom     int               `json:"zoom"`
+	CellSize float64           `json:"cellSize"` // grid cell size in degrees
+}
@@ -0,0 +1,5 @@
+package models
+
+type GetSharedEntryRequest struct {
+	EntryID string `json:"entryId" binding:"required"`
+}
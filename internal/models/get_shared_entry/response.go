@@ -0,0 +1,17 @@
+package models
+
+import (
+	getentrymodels "io.winapps.journeyapp/internal/models/get_entry"
+)
+
+// EntryOwner is the author of an entry, as shown to the people it's shared with
+type EntryOwner struct {
+	UID         string `json:"uid"`
+	DisplayName string `json:"displayName"`
+	PhotoURL    string `json:"photoURL"`
+}
+
+type GetSharedEntryResponse struct {
+	*getentrymodels.GetEntryResponse
+	Owner EntryOwner `json:"owner"`
+}
@@ -0,0 +1,9 @@
+package models
+
+type GetStreakResponse struct {
+	CurrentStreak int    `json:"currentStreak"` // consecutive days with an entry, ending today
+	LongestStreak int    `json:"longestStreak"`
+	LastEntryDate string `json:"lastEntryDate,omitempty"` // YYYY-MM-DD in timezone; omitted before the first entry
+	WrittenToday  bool   `json:"writtenToday"`
+	Timezone      string `json:"timezone"`
+}
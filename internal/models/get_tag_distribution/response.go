@@ -0,0 +1,13 @@
+package models
+
+type TagCount struct {
+	Key        string `json:"key"`
+	EntryCount int    `json:"entryCount"` // distinct entries carrying the key
+}
+
+type GetTagDistributionResponse struct {
+	From     string     `json:"from,omitempty"` // empty when the range is unbounded
+	To       string     `json:"to,omitempty"`
+	Timezone string     `json:"timezone"`
+	Tags     []TagCount `json:"tags"`
+}
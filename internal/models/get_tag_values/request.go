@@ -0,0 +1,5 @@
+package models
+
+type GetTagValuesRequest struct {
+	Key string `json:"key"`
+}
@@ -0,0 +1,11 @@
+package models
+
+type TagValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"` // times the value has been used with the key
+}
+
+type GetTagValuesResponse struct {
+	Key    string     `json:"key"`
+	Values []TagValue `json:"values"`
+}
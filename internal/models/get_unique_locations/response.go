@@ -4,6 +4,16 @@ import (
 	accountmodels "io.winapps.journeyapp/internal/models/account"
 )
 
+// UniqueLocation is one distinct location with the number of entries recorded there
+type UniqueLocation struct {
+	accountmodels.Location
+	Count int `json:"count"`
+}
+
 type GetUniqueLocationsResponse struct {
-	Locations []accountmodels.Location `json:"locations"`
-}
\ No newline at end of file
+	Locations []UniqueLocation `json:"locations"`
+	Total     int              `json:"total"` // distinct locations matching q, across all pages
+	Limit     int              `json:"limit"`
+	Offset    int              `json:"offset"`
+	HasMore   bool             `json:"hasMore"`
+}
@@ -4,6 +4,12 @@ import (
 	accountmodels "io.winapps.journeyapp/internal/models/account"
 )
 
+// UniqueTag is a tag key with its most recent value and how many entries use the key
+type UniqueTag struct {
+	accountmodels.Tag
+	Count int `json:"count"`
+}
+
 type GetUniqueTagsResponse struct {
-	Tags []accountmodels.Tag `json:"tags"`
-}
\ No newline at end of file
+	Tags []UniqueTag `json:"tags"`
+}
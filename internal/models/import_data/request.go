@@ -0,0 +1,11 @@
+package models
+
+import accountmodels "io.winapps.journeyapp/internal/models/account"
+
+// ImportDataJSONRequest is a JSON export posted to import-data instead of a zip. Entries
+// have the same shape as get-entries returns them; their media are references to files
+// already in the account's media storage.
+type ImportDataJSONRequest struct {
+	UID     string                `json:"uid" binding:"required"`
+	Entries []accountmodels.Entry `json:"entries" binding:"required"`
+}
@@ -0,0 +1,6 @@
+package models
+
+type ImportDataResponse struct {
+	ImportJobID string `json:"importJobId"`
+	Message     string `json:"message"`
+}
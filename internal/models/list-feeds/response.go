@@ -4,9 +4,15 @@ import (
 	accountmodels "io.winapps.journeyapp/internal/models/account"
 )
 
+// FeedEntry is an entry as shown in a friend's feed, with social counts alongside it
+type FeedEntry struct {
+	*accountmodels.Entry
+	CommentCount int `json:"commentCount"`
+}
+
 type ListFeedResult struct {
 	UID     string              `json:"uid"`
-	Entries []accountmodels.Entry `json:"entries"`
+	Entries []*FeedEntry `json:"entries"`
 }
 
 type ListFeedsResponse struct {
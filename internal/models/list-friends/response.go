@@ -3,14 +3,24 @@ package models
 import "time"
 
 type ListFriendsResponse struct {
-	Friends []ListFriend `json:"friends"`
+	Friends    []ListFriend `json:"friends"`
+	Pagination Pagination   `json:"pagination"`
 }
 
 type ListFriend struct {
-	UID string `json:"uid"`
-	DisplayName string `json:"displayName"`
-	Email string `json:"email"`
-	PhotoURL string `json:"photoURL"`
-	Status string `json:"status"`
-	CreatedAt time.Time `json:"createdAt"`
-}
\ No newline at end of file
+	UID         string    `json:"uid"`
+	DisplayName string    `json:"displayName"`
+	Email       string    `json:"email"`
+	PhotoURL    string    `json:"photoURL"`
+	Status      string    `json:"status"`
+	IsPremium   bool      `json:"isPremium"`
+	EntryCount  int       `json:"entryCount"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type Pagination struct {
+	Limit   int  `json:"limit"`
+	Offset  int  `json:"offset"`
+	Total   int  `json:"total"`
+	HasMore bool `json:"hasMore"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import (
+	searchmodels "io.winapps.journeyapp/internal/models/search_entries"
+)
+
+// ListEntriesResponse uses the same entry and pagination shapes as SearchEntries
+type ListEntriesResponse struct {
+	Entries    []searchmodels.EntryResult `json:"entries"`
+	Pagination searchmodels.Pagination    `json:"pagination"`
+}
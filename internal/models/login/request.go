@@ -0,0 +1,9 @@
+package models
+
+// LoginRequest carries a Firebase ID token obtained by signing in on the client.
+// Email and Password are accepted only so the server can reject them explicitly.
+type LoginRequest struct {
+	IDToken  string `json:"idToken"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type LoginResponse struct {
+	Success     bool      `json:"success"`
+	Message     string    `json:"message"`
+	UID         string    `json:"uid"`
+	DisplayName string    `json:"displayName"`
+	Email       string    `json:"email"`
+	PhotoURL    string    `json:"photoURL"`
+	Token       string    `json:"token"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	StreamToken string    `json:"streamToken,omitempty"`
+}
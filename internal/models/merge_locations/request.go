@@ -0,0 +1,7 @@
+package models
+
+// MergeLocationsRequest consolidates the caller's locations. Locations sharing a
+// display name, or lying within RadiusMeters of each other, are merged.
+type MergeLocationsRequest struct {
+	RadiusMeters float64 `json:"radiusMeters,omitempty"` // Default: 50, max 500
+}
@@ -0,0 +1,8 @@
+package models
+
+type MergeLocationsResponse struct {
+	Merged         int    `json:"merged"`         // locations whose address fields were rewritten
+	Groups         int    `json:"groups"`         // groups of two or more locations found
+	EntriesUpdated int    `json:"entriesUpdated"` // distinct entries touched
+	Message        string `json:"message"`
+}
@@ -0,0 +1,13 @@
+package models
+
+type MutualFriend struct {
+	UID         string `json:"uid"`
+	DisplayName string `json:"displayName"`
+	PhotoURL    string `json:"photoURL"`
+}
+
+type MutualFriendsResponse struct {
+	UID           string         `json:"uid"`
+	MutualFriends []MutualFriend `json:"mutualFriends"`
+	Count         int            `json:"count"`
+}
@@ -0,0 +1,10 @@
+package models
+
+type NormalizeTagsResponse struct {
+	// Renamed is how many tags had their key trimmed and lowercased
+	Renamed int `json:"renamed"`
+	// Merged is how many tags were dropped because their entry had another case variant
+	// of the same key; the most recently set one is kept
+	Merged  int    `json:"merged"`
+	Message string `json:"message"`
+}
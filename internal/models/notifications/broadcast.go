@@ -0,0 +1,28 @@
+package models
+
+// BroadcastSegment narrows a broadcast to matching push tokens. Empty lists and a nil
+// Premium match everyone.
+type BroadcastSegment struct {
+	Platforms []string `json:"platforms,omitempty"` // push_tokens.platform, e.g. "ios" or "android"
+	Timezones []string `json:"timezones,omitempty"` // IANA names as registered with the token
+	Languages []string `json:"languages,omitempty"` // user_settings.lang
+	Premium   *bool    `json:"premium,omitempty"`
+}
+
+type BroadcastNotificationRequest struct {
+	Title string            `json:"title" binding:"required"`
+	Body  string            `json:"body" binding:"required"`
+	Data  map[string]string `json:"data,omitempty"`
+	// Category is "announcements" (the default), which users can opt out of, or
+	// "service" for notices everyone must get, such as planned maintenance
+	Category string           `json:"category,omitempty"`
+	Segment  BroadcastSegment `json:"segment"`
+}
+
+type BroadcastNotificationResponse struct {
+	BroadcastID string `json:"broadcastId"`
+	Targeted    int    `json:"targeted"`
+	OptedOut    int    `json:"optedOut"`
+	Succeeded   int    `json:"succeeded"`
+	Failed      int    `json:"failed"`
+}
@@ -5,6 +5,7 @@ import "time"
 type DailyPrompt struct {
 	ID        string    `json:"id" db:"id"`
 	Prompt    string    `json:"prompt" db:"prompt"`
+	Lang      string    `json:"lang" db:"lang"`
 	Date      time.Time `json:"date" db:"date"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
\ No newline at end of file
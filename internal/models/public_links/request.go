@@ -0,0 +1,12 @@
+package models
+
+type CreatePublicLinkRequest struct {
+	EntryID       string `json:"entryId" binding:"required"`
+	ExpiresInDays int    `json:"expiresInDays"` // 0 for a link that never expires
+}
+
+// RevokePublicLinkRequest revokes one link by token, or every link to the entry when token is empty
+type RevokePublicLinkRequest struct {
+	EntryID string `json:"entryId" binding:"required"`
+	Token   string `json:"token"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	accountmodels "io.winapps.journeyapp/internal/models/account"
+)
+
+type CreatePublicLinkResponse struct {
+	Token     string     `json:"token"`
+	URL       string     `json:"url"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type RevokePublicLinkResponse struct {
+	Revoked int    `json:"revoked"`
+	Message string `json:"message"`
+}
+
+// SharedEntryResponse is the read-only view of an entry behind a public link. It carries
+// the author's display name but not their uid, visibility or share list, and its media
+// URLs point at the link rather than the owner's media paths.
+type SharedEntryResponse struct {
+	ID          string                   `json:"id"`
+	Title       string                   `json:"title"`
+	Description string                   `json:"description"`
+	Images      []string                 `json:"images"`
+	Audio       []string                 `json:"audio"`
+	Videos      []accountmodels.Video    `json:"videos"`
+	Tags        []accountmodels.Tag      `json:"tags"`
+	Locations   []accountmodels.Location `json:"locations"`
+	AuthorName  string                   `json:"authorName"`
+	CreatedAt   time.Time                `json:"createdAt"`
+	UpdatedAt   time.Time                `json:"updatedAt"`
+	ExpiresAt   *time.Time               `json:"expiresAt,omitempty"`
+}
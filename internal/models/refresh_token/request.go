@@ -0,0 +1,5 @@
+package models
+
+type RefreshTokenRequest struct {
+	IDToken string `json:"idToken" binding:"required"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type RefreshTokenResponse struct {
+	Success   bool      `json:"success"`
+	Message   string    `json:"message"`
+	UID       string    `json:"uid"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
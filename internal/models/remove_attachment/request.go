@@ -0,0 +1,6 @@
+package models
+
+type RemoveAttachmentRequest struct {
+	EntryID       string `json:"entryId" binding:"required"`
+	AttachmentURL string `json:"attachmentUrl" binding:"required"`
+}
@@ -0,0 +1,7 @@
+package models
+
+type RemoveAttachmentResponse struct {
+	EntryID       string `json:"entryId"`
+	AttachmentURL string `json:"attachmentUrl"`
+	Message       string `json:"message"`
+}
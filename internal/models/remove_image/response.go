@@ -1,7 +1,12 @@
 package models
 
+import "time"
+
 type RemoveImageResponse struct {
 	EntryID  string `json:"entryId"`
 	ImageURL string `json:"imageUrl"`
 	Message  string `json:"message"`
-}
\ No newline at end of file
+	// UndoToken restores the removal through undo-removal until UndoExpiresAt
+	UndoToken     string     `json:"undoToken,omitempty"`
+	UndoExpiresAt *time.Time `json:"undoExpiresAt,omitempty"`
+}
@@ -1,11 +1,16 @@
 package models
 
 import (
+	"time"
+
 	accountmodels "io.winapps.journeyapp/internal/models/account"
 )
 
 type RemoveLocationResponse struct {
-	EntryID  string                    `json:"entryId"`
-	Location accountmodels.Location    `json:"location"`
-	Message  string                    `json:"message"`
-}
\ No newline at end of file
+	EntryID  string                 `json:"entryId"`
+	Location accountmodels.Location `json:"location"`
+	Message  string                 `json:"message"`
+	// UndoToken restores the removal through undo-removal until UndoExpiresAt
+	UndoToken     string     `json:"undoToken,omitempty"`
+	UndoExpiresAt *time.Time `json:"undoExpiresAt,omitempty"`
+}
@@ -1,11 +1,16 @@
 package models
 
 import (
+	"time"
+
 	accountmodels "io.winapps.journeyapp/internal/models/account"
 )
 
 type RemoveTagResponse struct {
-	EntryID string                `json:"entryId"`
-	Tag     accountmodels.Tag     `json:"tag"`
-	Message string                `json:"message"`
-}
\ No newline at end of file
+	EntryID string            `json:"entryId"`
+	Tag     accountmodels.Tag `json:"tag"`
+	Message string            `json:"message"`
+	// UndoToken restores the removal through undo-removal until UndoExpiresAt
+	UndoToken     string     `json:"undoToken,omitempty"`
+	UndoExpiresAt *time.Time `json:"undoExpiresAt,omitempty"`
+}
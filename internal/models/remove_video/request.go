@@ -0,0 +1,6 @@
+package models
+
+type RemoveVideoRequest struct {
+	EntryID  string `json:"entryId" binding:"required"`
+	VideoURL string `json:"videoUrl" binding:"required"`
+}
@@ -0,0 +1,7 @@
+package models
+
+type RemoveVideoResponse struct {
+	EntryID  string `json:"entryId"`
+	VideoURL string `json:"videoUrl"`
+	Message  string `json:"message"`
+}
@@ -11,14 +11,23 @@ type SearchEntriesRequest struct {
 	Filters     SearchFilters              `json:"filters,omitempty"`
 	Page        int                        `json:"page,omitempty"`        // Default: 1
 	Limit       int                        `json:"limit,omitempty"`       // Default: 20
+	IncludeDrafts bool                     `json:"-"`                     // Set from ?includeDrafts=true; also includes scheduled entries
 }
 
 type SearchFilters struct {
 	Timeframe TimeframeFilter             `json:"timeframe,omitempty"`
-	SortRule  string                     `json:"sortRule,omitempty"`    // "Newest" (default) or "Oldest"
+	UpdatedWithin TimeframeFilter         `json:"updatedWithin,omitempty"` // Same types as Timeframe, applied to updatedAt
+	SortRule  string                     `json:"sortRule,omitempty"`    // "Newest" (default), "Oldest", "RecentlyUpdated" or "LeastRecentlyUpdated"
 	Locations []accountmodels.Location   `json:"locations,omitempty"`
-	Tags      []accountmodels.Tag        `json:"tags,omitempty"`
+	Tags      []accountmodels.Tag        `json:"tags,omitempty"`       // A tag with an empty value matches any value for its key
+	TagMatchMode string                  `json:"tagMatchMode,omitempty"` // "all" (default): every tag must match; "any": at least one
 	Visibilities []string                `json:"visibilities,omitempty"`
+	// Media filters are tri-state: omitted = ignore, true = must have, false = must not have
+	HasImages   *bool                    `json:"hasImages,omitempty"`
+	HasAudio    *bool                    `json:"hasAudio,omitempty"`
+	HasVideos   *bool                    `json:"hasVideos,omitempty"`
+	HasLocation *bool                    `json:"hasLocation,omitempty"`
+	HasTags     *bool                    `json:"hasTags,omitempty"`
 }
 
 type TimeframeFilter struct {
@@ -9,6 +9,9 @@ import (
 type SearchEntriesResponse struct {
 	Entries    []EntryResult `json:"entries"`
 	Pagination Pagination   `json:"pagination"`
+	// TextSearchSkipped is set when the query wasn't matched against titles and
+	// descriptions because the user's entries are encrypted; search them on the client
+	TextSearchSkipped bool `json:"textSearchSkipped,omitempty"`
 }
 
 type EntryResult struct {
@@ -17,9 +20,13 @@ type EntryResult struct {
 	Description string                      `json:"description"`
 	Images      []string                    `json:"images"`
 	Audio       []string                    `json:"audio"`
+	Videos      []accountmodels.Video       `json:"videos"`
 	Tags        []accountmodels.Tag         `json:"tags"`
 	Locations   []accountmodels.Location    `json:"locations"`
 	Visibility  string                      `json:"visibility"`
+	Status      string                      `json:"status"`              // "draft", "published" or "scheduled"
+	PublishAt   *time.Time                  `json:"publishAt,omitempty"` // set while scheduled
+	Encrypted   bool                        `json:"encrypted"`           // title and description are encrypted at rest
 	CreatedAt   time.Time                   `json:"createdAt"`
 	UpdatedAt   time.Time                   `json:"updatedAt"`
 }
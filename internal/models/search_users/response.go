@@ -3,14 +3,28 @@ package models
 import "time"
 
 type SearchUserResult struct {
-	UID string `json:"uid"`
-	DisplayName string `json:"displayName"`
-	Email string `json:"email"`
-	PhotoURL string `json:"photoURL"`
-	CreatedAt time.Time `json:"createdAt"`
-	IsPremium bool `json:"isPremium"`
+	UID         string    `json:"uid"`
+	DisplayName string    `json:"displayName"`
+	Email       string    `json:"email"`
+	PhotoURL    string    `json:"photoURL"`
+	CreatedAt   time.Time `json:"createdAt"`
+	IsPremium   bool      `json:"isPremium"`
+	// PublicEntryCount counts published public entries only
+	PublicEntryCount int `json:"publicEntryCount"`
+	// LastPublicEntryAt is when the newest public entry was written, null if there is none
+	LastPublicEntryAt *time.Time `json:"lastPublicEntryAt"`
+	// Relationship is the requester's friendship with the user: none, pending or approved
+	Relationship string `json:"relationship"`
 }
 
 type SearchUsersResponse struct {
-	Results []SearchUserResult `json:"results"`
-}
\ No newline at end of file
+	Results    []SearchUserResult `json:"results"`
+	Pagination Pagination         `json:"pagination"`
+}
+
+type Pagination struct {
+	Limit   int  `json:"limit"`
+	Offset  int  `json:"offset"`
+	Total   int  `json:"total"`
+	HasMore bool `json:"hasMore"`
+}
@@ -0,0 +1,5 @@
+package models
+
+type LogoutRequest struct {
+	RevokeFirebase bool `json:"revokeFirebase"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type Session struct {
+	TokenHint string     `json:"tokenHint"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Current   bool       `json:"current"`
+}
+
+type ListSessionsResponse struct {
+	Sessions []Session `json:"sessions"`
+}
+
+type LogoutResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// SweepMediaResponse reports a media sweep. Orphans are files with no database row;
+// Missing are rows whose file is gone. Both lists are capped, the counts are not.
+type SweepMediaResponse struct {
+	Applied      bool           `json:"applied"` // false for a dry run; nothing was deleted
+	Scanned      int            `json:"scanned"`
+	Skipped      int            `json:"skipped"` // files too new to judge
+	OrphanCount  int            `json:"orphanCount"`
+	OrphanBytes  int64          `json:"orphanBytes"`
+	Deleted      int            `json:"deleted"`
+	Orphans      []string       `json:"orphans"`
+	MissingCount int            `json:"missingCount"`
+	Missing      []MissingMedia `json:"missing"`
+	Truncated    bool           `json:"truncated"` // true when either list hit the cap
+}
+
+// MissingMedia is a database row pointing at a file that doesn't exist
+type MissingMedia struct {
+	Table   string `json:"table"` // "images", "audio", "videos" or "users" for profile photos
+	EntryID string `json:"entryId,omitempty"`
+	URL     string `json:"url"`
+}
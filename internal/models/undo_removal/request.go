@@ -0,0 +1,5 @@
+package models
+
+type UndoRemovalRequest struct {
+	UndoToken string `json:"undoToken" binding:"required"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	accountmodels "io.winapps.journeyapp/internal/models/account"
+)
+
+type UndoRemovalResponse struct {
+	EntryID   string                   `json:"entryId"`
+	Kind      string                   `json:"kind"` // tag, location, image or audio
+	Tags      []accountmodels.Tag      `json:"tags,omitempty"`
+	Locations []accountmodels.Location `json:"locations,omitempty"`
+	ImageURL  string                   `json:"imageUrl,omitempty"`
+	AudioURL  string                   `json:"audioUrl,omitempty"`
+	Message   string                   `json:"message"`
+}
@@ -12,6 +12,4 @@ type UpdateAccountRequest struct {
 	CreatedAt   				time.Time `json:"createdAt,omitempty"`
 	EmailVerified 			bool 			`json:"emailVerified,omitempty"`
 	PhoneNumberVerified bool 			`json:"phoneNumberVerified,omitempty"`
-	IsPremium 					bool 			`json:"isPremium,omitempty"` // TODO: remove this later
-	PremiumExpiresAt 		time.Time `json:"premiumExpiresAt,omitempty"` // TODO: remove this later
 }
\ No newline at end of file
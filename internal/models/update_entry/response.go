@@ -12,9 +12,11 @@ type UpdateEntryResponse struct {
 	Description string                      `json:"description"`
 	Images      []string                    `json:"images"`
 	Audio       []string                    `json:"audio"`
+	Videos      []accountmodels.Video       `json:"videos"`
 	Tags        []accountmodels.Tag         `json:"tags"`
 	Locations   []accountmodels.Location    `json:"locations"`
 	Visibility  string                      `json:"visibility"`
+	SharedWith  []string                    `json:"sharedWith"`
 	CreatedAt   time.Time                   `json:"createdAt"`
 	UpdatedAt   time.Time                   `json:"updatedAt"`
 }
\ No newline at end of file
@@ -5,4 +5,8 @@ type UpdateSettingsRequest struct {
 	Theme     *string `json:"theme,omitempty"`
 	AppFont   *string `json:"appFont,omitempty"`
 	Lang      *string `json:"lang,omitempty"`
-}
\ No newline at end of file
+	// NotifyAnnouncements turns admin announcement broadcasts on or off
+	NotifyAnnouncements *bool `json:"notifyAnnouncements,omitempty"`
+	// NormalizeTags saves tag keys trimmed and lowercased from now on
+	NormalizeTags *bool `json:"normalizeTags,omitempty"`
+}
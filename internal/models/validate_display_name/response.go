@@ -1,5 +1,8 @@
 package models
 
 type ValidateDisplayNameResponse struct {
-	IsValid bool `json:"isValid"`
-}
\ No newline at end of file
+	// IsValid mirrors Available for older clients
+	IsValid     bool     `json:"isValid"`
+	Available   bool     `json:"available"`
+	Suggestions []string `json:"suggestions"`
+}
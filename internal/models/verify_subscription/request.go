@@ -0,0 +1,9 @@
+package models
+
+// VerifySubscriptionRequest carries a store receipt: the StoreKit 2 transaction ID on
+// iOS or the purchase token on Android
+type VerifySubscriptionRequest struct {
+	Platform  string `json:"platform" binding:"required,oneof=ios android"`
+	ProductID string `json:"productId"`
+	Receipt   string `json:"receipt" binding:"required"`
+}
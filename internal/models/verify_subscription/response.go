@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type VerifySubscriptionResponse struct {
+	Success          bool       `json:"success"`
+	Message          string     `json:"message"`
+	ProductID        string     `json:"productId"`
+	IsPremium        bool       `json:"isPremium"`
+	PremiumExpiresAt *time.Time `json:"premiumExpiresAt,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// UpdateWebhookRequest changes only the fields that are set
+type UpdateWebhookRequest struct {
+	WebhookID    string    `json:"webhookId"`
+	URL          *string   `json:"url"`
+	Events       *[]string `json:"events"`
+	Active       *bool     `json:"active"`
+	RotateSecret bool      `json:"rotateSecret"`
+}
+
+type DeleteWebhookRequest struct {
+	WebhookID string `json:"webhookId"`
+}
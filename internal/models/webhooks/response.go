@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"` // only returned on create and when rotated
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type WebhookResponse struct {
+	Webhook Webhook `json:"webhook"`
+	Message string  `json:"message"`
+}
+
+type ListWebhooksResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+	Events   []string  `json:"events"` // every event that can be subscribed to
+}
@@ -0,0 +1,109 @@
+// Package moderation checks text users are about to share. A Filter finds the terms it
+// objects to; the Moderator built from the environment applies one in reject or mask
+// mode. The wordlist filter is the only built-in one, but anything implementing Filter,
+// such as a client for a hosted moderation API, can be used instead.
+package moderation
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Mode decides what happens to flagged text
+type Mode string
+
+const (
+	// ModeReject refuses the write and names the flagged terms
+	ModeReject Mode = "reject"
+	// ModeMask stores the text with the flagged terms masked
+	ModeMask Mode = "mask"
+)
+
+// Result is what a Filter found in a piece of text
+type Result struct {
+	// Terms are the distinct flagged terms, lowercased
+	Terms []string
+	// Masked is the text with every flagged term replaced by asterisks
+	Masked string
+}
+
+// Flagged reports whether anything was found
+func (r Result) Flagged() bool {
+	return len(r.Terms) > 0
+}
+
+// Filter finds objectionable terms in text
+type Filter interface {
+	Check(ctx context.Context, text string) (Result, error)
+}
+
+// Moderator is a Filter and the mode it is applied in
+type Moderator struct {
+	Mode   Mode
+	Filter Filter
+}
+
+// NewFromEnv builds the Moderator selected by CONTENT_FILTER_MODE: "off" (the default),
+// "reject" or "mask". It returns nil when filtering is off. CONTENT_FILTER_PROVIDER picks
+// the filter; "wordlist", the default, reads terms from the file at
+// CONTENT_FILTER_WORDLIST_FILE (one per line, # starts a comment) and from the
+// comma-separated CONTENT_FILTER_WORDS.
+func NewFromEnv() (*Moderator, error) {
+	mode := Mode(strings.ToLower(strings.TrimSpace(os.Getenv("CONTENT_FILTER_MODE"))))
+	switch mode {
+	case "", "off":
+		return nil, nil
+	case ModeReject, ModeMask:
+	default:
+		return nil, fmt.Errorf("unknown CONTENT_FILTER_MODE %q (want off, reject or mask)", mode)
+	}
+
+	switch provider := strings.ToLower(strings.TrimSpace(os.Getenv("CONTENT_FILTER_PROVIDER"))); provider {
+	case "", "wordlist":
+		terms := splitTerms(os.Getenv("CONTENT_FILTER_WORDS"))
+		if path := os.Getenv("CONTENT_FILTER_WORDLIST_FILE"); path != "" {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, fmt.Errorf("open CONTENT_FILTER_WORDLIST_FILE: %w", err)
+			}
+			defer f.Close()
+			fileTerms, err := readWordlist(f)
+			if err != nil {
+				return nil, fmt.Errorf("read CONTENT_FILTER_WORDLIST_FILE: %w", err)
+			}
+			terms = append(terms, fileTerms...)
+		}
+		if len(terms) == 0 {
+			return nil, fmt.Errorf("CONTENT_FILTER_MODE is %s but no terms are configured", mode)
+		}
+		return &Moderator{Mode: mode, Filter: NewWordlist(terms)}, nil
+	default:
+		return nil, fmt.Errorf("unknown CONTENT_FILTER_PROVIDER %q (want wordlist)", provider)
+	}
+}
+
+func splitTerms(s string) []string {
+	var terms []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+func readWordlist(r io.Reader) ([]string, error) {
+	var terms []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			terms = append(terms, line)
+		}
+	}
+	return terms, scanner.Err()
+}
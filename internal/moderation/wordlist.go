@@ -0,0 +1,99 @@
+package moderation
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Wordlist flags case-insensitive matches of a fixed list of terms. A term may be a
+// phrase, matching any run of whitespace between its words, and only matches whole words,
+// so "ass" doesn't flag "class".
+type Wordlist struct {
+	pattern *regexp.Regexp
+}
+
+// NewWordlist builds a Wordlist from terms; blank and repeated terms are ignored
+func NewWordlist(terms []string) *Wordlist {
+	seen := make(map[string]bool)
+	var patterns []string
+	for _, term := range terms {
+		words := strings.Fields(strings.ToLower(term))
+		if len(words) == 0 {
+			continue
+		}
+		key := strings.Join(words, " ")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		for i, w := range words {
+			words[i] = regexp.QuoteMeta(w)
+		}
+		patterns = append(patterns, strings.Join(words, `\s+`))
+	}
+	if len(patterns) == 0 {
+		return &Wordlist{}
+	}
+	// Longest first, so a phrase wins over a shorter term it starts with
+	sort.SliceStable(patterns, func(i, j int) bool { return len(patterns[i]) > len(patterns[j]) })
+	return &Wordlist{pattern: regexp.MustCompile(`(?i)(?:` + strings.Join(patterns, "|") + `)`)}
+}
+
+// Check implements Filter
+func (w *Wordlist) Check(_ context.Context, text string) (Result, error) {
+	res := Result{Masked: text}
+	if w.pattern == nil {
+		return res, nil
+	}
+
+	var masked strings.Builder
+	last := 0
+	seen := make(map[string]bool)
+	for _, m := range w.pattern.FindAllStringIndex(text, -1) {
+		if !isWordBoundary(text, m[0], m[1]) {
+			continue
+		}
+		term := strings.ToLower(strings.Join(strings.Fields(text[m[0]:m[1]]), " "))
+		if !seen[term] {
+			seen[term] = true
+			res.Terms = append(res.Terms, term)
+		}
+		masked.WriteString(text[last:m[0]])
+		masked.WriteString(maskWord(text[m[0]:m[1]]))
+		last = m[1]
+	}
+	if len(res.Terms) > 0 {
+		masked.WriteString(text[last:])
+		res.Masked = masked.String()
+	}
+	return res, nil
+}
+
+// isWordBoundary reports whether text[start:end] isn't part of a longer word
+func isWordBoundary(text string, start, end int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(r) {
+		return false
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// maskWord replaces every letter and digit in s with an asterisk, keeping its spacing
+func maskWord(s string) string {
+	return strings.Map(func(r rune) rune {
+		if isWordRune(r) {
+			return '*'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,247 @@
+package premium
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Tier is a user's subscription level
+type Tier string
+
+const (
+	TierFree    Tier = "free"
+	TierPremium Tier = "premium"
+)
+
+// Export formats accepted by ExportData
+const (
+	ExportFormatCSV = "csv"
+	ExportFormatPDF = "pdf"
+)
+
+// Limits are the features and quotas available to a tier. Zero counts mean unlimited.
+type Limits struct {
+	MaxEntries        int      `json:"maxEntries"`
+	MaxImagesPerEntry int      `json:"maxImagesPerEntry"`
+	MaxAudioPerEntry  int      `json:"maxAudioPerEntry"`
+	MaxVideosPerEntry int      `json:"maxVideosPerEntry"`
+	MaxImageBytes     int64    `json:"maxImageBytes"`
+	MaxAudioBytes     int64    `json:"maxAudioBytes"`
+	MaxVideoBytes     int64    `json:"maxVideoBytes"`
+	ExportFormats     []string `json:"exportFormats"`
+	VideoUpload       bool     `json:"videoUpload"`
+
+	MaxAttachmentsPerEntry int   `json:"maxAttachmentsPerEntry"`
+	MaxAttachmentBytes     int64 `json:"maxAttachmentBytes"`
+}
+
+var tierLimits = map[Tier]Limits{
+	TierFree: {
+		MaxEntries:        200,
+		MaxImagesPerEntry: 4,
+		MaxAudioPerEntry:  1,
+		MaxVideosPerEntry: 0,
+		MaxImageBytes:     10 << 20,
+		MaxAudioBytes:     25 << 20,
+		MaxVideoBytes:     0,
+		ExportFormats:     []string{ExportFormatCSV},
+		VideoUpload:       false,
+
+		MaxAttachmentsPerEntry: 2,
+		MaxAttachmentBytes:     10 << 20,
+	},
+	TierPremium: {
+		MaxEntries:        0,
+		MaxImagesPerEntry: 30,
+		MaxAudioPerEntry:  10,
+		MaxVideosPerEntry: 5,
+		MaxImageBytes:     25 << 20,
+		MaxAudioBytes:     100 << 20,
+		MaxVideoBytes:     100 << 20,
+		ExportFormats:     []string{ExportFormatCSV, ExportFormatPDF},
+		VideoUpload:       true,
+
+		MaxAttachmentsPerEntry: 20,
+		MaxAttachmentBytes:     50 << 20,
+	},
+}
+
+// mediaByteCeiling is an operator cap on decoded image, audio, video and attachment size that applies on top
+// of every tier, read from MEDIA_MAX_DECODED_BYTES. Zero leaves the tier limits alone.
+var mediaByteCeiling = mediaByteCeilingFromEnv()
+
+func mediaByteCeilingFromEnv() int64 {
+	if v, err := strconv.ParseInt(os.Getenv("MEDIA_MAX_DECODED_BYTES"), 10, 64); err == nil && v > 0 {
+		return v
+	}
+	return 0
+}
+
+// Reasons reported in a Denial so clients can show the matching upgrade prompt
+const (
+	ReasonEntryLimit          = "ENTRY_LIMIT"
+	ReasonImagesPerEntry      = "IMAGES_PER_ENTRY_LIMIT"
+	ReasonAudioPerEntry       = "AUDIO_PER_ENTRY_LIMIT"
+	ReasonVideosPerEntry      = "VIDEOS_PER_ENTRY_LIMIT"
+	ReasonAttachmentsPerEntry = "ATTACHMENTS_PER_ENTRY_LIMIT"
+	ReasonExportFormat        = "EXPORT_FORMAT"
+	ReasonVideoUploadBlocked  = "VIDEO_UPLOAD"
+)
+
+// Policy is the tier and limits that apply to one user
+type Policy struct {
+	Tier   Tier
+	Limits Limits
+}
+
+// ForTier returns the policy for a tier; unknown tiers get the free policy. Media size
+// limits are clamped to MEDIA_MAX_DECODED_BYTES when it is set.
+func ForTier(tier Tier) Policy {
+	limits, ok := tierLimits[tier]
+	if !ok {
+		tier, limits = TierFree, tierLimits[TierFree]
+	}
+	if mediaByteCeiling > 0 {
+		limits.MaxImageBytes = min(limits.MaxImageBytes, mediaByteCeiling)
+		limits.MaxAudioBytes = min(limits.MaxAudioBytes, mediaByteCeiling)
+		limits.MaxVideoBytes = min(limits.MaxVideoBytes, mediaByteCeiling)
+		limits.MaxAttachmentBytes = min(limits.MaxAttachmentBytes, mediaByteCeiling)
+	}
+	return Policy{Tier: tier, Limits: limits}
+}
+
+// ForUser reads the user's tier from the users table. Users without an active,
+// unexpired subscription are on the free tier.
+func ForUser(ctx context.Context, postgres *pgxpool.Pool, uid string) (Policy, error) {
+	var active bool
+	err := postgres.QueryRow(ctx, `
+		SELECT COALESCE(is_premium AND premium_expires_at > NOW(), FALSE)
+		FROM users WHERE uid = $1
+	`, uid).Scan(&active)
+	if err != nil {
+		return Policy{}, err
+	}
+	if active {
+		return ForTier(TierPremium), nil
+	}
+	return ForTier(TierFree), nil
+}
+
+// Denial explains why the policy refused an action
+type Denial struct {
+	Reason           string `json:"reason"`
+	Tier             Tier   `json:"tier"`
+	Limit            int    `json:"limit,omitempty"`
+	Usage            int    `json:"usage,omitempty"`
+	UpgradeAvailable bool   `json:"upgradeAvailable"`
+}
+
+func (d *Denial) Error() string {
+	switch d.Reason {
+	case ReasonEntryLimit:
+		return fmt.Sprintf("The %s plan allows %d entries", d.Tier, d.Limit)
+	case ReasonImagesPerEntry:
+		return fmt.Sprintf("The %s plan allows %d images per entry", d.Tier, d.Limit)
+	case ReasonAudioPerEntry:
+		return fmt.Sprintf("The %s plan allows %d audio recordings per entry", d.Tier, d.Limit)
+	case ReasonVideosPerEntry:
+		return fmt.Sprintf("The %s plan allows %d videos per entry", d.Tier, d.Limit)
+	case ReasonAttachmentsPerEntry:
+		return fmt.Sprintf("The %s plan allows %d attachments per entry", d.Tier, d.Limit)
+	case ReasonExportFormat:
+		return fmt.Sprintf("This export format is not available on the %s plan", d.Tier)
+	case ReasonVideoUploadBlocked:
+		return fmt.Sprintf("Video upload is not available on the %s plan", d.Tier)
+	default:
+		return "Not available on your plan"
+	}
+}
+
+// Status is 402 when upgrading would lift the limit and 403 when it wouldn't
+func (d *Denial) Status() int {
+	if d.UpgradeAvailable {
+		return http.StatusPaymentRequired
+	}
+	return http.StatusForbidden
+}
+
+// CheckEntries reports whether a user who already has count entries may create another
+func (p Policy) CheckEntries(count int) *Denial {
+	return p.checkCount(ReasonEntryLimit, p.Limits.MaxEntries, count, func(l Limits) int { return l.MaxEntries })
+}
+
+// CheckImages reports whether an entry that already has count images may get another
+func (p Policy) CheckImages(count int) *Denial {
+	return p.checkCount(ReasonImagesPerEntry, p.Limits.MaxImagesPerEntry, count, func(l Limits) int { return l.MaxImagesPerEntry })
+}
+
+// CheckAudio reports whether an entry that already has count recordings may get another
+func (p Policy) CheckAudio(count int) *Denial {
+	return p.checkCount(ReasonAudioPerEntry, p.Limits.MaxAudioPerEntry, count, func(l Limits) int { return l.MaxAudioPerEntry })
+}
+
+// CheckVideos reports whether an entry that already has count videos may get another.
+// Tiers without video upload are refused before the count matters.
+func (p Policy) CheckVideos(count int) *Denial {
+	if denial := p.CheckVideoUpload(); denial != nil {
+		return denial
+	}
+	return p.checkCount(ReasonVideosPerEntry, p.Limits.MaxVideosPerEntry, count, func(l Limits) int { return l.MaxVideosPerEntry })
+}
+
+// CheckAttachments reports whether an entry that already has count attachments may get another
+func (p Policy) CheckAttachments(count int) *Denial {
+	return p.checkCount(ReasonAttachmentsPerEntry, p.Limits.MaxAttachmentsPerEntry, count, func(l Limits) int { return l.MaxAttachmentsPerEntry })
+}
+
+// CheckExportFormat reports whether the tier may export in format
+func (p Policy) CheckExportFormat(format string) *Denial {
+	if containsFormat(p.Limits.ExportFormats, format) {
+		return nil
+	}
+	return &Denial{
+		Reason:           ReasonExportFormat,
+		Tier:             p.Tier,
+		UpgradeAvailable: containsFormat(tierLimits[TierPremium].ExportFormats, format),
+	}
+}
+
+// CheckVideoUpload reports whether the tier may upload video
+func (p Policy) CheckVideoUpload() *Denial {
+	if p.Limits.VideoUpload {
+		return nil
+	}
+	return &Denial{
+		Reason:           ReasonVideoUploadBlocked,
+		Tier:             p.Tier,
+		UpgradeAvailable: tierLimits[TierPremium].VideoUpload,
+	}
+}
+
+func (p Policy) checkCount(reason string, limit, count int, premiumLimit func(Limits) int) *Denial {
+	if limit == 0 || count < limit {
+		return nil
+	}
+	upgrade := premiumLimit(tierLimits[TierPremium])
+	return &Denial{
+		Reason:           reason,
+		Tier:             p.Tier,
+		Limit:            limit,
+		Usage:            count,
+		UpgradeAvailable: p.Tier != TierPremium && (upgrade == 0 || upgrade > limit),
+	}
+}
+
+func containsFormat(formats []string, format string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package premium
+
+import "testing"
+
+func TestForTierClampsMediaToCeiling(t *testing.T) {
+	defer func(v int64) { mediaByteCeiling = v }(mediaByteCeiling)
+
+	mediaByteCeiling = 0
+	if got := ForTier(TierPremium).Limits; got.MaxImageBytes != 25<<20 || got.MaxAudioBytes != 100<<20 {
+		t.Fatalf("without a ceiling: image %d, audio %d; want the premium limits", got.MaxImageBytes, got.MaxAudioBytes)
+	}
+
+	mediaByteCeiling = 12 << 20
+	for tier, want := range map[Tier][2]int64{
+		TierFree:    {10 << 20, 12 << 20},
+		TierPremium: {12 << 20, 12 << 20},
+	} {
+		got := ForTier(tier).Limits
+		if got.MaxImageBytes != want[0] || got.MaxAudioBytes != want[1] {
+			t.Errorf("%s: image %d, audio %d; want %d, %d", tier, got.MaxImageBytes, got.MaxAudioBytes, want[0], want[1])
+		}
+	}
+	// The ceiling must not leak into the shared tier table
+	if tierLimits[TierPremium].MaxAudioBytes != 100<<20 {
+		t.Error("ForTier modified tierLimits")
+	}
+}
+
+func TestMediaByteCeilingFromEnv(t *testing.T) {
+	for value, want := range map[string]int64{
+		"":          0,
+		"1048576":   1 << 20,
+		"0":         0,
+		"-1":        0,
+		"unlimited": 0,
+	} {
+		t.Setenv("MEDIA_MAX_DECODED_BYTES", value)
+		if got := mediaByteCeilingFromEnv(); got != want {
+			t.Errorf("MEDIA_MAX_DECODED_BYTES=%q: got %d, want %d", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,472 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config configures the S3 backend. It talks to the S3 REST API directly, signing
+// requests with AWS Signature Version 4, so any S3-compatible server works.
+type S3Config struct {
+	// Endpoint is the server's base URL, e.g. http://minio:9000. Empty means AWS in Region.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses objects as <endpoint>/<bucket>/<key> instead of
+	// <bucket>.<endpoint host>/<key>
+	PathStyle bool
+}
+
+type s3Store struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3 returns a store backed by an S3 bucket
+func NewS3(cfg S3Config) (Store, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("S3_BUCKET is required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("S3 access key ID and secret access key are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	// No client-wide timeout: every request is bounded by its caller's context instead.
+	// Media routes run without a request deadline, so a streamed GET ends when the
+	// body is done or the client disconnects
+	return &s3Store{cfg: cfg, endpoint: endpoint, client: &http.Client{}}, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, nil, header, r, size)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Open keeps ctx for the ranged GETs issued by Read and Seek, so ctx must outlive the
+// read: callers streaming to a client pass a request context without a deadline
+func (s *s3Store) Open(ctx context.Context, key string) (Object, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Object{ctx: ctx, store: s, info: info}, nil
+}
+
+func (s *s3Store) Stat(ctx context.Context, key string) (Info, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil, nil, 0)
+	if err != nil {
+		return Info{}, err
+	}
+	resp.Body.Close()
+	info := Info{Key: key, Size: resp.ContentLength}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = t
+	}
+	return info, nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, nil, 0)
+	if errors.Is(err, ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *s3Store) DeleteAll(ctx context.Context, dir string) error {
+	var keys []string
+	if err := s.Walk(ctx, dir, func(info Info) error {
+		keys = append(keys, info.Key)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := s.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Copy is a server-side copy, so the data never passes through this server
+func (s *s3Store) Copy(ctx context.Context, src, dst string) error {
+	header := http.Header{}
+	header.Set("X-Amz-Copy-Source", "/"+s.cfg.Bucket+"/"+escapePath(src))
+	resp, err := s.do(ctx, http.MethodPut, dst, nil, header, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// A copy can fail after the 200 status has been sent; the body then holds an <Error>
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(body), "<Error>") {
+		return s3ErrorFromBody(http.MethodPut, dst, resp.StatusCode, body)
+	}
+	return nil
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// Walk pages through ListObjectsV2
+func (s *s3Store) Walk(ctx context.Context, dir string, fn func(Info) error) error {
+	query := url.Values{}
+	query.Set("list-type", "2")
+	query.Set("prefix", strings.TrimSuffix(dir, "/")+"/")
+	for {
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil, nil, 0)
+		if err != nil {
+			return err
+		}
+		var page listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("s3: decode object list: %w", err)
+		}
+		for _, obj := range page.Contents {
+			if err := fn(Info{Key: obj.Key, Size: obj.Size, ModTime: obj.LastModified}); err != nil {
+				return err
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		query.Set("continuation-token", page.NextContinuationToken)
+	}
+}
+
+func (s *s3Store) Backend() string {
+	return "s3"
+}
+
+// do sends a signed request for key (the bucket itself when key is empty) and returns the
+// response if its status is 2xx. A 404 is reported as ErrNotExist.
+func (s *s3Store) do(ctx context.Context, method, key string, query url.Values, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	u := s.objectURL(key)
+	escapedPath := u.RawPath
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	s.sign(req, escapedPath, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotExist
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	return nil, s3ErrorFromBody(method, key, resp.StatusCode, data)
+}
+
+// objectURL returns the URL of key, or of the bucket when key is empty. RawPath holds the
+// path encoded the way signatures expect.
+func (s *s3Store) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	escapedPath := s.endpoint.EscapedPath()
+	if s.cfg.PathStyle {
+		escapedPath += "/" + escapePath(s.cfg.Bucket)
+		if key != "" {
+			escapedPath += "/" + escapePath(key)
+		}
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+		escapedPath += "/" + escapePath(key)
+	}
+	u.Path, _ = url.PathUnescape(escapedPath)
+	u.RawPath = escapedPath
+	return &u
+}
+
+// PresignPut returns a URL that accepts PUTs of exactly size bytes with the given
+// Content-Type until expires has passed. Both headers are signed, so a client can't
+// upload more than it declared or change the type.
+func (s *s3Store) PresignPut(key, contentType string, size int64, expires time.Duration) (string, error) {
+	if expires < time.Second || expires > 7*24*time.Hour {
+		return "", fmt.Errorf("s3: presign expiry %s out of range", expires)
+	}
+	u := s.objectURL(key)
+	amzDate := time.Now().UTC().Format("20060102T150405Z")
+	scope := s.scope(amzDate[:8])
+	const signedHeaders = "content-length;content-type;host"
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.cfg.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", signedHeaders)
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodPut,
+		u.RawPath,
+		canonicalQuery(query),
+		"content-length:" + strconv.FormatInt(size, 10) + "\n" +
+			"content-type:" + strings.TrimSpace(contentType) + "\n" +
+			"host:" + u.Host + "\n",
+		signedHeaders,
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(amzDate, scope, canonicalRequest))
+	u.RawQuery = canonicalQuery(query)
+	return u.String(), nil
+}
+
+// sign adds a Signature Version 4 Authorization header. The payload is sent unsigned,
+// which S3 and MinIO accept, so bodies can be streamed without hashing them first.
+func (s *s3Store) sign(req *http.Request, escapedPath string, now time.Time) {
+	const payloadHash = "UNSIGNED-PAYLOAD"
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapedPath,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := s.scope(date)
+	signature := s.signature(amzDate, scope, canonicalRequest)
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// scope is the credential scope for requests signed on date (YYYYMMDD)
+func (s *s3Store) scope(date string) string {
+	return date + "/" + s.cfg.Region + "/s3/aws4_request"
+}
+
+// signature signs a canonical request with the key derived for scope's date and region
+func (s *s3Store) signature(amzDate, scope, canonicalRequest string) string {
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+	signingKey := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), amzDate[:8])
+	signingKey = hmacSHA256(signingKey, s.cfg.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// escapePath URI-encodes each segment of a key the way SigV4 expects, keeping the slashes
+func escapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery encodes query parameters sorted by name, as both the request and its
+// signature need them
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except the unreserved characters A-Z a-z 0-9 - _ . ~
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3Error is the <Error> document S3 returns with failed requests
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func s3ErrorFromBody(method, key string, status int, body []byte) error {
+	var e s3Error
+	if err := xml.Unmarshal(body, &e); err != nil || e.Code == "" {
+		return fmt.Errorf("s3: %s %q: status %d", method, key, status)
+	}
+	if e.Code == "NoSuchKey" {
+		return ErrNotExist
+	}
+	return fmt.Errorf("s3: %s %q: %s: %s (status %d)", method, key, e.Code, e.Message, status)
+}
+
+// s3Object reads an object lazily with ranged GETs. Seeking just moves the offset; the
+// next Read starts a new request from there, which is how ServeContent serves Range
+// requests without downloading the whole object.
+type s3Object struct {
+	ctx    context.Context
+	store  *s3Store
+	info   Info
+	offset int64
+	body   io.ReadCloser
+}
+
+func (o *s3Object) Info() Info {
+	return o.info
+}
+
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.offset >= o.info.Size {
+		return 0, io.EOF
+	}
+	if o.body == nil {
+		header := http.Header{}
+		header.Set("Range", "bytes="+strconv.FormatInt(o.offset, 10)+"-")
+		resp, err := o.store.do(o.ctx, http.MethodGet, o.info.Key, nil, header, nil, 0)
+		if err != nil {
+			return 0, err
+		}
+		o.body = resp.Body
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	if err == io.EOF && o.offset < o.info.Size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = o.offset + offset
+	case io.SeekEnd:
+		abs = o.info.Size + offset
+	default:
+		return 0, errors.New("s3: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("s3: negative position")
+	}
+	if abs != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = abs
+	return abs, nil
+}
+
+func (o *s3Object) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
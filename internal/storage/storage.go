@@ -0,0 +1,105 @@
+// Package storage abstracts where uploaded media lives. The local backend keeps files
+// under internal/ on the server's disk, which ties media to one instance; the S3 backend
+// stores them in an S3-compatible bucket (AWS S3, MinIO, R2, ...) so several instances
+// can serve the same files.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLocalRoot is the directory the local backend stores media under
+const DefaultLocalRoot = "internal"
+
+// ErrNotExist is returned by Open and Stat when no object has the key
+var ErrNotExist = errors.New("storage: object not found")
+
+// Info describes a stored object
+type Info struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Object is an open stored object. Seeking lets it be served with http.ServeContent,
+// which handles Range requests for audio seeking.
+type Object interface {
+	io.ReadSeekCloser
+	Info() Info
+}
+
+// Store holds media objects under slash-separated keys such as
+// images/<uid>/<entryID>/<file>, mirroring their URL paths.
+type Store interface {
+	// Put writes size bytes from r to key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open opens key for reading; it returns ErrNotExist if there is no such object
+	Open(ctx context.Context, key string) (Object, error)
+	// Stat returns the object's info, or ErrNotExist
+	Stat(ctx context.Context, key string) (Info, error)
+	// Delete removes key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// DeleteAll removes every object under dir/
+	DeleteAll(ctx context.Context, dir string) error
+	// Copy copies src to dst within the store
+	Copy(ctx context.Context, src, dst string) error
+	// Walk calls fn for every object under dir/. An error from fn stops the walk and is returned.
+	Walk(ctx context.Context, dir string, fn func(Info) error) error
+	// Backend names the implementation ("local" or "s3")
+	Backend() string
+}
+
+// Presigner is implemented by stores that can let clients upload straight to them,
+// bypassing this server. The local backend can't.
+type Presigner interface {
+	// PresignPut returns a URL that accepts a PUT of exactly size bytes of contentType to
+	// key until expires has passed
+	PresignPut(key, contentType string, size int64, expires time.Duration) (string, error)
+}
+
+// NewFromEnv builds the store selected by MEDIA_STORAGE: "local" (the default) or "s3".
+// The S3 backend reads S3_BUCKET, S3_REGION (default us-east-1), S3_ENDPOINT (default:
+// AWS for the region), S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY (falling back to the
+// AWS_* variables) and S3_FORCE_PATH_STYLE, which defaults to true when S3_ENDPOINT is
+// set since MinIO and most self-hosted servers need it.
+func NewFromEnv() (Store, error) {
+	switch driver := strings.ToLower(strings.TrimSpace(os.Getenv("MEDIA_STORAGE"))); driver {
+	case "", "local":
+		return NewLocal(DefaultLocalRoot), nil
+	case "s3":
+		cfg := S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     firstEnv("S3_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: firstEnv("S3_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY"),
+			PathStyle:       os.Getenv("S3_ENDPOINT") != "",
+		}
+		if v := os.Getenv("S3_FORCE_PATH_STYLE"); v != "" {
+			pathStyle, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid S3_FORCE_PATH_STYLE %q", v)
+			}
+			cfg.PathStyle = pathStyle
+		}
+		return NewS3(cfg)
+	default:
+		return nil, fmt.Errorf("unknown MEDIA_STORAGE %q (want local or s3)", driver)
+	}
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package subscriptions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Supported store platforms
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+)
+
+// ErrInvalidReceipt is returned when the store doesn't recognise the receipt or it
+// belongs to another app
+var ErrInvalidReceipt = errors.New("invalid subscription receipt")
+
+// ErrNotConfigured is returned when no store credentials are set for the platform
+var ErrNotConfigured = errors.New("subscription verification is not configured for this platform")
+
+// Purchase is the store's view of a subscription
+type Purchase struct {
+	ProductID string
+	// SubscriptionID stays the same across renewals: the original transaction ID on
+	// iOS, the purchase token on Android
+	SubscriptionID string
+	ExpiresAt      time.Time
+	Active         bool
+}
+
+// Verifier checks a receipt with the store that issued it. For iOS the receipt is a
+// StoreKit 2 transaction ID; for Android it is the Play purchase token.
+type Verifier interface {
+	Verify(ctx context.Context, platform, productID, receipt string) (*Purchase, error)
+}
+
+// NewFromEnv builds a Verifier from the App Store (APPSTORE_*) and Google Play
+// (GOOGLE_PLAY_*) settings. Either store may be left unconfigured, in which case
+// receipts for that platform fail with ErrNotConfigured.
+func NewFromEnv(ctx context.Context) (Verifier, error) {
+	appStore, err := newAppStoreFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("app store: %w", err)
+	}
+	play, err := newPlayFromEnv(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("google play: %w", err)
+	}
+	return &storeVerifier{appStore: appStore, play: play}, nil
+}
+
+type storeVerifier struct {
+	appStore *appStoreVerifier
+	play     *playVerifier
+}
+
+func (v *storeVerifier) Verify(ctx context.Context, platform, productID, receipt string) (*Purchase, error) {
+	switch platform {
+	case PlatformIOS:
+		if v.appStore == nil {
+			return nil, ErrNotConfigured
+		}
+		return v.appStore.verify(ctx, productID, receipt)
+	case PlatformAndroid:
+		if v.play == nil {
+			return nil, ErrNotConfigured
+		}
+		return v.play.verify(ctx, productID, receipt)
+	default:
+		return nil, fmt.Errorf("unsupported platform %q", platform)
+	}
+}
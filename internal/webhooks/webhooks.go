@@ -0,0 +1,312 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"io.winapps.journeyapp/internal/cache"
+)
+
+// Events a webhook can subscribe to
+const (
+	EventEntryCreated  = "entry.created"
+	EventEntryShared   = "entry.shared"
+	EventFriendRequest = "friend.request"
+)
+
+// Events lists every supported event name
+var Events = []string{EventEntryCreated, EventEntryShared, EventFriendRequest}
+
+// Headers sent with every delivery. The signature is hex HMAC-SHA256, keyed with the
+// webhook secret, over "<timestamp>.<body>".
+const (
+	HeaderEvent     = "X-Journey-Event"
+	HeaderDelivery  = "X-Journey-Delivery"
+	HeaderTimestamp = "X-Journey-Timestamp"
+	HeaderSignature = "X-Journey-Signature"
+)
+
+const (
+	// MaxAttempts caps deliveries per event, including the first
+	MaxAttempts      = 5
+	baseRetryDelay   = 2 * time.Second
+	deliveryTimeout  = 10 * time.Second
+	deadLetterTTL    = 7 * 24 * time.Hour
+	maxResponseBytes = 4 << 10
+)
+
+// ErrInvalidURL is returned by ValidateURL for anything but an absolute https URL
+var ErrInvalidURL = errors.New("webhook URL must be an absolute https URL")
+
+// IsValidEvent reports whether name is one of Events
+func IsValidEvent(name string) bool {
+	for _, e := range Events {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateURL accepts only https URLs with a host. Deliveries additionally refuse to
+// connect to loopback, private and link-local addresses whatever the host resolves to.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return ErrInvalidURL
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !isPublicIP(ip) {
+		return ErrInvalidURL
+	}
+	if u.Hostname() == "localhost" {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// Sign returns the signature header value for body sent at timestamp (Unix seconds)
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Payload is the JSON body POSTed to a webhook
+type Payload struct {
+	ID        string      `json:"id"` // delivery id, stable across retries
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// Dispatcher delivers events to the webhooks users have registered. Each delivery runs
+// in its own goroutine and is retried with exponential backoff; deliveries that still
+// fail are recorded in the cache under webhook_dead_letter:<webhookId>:<deliveryId>.
+type Dispatcher struct {
+	postgres *pgxpool.Pool
+	cache    cache.Store
+	logger   *zap.SugaredLogger
+	client   *http.Client
+
+	wg      sync.WaitGroup
+	closing chan struct{}
+	once    sync.Once
+}
+
+// NewDispatcher creates a dispatcher whose HTTP client won't connect to non-public addresses
+func NewDispatcher(postgres *pgxpool.Pool, store cache.Store, logger *zap.SugaredLogger) *Dispatcher {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("webhook delivery to %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+
+	return &Dispatcher{
+		postgres: postgres,
+		cache:    store,
+		logger:   logger,
+		client: &http.Client{
+			Timeout:   deliveryTimeout,
+			Transport: transport,
+			// A redirect could point anywhere; treat it as a failed delivery
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		closing: make(chan struct{}),
+	}
+}
+
+type target struct {
+	id     string
+	url    string
+	secret string
+}
+
+// Emit delivers event to every active webhook of userUID subscribed to it. It returns
+// immediately; lookups and deliveries happen in the background. A nil Dispatcher is a no-op.
+func (d *Dispatcher) Emit(userUID, event string, data interface{}) {
+	if d == nil {
+		return
+	}
+	select {
+	case <-d.closing:
+		return
+	default:
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		targets, err := d.targets(ctx, userUID, event)
+		cancel()
+		if err != nil {
+			d.logger.Warnw("Failed to load webhooks", "uid", userUID, "event", event, "error", err)
+			return
+		}
+
+		payload := Payload{ID: uuid.New().String(), Event: event, CreatedAt: time.Now().UTC(), Data: data}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			d.logger.Errorw("Failed to encode webhook payload", "event", event, "error", err)
+			return
+		}
+
+		for _, t := range targets {
+			d.wg.Add(1)
+			go func(t target) {
+				defer d.wg.Done()
+				d.deliver(t, payload, body)
+			}(t)
+		}
+	}()
+}
+
+// Shutdown stops retries that are waiting for their next attempt and waits for
+// in-flight deliveries to finish
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	d.once.Do(func() { close(d.closing) })
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhook deliveries still running: %w", ctx.Err())
+	}
+}
+
+func (d *Dispatcher) targets(ctx context.Context, userUID, event string) ([]target, error) {
+	rows, err := d.postgres.Query(ctx, `
+		SELECT id, url, secret FROM webhooks
+		WHERE user_uid = $1 AND active = TRUE AND $2 = ANY(events)
+	`, userUID, event)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []target
+	for rows.Next() {
+		var t target
+		if err := rows.Scan(&t.id, &t.url, &t.secret); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	return targets, rows.Err()
+}
+
+// deliver POSTs body to t until it gets a 2xx response or MaxAttempts is reached,
+// waiting 2s, 4s, 8s, ... between attempts
+func (d *Dispatcher) deliver(t target, payload Payload, body []byte) {
+	var lastErr error
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		if attempt > 1 {
+			delay := baseRetryDelay << (attempt - 2)
+			select {
+			case <-time.After(delay):
+			case <-d.closing:
+				lastErr = fmt.Errorf("server shutting down after %d attempts: %w", attempt-1, lastErr)
+				d.deadLetter(t, payload, attempt-1, lastErr)
+				return
+			}
+		}
+
+		lastErr = d.post(t, payload, body)
+		if lastErr == nil {
+			return
+		}
+		d.logger.Debugw("Webhook delivery failed", "webhookId", t.id, "delivery", payload.ID, "attempt", attempt, "error", lastErr)
+	}
+	d.deadLetter(t, payload, MaxAttempts, lastErr)
+}
+
+func (d *Dispatcher) post(t target, payload Payload, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "JourneyApp-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, payload.Event)
+	req.Header.Set(HeaderDelivery, payload.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(t.secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// deadLetter records a delivery that exhausted its attempts so it can be inspected
+func (d *Dispatcher) deadLetter(t target, payload Payload, attempts int, lastErr error) {
+	d.logger.Warnw("Webhook delivery abandoned", "webhookId", t.id, "delivery", payload.ID, "event", payload.Event, "attempts", attempts, "error", lastErr)
+
+	record, err := json.Marshal(map[string]interface{}{
+		"webhookId": t.id,
+		"url":       t.url,
+		"attempts":  attempts,
+		"lastError": fmt.Sprint(lastErr),
+		"failedAt":  time.Now().UTC(),
+		"payload":   payload,
+	})
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = d.cache.Set(ctx, fmt.Sprintf("webhook_dead_letter:%s:%s", t.id, payload.ID), record, deadLetterTTL)
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast() || ip.IsInterfaceLocalMulticast())
+}